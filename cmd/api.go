@@ -8,7 +8,8 @@ type ConfigExtraArgs struct {
 type PackageSrcConfig struct {
 	Type    string            `yaml:"type"`    // tar.gz...
 	DstPath string            `yaml:"dstpath"` // untar path on dst node
-	SrcPath map[string]string `yaml:"srcpath"` // key: arm/amd/risc-v
+	SrcPath map[string]string `yaml:"srcpath"` // key: arm/amd/risc-v, value: local path or http(s) url
+	Sha256  map[string]string `yaml:"sha256"`  // key: arm/amd/risc-v, value: sha256 of package
 }
 
 type PackageConfig struct {
@@ -36,21 +37,105 @@ type HostConfig struct {
 	Name string `yaml:"name"`
 	Ip   string `yaml:"ip"`
 	Port int    `yaml:"port"`
-	Arch string `yaml:"arch"` // amd64, aarch64, default amd64
+	Arch string `yaml:"arch"` // amd64(x86_64), arm64(aarch64), default amd64
+	// labels and taints of kubernetes node, taint format: key[=value]:effect
+	Labels map[string]string `yaml:"labels,omitempty"`
+	Taints []string          `yaml:"taints,omitempty"`
+	// override sudo password of cluster for this host
+	SudoPassword string `yaml:"sudo-password,omitempty"`
+	// apt, yum or dnf, detected if empty
+	PackageManager string `yaml:"package-manager,omitempty"`
+	// host which eggo runs on, commands run locally instead of by ssh
+	Local bool `yaml:"local,omitempty"`
 }
 
 type LoadBalance struct {
 	Name     string `yaml:"name"`
 	Ip       string `yaml:"ip"`
 	Port     int    `yaml:"port"`
-	Arch     string `yaml:"arch"` // amd64, aarch64, default amd64
+	Arch     string `yaml:"arch"` // amd64(x86_64), arm64(aarch64), default amd64
 	BindPort int    `yaml:"bind-port"`
+
+	// keepalived is deployed with nginx to provide virtual ip, if vip is set
+	VIP       string `yaml:"vip"`
+	Interface string `yaml:"interface"`
+	VRID      int    `yaml:"vrid"`
 }
 
 type DnsConfig struct {
-	CorednsType  string `yaml:"corednstype"`
-	ImageVersion string `yaml:"imageversion"`
-	Replicas     int    `yaml:"replicas"`
+	CorednsType   string `yaml:"corednstype"`
+	ImageVersion  string `yaml:"imageversion"`
+	Replicas      int    `yaml:"replicas"`
+	Domain        string `yaml:"domain"` // default is dns-domain
+	CPURequest    string `yaml:"cpu-request"`
+	CPULimit      string `yaml:"cpu-limit"`
+	MemoryRequest string `yaml:"memory-request"`
+	MemoryLimit   string `yaml:"memory-limit"`
+	// upstream servers with format ip[:port], default is /etc/resolv.conf
+	UpstreamServers []string `yaml:"upstream-servers"`
+}
+
+type AddonConfig struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`     // file, url or inline
+	Filename string `yaml:"filename"` // local manifest file for file type
+	URL      string `yaml:"url"`
+	Sha256   string `yaml:"sha256"` // optional sha256 of manifest downloaded from url
+	Content  string `yaml:"content"`
+}
+
+type HostAlias struct {
+	IP        string   `yaml:"ip"`
+	Hostnames []string `yaml:"hostnames"`
+}
+
+type PackageRepo struct {
+	Name    string `yaml:"name"`
+	BaseURL string `yaml:"baseurl"`
+	GPGKey  string `yaml:"gpgkey"`
+}
+
+type ExtraFile struct {
+	Src   string   `yaml:"src"`
+	Dst   string   `yaml:"dst"`
+	Mode  int      `yaml:"mode"`  // default 0644
+	Roles []string `yaml:"roles"` // master, worker, etcd or loadbalance, all nodes if empty
+}
+
+// encryption at rest of resources in etcd
+type EncryptionConfig struct {
+	Provider  string   `yaml:"provider"`  // aescbc(default) or secretbox
+	Resources []string `yaml:"resources"` // default secrets
+}
+
+// audit logs of apiserver, default policy is used if neither policy nor policy-file is set
+type AuditConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	PolicyFile string `yaml:"policy-file"` // absolute path of policy on host running eggo
+	Policy     string `yaml:"policy"`      // inline policy
+	LogPath    string `yaml:"log-path"`    // default /var/log/kubernetes/audit/audit.log
+	MaxAge     int    `yaml:"max-age"`     // default 30 days
+	MaxBackup  int    `yaml:"max-backup"`  // default 10
+	MaxSize    int    `yaml:"max-size"`    // default 100 MB
+}
+
+type RegistryAuth struct {
+	Registry string `yaml:"registry"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type HookConfig struct {
+	Path          string `yaml:"path"`           // local script file, or dir of scripts
+	Role          string `yaml:"role,omitempty"` // master, worker, etcd or loadbalance, default all nodes
+	IgnoreFailure bool   `yaml:"ignore-failure,omitempty"`
+}
+
+type HooksConfig struct {
+	PreDeploy   []*HookConfig `yaml:"pre-deploy,omitempty"`
+	PostDeploy  []*HookConfig `yaml:"post-deploy,omitempty"`
+	PreCleanup  []*HookConfig `yaml:"pre-cleanup,omitempty"`
+	PostCleanup []*HookConfig `yaml:"post-cleanup,omitempty"`
 }
 
 type ServiceClusterConfig struct {
@@ -58,6 +143,8 @@ type ServiceClusterConfig struct {
 	DNSAddr string    `json:"dnsaddress"`
 	Gateway string    `json:"gateway"`
 	DNS     DnsConfig `json:"dns"`
+	// range of node ports, default is 30000-32767
+	NodePortRange string `yaml:"node-port-range"`
 }
 
 type NetworkConfig struct {
@@ -76,35 +163,80 @@ type OpenPorts struct {
 	Protocol string `yaml:"protocol"` // tcp/udp
 }
 
+// thresholds of preflight checks, 0 means use default
+type PreflightConfig struct {
+	MasterMinCPUs     int `yaml:"master-min-cpus"`
+	MasterMinMemoryMB int `yaml:"master-min-memory-mb"`
+	WorkerMinCPUs     int `yaml:"worker-min-cpus"`
+	WorkerMinMemoryMB int `yaml:"worker-min-memory-mb"`
+	MinConfigDiskMB   int `yaml:"min-config-disk-mb"` // free disk of config dir on masters and workers
+	MinEtcdDiskMB     int `yaml:"min-etcd-disk-mb"`   // free disk of etcd data dir on etcds
+}
+
 type DeployConfig struct {
 	ClusterID            string                  `yaml:"cluster-id"`
+	KubernetesVersion    string                  `yaml:"kubernetes-version,omitempty"` // such as v1.20.2, must match packages if set
 	Username             string                  `yaml:"username"`
 	Password             string                  `yaml:"password"`
 	PrivateKeyPath       string                  `yaml:"private-key-path"`
+	UseSSHAgent          bool                    `yaml:"use-ssh-agent"`
+	SudoPassword         string                  `yaml:"sudo-password,omitempty"`
+	Elevate              string                  `yaml:"elevate,omitempty"`
+	HostKeyChecking      string                  `yaml:"host-key-checking"`
+	KnownHostsPath       string                  `yaml:"known-hosts-path"`
+	ConfigDir            string                  `yaml:"config-dir,omitempty"` // default /etc/kubernetes
+	CertDir              string                  `yaml:"cert-dir,omitempty"`   // default /etc/kubernetes/pki
+	SSHKeepAliveInterval string                  `yaml:"ssh-keepalive-interval,omitempty"`
+	SSHConnectTimeout    string                  `yaml:"ssh-connect-timeout,omitempty"`
 	Masters              []*HostConfig           `yaml:"masters"`
 	Workers              []*HostConfig           `yaml:"workers"`
 	Etcds                []*HostConfig           `yaml:"etcds"`
 	LoadBalance          LoadBalance             `yaml:"loadbalance"`
 	ExternalCA           bool                    `yaml:"external-ca"`
 	ExternalCAPath       string                  `yaml:"external-ca-path"`
+	ExternalK8sCAPath    string                  `yaml:"external-kubernetes-ca-path"`
+	ExternalEtcdCAPath   string                  `yaml:"external-etcd-ca-path"`
+	ExternalProxyCAPath  string                  `yaml:"external-front-proxy-ca-path"`
 	Service              ServiceClusterConfig    `yaml:"service"`
 	NetWork              NetworkConfig           `yaml:"network"`
 	ApiServerEndpoint    string                  `yaml:"apiserver-endpoint"`
 	ApiServerCertSans    Sans                    `yaml:"apiserver-cert-sans"`
 	ApiServerTimeout     string                  `yaml:"apiserver-timeout"`
+	ScheduleOnMaster     bool                    `yaml:"schedule-workloads-on-master"` // masters are tainted NoSchedule if false
 	EtcdExternal         bool                    `yaml:"etcd-external"`
+	EtcdEndpoints        []string                `yaml:"etcd-endpoints"`
+	EtcdCAFile           string                  `yaml:"etcd-ca-file"`
+	EtcdCertFile         string                  `yaml:"etcd-cert-file"`
+	EtcdKeyFile          string                  `yaml:"etcd-key-file"`
 	EtcdToken            string                  `yaml:"etcd-token"`
+	EtcdClientPort       int                     `yaml:"etcd-client-port"`
+	EtcdPeerPort         int                     `yaml:"etcd-peer-port"`
+	EtcdMetricsPort      int                     `yaml:"etcd-metrics-port"`
 	DnsVip               string                  `yaml:"dns-vip"`
 	DnsDomain            string                  `yaml:"dns-domain"`
 	PauseImage           string                  `yaml:"pause-image"`
+	ImageRepository      string                  `yaml:"image-repository"`
 	NetworkPlugin        string                  `yaml:"network-plugin"`
 	EnableKubeletServing bool                    `yaml:"enable-kubelet-serving"`
+	CgroupDriver         string                  `yaml:"cgroup-driver,omitempty"` // systemd or cgroupfs, shared by kubelet and runtime
 	CniBinDir            string                  `yaml:"cni-bin-dir"`
 	Runtime              string                  `yaml:"runtime"`
 	RuntimeEndpoint      string                  `yaml:"runtime-endpoint"`
+	ImagePackage         string                  `yaml:"image-package"` // tarball of images loaded into runtime of workers
 	RegistryMirrors      []string                `yaml:"registry-mirrors"`
 	InsecureRegistries   []string                `yaml:"insecure-registries"`
+	RegistryAuths        []*RegistryAuth         `yaml:"registry-auths"` // credentials of private registries used by runtime
 	ConfigExtraArgs      []*ConfigExtraArgs      `yaml:"config-extra-args"`
 	OpenPorts            map[string][]*OpenPorts `yaml:"open-ports"` // key: master, worker, etcd, loadbalance
 	InstallConfig        InstallConfig           `yaml:"install"`
+	Preflight            PreflightConfig         `yaml:"preflight"`
+	Addons               []*AddonConfig          `yaml:"addons"`
+	HostAliases          []*HostAlias            `yaml:"host-aliases"` // extra entries of /etc/hosts on all nodes
+	Repos                []*PackageRepo          `yaml:"repos"`        // yum repos of repo type packages
+	Files                []*ExtraFile            `yaml:"files"`        // extra files shipped to nodes
+	Hooks                HooksConfig             `yaml:"hooks"`        // scripts run on nodes before and after deploy or cleanup
+	// settings of [Service] section written into systemd drop-ins of components, key is unit such as kubelet
+	SystemdDropIns map[string]map[string]string `yaml:"systemd-dropins"`
+	Encryption     EncryptionConfig             `yaml:"encryption"`
+	Audit          AuditConfig                  `yaml:"audit"`
 }
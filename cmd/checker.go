@@ -8,19 +8,66 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/validation"
 
 	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment/binary/commontools"
+	"isula.org/eggo/pkg/clusterdeployment/binary/network"
+	"isula.org/eggo/pkg/clusterdeployment/runtime"
 	"isula.org/eggo/pkg/constants"
 	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/certs"
+	"isula.org/eggo/pkg/utils/dependency"
 	"isula.org/eggo/pkg/utils/endpoint"
+	"isula.org/eggo/pkg/utils/infra"
+	"isula.org/eggo/pkg/utils/kubectl"
 	chain "isula.org/eggo/pkg/utils/responsibilitychain"
+	"isula.org/eggo/pkg/utils/runner"
 )
 
+// ErrInvalidDeployConfig is wrapped by errors of checking deploy config, match it by errors.Is
+var ErrInvalidDeployConfig = errors.New("invalid deploy config")
+
+var (
+	imagePathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*$`)
+	sha256Regexp             = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+	repoNameRegexp           = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+	// resource with optional group, such as secrets or widgets.example.com
+	encryptionResourceRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+)
+
+// image repository format: host[:port][/path]
+func checkImageRepository(repo string) error {
+	parts := strings.Split(strings.TrimSuffix(repo, "/"), "/")
+	host, port := parts[0], ""
+	if strings.Contains(host, ":") {
+		var err error
+		if host, port, err = net.SplitHostPort(host); err != nil {
+			return fmt.Errorf("invalid image repository: %s, err: %v", repo, err)
+		}
+		if p, err := strconv.Atoi(port); err != nil || !endpoint.ValidPort(p) {
+			return fmt.Errorf("invalid port of image repository: %s", repo)
+		}
+	}
+	if ip := net.ParseIP(host); ip == nil {
+		if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+			return fmt.Errorf("invalid host of image repository: %s, err: %v", repo, errs)
+		}
+	}
+	for _, c := range parts[1:] {
+		if !imagePathComponentRegexp.MatchString(c) {
+			return fmt.Errorf("invalid path of image repository: %s", repo)
+		}
+	}
+	return nil
+}
+
 type ClusterConfigResponsibility struct {
 	next chain.Responsibility
 	conf *DeployConfig
@@ -34,6 +81,115 @@ func (ccr *ClusterConfigResponsibility) Nexter() chain.Responsibility {
 	return ccr.next
 }
 
+func checkExternalEtcd(conf *DeployConfig) error {
+	if !conf.EtcdExternal {
+		return nil
+	}
+	if len(conf.Etcds) != 0 {
+		return fmt.Errorf("etcds should not be set when use external etcd")
+	}
+	if len(conf.EtcdEndpoints) == 0 {
+		return fmt.Errorf("no endpoints of external etcd")
+	}
+	for _, ep := range conf.EtcdEndpoints {
+		u, err := url.Parse(ep)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("invalid external etcd endpoint: %s, must be https://host:port", ep)
+		}
+	}
+	for _, f := range []string{conf.EtcdCAFile, conf.EtcdCertFile, conf.EtcdKeyFile} {
+		if f == "" {
+			return fmt.Errorf("etcd-ca-file, etcd-cert-file and etcd-key-file are required when use external etcd")
+		}
+		if !filepath.IsAbs(f) {
+			return fmt.Errorf("external etcd certificate path: %s is not abosulate", f)
+		}
+	}
+	return nil
+}
+
+func checkExternalCAs(conf *DeployConfig) error {
+	if conf.EtcdExternal && conf.ExternalEtcdCAPath != "" {
+		return fmt.Errorf("external-etcd-ca-path should not be set when use external etcd, use etcd-ca-file instead")
+	}
+	cas := []struct {
+		path string
+		name string
+	}{
+		{path: conf.ExternalK8sCAPath, name: "ca"},
+		{path: conf.ExternalEtcdCAPath, name: "ca"},
+		{path: conf.ExternalProxyCAPath, name: "front-proxy-ca"},
+	}
+	for _, ca := range cas {
+		if ca.path == "" {
+			continue
+		}
+		if !filepath.IsAbs(ca.path) {
+			return fmt.Errorf("external ca path: %s is not abosulate", ca.path)
+		}
+		if err := certs.ValidateCA(filepath.Join(ca.path, certs.GetCertName(ca.name)), filepath.Join(ca.path, certs.GetKeyName(ca.name))); err != nil {
+			return fmt.Errorf("invalid external ca in %s: %v", ca.path, err)
+		}
+	}
+	return nil
+}
+
+func checkEtcdPorts(conf *DeployConfig) error {
+	ecc := api.EtcdClusterConfig{
+		ClientPort:  conf.EtcdClientPort,
+		PeerPort:    conf.EtcdPeerPort,
+		MetricsPort: conf.EtcdMetricsPort,
+	}
+	// etcds are deployed on masters by default, ports of etcd should not collide with ports of kubernetes
+	used := make(map[int]string)
+	for _, p := range infra.MasterPorts {
+		used[p.Port] = "kubernetes master"
+	}
+	for _, p := range infra.WorkerPorts {
+		used[p.Port] = "kubernetes worker"
+	}
+	ports := []struct {
+		name string
+		port int
+	}{
+		{"etcd client port", ecc.GetClientPort()},
+		{"etcd peer port", ecc.GetPeerPort()},
+		{"etcd metrics port", ecc.GetMetricsPort()},
+	}
+	for _, p := range ports {
+		if !endpoint.ValidPort(p.port) {
+			return fmt.Errorf("invalid %s: %d", p.name, p.port)
+		}
+		if owner, ok := used[p.port]; ok {
+			return fmt.Errorf("%s: %d collide with port of %s", p.name, p.port, owner)
+		}
+		used[p.port] = p.name
+	}
+	return nil
+}
+
+func checkKubernetesVersion(version, runtime string) error {
+	if version == "" {
+		return nil
+	}
+	if _, err := api.ParseK8sVersion(version); err != nil {
+		return fmt.Errorf("invalid kubernetes version: %s, err: %v", version, err)
+	}
+	// dockershim is removed from kubelet since v1.24
+	ccfg := api.ClusterConfig{KubernetesVersion: version}
+	if utils.IsDocker(runtime) && ccfg.K8sVersionAtLeast("v1.24.0") {
+		return fmt.Errorf("runtime docker is unsupported by kubernetes %s, use containerd or isulad instead", version)
+	}
+	return nil
+}
+
+func checkCgroupDriver(conf *DeployConfig) error {
+	if d := getExtraArgs(conf, "kubelet")["--cgroup-driver"]; d != "" && conf.CgroupDriver != "" && d != conf.CgroupDriver {
+		return fmt.Errorf("cgroup driver: %s conflicts with --cgroup-driver %s in extra args of kubelet", conf.CgroupDriver, d)
+	}
+	return runtime.CheckCgroupDriver(getCgroupDriver(conf), conf.Runtime, getExtraArgs(conf, "container-engine"))
+}
+
 func (ccr *ClusterConfigResponsibility) Execute() error {
 	if ccr.conf == nil {
 		return fmt.Errorf("empty cluster config")
@@ -44,7 +200,7 @@ func (ccr *ClusterConfigResponsibility) Execute() error {
 	}
 	// check certificate of ssh
 	if ccr.conf.PrivateKeyPath == "" {
-		if ccr.conf.Username == "" || ccr.conf.Password == "" {
+		if ccr.conf.Username == "" || (ccr.conf.Password == "" && !ccr.conf.UseSSHAgent) {
 			return fmt.Errorf("no ceritificate of ssh set")
 		}
 	} else {
@@ -52,6 +208,22 @@ func (ccr *ClusterConfigResponsibility) Execute() error {
 			return fmt.Errorf("cluster private key path: %s is not abosulate", ccr.conf.PrivateKeyPath)
 		}
 	}
+	// check host key checking of ssh
+	if err := runner.CheckHostKeyMode(ccr.conf.HostKeyChecking); err != nil {
+		return err
+	}
+	if err := runner.CheckElevate(ccr.conf.Elevate); err != nil {
+		return err
+	}
+	if ccr.conf.KnownHostsPath != "" && !filepath.IsAbs(ccr.conf.KnownHostsPath) {
+		return fmt.Errorf("known hosts path: %s is not abosulate", ccr.conf.KnownHostsPath)
+	}
+	if _, err := runner.KeepAliveInterval(&api.SSHKeepAliveConfig{Interval: ccr.conf.SSHKeepAliveInterval}); err != nil {
+		return err
+	}
+	if _, err := runner.ConnectTimeout(ccr.conf.SSHConnectTimeout); err != nil {
+		return err
+	}
 	// check nodes of cluster
 	if len(ccr.conf.Masters) == 0 {
 		return fmt.Errorf("no master, master node is require for cluster")
@@ -62,6 +234,18 @@ func (ccr *ClusterConfigResponsibility) Execute() error {
 			return fmt.Errorf("cluster external ca path: %s is not abosulate", ccr.conf.ExternalCAPath)
 		}
 	}
+	// check external ca of each component
+	if err := checkExternalCAs(ccr.conf); err != nil {
+		return err
+	}
+	// check external etcd
+	if err := checkExternalEtcd(ccr.conf); err != nil {
+		return err
+	}
+	// check ports of etcd
+	if err := checkEtcdPorts(ccr.conf); err != nil {
+		return err
+	}
 	// check api server endpoint
 	if ccr.conf.ApiServerEndpoint != "" {
 		if host, port, err := net.SplitHostPort(ccr.conf.ApiServerEndpoint); err != nil {
@@ -97,6 +281,9 @@ func (ccr *ClusterConfigResponsibility) Execute() error {
 		if errs := validation.IsDNS1123Subdomain(ccr.conf.DnsDomain); len(errs) > 0 {
 			return fmt.Errorf("invalid dns domain: %v", errs)
 		}
+		if ccr.conf.Service.DNS.Domain != "" && ccr.conf.Service.DNS.Domain != ccr.conf.DnsDomain {
+			return fmt.Errorf("dns domain: %s conflicts with domain of service dns: %s", ccr.conf.DnsDomain, ccr.conf.Service.DNS.Domain)
+		}
 	}
 	// check CniBinDir
 	if ccr.conf.CniBinDir != "" {
@@ -110,7 +297,288 @@ func (ccr *ClusterConfigResponsibility) Execute() error {
 			return fmt.Errorf("invalid runtime endpoint: %s, err: %v", ccr.conf.RuntimeEndpoint, err)
 		}
 	}
+	// check kubernetes version
+	if err := checkKubernetesVersion(ccr.conf.KubernetesVersion, ccr.conf.Runtime); err != nil {
+		return err
+	}
+	// check cgroup driver of kubelet and runtime
+	if err := checkCgroupDriver(ccr.conf); err != nil {
+		return err
+	}
+	// check ImagePackage
+	if ccr.conf.ImagePackage != "" {
+		if !filepath.IsAbs(ccr.conf.ImagePackage) {
+			return fmt.Errorf("image package: %s is not abosulate", ccr.conf.ImagePackage)
+		}
+		if fi, err := os.Stat(ccr.conf.ImagePackage); err != nil || !fi.Mode().IsRegular() {
+			return fmt.Errorf("image package: %s is not a regular file", ccr.conf.ImagePackage)
+		}
+	}
+	// check dirs of kubernetes
+	if err := checkKubernetesDir("config-dir", ccr.conf.ConfigDir); err != nil {
+		return err
+	}
+	if err := checkKubernetesDir("cert-dir", ccr.conf.CertDir); err != nil {
+		return err
+	}
+	// check ImageRepository
+	if ccr.conf.ImageRepository != "" {
+		if err := checkImageRepository(ccr.conf.ImageRepository); err != nil {
+			return err
+		}
+	}
+	// check addons
+	if err := checkAddons(ccr.conf.Addons); err != nil {
+		return err
+	}
+	// check host aliases
+	if err := checkHostAliases(ccr.conf.HostAliases); err != nil {
+		return err
+	}
+	// check yum repos
+	if err := checkRepos(ccr.conf.Repos); err != nil {
+		return err
+	}
+	// check extra files
+	if err := checkExtraFiles(ccr.conf.Files); err != nil {
+		return err
+	}
+	// check auths of registries
+	if err := checkRegistryAuths(ccr.conf.RegistryAuths); err != nil {
+		return err
+	}
+	// check systemd drop-ins of components
+	if err := commontools.CheckSystemdDropIns(ccr.conf.SystemdDropIns); err != nil {
+		return err
+	}
+	// check encryption at rest
+	if err := checkEncryption(&ccr.conf.Encryption); err != nil {
+		return err
+	}
+	// check audit logs of apiserver
+	if err := checkAudit(&ccr.conf.Audit); err != nil {
+		return err
+	}
+	// check hooks
+	if err := checkHooks(&ccr.conf.Hooks); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func checkAddon(a *AddonConfig) error {
+	switch a.Type {
+	case api.AddonTypeFile:
+		if !filepath.IsAbs(a.Filename) {
+			return fmt.Errorf("filename: %s of addon %s must be absolute", a.Filename, a.Name)
+		}
+		exist, err := utils.CheckPathExist(a.Filename)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return fmt.Errorf("filename: %s of addon %s is not exist", a.Filename, a.Name)
+		}
+	case api.AddonTypeURL:
+		u, err := url.ParseRequestURI(a.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid url: %s of addon %s", a.URL, a.Name)
+		}
+		if a.Sha256 != "" && !sha256Regexp.MatchString(a.Sha256) {
+			return fmt.Errorf("invalid sha256: %s of addon %s", a.Sha256, a.Name)
+		}
+	case api.AddonTypeInline:
+		if strings.TrimSpace(a.Content) == "" {
+			return fmt.Errorf("empty content of inline addon %s", a.Name)
+		}
+	default:
+		return fmt.Errorf("unsupport type: %s of addon %s", a.Type, a.Name)
+	}
+	return nil
+}
+
+func checkAddons(addons []*AddonConfig) error {
+	names := make(map[string]bool)
+	for _, a := range addons {
+		if a == nil {
+			return errors.New("empty addon config")
+		}
+		// name is used as manifest file name on master
+		if errs := validation.IsDNS1123Label(a.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid addon name: %s, %v", a.Name, errs)
+		}
+		if names[a.Name] {
+			return fmt.Errorf("duplicate addon name: %s", a.Name)
+		}
+		names[a.Name] = true
+		if err := checkAddon(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkHostAliases(aliases []*HostAlias) error {
+	for _, h := range aliases {
+		if h == nil {
+			return errors.New("empty host alias")
+		}
+		if ip := net.ParseIP(h.IP); ip == nil {
+			return fmt.Errorf("invalid ip: %s of host alias", h.IP)
+		}
+		if len(h.Hostnames) == 0 {
+			return fmt.Errorf("no hostnames for host alias %s", h.IP)
+		}
+		for _, name := range h.Hostnames {
+			if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+				return fmt.Errorf("invalid hostname: %s of host alias %s: %v", name, h.IP, errs)
+			}
+		}
+	}
+	return nil
+}
+
+func checkRepoURL(u string) error {
+	pu, err := url.ParseRequestURI(u)
+	if err != nil {
+		return err
+	}
+	switch pu.Scheme {
+	case "http", "https", "ftp":
+		if pu.Host == "" {
+			return fmt.Errorf("no host")
+		}
+	case "file":
+	default:
+		return fmt.Errorf("unsupport scheme: %s", pu.Scheme)
+	}
+	// url is written into repo file
+	if strings.ContainsAny(u, " \t\n") {
+		return fmt.Errorf("contains whitespace")
+	}
+	return nil
+}
+
+func checkEncryption(conf *EncryptionConfig) error {
+	switch conf.Provider {
+	case "", api.EncryptionProviderAESCBC, api.EncryptionProviderSecretbox:
+	default:
+		return fmt.Errorf("unsupport encryption provider: %s, support: %s, %s", conf.Provider,
+			api.EncryptionProviderAESCBC, api.EncryptionProviderSecretbox)
+	}
+	for _, r := range conf.Resources {
+		if !encryptionResourceRegexp.MatchString(r) {
+			return fmt.Errorf("invalid encryption resource: %s, such as secrets or configmaps", r)
+		}
+	}
+	return nil
+}
+
+func checkAudit(conf *AuditConfig) error {
+	if !conf.Enabled {
+		return nil
+	}
+	if conf.Policy != "" && conf.PolicyFile != "" {
+		return errors.New("audit policy and policy-file are exclusive")
+	}
+	if conf.PolicyFile != "" && !filepath.IsAbs(conf.PolicyFile) {
+		return fmt.Errorf("audit policy-file: %s is not absolute", conf.PolicyFile)
+	}
+	if conf.LogPath != "" && (!filepath.IsAbs(conf.LogPath) || strings.HasSuffix(conf.LogPath, "/")) {
+		return fmt.Errorf("audit log-path: %s is not absolute path of file", conf.LogPath)
+	}
+	if conf.MaxAge < 0 || conf.MaxBackup < 0 || conf.MaxSize < 0 {
+		return errors.New("audit max-age, max-backup and max-size can not be negative")
+	}
+
+	policy, err := commontools.GetAuditPolicy(&api.AuditConfig{Policy: conf.Policy, PolicyFile: conf.PolicyFile})
+	if err != nil {
+		return err
+	}
+	return commontools.CheckAuditPolicy(policy)
+}
+
+func checkRepos(repos []*PackageRepo) error {
+	names := make(map[string]bool)
+	for _, r := range repos {
+		if r == nil {
+			return errors.New("empty repo")
+		}
+		if !repoNameRegexp.MatchString(r.Name) {
+			return fmt.Errorf("invalid repo name: %s, only letters, digits, '.', '_' and '-' are allowed", r.Name)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("duplicate repo name: %s", r.Name)
+		}
+		names[r.Name] = true
+		if err := checkRepoURL(r.BaseURL); err != nil {
+			return fmt.Errorf("invalid baseurl: %s of repo %s: %v", r.BaseURL, r.Name, err)
+		}
+		if r.GPGKey == "" {
+			continue
+		}
+		if err := checkRepoURL(r.GPGKey); err != nil {
+			return fmt.Errorf("invalid gpgkey: %s of repo %s: %v", r.GPGKey, r.Name, err)
+		}
+	}
+	return nil
+}
 
+// max mode of extra file, special bits are not allowed
+const maxExtraFileMode = 0777
+
+func checkExtraFiles(files []*ExtraFile) error {
+	dsts := make(map[string]bool)
+	for _, f := range files {
+		if f == nil {
+			return errors.New("empty extra file")
+		}
+		if !filepath.IsAbs(f.Src) {
+			return fmt.Errorf("src of extra file: %s is not absolute", f.Src)
+		}
+		if fi, err := os.Stat(f.Src); err != nil || !fi.Mode().IsRegular() {
+			return fmt.Errorf("src of extra file: %s is not a regular file", f.Src)
+		}
+		// dst is used in shell of nodes
+		if !filepath.IsAbs(f.Dst) || filepath.Clean(f.Dst) != f.Dst || f.Dst == "/" || strings.ContainsAny(f.Dst, " \t\n\"'`$\\;&|") {
+			return fmt.Errorf("invalid dst of extra file: %q, must be an absolute clean path of file", f.Dst)
+		}
+		if dsts[f.Dst] {
+			return fmt.Errorf("duplicate dst of extra file: %s", f.Dst)
+		}
+		dsts[f.Dst] = true
+		if f.Mode < 0 || f.Mode > maxExtraFileMode {
+			return fmt.Errorf("invalid mode of extra file %s: %o", f.Dst, f.Mode)
+		}
+		for _, role := range f.Roles {
+			if _, ok := toTypeInt[role]; !ok {
+				return fmt.Errorf("invalid role of extra file %s: %s, support: %s, %s, %s, %s", f.Dst, role,
+					MasterRole, WorkerRole, ETCDRole, LoadBalanceRole)
+			}
+		}
+	}
+	return nil
+}
+
+// credentials are not included in errors
+func checkRegistryAuths(auths []*RegistryAuth) error {
+	registries := make(map[string]bool)
+	for _, a := range auths {
+		if a == nil {
+			return errors.New("empty registry auth")
+		}
+		if a.Registry == "" || strings.Contains(a.Registry, "://") || strings.ContainsAny(a.Registry, " \t\"") {
+			return fmt.Errorf("invalid registry: %q of registry auth, such as example.com:5000", a.Registry)
+		}
+		if registries[a.Registry] {
+			return fmt.Errorf("duplicate auth of registry: %s", a.Registry)
+		}
+		registries[a.Registry] = true
+		if a.Username == "" || a.Password == "" {
+			return fmt.Errorf("username and password are required by auth of registry: %s", a.Registry)
+		}
+	}
 	return nil
 }
 
@@ -146,6 +614,15 @@ func checkHostconfig(h *HostConfig) error {
 	if !endpoint.ValidPort(h.Port) {
 		return fmt.Errorf("invalid host port: %v", h.Port)
 	}
+	if err := kubectl.ValidateLabels(h.Labels); err != nil {
+		return err
+	}
+	if _, err := kubectl.ParseTaints(h.Taints); err != nil {
+		return err
+	}
+	if h.PackageManager != "" && !dependency.IsSupportPackageManager(h.PackageManager) {
+		return fmt.Errorf("unsupport package manager: %s of host %s", h.PackageManager, h.Name)
+	}
 	return nil
 }
 
@@ -209,7 +686,35 @@ func (ccr *NodesResponsibility) Execute() error {
 			return fmt.Errorf("invalid loadbalance bind port: %v", ccr.conf.LoadBalance.BindPort)
 		}
 	}
+	if err := checkLoadBalanceVIP(&ccr.conf.LoadBalance); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// vrid of vrrp is in range 1-255
+const maxVRID = 255
 
+func checkLoadBalanceVIP(lb *LoadBalance) error {
+	if lb.VIP == "" {
+		return nil
+	}
+	if lb.Ip == "" {
+		return fmt.Errorf("vip of loadbalance %s requires loadbalance ip", lb.VIP)
+	}
+	if ip := net.ParseIP(lb.VIP); ip == nil {
+		return fmt.Errorf("invalid loadbalance vip: %s", lb.VIP)
+	}
+	if lb.VIP == lb.Ip {
+		return fmt.Errorf("vip of loadbalance must be different from ip: %s", lb.VIP)
+	}
+	if lb.Interface == "" {
+		return fmt.Errorf("vip of loadbalance %s requires interface", lb.VIP)
+	}
+	if lb.VRID < 1 || lb.VRID > maxVRID {
+		return fmt.Errorf("invalid vrid of loadbalance: %d, must be in 1-%d", lb.VRID, maxVRID)
+	}
 	return nil
 }
 
@@ -234,9 +739,22 @@ func (ccr *ServiceClusterResponsibility) Execute() error {
 	}
 
 	if ccr.conf.DNSAddr != "" {
-		if ip := net.ParseIP(ccr.conf.DNSAddr); ip == nil {
+		ip := net.ParseIP(ccr.conf.DNSAddr)
+		if ip == nil {
 			return fmt.Errorf("invalid dns address: %s", ccr.conf.DNSAddr)
 		}
+		// dns address is cluster ip of coredns service
+		if _, cidr, err := net.ParseCIDR(ccr.conf.CIDR); err == nil && !cidr.Contains(ip) {
+			return fmt.Errorf("dns address: %s is not in service cidr: %s", ccr.conf.DNSAddr, ccr.conf.CIDR)
+		}
+	}
+	if err := checkDNSConfig(ccr.conf.DNS); err != nil {
+		return err
+	}
+	if ccr.conf.NodePortRange != "" {
+		if _, _, err := api.ParsePortRange(ccr.conf.NodePortRange); err != nil {
+			return fmt.Errorf("invalid node-port-range of service: %v", err)
+		}
 	}
 	if ccr.conf.Gateway != "" {
 		if ip := net.ParseIP(ccr.conf.Gateway); ip == nil {
@@ -247,9 +765,60 @@ func (ccr *ServiceClusterResponsibility) Execute() error {
 	return nil
 }
 
+func checkDNSConfig(dns DnsConfig) error {
+	if dns.Replicas < 0 {
+		return fmt.Errorf("invalid replicas of coredns: %d", dns.Replicas)
+	}
+	if dns.Domain != "" {
+		if errs := validation.IsDNS1123Subdomain(dns.Domain); len(errs) > 0 {
+			return fmt.Errorf("invalid domain of service dns: %v", errs)
+		}
+	}
+	quantities := []struct {
+		name  string
+		value string
+	}{
+		{name: "cpu-request", value: dns.CPURequest},
+		{name: "cpu-limit", value: dns.CPULimit},
+		{name: "memory-request", value: dns.MemoryRequest},
+		{name: "memory-limit", value: dns.MemoryLimit},
+	}
+	for _, q := range quantities {
+		if q.value == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(q.value); err != nil {
+			return fmt.Errorf("invalid %s of coredns: %s, err: %v", q.name, q.value, err)
+		}
+	}
+	for _, s := range dns.UpstreamServers {
+		if err := checkUpstreamServer(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkUpstreamServer checks server is ip or ip:port, ipv6 with port must be [ip]:port
+func checkUpstreamServer(server string) error {
+	if net.ParseIP(server) != nil {
+		return nil
+	}
+	host, port, err := net.SplitHostPort(server)
+	if err != nil || net.ParseIP(host) == nil {
+		return fmt.Errorf("invalid upstream server of coredns: %s, must be ip[:port]", server)
+	}
+	if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+		return fmt.Errorf("invalid port of upstream server of coredns: %s", server)
+	}
+	return nil
+}
+
 type NetworkResponsibility struct {
 	next chain.Responsibility
 	conf NetworkConfig
+	// pod cidr must not overlap with service cidr
+	serviceCIDR string
 }
 
 func (ccr *NetworkResponsibility) SetNexter(nexter chain.Responsibility) {
@@ -265,11 +834,54 @@ func (ccr *NetworkResponsibility) Execute() error {
 		if _, _, err := net.ParseCIDR(ccr.conf.PodCIDR); err != nil {
 			return fmt.Errorf("invalid pod cidr: %s, err: %v", ccr.conf.PodCIDR, err)
 		}
+		if err := checkCIDROverlap(ccr.serviceCIDR, ccr.conf.PodCIDR); err != nil {
+			return err
+		}
+	}
+
+	// user-supplied yaml of network plugin is applied as it is
+	if _, ok := ccr.conf.PluginArgs[constants.NetworkPluginArgKeyYamlPath]; ok {
+		return nil
+	}
+	if network.IsBuiltinPlugin(ccr.conf.Plugin) {
+		if ccr.conf.PodCIDR == "" {
+			return fmt.Errorf("pod cidr is required by network plugin %s", ccr.conf.Plugin)
+		}
+		return network.CheckPluginArgs(ccr.conf.Plugin, ccr.conf.PluginArgs)
 	}
 
 	return nil
 }
 
+// checkCIDROverlap returns error if service cidr and pod cidr overlap, invalid cidr is ignored
+func checkCIDROverlap(serviceCIDR, podCIDR string) error {
+	_, service, err := net.ParseCIDR(serviceCIDR)
+	if err != nil {
+		return nil
+	}
+	_, pod, err := net.ParseCIDR(podCIDR)
+	if err != nil {
+		return nil
+	}
+	if service.Contains(pod.IP) || pod.Contains(service.IP) {
+		return fmt.Errorf("pod cidr: %s overlaps with service cidr: %s", podCIDR, serviceCIDR)
+	}
+	return nil
+}
+
+// CheckNetworkConfig checks network of service and pod, for callers without full deploy config
+func CheckNetworkConfig(service ServiceClusterConfig, network NetworkConfig) error {
+	nr := NetworkResponsibility{
+		conf:        network,
+		serviceCIDR: service.CIDR,
+	}
+	sr := ServiceClusterResponsibility{
+		next: &nr,
+		conf: service,
+	}
+	return chain.RunChainOfResponsibility(&sr)
+}
+
 type ApiSansResponsibility struct {
 	next chain.Responsibility
 	conf Sans
@@ -359,6 +971,15 @@ func checkPackageConfig(pc *PackageConfig) error {
 			return fmt.Errorf("package config dst path: %s must be absolute", pc.Dst)
 		}
 	}
+	switch pc.Type {
+	case "bin", "file", "dir":
+		// name may be list of packages split by comma
+		for _, name := range strings.Split(pc.Name, ",") {
+			if err := dependency.CheckPackageDst(name, pc.Dst); err != nil {
+				return err
+			}
+		}
+	}
 	if pc.TimeOut != "" {
 		if _, err := time.ParseDuration(pc.TimeOut); err != nil {
 			return fmt.Errorf("invalid timeout: %s for package: %s", pc.TimeOut, pc.Name)
@@ -374,6 +995,23 @@ func checkPackageConfig(pc *PackageConfig) error {
 	return nil
 }
 
+func checkHooks(hc *HooksConfig) error {
+	for _, hooks := range [][]*HookConfig{hc.PreDeploy, hc.PostDeploy, hc.PreCleanup, hc.PostCleanup} {
+		for _, h := range hooks {
+			if h == nil || h.Path == "" {
+				return errors.New("empty path of hook")
+			}
+			if !filepath.IsAbs(h.Path) {
+				return fmt.Errorf("hook path: %s is not abosulate", h.Path)
+			}
+			if _, ok := toTypeInt[h.Role]; h.Role != "" && !ok {
+				return fmt.Errorf("invalid role: %s of hook %s", h.Role, h.Path)
+			}
+		}
+	}
+	return nil
+}
+
 func checkCmdHooksParameter(pa ...string) error {
 	for _, v := range pa {
 		if v == "" {
@@ -431,6 +1069,15 @@ func (ccr *InstallConfigResponsibility) Execute() error {
 		}
 
 		for arch, path := range ccr.conf.PackageSrc.SrcPath {
+			if api.IsRemotePackage(path) {
+				if _, err := url.ParseRequestURI(path); err != nil {
+					return fmt.Errorf("srcpackage %s url: %s is invalid: %v", arch, path, err)
+				}
+				if ccr.conf.PackageSrc.Sha256[arch] == "" {
+					return fmt.Errorf("srcpackage %s url: %s requires sha256", arch, path)
+				}
+				continue
+			}
 			if !filepath.IsAbs(path) {
 				return fmt.Errorf("srcpackage %s path: %s must be absolute", arch, path)
 			}
@@ -445,6 +1092,15 @@ func (ccr *InstallConfigResponsibility) Execute() error {
 			}
 		}
 
+		for arch, sum := range ccr.conf.PackageSrc.Sha256 {
+			if _, ok := ccr.conf.PackageSrc.SrcPath[arch]; !ok {
+				return fmt.Errorf("no source package for sha256 of arch %s", arch)
+			}
+			if !sha256Regexp.MatchString(sum) {
+				return fmt.Errorf("invalid sha256: %s of arch %s", sum, arch)
+			}
+		}
+
 		if len(ccr.conf.PackageSrc.SrcPath) != 0 {
 			for a := range ccr.arch {
 				if _, ok := ccr.conf.PackageSrc.SrcPath[a]; !ok {
@@ -500,11 +1156,7 @@ func (ccr *InstallConfigResponsibility) Execute() error {
 	return nil
 }
 
-func RunChecker(conf *DeployConfig) error {
-	if conf == nil {
-		return errors.New("deploy config is nil")
-	}
-
+func newCheckerChain(conf *DeployConfig) chain.Responsibility {
 	arch := make(map[string]bool)
 	for _, m := range conf.Masters {
 		arch[m.Arch] = true
@@ -512,11 +1164,8 @@ func RunChecker(conf *DeployConfig) error {
 	for _, w := range conf.Workers {
 		arch[w.Arch] = true
 	}
-	for _, m := range conf.Masters {
-		arch[m.Arch] = true
-	}
-	for _, m := range conf.Masters {
-		arch[m.Arch] = true
+	for _, e := range conf.Etcds {
+		arch[e.Arch] = true
 	}
 	if conf.LoadBalance.Arch != "" {
 		arch[conf.LoadBalance.Arch] = true
@@ -535,8 +1184,9 @@ func RunChecker(conf *DeployConfig) error {
 		conf: conf.ApiServerCertSans,
 	}
 	network := NetworkResponsibility{
-		next: &sans,
-		conf: conf.NetWork,
+		next:        &sans,
+		conf:        conf.NetWork,
+		serviceCIDR: conf.Service.CIDR,
 	}
 	service := ServiceClusterResponsibility{
 		next: &network,
@@ -550,5 +1200,42 @@ func RunChecker(conf *DeployConfig) error {
 		next: &nodes,
 		conf: conf,
 	}
-	return chain.RunChainOfResponsibility(&cluster)
+	return &cluster
+}
+
+// checkKubernetesDir checks dir of kubernetes is absolute if set, dir is removed when cleanup cluster
+func checkKubernetesDir(name, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if !filepath.IsAbs(dir) || filepath.Clean(dir) == "/" {
+		return fmt.Errorf("%s: %s is not absolute or is root dir", name, dir)
+	}
+	return nil
+}
+
+// checkDirOverrides checks dirs of kubernetes set by command line
+func checkDirOverrides() error {
+	if err := checkKubernetesDir("--config-dir", opts.configDir); err != nil {
+		return err
+	}
+	return checkKubernetesDir("--cert-dir", opts.certDir)
+}
+
+func RunChecker(conf *DeployConfig) error {
+	if conf == nil {
+		return errors.New("deploy config is nil")
+	}
+	if err := chain.RunChainOfResponsibility(newCheckerChain(conf)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDeployConfig, err)
+	}
+	return nil
+}
+
+// CollectCheckErrors runs all checkers, and returns problems found by each of them
+func CollectCheckErrors(conf *DeployConfig) []error {
+	if conf == nil {
+		return []error{errors.New("deploy config is nil")}
+	}
+	return chain.CollectChainOfResponsibility(newCheckerChain(conf))
 }
@@ -16,10 +16,15 @@
 package cmd
 
 import (
+	"crypto/x509"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"isula.org/eggo/pkg/utils/certs"
 )
 
 func TestRunChecker(t *testing.T) {
@@ -48,7 +53,7 @@ func TestRunChecker(t *testing.T) {
 		t.Fatalf("load deploy config file failed: %v", err)
 	}
 
-	if err = RunChecker(conf); err == nil {
+	if err = RunChecker(conf); !errors.Is(err, ErrInvalidDeployConfig) {
 		t.Fatalf("test invalid cluster config failed: %v", err)
 	}
 
@@ -72,6 +77,138 @@ func TestRunChecker(t *testing.T) {
 	}
 	conf.ClusterID = tmpClusterID
 
+	// test image repository
+	conf.ImageRepository = "hub.example.com:5000/k8s"
+	if err = RunChecker(conf); err != nil {
+		t.Fatalf("test valid image repository failed: %v", err)
+	}
+	for _, repo := range []string{"https://hub.example.com", "hub.example.com:777777", "hub_example.com", "hub.example.com/K8S"} {
+		conf.ImageRepository = repo
+		if err = RunChecker(conf); err == nil {
+			t.Fatalf("test invalid image repository: %s failed", repo)
+		}
+	}
+	conf.ImageRepository = ""
+
+	// test external etcd
+	tmpEtcds := conf.Etcds
+	conf.EtcdExternal = true
+	conf.EtcdEndpoints = []string{"https://192.168.0.100:2379"}
+	conf.EtcdCAFile, conf.EtcdCertFile, conf.EtcdKeyFile = "/etc/etcd/ca.crt", "/etc/etcd/client.crt", "/etc/etcd/client.key"
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test external etcd with etcds failed")
+	}
+	conf.Etcds = nil
+	if err = RunChecker(conf); err != nil {
+		t.Fatalf("test valid external etcd failed: %v", err)
+	}
+	conf.EtcdEndpoints = []string{"http://192.168.0.100:2379"}
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test invalid external etcd endpoint failed")
+	}
+	conf.EtcdEndpoints = []string{"https://192.168.0.100:2379"}
+	conf.EtcdKeyFile = ""
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test external etcd without key failed")
+	}
+	conf.EtcdExternal, conf.EtcdEndpoints, conf.Etcds = false, nil, tmpEtcds
+	conf.EtcdCAFile, conf.EtcdCertFile = "", ""
+
+	// test external ca of each component
+	caDir := filepath.Join(tempdir, "external-ca")
+	lcg := certs.NewLocalCertGenerator()
+	for _, name := range []string{"ca", "front-proxy-ca"} {
+		if err = lcg.CreateCA(&certs.CertConfig{CommonName: name, PublicKeyAlgorithm: x509.ECDSA}, caDir, name); err != nil {
+			t.Fatalf("create external %s failed: %v", name, err)
+		}
+	}
+	conf.ExternalK8sCAPath, conf.ExternalEtcdCAPath, conf.ExternalProxyCAPath = caDir, caDir, caDir
+	if err = RunChecker(conf); err != nil {
+		t.Fatalf("test valid external cas failed: %v", err)
+	}
+	conf.EtcdExternal = true
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test external etcd ca with external etcd failed")
+	}
+	conf.EtcdExternal = false
+	conf.ExternalProxyCAPath = tempdir
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test external front proxy ca not exist failed")
+	}
+	conf.ExternalK8sCAPath, conf.ExternalProxyCAPath = "external-ca", ""
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test relative external ca path failed")
+	}
+	conf.ExternalK8sCAPath, conf.ExternalEtcdCAPath = "", ""
+
+	// test addons
+	addonFile := filepath.Join(tempdir, "addon.yaml")
+	if err = ioutil.WriteFile(addonFile, []byte("kind: Namespace"), 0600); err != nil {
+		t.Fatalf("write addon file failed: %v", err)
+	}
+	conf.Addons = []*AddonConfig{
+		{Name: "file", Type: "file", Filename: addonFile},
+		{Name: "url", Type: "url", URL: "https://example.com/addon.yaml", Sha256: strings.Repeat("a", 64)},
+		{Name: "inline", Type: "inline", Content: "kind: Namespace"},
+	}
+	if err = RunChecker(conf); err != nil {
+		t.Fatalf("test valid addons failed: %v", err)
+	}
+	invalidAddons := []*AddonConfig{
+		{Name: "file", Type: "file", Filename: filepath.Join(tempdir, "not-exist.yaml")},
+		{Name: "url", Type: "url", URL: "ftp://example.com/addon.yaml"},
+		{Name: "url", Type: "url", URL: "https://example.com/addon.yaml", Sha256: "abc"},
+		{Name: "inline", Type: "inline", Content: " \n"},
+		{Name: "Invalid_Name", Type: "inline", Content: "kind: Namespace"},
+		{Name: "yaml", Type: "yaml"},
+	}
+	for _, a := range invalidAddons {
+		conf.Addons = []*AddonConfig{a}
+		if err = RunChecker(conf); err == nil {
+			t.Fatalf("test invalid addon %v failed", *a)
+		}
+	}
+	conf.Addons = []*AddonConfig{{Name: "dup", Type: "inline", Content: "a: b"}, {Name: "dup", Type: "inline", Content: "a: b"}}
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test duplicate addon name failed")
+	}
+	conf.Addons = nil
+
+	// test image package
+	conf.ImagePackage = filepath.Join(tempdir, "images.tar")
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test image package not exist failed")
+	}
+	if err = ioutil.WriteFile(conf.ImagePackage, []byte("images"), 0600); err != nil {
+		t.Fatalf("write image package failed: %v", err)
+	}
+	if err = RunChecker(conf); err != nil {
+		t.Fatalf("test valid image package failed: %v", err)
+	}
+	conf.ImagePackage = "images.tar"
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test relative image package failed")
+	}
+	conf.ImagePackage = ""
+
+	// test host aliases
+	conf.HostAliases = []*HostAlias{{IP: "192.168.0.100", Hostnames: []string{"registry.local", "hub"}}}
+	if err = RunChecker(conf); err != nil {
+		t.Fatalf("test valid host aliases failed: %v", err)
+	}
+	invalidAliases := []*HostAlias{
+		{IP: "192.168.0.777", Hostnames: []string{"registry.local"}},
+		{IP: "192.168.0.100"},
+		{IP: "192.168.0.100", Hostnames: []string{"registry local"}},
+	}
+	for _, h := range invalidAliases {
+		conf.HostAliases = []*HostAlias{h}
+		if err = RunChecker(conf); err == nil {
+			t.Fatalf("test invalid host alias %v failed", *h)
+		}
+	}
+	conf.HostAliases = nil
+
 	// test invalid nodes
 	tmpBindPort := conf.LoadBalance.BindPort
 	conf.LoadBalance.BindPort = 777777
@@ -88,6 +225,44 @@ func TestRunChecker(t *testing.T) {
 	}
 	conf.Service.Gateway = tmpGateway
 
+	// test dns address out of service cidr
+	tmpDNSAddr := conf.Service.DNSAddr
+	conf.Service.DNSAddr = "10.96.0.10"
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test dns address out of service cidr failed")
+	}
+	conf.Service.DNSAddr = tmpDNSAddr
+
+	// test pod cidr overlaps with service cidr
+	podCIDR := conf.NetWork.PodCIDR
+	conf.NetWork.PodCIDR = "10.32.128.0/17"
+	if err = RunChecker(conf); err == nil || !strings.Contains(err.Error(), "overlaps") {
+		t.Fatalf("test pod cidr overlaps with service cidr failed: %v", err)
+	}
+	if err = CheckNetworkConfig(conf.Service, conf.NetWork); err == nil {
+		t.Fatalf("test check network config with overlapped cidr failed")
+	}
+	conf.NetWork.PodCIDR = podCIDR
+	if err = CheckNetworkConfig(conf.Service, conf.NetWork); err != nil {
+		t.Fatalf("test check valid network config failed: %v", err)
+	}
+
+	// test resources and domain of coredns
+	conf.Service.DNS.CPURequest, conf.Service.DNS.MemoryLimit = "200m", "256Mi"
+	if err = RunChecker(conf); err != nil {
+		t.Fatalf("test valid resources of coredns failed: %v", err)
+	}
+	conf.Service.DNS.MemoryLimit = "256MB"
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test invalid memory limit of coredns failed")
+	}
+	conf.Service.DNS.CPURequest, conf.Service.DNS.MemoryLimit = "", ""
+	conf.Service.DNS.Domain = "eggo.local"
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test domain of coredns conflicts with dns domain failed")
+	}
+	conf.Service.DNS.Domain = ""
+
 	// test invalid network
 	tmpPodCIDR := conf.NetWork.PodCIDR
 	conf.NetWork.PodCIDR = "192.168.0.777"
@@ -96,6 +271,19 @@ func TestRunChecker(t *testing.T) {
 	}
 	conf.NetWork.PodCIDR = tmpPodCIDR
 
+	// test builtin network plugin
+	tmpPlugin := conf.NetWork.Plugin
+	conf.NetWork.Plugin = "flannel"
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test flannel without backend failed")
+	}
+	conf.NetWork.PluginArgs["Backend"] = "vxlan"
+	if err = RunChecker(conf); err != nil {
+		t.Fatalf("test flannel with backend failed: %v", err)
+	}
+	delete(conf.NetWork.PluginArgs, "Backend")
+	conf.NetWork.Plugin = tmpPlugin
+
 	// test invalid apiSan
 	if len(conf.ApiServerCertSans.DNSNames) == 0 {
 		conf.ApiServerCertSans.DNSNames = []string{"test"}
@@ -132,4 +320,303 @@ func TestRunChecker(t *testing.T) {
 		t.Fatalf("test invalid install config failed: %v", err)
 	}
 	delete(conf.InstallConfig.PackageSrc.SrcPath, "test-arch")
+
+	// test http url of package source
+	conf.InstallConfig.PackageSrc.SrcPath["test-arch"] = "https://127.0.0.1/packages.tar.gz"
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test http package source without sha256 failed")
+	}
+	conf.InstallConfig.PackageSrc.Sha256 = map[string]string{"test-arch": "invalid-sha256"}
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test invalid sha256 of package source failed")
+	}
+	conf.InstallConfig.PackageSrc.Sha256["test-arch"] = strings.Repeat("a", 64)
+	if err = RunChecker(conf); err != nil {
+		t.Fatalf("test sha256 of package source failed: %v", err)
+	}
+	delete(conf.InstallConfig.PackageSrc.SrcPath, "test-arch")
+	if err = RunChecker(conf); err == nil {
+		t.Fatalf("test sha256 without package source failed")
+	}
+	conf.InstallConfig.PackageSrc.Sha256 = nil
+}
+
+func TestCheckRepos(t *testing.T) {
+	valids := []*PackageRepo{
+		{Name: "everything", BaseURL: "https://mirror.local/openEuler/everything/", GPGKey: "https://mirror.local/RPM-GPG-KEY"},
+		{Name: "local_update-1.0", BaseURL: "file:///mnt/update"},
+		{Name: "ftp", BaseURL: "ftp://mirror.local/repo"},
+	}
+	if err := checkRepos(valids); err != nil {
+		t.Fatalf("check valid repos failed: %v", err)
+	}
+
+	invalids := [][]*PackageRepo{
+		{nil},
+		{{Name: "", BaseURL: "https://mirror.local/repo"}},
+		{{Name: "bad name", BaseURL: "https://mirror.local/repo"}},
+		{{Name: "dup", BaseURL: "https://mirror.local/a"}, {Name: "dup", BaseURL: "https://mirror.local/b"}},
+		{{Name: "repo", BaseURL: ""}},
+		{{Name: "repo", BaseURL: "mirror.local/repo"}},
+		{{Name: "repo", BaseURL: "https:///repo"}},
+		{{Name: "repo", BaseURL: "ssh://mirror.local/repo"}},
+		{{Name: "repo", BaseURL: "https://mirror.local/repo\ngpgcheck=0"}},
+		{{Name: "repo", BaseURL: "https://mirror.local/repo", GPGKey: "RPM-GPG-KEY"}},
+	}
+	for _, repos := range invalids {
+		if err := checkRepos(repos); err == nil {
+			t.Fatalf("expect invalid repos: %+v", repos)
+		}
+	}
+}
+
+func TestCheckKubernetesVersion(t *testing.T) {
+	cases := []struct {
+		version string
+		runtime string
+		valid   bool
+	}{
+		{version: "", runtime: "docker", valid: true},
+		{version: "v1.20.2", runtime: "", valid: true},
+		{version: "1.23.1", runtime: "docker", valid: true},
+		{version: "v1.24.0", runtime: "containerd", valid: true},
+		{version: "v1.24.0", runtime: "docker", valid: false},
+		{version: "v1.25.3", runtime: "", valid: false},
+		{version: "v1.20", runtime: "iSulad", valid: false},
+		{version: "latest", runtime: "iSulad", valid: false},
+	}
+	for _, c := range cases {
+		err := checkKubernetesVersion(c.version, c.runtime)
+		if (err == nil) != c.valid {
+			t.Fatalf("check version %q with runtime %q expect valid: %v, get: %v", c.version, c.runtime, c.valid, err)
+		}
+	}
+}
+
+func TestCheckCgroupDriver(t *testing.T) {
+	kubeletArgs := func(driver string) []*ConfigExtraArgs {
+		return []*ConfigExtraArgs{{Name: "kubelet", ExtraArgs: map[string]string{"--cgroup-driver": driver}}}
+	}
+	cases := []struct {
+		name  string
+		conf  DeployConfig
+		valid bool
+	}{
+		{name: "default", conf: DeployConfig{}, valid: true},
+		{name: "cgroupfs of containerd", conf: DeployConfig{CgroupDriver: "cgroupfs", Runtime: "containerd"}, valid: true},
+		{name: "same driver of kubelet args", conf: DeployConfig{CgroupDriver: "cgroupfs", ConfigExtraArgs: kubeletArgs("cgroupfs")}, valid: true},
+		{name: "invalid driver", conf: DeployConfig{CgroupDriver: "none"}, valid: false},
+		{name: "conflict with kubelet args", conf: DeployConfig{CgroupDriver: "systemd", ConfigExtraArgs: kubeletArgs("cgroupfs")}, valid: false},
+		{
+			name: "mismatch with runtime args",
+			conf: DeployConfig{ConfigExtraArgs: append(kubeletArgs("systemd"), &ConfigExtraArgs{
+				Name: "container-engine", ExtraArgs: map[string]string{"--exec-opt": "native.cgroupdriver=cgroupfs"}}),
+			},
+			valid: false,
+		},
+	}
+	for _, c := range cases {
+		if err := checkCgroupDriver(&c.conf); (err == nil) != c.valid {
+			t.Fatalf("check cgroup driver with %s expect valid: %v, get: %v", c.name, c.valid, err)
+		}
+	}
+
+	conf := &DeployConfig{ConfigExtraArgs: kubeletArgs("cgroupfs")}
+	if d := getCgroupDriver(conf); d != "cgroupfs" {
+		t.Fatalf("expect cgroup driver of kubelet args, get: %s", d)
+	}
+}
+
+func TestCheckRegistryAuths(t *testing.T) {
+	valid := []*RegistryAuth{
+		{Registry: "registry.example.com:5000", Username: "admin", Password: "secret"},
+		{Registry: "hub.example.org/library", Username: "robot", Password: "token"},
+	}
+	if err := checkRegistryAuths(valid); err != nil {
+		t.Fatalf("check valid registry auths failed: %v", err)
+	}
+
+	invalids := [][]*RegistryAuth{
+		{nil},
+		{{Registry: "", Username: "admin", Password: "secret"}},
+		{{Registry: "https://registry.example.com", Username: "admin", Password: "secret"}},
+		{{Registry: "registry.example.com", Username: "admin"}},
+		{valid[0], valid[0]},
+	}
+	for _, auths := range invalids {
+		err := checkRegistryAuths(auths)
+		if err == nil {
+			t.Fatalf("expect invalid registry auths: %v", auths)
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Fatalf("error contains password: %v", err)
+		}
+	}
+}
+
+func TestCheckPackageDst(t *testing.T) {
+	valids := []*PackageConfig{
+		{Name: "kubectl,kubelet", Type: "bin", Dst: "/usr/bin"},
+		{Name: "10-calico.conflist", Type: "file", Dst: "/etc/cni/net.d"},
+		{Name: "conntrack-tools", Type: "pkg"},
+	}
+	for _, pc := range valids {
+		if err := checkPackageConfig(pc); err != nil {
+			t.Fatalf("check valid package %v failed: %v", pc, err)
+		}
+	}
+
+	invalids := []*PackageConfig{
+		{Name: "kubectl", Type: "bin"},
+		{Name: "kubectl", Type: "bin", Dst: "usr/bin"},
+		{Name: "kubectl", Type: "bin", Dst: "/usr/bin/"},
+		{Name: "kubectl", Type: "bin", Dst: "/usr/local/../bin"},
+		{Name: "../kubectl", Type: "bin", Dst: "/usr/bin"},
+		{Name: "passwd", Type: "file", Dst: "/etc"},
+		{Name: "kubectl,", Type: "bin", Dst: "/usr/bin"},
+	}
+	for _, pc := range invalids {
+		if err := checkPackageConfig(pc); err == nil {
+			t.Fatalf("expect invalid package %v", pc)
+		}
+	}
+}
+
+func TestCheckEncryption(t *testing.T) {
+	valids := []EncryptionConfig{
+		{},
+		{Provider: "aescbc"},
+		{Provider: "secretbox", Resources: []string{"secrets", "configmaps", "widgets.example.com"}},
+	}
+	for _, v := range valids {
+		if err := checkEncryption(&v); err != nil {
+			t.Fatalf("check valid encryption %v failed: %v", v, err)
+		}
+	}
+
+	invalids := []EncryptionConfig{
+		{Provider: "kms"},
+		{Provider: "identity"},
+		{Resources: []string{"Secrets"}},
+		{Resources: []string{"secrets\n"}},
+		{Resources: []string{""}},
+	}
+	for _, v := range invalids {
+		if err := checkEncryption(&v); err == nil {
+			t.Fatalf("expect invalid encryption: %v", v)
+		}
+	}
+}
+
+func TestCheckAudit(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "cmd-audit-test-")
+	if err != nil {
+		t.Fatalf("create tempdir failed: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+	policyFile := filepath.Join(tempdir, "policy.yaml")
+	if err = ioutil.WriteFile(policyFile, []byte("apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n- level: Metadata\n"), 0600); err != nil {
+		t.Fatalf("write policy failed: %v", err)
+	}
+	badFile := filepath.Join(tempdir, "bad.yaml")
+	if err = ioutil.WriteFile(badFile, []byte("rules: ["), 0600); err != nil {
+		t.Fatalf("write policy failed: %v", err)
+	}
+
+	valids := []AuditConfig{
+		{},
+		{Enabled: true},
+		{Enabled: true, PolicyFile: policyFile, LogPath: "/var/log/audit.log", MaxAge: 7},
+		{Enabled: true, Policy: "apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n- level: None\n"},
+	}
+	for _, v := range valids {
+		if err = checkAudit(&v); err != nil {
+			t.Fatalf("check valid audit %+v failed: %v", v, err)
+		}
+	}
+
+	invalids := []AuditConfig{
+		{Enabled: true, PolicyFile: "policy.yaml"},
+		{Enabled: true, PolicyFile: filepath.Join(tempdir, "not-exist.yaml")},
+		{Enabled: true, PolicyFile: badFile},
+		{Enabled: true, Policy: "kind: Policy"},
+		{Enabled: true, Policy: "kind: Policy", PolicyFile: policyFile},
+		{Enabled: true, LogPath: "audit.log"},
+		{Enabled: true, LogPath: "/var/log/audit/"},
+		{Enabled: true, MaxSize: -1},
+	}
+	for _, v := range invalids {
+		if err = checkAudit(&v); err == nil {
+			t.Fatalf("expect invalid audit: %+v", v)
+		}
+	}
+}
+
+func TestCheckUpstreamServers(t *testing.T) {
+	valid := DnsConfig{UpstreamServers: []string{"10.0.0.53", "10.0.0.54:5353", "fd00::53", "[fd00::53]:53"}}
+	if err := checkDNSConfig(valid); err != nil {
+		t.Fatalf("check valid upstream servers failed: %v", err)
+	}
+
+	invalids := []string{"", "dns.example.com", "10.0.0.53:", "10.0.0.53:dns", "10.0.0.53:65536", "fd00::53:53:x", "udp://10.0.0.53"}
+	for _, s := range invalids {
+		if err := checkDNSConfig(DnsConfig{UpstreamServers: []string{s}}); err == nil {
+			t.Fatalf("expect invalid upstream server: %q", s)
+		}
+	}
+}
+
+func TestCheckLoadBalanceVIP(t *testing.T) {
+	lb := LoadBalance{Ip: "192.168.0.10", Port: 22, BindPort: 8443, VIP: "192.168.0.100", Interface: "eth0", VRID: 51}
+	if err := checkLoadBalanceVIP(&lb); err != nil {
+		t.Fatalf("check valid vip failed: %v", err)
+	}
+
+	invalids := []LoadBalance{
+		{VIP: "192.168.0.100", Interface: "eth0", VRID: 51},
+		{Ip: "192.168.0.10", VIP: "192.168.0.300", Interface: "eth0", VRID: 51},
+		{Ip: "192.168.0.10", VIP: "192.168.0.10", Interface: "eth0", VRID: 51},
+		{Ip: "192.168.0.10", VIP: "192.168.0.100", VRID: 51},
+		{Ip: "192.168.0.10", VIP: "192.168.0.100", Interface: "eth0"},
+		{Ip: "192.168.0.10", VIP: "192.168.0.100", Interface: "eth0", VRID: 256},
+	}
+	for _, v := range invalids {
+		if err := checkLoadBalanceVIP(&v); err == nil {
+			t.Fatalf("expect invalid vip: %+v", v)
+		}
+	}
+}
+
+func TestCheckExtraFiles(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "config.toml")
+	if err := ioutil.WriteFile(src, []byte("version = 2"), 0644); err != nil {
+		t.Fatalf("write src file failed: %v", err)
+	}
+	valid := []*ExtraFile{
+		{Src: src, Dst: "/etc/containerd/config.toml", Mode: 0600, Roles: []string{WorkerRole}},
+		{Src: src, Dst: "/etc/eggo/config.toml"},
+	}
+	if err := checkExtraFiles(valid); err != nil {
+		t.Fatalf("check valid extra files failed: %v", err)
+	}
+
+	invalids := []*ExtraFile{
+		nil,
+		{Src: "config.toml", Dst: "/etc/config.toml"},
+		{Src: filepath.Dir(src), Dst: "/etc/config.toml"},
+		{Src: src, Dst: "etc/config.toml"},
+		{Src: src, Dst: "/etc/../config.toml"},
+		{Src: src, Dst: "/"},
+		{Src: src, Dst: "/etc/config.toml; reboot"},
+		{Src: src, Dst: "/etc/config.toml", Mode: 04755},
+		{Src: src, Dst: "/etc/config.toml", Roles: []string{"node"}},
+	}
+	for _, f := range invalids {
+		if err := checkExtraFiles([]*ExtraFile{f}); err == nil {
+			t.Fatalf("expect invalid extra file: %+v", f)
+		}
+	}
+	if err := checkExtraFiles([]*ExtraFile{valid[0], valid[0]}); err == nil {
+		t.Fatalf("expect duplicate dst of extra files")
+	}
 }
@@ -16,17 +16,18 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 
 	"isula.org/eggo/pkg/api"
-	"isula.org/eggo/pkg/clusterdeployment"
+	eggodeploy "isula.org/eggo/pkg/deploy"
 )
 
-func cleanup(ccfg *api.ClusterConfig) error {
-	return clusterdeployment.RemoveCluster(ccfg)
+func cleanup(ctx context.Context, ccfg *api.ClusterConfig) error {
+	return eggodeploy.Cleanup(ctx, ccfg)
 }
 
 func cleanupCluster(cmd *cobra.Command, args []string) error {
@@ -61,7 +62,7 @@ func cleanupCluster(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	hooksConf, err := getClusterHookConf(api.HookOpCleanup)
+	hooksConf, err := getClusterHookConf(conf, api.HookOpCleanup)
 	if err != nil {
 		return fmt.Errorf("get cmd hooks config failed:%v", err)
 	}
@@ -76,7 +77,16 @@ func cleanupCluster(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
-	if err = cleanup(toClusterdeploymentConfig(conf, hooksConf)); err != nil {
+	ccfg := toClusterdeploymentConfig(conf, hooksConf)
+	ccfg.Drain = api.DrainConfig{
+		GracePeriod: opts.drainGracePeriod,
+		Timeout:     opts.drainTimeout,
+		Force:       opts.drainForce,
+	}
+
+	ctx, cancel := newCommandContext()
+	defer cancel()
+	if err = cleanup(ctx, ccfg); err != nil {
 		return err
 	}
 
@@ -29,6 +29,7 @@ import (
 	"gopkg.in/yaml.v1"
 
 	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterconfig"
 	"isula.org/eggo/pkg/clusterdeployment/binary/coredns"
 	"isula.org/eggo/pkg/constants"
 	"isula.org/eggo/pkg/utils"
@@ -54,6 +55,14 @@ var (
 	}
 )
 
+func toEggoExtraFile(f *ExtraFile) api.ExtraFile {
+	ef := api.ExtraFile{Src: f.Src, Dst: f.Dst, Mode: uint32(f.Mode)}
+	for _, role := range f.Roles {
+		ef.Roles |= toTypeInt[role]
+	}
+	return ef
+}
+
 func ToEggoPackageConfig(pcs []*PackageConfig) []*api.PackageConfig {
 	var res []*api.PackageConfig
 	for _, pc := range pcs {
@@ -97,6 +106,14 @@ func eggoPlaceHolderPath(ClusterID string) string {
 	return filepath.Join(api.GetEggoClusterPath(), ClusterID, ".eggo.pid")
 }
 
+func clusterStatePath(ClusterID string) string {
+	return filepath.Join(api.GetEggoClusterPath(), ClusterID, "state.json")
+}
+
+func defaultKubeConfigOutPath(ClusterID string) string {
+	return filepath.Join(utils.GetEggoDir(), ClusterID, constants.KubeConfigFileNameUser)
+}
+
 func savedDeployConfigPath(ClusterID string) string {
 	return filepath.Join(api.GetEggoClusterPath(), ClusterID, "deploy.yaml")
 }
@@ -124,7 +141,8 @@ func saveDeployConfig(cc *DeployConfig, filePath string) error {
 }
 
 func fillEtcdsIfNotExist(cc *DeployConfig) {
-	if len(cc.Etcds) != 0 {
+	// external etcd is not deployed by eggo
+	if cc.EtcdExternal || len(cc.Etcds) != 0 {
 		return
 	}
 
@@ -141,6 +159,12 @@ func loadDeployConfig(file string) (*DeployConfig, error) {
 	if err := yaml.Unmarshal([]byte(yamlStr), conf); err != nil {
 		return nil, err
 	}
+	if err := checkStrictYaml(yamlStr, conf); err != nil {
+		return nil, fmt.Errorf("invalid deploy config %s: %v", file, err)
+	}
+	if err := normalizeDeployConfigArch(conf); err != nil {
+		return nil, err
+	}
 
 	// default install etcds to masters if etcds not configed
 	fillEtcdsIfNotExist(conf)
@@ -148,69 +172,91 @@ func loadDeployConfig(file string) (*DeployConfig, error) {
 	return conf, nil
 }
 
-func getDefaultClusterdeploymentConfig() *api.ClusterConfig {
-	return &api.ClusterConfig{
-		Name:      "k8s-cluster",
-		ConfigDir: constants.DefaultK8SRootDir,
-		Certificate: api.CertificateConfig{
-			SavePath: constants.DefaultK8SCertDir,
-		},
-		ServiceCluster: api.ServiceClusterConfig{
-			CIDR:    "10.32.0.0/16",
-			DNSAddr: "10.32.0.10",
-			Gateway: "10.32.0.1",
-		},
-		Network: api.NetworkConfig{
-			PodCIDR:    "10.244.0.0/16",
-			PluginArgs: make(map[string]string),
-		},
-		ControlPlane: api.ControlPlaneConfig{
-			APIConf: &api.APIServer{
-				Timeout: "120s",
-			},
-		},
-		WorkerConfig: api.WorkerConfig{
-			KubeletConf: &api.Kubelet{
-				DNSVip:        "10.32.0.10",
-				DNSDomain:     "cluster.local",
-				PauseImage:    "k8s.gcr.io/pause:3.2",
-				NetworkPlugin: "cni",
-				CniBinDir:     "/opt/cni/bin",
-				EnableServer:  false,
-			},
-			ContainerEngineConf: &api.ContainerEngine{
-				RegistryMirrors:    []string{},
-				InsecureRegistries: []string{},
-				ExtraArgs:          make(map[string]string),
-			},
-		},
-		PackageSrc: api.PackageSrcConfig{},
-		EtcdCluster: api.EtcdClusterConfig{
-			Token:    "etcd-cluster",
-			DataDir:  "/var/lib/etcd/default.etcd",
-			CertsDir: constants.DefaultK8SCertDir,
-			External: false,
-		},
-		DeployDriver: "binary",
-		RoleInfra:    infra.RegisterInfra(),
+func normalizeArchKeys(m map[string]string, name string) (map[string]string, error) {
+	if m == nil {
+		return nil, nil
+	}
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		arch, err := api.NormalizeArch(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arch of %s: %v", name, err)
+		}
+		if old, ok := result[arch]; ok && old != v {
+			return nil, fmt.Errorf("conflict %s for arch %s: %s and %s", name, arch, old, v)
+		}
+		result[arch] = v
+	}
+	return result, nil
+}
+
+// normalizeDeployConfigArch converts arch of nodes and package source to canonical arch,
+// so that each node is mapped to package source of its arch
+func normalizeDeployConfigArch(conf *DeployConfig) error {
+	var hosts []*HostConfig
+	hosts = append(hosts, conf.Masters...)
+	hosts = append(hosts, conf.Workers...)
+	hosts = append(hosts, conf.Etcds...)
+	for _, h := range hosts {
+		if h == nil {
+			continue
+		}
+		arch, err := api.NormalizeArch(h.Arch)
+		if err != nil {
+			return fmt.Errorf("invalid arch of node %s: %v", h.Ip, err)
+		}
+		h.Arch = arch
+	}
+	if conf.LoadBalance.Ip != "" {
+		arch, err := api.NormalizeArch(conf.LoadBalance.Arch)
+		if err != nil {
+			return fmt.Errorf("invalid arch of loadbalance %s: %v", conf.LoadBalance.Ip, err)
+		}
+		conf.LoadBalance.Arch = arch
+	}
+
+	if conf.InstallConfig.PackageSrc == nil {
+		return nil
+	}
+	var err error
+	if conf.InstallConfig.PackageSrc.SrcPath, err = normalizeArchKeys(conf.InstallConfig.PackageSrc.SrcPath, "package source"); err != nil {
+		return err
+	}
+	if conf.InstallConfig.PackageSrc.Sha256, err = normalizeArchKeys(conf.InstallConfig.PackageSrc.Sha256, "package sha256"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// canonicalArch returns canonical arch for config of cluster deployment, which may be
+// converted from deploy config not loaded from file, unsupported arch is left to checker
+func canonicalArch(arch string) string {
+	if a, err := api.NormalizeArch(arch); err == nil {
+		return a
 	}
+	return strings.ToLower(arch)
 }
 
 func getDefaultPrivateKeyPath() string {
 	return filepath.Join(utils.GetSysHome(), ".ssh", "id_rsa")
 }
 
+func getDefaultKnownHostsPath() string {
+	return filepath.Join(utils.GetSysHome(), ".ssh", "known_hosts")
+}
+
 func createCommonHostConfig(userHostconfig *HostConfig, defaultName string, username string,
-	password string, userPrivateKeyPath string) *api.HostConfig {
-	arch, name, port, privateKeyPath := "amd64", defaultName, 22, getDefaultPrivateKeyPath()
-	if userHostconfig.Arch != "" {
-		arch = userHostconfig.Arch
+	password string, userPrivateKeyPath string, useSSHAgent bool, sudoPassword string, elevate string) *api.HostConfig {
+	arch, name, port, privateKeyPath := canonicalArch(userHostconfig.Arch), defaultName, 22, userPrivateKeyPath
+	if userHostconfig.SudoPassword != "" {
+		sudoPassword = userHostconfig.SudoPassword
 	}
 	if userHostconfig.Name != "" {
 		name = userHostconfig.Name
 	}
-	if userPrivateKeyPath != "" {
-		privateKeyPath = userPrivateKeyPath
+	// default private key is not a fall back of ssh agent, unavailable agent must be reported
+	if privateKeyPath == "" && !useSSHAgent {
+		privateKeyPath = getDefaultPrivateKeyPath()
 	}
 	// If private key path does not exist, ignore it
 	if _, err := os.Stat(privateKeyPath); err != nil {
@@ -228,6 +274,13 @@ func createCommonHostConfig(userHostconfig *HostConfig, defaultName string, user
 		UserName:       username,
 		Password:       password,
 		PrivateKeyPath: privateKeyPath,
+		UseSSHAgent:    useSSHAgent,
+		SudoPassword:   sudoPassword,
+		Elevate:        elevate,
+		PackageManager: userHostconfig.PackageManager,
+		Local:          userHostconfig.Local,
+		Labels:         userHostconfig.Labels,
+		Taints:         userHostconfig.Taints,
 	}
 
 	return hostconfig
@@ -260,10 +313,14 @@ func appendSoftware(software, packageConfig, defaultPackage []*api.PackageConfig
 
 func fillPackageConfig(ccfg *api.ClusterConfig, icfg *InstallConfig) {
 	ccfg.PackageSrc.SrcPath = make(map[string]string)
+	ccfg.PackageSrc.Sha256 = make(map[string]string)
 	if icfg.PackageSrc != nil {
 		setIfStrConfigNotEmpty(&ccfg.PackageSrc.Type, icfg.PackageSrc.Type)
 		for arch, path := range icfg.PackageSrc.SrcPath {
-			ccfg.PackageSrc.SrcPath[strings.ToLower(arch)] = path
+			ccfg.PackageSrc.SrcPath[canonicalArch(arch)] = path
+		}
+		for arch, sum := range icfg.PackageSrc.Sha256 {
+			ccfg.PackageSrc.Sha256[canonicalArch(arch)] = strings.ToLower(sum)
 		}
 	}
 
@@ -316,6 +373,14 @@ func fillOpenPort(ccfg *api.ClusterConfig, openports map[string][]*OpenPorts, dn
 		ccfg.RoleInfra[role].OpenPorts = append(ccfg.RoleInfra[role].OpenPorts, ToEggoOpenPort(p)...)
 	}
 
+	// node ports of services are served by kube-proxy on workers
+	if base, max, err := api.ParsePortRange(ccfg.GetNodePortRange()); err == nil {
+		for _, proto := range []string{"tcp", "udp"} {
+			ccfg.RoleInfra[api.Worker].OpenPorts = append(ccfg.RoleInfra[api.Worker].OpenPorts,
+				&api.OpenPorts{Port: base, EndPort: max, Protocol: proto})
+		}
+	}
+
 	if coredns.IsTypeBinary(dnsType) {
 		ccfg.RoleInfra[api.Master].OpenPorts =
 			append(ccfg.RoleInfra[api.Master].OpenPorts, infra.CorednsPorts...)
@@ -364,6 +429,11 @@ func createHostConfig(host *HostConfig, joinHost *HostConfig, defaultName string
 		hostconfig.Name = host.Name
 		hostconfig.Arch = host.Arch
 		hostconfig.Port = host.Port
+		hostconfig.Labels = host.Labels
+		hostconfig.Taints = host.Taints
+		hostconfig.SudoPassword = host.SudoPassword
+		hostconfig.PackageManager = host.PackageManager
+		hostconfig.Local = host.Local
 	} else {
 		hostconfig.Name = defaultName
 		if joinHost.Name != "" {
@@ -377,89 +447,44 @@ func createHostConfig(host *HostConfig, joinHost *HostConfig, defaultName string
 		if joinHost.Port != 0 {
 			hostconfig.Port = joinHost.Port
 		}
+		hostconfig.Labels = joinHost.Labels
+		hostconfig.Taints = joinHost.Taints
+		hostconfig.SudoPassword = joinHost.SudoPassword
+		hostconfig.PackageManager = joinHost.PackageManager
+		hostconfig.Local = joinHost.Local
 	}
 	hostconfig.Ip = joinHost.Ip
 
 	return &hostconfig
 }
 
-func fillHostConfig(ccfg *api.ClusterConfig, conf *DeployConfig) {
-	var hostconfig *api.HostConfig
-	cache := make(map[string]int)
-	var nodes []*api.HostConfig
-
+func fillHostConfig(builder *clusterconfig.ClusterConfigBuilder, conf *DeployConfig) {
 	for i, master := range conf.Masters {
-		hostconfig = createCommonHostConfig(master, conf.ClusterID+"-master-"+strconv.Itoa(i),
-			conf.Username, conf.Password, conf.PrivateKeyPath)
-		hostconfig.Type |= api.Master
-		idx, ok := cache[hostconfig.Address]
-		if ok {
-			nodes[idx] = hostconfig
-			continue
-		}
-		cache[hostconfig.Address] = len(nodes)
-		nodes = append(nodes, hostconfig)
+		builder.AddMaster(createCommonHostConfig(master, conf.ClusterID+"-master-"+strconv.Itoa(i),
+			conf.Username, conf.Password, conf.PrivateKeyPath, conf.UseSSHAgent, conf.SudoPassword, conf.Elevate))
 	}
 
 	for i, worker := range conf.Workers {
-		idx, exist := cache[worker.Ip]
-		if !exist {
-			hostconfig = createCommonHostConfig(worker, conf.ClusterID+"-worker-"+strconv.Itoa(i),
-				conf.Username, conf.Password, conf.PrivateKeyPath)
-		} else {
-			hostconfig = nodes[idx]
-		}
-		hostconfig.Type |= api.Worker
-		if exist {
-			nodes[idx] = hostconfig
-			continue
-		}
-		cache[hostconfig.Address] = len(nodes)
-		nodes = append(nodes, hostconfig)
+		builder.AddWorker(createCommonHostConfig(worker, conf.ClusterID+"-worker-"+strconv.Itoa(i),
+			conf.Username, conf.Password, conf.PrivateKeyPath, conf.UseSSHAgent, conf.SudoPassword, conf.Elevate))
 	}
 
 	for i, etcd := range conf.Etcds {
-		idx, exist := cache[etcd.Ip]
-		if !exist {
-			hostconfig = createCommonHostConfig(etcd, conf.ClusterID+"-etcd-"+strconv.Itoa(i),
-				conf.Username, conf.Password, conf.PrivateKeyPath)
-		} else {
-			hostconfig = nodes[idx]
-		}
-		hostconfig.Type |= api.ETCD
-		if exist {
-			nodes[idx] = hostconfig
-			continue
-		}
-		cache[hostconfig.Address] = len(nodes)
-		nodes = append(nodes, hostconfig)
+		builder.AddEtcd(createCommonHostConfig(etcd, conf.ClusterID+"-etcd-"+strconv.Itoa(i),
+			conf.Username, conf.Password, conf.PrivateKeyPath, conf.UseSSHAgent, conf.SudoPassword, conf.Elevate))
 	}
 
 	if conf.LoadBalance.Ip != "" {
-		idx, exist := cache[conf.LoadBalance.Ip]
-		if !exist {
-			config := &HostConfig{
-				Name: conf.LoadBalance.Name,
-				Ip:   conf.LoadBalance.Ip,
-				Port: conf.LoadBalance.Port,
-				Arch: conf.LoadBalance.Arch,
-			}
-			hostconfig = createCommonHostConfig(config, conf.ClusterID+"-loadbalance", conf.Username,
-				conf.Password, conf.PrivateKeyPath)
-		} else {
-			hostconfig = nodes[idx]
-		}
-		hostconfig.Type |= api.LoadBalance
-
-		if exist {
-			nodes[idx] = hostconfig
-		} else {
-			cache[hostconfig.Address] = len(nodes)
-			nodes = append(nodes, hostconfig)
+		config := &HostConfig{
+			Name: conf.LoadBalance.Name,
+			Ip:   conf.LoadBalance.Ip,
+			Port: conf.LoadBalance.Port,
+			Arch: conf.LoadBalance.Arch,
 		}
+		builder.AddLoadBalance(createCommonHostConfig(config, conf.ClusterID+"-loadbalance", conf.Username,
+			conf.Password, conf.PrivateKeyPath, conf.UseSSHAgent, conf.SudoPassword, conf.Elevate), conf.LoadBalance.BindPort)
+		builder.WithLoadBalanceVIP(conf.LoadBalance.VIP, conf.LoadBalance.Interface, conf.LoadBalance.VRID)
 	}
-
-	ccfg.Nodes = append(ccfg.Nodes, nodes...)
 }
 
 func setIfStrConfigNotEmpty(config *string, userConfig string) {
@@ -472,16 +497,6 @@ func setIfStrConfigNotEmpty(config *string, userConfig string) {
 	}
 }
 
-func setStrStrMap(config map[string]string, userConfig map[string]string) {
-	if config == nil {
-		logrus.Errorf("invalid nil config")
-		return
-	}
-	for k, v := range userConfig {
-		config[k] = v
-	}
-}
-
 func notInStrArray(arr []string, val string) bool {
 	for _, v := range arr {
 		if v == val {
@@ -499,57 +514,39 @@ func setStrArray(config *[]string, userConfig []string) {
 	}
 }
 
-func fillLoadBalance(LoadBalancer *api.LoadBalancer, lb LoadBalance) {
-	if lb.Ip == "" || lb.BindPort <= 0 {
+// api endpoint is loadbalance or first master if not set
+func fillAPIEndPoint(builder *clusterconfig.ClusterConfigBuilder, conf *DeployConfig) {
+	if conf.ApiServerEndpoint == "" {
 		return
 	}
 
-	setIfStrConfigNotEmpty(&LoadBalancer.IP, lb.Ip)
-	setIfStrConfigNotEmpty(&LoadBalancer.Port, strconv.Itoa(lb.BindPort))
-}
-
-func fillAPIEndPoint(APIEndpoint *api.APIEndpoint, conf *DeployConfig) {
-	host, port := "", ""
-	if conf.ApiServerEndpoint != "" {
-		var err error
-		host, port, err = net.SplitHostPort(conf.ApiServerEndpoint)
-		if err != nil {
-			logrus.Errorf("invalid api endpoint %s: %v", conf.ApiServerEndpoint, err)
-		}
-	}
-
-	if host == "" || port == "" {
-		host, port = conf.LoadBalance.Ip, strconv.Itoa(conf.LoadBalance.BindPort)
-	}
-	if (host == "" || port == "") && len(conf.Masters) != 0 {
-		host = conf.Masters[0].Ip
-		port = "6443"
-	}
-
-	if host == "" || port == "" {
+	host, port, err := net.SplitHostPort(conf.ApiServerEndpoint)
+	if err != nil {
+		logrus.Errorf("invalid api endpoint %s: %v", conf.ApiServerEndpoint, err)
 		return
 	}
-
 	iport, err := strconv.ParseInt(port, parseBase, parseBitSize)
 	if err != nil {
 		logrus.Errorf("invalid port %s: %v", port, err)
 		return
 	}
 
-	APIEndpoint.AdvertiseAddress = host
-	APIEndpoint.BindPort = int32(iport)
+	builder.WithAPIEndpoint(host, int32(iport))
 }
 
 func fillExtrArgs(ccfg *api.ClusterConfig, eargs []*ConfigExtraArgs) {
 	for _, ea := range eargs {
+		if ea == nil {
+			continue
+		}
 		switch ea.Name {
 		case "etcd":
 			api.WithEtcdExtrArgs(ea.ExtraArgs)(ccfg)
-		case "kube-apiserver":
+		case "kube-apiserver", "apiserver":
 			api.WithAPIServerExtrArgs(ea.ExtraArgs)(ccfg)
-		case "kube-controller-manager":
+		case "kube-controller-manager", "controller-manager":
 			api.WithControllerManagerExtrArgs(ea.ExtraArgs)(ccfg)
-		case "kube-scheduler":
+		case "kube-scheduler", "scheduler":
 			api.WithSchedulerExtrArgs(ea.ExtraArgs)(ccfg)
 		case "kube-proxy":
 			api.WithKubeProxyExtrArgs(ea.ExtraArgs)(ccfg)
@@ -563,32 +560,89 @@ func fillExtrArgs(ccfg *api.ClusterConfig, eargs []*ConfigExtraArgs) {
 	}
 }
 
+// getExtraArgs returns merged extra args of component in deploy config
+func getExtraArgs(conf *DeployConfig, names ...string) map[string]string {
+	args := make(map[string]string)
+	for _, ea := range conf.ConfigExtraArgs {
+		if ea == nil {
+			continue
+		}
+		for _, name := range names {
+			if ea.Name == name {
+				for k, v := range ea.ExtraArgs {
+					args[k] = v
+				}
+			}
+		}
+	}
+	return args
+}
+
+// getCgroupDriver returns cgroup driver of kubelet, "--cgroup-driver" in extra args of kubelet is used if it is not set
+func getCgroupDriver(conf *DeployConfig) string {
+	if conf.CgroupDriver != "" {
+		return conf.CgroupDriver
+	}
+	return getExtraArgs(conf, "kubelet")["--cgroup-driver"]
+}
+
+// ToClusterConfig checks deploy config and converts it to config of cluster deployment with hooks of op,
+// for callers of library which do not load config from file, hooks of command line are not included
+func ToClusterConfig(conf *DeployConfig, op api.HookOperator) (*api.ClusterConfig, error) {
+	if conf == nil {
+		return nil, fmt.Errorf("deploy config is nil")
+	}
+	if err := normalizeDeployConfigArch(conf); err != nil {
+		return nil, err
+	}
+	fillEtcdsIfNotExist(conf)
+	if err := RunChecker(conf); err != nil {
+		return nil, err
+	}
+
+	hooks, err := getConfigHookConf(conf, op)
+	if err != nil {
+		return nil, fmt.Errorf("get hooks config failed: %v", err)
+	}
+	return toClusterdeploymentConfig(conf, hooks), nil
+}
+
 func toClusterdeploymentConfig(conf *DeployConfig, hooks []*api.ClusterHookConf) *api.ClusterConfig {
-	ccfg := getDefaultClusterdeploymentConfig()
+	builder := clusterconfig.NewClusterConfigBuilder().WithName(conf.ClusterID)
+	fillHostConfig(builder, conf)
+	fillAPIEndPoint(builder, conf)
+	builder.WithServiceCluster(conf.Service.CIDR, conf.Service.DNSAddr, conf.Service.Gateway).
+		WithNetwork(conf.NetWork.PodCIDR, conf.NetWork.Plugin, conf.NetWork.PluginArgs)
+	ccfg := builder.Config()
 
-	setIfStrConfigNotEmpty(&ccfg.Name, conf.ClusterID)
-	fillHostConfig(ccfg, conf)
 	ccfg.Certificate.ExternalCA = conf.ExternalCA
 	setIfStrConfigNotEmpty(&ccfg.Certificate.ExternalCAPath, conf.ExternalCAPath)
-	setIfStrConfigNotEmpty(&ccfg.ServiceCluster.CIDR, conf.Service.CIDR)
-	setIfStrConfigNotEmpty(&ccfg.ServiceCluster.DNSAddr, conf.Service.DNSAddr)
-	setIfStrConfigNotEmpty(&ccfg.ServiceCluster.Gateway, conf.Service.Gateway)
+	setIfStrConfigNotEmpty(&ccfg.Certificate.ExternalKubernetesCAPath, conf.ExternalK8sCAPath)
+	setIfStrConfigNotEmpty(&ccfg.Certificate.ExternalEtcdCAPath, conf.ExternalEtcdCAPath)
+	setIfStrConfigNotEmpty(&ccfg.Certificate.ExternalFrontProxyCAPath, conf.ExternalProxyCAPath)
+	setIfStrConfigNotEmpty(&ccfg.ServiceCluster.NodePortRange, conf.Service.NodePortRange)
 	setIfStrConfigNotEmpty(&ccfg.ServiceCluster.DNS.CorednsType, conf.Service.DNS.CorednsType)
 	setIfStrConfigNotEmpty(&ccfg.ServiceCluster.DNS.ImageVersion, conf.Service.DNS.ImageVersion)
 	ccfg.ServiceCluster.DNS.Replicas = conf.Service.DNS.Replicas
-	setIfStrConfigNotEmpty(&ccfg.Network.PodCIDR, conf.NetWork.PodCIDR)
-	setIfStrConfigNotEmpty(&ccfg.Network.Plugin, conf.NetWork.Plugin)
-	setStrStrMap(ccfg.Network.PluginArgs, conf.NetWork.PluginArgs)
+	setIfStrConfigNotEmpty(&ccfg.ServiceCluster.DNS.Domain, conf.Service.DNS.Domain)
+	setIfStrConfigNotEmpty(&ccfg.ServiceCluster.DNS.CPURequest, conf.Service.DNS.CPURequest)
+	setIfStrConfigNotEmpty(&ccfg.ServiceCluster.DNS.CPULimit, conf.Service.DNS.CPULimit)
+	setIfStrConfigNotEmpty(&ccfg.ServiceCluster.DNS.MemoryRequest, conf.Service.DNS.MemoryRequest)
+	setIfStrConfigNotEmpty(&ccfg.ServiceCluster.DNS.MemoryLimit, conf.Service.DNS.MemoryLimit)
+	ccfg.ServiceCluster.DNS.UpstreamServers = conf.Service.DNS.UpstreamServers
 	setStrArray(&ccfg.ControlPlane.APIConf.CertSans.DNSNames, conf.ApiServerCertSans.DNSNames)
 	setStrArray(&ccfg.ControlPlane.APIConf.CertSans.IPs, conf.ApiServerCertSans.IPs)
 	setIfStrConfigNotEmpty(&ccfg.ControlPlane.APIConf.Timeout, conf.ApiServerTimeout)
+	ccfg.ControlPlane.ScheduleWorkloadsOnMaster = conf.ScheduleOnMaster
 	ccfg.EtcdCluster.External = conf.EtcdExternal
-	for _, node := range ccfg.Nodes {
-		if (node.Type & api.ETCD) != 0 {
-			ccfg.EtcdCluster.Nodes = append(ccfg.EtcdCluster.Nodes, node)
-		}
-	}
+	setStrArray(&ccfg.EtcdCluster.ExternalEndpoints, conf.EtcdEndpoints)
+	setIfStrConfigNotEmpty(&ccfg.EtcdCluster.ExternalCAFile, conf.EtcdCAFile)
+	setIfStrConfigNotEmpty(&ccfg.EtcdCluster.ExternalCertFile, conf.EtcdCertFile)
+	setIfStrConfigNotEmpty(&ccfg.EtcdCluster.ExternalKeyFile, conf.EtcdKeyFile)
 	setIfStrConfigNotEmpty(&ccfg.EtcdCluster.Token, conf.EtcdToken)
+	ccfg.EtcdCluster.ClientPort = conf.EtcdClientPort
+	ccfg.EtcdCluster.PeerPort = conf.EtcdPeerPort
+	ccfg.EtcdCluster.MetricsPort = conf.EtcdMetricsPort
 	setIfStrConfigNotEmpty(&ccfg.WorkerConfig.KubeletConf.DNSVip, conf.DnsVip)
 	setIfStrConfigNotEmpty(&ccfg.WorkerConfig.KubeletConf.DNSDomain, conf.DnsDomain)
 	setIfStrConfigNotEmpty(&ccfg.WorkerConfig.KubeletConf.PauseImage, conf.PauseImage)
@@ -596,22 +650,115 @@ func toClusterdeploymentConfig(conf *DeployConfig, hooks []*api.ClusterHookConf)
 	setIfStrConfigNotEmpty(&ccfg.WorkerConfig.KubeletConf.CniBinDir, conf.CniBinDir)
 	setIfStrConfigNotEmpty(&ccfg.WorkerConfig.ContainerEngineConf.Runtime, conf.Runtime)
 	setIfStrConfigNotEmpty(&ccfg.WorkerConfig.ContainerEngineConf.RuntimeEndpoint, conf.RuntimeEndpoint)
+	ccfg.WorkerConfig.ContainerEngineConf.ImagePackage = conf.ImagePackage
 	setStrArray(&ccfg.WorkerConfig.ContainerEngineConf.RegistryMirrors, conf.RegistryMirrors)
 	setStrArray(&ccfg.WorkerConfig.ContainerEngineConf.InsecureRegistries, conf.InsecureRegistries)
-	fillLoadBalance(&ccfg.LoadBalancer, conf.LoadBalance)
-	fillAPIEndPoint(&ccfg.APIEndpoint, conf)
+	for _, a := range conf.RegistryAuths {
+		ccfg.WorkerConfig.ContainerEngineConf.RegistryAuths = append(ccfg.WorkerConfig.ContainerEngineConf.RegistryAuths,
+			api.RegistryAuth{Registry: a.Registry, Username: a.Username, Password: a.Password})
+	}
 	fillPackageConfig(ccfg, &conf.InstallConfig)
 	fillOpenPort(ccfg, conf.OpenPorts, conf.Service.DNS.CorednsType, conf.LoadBalance)
 	ccfg.WorkerConfig.KubeletConf.EnableServer = conf.EnableKubeletServing
 
 	fillExtrArgs(ccfg, conf.ConfigExtraArgs)
+	setIfStrConfigNotEmpty(&ccfg.WorkerConfig.KubeletConf.CgroupDriver, getCgroupDriver(conf))
 	ccfg.HooksConf = hooks
 
+	ccfg.ImageRepository = conf.ImageRepository
+	ccfg.SSHHostKey = api.SSHHostKeyConfig{
+		Checking:       conf.HostKeyChecking,
+		KnownHostsPath: conf.KnownHostsPath,
+	}
+	if ccfg.SSHHostKey.KnownHostsPath == "" {
+		ccfg.SSHHostKey.KnownHostsPath = getDefaultKnownHostsPath()
+	}
+	ccfg.SSHKeepAlive.Interval = conf.SSHKeepAliveInterval
+	ccfg.ConnectTimeout = conf.SSHConnectTimeout
+	ccfg.KubernetesVersion = conf.KubernetesVersion
+	for _, a := range conf.Addons {
+		ccfg.Addons = append(ccfg.Addons, &api.AddonConfig{
+			Name:     a.Name,
+			Type:     a.Type,
+			Filename: a.Filename,
+			URL:      a.URL,
+			Sha256:   a.Sha256,
+			Content:  a.Content,
+		})
+	}
+	for _, h := range conf.HostAliases {
+		ccfg.HostAliases = append(ccfg.HostAliases, api.HostAlias{IP: h.IP, Hostnames: h.Hostnames})
+	}
+	for _, r := range conf.Repos {
+		ccfg.Repos = append(ccfg.Repos, api.PackageRepo{Name: r.Name, BaseURL: r.BaseURL, GPGKey: r.GPGKey})
+	}
+	for _, f := range conf.Files {
+		ccfg.Files = append(ccfg.Files, toEggoExtraFile(f))
+	}
+	ccfg.SystemdDropIns = conf.SystemdDropIns
+	ccfg.Encryption = api.EncryptionConfig{Provider: conf.Encryption.Provider, Resources: conf.Encryption.Resources}
+	ccfg.Audit = api.AuditConfig{
+		Enabled:    conf.Audit.Enabled,
+		PolicyFile: conf.Audit.PolicyFile,
+		Policy:     conf.Audit.Policy,
+		LogPath:    conf.Audit.LogPath,
+		MaxAge:     conf.Audit.MaxAge,
+		MaxBackup:  conf.Audit.MaxBackup,
+		MaxSize:    conf.Audit.MaxSize,
+	}
+	// dirs set by command line override deploy config for this run
+	setIfStrConfigNotEmpty(&ccfg.ConfigDir, conf.ConfigDir)
+	setIfStrConfigNotEmpty(&ccfg.ConfigDir, opts.configDir)
+	setIfStrConfigNotEmpty(&ccfg.Certificate.SavePath, conf.CertDir)
+	setIfStrConfigNotEmpty(&ccfg.Certificate.SavePath, opts.certDir)
+	ccfg.FailFast = opts.failFast
+
 	return ccfg
 }
 
-func getClusterHookConf(op api.HookOperator) ([]*api.ClusterHookConf, error) {
+// getConfigHookConf returns hooks in deploy config of operator, pre hooks before post hooks
+func getConfigHookConf(conf *DeployConfig, op api.HookOperator) ([]*api.ClusterHookConf, error) {
+	var pre, post []*HookConfig
+	switch op {
+	case api.HookOpDeploy:
+		pre, post = conf.Hooks.PreDeploy, conf.Hooks.PostDeploy
+	case api.HookOpCleanup:
+		pre, post = conf.Hooks.PreCleanup, conf.Hooks.PostCleanup
+	default:
+		return nil, nil
+	}
+
 	var hooks []*api.ClusterHookConf
+	phases := []struct {
+		ty    api.HookType
+		confs []*HookConfig
+	}{
+		{ty: api.ClusterPrehookType, confs: pre},
+		{ty: api.ClusterPosthookType, confs: post},
+	}
+	for _, phase := range phases {
+		for _, h := range phase.confs {
+			var target uint16 = api.Master | api.Worker | api.ETCD | api.LoadBalance
+			if h.Role != "" {
+				target = toTypeInt[h.Role]
+			}
+			hook, err := getResolvedHook(h.Path, phase.ty, op, target)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hook %s: %v", h.Path, err)
+			}
+			hook.IgnoreFailure = h.IgnoreFailure
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+func getClusterHookConf(conf *DeployConfig, op api.HookOperator) ([]*api.ClusterHookConf, error) {
+	// hooks of config run before hooks of command line
+	hooks, err := getConfigHookConf(conf, op)
+	if err != nil {
+		return nil, err
+	}
 
 	if opts.clusterPrehook != "" {
 		hook, err := getCmdHooks(opts.clusterPrehook, api.ClusterPrehookType, op)
@@ -744,7 +891,92 @@ func getHostconfigs(format string, ips []string) []*HostConfig {
 	return confs
 }
 
-func createDeployConfigTemplate(file string) error {
+// minimalDeployConfig only contains required fields of DeployConfig, others use defaults of eggo
+type minimalDeployConfig struct {
+	ClusterID      string        `yaml:"cluster-id"`
+	Username       string        `yaml:"username"`
+	Password       string        `yaml:"password"`
+	PrivateKeyPath string        `yaml:"private-key-path"`
+	Masters        []*HostConfig `yaml:"masters"`
+	Workers        []*HostConfig `yaml:"workers"`
+	Etcds          []*HostConfig `yaml:"etcds,omitempty"`
+	InstallConfig  struct {
+		PackageSrc *PackageSrcConfig `yaml:"package-source"`
+	} `yaml:"install"`
+}
+
+type templateHosts struct {
+	masters []*HostConfig
+	workers []*HostConfig
+	etcds   []*HostConfig
+	lb      LoadBalance
+}
+
+func templatePackageSrc() *PackageSrcConfig {
+	return &PackageSrcConfig{
+		Type: "tar.gz",
+		SrcPath: map[string]string{
+			"arm64": "/root/packages/packages-arm64.tar.gz",
+			"amd64": "/root/packages/packages-amd64.tar.gz",
+		},
+	}
+}
+
+func writeDeployConfigTemplate(file string, conf interface{}) error {
+	d, err := yaml.Marshal(conf)
+	if err != nil {
+		return fmt.Errorf("marshal template config failed: %v", err)
+	}
+
+	if err := ioutil.WriteFile(file, d, constants.DeployConfigFileMode); err != nil {
+		return fmt.Errorf("write template config file failed: %v", err)
+	}
+
+	return nil
+}
+
+func createMinimalDeployConfigTemplate(file string) error {
+	hosts := getTemplateHosts()
+	conf := &minimalDeployConfig{
+		ClusterID:      opts.name,
+		Username:       opts.username,
+		Password:       opts.password,
+		PrivateKeyPath: getDefaultPrivateKeyPath(),
+		Masters:        hosts.masters,
+		Workers:        hosts.workers,
+	}
+	// etcds are deployed on masters by default
+	if opts.etcds != nil {
+		conf.Etcds = hosts.etcds
+	}
+	conf.InstallConfig.PackageSrc = templatePackageSrc()
+
+	return writeDeployConfigTemplate(file, conf)
+}
+
+// createSingleNodeDeployConfigTemplate creates config of all-in-one cluster, master, worker and etcd
+// are deployed on the same host, which is the host eggo runs on
+func createSingleNodeDeployConfigTemplate(file string) error {
+	ip := "192.168.0.2"
+	if len(opts.masters) > 0 {
+		ip = opts.masters[0]
+	}
+	host := getHostconfigs("k8s-master-%d", []string{ip})[0]
+	host.Local = true
+	conf := &minimalDeployConfig{
+		ClusterID:      opts.name,
+		Username:       opts.username,
+		Password:       opts.password,
+		PrivateKeyPath: getDefaultPrivateKeyPath(),
+		Masters:        []*HostConfig{host},
+		Workers:        []*HostConfig{host},
+	}
+	conf.InstallConfig.PackageSrc = templatePackageSrc()
+
+	return writeDeployConfigTemplate(file, conf)
+}
+
+func getTemplateHosts() templateHosts {
 	var masters, workers, etcds []*HostConfig
 	masterIP := []string{"192.168.0.2"}
 	if opts.masters != nil {
@@ -778,6 +1010,17 @@ func createDeployConfigTemplate(file string) error {
 	if etcds == nil {
 		etcds = masters
 	}
+	return templateHosts{
+		masters: masters,
+		workers: workers,
+		etcds:   etcds,
+		lb:      lb,
+	}
+}
+
+func createDeployConfigTemplate(file string) error {
+	hosts := getTemplateHosts()
+	masters, workers, etcds, lb := hosts.masters, hosts.workers, hosts.etcds, hosts.lb
 	conf := &DeployConfig{
 		ClusterID:      opts.name,
 		Username:       opts.username,
@@ -841,13 +1084,7 @@ func createDeployConfigTemplate(file string) error {
 			},
 		},
 		InstallConfig: InstallConfig{
-			PackageSrc: &PackageSrcConfig{
-				Type: "tar.gz",
-				SrcPath: map[string]string{
-					"arm64": "/root/packages/packages-arm64.tar.gz",
-					"amd64": "/root/packages/packages-amd64.tar.gz",
-				},
-			},
+			PackageSrc: templatePackageSrc(),
 			KubernetesMaster: []*PackageConfig{
 				{
 					Name: "kubernetes-client,kubernetes-master",
@@ -936,14 +1173,5 @@ func createDeployConfigTemplate(file string) error {
 		},
 	}
 
-	d, err := yaml.Marshal(conf)
-	if err != nil {
-		return fmt.Errorf("marshal template config failed: %v", err)
-	}
-
-	if err := ioutil.WriteFile(file, d, constants.DeployConfigFileMode); err != nil {
-		return fmt.Errorf("write template config file failed: %v", err)
-	}
-
-	return nil
+	return writeDeployConfigTemplate(file, conf)
 }
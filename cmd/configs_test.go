@@ -20,11 +20,13 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v1"
 
 	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner"
 )
 
 func TestCmdConfigs(t *testing.T) {
@@ -61,8 +63,578 @@ func TestCmdConfigs(t *testing.T) {
 
 	fmt.Printf("%v\n", string(d))
 
+	// check images with image repository, pause image is replaced by deployment driver
+	conf.ImageRepository = "hub.example.com:5000/k8s"
+	ccfg = toClusterdeploymentConfig(conf, nil)
+	if ccfg.WorkerConfig.KubeletConf.PauseImage != "k8s.gcr.io/pause:3.2" {
+		t.Fatalf("expect pause image not replaced in config: %s", ccfg.WorkerConfig.KubeletConf.PauseImage)
+	}
+	if image := ccfg.GetImage(ccfg.WorkerConfig.KubeletConf.PauseImage); image != "hub.example.com:5000/k8s/pause:3.2" {
+		t.Fatalf("invalid pause image with image repository: %s", image)
+	}
+	if image := ccfg.GetImage("coredns/coredns:1.8.4"); image != "hub.example.com:5000/k8s/coredns/coredns:1.8.4" {
+		t.Fatalf("invalid coredns image with image repository: %s", image)
+	}
+	conf.ImageRepository = ""
+
 	api.EggoHomePath = tempdir
 	if err := saveDeployConfig(conf, savedDeployConfigPath(conf.ClusterID)); err != nil {
 		t.Fatalf("save deploy config to file failed: %v", err)
 	}
 }
+
+func TestConfigExtraArgs(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "cmd-extra-args-test-")
+	if err != nil {
+		t.Fatalf("create tempdir for cmd configs failed: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	f := filepath.Join(tempdir, "config.yaml")
+	if err = createDeployConfigTemplate(f); err != nil {
+		t.Fatalf("create deploy template config file failed: %v", err)
+	}
+	conf, err := loadDeployConfig(f)
+	if err != nil {
+		t.Fatalf("load deploy config file failed: %v", err)
+	}
+
+	components := []string{"etcd", "kube-apiserver", "kube-controller-manager", "kube-scheduler", "kube-proxy", "kubelet", "container-engine"}
+	conf.ConfigExtraArgs = nil
+	for _, c := range components {
+		conf.ConfigExtraArgs = append(conf.ConfigExtraArgs, &ConfigExtraArgs{
+			Name:      c,
+			ExtraArgs: map[string]string{"--component": c},
+		})
+	}
+	// same component will be merged
+	conf.ConfigExtraArgs = append(conf.ConfigExtraArgs, &ConfigExtraArgs{
+		Name:      "apiserver",
+		ExtraArgs: map[string]string{"--merged": "true"},
+	})
+	conf.ConfigExtraArgs = append(conf.ConfigExtraArgs, &ConfigExtraArgs{
+		Name:      "unknown",
+		ExtraArgs: map[string]string{"--component": "unknown"},
+	})
+
+	// round trip of deploy config
+	api.EggoHomePath = tempdir
+	savedPath := savedDeployConfigPath(conf.ClusterID)
+	if err = saveDeployConfig(conf, savedPath); err != nil {
+		t.Fatalf("save deploy config to file failed: %v", err)
+	}
+	if conf, err = loadDeployConfig(savedPath); err != nil {
+		t.Fatalf("load deploy config file failed: %v", err)
+	}
+
+	ccfg := toClusterdeploymentConfig(conf, nil)
+	got := map[string]map[string]string{
+		"etcd":                    ccfg.EtcdCluster.ExtraArgs,
+		"kube-apiserver":          ccfg.ControlPlane.APIConf.ExtraArgs,
+		"kube-controller-manager": ccfg.ControlPlane.ManagerConf.ExtraArgs,
+		"kube-scheduler":          ccfg.ControlPlane.SchedulerConf.ExtraArgs,
+		"kube-proxy":              ccfg.WorkerConfig.ProxyConf.ExtraArgs,
+		"kubelet":                 ccfg.WorkerConfig.KubeletConf.ExtraArgs,
+		"container-engine":        ccfg.WorkerConfig.ContainerEngineConf.ExtraArgs,
+	}
+	for _, c := range components {
+		if got[c]["--component"] != c {
+			t.Fatalf("expect extra args of %s, get: %v", c, got[c])
+		}
+	}
+	if ccfg.ControlPlane.APIConf.ExtraArgs["--merged"] != "true" {
+		t.Fatalf("extra args of apiserver should be merged, get: %v", ccfg.ControlPlane.APIConf.ExtraArgs)
+	}
+}
+
+func TestMinimalTemplate(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "cmd-minimal-template-test-")
+	if err != nil {
+		t.Fatalf("create tempdir for minimal template failed: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	f := filepath.Join(tempdir, "config.yaml")
+	if err = createMinimalDeployConfigTemplate(f); err != nil {
+		t.Fatalf("create minimal template config file failed: %v", err)
+	}
+	d, err := ioutil.ReadFile(f)
+	if err != nil {
+		t.Fatalf("read minimal template failed: %v", err)
+	}
+	for _, section := range []string{"service:", "network:", "open-ports:", "kubernetes-master:", "loadbalance:"} {
+		if strings.Contains(string(d), section) {
+			t.Fatalf("minimal template should not contain %s\n%s", section, string(d))
+		}
+	}
+
+	conf, err := loadDeployConfig(f)
+	if err != nil {
+		t.Fatalf("load minimal template failed: %v", err)
+	}
+	if len(conf.Masters) == 0 || len(conf.Etcds) != len(conf.Masters) {
+		t.Fatalf("invalid nodes of minimal template: %d masters, %d etcds", len(conf.Masters), len(conf.Etcds))
+	}
+	if conf.InstallConfig.PackageSrc == nil || len(conf.InstallConfig.PackageSrc.SrcPath) == 0 {
+		t.Fatalf("package source is required in minimal template")
+	}
+
+	ccfg := toClusterdeploymentConfig(conf, nil)
+	if ccfg.ServiceCluster.CIDR == "" || ccfg.Network.PodCIDR == "" {
+		t.Fatalf("defaults of eggo are not applied: service cidr %q, pod cidr %q",
+			ccfg.ServiceCluster.CIDR, ccfg.Network.PodCIDR)
+	}
+}
+
+func TestSingleNodeTemplate(t *testing.T) {
+	// init opts
+	if NewEggoCmd() == nil {
+		t.Fatalf("failed to create eggo command")
+	}
+
+	tempdir := t.TempDir()
+	f := filepath.Join(tempdir, "config.yaml")
+	if err := createSingleNodeDeployConfigTemplate(f); err != nil {
+		t.Fatalf("create single node template config file failed: %v", err)
+	}
+	conf, err := loadDeployConfig(f)
+	if err != nil {
+		t.Fatalf("load single node template failed: %v", err)
+	}
+	for arch := range conf.InstallConfig.PackageSrc.SrcPath {
+		conf.InstallConfig.PackageSrc.SrcPath[arch] = tempdir
+	}
+	if err = RunChecker(conf); err != nil {
+		t.Fatalf("check single node template failed: %v", err)
+	}
+
+	ccfg := toClusterdeploymentConfig(conf, nil)
+	if len(ccfg.Nodes) != 1 {
+		t.Fatalf("expect 1 node of single node cluster, get %d", len(ccfg.Nodes))
+	}
+	node := ccfg.Nodes[0]
+	if node.Type != api.Master|api.Worker|api.ETCD {
+		t.Fatalf("expect master, worker and etcd on the node, get type %d", node.Type)
+	}
+	if !node.Local {
+		t.Fatalf("node of single node template should be local")
+	}
+
+	r, err := runner.NewRunner(node, &ccfg.SSHHostKey, &ccfg.SSHKeepAlive, ccfg.ConnectTimeout)
+	if err != nil {
+		t.Fatalf("create runner of single node failed: %v", err)
+	}
+	if _, ok := r.(*runner.LocalRunner); !ok {
+		t.Fatalf("expect local runner of single node, get %T", r)
+	}
+	if output, err := r.RunCommand("echo eggo"); err != nil || output != "eggo\n" {
+		t.Fatalf("run command by local runner failed: %v, output: %s", err, output)
+	}
+}
+
+func TestNormalizeDeployConfigArch(t *testing.T) {
+	conf := &DeployConfig{
+		Masters:     []*HostConfig{{Ip: "192.168.0.2", Arch: "x86_64"}},
+		Workers:     []*HostConfig{{Ip: "192.168.0.3", Arch: "aarch64"}, {Ip: "192.168.0.4"}},
+		LoadBalance: LoadBalance{Ip: "192.168.0.1", Arch: "AMD64"},
+		InstallConfig: InstallConfig{
+			PackageSrc: &PackageSrcConfig{
+				SrcPath: map[string]string{"x86_64": "/root/amd64.tar.gz", "aarch64": "/root/arm64.tar.gz"},
+				Sha256:  map[string]string{"aarch64": strings.Repeat("a", 64)},
+			},
+		},
+	}
+	if err := normalizeDeployConfigArch(conf); err != nil {
+		t.Fatalf("normalize arch failed: %v", err)
+	}
+	expects := map[string]string{
+		"192.168.0.2": api.ArchAMD64,
+		"192.168.0.3": api.ArchARM64,
+		"192.168.0.4": api.ArchAMD64,
+	}
+	for _, h := range append(conf.Masters, conf.Workers...) {
+		if h.Arch != expects[h.Ip] {
+			t.Fatalf("expect arch %s of %s, got %s", expects[h.Ip], h.Ip, h.Arch)
+		}
+	}
+	if conf.LoadBalance.Arch != api.ArchAMD64 {
+		t.Fatalf("expect arch amd64 of loadbalance, got %s", conf.LoadBalance.Arch)
+	}
+	src := conf.InstallConfig.PackageSrc
+	if src.SrcPath[api.ArchAMD64] != "/root/amd64.tar.gz" || src.SrcPath[api.ArchARM64] != "/root/arm64.tar.gz" {
+		t.Fatalf("package source is not mapped to canonical arch: %v", src.SrcPath)
+	}
+	if _, ok := src.Sha256[api.ArchARM64]; !ok {
+		t.Fatalf("sha256 is not mapped to canonical arch: %v", src.Sha256)
+	}
+
+	conf.Workers[0].Arch = "arm"
+	if err := normalizeDeployConfigArch(conf); err == nil {
+		t.Fatalf("expect error for unsupported arch")
+	}
+	conf.Workers[0].Arch = "arm64"
+
+	src.SrcPath["x86_64"] = "/root/other.tar.gz"
+	if err := normalizeDeployConfigArch(conf); err == nil {
+		t.Fatalf("expect error for conflict package source of same arch")
+	}
+}
+
+func TestClusterConfigArch(t *testing.T) {
+	// deploy config converted by operator is not loaded from file
+	conf := &DeployConfig{
+		ClusterID: "test-cluster",
+		Masters:   []*HostConfig{{Ip: "192.168.0.2", Arch: "x86_64"}},
+		Workers:   []*HostConfig{{Ip: "192.168.0.3", Arch: "AArch64"}, {Ip: "192.168.0.4"}},
+		InstallConfig: InstallConfig{
+			PackageSrc: &PackageSrcConfig{
+				SrcPath: map[string]string{"x86_64": "/root/amd64.tar.gz", "aarch64": "/root/arm64.tar.gz"},
+			},
+		},
+	}
+	ccfg := toClusterdeploymentConfig(conf, nil)
+	expects := map[string]string{
+		"192.168.0.2": api.ArchAMD64,
+		"192.168.0.3": api.ArchARM64,
+		"192.168.0.4": api.ArchAMD64,
+	}
+	for _, n := range ccfg.Nodes {
+		if n.Arch != expects[n.Address] {
+			t.Fatalf("expect arch %s of %s, got %s", expects[n.Address], n.Address, n.Arch)
+		}
+	}
+	if ccfg.PackageSrc.SrcPath[api.ArchAMD64] != "/root/amd64.tar.gz" || ccfg.PackageSrc.SrcPath[api.ArchARM64] != "/root/arm64.tar.gz" {
+		t.Fatalf("package source is not mapped to canonical arch: %v", ccfg.PackageSrc.SrcPath)
+	}
+}
+
+func TestEtcdPorts(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "cmd-etcd-ports-test-")
+	if err != nil {
+		t.Fatalf("create tempdir for cmd configs failed: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	f := filepath.Join(tempdir, "config.yaml")
+	if err = createDeployConfigTemplate(f); err != nil {
+		t.Fatalf("create deploy template config file failed: %v", err)
+	}
+	conf, err := loadDeployConfig(f)
+	if err != nil {
+		t.Fatalf("load deploy config file failed: %v", err)
+	}
+
+	conf.EtcdClientPort, conf.EtcdPeerPort, conf.EtcdMetricsPort = 12379, 12380, 12381
+	if err = checkEtcdPorts(conf); err != nil {
+		t.Fatalf("check valid etcd ports failed: %v", err)
+	}
+	ccfg := toClusterdeploymentConfig(conf, nil)
+	ecc := &ccfg.EtcdCluster
+	if ecc.GetClientPort() != 12379 || ecc.GetPeerPort() != 12380 || ecc.GetMetricsPort() != 12381 {
+		t.Fatalf("expect custom etcd ports, get: %d %d %d", ecc.GetClientPort(), ecc.GetPeerPort(), ecc.GetMetricsPort())
+	}
+	if servers := api.GetEtcdServers(&ccfg.EtcdCluster); !strings.Contains(servers, ":12379") ||
+		strings.Contains(servers, ":2379") {
+		t.Fatalf("expect etcd servers with custom client port, get: %s", servers)
+	}
+
+	invalids := []struct{ client, peer, metrics int }{
+		{12379, 12379, 0},
+		{0, 0, 2379},
+		{6443, 0, 0},
+		{0, 70000, 0},
+		{-1, 0, 0},
+	}
+	for _, p := range invalids {
+		conf.EtcdClientPort, conf.EtcdPeerPort, conf.EtcdMetricsPort = p.client, p.peer, p.metrics
+		if err = checkEtcdPorts(conf); err == nil {
+			t.Fatalf("expect invalid etcd ports: %v", p)
+		}
+	}
+}
+
+func TestElevateConfig(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "cmd-elevate-test-")
+	if err != nil {
+		t.Fatalf("create tempdir for cmd configs failed: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	f := filepath.Join(tempdir, "config.yaml")
+	if err = createDeployConfigTemplate(f); err != nil {
+		t.Fatalf("create deploy template config file failed: %v", err)
+	}
+	conf, err := loadDeployConfig(f)
+	if err != nil {
+		t.Fatalf("load deploy config file failed: %v", err)
+	}
+
+	conf.Elevate = "doas"
+	ccfg := toClusterdeploymentConfig(conf, nil)
+	for _, n := range ccfg.Nodes {
+		if n.Elevate != "doas" {
+			t.Fatalf("expect elevate of node %s is doas, get: %s", n.Name, n.Elevate)
+		}
+	}
+
+	conf.Elevate = "su -c"
+	if err = RunChecker(conf); err == nil || !strings.Contains(err.Error(), "unsupport elevate command") {
+		t.Fatalf("expect invalid elevate command, get: %v", err)
+	}
+}
+
+func TestStrictDeployConfig(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "cmd-strict-test-")
+	if err != nil {
+		t.Fatalf("create tempdir for cmd configs failed: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	tcs := []struct {
+		content string
+		expect  string
+	}{
+		{
+			content: "cluster-id: k8s-cluster\nnetwrok:\n  podcidr: 10.244.0.0/16\n",
+			expect:  "unknown field netwrok, did you mean network?",
+		},
+		{
+			content: "cluster-id: k8s-cluster\nmasters:\n- name: master0\n  ip: 192.168.0.2\n  prot: 22\n",
+			expect:  "unknown field masters[0].prot, did you mean port?",
+		},
+		{
+			content: "cluster-id: k8s-cluster\ninstall:\n  pacakges: {}\n",
+			expect:  "unknown field install.pacakges",
+		},
+		{
+			content: "cluster-id: k8s-cluster\nloadbalance:\n  bind-port: abc\n",
+			expect:  "field loadbalance.bind-port: expect integer",
+		},
+		{
+			content: "cluster-id: k8s-cluster\nmasters:\n  name: master0\n",
+			expect:  "field masters: expect sequence",
+		},
+	}
+	for i, tc := range tcs {
+		f := filepath.Join(tempdir, fmt.Sprintf("config-%d.yaml", i))
+		if err = ioutil.WriteFile(f, []byte(tc.content), 0600); err != nil {
+			t.Fatalf("write config file failed: %v", err)
+		}
+		if _, err = loadDeployConfig(f); err == nil || !strings.Contains(err.Error(), tc.expect) {
+			t.Fatalf("expect error: %s, get: %v", tc.expect, err)
+		}
+	}
+
+	// valid config is loaded
+	f := filepath.Join(tempdir, "config.yaml")
+	content := "cluster-id: k8s-cluster\nmasters:\n- name: master0\n  ip: 192.168.0.2\n  port: 22\nnetwork:\n  podcidr: 10.244.0.0/16\n"
+	if err = ioutil.WriteFile(f, []byte(content), 0600); err != nil {
+		t.Fatalf("write config file failed: %v", err)
+	}
+	conf, err := loadDeployConfig(f)
+	if err != nil {
+		t.Fatalf("load valid deploy config failed: %v", err)
+	}
+	if conf.NetWork.PodCIDR != "10.244.0.0/16" || conf.Masters[0].Port != 22 {
+		t.Fatalf("unexpect deploy config: %+v", conf)
+	}
+}
+
+func TestConfigHooks(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "cmd-hooks-test-")
+	if err != nil {
+		t.Fatalf("create tempdir for cmd configs failed: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	for _, name := range []string{"a.sh", "b.sh", "c.sh", "d.sh"} {
+		if err = ioutil.WriteFile(filepath.Join(tempdir, name), []byte("#!/bin/bash\nexit 0\n"), 0750); err != nil {
+			t.Fatalf("write hook failed: %v", err)
+		}
+		if err = os.Chmod(filepath.Join(tempdir, name), 0750); err != nil {
+			t.Fatalf("chmod hook failed: %v", err)
+		}
+	}
+	conf := &DeployConfig{
+		Hooks: HooksConfig{
+			PreDeploy: []*HookConfig{
+				{Path: filepath.Join(tempdir, "b.sh")},
+				{Path: filepath.Join(tempdir, "a.sh"), Role: "worker", IgnoreFailure: true},
+			},
+			PostDeploy:  []*HookConfig{{Path: filepath.Join(tempdir, "c.sh")}},
+			PostCleanup: []*HookConfig{{Path: filepath.Join(tempdir, "d.sh")}},
+		},
+	}
+	if err = checkHooks(&conf.Hooks); err != nil {
+		t.Fatalf("check valid hooks failed: %v", err)
+	}
+
+	hooks, err := getConfigHookConf(conf, api.HookOpDeploy)
+	if err != nil {
+		t.Fatalf("get hooks of deploy failed: %v", err)
+	}
+	all := uint16(api.Master | api.Worker | api.ETCD | api.LoadBalance)
+	expects := []struct {
+		ty     api.HookType
+		file   string
+		target uint16
+		ignore bool
+	}{
+		{ty: api.ClusterPrehookType, file: "b.sh", target: all},
+		{ty: api.ClusterPrehookType, file: "a.sh", target: api.Worker, ignore: true},
+		{ty: api.ClusterPosthookType, file: "c.sh", target: all},
+	}
+	if len(hooks) != len(expects) {
+		t.Fatalf("expect %d hooks of deploy, get: %d", len(expects), len(hooks))
+	}
+	for i, e := range expects {
+		h := hooks[i]
+		if h.Operator != api.HookOpDeploy || h.Type != e.ty || h.Target != e.target || h.IgnoreFailure != e.ignore ||
+			len(h.HookFiles) != 1 || h.HookFiles[0] != e.file || h.HookSrcDir != tempdir {
+			t.Fatalf("unexpect hook %d: %+v", i, h)
+		}
+	}
+
+	if hooks, err = getConfigHookConf(conf, api.HookOpCleanup); err != nil || len(hooks) != 1 ||
+		hooks[0].Type != api.ClusterPosthookType || hooks[0].HookFiles[0] != "d.sh" {
+		t.Fatalf("unexpect hooks of cleanup: %v, err: %v", hooks, err)
+	}
+	if hooks, err = getConfigHookConf(conf, api.HookOpJoin); err != nil || len(hooks) != 0 {
+		t.Fatalf("expect no hooks of join, get: %v, err: %v", hooks, err)
+	}
+
+	conf.Hooks.PreCleanup = []*HookConfig{{Path: filepath.Join(tempdir, "not-exist.sh")}}
+	if _, err = getConfigHookConf(conf, api.HookOpCleanup); err == nil {
+		t.Fatalf("expect not exist hook failed")
+	}
+	conf.Hooks.PreCleanup = []*HookConfig{{Path: filepath.Join(tempdir, "a.sh"), Role: "node"}}
+	if err = checkHooks(&conf.Hooks); err == nil {
+		t.Fatalf("expect invalid role of hook failed")
+	}
+	conf.Hooks.PreCleanup = []*HookConfig{{Path: "a.sh"}}
+	if err = checkHooks(&conf.Hooks); err == nil {
+		t.Fatalf("expect relative path of hook failed")
+	}
+}
+
+func TestDirOverrides(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "cmd-dir-overrides-test-")
+	if err != nil {
+		t.Fatalf("create tempdir for dir overrides failed: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	f := filepath.Join(tempdir, "config.yaml")
+	if err = createDeployConfigTemplate(f); err != nil {
+		t.Fatalf("create deploy template config file failed: %v", err)
+	}
+	conf, err := loadDeployConfig(f)
+	if err != nil {
+		t.Fatalf("load deploy config file failed: %v", err)
+	}
+	defer func() {
+		opts.configDir, opts.certDir = "", ""
+	}()
+
+	// dirs in deploy config
+	conf.ConfigDir, conf.CertDir = "/data/kubernetes", "/data/kubernetes/pki"
+	ccfg := toClusterdeploymentConfig(conf, nil)
+	if ccfg.GetConfigDir() != "/data/kubernetes" || ccfg.GetCertDir() != "/data/kubernetes/pki" {
+		t.Fatalf("expect dirs of deploy config, get: %s, %s", ccfg.GetConfigDir(), ccfg.GetCertDir())
+	}
+
+	// command line wins over deploy config
+	opts.configDir, opts.certDir = filepath.Join(tempdir, "k8s"), filepath.Join(tempdir, "pki")
+	if err = checkDirOverrides(); err != nil {
+		t.Fatalf("check dir overrides failed: %v", err)
+	}
+	ccfg = toClusterdeploymentConfig(conf, nil)
+	if ccfg.GetConfigDir() != opts.configDir || ccfg.GetCertDir() != opts.certDir {
+		t.Fatalf("expect dirs of command line, get: %s, %s", ccfg.GetConfigDir(), ccfg.GetCertDir())
+	}
+
+	// relative dirs are rejected
+	for _, dir := range []string{"scratch/k8s", "./pki", "/"} {
+		opts.configDir, opts.certDir = dir, ""
+		if err = checkDirOverrides(); err == nil {
+			t.Fatalf("expect invalid --config-dir %s", dir)
+		}
+		opts.configDir, opts.certDir = "", dir
+		if err = checkDirOverrides(); err == nil {
+			t.Fatalf("expect invalid --cert-dir %s", dir)
+		}
+	}
+	if err = checkKubernetesDir("config-dir", "scratch/k8s"); err == nil {
+		t.Fatalf("expect invalid config-dir of deploy config")
+	}
+}
+
+func TestNodePortRange(t *testing.T) {
+	// init opts
+	if NewEggoCmd() == nil {
+		t.Fatalf("failed to create eggo command")
+	}
+	f := filepath.Join(t.TempDir(), "config.yaml")
+	if err := createDeployConfigTemplate(f); err != nil {
+		t.Fatalf("create deploy template config file failed: %v", err)
+	}
+	conf, err := loadDeployConfig(f)
+	if err != nil {
+		t.Fatalf("load deploy config file failed: %v", err)
+	}
+
+	conf.Service.NodePortRange = "20000-22767"
+	ccr := &ServiceClusterResponsibility{conf: conf.Service}
+	if err = ccr.Execute(); err != nil {
+		t.Fatalf("check valid node port range failed: %v", err)
+	}
+	ccfg := toClusterdeploymentConfig(conf, nil)
+	if ccfg.GetNodePortRange() != "20000-22767" {
+		t.Fatalf("node port range is not applied: %s", ccfg.GetNodePortRange())
+	}
+	opened := make(map[string]bool)
+	for _, p := range ccfg.RoleInfra[api.Worker].OpenPorts {
+		opened[fmt.Sprintf("%d-%d/%s", p.Port, p.EndPort, p.Protocol)] = true
+	}
+	if !opened["20000-22767/tcp"] || !opened["20000-22767/udp"] {
+		t.Fatalf("expect node port range opened on workers, get: %v", opened)
+	}
+
+	for _, r := range []string{"20000", "22767-20000", "20000-70000"} {
+		ccr.conf.NodePortRange = r
+		if err = ccr.Execute(); err == nil {
+			t.Fatalf("expect invalid node port range: %s", r)
+		}
+	}
+}
+
+func TestSSHAgentWithoutSocket(t *testing.T) {
+	oldSocket, hasSocket := os.LookupEnv(runner.SSHAgentSocketEnv)
+	defer func() {
+		if hasSocket {
+			os.Setenv(runner.SSHAgentSocketEnv, oldSocket)
+		} else {
+			os.Unsetenv(runner.SSHAgentSocketEnv)
+		}
+	}()
+	os.Unsetenv(runner.SSHAgentSocketEnv)
+
+	conf := &DeployConfig{
+		ClusterID:   "test-cluster",
+		Username:    "root",
+		UseSSHAgent: true,
+		Masters:     []*HostConfig{{Ip: "192.168.0.2"}},
+	}
+	ccfg := toClusterdeploymentConfig(conf, nil)
+	if len(ccfg.Nodes) == 0 {
+		t.Fatalf("expect nodes in config of cluster deployment")
+	}
+	node := ccfg.Nodes[0]
+	if node.PrivateKeyPath != "" {
+		t.Fatalf("default private key should not be set when ssh agent is requested, got %s", node.PrivateKeyPath)
+	}
+	// fails before connecting to node
+	if _, err := runner.NewRunner(node, &ccfg.SSHHostKey, nil, "1s"); err == nil || !strings.Contains(err.Error(), "use ssh agent failed") {
+		t.Fatalf("expect error of ssh agent without socket, got: %v", err)
+	}
+}
@@ -22,6 +22,7 @@ import (
 
 	"isula.org/eggo/pkg/api"
 	"isula.org/eggo/pkg/clusterdeployment"
+	"isula.org/eggo/pkg/utils"
 )
 
 func splitDeletedConfigs(hosts []*HostConfig, delNames []string) ([]*HostConfig, []*HostConfig) {
@@ -71,13 +72,27 @@ func getDeletedAndDiffConfigs(conf *DeployConfig, delNames []string) (*DeployCon
 	return &deletedConfig, clusterConfig.Nodes, nil
 }
 
+// delete master or etcd will affect quorum of cluster, only allowed with force
+func checkDeletedNodes(hosts []*api.HostConfig, force bool) error {
+	if force {
+		return nil
+	}
+	for _, h := range hosts {
+		if utils.IsType(h.Type, api.Master) || utils.IsType(h.Type, api.ETCD) {
+			return fmt.Errorf("node %s has master or etcd role, use --force to delete it", h.Name)
+		}
+	}
+	return nil
+}
+
 func deleteCluster(cmd *cobra.Command, args []string) error {
 	if opts.debug {
 		initLog()
 	}
 
-	if len(args) == 0 {
-		return fmt.Errorf("delete command need at least one argument")
+	delNames := append(append([]string{}, args...), opts.delNodes...)
+	if len(delNames) == 0 {
+		return fmt.Errorf("delete command need at least one node")
 	}
 
 	if opts.delClusterID == "" {
@@ -97,7 +112,7 @@ func deleteCluster(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	hooksConf, err := getClusterHookConf(api.HookOpDelete)
+	hooksConf, err := getClusterHookConf(conf, api.HookOpDelete)
 	if err != nil {
 		return fmt.Errorf("get cmd hooks config failed:%v", err)
 	}
@@ -112,17 +127,31 @@ func deleteCluster(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
-	deletedConfig, diffHostconfigs, err := getDeletedAndDiffConfigs(conf, args)
+	deletedConfig, diffHostconfigs, err := getDeletedAndDiffConfigs(conf, delNames)
 	if err != nil {
 		return fmt.Errorf("get deleted and diff config failed: %v", err)
 	}
 
+	if err = checkDeletedNodes(diffHostconfigs, opts.delForce); err != nil {
+		return err
+	}
+
 	// check deleted config
 	if err = RunChecker(deletedConfig); err != nil {
 		return err
 	}
 
-	if err = clusterdeployment.DeleteNodes(toClusterdeploymentConfig(conf, hooksConf), diffHostconfigs); err != nil {
+	ccfg := toClusterdeploymentConfig(conf, hooksConf)
+	ccfg.Drain = api.DrainConfig{
+		GracePeriod: opts.drainGracePeriod,
+		Timeout:     opts.drainTimeout,
+		Force:       opts.delForce,
+		Strict:      opts.drainStrict,
+	}
+
+	ctx, cancel := newCommandContext()
+	defer cancel()
+	if err = clusterdeployment.DeleteNodes(ctx, ccfg, diffHostconfigs); err != nil {
 		return err
 	}
 
@@ -135,7 +164,7 @@ func deleteCluster(cmd *cobra.Command, args []string) error {
 
 func NewDeleteCmd() *cobra.Command {
 	deleteCmd := &cobra.Command{
-		Use:   "delete NAME [NAME...]",
+		Use:   "delete [NAME...]",
 		Short: "delete nodes from cluster",
 		RunE:  deleteCluster,
 	}
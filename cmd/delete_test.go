@@ -17,6 +17,8 @@ package cmd
 
 import (
 	"testing"
+
+	"isula.org/eggo/pkg/api"
 )
 
 func TestSplitDeletedConfigs(t *testing.T) {
@@ -97,3 +99,38 @@ func TestGetDeletedAndDiffConfigs(t *testing.T) {
 		t.Fatalf("test get deleted and diff configs failed")
 	}
 }
+
+func TestCheckDeletedNodes(t *testing.T) {
+	workers := []*api.HostConfig{
+		{
+			Name: "worker1",
+			Type: api.Worker,
+		},
+	}
+	if err := checkDeletedNodes(workers, false); err != nil {
+		t.Fatalf("delete worker should be allowed: %v", err)
+	}
+
+	masters := []*api.HostConfig{
+		{
+			Name: "master1",
+			Type: api.Master | api.Worker,
+		},
+	}
+	if err := checkDeletedNodes(masters, false); err == nil {
+		t.Fatalf("delete master without force should be refused")
+	}
+	if err := checkDeletedNodes(masters, true); err != nil {
+		t.Fatalf("delete master with force should be allowed: %v", err)
+	}
+
+	etcds := []*api.HostConfig{
+		{
+			Name: "etcd1",
+			Type: api.ETCD,
+		},
+	}
+	if err := checkDeletedNodes(etcds, false); err == nil {
+		t.Fatalf("delete etcd without force should be refused")
+	}
+}
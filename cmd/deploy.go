@@ -16,16 +16,26 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"isula.org/eggo/pkg/api"
 	"isula.org/eggo/pkg/clusterdeployment"
+	"isula.org/eggo/pkg/clusterdeployment/binary/commontools"
+	"isula.org/eggo/pkg/constants"
+	eggodeploy "isula.org/eggo/pkg/deploy"
 	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/metrics"
+	"isula.org/eggo/pkg/utils/progress"
 )
 
-func removeFailedNodes(cstatus *api.ClusterStatus, conf *DeployConfig) {
+func removeFailedNodes(cstatus *api.ClusterStatus, conf *DeployConfig, w io.Writer) {
 	// if partial success, just update config of cluster, remove failed nodes
 	if cstatus.FailureCnt == 0 {
 		return
@@ -59,48 +69,127 @@ func removeFailedNodes(cstatus *api.ClusterStatus, conf *DeployConfig) {
 	conf.Etcds = tmp
 
 	if err := saveDeployConfig(conf, savedDeployConfigPath(conf.ClusterID)); err != nil {
-		fmt.Printf("Warn: failed to save config!!!\n")
-		fmt.Printf("	you can call \"eggo delete --id %s [failed nodes id]\" to remove failed node from your cluster.\n", conf.ClusterID)
+		fmt.Fprintf(w, "Warn: failed to save config!!!\n")
+		fmt.Fprintf(w, "	you can call \"eggo delete --id %s [failed nodes id]\" to remove failed node from your cluster.\n", conf.ClusterID)
 		return
 	}
-	fmt.Printf("update config of cluster: %s", conf.ClusterID)
+	fmt.Fprintf(w, "update config of cluster: %s", conf.ClusterID)
 }
 
-func deploy(conf *DeployConfig) error {
+// isTerminal reports whether w is a terminal, progress is redrawn in place on terminal only
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && progress.IsTerminal(f)
+}
+
+func showDeployMetrics(w io.Writer) {
+	m := metrics.Snapshot()
+	fmt.Fprint(w, m.Show())
+	if opts.metricsOut == "" {
+		return
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		logrus.Warnf("marshal deploy metrics failed: %v", err)
+		return
+	}
+	if err = ioutil.WriteFile(opts.metricsOut, data, constants.MetricsFileMode); err != nil {
+		logrus.Warnf("write deploy metrics to %s failed: %v", opts.metricsOut, err)
+	}
+}
+
+// deploy deploys cluster of conf, progress and summary are written to w,
+// result of deploy is written to out as json if out is not nil
+func deploy(conf *DeployConfig, w io.Writer, out io.Writer) error {
 	if err := saveDeployConfig(conf, savedDeployConfigPath(conf.ClusterID)); err != nil {
 		return fmt.Errorf("save deploy config failed: %v", err)
 	}
 
-	hooksConf, err := getClusterHookConf(api.HookOpDeploy)
+	hooksConf, err := getClusterHookConf(conf, api.HookOpDeploy)
 	if err != nil {
 		return fmt.Errorf("get cmd hooks config failed:%v", err)
 	}
 	ccfg := toClusterdeploymentConfig(conf, hooksConf)
+	if out != nil {
+		// generate join token before deploy, so it could be reported
+		token, _, _, err := commontools.ParseBootstrapTokenStr("")
+		if err != nil {
+			return fmt.Errorf("generate bootstrap token failed: %v", err)
+		}
+		ccfg.JoinToken = token
+	}
+	dopts := eggodeploy.DeployOptions{
+		EnableRollback: opts.deployEnableRollback,
+		KubeConfigOut:  opts.kubeconfigOut,
+	}
+	if dopts.KubeConfigOut == "" {
+		dopts.KubeConfigOut = defaultKubeConfigOutPath(conf.ClusterID)
+	}
 
-	cstatus, err := clusterdeployment.CreateCluster(ccfg, opts.deployEnableRollback)
+	ctx, cancel := newCommandContext()
+	defer cancel()
+	metrics.Reset()
+	var reporter *progress.Reporter
+	if !opts.quiet {
+		reporter = progress.NewReporter(w, isTerminal(w), clusterdeployment.CreateClusterPhases)
+		progress.SetReporter(reporter)
+		defer progress.SetReporter(nil)
+	}
+	cstatus, err := eggodeploy.Run(ctx, ccfg, dopts)
+	if reporter != nil {
+		reporter.Finish(err)
+	}
+	saveClusterState(newClusterState(conf.ClusterID, &cstatus, err), clusterStatePath(conf.ClusterID))
+	showDeployMetrics(w)
+	if out != nil {
+		if werr := writeDeployResult(out, newDeployResult(ccfg, &cstatus, err, metrics.Snapshot())); werr != nil {
+			logrus.Warnf("write result of deploy failed: %v", werr)
+		}
+	}
 	if err != nil {
 		return err
 	}
 
 	// if disable rollback, just ignore error, and wait user to cleanup
 	if opts.deployEnableRollback {
-		removeFailedNodes(&cstatus, conf)
+		removeFailedNodes(&cstatus, conf, w)
 	} else {
 		if cstatus.FailureCnt > 0 {
-			fmt.Printf("Warn: you can call \"eggo delete --id %s [failed nodes id]\" to remove failed node from your cluster.\n", conf.ClusterID)
+			fmt.Fprintf(w, "Warn: you can call \"eggo delete --id %s [failed nodes id]\" to remove failed node from your cluster.\n", conf.ClusterID)
 		}
 	}
 
-	fmt.Print(cstatus.Show())
+	fmt.Fprint(w, cstatus.Show())
 
 	if cstatus.Working {
-		fmt.Printf("To start using cluster: %s, you need following as a regular user:\n\n", ccfg.Name)
-		fmt.Printf("\texport KUBECONFIG=%s/admin.conf\n\n", api.GetClusterHomePath(ccfg.Name))
+		fmt.Fprintf(w, "To start using cluster: %s, you need following as a regular user:\n\n", ccfg.Name)
+		fmt.Fprintf(w, "\texport KUBECONFIG=%s\n\n", ccfg.KubeConfigOut)
 	}
 
 	return err
 }
 
+// deployPhase runs one phase of deploy without hooks and rollback
+func deployPhase(conf *DeployConfig, phase string, w io.Writer) error {
+	if err := saveDeployConfig(conf, savedDeployConfigPath(conf.ClusterID)); err != nil {
+		return fmt.Errorf("save deploy config failed: %v", err)
+	}
+
+	ccfg := toClusterdeploymentConfig(conf, nil)
+	ccfg.KubeConfigOut = opts.kubeconfigOut
+	if ccfg.KubeConfigOut == "" {
+		ccfg.KubeConfigOut = defaultKubeConfigOutPath(conf.ClusterID)
+	}
+
+	ctx, cancel := newCommandContext()
+	defer cancel()
+	if err := clusterdeployment.RunDeployPhase(ctx, ccfg, phase); err != nil {
+		return fmt.Errorf("run phase %s failed: %v", phase, err)
+	}
+	fmt.Fprintf(w, "run phase %s of cluster %s success\n", phase, conf.ClusterID)
+	return nil
+}
+
 func checkClusterExist(ClusterID string) error {
 	clusterHomeDir := api.GetClusterHomePath(ClusterID)
 	if exist, err := utils.CheckPathExist(clusterHomeDir); err != nil || exist {
@@ -109,10 +198,37 @@ func checkClusterExist(ClusterID string) error {
 	return nil
 }
 
+// validateDeployConfig prints all problems of deploy config to w, it never connects to nodes
+func validateDeployConfig(conf *DeployConfig, w io.Writer) error {
+	errs := CollectCheckErrors(conf)
+	if err := checkCmdHooksParameter(opts.clusterPrehook, opts.clusterPosthook); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		fmt.Fprintf(w, "deploy config of cluster %s is valid\n", conf.ClusterID)
+		return nil
+	}
+
+	fmt.Fprintf(w, "deploy config of cluster %s is invalid:\n", conf.ClusterID)
+	for _, err := range errs {
+		fmt.Fprintf(w, "  - %v\n", err)
+	}
+	return fmt.Errorf("found %d problems in deploy config", len(errs))
+}
+
 func deployCluster(cmd *cobra.Command, args []string) error {
 	if opts.debug {
 		initLog()
 	}
+	if opts.deployOutput != "" && opts.deployOutput != "json" {
+		return fmt.Errorf("unsupported output format: %s, only json is supported", opts.deployOutput)
+	}
+	var w, out io.Writer = os.Stdout, nil
+	if opts.deployOutput == "json" {
+		// stdout only carries result of deploy, others are written to stderr
+		logrus.SetOutput(os.Stderr)
+		w, out = os.Stderr, os.Stdout
+	}
 	var err error
 
 	conf, err := loadDeployConfig(opts.deployConfig)
@@ -120,6 +236,15 @@ func deployCluster(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("load deploy config file failed: %v", err)
 	}
 
+	if opts.validateOnly {
+		return validateDeployConfig(conf, w)
+	}
+
+	if opts.onlyPhase != "" {
+		if err = clusterdeployment.CheckDeployPhase(opts.onlyPhase); err != nil {
+			return err
+		}
+	}
 	if err = checkCmdHooksParameter(opts.clusterPrehook, opts.clusterPosthook); err != nil {
 		return err
 	}
@@ -127,9 +252,22 @@ func deployCluster(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// check cluster home dir
-	if err = checkClusterExist(conf.ClusterID); err != nil {
-		return err
+	// check cluster home dir, single phase is run for existing cluster
+	if opts.onlyPhase == "" {
+		if err = checkClusterExist(conf.ClusterID); err != nil {
+			return err
+		}
+	}
+
+	if !opts.skipPreflight {
+		if err = preflight(conf, false, w); err != nil {
+			return fmt.Errorf("%v, fix nodes or deploy with --skip-preflight", err)
+		}
+	}
+	if opts.onlyPhase == "" {
+		if err = checkExistingCluster(conf, opts.deployForce); err != nil {
+			return err
+		}
 	}
 
 	holder, err := NewProcessPlaceHolder(eggoPlaceHolderPath(conf.ClusterID))
@@ -138,11 +276,14 @@ func deployCluster(cmd *cobra.Command, args []string) error {
 	}
 	defer func() {
 		if terr := holder.Remove(); terr != nil {
-			fmt.Printf("remove process place holder failed: %v", terr)
+			fmt.Fprintf(w, "remove process place holder failed: %v", terr)
 		}
 	}()
 
-	if err = deploy(conf); err != nil {
+	if opts.onlyPhase != "" {
+		return deployPhase(conf, opts.onlyPhase, w)
+	}
+	if err = deploy(conf, w, out); err != nil {
 		return err
 	}
 
@@ -0,0 +1,163 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: cmd deploy testcase
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v1"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/metrics"
+)
+
+func TestValidateOnly(t *testing.T) {
+	tempdir := t.TempDir()
+	// init opts
+	if NewEggoCmd() == nil {
+		t.Fatalf("failed to create eggo command")
+	}
+
+	f := filepath.Join(tempdir, "config.yaml")
+	if err := createDeployConfigTemplate(f); err != nil {
+		t.Fatalf("create deploy template config file failed: %v", err)
+	}
+	conf, err := loadDeployConfig(f)
+	if err != nil {
+		t.Fatalf("load deploy config file failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	// source packages are not exist
+	if err = validateDeployConfig(conf, &buf); err == nil {
+		t.Fatalf("expect validate config without source packages failed")
+	}
+	for _, fn := range conf.InstallConfig.PackageSrc.SrcPath {
+		if err = os.MkdirAll(fn, 0755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		defer os.RemoveAll(fn)
+	}
+	if err = validateDeployConfig(conf, &buf); err != nil {
+		t.Fatalf("validate valid config failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "is valid") {
+		t.Fatalf("expect valid config is reported, get: %s", buf.String())
+	}
+
+	// nodes of template are not reachable, deploy returns before any connection
+	oldValidateOnly, oldDeployConfig := opts.validateOnly, opts.deployConfig
+	defer func() {
+		opts.validateOnly, opts.deployConfig = oldValidateOnly, oldDeployConfig
+	}()
+	opts.validateOnly, opts.deployConfig = true, f
+	if err = deployCluster(nil, nil); err != nil {
+		t.Fatalf("deploy with validate only failed: %v", err)
+	}
+
+	// all problems are reported
+	conf.ClusterID = ""
+	conf.Service.Gateway = "192.168.0.777"
+	if errs := CollectCheckErrors(conf); len(errs) != 2 {
+		t.Fatalf("expect 2 problems, get: %v", errs)
+	}
+	if err = validateDeployConfig(conf, ioutil.Discard); err == nil || !strings.Contains(err.Error(), "found 2 problems") {
+		t.Fatalf("expect 2 problems in config, get: %v", err)
+	}
+	d, err := yaml.Marshal(conf)
+	if err != nil {
+		t.Fatalf("marshal deploy config failed: %v", err)
+	}
+	if err = ioutil.WriteFile(f, d, 0600); err != nil {
+		t.Fatalf("write deploy config failed: %v", err)
+	}
+	if err = deployCluster(nil, nil); err == nil {
+		t.Fatalf("expect deploy with invalid config and validate only failed")
+	}
+}
+
+func TestDeployResult(t *testing.T) {
+	ccfg := &api.ClusterConfig{
+		Name:          "test-cluster",
+		KubeConfigOut: "/root/.eggo/test-cluster/admin.conf",
+		JoinToken:     "abcdef.0123456789abcdef",
+		APIEndpoint:   api.APIEndpoint{AdvertiseAddress: "192.168.0.1", BindPort: 6443},
+		Nodes: []*api.HostConfig{
+			{Name: "master0", Address: "192.168.0.1", Type: api.Master | api.ETCD},
+			{Name: "worker0", Address: "192.168.0.2", Type: api.Worker},
+		},
+	}
+	cstatus := &api.ClusterStatus{
+		Working:       true,
+		StatusOfNodes: map[string]bool{"192.168.0.1": true, "192.168.0.2": true},
+		SuccessCnt:    2,
+	}
+	metrics.Reset()
+	metrics.StartPhase("infrastructure", "192.168.0.1")()
+
+	var buf bytes.Buffer
+	if err := writeDeployResult(&buf, newDeployResult(ccfg, cstatus, nil, metrics.Snapshot())); err != nil {
+		t.Fatalf("write deploy result failed: %v", err)
+	}
+
+	var res map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+		t.Fatalf("deploy result is not json: %v\n%s", err, buf.String())
+	}
+	expects := map[string]interface{}{
+		"cluster-id": "test-cluster",
+		"status":     stateSuccess,
+		"endpoint":   "https://192.168.0.1:6443",
+		"kubeconfig": "/root/.eggo/test-cluster/admin.conf",
+		"token":      "abcdef.0123456789abcdef",
+	}
+	for k, v := range expects {
+		if res[k] != v {
+			t.Fatalf("expect %s: %v, get: %v", k, v, res[k])
+		}
+	}
+	if _, ok := res["errors"]; ok {
+		t.Fatalf("expect no errors of successful deploy, get: %v", res["errors"])
+	}
+	if _, ok := res["metrics"].(map[string]interface{}); !ok {
+		t.Fatalf("expect metrics in deploy result, get: %v", res["metrics"])
+	}
+
+	nodes, ok := res["nodes"].([]interface{})
+	if !ok || len(nodes) != 2 {
+		t.Fatalf("expect 2 nodes in deploy result, get: %v", res["nodes"])
+	}
+	master := nodes[0].(map[string]interface{})
+	if master["name"] != "master0" || master["ip"] != "192.168.0.1" || master["success"] != true {
+		t.Fatalf("unexpected result of master0: %v", master)
+	}
+	if roles := master["roles"].([]interface{}); len(roles) != 2 || roles[0] != "master" || roles[1] != "etcd" {
+		t.Fatalf("unexpected roles of master0: %v", roles)
+	}
+
+	// failed node is reported in partial deploy
+	cstatus.StatusOfNodes["192.168.0.2"], cstatus.SuccessCnt, cstatus.FailureCnt = false, 1, 1
+	r := newDeployResult(ccfg, cstatus, nil, metrics.Snapshot())
+	if r.Status != statePartial || r.Nodes[1].Success || len(r.Errors) != 1 {
+		t.Fatalf("expect partial result with failed worker0, get: %+v", r)
+	}
+}
@@ -0,0 +1,88 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: machine-readable result of deploy
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/endpoint"
+	"isula.org/eggo/pkg/utils/metrics"
+)
+
+type deployNodeResult struct {
+	Name    string   `json:"name"`
+	IP      string   `json:"ip"`
+	Roles   []string `json:"roles"`
+	Success bool     `json:"success"`
+}
+
+// deployResult is printed to stdout when deploy with --output json
+type deployResult struct {
+	ClusterID  string                `json:"cluster-id"`
+	Status     string                `json:"status"`
+	Message    string                `json:"message,omitempty"`
+	Endpoint   string                `json:"endpoint,omitempty"`
+	KubeConfig string                `json:"kubeconfig,omitempty"`
+	Token      string                `json:"token,omitempty"`
+	Nodes      []deployNodeResult    `json:"nodes"`
+	Errors     []string              `json:"errors,omitempty"`
+	Metrics    metrics.DeployMetrics `json:"metrics"`
+}
+
+func newDeployResult(ccfg *api.ClusterConfig, cstatus *api.ClusterStatus, err error, m metrics.DeployMetrics) *deployResult {
+	state := newClusterState(ccfg.Name, cstatus, err)
+	res := &deployResult{
+		ClusterID:  ccfg.Name,
+		Status:     state.Status,
+		Message:    state.Message,
+		KubeConfig: ccfg.KubeConfigOut,
+		Token:      ccfg.JoinToken,
+		Metrics:    m,
+	}
+	if ep, eerr := endpoint.GetAPIServerEndpoint(ccfg); eerr == nil {
+		res.Endpoint = ep
+	}
+	if err != nil {
+		res.Errors = append(res.Errors, err.Error())
+	}
+
+	for _, n := range ccfg.Nodes {
+		success := err == nil
+		if s, ok := cstatus.StatusOfNodes[n.Address]; ok && !s {
+			success = false
+			res.Errors = append(res.Errors, fmt.Sprintf("node %s(%s) failed", n.Name, n.Address))
+		}
+		res.Nodes = append(res.Nodes, deployNodeResult{
+			Name:    n.Name,
+			IP:      n.Address,
+			Roles:   api.GetRoleString(n.Type),
+			Success: success,
+		})
+	}
+	return res
+}
+
+func writeDeployResult(w io.Writer, res *deployResult) error {
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
@@ -0,0 +1,273 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo diagnose command implement
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment/binary/etcdcluster"
+	"isula.org/eggo/pkg/clusterdeployment/runtime"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/runner"
+)
+
+const (
+	diagnoseConnectTimeout = 30 * time.Second
+	diagnoseCommandTimeout = 120 * time.Second
+	diagnoseJournalLines   = 2000
+	diagnoseRedacted       = "******"
+)
+
+var (
+	// config files of runtime, default runtime is docker
+	runtimeConfigFiles = map[string]string{
+		"docker":     "/etc/docker/daemon.json",
+		"isulad":     "/etc/sysconfig/iSulad",
+		"containerd": "/etc/containerd/config.toml",
+	}
+
+	// value of password, token, secret and private key in formats of
+	// "key: value", "key=value", "--key=value" and "\"key\": \"value\""
+	diagnoseSecretPattern = regexp.MustCompile(`(?i)((?:password|passwd|token|secret|client-key-data)[\w-]*["']?\s*[:=]\s*)("[^"]*"|'[^']*'|\S+)`)
+
+	// replaced in testcase
+	diagnoseConnect = func(hcf *api.HostConfig, hostKey *api.SSHHostKeyConfig) (runner.Runner, error) {
+		return connectWithTimeout(hcf, hostKey, diagnoseConnectTimeout)
+	}
+)
+
+// diagnoseItem is output of command saved as file in support bundle
+type diagnoseItem struct {
+	name string
+	cmd  string
+}
+
+// services and config files to collect of node according to its roles
+func diagnoseServicesAndFiles(ccfg *api.ClusterConfig, host *api.HostConfig) ([]string, []string) {
+	var services, files []string
+	configDir := ccfg.GetConfigDir()
+	if utils.IsType(host.Type, api.Master) {
+		services = append(services, "kube-apiserver", "kube-controller-manager", "kube-scheduler")
+		files = append(files,
+			filepath.Join(configDir, "admin.conf"),
+			filepath.Join(configDir, "controller-manager.conf"),
+			filepath.Join(configDir, "scheduler.conf"),
+			filepath.Join(configDir, "encryption-config.yaml"))
+	}
+	if utils.IsType(host.Type, api.Worker) {
+		engine := ""
+		if ccfg.WorkerConfig.ContainerEngineConf != nil {
+			engine = ccfg.WorkerConfig.ContainerEngineConf.Runtime
+		}
+		if rt := runtime.GetRuntime(engine); rt != nil {
+			services = append(services, rt.GetRuntimeService())
+		}
+		services = append(services, "kubelet", "kube-proxy")
+		files = append(files,
+			filepath.Join(configDir, "kubelet_config.yaml"),
+			filepath.Join(configDir, "kubelet.kubeconfig"),
+			filepath.Join(configDir, "kube-proxy-config.yaml"))
+		if f, ok := runtimeConfigFiles[strings.ToLower(engine)]; ok {
+			files = append(files, f)
+		} else if engine == "" {
+			files = append(files, runtimeConfigFiles["docker"])
+		}
+	}
+	if utils.IsType(host.Type, api.ETCD) && !ccfg.EtcdCluster.External {
+		services = append(services, "etcd")
+		files = append(files, etcdcluster.EtcdConfFile)
+	}
+	if utils.IsType(host.Type, api.LoadBalance) {
+		services = append(services, "nginx")
+		files = append(files, filepath.Join(configDir, "kube-nginx.conf"))
+	}
+	return services, files
+}
+
+// diagnoseItems returns journal logs and status of services, and config files to collect of node
+func diagnoseItems(ccfg *api.ClusterConfig, host *api.HostConfig) []diagnoseItem {
+	services, files := diagnoseServicesAndFiles(ccfg, host)
+	var items []diagnoseItem
+	for _, s := range services {
+		items = append(items,
+			diagnoseItem{
+				name: filepath.Join("journal", s+".log"),
+				cmd:  fmt.Sprintf("journalctl -u %s --no-pager -n %d", s, diagnoseJournalLines),
+			},
+			diagnoseItem{
+				name: filepath.Join("status", s+".txt"),
+				// systemctl status exit with error if service is not running
+				cmd: fmt.Sprintf("systemctl status %s --no-pager -l 2>&1 || true", s),
+			})
+	}
+	for _, f := range files {
+		items = append(items, diagnoseItem{
+			name: filepath.Join("files", strings.TrimPrefix(f, "/")),
+			cmd:  fmt.Sprintf("cat %s", f),
+		})
+	}
+	return items
+}
+
+// redactSecrets replaces value of passwords, tokens and keys with asterisks
+func redactSecrets(content string) string {
+	return diagnoseSecretPattern.ReplaceAllString(content, "${1}"+diagnoseRedacted)
+}
+
+func writeDiagnoseBundle(path string, contents map[string]string, names []string) error {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	now := time.Now()
+	for _, name := range names {
+		data := []byte(contents[name])
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("write header of %s failed: %v", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("write %s failed: %v", name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+
+	// bundle may contain sensitive informations
+	return os.WriteFile(path, buf.Bytes(), 0600)
+}
+
+// collectNodeDiagnostics collects diagnostics of node into tarball under outputDir
+func collectNodeDiagnostics(ccfg *api.ClusterConfig, host *api.HostConfig, outputDir string) healthItem {
+	item := healthItem{name: host.Address, status: preflightPassed}
+	r, err := diagnoseConnect(host, &ccfg.SSHHostKey)
+	if err != nil {
+		item.status, item.message = preflightFailed, fmt.Sprintf("ssh login failed: %v", err)
+		return item
+	}
+	defer r.Close()
+
+	var names, failures []string
+	contents := make(map[string]string)
+	for _, di := range diagnoseItems(ccfg, host) {
+		output, err := runCommandWithTimeout(r, utils.AddSudo(di.cmd), diagnoseCommandTimeout)
+		if err != nil {
+			logrus.Debugf("collect %s of node %s failed: %v", di.name, host.Address, err)
+			failures = append(failures, di.name)
+			output = fmt.Sprintf("%s\n%v\n", output, err)
+		}
+		names = append(names, di.name)
+		contents[di.name] = redactSecrets(output)
+	}
+
+	path := filepath.Join(outputDir, host.Name+".tar.gz")
+	if err := writeDiagnoseBundle(path, contents, names); err != nil {
+		item.status, item.message = preflightFailed, fmt.Sprintf("write %s failed: %v", path, err)
+		return item
+	}
+	item.message = path
+	if len(failures) != 0 {
+		item.message = fmt.Sprintf("%s, collect failed: %s", path, strings.Join(failures, ", "))
+	}
+	return item
+}
+
+func runDiagnose(ccfg *api.ClusterConfig, outputDir string) ([]healthItem, error) {
+	if err := os.MkdirAll(outputDir, 0700); err != nil {
+		return nil, fmt.Errorf("create dir %s failed: %v", outputDir, err)
+	}
+
+	items := make([]healthItem, len(ccfg.Nodes))
+	var wg sync.WaitGroup
+	wg.Add(len(ccfg.Nodes))
+	for i, n := range ccfg.Nodes {
+		go func(idx int, host *api.HostConfig) {
+			defer wg.Done()
+			items[idx] = collectNodeDiagnostics(ccfg, host, outputDir)
+		}(i, n)
+	}
+	wg.Wait()
+
+	for _, item := range items {
+		if item.status != preflightPassed {
+			return items, fmt.Errorf("diagnose of cluster: %s failed", ccfg.Name)
+		}
+	}
+	return items, nil
+}
+
+func runDiagnoseCmd(cmd *cobra.Command, args []string) error {
+	if opts.debug {
+		initLog()
+	}
+
+	confPath := opts.diagnoseConfig
+	if confPath == "" {
+		confPath = defaultDeployConfigPath()
+	}
+	if _, err := os.Stat(confPath); err != nil {
+		return fmt.Errorf("stat %v failed: %v", confPath, err)
+	}
+
+	conf, err := loadDeployConfig(confPath)
+	if err != nil {
+		return fmt.Errorf("load deploy config file %v failed: %v", confPath, err)
+	}
+	if err = RunChecker(conf); err != nil {
+		return err
+	}
+
+	outputDir := opts.diagnoseOutput
+	if outputDir == "" {
+		outputDir = fmt.Sprintf("eggo-diagnose-%s-%s", conf.ClusterID, time.Now().Format("20060102150405"))
+	}
+	items, err := runDiagnose(toClusterdeploymentConfig(conf, nil), outputDir)
+	showHealthItems(os.Stdout, "Diagnose results", items)
+	return err
+}
+
+func NewDiagnoseCmd() *cobra.Command {
+	diagnoseCmd := &cobra.Command{
+		Use:   "diagnose",
+		Short: "collect journal logs, service status and config files of all nodes into tarballs",
+		RunE:  runDiagnoseCmd,
+	}
+
+	setupDiagnoseCmdOpts(diagnoseCmd)
+
+	return diagnoseCmd
+}
@@ -0,0 +1,203 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo diagnose command testcase
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/runner"
+)
+
+func itemNames(items []diagnoseItem) []string {
+	var names []string
+	for _, di := range items {
+		names = append(names, di.name)
+	}
+	return names
+}
+
+func TestDiagnoseItems(t *testing.T) {
+	ccfg := &api.ClusterConfig{
+		WorkerConfig: api.WorkerConfig{
+			ContainerEngineConf: &api.ContainerEngine{Runtime: "containerd"},
+		},
+	}
+
+	master := &api.HostConfig{Name: "master0", Address: "192.168.0.2", Type: api.Master | api.ETCD}
+	expect := []string{
+		"journal/kube-apiserver.log", "status/kube-apiserver.txt",
+		"journal/kube-controller-manager.log", "status/kube-controller-manager.txt",
+		"journal/kube-scheduler.log", "status/kube-scheduler.txt",
+		"journal/etcd.log", "status/etcd.txt",
+		"files/etc/kubernetes/admin.conf",
+		"files/etc/kubernetes/controller-manager.conf",
+		"files/etc/kubernetes/scheduler.conf",
+		"files/etc/kubernetes/encryption-config.yaml",
+		"files/etc/etcd/etcd.conf",
+	}
+	items := diagnoseItems(ccfg, master)
+	if got := itemNames(items); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("unexpect items of master: %v", got)
+	}
+	if items[0].cmd != "journalctl -u kube-apiserver --no-pager -n 2000" {
+		t.Fatalf("unexpect journal command: %s", items[0].cmd)
+	}
+	if items[1].cmd != "systemctl status kube-apiserver --no-pager -l 2>&1 || true" {
+		t.Fatalf("unexpect status command: %s", items[1].cmd)
+	}
+
+	worker := &api.HostConfig{Name: "worker0", Address: "192.168.0.3", Type: api.Worker}
+	expect = []string{
+		"journal/containerd.log", "status/containerd.txt",
+		"journal/kubelet.log", "status/kubelet.txt",
+		"journal/kube-proxy.log", "status/kube-proxy.txt",
+		"files/etc/kubernetes/kubelet_config.yaml",
+		"files/etc/kubernetes/kubelet.kubeconfig",
+		"files/etc/kubernetes/kube-proxy-config.yaml",
+		"files/etc/containerd/config.toml",
+	}
+	items = diagnoseItems(ccfg, worker)
+	if got := itemNames(items); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("unexpect items of worker: %v", got)
+	}
+	if items[len(items)-1].cmd != "cat /etc/containerd/config.toml" {
+		t.Fatalf("unexpect file command: %s", items[len(items)-1].cmd)
+	}
+
+	// etcd of external cluster is not collected
+	ccfg.EtcdCluster.External = true
+	for _, name := range itemNames(diagnoseItems(ccfg, master)) {
+		if strings.Contains(name, "etcd") {
+			t.Fatalf("collect %s of external etcd", name)
+		}
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	cases := []struct {
+		input  string
+		expect string
+	}{
+		{input: "    client-key-data: LS0tLS1CRUdJTg==", expect: "    client-key-data: ******"},
+		{input: "    token: abcdef.0123456789abcdef", expect: "    token: ******"},
+		{input: `{"password": "123456", "debug": true}`, expect: `{"password": ******, "debug": true}`},
+		{input: "--bootstrap-token=abcdef", expect: "--bootstrap-token=******"},
+		{input: "        secret: c2VjcmV0", expect: "        secret: ******"},
+		{input: "certificate-authority-data: LS0tLS1CRUdJTg==", expect: "certificate-authority-data: LS0tLS1CRUdJTg=="},
+	}
+	for _, c := range cases {
+		if got := redactSecrets(c.input); got != c.expect {
+			t.Fatalf("redact %q expect %q, got %q", c.input, c.expect, got)
+		}
+	}
+}
+
+func readDiagnoseBundle(t *testing.T, path string) map[string]string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s failed: %v", path, err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("read gzip %s failed: %v", path, err)
+	}
+	contents := make(map[string]string)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read tar %s failed: %v", path, err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("read %s failed: %v", hdr.Name, err)
+		}
+		contents[hdr.Name] = string(data)
+	}
+	return contents
+}
+
+func TestRunDiagnose(t *testing.T) {
+	ccfg := &api.ClusterConfig{
+		Name: "test-cluster",
+		Nodes: []*api.HostConfig{
+			{Name: "worker0", Address: "192.168.0.3", Type: api.Worker},
+			{Name: "worker1", Address: "192.168.0.4", Type: api.Worker},
+		},
+	}
+	outputs := make(map[string]string)
+	for _, di := range diagnoseItems(ccfg, ccfg.Nodes[0]) {
+		outputs[utils.AddSudo(di.cmd)] = di.name + "\n"
+	}
+	outputs[utils.AddSudo("cat /etc/kubernetes/kubelet.kubeconfig")] = "    client-key-data: LS0tLS1CRUdJTg==\n"
+	delete(outputs, utils.AddSudo("cat /etc/docker/daemon.json"))
+
+	oldConnect := diagnoseConnect
+	diagnoseConnect = func(hcf *api.HostConfig, hostKey *api.SSHHostKeyConfig) (runner.Runner, error) {
+		if hcf.Address != "192.168.0.3" {
+			return nil, fmt.Errorf("connection refused")
+		}
+		return preflightRunner(outputs), nil
+	}
+	defer func() {
+		diagnoseConnect = oldConnect
+	}()
+
+	outputDir, err := os.MkdirTemp("", "eggo-diagnose-")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	items, err := runDiagnose(ccfg, outputDir)
+	if err == nil {
+		t.Fatalf("expect failure of unreachable node")
+	}
+	if items[1].status != preflightFailed || !strings.Contains(items[1].message, "ssh login failed") {
+		t.Fatalf("unexpect result of unreachable node: %v", items[1])
+	}
+	if items[0].status != preflightPassed || !strings.Contains(items[0].message, "collect failed: files/etc/docker/daemon.json") {
+		t.Fatalf("unexpect result of worker: %v", items[0])
+	}
+
+	contents := readDiagnoseBundle(t, filepath.Join(outputDir, "worker0.tar.gz"))
+	if contents["journal/kubelet.log"] != "journal/kubelet.log\n" {
+		t.Fatalf("unexpect kubelet journal: %q", contents["journal/kubelet.log"])
+	}
+	if contents["files/etc/kubernetes/kubelet.kubeconfig"] != "    client-key-data: ******\n" {
+		t.Fatalf("secret is not redacted: %q", contents["files/etc/kubernetes/kubelet.kubeconfig"])
+	}
+	if !strings.Contains(contents["files/etc/docker/daemon.json"], "failed") {
+		t.Fatalf("expect error of failed collection: %q", contents["files/etc/docker/daemon.json"])
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "worker1.tar.gz")); err == nil {
+		t.Fatalf("unexpect tarball of unreachable node")
+	}
+}
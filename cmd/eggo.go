@@ -16,12 +16,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -74,14 +77,28 @@ func preCheck() {
 	}
 }
 
+// newCommandContext return context which will be canceled by SIGINT/SIGTERM or timeout
+func newCommandContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if opts.timeout <= 0 {
+		return ctx, stop
+	}
+	tctx, cancel := context.WithTimeout(ctx, opts.timeout)
+	return tctx, func() {
+		cancel()
+		stop()
+	}
+}
+
 func NewEggoCmd() *cobra.Command {
 	eggoCmd := &cobra.Command{
 		Short:         "eggo is a tool built to provide standard multi-ways for creating Kubernetes clusters",
 		Use:           "eggo",
 		SilenceUsage:  true,
 		SilenceErrors: true,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			preCheck()
+			return checkDirOverrides()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if opts.version {
@@ -95,6 +112,9 @@ func NewEggoCmd() *cobra.Command {
 		},
 	}
 	eggoCmd.PersistentFlags().BoolVarP(&opts.debug, "debug", "d", false, "Run debug mode")
+	eggoCmd.PersistentFlags().StringVarP(&opts.configDir, "config-dir", "", "", "override config dir of kubernetes in deploy config for this run, must be absolute")
+	eggoCmd.PersistentFlags().BoolVarP(&opts.failFast, "fail-fast", "", false, "stop at the first failed node, default all nodes are attempted and failures are reported together")
+	eggoCmd.PersistentFlags().StringVarP(&opts.certDir, "cert-dir", "", "", "override certificate dir of kubernetes in deploy config for this run, must be absolute")
 
 	setupEggoCmdOpts(eggoCmd)
 
@@ -104,6 +124,12 @@ func NewEggoCmd() *cobra.Command {
 	eggoCmd.AddCommand(NewJoinCmd())
 	eggoCmd.AddCommand(NewDeleteCmd())
 	eggoCmd.AddCommand(NewListCmd())
+	eggoCmd.AddCommand(NewStatusCmd())
+	eggoCmd.AddCommand(NewVerifyCmd())
+	eggoCmd.AddCommand(NewUpgradeCmd())
+	eggoCmd.AddCommand(NewPreflightCmd())
+	eggoCmd.AddCommand(NewDiagnoseCmd())
+	eggoCmd.AddCommand(NewTokenCmd())
 
 	return eggoCmd
 }
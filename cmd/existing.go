@@ -0,0 +1,128 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: detect existing cluster before deploy
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/endpoint"
+)
+
+const existingProbeTimeout = 5 * time.Second
+
+var (
+	// probeAPIServer returns nil if apiserver endpoint answers
+	probeAPIServer = func(ep string) error {
+		u, err := url.Parse(ep)
+		if err != nil {
+			return err
+		}
+		conn, err := net.DialTimeout("tcp", u.Host, existingProbeTimeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	// probeEtcdMembers returns number of etcd members queried by the first reachable master
+	probeEtcdMembers = queryEtcdMembers
+)
+
+// parse output of "etcdctl member list", format of line:
+// 8e9e05c52164694d, started, master0, https://192.168.0.2:2380, https://192.168.0.2:2379, false
+func parseEtcdMembersOutput(output string) int {
+	cnt := 0
+	for _, line := range strings.Split(output, "\n") {
+		if len(strings.Split(line, ",")) >= 5 {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+func queryEtcdMembers(ccfg *api.ClusterConfig) (int, error) {
+	masters := connectMasters(ccfg)
+	defer func() {
+		for _, m := range masters {
+			if m.r != nil {
+				m.r.Close()
+			}
+		}
+	}()
+
+	certDir := ccfg.GetCertDir()
+	cmd := fmt.Sprintf("ETCDCTL_API=3 etcdctl member list --endpoints=%s --cacert=%s/etcd/ca.crt --cert=%s/apiserver-etcd-client.crt --key=%s/apiserver-etcd-client.key",
+		api.GetEtcdServers(&ccfg.EtcdCluster), certDir, certDir, certDir)
+	for _, m := range masters {
+		if m.err != nil {
+			logrus.Debugf("connect master %s failed: %v", m.host.Address, m.err)
+			continue
+		}
+		output, err := runCommandWithTimeout(m.r, utils.AddSudo(cmd), statusCommandTimeout)
+		if err != nil {
+			return 0, err
+		}
+		return parseEtcdMembersOutput(output), nil
+	}
+	return 0, fmt.Errorf("all masters of cluster: %s are unreachable", ccfg.Name)
+}
+
+// detectExistingCluster returns reason if control plane endpoint of cluster answers and etcd has members,
+// which means cluster is already deployed, external etcd always has members
+func detectExistingCluster(ccfg *api.ClusterConfig) string {
+	ep, err := endpoint.GetAPIServerEndpoint(ccfg)
+	if err != nil {
+		logrus.Debugf("get apiserver endpoint failed: %v", err)
+		return ""
+	}
+	if err = probeAPIServer(ep); err != nil {
+		logrus.Debugf("apiserver %s does not answer: %v", ep, err)
+		return ""
+	}
+	if ccfg.EtcdCluster.External {
+		return fmt.Sprintf("apiserver %s answers", ep)
+	}
+
+	cnt, err := probeEtcdMembers(ccfg)
+	if err != nil {
+		logrus.Debugf("query etcd members failed: %v", err)
+		return ""
+	}
+	if cnt == 0 {
+		return ""
+	}
+	return fmt.Sprintf("apiserver %s answers and etcd has %d members", ep, cnt)
+}
+
+// checkExistingCluster refuses to deploy existing cluster without force, to avoid reinitializing control plane
+func checkExistingCluster(conf *DeployConfig, force bool) error {
+	reason := detectExistingCluster(toClusterdeploymentConfig(conf, nil))
+	if reason == "" {
+		return nil
+	}
+	if force {
+		logrus.Warnf("cluster: %s seems to exist: %s, deploy it by force", conf.ClusterID, reason)
+		return nil
+	}
+	return fmt.Errorf("cluster: %s seems to exist: %s, use \"eggo join\" to add nodes, or deploy with --force to reinitialize it", conf.ClusterID, reason)
+}
@@ -0,0 +1,111 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for detecting existing cluster
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func TestParseEtcdMembersOutput(t *testing.T) {
+	output := `8e9e05c52164694d, started, master0, https://192.168.0.2:2380, https://192.168.0.2:2379, false
+91bc3c398fb3c146, started, master1, https://192.168.0.3:2380, https://192.168.0.3:2379, false
+`
+	if cnt := parseEtcdMembersOutput(output); cnt != 2 {
+		t.Fatalf("expect 2 members, get: %d", cnt)
+	}
+	if cnt := parseEtcdMembersOutput("Error: context deadline exceeded\n"); cnt != 0 {
+		t.Fatalf("expect no member, get: %d", cnt)
+	}
+}
+
+func TestProbeAPIServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	addr := l.Addr().String()
+	if err = probeAPIServer("https://" + addr); err != nil {
+		t.Fatalf("expect listening endpoint answers: %v", err)
+	}
+	l.Close()
+	if err = probeAPIServer("https://" + addr); err == nil {
+		t.Fatalf("expect closed endpoint does not answer")
+	}
+}
+
+func TestCheckExistingCluster(t *testing.T) {
+	// init opts
+	if NewEggoCmd() == nil {
+		t.Fatalf("failed to create eggo command")
+	}
+	f := filepath.Join(t.TempDir(), "config.yaml")
+	if err := createDeployConfigTemplate(f); err != nil {
+		t.Fatalf("create deploy template config file failed: %v", err)
+	}
+	conf, err := loadDeployConfig(f)
+	if err != nil {
+		t.Fatalf("load deploy config file failed: %v", err)
+	}
+
+	oldAPIServer, oldEtcdMembers := probeAPIServer, probeEtcdMembers
+	defer func() {
+		probeAPIServer, probeEtcdMembers = oldAPIServer, oldEtcdMembers
+	}()
+	var apiserverErr, etcdErr error
+	members := 0
+	probeAPIServer = func(ep string) error {
+		return apiserverErr
+	}
+	probeEtcdMembers = func(ccfg *api.ClusterConfig) (int, error) {
+		return members, etcdErr
+	}
+
+	cases := []struct {
+		name         string
+		apiserverErr error
+		members      int
+		etcdErr      error
+		external     bool
+		exist        bool
+	}{
+		{name: "new cluster", apiserverErr: errors.New("connection refused")},
+		{name: "apiserver without etcd members", members: 0},
+		{name: "etcd query failed", etcdErr: errors.New("certificate not found")},
+		{name: "existing cluster", members: 3, exist: true},
+		{name: "external etcd", external: true, exist: true},
+	}
+	for _, c := range cases {
+		apiserverErr, members, etcdErr = c.apiserverErr, c.members, c.etcdErr
+		conf.EtcdExternal = c.external
+		err = checkExistingCluster(conf, false)
+		if c.exist != (err != nil) {
+			t.Fatalf("%s: expect exist %v, get: %v", c.name, c.exist, err)
+		}
+		if c.exist && !strings.Contains(err.Error(), "--force") {
+			t.Fatalf("%s: expect hint of --force, get: %v", c.name, err)
+		}
+		// force to deploy existing cluster
+		if err = checkExistingCluster(conf, true); err != nil {
+			t.Fatalf("%s: expect deploy by force, get: %v", c.name, err)
+		}
+	}
+}
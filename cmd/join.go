@@ -88,6 +88,9 @@ func parseJoinInput(joinYaml string, host *HostConfig, joinType string, clusterI
 	if len(conf.Masters) == 0 && len(conf.Workers) == 0 {
 		return nil, fmt.Errorf("no join ip address found")
 	}
+	if err = normalizeDeployConfigArch(conf); err != nil {
+		return nil, err
+	}
 
 	return conf, nil
 }
@@ -244,16 +247,21 @@ func joinCluster(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	hooksConf, err := getClusterHookConf(api.HookOpJoin)
+	hooksConf, err := getClusterHookConf(mergedConf, api.HookOpJoin)
 	if err != nil {
 		return fmt.Errorf("get cmd hooks config failed:%v", err)
 	}
 
-	cstatus, err := clusterdeployment.JoinNodes(toClusterdeploymentConfig(conf, hooksConf), diffConfigs)
-	if err != nil {
+	ctx, cancel := newCommandContext()
+	defer cancel()
+	cstatus, err := clusterdeployment.JoinNodes(ctx, toClusterdeploymentConfig(conf, hooksConf), diffConfigs)
+	if err != nil && ctx.Err() != nil {
+		// interrupted, keep joined nodes in config, and let user to delete them
+		fmt.Printf("Warn: join is interrupted, you can call \"eggo delete --id %s [failed nodes id]\" to remove failed nodes from your cluster.\n", joinConf.ClusterID)
+	} else if err != nil {
 		failedConfigs := getFailedConfigs(diffConfigs, cstatus)
 		// rollback
-		if err1 := clusterdeployment.DeleteNodes(toClusterdeploymentConfig(mergedConf, nil), failedConfigs); err1 != nil {
+		if err1 := clusterdeployment.DeleteNodes(ctx, toClusterdeploymentConfig(mergedConf, nil), failedConfigs); err1 != nil {
 			logrus.Errorf("delete nodes failed when join failed: %v", err1)
 		}
 
@@ -16,9 +16,11 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
-	"os"
+	"io/ioutil"
 	"path/filepath"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -27,87 +29,105 @@ import (
 )
 
 type clusterInfo struct {
-	name      string
-	masterCnt int
-	workerCnt int
-	status    string
+	Name       string     `json:"cluster-id"`
+	MasterCnt  int        `json:"masters"`
+	WorkerCnt  int        `json:"workers"`
+	EtcdCnt    int        `json:"etcds"`
+	DeployTime *time.Time `json:"deploy-time,omitempty"`
+	Status     string     `json:"status"`
+	Message    string     `json:"message,omitempty"`
 }
 
-var (
-	infos []clusterInfo
-)
-
-func addClusterInfo(name string, conf *DeployConfig, err error) {
+// getClusterInfo gets info of cluster from deploy config and state in home dir of cluster,
+// cluster with corrupt or missing deploy config or state is unknown
+func getClusterInfo(dir string) clusterInfo {
 	info := clusterInfo{
-		name: name,
+		Name:   filepath.Base(dir),
+		Status: stateUnknown,
 	}
+	conf, err := loadDeployConfig(filepath.Join(dir, "deploy.yaml"))
 	if err != nil {
-		info.status = "unknow"
-		logrus.Debugf("%s: %s", info.name, err.Error())
-		infos = append(infos, info)
-		return
-	}
-	if conf.Masters != nil {
-		info.masterCnt = len(conf.Masters)
-	}
-	if conf.Workers != nil {
-		info.workerCnt = len(conf.Workers)
+		logrus.Debugf("%s: %v", info.Name, err)
+		info.Message = fmt.Sprintf("load deploy config failed: %v", err)
+		return info
 	}
+	info.MasterCnt, info.WorkerCnt, info.EtcdCnt = len(conf.Masters), len(conf.Workers), len(conf.Etcds)
 
-	if terr := RunChecker(conf); terr != nil {
-		info.status = "broken"
-		logrus.Debugf("%s: %s", info.name, terr.Error())
-	} else {
-		info.status = "success"
+	if err = RunChecker(conf); err != nil {
+		logrus.Debugf("%s: %v", info.Name, err)
+		info.Status, info.Message = "broken", err.Error()
+		return info
 	}
 
-	infos = append(infos, info)
+	state, err := loadClusterState(filepath.Join(dir, "state.json"))
+	if err != nil {
+		logrus.Debugf("%s: %v", info.Name, err)
+		return info
+	}
+	info.DeployTime = &state.DeployTime
+	info.Status, info.Message = state.Status, state.Message
+	return info
 }
 
-func checkFile(path string, info os.FileInfo, err error) error {
+func collectClustersInfo(eggoDir string) ([]clusterInfo, error) {
+	files, err := ioutil.ReadDir(eggoDir)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if !info.IsDir() {
-		logrus.Debugf("ingore non-dir: %q", path)
-		return nil
-	}
-
-	if path == api.GetEggoClusterPath() {
-		return nil
+	var infos []clusterInfo
+	for _, f := range files {
+		if !f.IsDir() {
+			logrus.Debugf("ingore non-dir: %q", f.Name())
+			continue
+		}
+		infos = append(infos, getClusterInfo(filepath.Join(eggoDir, f.Name())))
 	}
-
-	conf, err := loadDeployConfig(savedDeployConfigPath(info.Name()))
-	addClusterInfo(info.Name(), conf, err)
-	return filepath.SkipDir
+	return infos, nil
 }
 
-func showClustersInfo() {
+func showClustersInfo(infos []clusterInfo) {
 	maxLen := 8
 	for _, info := range infos {
-		if len(info.name) > maxLen {
-			maxLen = len(info.name)
+		if len(info.Name) > maxLen {
+			maxLen = len(info.Name)
 		}
 	}
-	fmt.Printf("Name%*s\tMasters\tWorkers\tStatus\n", maxLen, "")
+	fmt.Printf("%-*s\tMasters\tWorkers\tEtcds\t%-19s\tStatus\n", maxLen, "Name", "DeployTime")
 	for _, info := range infos {
-		fmt.Printf("%s%*s\t%d\t%d\t%s\n", info.name, len(info.name)-maxLen, "", info.masterCnt, info.workerCnt, info.status)
+		deployTime := "-"
+		if info.DeployTime != nil {
+			deployTime = info.DeployTime.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%-*s\t%d\t%d\t%d\t%-19s\t%s\n", maxLen, info.Name, info.MasterCnt, info.WorkerCnt, info.EtcdCnt, deployTime, info.Status)
 	}
 }
 
 func listClusters(cmd *cobra.Command, args []string) error {
-	infos = nil
 	if opts.debug {
 		initLog()
 	}
+	if opts.listOutput != "" && opts.listOutput != "json" {
+		return fmt.Errorf("unsupported output format: %s, only json is supported", opts.listOutput)
+	}
 
 	eggoDir := api.GetEggoClusterPath()
-
-	if err := filepath.Walk(eggoDir, checkFile); err != nil {
-		logrus.Debugf("walk eggo cluster dir: %s, err: %v\n", eggoDir, err)
+	infos, err := collectClustersInfo(eggoDir)
+	if err != nil {
+		logrus.Debugf("read eggo cluster dir: %s, err: %v\n", eggoDir, err)
 	}
 
-	showClustersInfo()
+	if opts.listOutput == "json" {
+		if infos == nil {
+			infos = []clusterInfo{}
+		}
+		data, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	showClustersInfo(infos)
 
 	return nil
 }
@@ -119,5 +139,7 @@ func NewListCmd() *cobra.Command {
 		RunE:  listClusters,
 	}
 
+	setupListCmdOpts(listCmd)
+
 	return listCmd
 }
@@ -0,0 +1,122 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for eggo list command
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func TestNewClusterState(t *testing.T) {
+	cases := []struct {
+		cstatus api.ClusterStatus
+		err     error
+		expect  string
+	}{
+		{cstatus: api.ClusterStatus{Working: true}, expect: stateSuccess},
+		{cstatus: api.ClusterStatus{Working: true, FailureCnt: 1}, expect: statePartial},
+		{cstatus: api.ClusterStatus{Working: false, Message: "no master"}, expect: stateFailed},
+		{err: errors.New("deploy timeout"), expect: stateFailed},
+	}
+	for _, c := range cases {
+		if s := newClusterState("k8s-cluster", &c.cstatus, c.err); s.Status != c.expect || s.ClusterID != "k8s-cluster" {
+			t.Fatalf("expect status %s of %+v, %v, get: %+v", c.expect, c.cstatus, c.err, s)
+		}
+	}
+}
+
+func TestCollectClustersInfo(t *testing.T) {
+	eggoDir := t.TempDir()
+	// init opts
+	if NewEggoCmd() == nil {
+		t.Fatalf("failed to create eggo command")
+	}
+
+	writeCluster := func(name string, withState bool, state string) {
+		dir := filepath.Join(eggoDir, name)
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		if err := createDeployConfigTemplate(filepath.Join(dir, "deploy.yaml")); err != nil {
+			t.Fatalf("create deploy config failed: %v", err)
+		}
+		if withState {
+			if err := ioutil.WriteFile(filepath.Join(dir, "state.json"), []byte(state), 0640); err != nil {
+				t.Fatalf("write state failed: %v", err)
+			}
+		}
+	}
+	writeCluster("deployed", true, "")
+	saveClusterState(newClusterState("deployed", &api.ClusterStatus{Working: true, FailureCnt: 1}, nil), filepath.Join(eggoDir, "deployed", "state.json"))
+	writeCluster("corrupt-state", true, "{\"status\": ")
+	writeCluster("no-state", false, "")
+	if err := os.MkdirAll(filepath.Join(eggoDir, "corrupt-config"), 0750); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(eggoDir, "corrupt-config", "deploy.yaml"), []byte("masters: [\n"), 0640); err != nil {
+		t.Fatalf("write deploy config failed: %v", err)
+	}
+	// files in eggo dir are ignored
+	if err := ioutil.WriteFile(filepath.Join(eggoDir, "deploy.yaml"), []byte(""), 0640); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+
+	conf, err := loadDeployConfig(filepath.Join(eggoDir, "deployed", "deploy.yaml"))
+	if err != nil {
+		t.Fatalf("load deploy config failed: %v", err)
+	}
+	for _, fn := range conf.InstallConfig.PackageSrc.SrcPath {
+		if err = os.MkdirAll(fn, 0755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		defer os.RemoveAll(fn)
+	}
+
+	infos, err := collectClustersInfo(eggoDir)
+	if err != nil {
+		t.Fatalf("collect clusters info failed: %v", err)
+	}
+	if len(infos) != 4 {
+		t.Fatalf("expect 4 clusters, get: %+v", infos)
+	}
+	got := make(map[string]clusterInfo)
+	for _, info := range infos {
+		got[info.Name] = info
+	}
+
+	deployed := got["deployed"]
+	if deployed.Status != statePartial || deployed.DeployTime == nil || deployed.MasterCnt != len(conf.Masters) ||
+		deployed.WorkerCnt != len(conf.Workers) || deployed.EtcdCnt != len(conf.Etcds) {
+		t.Fatalf("invalid info of deployed cluster: %+v", deployed)
+	}
+	for _, name := range []string{"corrupt-state", "no-state"} {
+		if info := got[name]; info.Status != stateUnknown || info.DeployTime != nil || info.MasterCnt != len(conf.Masters) {
+			t.Fatalf("expect %s is unknown with node counts, get: %+v", name, info)
+		}
+	}
+	if info := got["corrupt-config"]; info.Status != stateUnknown || info.MasterCnt != 0 || info.Message == "" {
+		t.Fatalf("expect corrupt-config is unknown, get: %+v", info)
+	}
+
+	if _, err = collectClustersInfo(filepath.Join(eggoDir, "not-exist")); err == nil {
+		t.Fatalf("expect collect from not exist dir failed")
+	}
+}
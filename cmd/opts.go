@@ -17,10 +17,14 @@ package cmd
 
 import (
 	"os"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"isula.org/eggo/pkg/clusterdeployment"
+	"isula.org/eggo/pkg/clusterdeployment/binary/commontools"
 	"isula.org/eggo/pkg/constants"
 	"isula.org/eggo/pkg/utils"
 )
@@ -28,6 +32,8 @@ import (
 type eggoOptions struct {
 	name                 string
 	templateConfig       string
+	templateMinimal      bool
+	templateSingleNode   bool
 	masters              []string
 	nodes                []string
 	etcds                []string
@@ -36,19 +42,58 @@ type eggoOptions struct {
 	password             string
 	deployConfig         string
 	deployEnableRollback bool
+	deployForce          bool
+	deployOutput         string
+	kubeconfigOut        string
+	metricsOut           string
+	skipPreflight        bool
+	validateOnly         bool
+	onlyPhase            string
+	preflightConfig      string
+	preflightFix         bool
 	cleanupConfig        string
 	cleanupClusterID     string
 	debug                bool
+	configDir            string
+	certDir              string
+	failFast             bool
+	quiet                bool
 	version              bool
 	joinType             string
 	joinClusterID        string
 	joinYaml             string
 	joinHost             HostConfig
 	delClusterID         string
+	delNodes             []string
+	delForce             bool
+	drainGracePeriod     int
+	drainTimeout         time.Duration
+	drainForce           bool
+	drainStrict          bool
+	statusConfig         string
+	statusClusterID      string
+	diagnoseConfig       string
+	diagnoseOutput       string
+	verifyConfig         string
+	verifyClusterID      string
+	verifyNamespace      string
+	verifyImage          string
 	clusterPrehook       string
 	clusterPosthook      string
 	prehook              string
 	posthook             string
+	upgradeClusterID     string
+	upgradeVersion       string
+	upgradePackages      map[string]string
+	upgradeSha256        map[string]string
+	upgradeNodeTimeout   time.Duration
+	tokenConfig          string
+	tokenClusterID       string
+	tokenTTL             time.Duration
+	tokenUsages          []string
+	tokenGroups          []string
+	listOutput           string
+	timeout              time.Duration
 }
 
 var opts eggoOptions
@@ -72,8 +117,24 @@ func setupDeployCmdOpts(deployCmd *cobra.Command) {
 	flags := deployCmd.Flags()
 	flags.StringVarP(&opts.deployConfig, "file", "f", defaultDeployConfigPath(), "location of cluster deploy config file, default $HOME/.eggo/deploy.yaml")
 	flags.BoolVarP(&opts.deployEnableRollback, "rollback", "", true, "rollback failed node to cleanup")
+	flags.BoolVarP(&opts.deployForce, "force", "", false, "deploy even if apiserver and etcd of cluster already exist, control plane will be reinitialized")
+	flags.StringVarP(&opts.kubeconfigOut, "kubeconfig-out", "", "", "location to write admin kubeconfig, default $HOME/.eggo/<cluster-id>/admin.kubeconfig")
+	flags.StringVarP(&opts.deployOutput, "output", "o", "", "output format of deploy result, only json is supported, logs are written to stderr")
+	flags.StringVarP(&opts.metricsOut, "metrics-out", "", "", "location to write timing metrics of deployment as json")
+	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "do not print progress of deployment")
 	flags.StringVarP(&opts.clusterPrehook, "cluster-prehook", "", "", "cluser prehooks when deploy cluser")
 	flags.StringVarP(&opts.clusterPosthook, "cluster-posthook", "", "", "cluster posthook when deploy cluster")
+	flags.DurationVarP(&opts.timeout, "timeout", "", 0, "timeout to deploy cluster, such as 30m, 0 means no timeout")
+	flags.BoolVarP(&opts.skipPreflight, "skip-preflight", "", false, "skip preflight checks of nodes before deploy")
+	flags.BoolVarP(&opts.validateOnly, "validate-only", "", false, "only validate deploy config and print all problems, no node is connected")
+	flags.StringVarP(&opts.onlyPhase, "only-phase", "", "", "only run one phase of deploy, assume former phases are done: "+
+		strings.Join(clusterdeployment.DeployPhases(), ", "))
+}
+
+func setupPreflightCmdOpts(preflightCmd *cobra.Command) {
+	flags := preflightCmd.Flags()
+	flags.StringVarP(&opts.preflightConfig, "file", "f", defaultDeployConfigPath(), "location of cluster deploy config file, default $HOME/.eggo/deploy.yaml")
+	flags.BoolVarP(&opts.preflightFix, "fix", "", false, "disable swap, load kernel modules and set sysctls required by kubernetes on nodes")
 }
 
 func setupCleanupCmdOpts(cleanupCmd *cobra.Command) {
@@ -82,6 +143,10 @@ func setupCleanupCmdOpts(cleanupCmd *cobra.Command) {
 	flags.StringVarP(&opts.cleanupClusterID, "id", "", "", "cluster id")
 	flags.StringVarP(&opts.clusterPrehook, "cluster-prehook", "", "", "cluser prehooks when clenaup cluser")
 	flags.StringVarP(&opts.clusterPosthook, "cluster-posthook", "", "", "cluster posthook when cleaup cluster")
+	flags.DurationVarP(&opts.timeout, "timeout", "", 0, "timeout to cleanup cluster, such as 30m, 0 means no timeout")
+	flags.IntVarP(&opts.drainGracePeriod, "grace-period", "", 0, "seconds for pods to terminate gracefully when drain workers, 0 uses grace period of pod")
+	flags.DurationVarP(&opts.drainTimeout, "drain-timeout", "", 0, "timeout of evicting pods when drain workers, default 120s")
+	flags.BoolVarP(&opts.drainForce, "force", "", false, "delete pods ignore PodDisruptionBudgets if eviction timeout")
 }
 
 func setupJoinCmdOpts(joinCmd *cobra.Command) {
@@ -94,13 +159,65 @@ func setupJoinCmdOpts(joinCmd *cobra.Command) {
 	flags.StringVarP(&opts.joinYaml, "file", "f", "", "yaml file contain nodes information")
 	flags.StringVarP(&opts.prehook, "prehook", "", "", "prehook when join cluster")
 	flags.StringVarP(&opts.posthook, "posthook", "", "", "posthook when join cluster")
+	flags.DurationVarP(&opts.timeout, "timeout", "", 0, "timeout to join nodes, such as 30m, 0 means no timeout")
 }
 
 func setupDeleteCmdOpts(deleteCmd *cobra.Command) {
 	flags := deleteCmd.Flags()
 	flags.StringVarP(&opts.delClusterID, "id", "", "", "cluster id")
+	flags.StringArrayVarP(&opts.delNodes, "node", "", []string{}, "ip or name of node to delete, can be set multiple times")
+	flags.BoolVarP(&opts.delForce, "force", "", false, "force to delete node with master or etcd role, and delete pods ignore PodDisruptionBudgets if eviction timeout")
+	flags.IntVarP(&opts.drainGracePeriod, "grace-period", "", 0, "seconds for pods to terminate gracefully when drain nodes, 0 uses grace period of pod")
+	flags.DurationVarP(&opts.drainTimeout, "drain-timeout", "", 0, "timeout of evicting pods when drain nodes, default 120s")
+	flags.BoolVarP(&opts.drainStrict, "drain-strict", "", false, "abort delete of node if drain it failed")
 	flags.StringVarP(&opts.prehook, "prehook", "", "", "prehook when delete cluster")
 	flags.StringVarP(&opts.posthook, "posthook", "", "", "posthook when delete cluster")
+	flags.DurationVarP(&opts.timeout, "timeout", "", 0, "timeout to delete nodes, such as 30m, 0 means no timeout")
+}
+
+func setupStatusCmdOpts(statusCmd *cobra.Command) {
+	flags := statusCmd.Flags()
+	flags.StringVarP(&opts.statusConfig, "file", "f", "", "location of cluster deploy config file")
+	flags.StringVarP(&opts.statusClusterID, "id", "", "", "cluster id")
+}
+
+func setupDiagnoseCmdOpts(diagnoseCmd *cobra.Command) {
+	flags := diagnoseCmd.Flags()
+	flags.StringVarP(&opts.diagnoseConfig, "file", "f", defaultDeployConfigPath(), "location of cluster deploy config file, default $HOME/.eggo/deploy.yaml")
+	flags.StringVarP(&opts.diagnoseOutput, "output", "o", "", "local dir to save tarballs of nodes, default eggo-diagnose-<cluster id>-<time>")
+}
+
+func setupVerifyCmdOpts(verifyCmd *cobra.Command) {
+	flags := verifyCmd.Flags()
+	flags.StringVarP(&opts.verifyConfig, "file", "f", "", "location of cluster deploy config file")
+	flags.StringVarP(&opts.verifyClusterID, "id", "", "", "cluster id")
+	flags.StringVarP(&opts.verifyNamespace, "namespace", "n", "eggo-verify", "namespace of test workload, it will be deleted after verify")
+	flags.StringVarP(&opts.verifyImage, "image", "", "busybox:1.28", "image of test workload")
+}
+
+func setupUpgradeCmdOpts(upgradeCmd *cobra.Command) {
+	flags := upgradeCmd.Flags()
+	flags.StringVarP(&opts.upgradeClusterID, "id", "", "", "cluster id")
+	flags.StringVarP(&opts.upgradeVersion, "target-version", "", "", "kubernetes version to upgrade, such as v1.21.1")
+	flags.StringToStringVarP(&opts.upgradePackages, "package", "", nil, "package of target version for arch, such as amd64=/root/packages-amd64.tar.gz")
+	flags.StringToStringVarP(&opts.upgradeSha256, "package-sha256", "", nil, "sha256 of package for arch, such as amd64=<sha256>")
+	flags.DurationVarP(&opts.upgradeNodeTimeout, "node-timeout", "", time.Minute*constants.DefaultNodeReadyWaitMinutes, "timeout to wait node ready after upgrade")
+	flags.DurationVarP(&opts.timeout, "timeout", "", 0, "timeout to upgrade cluster, such as 1h, 0 means no timeout")
+}
+
+func setupListCmdOpts(listCmd *cobra.Command) {
+	flags := listCmd.Flags()
+	flags.StringVarP(&opts.listOutput, "output", "o", "", "output format, only json is supported, default is table")
+}
+
+func setupTokenCmdOpts(tokenCmd, createCmd *cobra.Command) {
+	flags := tokenCmd.PersistentFlags()
+	flags.StringVarP(&opts.tokenConfig, "file", "f", "", "location of cluster deploy config file")
+	flags.StringVarP(&opts.tokenClusterID, "id", "", "", "cluster id")
+	createFlags := createCmd.Flags()
+	createFlags.DurationVarP(&opts.tokenTTL, "ttl", "", 24*time.Hour, "duration before the token is expired, such as 1h, max is 8760h")
+	createFlags.StringSliceVarP(&opts.tokenUsages, "usages", "", commontools.DefaultBootstrapTokenUsages, "usages of the token, can be \"signing,authentication\"")
+	createFlags.StringSliceVarP(&opts.tokenGroups, "groups", "", commontools.DefaultBootstrapTokenGroups, "extra groups of the token, must be in format of system:bootstrappers:<name>")
 }
 
 func setupTemplateCmdOpts(templateCmd *cobra.Command) {
@@ -113,4 +230,6 @@ func setupTemplateCmdOpts(templateCmd *cobra.Command) {
 	flags.StringArrayVarP(&opts.etcds, "etcds", "", nil, "set etcd node ips")
 	flags.StringVarP(&opts.loadbalance, "loadbalance", "l", "192.168.0.1", "set loadbalance node")
 	flags.StringVarP(&opts.templateConfig, "file", "f", "template.yaml", "location of eggo's template config file, default $(current)/template.yaml")
+	flags.BoolVarP(&opts.templateMinimal, "minimal", "", false, "only create required fields of config, others use defaults of eggo")
+	flags.BoolVarP(&opts.templateSingleNode, "single-node", "", false, "create config of all-in-one cluster on the first master, which is the host eggo runs on")
 }
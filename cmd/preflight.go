@@ -0,0 +1,419 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo preflight command implement
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment/runtime"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/runner"
+)
+
+const (
+	preflightConnectTimeout = 30 * time.Second
+	preflightCommandTimeout = 30 * time.Second
+	preflightPassed         = "pass"
+	preflightFailed         = "fail"
+
+	// default thresholds of resources
+	defaultMasterMinCPUs     = 2
+	defaultMasterMinMemoryMB = 1700
+	defaultWorkerMinCPUs     = 1
+	defaultWorkerMinMemoryMB = 1024
+	defaultMinConfigDiskMB   = 1024
+	defaultMinEtcdDiskMB     = 2048
+)
+
+var (
+	// base tools required by deployment on every node
+	preflightRequiredTools = []string{"tar", "systemctl"}
+	// kernel modules and sysctls required by kubelet and network
+	preflightRequiredModules = []string{"br_netfilter", "overlay"}
+	preflightRequiredSysctls = []struct {
+		key   string
+		value string
+	}{
+		{key: "net.bridge.bridge-nf-call-iptables", value: "1"},
+		{key: "net.ipv4.ip_forward", value: "1"},
+	}
+
+	// replaced in testcase
+	preflightConnect = func(hcf *api.HostConfig, hostKey *api.SSHHostKeyConfig) (runner.Runner, error) {
+		return connectWithTimeout(hcf, hostKey, preflightConnectTimeout)
+	}
+)
+
+func checkNodeSudo(r runner.Runner, host *api.HostConfig) error {
+	// elevated by runner with elevate command and sudo password of host
+	if _, err := runCommandWithTimeout(r, utils.AddSudo("true"), preflightCommandTimeout); err != nil {
+		return fmt.Errorf("elevate privilege is unavailable: %v", err)
+	}
+	return nil
+}
+
+func checkNodeArch(r runner.Runner, host *api.HostConfig) error {
+	output, err := runCommandWithTimeout(r, "uname -m", preflightCommandTimeout)
+	if err != nil {
+		return fmt.Errorf("get arch failed: %v", err)
+	}
+	actual, err := api.NormalizeArch(output)
+	if err != nil {
+		return err
+	}
+	expect, err := api.NormalizeArch(host.Arch)
+	if err != nil {
+		return err
+	}
+	if actual != expect {
+		return fmt.Errorf("arch is %s, but %s is declared", actual, expect)
+	}
+	return nil
+}
+
+func checkNodeTools(r runner.Runner, host *api.HostConfig) error {
+	var missing []string
+	for _, tool := range preflightRequiredTools {
+		if _, err := runCommandWithTimeout(r, fmt.Sprintf("command -v %s", tool), preflightCommandTimeout); err != nil {
+			missing = append(missing, tool)
+		}
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("missing tools: %s", strings.Join(missing, ","))
+	}
+	return nil
+}
+
+func setIfIntConfigZero(v *int, def int) {
+	if *v == 0 {
+		*v = def
+	}
+}
+
+func getPreflightThresholds(conf *DeployConfig) PreflightConfig {
+	th := conf.Preflight
+	setIfIntConfigZero(&th.MasterMinCPUs, defaultMasterMinCPUs)
+	setIfIntConfigZero(&th.MasterMinMemoryMB, defaultMasterMinMemoryMB)
+	setIfIntConfigZero(&th.WorkerMinCPUs, defaultWorkerMinCPUs)
+	setIfIntConfigZero(&th.WorkerMinMemoryMB, defaultWorkerMinMemoryMB)
+	setIfIntConfigZero(&th.MinConfigDiskMB, defaultMinConfigDiskMB)
+	setIfIntConfigZero(&th.MinEtcdDiskMB, defaultMinEtcdDiskMB)
+	return th
+}
+
+func runIntCommand(r runner.Runner, cmd string) (int, error) {
+	output, err := runCommandWithTimeout(r, cmd, preflightCommandTimeout)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(output))
+}
+
+func checkNodeCPUAndMemory(r runner.Runner, host *api.HostConfig, th PreflightConfig) error {
+	minCPUs, minMemoryMB := 0, 0
+	if utils.IsType(host.Type, api.Master) {
+		minCPUs, minMemoryMB = th.MasterMinCPUs, th.MasterMinMemoryMB
+	} else if utils.IsType(host.Type, api.Worker) {
+		minCPUs, minMemoryMB = th.WorkerMinCPUs, th.WorkerMinMemoryMB
+	}
+
+	var failures []string
+	if minCPUs > 0 {
+		cpus, err := runIntCommand(r, "nproc")
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("get cpus failed: %v", err))
+		} else if cpus < minCPUs {
+			failures = append(failures, fmt.Sprintf("cpus %d is less than %d", cpus, minCPUs))
+		}
+	}
+	if minMemoryMB > 0 {
+		memKB, err := runIntCommand(r, "awk '/^MemTotal:/{print $2}' /proc/meminfo")
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("get memory failed: %v", err))
+		} else if memKB/1024 < minMemoryMB {
+			failures = append(failures, fmt.Sprintf("memory %dMB is less than %dMB", memKB/1024, minMemoryMB))
+		}
+	}
+	if len(failures) != 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// dir may not exist before deploy, so check free space of its nearest exist parent
+func diskAvailableCommand(dir string) string {
+	return fmt.Sprintf("d=%s; while [ ! -e \"$d\" ]; do d=$(dirname \"$d\"); done; df -Pm \"$d\" | awk 'NR==2{print $4}'", dir)
+}
+
+func checkNodeDisk(r runner.Runner, dirs map[string]int) error {
+	var failures []string
+	for dir, minMB := range dirs {
+		if minMB <= 0 {
+			continue
+		}
+		availMB, err := runIntCommand(r, diskAvailableCommand(dir))
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("get free disk of %s failed: %v", dir, err))
+		} else if availMB < minMB {
+			failures = append(failures, fmt.Sprintf("free disk of %s %dMB is less than %dMB", dir, availMB, minMB))
+		}
+	}
+	if len(failures) != 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+type kernelStatus struct {
+	swapOn         bool
+	missingModules []string
+	wrongSysctls   []string
+}
+
+func (ks *kernelStatus) ok() bool {
+	return !ks.swapOn && len(ks.missingModules) == 0 && len(ks.wrongSysctls) == 0
+}
+
+func (ks *kernelStatus) String() string {
+	var problems []string
+	if ks.swapOn {
+		problems = append(problems, "swap is on")
+	}
+	if len(ks.missingModules) != 0 {
+		problems = append(problems, fmt.Sprintf("kernel modules not loaded: %s", strings.Join(ks.missingModules, ",")))
+	}
+	if len(ks.wrongSysctls) != 0 {
+		problems = append(problems, fmt.Sprintf("sysctls not set to 1: %s", strings.Join(ks.wrongSysctls, ",")))
+	}
+	return strings.Join(problems, "; ")
+}
+
+func getKernelStatus(r runner.Runner) (*kernelStatus, error) {
+	ks := &kernelStatus{}
+	// first line of /proc/swaps is header
+	output, err := runCommandWithTimeout(r, "tail -n +2 /proc/swaps", preflightCommandTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("get swap status failed: %v", err)
+	}
+	ks.swapOn = strings.TrimSpace(output) != ""
+
+	// builtin modules are not listed by lsmod, but exist in /sys/module
+	for _, m := range preflightRequiredModules {
+		if _, err = runCommandWithTimeout(r, fmt.Sprintf("test -d /sys/module/%s", m), preflightCommandTimeout); err != nil {
+			ks.missingModules = append(ks.missingModules, m)
+		}
+	}
+
+	for _, sc := range preflightRequiredSysctls {
+		output, err = runCommandWithTimeout(r, fmt.Sprintf("sysctl -n %s", sc.key), preflightCommandTimeout)
+		if err != nil || strings.TrimSpace(output) != sc.value {
+			ks.wrongSysctls = append(ks.wrongSysctls, sc.key)
+		}
+	}
+	return ks, nil
+}
+
+// kernelRemediation returns idempotent commands to fix problems of kernel, and persist the fix
+func kernelRemediation(ks *kernelStatus) []string {
+	var cmds []string
+	if ks.swapOn {
+		cmds = append(cmds, "swapoff -a",
+			`sed -ri 's/^([^#].*[[:space:]]swap[[:space:]].*)$/#\1/' /etc/fstab`)
+	}
+	if len(ks.missingModules) != 0 {
+		for _, m := range ks.missingModules {
+			cmds = append(cmds, fmt.Sprintf("modprobe %s", m))
+		}
+		cmds = append(cmds, fmt.Sprintf("printf '%s\\n' > /etc/modules-load.d/eggo.conf",
+			strings.Join(preflightRequiredModules, "\\n")))
+	}
+	if len(ks.wrongSysctls) != 0 {
+		var confs []string
+		for _, sc := range preflightRequiredSysctls {
+			cmds = append(cmds, fmt.Sprintf("sysctl -w %s=%s", sc.key, sc.value))
+			confs = append(confs, fmt.Sprintf("%s = %s", sc.key, sc.value))
+		}
+		cmds = append(cmds, fmt.Sprintf("printf '%s\\n' > /etc/sysctl.d/99-eggo.conf", strings.Join(confs, "\\n")))
+	}
+	return cmds
+}
+
+func checkNodeKernel(r runner.Runner, host *api.HostConfig, fix bool) error {
+	ks, err := getKernelStatus(r)
+	if err != nil {
+		return err
+	}
+	if ks.ok() {
+		return nil
+	}
+	if !fix {
+		return fmt.Errorf("%s", ks.String())
+	}
+
+	shell := "#!/bin/bash\nset -e\n" + strings.Join(kernelRemediation(ks), "\n") + "\n"
+	if _, err = r.RunShell(shell, "eggoPreflightFix"); err != nil {
+		return fmt.Errorf("fix %s failed: %v", ks.String(), err)
+	}
+	if ks, err = getKernelStatus(r); err != nil {
+		return err
+	}
+	if !ks.ok() {
+		return fmt.Errorf("%s after fix", ks.String())
+	}
+	return nil
+}
+
+// checkNodeCgroupDriver checks cgroup driver of runtime on worker matches kubelet, skip if runtime is not running
+func checkNodeCgroupDriver(r runner.Runner, host *api.HostConfig, ccfg *api.ClusterConfig) error {
+	if !utils.IsType(host.Type, api.Worker) || ccfg.WorkerConfig.ContainerEngineConf == nil {
+		return nil
+	}
+	rt := runtime.GetRuntime(ccfg.WorkerConfig.ContainerEngineConf.Runtime)
+	if rt == nil {
+		return nil
+	}
+	output, err := runCommandWithTimeout(r, utils.AddSudo(rt.GetCgroupDriverCommand()), preflightCommandTimeout)
+	if err != nil || strings.TrimSpace(output) == "" {
+		return nil
+	}
+	expect := ccfg.WorkerConfig.KubeletConf.GetCgroupDriver()
+	if actual := strings.TrimSpace(output); actual != expect {
+		return fmt.Errorf("cgroup driver of %s is %s, but %s is configured", rt.GetRuntimeService(), actual, expect)
+	}
+	return nil
+}
+
+type preflightCheck func(r runner.Runner, host *api.HostConfig) error
+
+func resourceChecks(ccfg *api.ClusterConfig, th PreflightConfig) []preflightCheck {
+	checkResources := func(r runner.Runner, host *api.HostConfig) error {
+		return checkNodeCPUAndMemory(r, host, th)
+	}
+	checkDisk := func(r runner.Runner, host *api.HostConfig) error {
+		dirs := make(map[string]int)
+		if utils.IsType(host.Type, api.Master) || utils.IsType(host.Type, api.Worker) {
+			dirs[ccfg.GetConfigDir()] = th.MinConfigDiskMB
+		}
+		if utils.IsType(host.Type, api.ETCD) && !ccfg.EtcdCluster.External {
+			dirs[ccfg.GetEtcdDataDir()] = th.MinEtcdDiskMB
+		}
+		return checkNodeDisk(r, dirs)
+	}
+	return []preflightCheck{checkResources, checkDisk}
+}
+
+// preflightNode runs all checks on node, returns item with all failures
+func preflightNode(host *api.HostConfig, hostKey *api.SSHHostKeyConfig, extraChecks []preflightCheck) healthItem {
+	item := healthItem{name: host.Address, status: preflightPassed}
+	r, err := preflightConnect(host, hostKey)
+	if err != nil {
+		item.status, item.message = preflightFailed, fmt.Sprintf("ssh login failed: %v", err)
+		return item
+	}
+	defer r.Close()
+
+	var failures []string
+	checks := append([]preflightCheck{checkNodeSudo, checkNodeArch, checkNodeTools}, extraChecks...)
+	for _, check := range checks {
+		if err := check(r, host); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) != 0 {
+		item.status, item.message = preflightFailed, strings.Join(failures, "; ")
+	}
+	return item
+}
+
+// fix problems of swap, kernel modules and sysctls if fix is true
+func runPreflight(ccfg *api.ClusterConfig, th PreflightConfig, fix bool) ([]healthItem, error) {
+	checkKernel := func(r runner.Runner, host *api.HostConfig) error {
+		return checkNodeKernel(r, host, fix)
+	}
+	checkCgroupDriver := func(r runner.Runner, host *api.HostConfig) error {
+		return checkNodeCgroupDriver(r, host, ccfg)
+	}
+	extraChecks := append(resourceChecks(ccfg, th), checkKernel, checkCgroupDriver)
+	items := make([]healthItem, len(ccfg.Nodes))
+	var wg sync.WaitGroup
+	wg.Add(len(ccfg.Nodes))
+	for i, n := range ccfg.Nodes {
+		go func(idx int, host *api.HostConfig) {
+			defer wg.Done()
+			items[idx] = preflightNode(host, &ccfg.SSHHostKey, extraChecks)
+		}(i, n)
+	}
+	wg.Wait()
+
+	for _, item := range items {
+		if item.status != preflightPassed {
+			return items, fmt.Errorf("preflight of cluster: %s failed", ccfg.Name)
+		}
+	}
+	return items, nil
+}
+
+// preflight runs preflight checks on all nodes and shows results to w
+func preflight(conf *DeployConfig, fix bool, w io.Writer) error {
+	items, err := runPreflight(toClusterdeploymentConfig(conf, nil), getPreflightThresholds(conf), fix)
+	showHealthItems(w, "Preflight results", items)
+	return err
+}
+
+func runPreflightCmd(cmd *cobra.Command, args []string) error {
+	if opts.debug {
+		initLog()
+	}
+
+	confPath := opts.preflightConfig
+	if confPath == "" {
+		confPath = defaultDeployConfigPath()
+	}
+	if _, err := os.Stat(confPath); err != nil {
+		return fmt.Errorf("stat %v failed: %v", confPath, err)
+	}
+
+	conf, err := loadDeployConfig(confPath)
+	if err != nil {
+		return fmt.Errorf("load deploy config file %v failed: %v", confPath, err)
+	}
+	if err = RunChecker(conf); err != nil {
+		return err
+	}
+
+	return preflight(conf, opts.preflightFix, os.Stdout)
+}
+
+func NewPreflightCmd() *cobra.Command {
+	preflightCmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "check ssh login, sudo, arch, base tools, resources and kernel settings of all nodes before deploy",
+		RunE:  runPreflightCmd,
+	}
+
+	setupPreflightCmdOpts(preflightCmd)
+
+	return preflightCmd
+}
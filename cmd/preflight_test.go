@@ -0,0 +1,225 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo preflight command testcase
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/constants"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/runner"
+	"isula.org/eggo/pkg/utils/runner/runnertest"
+)
+
+// preflightRunner returns output of command, command not in outputs fails
+func preflightRunner(outputs map[string]string) *runnertest.Runner {
+	return &runnertest.Runner{Outputs: outputs, Strict: true}
+}
+
+const testMemInfoCommand = "awk '/^MemTotal:/{print $2}' /proc/meminfo"
+
+func healthyOutputs(arch string) map[string]string {
+	return map[string]string{
+		utils.AddSudo("true"):  "",
+		"uname -m":             arch + "\n",
+		"command -v tar":       "/usr/bin/tar\n",
+		"command -v systemctl": "/usr/bin/systemctl\n",
+		"nproc":                "4\n",
+		testMemInfoCommand:     "8009836\n",
+		diskAvailableCommand(constants.DefaultK8SRootDir):  "20480\n",
+		diskAvailableCommand(constants.DefaultEtcdDataDir): "20480\n",
+		"tail -n +2 /proc/swaps":                           "",
+		"test -d /sys/module/br_netfilter":                 "",
+		"test -d /sys/module/overlay":                      "",
+		"sysctl -n net.bridge.bridge-nf-call-iptables":     "1\n",
+		"sysctl -n net.ipv4.ip_forward":                    "1\n",
+	}
+}
+
+func TestRunPreflight(t *testing.T) {
+	runners := map[string]*runnertest.Runner{
+		"192.168.0.1": preflightRunner(healthyOutputs("x86_64")),
+		"192.168.0.2": preflightRunner(healthyOutputs("aarch64")),
+	}
+	oldConnect := preflightConnect
+	preflightConnect = func(hcf *api.HostConfig, hostKey *api.SSHHostKeyConfig) (runner.Runner, error) {
+		r, ok := runners[hcf.Address]
+		if !ok {
+			return nil, fmt.Errorf("connection refused")
+		}
+		return r, nil
+	}
+	defer func() {
+		preflightConnect = oldConnect
+	}()
+
+	ccfg := &api.ClusterConfig{
+		Name: "test-cluster",
+		Nodes: []*api.HostConfig{
+			{Name: "master0", Address: "192.168.0.1", Arch: "amd64", Type: api.Master | api.ETCD},
+			{Name: "worker0", Address: "192.168.0.2", Arch: "arm64", Type: api.Worker | api.ETCD},
+		},
+		WorkerConfig: api.WorkerConfig{
+			KubeletConf:         &api.Kubelet{},
+			ContainerEngineConf: &api.ContainerEngine{Runtime: "docker"},
+		},
+	}
+	th := getPreflightThresholds(&DeployConfig{})
+	items, err := runPreflight(ccfg, th, false)
+	if err != nil {
+		t.Fatalf("preflight of healthy nodes failed: %v, items: %v", err, items)
+	}
+	if len(items) != 2 || items[0].name != "192.168.0.1" || items[1].status != preflightPassed {
+		t.Fatalf("unexpect preflight results: %v", items)
+	}
+
+	cases := []struct {
+		name   string
+		modify func()
+		expect string
+	}{
+		{
+			name:   "ssh login",
+			modify: func() { ccfg.Nodes[1].Address = "192.168.0.3" },
+			expect: "ssh login failed",
+		},
+		{
+			name:   "elevate failed",
+			modify: func() { delete(runners["192.168.0.2"].Outputs, utils.AddSudo("true")) },
+			expect: "elevate privilege is unavailable",
+		},
+		{
+			name:   "arch mismatch",
+			modify: func() { runners["192.168.0.2"].Outputs["uname -m"] = "x86_64\n" },
+			expect: "arch is amd64, but arm64 is declared",
+		},
+		{
+			name:   "missing tools",
+			modify: func() { delete(runners["192.168.0.2"].Outputs, "command -v systemctl") },
+			expect: "missing tools: systemctl",
+		},
+		{
+			name:   "low memory",
+			modify: func() { runners["192.168.0.2"].Outputs[testMemInfoCommand] = "512000\n" },
+			expect: "memory 500MB is less than 1024MB",
+		},
+		{
+			name:   "low cpus",
+			modify: func() { ccfg.Nodes[1].Type |= api.Master; runners["192.168.0.2"].Outputs["nproc"] = "1\n" },
+			expect: "cpus 1 is less than 2",
+		},
+		{
+			name:   "low disk of etcd data dir",
+			modify: func() { runners["192.168.0.2"].Outputs[diskAvailableCommand(constants.DefaultEtcdDataDir)] = "100\n" },
+			expect: "free disk of /var/lib/etcd/default.etcd 100MB is less than 2048MB",
+		},
+		{
+			name:   "swap on",
+			modify: func() { runners["192.168.0.2"].Outputs["tail -n +2 /proc/swaps"] = "/swapfile file 2097148 0 -2\n" },
+			expect: "swap is on",
+		},
+		{
+			name:   "module not loaded",
+			modify: func() { delete(runners["192.168.0.2"].Outputs, "test -d /sys/module/br_netfilter") },
+			expect: "kernel modules not loaded: br_netfilter",
+		},
+		{
+			name: "cgroup driver mismatch",
+			modify: func() {
+				runners["192.168.0.2"].Outputs[utils.AddSudo("docker info --format '{{ .CgroupDriver }}'")] = "cgroupfs\n"
+			},
+			expect: "cgroup driver of docker is cgroupfs, but systemd is configured",
+		},
+		{
+			name:   "sysctl not set",
+			modify: func() { runners["192.168.0.2"].Outputs["sysctl -n net.ipv4.ip_forward"] = "0\n" },
+			expect: "sysctls not set to 1: net.ipv4.ip_forward",
+		},
+	}
+	for _, c := range cases {
+		runners["192.168.0.2"].Outputs = healthyOutputs("aarch64")
+		ccfg.Nodes[1].Address, ccfg.Nodes[1].Type = "192.168.0.2", api.Worker|api.ETCD
+		c.modify()
+
+		items, err = runPreflight(ccfg, th, false)
+		if err == nil {
+			t.Fatalf("expect preflight failed with %s", c.name)
+		}
+		if items[0].status != preflightPassed {
+			t.Fatalf("healthy node should pass with %s: %v", c.name, items[0])
+		}
+		if items[1].status != preflightFailed || !strings.Contains(items[1].message, c.expect) {
+			t.Fatalf("expect %q with %s, get: %v", c.expect, c.name, items[1])
+		}
+	}
+
+	// thresholds are configurable
+	runners["192.168.0.2"].Outputs = healthyOutputs("aarch64")
+	ccfg.Nodes[1].Address, ccfg.Nodes[1].Type = "192.168.0.2", api.Worker|api.ETCD
+	th = getPreflightThresholds(&DeployConfig{Preflight: PreflightConfig{WorkerMinMemoryMB: 16384}})
+	if th.MasterMinMemoryMB != defaultMasterMinMemoryMB {
+		t.Fatalf("unset threshold should use default, get: %d", th.MasterMinMemoryMB)
+	}
+	if items, err = runPreflight(ccfg, th, false); err == nil || !strings.Contains(items[1].message, "less than 16384MB") {
+		t.Fatalf("expect memory less than configured threshold, get: %v", items)
+	}
+}
+
+func TestKernelRemediation(t *testing.T) {
+	ks := &kernelStatus{swapOn: true, missingModules: []string{"br_netfilter"}, wrongSysctls: []string{"net.bridge.bridge-nf-call-iptables"}}
+	expects := []string{
+		"swapoff -a",
+		`sed -ri 's/^([^#].*[[:space:]]swap[[:space:]].*)$/#\1/' /etc/fstab`,
+		"modprobe br_netfilter",
+		`printf 'br_netfilter\noverlay\n' > /etc/modules-load.d/eggo.conf`,
+		"sysctl -w net.bridge.bridge-nf-call-iptables=1",
+		"sysctl -w net.ipv4.ip_forward=1",
+		`printf 'net.bridge.bridge-nf-call-iptables = 1\nnet.ipv4.ip_forward = 1\n' > /etc/sysctl.d/99-eggo.conf`,
+	}
+	cmds := kernelRemediation(ks)
+	if strings.Join(cmds, "\n") != strings.Join(expects, "\n") {
+		t.Fatalf("expect remediation:\n%s\nget:\n%s", strings.Join(expects, "\n"), strings.Join(cmds, "\n"))
+	}
+	if cmds = kernelRemediation(&kernelStatus{}); len(cmds) != 0 {
+		t.Fatalf("expect no remediation for healthy node, get: %v", cmds)
+	}
+
+	host := &api.HostConfig{Name: "worker0", Address: "192.168.0.2", Arch: "arm64", Type: api.Worker}
+	r := preflightRunner(healthyOutputs("aarch64"))
+	// remediation shell fixes the node
+	r.AfterShell = func(shell, name string) {
+		r.Outputs = healthyOutputs("aarch64")
+	}
+	r.Outputs["tail -n +2 /proc/swaps"] = "/swapfile file 2097148 0 -2\n"
+	delete(r.Outputs, "test -d /sys/module/br_netfilter")
+	delete(r.Outputs, "sysctl -n net.bridge.bridge-nf-call-iptables")
+	if err := checkNodeKernel(r, host, false); err == nil || len(r.Shells) != 0 {
+		t.Fatalf("expect check failed without fix, err: %v, shells: %v", err, r.Shells)
+	}
+	if err := checkNodeKernel(r, host, true); err != nil {
+		t.Fatalf("fix kernel of node failed: %v", err)
+	}
+	if len(r.Shells) != 1 || !strings.Contains(r.Shells[0], "swapoff -a") || !strings.Contains(r.Shells[0], "modprobe br_netfilter") {
+		t.Fatalf("unexpect remediation shell: %v", r.Shells)
+	}
+	// fixed node should not be fixed again
+	if err := checkNodeKernel(r, host, true); err != nil || len(r.Shells) != 1 {
+		t.Fatalf("expect no remediation for fixed node, err: %v, shells: %v", err, r.Shells)
+	}
+}
@@ -0,0 +1,86 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: state of clusters saved by eggo
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/constants"
+)
+
+const (
+	stateSuccess = "success"
+	statePartial = "partial"
+	stateFailed  = "failed"
+	stateUnknown = "unknown"
+)
+
+// clusterState is result of last deploy of cluster, saved in home dir of cluster
+type clusterState struct {
+	ClusterID  string    `json:"cluster-id"`
+	DeployTime time.Time `json:"deploy-time"`
+	Status     string    `json:"status"`
+	Message    string    `json:"message,omitempty"`
+}
+
+func newClusterState(clusterID string, cstatus *api.ClusterStatus, err error) *clusterState {
+	state := &clusterState{
+		ClusterID:  clusterID,
+		DeployTime: time.Now(),
+		Status:     stateSuccess,
+	}
+	if err != nil {
+		state.Status, state.Message = stateFailed, err.Error()
+		return state
+	}
+	if cstatus.FailureCnt > 0 {
+		state.Status = statePartial
+		state.Message = fmt.Sprintf("%d nodes failed", cstatus.FailureCnt)
+	}
+	if !cstatus.Working {
+		state.Status, state.Message = stateFailed, cstatus.Message
+	}
+	return state
+}
+
+func saveClusterState(state *clusterState, path string) {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		logrus.Warnf("marshal state of cluster %s failed: %v", state.ClusterID, err)
+		return
+	}
+	if err = ioutil.WriteFile(path, data, constants.ClusterStateFileMode); err != nil {
+		logrus.Warnf("write state of cluster %s failed: %v", state.ClusterID, err)
+	}
+}
+
+func loadClusterState(path string) (*clusterState, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := &clusterState{}
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid state %s: %v", path, err)
+	}
+	return state, nil
+}
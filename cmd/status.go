@@ -0,0 +1,317 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo status command implement
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/constants"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/runner"
+)
+
+const (
+	statusConnectTimeout = 30 * time.Second
+	statusCommandTimeout = 60 * time.Second
+	statusRequestTimeout = "10s"
+)
+
+type healthItem struct {
+	name    string
+	status  string
+	message string
+}
+
+type masterReachability struct {
+	host *api.HostConfig
+	r    runner.Runner
+	err  error
+}
+
+type clusterHealth struct {
+	masters    []*masterReachability
+	queryNode  string
+	nodes      []healthItem
+	components []healthItem
+	etcds      []healthItem
+	errs       []string
+}
+
+func connectWithTimeout(hcf *api.HostConfig, hostKey *api.SSHHostKeyConfig, timeout time.Duration) (runner.Runner, error) {
+	type result struct {
+		r   runner.Runner
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		r, err := runner.NewRunner(hcf, hostKey, nil, timeout.String())
+		ch <- result{r: r, err: err}
+	}()
+
+	select {
+	case res := <-ch:
+		return res.r, res.err
+	case <-time.After(timeout):
+		// runner connected after timeout is useless, close it to release its connection
+		go func() {
+			if res := <-ch; res.r != nil {
+				res.r.Close()
+			}
+		}()
+		return nil, fmt.Errorf("timeout %s for connect to %s", timeout.String(), hcf.Address)
+	}
+}
+
+func runCommandWithTimeout(r runner.Runner, cmd string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return runner.RunCommandWithContext(ctx, r, cmd)
+}
+
+func connectMasters(ccfg *api.ClusterConfig) []*masterReachability {
+	var masters []*masterReachability
+	for _, n := range ccfg.Nodes {
+		if utils.IsType(n.Type, api.Master) {
+			masters = append(masters, &masterReachability{host: n})
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(masters))
+	for _, m := range masters {
+		go func(mr *masterReachability) {
+			defer wg.Done()
+			mr.r, mr.err = connectWithTimeout(mr.host, &ccfg.SSHHostKey, statusConnectTimeout)
+		}(m)
+	}
+	wg.Wait()
+
+	return masters
+}
+
+// parse output of "kubectl get nodes --no-headers"
+func parseNodesOutput(output string) []healthItem {
+	var items []healthItem
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		items = append(items, healthItem{name: fields[0], status: fields[1]})
+	}
+	return items
+}
+
+// parse output of "kubectl get componentstatuses --no-headers"
+func parseComponentsOutput(output string) []healthItem {
+	var items []healthItem
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		items = append(items, healthItem{
+			name:    fields[0],
+			status:  fields[1],
+			message: strings.Join(fields[2:], " "),
+		})
+	}
+	return items
+}
+
+// parse output of "etcdctl endpoint health", format of line:
+// https://192.168.0.2:2379 is healthy: successfully committed proposal: took = 10.1ms
+// https://192.168.0.3:2379 is unhealthy: failed to commit proposal: context deadline exceeded
+func parseEtcdHealthOutput(output string) []healthItem {
+	var items []healthItem
+	for _, line := range strings.Split(output, "\n") {
+		idx := strings.Index(line, " is ")
+		if idx <= 0 {
+			continue
+		}
+		rest := line[idx+len(" is "):]
+		status, message := rest, ""
+		if sep := strings.Index(rest, ":"); sep >= 0 {
+			status, message = rest[:sep], strings.TrimSpace(rest[sep+1:])
+		}
+		if status != "healthy" && status != "unhealthy" {
+			continue
+		}
+		items = append(items, healthItem{
+			name:    strings.TrimSpace(line[:idx]),
+			status:  status,
+			message: message,
+		})
+	}
+	return items
+}
+
+func queryClusterHealth(r runner.Runner, ccfg *api.ClusterConfig, ch *clusterHealth) {
+	kubeconfig := filepath.Join(ccfg.GetConfigDir(), constants.KubeConfigFileNameAdmin)
+
+	cmd := fmt.Sprintf("KUBECONFIG=%s kubectl --request-timeout=%s get nodes --no-headers", kubeconfig, statusRequestTimeout)
+	output, err := runCommandWithTimeout(r, utils.AddSudo(cmd), statusCommandTimeout)
+	if err != nil {
+		ch.errs = append(ch.errs, fmt.Sprintf("get nodes failed: %v", err))
+	} else {
+		ch.nodes = parseNodesOutput(output)
+	}
+
+	cmd = fmt.Sprintf("KUBECONFIG=%s kubectl --request-timeout=%s get componentstatuses --no-headers", kubeconfig, statusRequestTimeout)
+	output, err = runCommandWithTimeout(r, utils.AddSudo(cmd), statusCommandTimeout)
+	if err != nil {
+		ch.errs = append(ch.errs, fmt.Sprintf("get componentstatuses failed: %v", err))
+	} else {
+		ch.components = parseComponentsOutput(output)
+	}
+
+	if ccfg.EtcdCluster.External {
+		return
+	}
+	certDir := ccfg.GetCertDir()
+	// etcdctl exit with error if any endpoint is unhealthy, ignore it and parse the output
+	cmd = fmt.Sprintf("ETCDCTL_API=3 etcdctl endpoint health --endpoints=%s --cacert=%s/etcd/ca.crt --cert=%s/apiserver-etcd-client.crt --key=%s/apiserver-etcd-client.key 2>&1 || true",
+		api.GetEtcdServers(&ccfg.EtcdCluster), certDir, certDir, certDir)
+	output, err = runCommandWithTimeout(r, utils.AddSudo(cmd), statusCommandTimeout)
+	if err != nil {
+		ch.errs = append(ch.errs, fmt.Sprintf("check etcd health failed: %v", err))
+		return
+	}
+	ch.etcds = parseEtcdHealthOutput(output)
+	if len(ch.etcds) == 0 {
+		ch.errs = append(ch.errs, fmt.Sprintf("check etcd health failed: %s", strings.TrimSpace(output)))
+	}
+}
+
+func getClusterHealth(ccfg *api.ClusterConfig) (*clusterHealth, error) {
+	ch := &clusterHealth{
+		masters: connectMasters(ccfg),
+	}
+	defer func() {
+		for _, m := range ch.masters {
+			if m.r != nil {
+				m.r.Close()
+			}
+		}
+	}()
+
+	for _, m := range ch.masters {
+		if m.err != nil {
+			logrus.Debugf("connect master %s failed: %v", m.host.Address, m.err)
+			continue
+		}
+		ch.queryNode = m.host.Address
+		queryClusterHealth(m.r, ccfg, ch)
+		return ch, nil
+	}
+
+	return ch, fmt.Errorf("all masters of cluster: %s are unreachable", ccfg.Name)
+}
+
+func showHealthItems(w io.Writer, title string, items []healthItem) {
+	maxLen := 8
+	for _, item := range items {
+		if len(item.name) > maxLen {
+			maxLen = len(item.name)
+		}
+	}
+	fmt.Fprintf(w, "\n%s:\n", title)
+	fmt.Fprintf(w, "%-*s\tStatus\tMessage\n", maxLen, "Name")
+	for _, item := range items {
+		fmt.Fprintf(w, "%-*s\t%s\t%s\n", maxLen, item.name, item.status, item.message)
+	}
+}
+
+func showClusterHealth(ch *clusterHealth) {
+	var masters []healthItem
+	for _, m := range ch.masters {
+		item := healthItem{name: m.host.Address, status: "reachable"}
+		if m.err != nil {
+			item.status = "unreachable"
+			item.message = m.err.Error()
+		}
+		masters = append(masters, item)
+	}
+	showHealthItems(os.Stdout, "Masters", masters)
+
+	if ch.queryNode == "" {
+		return
+	}
+	fmt.Printf("\nquery status from master: %s\n", ch.queryNode)
+	showHealthItems(os.Stdout, "Nodes", ch.nodes)
+	showHealthItems(os.Stdout, "Control plane components", ch.components)
+	showHealthItems(os.Stdout, "Etcd members", ch.etcds)
+	if len(ch.errs) != 0 {
+		fmt.Printf("\nErrors:\n")
+		for _, e := range ch.errs {
+			fmt.Printf("\t%s\n", e)
+		}
+	}
+}
+
+func showClusterStatus(cmd *cobra.Command, args []string) error {
+	if opts.debug {
+		initLog()
+	}
+
+	if opts.statusConfig == "" && opts.statusClusterID == "" {
+		return fmt.Errorf("please specify cluster id or deploy config file")
+	}
+
+	confPath := opts.statusConfig
+	if confPath == "" {
+		confPath = savedDeployConfigPath(opts.statusClusterID)
+		if _, err := os.Stat(confPath); err != nil {
+			return fmt.Errorf("stat %v failed: %v", confPath, err)
+		}
+	}
+
+	conf, err := loadDeployConfig(confPath)
+	if err != nil {
+		return fmt.Errorf("load deploy config file %v failed: %v", confPath, err)
+	}
+	if err = RunChecker(conf); err != nil {
+		return err
+	}
+
+	ch, err := getClusterHealth(toClusterdeploymentConfig(conf, nil))
+	showClusterHealth(ch)
+
+	return err
+}
+
+func NewStatusCmd() *cobra.Command {
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "show health status of a kubernetes cluster",
+		RunE:  showClusterStatus,
+	}
+
+	setupStatusCmdOpts(statusCmd)
+
+	return statusCmd
+}
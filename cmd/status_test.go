@@ -0,0 +1,74 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: cmd status testcase
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"testing"
+)
+
+func TestParseNodesOutput(t *testing.T) {
+	output := `k8s-master-0   Ready      <none>   10m   v1.20.2
+k8s-worker-0   NotReady   <none>   9m    v1.20.2
+
+`
+	items := parseNodesOutput(output)
+	if len(items) != 2 {
+		t.Fatalf("expect 2 nodes, get %d", len(items))
+	}
+	if items[0].name != "k8s-master-0" || items[0].status != "Ready" {
+		t.Fatalf("invalid node: %v", items[0])
+	}
+	if items[1].name != "k8s-worker-0" || items[1].status != "NotReady" {
+		t.Fatalf("invalid node: %v", items[1])
+	}
+}
+
+func TestParseComponentsOutput(t *testing.T) {
+	output := `scheduler            Healthy   ok
+controller-manager   Unhealthy Get "http://127.0.0.1:10252/healthz": dial tcp 127.0.0.1:10252: connect: connection refused
+etcd-0               Healthy   {"health":"true"}
+`
+	items := parseComponentsOutput(output)
+	if len(items) != 3 {
+		t.Fatalf("expect 3 components, get %d", len(items))
+	}
+	if items[0].name != "scheduler" || items[0].status != "Healthy" || items[0].message != "ok" {
+		t.Fatalf("invalid component: %v", items[0])
+	}
+	if items[1].status != "Unhealthy" || items[1].message == "" {
+		t.Fatalf("invalid component: %v", items[1])
+	}
+}
+
+func TestParseEtcdHealthOutput(t *testing.T) {
+	output := `https://192.168.0.2:2379 is healthy: successfully committed proposal: took = 10.1ms
+https://192.168.0.3:2379 is unhealthy: failed to commit proposal: context deadline exceeded
+Error: unhealthy cluster
+`
+	items := parseEtcdHealthOutput(output)
+	if len(items) != 2 {
+		t.Fatalf("expect 2 etcd members, get %d", len(items))
+	}
+	if items[0].name != "https://192.168.0.2:2379" || items[0].status != "healthy" {
+		t.Fatalf("invalid etcd member: %v", items[0])
+	}
+	if items[1].name != "https://192.168.0.3:2379" || items[1].status != "unhealthy" {
+		t.Fatalf("invalid etcd member: %v", items[1])
+	}
+	if items[1].message != "failed to commit proposal: context deadline exceeded" {
+		t.Fatalf("invalid message of etcd member: %s", items[1].message)
+	}
+}
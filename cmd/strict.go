@@ -0,0 +1,179 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: strict check of fields in deploy config
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v1"
+)
+
+// checkStrictYaml reports unknown fields and mismatched types of yaml, which are ignored by yaml.Unmarshal
+func checkStrictYaml(data []byte, out interface{}) error {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return checkYamlValue(raw, reflect.TypeOf(out), "")
+}
+
+func yamlFieldPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func yamlTagName(f reflect.StructField) string {
+	tag := f.Tag.Get("yaml")
+	if tag == "-" {
+		return ""
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name
+	}
+	return strings.ToLower(f.Name)
+}
+
+func checkYamlValue(raw interface{}, t reflect.Type, path string) error {
+	if raw == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := raw.(map[interface{}]interface{})
+		if !ok {
+			return fmt.Errorf("field %s: expect mapping, but get: %v", path, raw)
+		}
+		return checkYamlStruct(m, t, path)
+	case reflect.Map:
+		m, ok := raw.(map[interface{}]interface{})
+		if !ok {
+			return fmt.Errorf("field %s: expect mapping, but get: %v", path, raw)
+		}
+		for k, v := range m {
+			if err := checkYamlValue(v, t.Elem(), yamlFieldPath(path, fmt.Sprint(k))); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		s, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("field %s: expect sequence, but get: %v", path, raw)
+		}
+		for i, v := range s {
+			if err := checkYamlValue(v, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Bool:
+		if _, ok := raw.(bool); !ok {
+			return fmt.Errorf("field %s: expect bool, but get: %v", path, raw)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch raw.(type) {
+		case int, int64, uint64:
+		default:
+			return fmt.Errorf("field %s: expect integer, but get: %v", path, raw)
+		}
+	case reflect.String:
+		switch raw.(type) {
+		case map[interface{}]interface{}, []interface{}:
+			return fmt.Errorf("field %s: expect string, but get: %v", path, raw)
+		}
+	}
+	return nil
+}
+
+func checkYamlStruct(m map[interface{}]interface{}, t reflect.Type, path string) error {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if name := yamlTagName(t.Field(i)); name != "" {
+			fields[name] = t.Field(i)
+		}
+	}
+
+	// sort keys to report the same error each time
+	keys := make([]string, 0, len(m))
+	values := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		keys = append(keys, fmt.Sprint(k))
+		values[fmt.Sprint(k)] = v
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f, ok := fields[key]
+		if !ok {
+			if s := similarYamlField(key, fields); s != "" {
+				return fmt.Errorf("unknown field %s, did you mean %s?", yamlFieldPath(path, key), s)
+			}
+			return fmt.Errorf("unknown field %s", yamlFieldPath(path, key))
+		}
+		if err := checkYamlValue(values[key], f.Type, yamlFieldPath(path, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// similarYamlField finds the most similar field name of misspelled key, empty if none is similar
+func similarYamlField(key string, fields map[string]reflect.StructField) string {
+	best, bestDist := "", len(key)/2+1
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDist || (d == bestDist && best != "" && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
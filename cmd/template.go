@@ -23,6 +23,12 @@ func createTemplate(cmd *cobra.Command, args []string) error {
 	if opts.debug {
 		initLog()
 	}
+	if opts.templateSingleNode {
+		return createSingleNodeDeployConfigTemplate(opts.templateConfig)
+	}
+	if opts.templateMinimal {
+		return createMinimalDeployConfigTemplate(opts.templateConfig)
+	}
 	return createDeployConfigTemplate(opts.templateConfig)
 }
 
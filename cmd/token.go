@@ -0,0 +1,221 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo token command implement
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment/binary/commontools"
+	"isula.org/eggo/pkg/constants"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/endpoint"
+	"isula.org/eggo/pkg/utils/runner"
+)
+
+const tokenDescription = "bootstrap token created by eggo token"
+
+func loadTokenClusterConfig() (*api.ClusterConfig, error) {
+	if opts.tokenConfig == "" && opts.tokenClusterID == "" {
+		return nil, fmt.Errorf("please specify cluster id or deploy config file")
+	}
+
+	confPath := opts.tokenConfig
+	if confPath == "" {
+		confPath = savedDeployConfigPath(opts.tokenClusterID)
+		if _, err := os.Stat(confPath); err != nil {
+			return nil, fmt.Errorf("stat %v failed: %v", confPath, err)
+		}
+	}
+
+	conf, err := loadDeployConfig(confPath)
+	if err != nil {
+		return nil, fmt.Errorf("load deploy config file %v failed: %v", confPath, err)
+	}
+	if err = RunChecker(conf); err != nil {
+		return nil, err
+	}
+	return toClusterdeploymentConfig(conf, nil), nil
+}
+
+// runOnMaster runs fn with the first reachable master of cluster
+func runOnMaster(ccfg *api.ClusterConfig, fn func(r runner.Runner, kubeconfig string) error) error {
+	masters := connectMasters(ccfg)
+	defer func() {
+		for _, m := range masters {
+			if m.r != nil {
+				m.r.Close()
+			}
+		}
+	}()
+
+	for _, m := range masters {
+		if m.err != nil {
+			logrus.Debugf("connect master %s failed: %v", m.host.Address, m.err)
+			continue
+		}
+		return fn(m.r, filepath.Join(ccfg.GetConfigDir(), constants.KubeConfigFileNameAdmin))
+	}
+	return fmt.Errorf("all masters of cluster: %s are unreachable", ccfg.Name)
+}
+
+func newBootstrapTokenConfig(ttl time.Duration, usages, groups []string) (string, *api.BootstrapTokenConfig, error) {
+	if len(usages) == 0 {
+		return "", nil, fmt.Errorf("empty usages of bootstrap token")
+	}
+	if err := commontools.CheckBootstrapTokenUsagesAndGroups(usages, groups); err != nil {
+		return "", nil, err
+	}
+	token, id, secret, err := commontools.ParseBootstrapTokenStr("")
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &api.BootstrapTokenConfig{
+		Description:     tokenDescription,
+		ID:              id,
+		Secret:          secret,
+		TTL:             &ttl,
+		Usages:          usages,
+		AuthExtraGroups: groups,
+	}, nil
+}
+
+// tokenJoinCommand returns command to run on new node, which generates bootstrap kubeconfig of kubelet with token
+func tokenJoinCommand(ccfg *api.ClusterConfig, token string) (string, error) {
+	apiEndpoint, err := endpoint.GetAPIServerEndpoint(ccfg)
+	if err != nil {
+		return "", err
+	}
+	return utils.AddSudo(commontools.KubeletBootstrapCommand(ccfg.Name, apiEndpoint, token)), nil
+}
+
+func createToken(cmd *cobra.Command, args []string) error {
+	if opts.debug {
+		initLog()
+	}
+
+	ccfg, err := loadTokenClusterConfig()
+	if err != nil {
+		return err
+	}
+	token, bconf, err := newBootstrapTokenConfig(opts.tokenTTL, opts.tokenUsages, opts.tokenGroups)
+	if err != nil {
+		return err
+	}
+	joinCmd, err := tokenJoinCommand(ccfg, token)
+	if err != nil {
+		return err
+	}
+
+	if err = runOnMaster(ccfg, func(r runner.Runner, kubeconfig string) error {
+		return commontools.CreateBootstrapToken(r, bconf, kubeconfig, ccfg.GetManifestDir())
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("token: %s\n", token)
+	fmt.Printf("expiration: %s\n", time.Now().Add(opts.tokenTTL).Format(time.RFC3339))
+	fmt.Printf("\nrun the command on new node, which has %s/pki/ca.crt, to generate bootstrap kubeconfig of kubelet:\n\n", constants.DefaultK8SRootDir)
+	fmt.Printf("%s\n", joinCmd)
+	return nil
+}
+
+func showTokens(tokens []commontools.BootstrapTokenInfo) {
+	fmt.Printf("ID\tExpiration\tUsages\tGroups\tDescription\n")
+	for _, t := range tokens {
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\n", t.ID, t.Expiration, strings.Join(t.Usages, ","),
+			strings.Join(t.AuthExtraGroups, ","), t.Description)
+	}
+}
+
+func listTokens(cmd *cobra.Command, args []string) error {
+	if opts.debug {
+		initLog()
+	}
+
+	ccfg, err := loadTokenClusterConfig()
+	if err != nil {
+		return err
+	}
+	return runOnMaster(ccfg, func(r runner.Runner, kubeconfig string) error {
+		tokens, err := commontools.ListBootstrapTokens(r, kubeconfig)
+		if err != nil {
+			return err
+		}
+		showTokens(tokens)
+		return nil
+	})
+}
+
+func deleteTokens(cmd *cobra.Command, args []string) error {
+	if opts.debug {
+		initLog()
+	}
+
+	ccfg, err := loadTokenClusterConfig()
+	if err != nil {
+		return err
+	}
+	return runOnMaster(ccfg, func(r runner.Runner, kubeconfig string) error {
+		for _, t := range args {
+			if err := commontools.DeleteBootstrapToken(r, t, kubeconfig); err != nil {
+				return err
+			}
+			fmt.Printf("bootstrap token %s deleted\n", t)
+		}
+		return nil
+	})
+}
+
+func NewTokenCmd() *cobra.Command {
+	tokenCmd := &cobra.Command{
+		Use:   "token",
+		Short: "manage bootstrap tokens of a kubernetes cluster",
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "create a new bootstrap token and print command to join node with it",
+		Args:  cobra.NoArgs,
+		RunE:  createToken,
+	}
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "list bootstrap tokens",
+		Args:  cobra.NoArgs,
+		RunE:  listTokens,
+	}
+	deleteCmd := &cobra.Command{
+		Use:   "delete TOKEN_ID [TOKEN_ID...]",
+		Short: "delete bootstrap tokens by id or token",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  deleteTokens,
+	}
+	setupTokenCmdOpts(tokenCmd, createCmd)
+
+	tokenCmd.AddCommand(createCmd)
+	tokenCmd.AddCommand(listCmd)
+	tokenCmd.AddCommand(deleteCmd)
+
+	return tokenCmd
+}
@@ -0,0 +1,91 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for eggo token command
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func TestNewBootstrapTokenConfig(t *testing.T) {
+	token, bconf, err := newBootstrapTokenConfig(time.Hour, []string{"authentication", "signing"}, nil)
+	if err != nil {
+		t.Fatalf("new bootstrap token failed: %v", err)
+	}
+	if ok, _ := regexp.MatchString(`\A[a-z0-9]{6}\.[a-z0-9]{16}\z`, token); !ok {
+		t.Fatalf("invalid token: %s", token)
+	}
+	if token != bconf.ID+"."+bconf.Secret || bconf.TTL == nil || *bconf.TTL != time.Hour {
+		t.Fatalf("token %s mismatch with config: %+v", token, bconf)
+	}
+
+	another, _, err := newBootstrapTokenConfig(time.Hour, []string{"authentication", "signing"}, nil)
+	if err != nil || another == token {
+		t.Fatalf("expect different token, get: %s, %v", another, err)
+	}
+}
+
+func TestTokenJoinCommand(t *testing.T) {
+	ccfg := &api.ClusterConfig{Name: "k8s-cluster"}
+	if _, err := tokenJoinCommand(ccfg, "abcdef.0123456789abcdef"); err == nil {
+		t.Fatalf("expect failed without apiserver endpoint")
+	}
+
+	ccfg.APIEndpoint.AdvertiseAddress = "192.168.0.1"
+	ccfg.APIEndpoint.BindPort = 6443
+	cmd, err := tokenJoinCommand(ccfg, "abcdef.0123456789abcdef")
+	if err != nil {
+		t.Fatalf("get join command failed: %v", err)
+	}
+	if !strings.HasPrefix(cmd, "sudo -E /bin/sh -c \"cd /etc/kubernetes/ && ") || !strings.HasSuffix(cmd, "\"") {
+		t.Fatalf("join command should be run with sudo: %s", cmd)
+	}
+	for _, e := range []string{"--server=https://192.168.0.1:6443", "--token=abcdef.0123456789abcdef", "--cluster=k8s-cluster"} {
+		if !strings.Contains(cmd, e) {
+			t.Fatalf("expect %s in join command: %s", e, cmd)
+		}
+	}
+}
+
+func TestNewBootstrapTokenConfigUsagesAndGroups(t *testing.T) {
+	_, bconf, err := newBootstrapTokenConfig(time.Hour, []string{"signing"}, []string{"system:bootstrappers:eggo"})
+	if err != nil {
+		t.Fatalf("new bootstrap token with usages and groups failed: %v", err)
+	}
+	if strings.Join(bconf.Usages, ",") != "signing" || strings.Join(bconf.AuthExtraGroups, ",") != "system:bootstrappers:eggo" {
+		t.Fatalf("usages and groups are not applied: %+v", bconf)
+	}
+
+	invalids := []struct {
+		usages []string
+		groups []string
+	}{
+		{usages: nil},
+		{usages: []string{"signing", "login"}},
+		{usages: []string{"signing", "signing"}},
+		{usages: []string{"signing"}, groups: []string{"system:masters"}},
+		{usages: []string{"signing"}, groups: []string{"system:bootstrappers:Eggo"}},
+	}
+	for _, i := range invalids {
+		if _, _, err = newBootstrapTokenConfig(time.Hour, i.usages, i.groups); err == nil {
+			t.Fatalf("expect invalid usages %v or groups %v", i.usages, i.groups)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo upgrade command implement
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment"
+)
+
+// package source of upgrade use the same type and dst path with deploy
+func getUpgradePackageSrc(conf *DeployConfig, srcPath, sha256 map[string]string) (*PackageSrcConfig, error) {
+	if len(srcPath) == 0 {
+		return nil, fmt.Errorf("please specify package of target version")
+	}
+
+	psc := &PackageSrcConfig{
+		SrcPath: make(map[string]string),
+		Sha256:  make(map[string]string),
+	}
+	if conf.InstallConfig.PackageSrc != nil {
+		psc.Type = conf.InstallConfig.PackageSrc.Type
+		psc.DstPath = conf.InstallConfig.PackageSrc.DstPath
+	}
+	for arch, path := range srcPath {
+		psc.SrcPath[arch] = path
+	}
+	for arch, sum := range sha256 {
+		psc.Sha256[arch] = strings.ToLower(sum)
+	}
+
+	var err error
+	if psc.SrcPath, err = normalizeArchKeys(psc.SrcPath, "package"); err != nil {
+		return nil, err
+	}
+	if psc.Sha256, err = normalizeArchKeys(psc.Sha256, "package sha256"); err != nil {
+		return nil, err
+	}
+	for arch, path := range psc.SrcPath {
+		if api.IsRemotePackage(path) && psc.Sha256[arch] == "" {
+			return nil, fmt.Errorf("package %s of arch %s requires sha256", path, arch)
+		}
+	}
+	return psc, nil
+}
+
+// newUpgradeDeployConfig returns deploy config of cluster after upgrade, nodes join later
+// install packages of target version and are checked with it
+func newUpgradeDeployConfig(conf *DeployConfig, psc *PackageSrcConfig, version string) *DeployConfig {
+	upgradeConf := *conf
+	upgradeConf.InstallConfig.PackageSrc = psc
+	upgradeConf.KubernetesVersion = version
+	return &upgradeConf
+}
+
+func upgradeCluster(cmd *cobra.Command, args []string) error {
+	if opts.debug {
+		initLog()
+	}
+
+	if opts.upgradeClusterID == "" {
+		return fmt.Errorf("please specify cluster id")
+	}
+	if opts.upgradeVersion == "" {
+		return fmt.Errorf("please specify target version")
+	}
+
+	conf, err := loadDeployConfig(savedDeployConfigPath(opts.upgradeClusterID))
+	if err != nil {
+		return fmt.Errorf("load saved deploy config failed: %v", err)
+	}
+
+	psc, err := getUpgradePackageSrc(conf, opts.upgradePackages, opts.upgradeSha256)
+	if err != nil {
+		return err
+	}
+	// check package source of upgrade with nodes of cluster
+	upgradeConf := newUpgradeDeployConfig(conf, psc, opts.upgradeVersion)
+	if err = RunChecker(upgradeConf); err != nil {
+		return err
+	}
+
+	holder, err := NewProcessPlaceHolder(eggoPlaceHolderPath(conf.ClusterID))
+	if err != nil {
+		return fmt.Errorf("create process holder failed: %v, mayebe other eggo is running with cluster: %s", err, conf.ClusterID)
+	}
+	defer func() {
+		if terr := holder.Remove(); terr != nil {
+			fmt.Printf("remove process place holder failed: %v", terr)
+		}
+	}()
+
+	ccfg := toClusterdeploymentConfig(upgradeConf, nil)
+	uconf := &api.UpgradeConfig{
+		TargetVersion:    opts.upgradeVersion,
+		PackageSrc:       ccfg.PackageSrc,
+		NodeReadyTimeout: opts.upgradeNodeTimeout,
+	}
+
+	ctx, cancel := newCommandContext()
+	defer cancel()
+	if err = clusterdeployment.UpgradeCluster(ctx, toClusterdeploymentConfig(conf, nil), uconf); err != nil {
+		return err
+	}
+
+	// nodes join later should install packages of target version
+	if err = saveDeployConfig(upgradeConf, savedDeployConfigPath(conf.ClusterID)); err != nil {
+		return err
+	}
+
+	fmt.Printf("upgrade cluster %s to %s success\n", conf.ClusterID, opts.upgradeVersion)
+	return nil
+}
+
+func NewUpgradeCmd() *cobra.Command {
+	upgradeCmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "rolling upgrade kubernetes of cluster to target version",
+		RunE:  upgradeCluster,
+	}
+
+	setupUpgradeCmdOpts(upgradeCmd)
+
+	return upgradeCmd
+}
@@ -0,0 +1,138 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: cmd upgrade testcase
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment/binary/infrastructure"
+)
+
+func TestGetUpgradePackageSrc(t *testing.T) {
+	conf := &DeployConfig{
+		InstallConfig: InstallConfig{
+			PackageSrc: &PackageSrcConfig{
+				Type:    "tar.gz",
+				DstPath: "/root/packages",
+				SrcPath: map[string]string{"amd64": "/root/packages-x86-v1.20.tar.gz"},
+			},
+		},
+	}
+
+	if _, err := getUpgradePackageSrc(conf, nil, nil); err == nil {
+		t.Fatalf("expect error without package of target version")
+	}
+
+	psc, err := getUpgradePackageSrc(conf, map[string]string{"X86_64": "/root/packages-x86-v1.21.tar.gz"}, map[string]string{"x86_64": "ABCD"})
+	if err != nil {
+		t.Fatalf("get upgrade package source failed: %v", err)
+	}
+	if psc.Type != "tar.gz" || psc.DstPath != "/root/packages" {
+		t.Fatalf("type and dst path should be same with deploy: %v", psc)
+	}
+	if psc.SrcPath["amd64"] != "/root/packages-x86-v1.21.tar.gz" || psc.Sha256["amd64"] != "abcd" {
+		t.Fatalf("invalid upgrade package source: %v", psc)
+	}
+	if conf.InstallConfig.PackageSrc.SrcPath["amd64"] != "/root/packages-x86-v1.20.tar.gz" {
+		t.Fatalf("package source of deploy should not be changed")
+	}
+
+	if _, err = getUpgradePackageSrc(conf, map[string]string{"x86": "/root/packages-x86-v1.21.tar.gz"}, nil); err == nil {
+		t.Fatalf("expect error for unsupported arch of package")
+	}
+	if _, err = getUpgradePackageSrc(conf, map[string]string{"amd64": "https://127.0.0.1/packages.tar.gz"}, nil); err == nil {
+		t.Fatalf("expect error for http package without sha256")
+	}
+}
+
+func writePackagesOfVersion(t *testing.T, path string, version string) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create package %s failed: %v", path, err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	name := "pkg/kubernetes-node-" + version + "-1.oe1.x86_64.rpm"
+	if err = tw.WriteHeader(&tar.Header{Name: name, Mode: 0600}); err != nil {
+		t.Fatalf("write tar header failed: %v", err)
+	}
+	if err = tw.Close(); err != nil {
+		t.Fatalf("close tar failed: %v", err)
+	}
+	if err = gw.Close(); err != nil {
+		t.Fatalf("close gzip failed: %v", err)
+	}
+}
+
+func TestJoinAfterUpgrade(t *testing.T) {
+	tempdir := t.TempDir()
+	api.EggoHomePath = tempdir
+	oldPkg := filepath.Join(tempdir, "packages-v1.20.2.tar.gz")
+	newPkg := filepath.Join(tempdir, "packages-v1.21.1.tar.gz")
+	writePackagesOfVersion(t, oldPkg, "1.20.2")
+	writePackagesOfVersion(t, newPkg, "1.21.1")
+
+	conf := &DeployConfig{
+		ClusterID:         "k8s-cluster",
+		KubernetesVersion: "v1.20.2",
+		InstallConfig: InstallConfig{
+			PackageSrc: &PackageSrcConfig{
+				Type:    "tar.gz",
+				DstPath: "/root/packages",
+				SrcPath: map[string]string{"amd64": oldPkg},
+			},
+		},
+	}
+	psc, err := getUpgradePackageSrc(conf, map[string]string{"amd64": newPkg}, nil)
+	if err != nil {
+		t.Fatalf("get upgrade package source failed: %v", err)
+	}
+
+	// saved config after upgrade is used by join
+	savedPath := savedDeployConfigPath(conf.ClusterID)
+	if err = saveDeployConfig(newUpgradeDeployConfig(conf, psc, "v1.21.1"), savedPath); err != nil {
+		t.Fatalf("save deploy config failed: %v", err)
+	}
+	saved, err := loadDeployConfig(savedPath)
+	if err != nil {
+		t.Fatalf("load saved deploy config failed: %v", err)
+	}
+	if conf.KubernetesVersion != "v1.20.2" {
+		t.Fatalf("deploy config before upgrade should not be changed")
+	}
+
+	ccfg := toClusterdeploymentConfig(saved, nil)
+	if ccfg.KubernetesVersion != "v1.21.1" {
+		t.Fatalf("kubernetes version after upgrade should be v1.21.1, get: %s", ccfg.KubernetesVersion)
+	}
+	joinNodes := []*api.HostConfig{{Name: "worker1", Arch: "amd64"}}
+	if err = infrastructure.CheckPackageSrcK8sVersion(&ccfg.PackageSrc, joinNodes, ccfg.KubernetesVersion); err != nil {
+		t.Fatalf("join after upgrade should install packages of target version: %v", err)
+	}
+
+	// packages of target version mismatch with version before upgrade
+	err = infrastructure.CheckPackageSrcK8sVersion(&ccfg.PackageSrc, joinNodes, conf.KubernetesVersion)
+	if !errors.Is(err, infrastructure.ErrK8sVersionMismatch) {
+		t.Fatalf("expect version mismatch error, get: %v", err)
+	}
+}
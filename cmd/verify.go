@@ -0,0 +1,306 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo verify command implement
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/constants"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/runner"
+	"isula.org/eggo/pkg/utils/template"
+)
+
+const (
+	verifyAppName        = "eggo-verify"
+	verifyHTTPPort       = 8080
+	verifyWaitTimeout    = "180s"
+	verifyCommandTimeout = 240 * time.Second
+	verifyPassed         = "pass"
+	verifyFailed         = "fail"
+)
+
+const verifyManifestTmpl = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Namespace }}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: {{ .Name }}
+  template:
+    metadata:
+      labels:
+        app: {{ .Name }}
+    spec:
+      containers:
+      - name: {{ .Name }}
+        image: {{ .Image }}
+        command: ["/bin/sh", "-c", "mkdir -p /www && echo {{ .Name }} > /www/index.html && httpd -f -p {{ .Port }} -h /www"]
+        ports:
+        - containerPort: {{ .Port }}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+spec:
+  selector:
+    app: {{ .Name }}
+  ports:
+  - port: 80
+    targetPort: {{ .Port }}
+`
+
+func renderVerifyManifest(namespace, image string) (string, error) {
+	datastore := make(map[string]interface{})
+	datastore["Namespace"] = namespace
+	datastore["Name"] = verifyAppName
+	datastore["Image"] = image
+	datastore["Port"] = verifyHTTPPort
+	return template.TemplateRender(verifyManifestTmpl, datastore)
+}
+
+// parse output of "kubectl get pods -o jsonpath={.items[*].status.podIP}"
+func parsePodIPs(output string) []string {
+	var ips []string
+	for _, ip := range strings.Fields(output) {
+		if ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+type verifier struct {
+	r          runner.Runner
+	namespace  string
+	kubeconfig string
+	dnsDomain  string
+}
+
+func (v *verifier) kubectl(args string) (string, error) {
+	cmd := fmt.Sprintf("KUBECONFIG=%s kubectl -n %s %s", v.kubeconfig, v.namespace, args)
+	return runCommandWithTimeout(v.r, utils.AddSudo(cmd), verifyCommandTimeout)
+}
+
+// run command in the pod of verify deployment
+func (v *verifier) exec(cmd string) (string, error) {
+	return v.kubectl(fmt.Sprintf("exec deploy/%s -- %s", verifyAppName, cmd))
+}
+
+func (v *verifier) deploy(image string) error {
+	manifest, err := renderVerifyManifest(v.namespace, image)
+	if err != nil {
+		return err
+	}
+	shell := fmt.Sprintf(`#!/bin/bash
+echo %s | base64 -d | KUBECONFIG=%s kubectl apply -f -
+`, base64.StdEncoding.EncodeToString([]byte(manifest)), v.kubeconfig)
+	if _, err = v.r.RunShell(shell, "eggoVerifyApply"); err != nil {
+		return fmt.Errorf("apply verify workload failed: %v", err)
+	}
+	return nil
+}
+
+// namespace of test workload will be deleted after verify, so it must not be used by others
+func (v *verifier) checkNamespace() error {
+	cmd := fmt.Sprintf("KUBECONFIG=%s kubectl get namespace %s --ignore-not-found -o name", v.kubeconfig, v.namespace)
+	output, err := runCommandWithTimeout(v.r, utils.AddSudo(cmd), verifyCommandTimeout)
+	if err != nil {
+		return fmt.Errorf("get namespace %s failed: %v", v.namespace, err)
+	}
+	if strings.TrimSpace(output) != "" {
+		return fmt.Errorf("namespace %s already exists, please specify another one", v.namespace)
+	}
+	return nil
+}
+
+func (v *verifier) cleanup() {
+	cmd := fmt.Sprintf("KUBECONFIG=%s kubectl delete namespace %s --ignore-not-found --wait=false", v.kubeconfig, v.namespace)
+	if _, err := runCommandWithTimeout(v.r, utils.AddSudo(cmd), verifyCommandTimeout); err != nil {
+		logrus.Warnf("cleanup verify namespace %s failed: %v", v.namespace, err)
+	}
+}
+
+func (v *verifier) checkWorkloadReady() error {
+	_, err := v.kubectl(fmt.Sprintf("rollout status deployment/%s --timeout=%s", verifyAppName, verifyWaitTimeout))
+	return err
+}
+
+func (v *verifier) checkDNS() error {
+	_, err := v.exec(fmt.Sprintf("nslookup kubernetes.default.svc.%s", v.dnsDomain))
+	return err
+}
+
+func (v *verifier) checkPodNetwork() error {
+	output, err := v.kubectl(fmt.Sprintf("get pods -l app=%s -o jsonpath={.items[*].status.podIP}", verifyAppName))
+	if err != nil {
+		return err
+	}
+	ips := parsePodIPs(output)
+	if len(ips) == 0 {
+		return fmt.Errorf("no ip of verify pods")
+	}
+	for _, ip := range ips {
+		if _, err := v.exec(fmt.Sprintf("wget -q -O - -T 5 http://%s:%d", ip, verifyHTTPPort)); err != nil {
+			return fmt.Errorf("access pod %s failed: %v", ip, err)
+		}
+	}
+	return nil
+}
+
+func (v *verifier) checkService() error {
+	svc := fmt.Sprintf("%s.%s.svc.%s", verifyAppName, v.namespace, v.dnsDomain)
+	output, err := v.exec(fmt.Sprintf("wget -q -O - -T 5 http://%s", svc))
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(output, verifyAppName) {
+		return fmt.Errorf("unexpected response of service %s: %s", svc, output)
+	}
+	return nil
+}
+
+func verifyCluster(ccfg *api.ClusterConfig, namespace, image string) ([]healthItem, error) {
+	masters := connectMasters(ccfg)
+	defer func() {
+		for _, m := range masters {
+			if m.r != nil {
+				m.r.Close()
+			}
+		}
+	}()
+
+	var v *verifier
+	for _, m := range masters {
+		if m.err != nil {
+			logrus.Debugf("connect master %s failed: %v", m.host.Address, m.err)
+			continue
+		}
+		v = &verifier{
+			r:          m.r,
+			namespace:  namespace,
+			kubeconfig: filepath.Join(ccfg.GetConfigDir(), constants.KubeConfigFileNameAdmin),
+			dnsDomain:  ccfg.GetDNSDomain(),
+		}
+		break
+	}
+	if v == nil {
+		return nil, fmt.Errorf("all masters of cluster: %s are unreachable", ccfg.Name)
+	}
+
+	if err := v.checkNamespace(); err != nil {
+		return nil, err
+	}
+	// cleanup test workload even if deploy failed
+	defer v.cleanup()
+	if err := v.deploy(ccfg.GetImage(image)); err != nil {
+		return nil, err
+	}
+
+	checks := []struct {
+		name string
+		fn   func() error
+	}{
+		{name: "workload ready", fn: v.checkWorkloadReady},
+		{name: "coredns resolution", fn: v.checkDNS},
+		{name: "pod to pod network", fn: v.checkPodNetwork},
+		{name: "service connectivity", fn: v.checkService},
+	}
+
+	var items []healthItem
+	failed := false
+	for _, c := range checks {
+		item := healthItem{name: c.name, status: verifyPassed}
+		if err := c.fn(); err != nil {
+			failed = true
+			item.status, item.message = verifyFailed, err.Error()
+		}
+		items = append(items, item)
+	}
+	if failed {
+		return items, fmt.Errorf("verify cluster: %s failed", ccfg.Name)
+	}
+
+	return items, nil
+}
+
+func verifyClusterCmd(cmd *cobra.Command, args []string) error {
+	if opts.debug {
+		initLog()
+	}
+
+	if opts.verifyConfig == "" && opts.verifyClusterID == "" {
+		return fmt.Errorf("please specify cluster id or deploy config file")
+	}
+	if errs := validation.IsDNS1123Label(opts.verifyNamespace); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace: %s, err: %v", opts.verifyNamespace, errs)
+	}
+
+	confPath := opts.verifyConfig
+	if confPath == "" {
+		confPath = savedDeployConfigPath(opts.verifyClusterID)
+		if _, err := os.Stat(confPath); err != nil {
+			return fmt.Errorf("stat %v failed: %v", confPath, err)
+		}
+	}
+
+	conf, err := loadDeployConfig(confPath)
+	if err != nil {
+		return fmt.Errorf("load deploy config file %v failed: %v", confPath, err)
+	}
+	if err = RunChecker(conf); err != nil {
+		return err
+	}
+
+	items, err := verifyCluster(toClusterdeploymentConfig(conf, nil), opts.verifyNamespace, opts.verifyImage)
+	if len(items) != 0 {
+		showHealthItems(os.Stdout, "Verify results", items)
+	}
+
+	return err
+}
+
+func NewVerifyCmd() *cobra.Command {
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "verify dns, pod network and service of a kubernetes cluster",
+		RunE:  verifyClusterCmd,
+	}
+
+	setupVerifyCmdOpts(verifyCmd)
+
+	return verifyCmd
+}
@@ -0,0 +1,50 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: cmd verify testcase
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderVerifyManifest(t *testing.T) {
+	manifest, err := renderVerifyManifest("eggo-test", "hub.example.com/library/busybox:1.28")
+	if err != nil {
+		t.Fatalf("render verify manifest failed: %v", err)
+	}
+	expects := []string{
+		"name: eggo-test",
+		"namespace: eggo-test",
+		"image: hub.example.com/library/busybox:1.28",
+		"containerPort: 8080",
+		"kind: Service",
+	}
+	for _, e := range expects {
+		if !strings.Contains(manifest, e) {
+			t.Fatalf("expect %q in manifest: %s", e, manifest)
+		}
+	}
+}
+
+func TestParsePodIPs(t *testing.T) {
+	ips := parsePodIPs("10.244.1.2 10.244.2.3\n")
+	if len(ips) != 2 || ips[0] != "10.244.1.2" || ips[1] != "10.244.2.3" {
+		t.Fatalf("invalid pod ips: %v", ips)
+	}
+	if ips = parsePodIPs(""); len(ips) != 0 {
+		t.Fatalf("expect no pod ips, get: %v", ips)
+	}
+}
@@ -55,6 +55,22 @@ type RequireMachineConfig struct {
 	// require machie need in which cidr
 	// +optional
 	Features map[string]string `json:"features,omitempty"`
+
+	// names of machines pinned for the role, intersect with machines selected by features if both set,
+	// all named machines must be free
+	// +optional
+	MachineNames []string `json:"machineNames,omitempty"`
+
+	// spread machines across failure domains, such as zones
+	// +optional
+	AntiAffinity *MachineAntiAffinity `json:"antiAffinity,omitempty"`
+}
+
+// MachineAntiAffinity spreads machines of role across domains defined by label of machines,
+// machines without the label are in the same domain
+type MachineAntiAffinity struct {
+	// label key of machines which defines domains, such as topology.zone
+	TopologyKey string `json:"topologyKey"`
 }
 
 // ClusterSpec defines the desired state of Cluster
@@ -77,6 +93,14 @@ type ClusterSpec struct {
 	// Describe affinity scheduling rules for eggo pod
 	EggoAffinity *v1.Affinity `json:"eggoAffinity,omitempty"`
 
+	// node selector of eggo pod, to run it on control nodes
+	// +optional
+	EggoNodeSelector map[string]string `json:"eggoNodeSelector,omitempty"`
+
+	// tolerations of eggo pod
+	// +optional
+	EggoTolerations []v1.Toleration `json:"eggoTolerations,omitempty"`
+
 	// MachineLoginSecret save user/password for ssh login
 	//+kubebuilder:validation:Required
 	MachineLoginSecret *v1.ObjectReference `json:"machineLoginSecret,omitempty"`
@@ -98,14 +122,71 @@ type ClusterSpec struct {
 	// +optional
 	EggoImageVersion string `json:"eggoImageVersion"`
 
+	// full reference of eggo image in private registry, overrides eggoImageVersion
+	// +optional
+	EggoImage string `json:"eggoImage,omitempty"`
+
+	// secrets in workspace namespace to pull eggo image
+	// +optional
+	ImagePullSecrets []v1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// retries before marking eggo job as failed, default is 3
+	// +optional
+	//+kubebuilder:validation:Minimum=0
+	JobBackoffLimit *int32 `json:"jobBackoffLimit,omitempty"`
+
+	// duration in seconds of eggo job may be active, default is 7200
+	// +optional
+	//+kubebuilder:validation:Minimum=1
+	JobActiveDeadlineSeconds *int64 `json:"jobActiveDeadlineSeconds,omitempty"`
+
+	// resource requests and limits of eggo container, default requests 100m cpu and 128Mi memory,
+	// limits 1 cpu and 1Gi memory, keep eggo pod from being evicted during deploy
+	// +optional
+	JobResources *v1.ResourceRequirements `json:"jobResources,omitempty"`
+
+	// seconds for pods to terminate gracefully when drain workers before removing them,
+	// default uses grace period of pods
+	// +optional
+	//+kubebuilder:validation:Minimum=0
+	DrainGracePeriodSeconds *int32 `json:"drainGracePeriodSeconds,omitempty"`
+
 	Addons []string `json:"addons,omitempty"`
+
+	// namespace to place machine binding, configmap and jobs of cluster, default is namespace of cluster;
+	// machine login secret and package pvc are mounted into jobs, so they must be in this namespace
+	// +optional
+	WorkspaceNamespace string `json:"workspaceNamespace,omitempty"`
 }
 
+// ClusterPhase is the phase of cluster lifecycle
+// +kubebuilder:validation:Enum=Pending;Binding;Configuring;Deploying;Running;Deleting;Failed
+type ClusterPhase string
+
+const (
+	// waiting for machines to bind
+	ClusterPhasePending ClusterPhase = "Pending"
+	// machines binded, getting secret, infrastructure and pvc of cluster
+	ClusterPhaseBinding ClusterPhase = "Binding"
+	// generating eggo config of cluster
+	ClusterPhaseConfiguring ClusterPhase = "Configuring"
+	// job to create cluster is running
+	ClusterPhaseDeploying ClusterPhase = "Deploying"
+	ClusterPhaseRunning   ClusterPhase = "Running"
+	ClusterPhaseDeleting  ClusterPhase = "Deleting"
+	// last job to create cluster failed, it will be retried
+	ClusterPhaseFailed ClusterPhase = "Failed"
+)
+
 type JobHistory struct {
 	Name       string       `json:"name"`
 	StartTime  metav1.Time  `json:"start-time"`
 	FinishTime *metav1.Time `json:"finish-time,omitempty"`
-	Message    string       `json:"message,omitempty"`
+	Succeeded  bool         `json:"succeeded"`
+	// reason of failed job
+	Message string `json:"message,omitempty"`
+	// tail logs of eggo container when job failed
+	Logs string `json:"logs,omitempty"`
 }
 
 // ClusterStatus defines the observed state of Cluster
@@ -127,10 +208,17 @@ type ClusterStatus struct {
 	HasCluster bool   `json:"hasCluster,omitempty"`
 	Deleted    bool   `json:"deleted,omitempty"`
 	Message    string `json:"message,omitempty"`
+
+	Phase ClusterPhase `json:"phase,omitempty"`
+	// number of machines binded to cluster
+	Nodes int32 `json:"nodes,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Nodes",type=integer,JSONPath=`.status.nodes`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Cluster is the Schema for the clusters API
 type Cluster struct {
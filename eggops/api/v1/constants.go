@@ -10,13 +10,33 @@ const (
 const (
 	ImageVersion string = "1.0.0-alpha"
 
+	DefaultJobBackoffLimit          int32 = 3
+	DefaultJobActiveDeadlineSeconds int64 = 7200
+
+	// default resources of eggo container
+	DefaultJobCPURequest    string = "100m"
+	DefaultJobMemoryRequest string = "128Mi"
+	DefaultJobCPULimit      string = "1"
+	DefaultJobMemoryLimit   string = "1Gi"
+
 	ClusterConfigMapNameFormat    string = "eggo-cluster-%s-%s"
 	ClusterConfigMapBinaryConfKey string = "eggo-binary-config"
+	ClusterConfigMapJoinConfKey   string = "eggo-join-config"
 
 	EggoConfigVolumeFormat string = "/%s-config"
 	PrivateKeyVolumeFormat string = "/%s-privatekey"
 	PackageVolumeFormat    string = "/%s-package"
 
+	// pvc keeps config and certificates of cluster for its jobs
+	EggoHomeVolumeClaimFormat     string = "eggo-home-%s"
+	DefaultEggoHomeStorageRequest string = "100Mi"
+	// secret keeps config and certificates of cluster deployed in process
+	EggoHomeSecretFormat string = "eggo-home-%s"
+	EggoHomeSecretKey    string = "eggo-home.tar.gz"
+
+	// optional key of sudo password in machine login secret
+	MachineLoginSecretSudoPasswordKey string = "sudo-password"
+
 	DefaultPackageArmName   string = "packages-arm.tar.gz"
 	DefaultPackageX86Name   string = "packages-x86.tar.gz"
 	DefaultPackageRISCVName string = "packages-risc-v.tar.gz"
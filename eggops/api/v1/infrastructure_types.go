@@ -122,6 +122,10 @@ type InfrastructureSpec struct {
 	//+kubebuilder:validation:Required
 	PackagePersistentVolumeClaim *v1.ObjectReference `json:"packagePersistentVolumeClaim,omitempty"`
 
+	// EggoHomeStorageClassName is storage class of volume created for each cluster to keep its
+	// config and certificates, default storage class is used if not set
+	EggoHomeStorageClassName *string `json:"eggoHomeStorageClassName,omitempty"`
+
 	InstallConfig InstallConfig `json:"install,omitempty"`
 
 	OpenPorts OpenPortsConfig `json:"open-ports,omitempty"`
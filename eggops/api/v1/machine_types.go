@@ -45,6 +45,11 @@ type MachineSpec struct {
 	//+kubebuilder:validation:Minimum=0
 	//+kubebuilder:validation:Maximum=65535
 	Port *int32 `json:"port,omitempty"`
+
+	// machine in maintenance is not selected by clusters, and is removed from cluster it is bound to,
+	// a free machine is selected to replace it if available
+	// +optional
+	Maintenance bool `json:"maintenance,omitempty"`
 }
 
 // MachineStatus defines the observed state of Machine
@@ -63,8 +68,22 @@ type MachineStatus struct {
 
 	// record error information
 	ErrorMessage string `json:"error-message,omitempty"`
+
+	// health of machine probed by machine controller, empty if not probed yet
+	Health MachineHealth `json:"health,omitempty"`
+
+	// last time when machine is probed
+	LastProbeTime *metav1.Time `json:"last-probe-time,omitempty"`
 }
 
+// MachineHealth is reachability of machine
+type MachineHealth string
+
+const (
+	MachineHealthy   MachineHealth = "Healthy"
+	MachineUnhealthy MachineHealth = "Unhealthy"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 
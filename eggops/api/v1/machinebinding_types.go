@@ -61,6 +61,8 @@ type MachineBindingSpec struct {
 type MachineCondition struct {
 	UsagesStatus int32  `json:"usagesStatus,omitempty"`
 	Message      string `json:"message,omitempty"`
+	// machine is being cleanup from cluster, it is kept binded until cleanup succeeded
+	Removing bool `json:"removing,omitempty"`
 }
 
 // MachineBindingStatus defines the observed state of MachineBinding
@@ -113,9 +115,9 @@ func (mb *MachineBinding) AddMachine(machine Machine, usage int32) {
 	mb.Spec.Usages[string(machine.UID)] = old | usage
 
 	uStr := getUsageStr(usage)
-	for _, set := range mb.Spec.MachineSets {
-		if set.Usage == uStr {
-			set.Machines = append(set.Machines, &machine)
+	for i := range mb.Spec.MachineSets {
+		if mb.Spec.MachineSets[i].Usage == uStr {
+			mb.Spec.MachineSets[i].Machines = append(mb.Spec.MachineSets[i].Machines, &machine)
 			return
 		}
 	}
@@ -125,6 +127,41 @@ func (mb *MachineBinding) AddMachine(machine Machine, usage int32) {
 	})
 }
 
+// MarkRemoving marks machine with uid is being cleanup from cluster
+func (mb *MachineBinding) MarkRemoving(uid string, message string) {
+	mb.UpdateCondition(MachineCondition{UsagesStatus: mb.Spec.Usages[uid], Message: message, Removing: true}, uid)
+}
+
+// IsRemoving returns true if machine with uid is being cleanup from cluster
+func (mb *MachineBinding) IsRemoving(uid string) bool {
+	return mb.Status.Conditions[uid].Removing
+}
+
+// RemoveMachine remove machine with uid from all usages
+func (mb *MachineBinding) RemoveMachine(uid string) {
+	delete(mb.Spec.Usages, uid)
+	delete(mb.Status.Conditions, uid)
+	for i := range mb.Spec.MachineSets {
+		var machines []*Machine
+		for _, m := range mb.Spec.MachineSets[i].Machines {
+			if string(m.UID) != uid {
+				machines = append(machines, m)
+			}
+		}
+		mb.Spec.MachineSets[i].Machines = machines
+	}
+}
+
+// GetMachines return machines binded with usage
+func (mb *MachineBinding) GetMachines(usage int32) []*Machine {
+	for _, set := range mb.Spec.MachineSets {
+		if set.MatchType(uint32(usage)) {
+			return set.Machines
+		}
+	}
+	return nil
+}
+
 //+kubebuilder:object:root=true
 
 // MachineBindingList contains a list of MachineBinding
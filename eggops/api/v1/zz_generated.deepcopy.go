@@ -196,6 +196,20 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 		*out = new(corev1.Affinity)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.EggoNodeSelector != nil {
+		in, out := &in.EggoNodeSelector, &out.EggoNodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.EggoTolerations != nil {
+		in, out := &in.EggoTolerations, &out.EggoTolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.MachineLoginSecret != nil {
 		in, out := &in.MachineLoginSecret, &out.MachineLoginSecret
 		*out = new(corev1.ObjectReference)
@@ -209,11 +223,36 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 	in.ApiEndpoint.DeepCopyInto(&out.ApiEndpoint)
 	out.Runtime = in.Runtime
 	in.Network.DeepCopyInto(&out.Network)
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
 	if in.Addons != nil {
 		in, out := &in.Addons, &out.Addons
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.JobBackoffLimit != nil {
+		in, out := &in.JobBackoffLimit, &out.JobBackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.JobActiveDeadlineSeconds != nil {
+		in, out := &in.JobActiveDeadlineSeconds, &out.JobActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.JobResources != nil {
+		in, out := &in.JobResources, &out.JobResources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DrainGracePeriodSeconds != nil {
+		in, out := &in.DrainGracePeriodSeconds, &out.DrainGracePeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSpec.
@@ -349,6 +388,11 @@ func (in *InfrastructureSpec) DeepCopyInto(out *InfrastructureSpec) {
 		*out = new(corev1.ObjectReference)
 		**out = **in
 	}
+	if in.EggoHomeStorageClassName != nil {
+		in, out := &in.EggoHomeStorageClassName, &out.EggoHomeStorageClassName
+		*out = new(string)
+		**out = **in
+	}
 	in.InstallConfig.DeepCopyInto(&out.InstallConfig)
 	in.OpenPorts.DeepCopyInto(&out.OpenPorts)
 }
@@ -513,7 +557,7 @@ func (in *Machine) DeepCopyInto(out *Machine) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Machine.
@@ -534,6 +578,21 @@ func (in *Machine) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineAntiAffinity) DeepCopyInto(out *MachineAntiAffinity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineAntiAffinity.
+func (in *MachineAntiAffinity) DeepCopy() *MachineAntiAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineAntiAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineBinding) DeepCopyInto(out *MachineBinding) {
 	*out = *in
@@ -740,6 +799,10 @@ func (in *MachineSpec) DeepCopy() *MachineSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineStatus) DeepCopyInto(out *MachineStatus) {
 	*out = *in
+	if in.LastProbeTime != nil {
+		in, out := &in.LastProbeTime, &out.LastProbeTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineStatus.
@@ -878,6 +941,16 @@ func (in *RequireMachineConfig) DeepCopyInto(out *RequireMachineConfig) {
 			(*out)[key] = val
 		}
 	}
+	if in.MachineNames != nil {
+		in, out := &in.MachineNames, &out.MachineNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AntiAffinity != nil {
+		in, out := &in.AntiAffinity, &out.AntiAffinity
+		*out = new(MachineAntiAffinity)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequireMachineConfig.
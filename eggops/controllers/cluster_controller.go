@@ -25,22 +25,48 @@ import (
 	"github.com/go-logr/logr"
 	batch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/tools/reference"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	eggov1 "isula.org/eggo/eggops/api/v1"
+	"isula.org/eggo/pkg/utils"
 )
 
 const (
 	ClusterFinalizerName = "cluster.eggo.isula.org/finalizer"
 	MachineBindingFormat = "machinebind-%s"
+
+	eggoHomePath = "/etc/eggo"
+)
+
+// reasons of events recorded for cluster
+const (
+	ReasonMachineBindingCreated = "MachineBindingCreated"
+	ReasonConfigGenerated       = "ConfigGenerated"
+	ReasonConfigChanged         = "ConfigChanged"
+	ReasonJobStarted            = "JobStarted"
+	ReasonJobSucceeded          = "JobSucceeded"
+	ReasonJobFailed             = "JobFailed"
+	ReasonClusterDeleted        = "ClusterDeleted"
+	ReasonWaitingResources      = "WaitingResources"
+	ReasonMachinesNotSpread     = "MachinesNotSpread"
+	ReasonRemoveMastersRefused  = "RemoveMastersRefused"
+	ReasonScaleDownWorkers      = "ScaleDownWorkers"
 )
 
 // ClusterReconciler reconciles a Cluster object
@@ -48,6 +74,27 @@ type ClusterReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	// used to get logs of failed job
+	KubeClient kubernetes.Interface
+	// number of lines from the end of failed job logs to save
+	JobLogLines int64
+	// record events of cluster lifecycle
+	Recorder record.EventRecorder
+	// max number of job histories saved in status of cluster, oldest ones are trimmed
+	JobHistoryLimit int
+	// requeue interval of not ready cluster grows from base to max, and resets on progress
+	RequeueBaseInterval time.Duration
+	RequeueMaxInterval  time.Duration
+	// deploy and cleanup cluster by eggo library in controller, instead of eggo job
+	InProcessDeploy bool
+	// deployer used in process, default is eggo library
+	Deployer ClusterDeployer
+	// stop at the first problem of machines or nodes, instead of reporting all of them
+	FailFast bool
+
+	backoff requeueBackoff
+	// deploy and cleanup of clusters running in process
+	inProcess inProcessTasks
 }
 
 // +kubebuilder:rbac:groups=eggo.isula.org,resources=clusters,verbs=get;list;watch;create;update;patch;delete
@@ -59,10 +106,13 @@ type ClusterReconciler struct {
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=jobs/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
 // +kubebuilder:rbac:groups="",resources=configmaps/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;delete
 // +kubebuilder:rbac:groups="",resources=persistentvolumeclaims/status,verbs=get;list;watch
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -90,13 +140,14 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 		return ctrl.Result{}, nil
 	}
 
+	// patch changes from base, to avoid conflicts with other writers of cluster
+	base := cluster.DeepCopy()
 	// update cluster after Reconcile
 	defer func() {
 		if err != nil {
 			return
 		}
-		// TODO: maybe should use patch to replace
-		if err = r.Update(ctx, cluster); err != nil {
+		if err = r.Patch(ctx, cluster, client.MergeFrom(base)); err != nil {
 			log.Error(err, "unable to update cluster", "name", cluster.Name)
 			return
 		}
@@ -114,7 +165,7 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 	} else {
 		// this cluster is being deleting
 		if foundString(cluster.GetFinalizers(), ClusterFinalizerName) {
-			res, err = r.reconcileDelete(ctx, cluster)
+			res, err = r.reconcileDelete(ctx, cluster, base)
 			if err != nil {
 				return
 			}
@@ -130,14 +181,14 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 		return
 	}
 
-	return r.reconcile(ctx, cluster)
+	return r.reconcile(ctx, cluster, base)
 }
 
 func (r *ClusterReconciler) prepareDeleteClusterJob(ctx context.Context, cluster *eggov1.Cluster) (bool, error) {
 	cmName := fmt.Sprintf(eggov1.ClusterConfigMapNameFormat, cluster.Name, "cmd-config")
 	job := &batch.Job{}
 	jobName := fmt.Sprintf("%s-delete-job", cluster.Name)
-	err := r.Get(ctx, types.NamespacedName{Name: jobName, Namespace: cluster.Namespace}, job)
+	err := r.Get(ctx, types.NamespacedName{Name: jobName, Namespace: GetWorkspaceNamespace(cluster)}, job)
 	if err == nil {
 		finish, terr := jobIsFinished(job)
 		if finish {
@@ -147,12 +198,18 @@ func (r *ClusterReconciler) prepareDeleteClusterJob(ctx context.Context, cluster
 			}
 			if terr != nil {
 				history.Message = terr.Error()
+				history.Logs = r.getJobLogs(ctx, job)
 			} else {
-				history.Message = "success"
+				history.Succeeded = true
 			}
 			background := metav1.DeletePropagationBackground
 			if err = r.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &background}); err == nil {
-				cluster.Status.JobHistorys = append(cluster.Status.JobHistorys, history)
+				r.addJobHistory(cluster, history)
+			}
+			if terr != nil {
+				r.Recorder.Event(cluster, v1.EventTypeWarning, ReasonJobFailed, terr.Error())
+			} else {
+				r.Recorder.Eventf(cluster, v1.EventTypeNormal, ReasonJobSucceeded, "job %s to delete cluster succeeded", job.GetName())
 			}
 		}
 		return finish, terr
@@ -171,7 +228,7 @@ func (r *ClusterReconciler) prepareDeleteClusterJob(ctx context.Context, cluster
 
 	configPath := fmt.Sprintf(eggov1.EggoConfigVolumeFormat, cluster.Name)
 	Command := []string{"eggo", "-d", "cleanup", "-f", filepath.Join(configPath, eggov1.ClusterConfigMapBinaryConfKey)}
-	job = createEggoJobConfig(cluster.Namespace, jobName, "eggo-create-cluster", GetEggoImageVersion(cluster), configPath, cmName,
+	job = createEggoJobConfig(GetWorkspaceNamespace(cluster), jobName, "eggo-create-cluster", GetEggoImage(cluster), configPath, cmName,
 		fmt.Sprintf(eggov1.PackageVolumeFormat, cluster.Name), packagePVC.Name, Command)
 
 	err = fillEggoJobConfig(r, ctx, cluster, job)
@@ -184,16 +241,17 @@ func (r *ClusterReconciler) prepareDeleteClusterJob(ctx context.Context, cluster
 	if err != nil {
 		return false, err
 	}
+	r.Recorder.Eventf(cluster, v1.EventTypeNormal, ReasonJobStarted, "job %s to delete cluster started", jobName)
 
 	return false, nil
 }
 
-func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster *eggov1.Cluster) (ctrl.Result, error) {
+func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster, base *eggov1.Cluster) (ctrl.Result, error) {
 	log := r.Log
 	// TODO: cleanup external resources
 	defer func() {
-		// TODO: maybe should use patch to replace
-		if err := r.Status().Update(ctx, cluster); err != nil {
+		cluster.Status.Phase = eggov1.ClusterPhaseDeleting
+		if err := r.Status().Patch(ctx, cluster, client.MergeFrom(base)); err != nil {
 			log.Error(err, "unable to update cluster status", "name", cluster.Name)
 			return
 		}
@@ -217,26 +275,39 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster *eggov1
 			if err != nil {
 				log.Error(err, "delete running job for cluster")
 			}
-			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+			return r.requeueNotReady(cluster), nil
 		}
 
 		if client.IgnoreNotFound(err) != nil {
 			r.Log.Error(err, "get running job failed")
-			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+			return r.requeueNotReady(cluster), nil
 		}
 
 		r.Log.Info("delete running job success")
 		cluster.Status.JobRef = nil
 	}
 
+	// interrupt deploy running in process
+	if r.InProcessDeploy && !r.cancelInProcess(cluster, deployInProcessName(cluster)) {
+		return r.requeueNotReady(cluster), nil
+	}
+
 	// Step 2: run job to delete cluster
-	if cluster.IsCreated() {
-		finish, err := r.prepareDeleteClusterJob(ctx, cluster)
+	if cluster.IsCreated() && r.InProcessDeploy {
+		finish, err := r.cleanupClusterInProcess(ctx, cluster)
+		if err != nil {
+			log.Error(err, "cleanup cluster in process", "name", cluster.Name)
+			return r.requeueNotReady(cluster), nil
+		}
 		if !finish {
-			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+			return r.requeueNotReady(cluster), nil
 		}
-		if err != nil {
-			return ctrl.Result{RequeueAfter: time.Second}, nil
+		cluster.Status.HasCluster = false
+	}
+	if cluster.IsCreated() {
+		finish, err := r.prepareDeleteClusterJob(ctx, cluster)
+		if !finish || err != nil {
+			return r.requeueNotReady(cluster), nil
 		}
 		// delete cluster success, just update status of cluster
 		cluster.Status.HasCluster = false
@@ -255,6 +326,10 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster *eggov1
 		log.Info("delete machine binding success...")
 		cluster.Status.MachineBindingRef = nil
 	}
+	if err := r.releaseMachines(ctx, cluster, nil); err != nil {
+		log.Error(err, "release machines of cluster", "name", cluster.Name)
+		return r.requeueNotReady(cluster), nil
+	}
 
 	// Step 4: delete configmap
 	if cluster.Status.ConfigRef != nil {
@@ -270,18 +345,35 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster *eggov1
 		cluster.Status.ConfigRef = nil
 	}
 
-	// Step 5: reset secret and pvc
+	// Step 5: delete pvc and secret keeping config of cluster, after jobs of cluster finished
+	removed, err := r.deleteEggoHomePVC(ctx, cluster)
+	if err == nil {
+		err = r.deleteEggoHomeSecret(ctx, cluster)
+	}
+	if err != nil {
+		log.Error(err, "delete eggo home pvc for cluster", "name", cluster.Name)
+		return r.requeueNotReady(cluster), nil
+	}
+	if !removed {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// Step 6: reset secret and pvc
 	cluster.Status.MachineBindingRef = nil
 	cluster.Status.PackagePersistentVolumeClaimRef = nil
 
 	cluster.Status.Deleted = true
+	r.forgetBackoff(cluster)
+	r.Recorder.Event(cluster, v1.EventTypeNormal, ReasonClusterDeleted, "cluster deleted")
 
 	return ctrl.Result{}, nil
 }
 
+// bindedSelectMachines returns machines in namespace binded by clusters,
+// machine bindings may be in workspace namespaces of clusters, so list them in all namespaces
 func (r *ClusterReconciler) bindedSelectMachines(ctx context.Context, namespace string) (map[string]bool, error) {
 	var mbList eggov1.MachineBindingList
-	mbOptions := client.ListOptions{Namespace: namespace}
+	mbOptions := client.ListOptions{}
 	mbOptions.LabelSelector = labels.SelectorFromSet(labels.Set{})
 	if err := r.List(ctx, &mbList, &mbOptions); err != nil {
 		return nil, err
@@ -291,7 +383,14 @@ func (r *ClusterReconciler) bindedSelectMachines(ctx context.Context, namespace
 	for _, mb := range mbList.Items {
 		for _, ms := range mb.Spec.MachineSets {
 			for _, m := range ms.Machines {
-				machinesBinded[m.GetName()] = true
+				// machines saved without namespace are in namespace of binding
+				mns := m.GetNamespace()
+				if mns == "" {
+					mns = mb.GetNamespace()
+				}
+				if mns == namespace {
+					machinesBinded[m.GetName()] = true
+				}
 			}
 		}
 	}
@@ -311,14 +410,50 @@ func (r *ClusterReconciler) labelSelectMachines(ctx context.Context, namespace s
 		return nil, err
 	}
 
+	var names map[string]bool
+	if len(config.MachineNames) != 0 {
+		names = make(map[string]bool, len(config.MachineNames))
+		for _, name := range config.MachineNames {
+			names[name] = true
+		}
+	}
+
 	machinesSelected := make(map[string]eggov1.Machine)
 	for _, m := range mList.Items {
+		// pinned machines must match features too
+		if names != nil && !names[m.GetName()] {
+			continue
+		}
 		machinesSelected[m.GetName()] = m
 	}
 
 	return machinesSelected, nil
 }
 
+// machineSchedulable returns true if machine can be selected by cluster, machine not probed yet is
+// schedulable, unhealthy one is skipped until it recovers, and one in maintenance until it finishes
+func machineSchedulable(m *eggov1.Machine) bool {
+	return m.Status.Health != eggov1.MachineUnhealthy && !m.Spec.Maintenance
+}
+
+// checkPinnedMachine checks machine pinned by machineNames could be selected
+func checkPinnedMachine(machinesSelected map[string]eggov1.Machine, machineBinded map[string]bool, name string) error {
+	m, ok := machinesSelected[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrMachineNotMatch, name)
+	}
+	if machineBinded[name] {
+		return fmt.Errorf("%w: %s", ErrMachineInUse, name)
+	}
+	if m.Status.Health == eggov1.MachineUnhealthy {
+		return fmt.Errorf("%w: %s", ErrMachineUnhealthy, name)
+	}
+	if m.Spec.Maintenance {
+		return fmt.Errorf("%w: %s", ErrMachineInMaintenance, name)
+	}
+	return nil
+}
+
 func (r *ClusterReconciler) availableSelectMachines(ctx context.Context, namespace string, config eggov1.RequireMachineConfig, machineBinded map[string]bool) (map[string]eggov1.Machine, error) {
 	if config.Number <= 0 {
 		return map[string]eggov1.Machine{}, nil
@@ -329,19 +464,30 @@ func (r *ClusterReconciler) availableSelectMachines(ctx context.Context, namespa
 		return nil, err
 	}
 
+	// problems of all pinned machines are reported together
+	errs := utils.NewMultiError(r.FailFast)
+	for _, name := range config.MachineNames {
+		if errs.Add(checkPinnedMachine(machinesSelected, machineBinded, name)) {
+			break
+		}
+	}
+	if err = errs.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
 	if int(config.Number) > len(machinesSelected) {
-		return nil, fmt.Errorf("cannot find enough machine")
+		return nil, fmt.Errorf("%w: require %d, found %d", ErrInsufficientMachines, config.Number, len(machinesSelected))
 	}
 
 	machinesAvailable := make(map[string]eggov1.Machine)
 	for name, m := range machinesSelected {
-		if _, ok := machineBinded[name]; !ok {
+		if _, ok := machineBinded[name]; !ok && machineSchedulable(&m) {
 			machinesAvailable[name] = m
 		}
 	}
 
 	if int(config.Number) > len(machinesAvailable) {
-		return nil, fmt.Errorf("cannot find enough machine")
+		return nil, fmt.Errorf("%w: require %d, available %d", ErrInsufficientMachines, config.Number, len(machinesAvailable))
 	}
 
 	return machinesAvailable, nil
@@ -360,11 +506,11 @@ type machineFilter struct {
 	filter_len int32
 }
 
-// TODO: filter Machines by better algorithm
 func (r *ClusterReconciler) filterMachines(ctx context.Context, cluster *eggov1.Cluster) (mMachines, wMachines, lMachines []eggov1.Machine, err error) {
 	log := r.Log
 
-	machineBinded, err := r.bindedSelectMachines(ctx, cluster.Namespace)
+	// machines reserved by other clusters are in use too
+	machineBinded, err := r.unavailableMachines(ctx, cluster)
 	if err != nil {
 		log.Error(err, "select binded machines")
 		return
@@ -396,13 +542,20 @@ func (r *ClusterReconciler) filterMachines(ctx context.Context, cluster *eggov1.
 	}
 	machinesFilter := []*machineFilter{&masterFilter, &workerFilter, &loadbalanceFilter}
 
+	// machines of all roles are checked, problems of roles are reported together
+	errs := utils.NewMultiError(r.FailFast)
 	for _, mf := range machinesFilter {
 		mf.available, err = r.availableSelectMachines(ctx, cluster.Namespace, mf.require, machineBinded)
 		if err != nil {
-			log.Error(err, "available select machines")
-			return
+			if errs.Add(fmt.Errorf("%s: %w", mf.name, err)) {
+				break
+			}
 		}
 	}
+	if err = errs.ErrorOrNil(); err != nil {
+		log.Error(err, "available select machines")
+		return
+	}
 
 	// set machineTable
 	machineTable := make(map[string]uint32)
@@ -416,99 +569,103 @@ func (r *ClusterReconciler) filterMachines(ctx context.Context, cluster *eggov1.
 		}
 	}
 
-	// select unique machine
+	// select machines for roles in order, machines only available for the role are preferred,
+	// and machines are spread across domains if anti-affinity of role is set
 	for _, mf := range machinesFilter {
-		if mf.filter_len >= mf.require.Number {
-			continue
+		var candidates []eggov1.Machine
+		unique := make(map[string]bool)
+		for name, m := range mf.available {
+			candidates = append(candidates, m)
+			unique[name] = machineTable[name] == mf.role
 		}
+		mf.filter = pickMachines(candidates, unique, int(mf.require.Number), mf.require.AntiAffinity, nil)
+		mf.filter_len = int32(len(mf.filter))
 
-		for m, types := range machineTable {
-			if types != mf.role {
-				continue
+		// delete machines from available machines of all roles
+		for _, m := range mf.filter {
+			for _, other := range machinesFilter {
+				delete(other.available, m.Name)
 			}
-
-			// types == mf.role && mf.filter_len < mf.require.Number
-			mf.filter = append(mf.filter, mf.available[m])
-			mf.filter_len++
-			delete(mf.available, m)
 		}
 	}
 
-	// try to select enough machines
+	errs = utils.NewMultiError(r.FailFast)
 	for _, mf := range machinesFilter {
-		if mf.filter_len >= mf.require.Number {
-			continue
-		}
-
-		for k, v := range mf.available {
-			mf.filter = append(mf.filter, v)
-			mf.filter_len++
-
-			// delete machine from available machines
-			for _, mf := range machinesFilter {
-				delete(mf.available, k)
-			}
-
-			if mf.filter_len == mf.require.Number {
+		if mf.filter_len != mf.require.Number {
+			if errs.Add(fmt.Errorf("%w: %s, require machines %d but filter %d machines", ErrInsufficientMachines, mf.name, mf.require.Number, mf.filter_len)) {
 				break
 			}
 		}
 	}
+	if err = errs.ErrorOrNil(); err != nil {
+		return
+	}
 
 	for _, mf := range machinesFilter {
-		if mf.filter_len != mf.require.Number {
-			err = fmt.Errorf("%s, require machines %d but filter %d machines, no enough machines", mf.name, mf.require.Number, mf.filter_len)
-			return
-		}
+		r.warnNotSpread(cluster, mf.name, mf.require.AntiAffinity, mf.filter)
 	}
 
 	return masterFilter.filter, workerFilter.filter, loadbalanceFilter.filter, nil
 }
 
-func (r *ClusterReconciler) prepareSecret(ctx context.Context, cluster *eggov1.Cluster) (err error) {
-	secret := v1.Secret{}
-	if cluster.Spec.MachineLoginSecret.Namespace != "" && cluster.Spec.MachineLoginSecret.Namespace != cluster.Namespace {
-		err = fmt.Errorf("secret \"%s\" namespace \"%s\" is different from cluster's \"%s\"",
-			cluster.Spec.MachineLoginSecret.Name, cluster.Spec.MachineLoginSecret.Namespace, cluster.Namespace)
-		return
+// getLoginSecret returns secret to login machines of cluster, if it is valid
+func (r *ClusterReconciler) getLoginSecret(ctx context.Context, cluster *eggov1.Cluster) (*v1.Secret, error) {
+	if cluster.Spec.MachineLoginSecret == nil {
+		return nil, fmt.Errorf("%w: no machine login secret for cluster %s", ErrMissingReference, cluster.Name)
+	}
+	secret := &v1.Secret{}
+	namespace := GetWorkspaceNamespace(cluster)
+	if cluster.Spec.MachineLoginSecret.Namespace != "" && cluster.Spec.MachineLoginSecret.Namespace != namespace {
+		return nil, fmt.Errorf("%w: secret \"%s\" namespace \"%s\" is different from workspace's \"%s\"", ErrNamespaceMismatch,
+			cluster.Spec.MachineLoginSecret.Name, cluster.Spec.MachineLoginSecret.Namespace, namespace)
 	}
 
-	err = r.Get(ctx, types.NamespacedName{Name: cluster.Spec.MachineLoginSecret.Name, Namespace: cluster.Namespace}, &secret)
+	err := r.Get(ctx, types.NamespacedName{Name: cluster.Spec.MachineLoginSecret.Name, Namespace: namespace}, secret)
 	if err != nil {
 		if client.IgnoreNotFound(err) != nil {
 			r.Log.Error(err, "get secret for cluster", "name", cluster.Name)
 		}
-		return
+		return nil, err
 	}
 
 	if secret.Type == v1.SecretTypeSSHAuth {
 		if _, ok := secret.Data[v1.SSHAuthPrivateKey]; !ok {
-			err = fmt.Errorf("invalid secret")
+			err = fmt.Errorf("%w: secret %s has no %s", ErrInvalidSecret, secret.Name, v1.SSHAuthPrivateKey)
 			r.Log.Error(err, "get secret for cluster", "name", cluster.Name)
+			return nil, err
 		}
-		return
+		return secret, nil
 	}
 
 	if secret.Type != v1.SecretTypeBasicAuth {
-		err = fmt.Errorf("secret %s type invalid", secret.Name)
+		err = fmt.Errorf("%w: secret %s type %s", ErrInvalidSecret, secret.Name, secret.Type)
 		r.Log.Error(err, "get secret for cluster", "name", cluster.Name)
-		return
+		return nil, err
 	}
 
 	// secret.Type == v1.SecretTypeBasicAuth
 	if _, ok := secret.Data[v1.BasicAuthUsernameKey]; !ok {
-		err = fmt.Errorf("invalid secret")
+		err = fmt.Errorf("%w: secret %s has no %s", ErrInvalidSecret, secret.Name, v1.BasicAuthUsernameKey)
 		r.Log.Error(err, "get secret for cluster", "name", cluster.Name)
-		return
+		return nil, err
 	}
 
 	if _, ok := secret.Data[v1.BasicAuthPasswordKey]; !ok {
-		err = fmt.Errorf("invalid secret")
+		err = fmt.Errorf("%w: secret %s has no %s", ErrInvalidSecret, secret.Name, v1.BasicAuthPasswordKey)
 		r.Log.Error(err, "get secret for cluster", "name", cluster.Name)
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+func (r *ClusterReconciler) prepareSecret(ctx context.Context, cluster *eggov1.Cluster) (err error) {
+	secret, err := r.getLoginSecret(ctx, cluster)
+	if err != nil {
 		return
 	}
 
-	cluster.Status.MachineLoginSecretRef, err = reference.GetReference(r.Scheme, &secret)
+	cluster.Status.MachineLoginSecretRef, err = reference.GetReference(r.Scheme, secret)
 	if err != nil {
 		r.Log.Error(err, "unable to reference to secret for cluster", "name", cluster.Name)
 	}
@@ -519,7 +676,7 @@ func (r *ClusterReconciler) prepareSecret(ctx context.Context, cluster *eggov1.C
 func (r *ClusterReconciler) prepareInfrastructureRef(ctx context.Context, cluster *eggov1.Cluster) (err error) {
 	infrastructure := eggov1.Infrastructure{}
 	if cluster.Spec.Infrastructure.Namespace != "" && cluster.Spec.Infrastructure.Namespace != cluster.Namespace {
-		err = fmt.Errorf("infrastructure \"%s\" namespace \"%s\" is different from cluster's \"%s\"",
+		err = fmt.Errorf("%w: infrastructure \"%s\" namespace \"%s\" is different from cluster's \"%s\"", ErrNamespaceMismatch,
 			cluster.Spec.Infrastructure.Name, cluster.Spec.Infrastructure.Namespace, cluster.Namespace)
 		return
 	}
@@ -538,36 +695,49 @@ func (r *ClusterReconciler) prepareInfrastructureRef(ctx context.Context, cluste
 	return
 }
 
-func (r *ClusterReconciler) preparePVCRef(ctx context.Context, cluster *eggov1.Cluster) (err error) {
-	infrastructure := &eggov1.Infrastructure{}
-	err = r.Get(ctx, ReferenceToNamespacedName(cluster.Status.InfrastructureRef), infrastructure)
-	if err != nil {
-		r.Log.Error(err, "get infrastructure for cluster config failed", "name", cluster.Name)
-		return
+// getPackagePVC returns bound pvc of packages in infrastructure
+func (r *ClusterReconciler) getPackagePVC(ctx context.Context, cluster *eggov1.Cluster, infrastructure *eggov1.Infrastructure) (*v1.PersistentVolumeClaim, error) {
+	if infrastructure.Spec.PackagePersistentVolumeClaim == nil {
+		return nil, fmt.Errorf("%w: no package persistentVolumeClaim in infrastructure %s", ErrMissingReference, infrastructure.Name)
 	}
-
-	pvc := v1.PersistentVolumeClaim{}
-	if infrastructure.Spec.PackagePersistentVolumeClaim.Namespace != "" && infrastructure.Spec.PackagePersistentVolumeClaim.Namespace != cluster.Namespace {
-		err = fmt.Errorf("PVC \"%s\" namespace \"%s\" is different from cluster's \"%s\"",
-			infrastructure.Spec.PackagePersistentVolumeClaim.Name, infrastructure.Spec.PackagePersistentVolumeClaim.Namespace, cluster.Namespace)
-		return
+	pvc := &v1.PersistentVolumeClaim{}
+	namespace := GetWorkspaceNamespace(cluster)
+	if infrastructure.Spec.PackagePersistentVolumeClaim.Namespace != "" && infrastructure.Spec.PackagePersistentVolumeClaim.Namespace != namespace {
+		return nil, fmt.Errorf("%w: PVC \"%s\" namespace \"%s\" is different from workspace's \"%s\"", ErrNamespaceMismatch,
+			infrastructure.Spec.PackagePersistentVolumeClaim.Name, infrastructure.Spec.PackagePersistentVolumeClaim.Namespace, namespace)
 	}
 
-	err = r.Get(ctx, types.NamespacedName{Name: infrastructure.Spec.PackagePersistentVolumeClaim.Name, Namespace: cluster.Namespace}, &pvc)
+	err := r.Get(ctx, types.NamespacedName{Name: infrastructure.Spec.PackagePersistentVolumeClaim.Name, Namespace: namespace}, pvc)
 	if err != nil {
 		if client.IgnoreNotFound(err) != nil {
 			r.Log.Error(err, "get pvc for cluster", "name", cluster.Name)
 		}
-		return
+		return nil, err
 	}
 
 	if pvc.Status.Phase != v1.ClaimBound {
-		err = fmt.Errorf("persistentVolumeClaim %s is not bound to a PersistentVolume", pvc.Name)
+		err = fmt.Errorf("%w: %s", ErrPVCNotBound, pvc.Name)
 		r.Log.Error(err, "get persistentVolumeClaim for cluster", "name", cluster.Name)
+		return nil, err
+	}
+
+	return pvc, nil
+}
+
+func (r *ClusterReconciler) preparePVCRef(ctx context.Context, cluster *eggov1.Cluster) (err error) {
+	infrastructure := &eggov1.Infrastructure{}
+	err = r.Get(ctx, ReferenceToNamespacedName(cluster.Status.InfrastructureRef), infrastructure)
+	if err != nil {
+		r.Log.Error(err, "get infrastructure for cluster config failed", "name", cluster.Name)
 		return
 	}
 
-	cluster.Status.PackagePersistentVolumeClaimRef, err = reference.GetReference(r.Scheme, &pvc)
+	pvc, err := r.getPackagePVC(ctx, cluster, infrastructure)
+	if err != nil {
+		return
+	}
+
+	cluster.Status.PackagePersistentVolumeClaimRef, err = reference.GetReference(r.Scheme, pvc)
 	if err != nil {
 		r.Log.Error(err, "unable to reference to persistent volume claim for cluster", "name", cluster.Name)
 	}
@@ -575,6 +745,31 @@ func (r *ClusterReconciler) preparePVCRef(ctx context.Context, cluster *eggov1.C
 	return
 }
 
+// validateLoginAndPackage checks secret and pvc of cluster before bind machines,
+// so machines are not reserved for cluster which can not deploy
+func (r *ClusterReconciler) validateLoginAndPackage(ctx context.Context, cluster *eggov1.Cluster) error {
+	if _, err := r.getLoginSecret(ctx, cluster); err != nil {
+		return err
+	}
+
+	if cluster.Spec.Infrastructure == nil {
+		return fmt.Errorf("%w: no infrastructure for cluster %s", ErrMissingReference, cluster.Name)
+	}
+	if cluster.Spec.Infrastructure.Namespace != "" && cluster.Spec.Infrastructure.Namespace != cluster.Namespace {
+		return fmt.Errorf("%w: infrastructure \"%s\" namespace \"%s\" is different from cluster's \"%s\"", ErrNamespaceMismatch,
+			cluster.Spec.Infrastructure.Name, cluster.Spec.Infrastructure.Namespace, cluster.Namespace)
+	}
+	infrastructure := &eggov1.Infrastructure{}
+	err := r.Get(ctx, types.NamespacedName{Name: cluster.Spec.Infrastructure.Name, Namespace: cluster.Namespace}, infrastructure)
+	if err != nil {
+		r.Log.Error(err, "get infrastructure for cluster", "name", cluster.Name)
+		return err
+	}
+
+	_, err = r.getPackagePVC(ctx, cluster, infrastructure)
+	return err
+}
+
 func (r *ClusterReconciler) prepareMachineBinding(ctx context.Context, cluster *eggov1.Cluster) error {
 	log := r.Log
 	var mb eggov1.MachineBinding
@@ -586,6 +781,13 @@ func (r *ClusterReconciler) prepareMachineBinding(ctx context.Context, cluster *
 		return err
 	}
 
+	// reserve machines before bind them, other clusters may select the same machines concurrently
+	selected := append(append(append([]eggov1.Machine{}, mMachines...), wMachines...), lMachines...)
+	if err = r.reserveMachines(ctx, cluster, selected); err != nil {
+		log.Error(err, "reserve machines")
+		return err
+	}
+
 	log.Info(fmt.Sprintf("get machines for master: %v", eggov1.PrintMachineSlice(mMachines)))
 	for _, m := range mMachines {
 		mb.AddMachine(m, eggov1.UsageMaster)
@@ -606,12 +808,17 @@ func (r *ClusterReconciler) prepareMachineBinding(ctx context.Context, cluster *
 
 	mb.SetName(fmt.Sprintf(MachineBindingFormat, cluster.Name))
 	mb.SetLabels(labels)
-	mb.SetNamespace(cluster.Namespace)
+	mb.SetNamespace(GetWorkspaceNamespace(cluster))
 
 	if err = r.Create(ctx, &mb); err != nil {
 		log.Error(err, "create machine binding for cluster", "name", cluster.Name)
 		return err
 	}
+	// machines reserved in previous failed round are not binded, release them
+	if err = r.releaseMachines(ctx, cluster, bindingMachineNames(&mb)); err != nil {
+		log.Error(err, "release machines not binded", "name", cluster.Name)
+	}
+	r.Recorder.Eventf(cluster, v1.EventTypeNormal, ReasonMachineBindingCreated, "machine binding %s created", mb.Name)
 	return nil
 }
 
@@ -649,23 +856,30 @@ func (r *ClusterReconciler) prepareEggoConfig(ctx context.Context, cluster *eggo
 
 	cm := v1.ConfigMap{}
 	cmName := fmt.Sprintf(eggov1.ClusterConfigMapNameFormat, cluster.Name, "cmd-config")
-	err = r.Get(ctx, types.NamespacedName{Name: cmName, Namespace: cluster.Namespace}, &cm)
+	err = r.Get(ctx, types.NamespacedName{Name: cmName, Namespace: GetWorkspaceNamespace(cluster)}, &cm)
 	if err != nil {
 		if client.IgnoreNotFound(err) != nil {
 			return res, err
 		}
 		cm.SetName(cmName)
-		cm.SetNamespace(cluster.Namespace)
+		cm.SetNamespace(GetWorkspaceNamespace(cluster))
 		// owner reference cause to remove configmap
-		cm.BinaryData = make(map[string][]byte)
-		cm.BinaryData[eggov1.ClusterConfigMapBinaryConfKey] = data
-		return ctrl.Result{RequeueAfter: time.Second * 2}, r.Create(ctx, &cm)
+		specHash, err := deployedSpecHash(&cluster.Spec)
+		if err != nil {
+			return res, err
+		}
+		setEggoConfigData(&cm, data, specHash)
+		if err = r.Create(ctx, &cm); err != nil {
+			return r.requeueNotReady(cluster), err
+		}
+		return r.requeueProgress(cluster), nil
 	}
 	cluster.Status.ConfigRef, err = reference.GetReference(r.Scheme, &cm)
 	if err != nil {
-		return ctrl.Result{RequeueAfter: time.Second * 2}, err
+		return r.requeueNotReady(cluster), err
 	}
 	r.Log.Info("save cluster config into configmap success", "name", cluster.Name)
+	r.Recorder.Eventf(cluster, v1.EventTypeNormal, ReasonConfigGenerated, "eggo config saved into configmap %s", cm.Name)
 	return res, nil
 }
 
@@ -748,6 +962,73 @@ func addPrivateKeySecret(machineLoginSecret, mountPath string, job *batch.Job) {
 		})
 }
 
+// prepareEggoHomePVC creates pvc of cluster if not exist, it keeps config and certificates of
+// cluster for all jobs of cluster, wherever they run, and is deleted with cluster
+func (r *ClusterReconciler) prepareEggoHomePVC(ctx context.Context, cluster *eggov1.Cluster) (string, error) {
+	pvc := &v1.PersistentVolumeClaim{}
+	name := types.NamespacedName{Name: fmt.Sprintf(eggov1.EggoHomeVolumeClaimFormat, cluster.Name), Namespace: GetWorkspaceNamespace(cluster)}
+	err := r.Get(ctx, name, pvc)
+	if err == nil || client.IgnoreNotFound(err) != nil {
+		return name.Name, err
+	}
+
+	var storageClassName *string
+	if cluster.Status.InfrastructureRef != nil {
+		infrastructure := &eggov1.Infrastructure{}
+		if err = r.Get(ctx, ReferenceToNamespacedName(cluster.Status.InfrastructureRef), infrastructure); err != nil {
+			return "", err
+		}
+		storageClassName = infrastructure.Spec.EggoHomeStorageClassName
+	}
+	pvc.Name, pvc.Namespace = name.Name, name.Namespace
+	pvc.Spec = v1.PersistentVolumeClaimSpec{
+		AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+		StorageClassName: storageClassName,
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(eggov1.DefaultEggoHomeStorageRequest)},
+		},
+	}
+	if err = r.Create(ctx, pvc); err != nil {
+		return "", err
+	}
+	r.Log.Info("create eggo home pvc for cluster", "name", cluster.Name, "pvc", pvc.Name)
+	return pvc.Name, nil
+}
+
+// deleteEggoHomePVC returns true if pvc of cluster is removed
+func (r *ClusterReconciler) deleteEggoHomePVC(ctx context.Context, cluster *eggov1.Cluster) (bool, error) {
+	pvc := &v1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf(eggov1.EggoHomeVolumeClaimFormat, cluster.Name), Namespace: GetWorkspaceNamespace(cluster)}, pvc)
+	if err != nil {
+		return client.IgnoreNotFound(err) == nil, client.IgnoreNotFound(err)
+	}
+	// pvc is removed after pods of jobs using it are removed
+	if pvc.DeletionTimestamp.IsZero() {
+		if err = r.Delete(ctx, pvc); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+	}
+	return false, nil
+}
+
+func addEggoHomeVolume(pvcName string, job *batch.Job) {
+	job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes,
+		v1.Volume{
+			Name: "eggo-home",
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+					ClaimName: pvcName,
+				},
+			},
+		})
+
+	job.Spec.Template.Spec.Containers[0].VolumeMounts = append(job.Spec.Template.Spec.Containers[0].VolumeMounts,
+		v1.VolumeMount{
+			Name:      "eggo-home",
+			MountPath: eggoHomePath,
+		})
+}
+
 func fillEggoJobConfig(r *ClusterReconciler, ctx context.Context, cluster *eggov1.Cluster, job *batch.Job) (err error) {
 	// ssh privatekey
 	secret := v1.Secret{}
@@ -762,10 +1043,51 @@ func fillEggoJobConfig(r *ClusterReconciler, ctx context.Context, cluster *eggov
 		addPrivateKeySecret(secret.Name, fmt.Sprintf(eggov1.PrivateKeyVolumeFormat, cluster.Name), job)
 	}
 
+	// config and certificates of cluster are kept in pvc of cluster, not on host running the job
+	homePVC, err := r.prepareEggoHomePVC(ctx, cluster)
+	if err != nil {
+		r.Log.Error(err, "prepare eggo home pvc for cluster", "name", cluster.Name)
+		return err
+	}
+	addEggoHomeVolume(homePVC, job)
+
+	// secrets to pull eggo image, pod can not start if they are not found
+	for _, ps := range cluster.Spec.ImagePullSecrets {
+		pullSecret := v1.Secret{}
+		if err = r.Get(ctx, types.NamespacedName{Name: ps.Name, Namespace: job.Namespace}, &pullSecret); err != nil {
+			return fmt.Errorf("get image pull secret %s in namespace %s failed: %v", ps.Name, job.Namespace, err)
+		}
+	}
+	if len(cluster.Spec.ImagePullSecrets) != 0 {
+		job.Spec.Template.Spec.ImagePullSecrets = cluster.Spec.ImagePullSecrets
+	}
+
 	// eggo pod affinity
 	if cluster.Spec.EggoAffinity != nil {
 		job.Spec.Template.Spec.Affinity = cluster.Spec.EggoAffinity
 	}
+	// eggo pod node selector and tolerations, to run on control nodes
+	if len(cluster.Spec.EggoNodeSelector) != 0 {
+		job.Spec.Template.Spec.NodeSelector = cluster.Spec.EggoNodeSelector
+	}
+	if len(cluster.Spec.EggoTolerations) != 0 {
+		job.Spec.Template.Spec.Tolerations = cluster.Spec.EggoTolerations
+	}
+
+	// keep eggo pod from being evicted on constrained cluster
+	job.Spec.Template.Spec.Containers[0].Resources = GetJobResources(cluster)
+
+	// cluster deployed in process saves its eggo home in secret, instead of pvc of cluster
+	if err = r.addEggoHomeSeed(ctx, cluster, job); err != nil {
+		r.Log.Error(err, "seed eggo home of job", "name", cluster.Name)
+		return err
+	}
+
+	// retry eggo pod when transient failure
+	backoffLimit := GetJobBackoffLimit(cluster)
+	activeDeadlineSeconds := GetJobActiveDeadlineSeconds(cluster)
+	job.Spec.BackoffLimit = &backoffLimit
+	job.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
 
 	return
 }
@@ -774,7 +1096,7 @@ func (r *ClusterReconciler) prepareCreateClusterJob(ctx context.Context, cluster
 	cmName := fmt.Sprintf(eggov1.ClusterConfigMapNameFormat, cluster.Name, "cmd-config")
 	job := &batch.Job{}
 	jobName := fmt.Sprintf("%s-create-job", cluster.Name)
-	err := r.Get(ctx, types.NamespacedName{Name: jobName, Namespace: cluster.Namespace}, job)
+	err := r.Get(ctx, types.NamespacedName{Name: jobName, Namespace: GetWorkspaceNamespace(cluster)}, job)
 	if err == nil {
 		cluster.Status.JobRef, err = reference.GetReference(r.Scheme, job)
 		if err != nil {
@@ -796,7 +1118,7 @@ func (r *ClusterReconciler) prepareCreateClusterJob(ctx context.Context, cluster
 
 	configPath := fmt.Sprintf(eggov1.EggoConfigVolumeFormat, cluster.Name)
 	Command := []string{"eggo", "-d", "deploy", "-f", filepath.Join(configPath, eggov1.ClusterConfigMapBinaryConfKey)}
-	job = createEggoJobConfig(cluster.Namespace, jobName, "eggo-create-cluster", GetEggoImageVersion(cluster), configPath, cmName,
+	job = createEggoJobConfig(GetWorkspaceNamespace(cluster), jobName, "eggo-create-cluster", GetEggoImage(cluster), configPath, cmName,
 		fmt.Sprintf(eggov1.PackageVolumeFormat, cluster.Name), packagePVC.Name, Command)
 
 	err = fillEggoJobConfig(r, ctx, cluster, job)
@@ -809,6 +1131,7 @@ func (r *ClusterReconciler) prepareCreateClusterJob(ctx context.Context, cluster
 	if err != nil {
 		return err
 	}
+	r.Recorder.Eventf(cluster, v1.EventTypeNormal, ReasonJobStarted, "job %s to create cluster started", jobName)
 
 	return nil
 }
@@ -820,7 +1143,7 @@ func jobIsFinished(job *batch.Job) (bool, error) {
 				return true, nil
 			}
 			if c.Type == batch.JobFailed {
-				return true, fmt.Errorf("job: %s failed: %s", job.GetName(), c.Reason)
+				return true, fmt.Errorf("job: %s failed: %s, %s", job.GetName(), c.Reason, c.Message)
 			}
 		}
 	}
@@ -838,6 +1161,11 @@ func (r *ClusterReconciler) checkAndLogClusterJob(ctx context.Context, cluster *
 	var finish bool
 	finish, err = jobIsFinished(job)
 	if !finish {
+		// failed pods will be retried until backoff limit or deadline exceeded
+		if job.Status.Failed > 0 {
+			cluster.Status.Message = fmt.Sprintf("create cluster job retrying, failed %d times", job.Status.Failed)
+			r.Log.Info(cluster.Status.Message, "name", cluster.Name)
+		}
 		// just requeue to wait job finish
 		return finish, err
 	}
@@ -849,6 +1177,8 @@ func (r *ClusterReconciler) checkAndLogClusterJob(ctx context.Context, cluster *
 	}
 	if err != nil {
 		r.Log.Error(err, "create cluster job failed, remove job...")
+		r.Recorder.Event(cluster, v1.EventTypeWarning, ReasonJobFailed, err.Error())
+		history.Logs = r.getJobLogs(ctx, job)
 		background := metav1.DeletePropagationBackground
 		if terr := r.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &background}); terr != nil {
 			r.Log.Error(err, "delete create cluster job failed")
@@ -857,7 +1187,7 @@ func (r *ClusterReconciler) checkAndLogClusterJob(ctx context.Context, cluster *
 		r.Log.Info("delete old create cluster job success")
 
 		history.Message = err.Error()
-		cluster.Status.JobHistorys = append(cluster.Status.JobHistorys, history)
+		r.addJobHistory(cluster, history)
 		// clear ref of failed job
 		cluster.Status.JobRef = nil
 	}
@@ -882,24 +1212,31 @@ func (r *ClusterReconciler) reconcileCreate(ctx context.Context, cluster *eggov1
 	// Step 1: get free machines which match feature of cluster required
 	if cluster.Status.MachineBindingRef == nil {
 		var mb eggov1.MachineBinding
-		err = r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf(MachineBindingFormat, cluster.Name), Namespace: cluster.Namespace}, &mb)
+		err = r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf(MachineBindingFormat, cluster.Name), Namespace: GetWorkspaceNamespace(cluster)}, &mb)
 		if err != nil {
 			if client.IgnoreNotFound(err) != nil {
 				r.Log.Error(err, "get machine binding for cluster", "name", cluster.Name)
 				return
 			}
+			// do not bind machines if cluster can not deploy
+			if err = r.validateLoginAndPackage(ctx, cluster); err != nil {
+				r.Log.Error(err, "validate secret and pvc for cluster", "name", cluster.Name)
+				return r.requeueOnError(cluster, err)
+			}
 			err = r.prepareMachineBinding(ctx, cluster)
 			if err != nil {
 				r.Log.Error(err, "prepare machine binding for cluster", "name", cluster.Name)
+				return r.requeueOnError(cluster, err)
 			}
 			// requeue to wait machine binding success
-			return ctrl.Result{RequeueAfter: time.Second * 2}, err
+			return r.requeueProgress(cluster), nil
 		}
 
 		cluster.Status.MachineBindingRef, err = reference.GetReference(r.Scheme, &mb)
 		if err != nil {
 			r.Log.Error(err, "unable to reference to machine binding for cluster", "name", cluster.Name)
 		}
+		cluster.Status.Nodes = int32(len(mb.Spec.Usages))
 		return
 	}
 
@@ -907,7 +1244,7 @@ func (r *ClusterReconciler) reconcileCreate(ctx context.Context, cluster *eggov1
 	if cluster.Status.MachineLoginSecretRef == nil {
 		err = r.prepareSecret(ctx, cluster)
 		if err != nil {
-			res = ctrl.Result{RequeueAfter: time.Second * 30}
+			res = r.requeueNotReady(cluster)
 		}
 		return
 	}
@@ -916,16 +1253,15 @@ func (r *ClusterReconciler) reconcileCreate(ctx context.Context, cluster *eggov1
 	if cluster.Status.InfrastructureRef == nil {
 		err = r.prepareInfrastructureRef(ctx, cluster)
 		if err != nil {
-			res = ctrl.Result{RequeueAfter: time.Second * 30}
+			res = r.requeueNotReady(cluster)
 		}
 		return
 	}
 
 	// Step 4: get persistentVolumeClaimRef
 	if cluster.Status.PackagePersistentVolumeClaimRef == nil {
-		err = r.preparePVCRef(ctx, cluster)
-		if err != nil {
-			res = ctrl.Result{RequeueAfter: time.Second * 30}
+		if err = r.preparePVCRef(ctx, cluster); err != nil {
+			return r.requeueOnError(cluster, err)
 		}
 		return
 	}
@@ -935,34 +1271,57 @@ func (r *ClusterReconciler) reconcileCreate(ctx context.Context, cluster *eggov1
 		return r.prepareEggoConfig(ctx, cluster)
 	}
 
-	// Step 6: create job to create cluster
+	// Step 6: create job to create cluster, or deploy cluster in process
+	if r.InProcessDeploy {
+		finish, err := r.deployClusterInProcess(ctx, cluster)
+		if err != nil {
+			r.Log.Error(err, "deploy cluster in process", "name", cluster.Name)
+			return r.requeueNotReady(cluster), err
+		}
+		if !finish {
+			// deploy runs in background, check it later
+			return r.requeueNotReady(cluster), nil
+		}
+		// cluster is deployed, do not deploy again even if update status of machine binding failed
+		cluster.Status.HasCluster = true
+		return r.finishCreate(ctx, cluster, "deploy cluster in process successfully")
+	}
 	if cluster.Status.JobRef == nil {
 		// create job
 		err = r.prepareCreateClusterJob(ctx, cluster)
 		if err != nil {
 			r.Log.Error(err, "prepare job to create cluster", "name", cluster.Name)
+			return r.requeueNotReady(cluster), err
 		}
 		// requeue after prepare job
-		return ctrl.Result{RequeueAfter: time.Second * 2}, err
+		return r.requeueProgress(cluster), nil
 	}
 
 	// Step 7: wait job success
 	finish, err := r.checkAndLogClusterJob(ctx, cluster)
 	if !finish || err != nil {
-		return ctrl.Result{RequeueAfter: time.Second * 5}, err
+		return r.requeueNotReady(cluster), err
 	}
 
-	// Step 8: update status of resources, cluster and machinebinding
-	// TODO: update other status
-	err = r.updateMachineBindingStatus(ctx, cluster)
-	if err != nil {
+	if res, err = r.finishCreate(ctx, cluster, "create cluster job successfully"); err != nil {
 		return
 	}
+	r.Recorder.Eventf(cluster, v1.EventTypeNormal, ReasonJobSucceeded, "job %s to create cluster succeeded", cluster.Status.JobRef.Name)
+	return
+}
+
+// Step 8: update status of resources, cluster and machinebinding
+func (r *ClusterReconciler) finishCreate(ctx context.Context, cluster *eggov1.Cluster, message string) (ctrl.Result, error) {
+	// TODO: update other status
+	if err := r.updateMachineBindingStatus(ctx, cluster); err != nil {
+		return ctrl.Result{}, err
+	}
 	cluster.Status.HasCluster = true
-	cluster.Status.Message = "create cluster job successfully"
+	cluster.Status.Message = message
+	r.forgetBackoff(cluster)
 
 	r.Log.Info("create new cluster success", "name", cluster.Name)
-	return
+	return ctrl.Result{}, nil
 }
 
 func foundString(list []string, target string) bool {
@@ -974,7 +1333,29 @@ func foundString(list []string, target string) bool {
 	return false
 }
 
-func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *eggov1.Cluster) (res ctrl.Result, err error) {
+// computeClusterPhase maps steps of cluster to phase
+func computeClusterPhase(cluster *eggov1.Cluster) eggov1.ClusterPhase {
+	status := cluster.Status
+	switch {
+	case !cluster.ObjectMeta.DeletionTimestamp.IsZero():
+		return eggov1.ClusterPhaseDeleting
+	case status.HasCluster:
+		return eggov1.ClusterPhaseRunning
+	case status.MachineBindingRef == nil:
+		return eggov1.ClusterPhasePending
+	case status.MachineLoginSecretRef == nil || status.InfrastructureRef == nil || status.PackagePersistentVolumeClaimRef == nil:
+		return eggov1.ClusterPhaseBinding
+	case status.ConfigRef == nil:
+		return eggov1.ClusterPhaseConfiguring
+	case status.JobRef == nil && len(status.JobHistorys) != 0 && !status.JobHistorys[len(status.JobHistorys)-1].Succeeded:
+		// ref of failed job is cleared, and job will be created again
+		return eggov1.ClusterPhaseFailed
+	default:
+		return eggov1.ClusterPhaseDeploying
+	}
+}
+
+func (r *ClusterReconciler) reconcile(ctx context.Context, cluster, base *eggov1.Cluster) (res ctrl.Result, err error) {
 	log := r.Log
 	res = ctrl.Result{}
 
@@ -983,12 +1364,15 @@ func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *eggov1.Clust
 		res, err = r.reconcileCreate(ctx, cluster)
 		if err != nil {
 			log.Error(err, "unable to create cluster")
-			return
 		}
 
-		// TODO: when need requeue
-		if err = r.Status().Update(ctx, cluster); err != nil {
-			log.Error(err, "unable to update cluster status", "name", cluster.Name)
+		// save status even if failed, so phase and history of failed job are kept
+		cluster.Status.Phase = computeClusterPhase(cluster)
+		if perr := r.Status().Patch(ctx, cluster, client.MergeFrom(base)); perr != nil {
+			log.Error(perr, "unable to update cluster status", "name", cluster.Name)
+			if err == nil {
+				err = perr
+			}
 			return
 		}
 		log.Info("update cluster status success", "name", cluster.Name)
@@ -996,15 +1380,41 @@ func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *eggov1.Clust
 		return
 	}
 
-	// TODO: finish join, cleanup node and update cluster
-	log.Info("call eggo job to join/cleanup node from cluster", "name", cluster.Name)
+	// join or cleanup nodes to match machines required by cluster
+	res, err = r.reconcileMembership(ctx, cluster)
+	if err != nil {
+		log.Error(err, "unable to reconcile nodes of cluster", "name", cluster.Name)
+		return
+	}
+	cluster.Status.Phase = computeClusterPhase(cluster)
+	if err = r.Status().Patch(ctx, cluster, client.MergeFrom(base)); err != nil {
+		log.Error(err, "unable to update cluster status", "name", cluster.Name)
+		return
+	}
 
 	return res, nil
 }
 
+// clustersInNamespace maps machine to clusters in its namespace, which may select or bind it
+func (r *ClusterReconciler) clustersInNamespace(obj client.Object) []reconcile.Request {
+	var cList eggov1.ClusterList
+	if err := r.List(context.Background(), &cList, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "list clusters for machine", "name", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, c := range cList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: c.Name, Namespace: c.Namespace}})
+	}
+	return requests
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&eggov1.Cluster{}).
+		// spec of machine changed, such as maintenance, status updated by probe is ignored
+		Watches(&source.Kind{Type: &eggov1.Machine{}}, handler.EnqueueRequestsFromMapFunc(r.clustersInNamespace),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Complete(r)
 }
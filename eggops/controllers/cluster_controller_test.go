@@ -0,0 +1,636 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+func newTestReconciler(t *testing.T, objs ...client.Object) *ClusterReconciler {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("add client-go scheme failed: %v", err)
+	}
+	if err := eggov1.AddToScheme(scheme); err != nil {
+		t.Fatalf("add eggo scheme failed: %v", err)
+	}
+	return &ClusterReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Log:      logf.Log.WithName("test"),
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(100),
+	}
+}
+
+func TestBadSecretPreventsMachineBinding(t *testing.T) {
+	ns := "default"
+	port := int32(22)
+	machine := &eggov1.Machine{}
+	machine.Name, machine.Namespace = "machine0", ns
+	machine.Spec = eggov1.MachineSpec{HostName: "machine0", IP: "192.168.0.1", Port: &port, Arch: "amd64"}
+
+	pvc := &v1.PersistentVolumeClaim{}
+	pvc.Name, pvc.Namespace = "packages", ns
+	pvc.Status.Phase = v1.ClaimBound
+	infra := &eggov1.Infrastructure{}
+	infra.Name, infra.Namespace = "infra", ns
+	infra.Spec.PackagePersistentVolumeClaim = &v1.ObjectReference{Name: pvc.Name}
+
+	newCluster := func() *eggov1.Cluster {
+		cluster := &eggov1.Cluster{}
+		cluster.Name, cluster.Namespace = "test-cluster", ns
+		cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 1}
+		cluster.Spec.MachineLoginSecret = &v1.ObjectReference{Name: "login"}
+		cluster.Spec.Infrastructure = &v1.ObjectReference{Name: infra.Name}
+		return cluster
+	}
+	mbName := types.NamespacedName{Name: fmt.Sprintf(MachineBindingFormat, "test-cluster"), Namespace: ns}
+
+	badSecrets := []*v1.Secret{
+		{Type: v1.SecretTypeOpaque},
+		{Type: v1.SecretTypeBasicAuth, Data: map[string][]byte{v1.BasicAuthUsernameKey: []byte("root")}},
+		{Type: v1.SecretTypeSSHAuth},
+	}
+	for _, secret := range badSecrets {
+		secret.Name, secret.Namespace = "login", ns
+		r := newTestReconciler(t, machine, pvc, infra, secret)
+		cluster := newCluster()
+		if _, err := r.reconcileCreate(context.Background(), cluster); err == nil {
+			t.Fatalf("expect reconcile failed with bad secret of type %s", secret.Type)
+		}
+		mb := &eggov1.MachineBinding{}
+		if err := r.Get(context.Background(), mbName, mb); client.IgnoreNotFound(err) != nil || err == nil {
+			t.Fatalf("machine binding should not be created with bad secret of type %s, err: %v", secret.Type, err)
+		}
+	}
+
+	secret := &v1.Secret{
+		Type: v1.SecretTypeBasicAuth,
+		Data: map[string][]byte{v1.BasicAuthUsernameKey: []byte("root"), v1.BasicAuthPasswordKey: []byte("123456")},
+	}
+	secret.Name, secret.Namespace = "login", ns
+
+	// pvc is not bound, wait for it without error
+	unboundPVC := pvc.DeepCopy()
+	unboundPVC.Status.Phase = v1.ClaimPending
+	r := newTestReconciler(t, machine, unboundPVC, infra, secret)
+	if res, err := r.reconcileCreate(context.Background(), newCluster()); err != nil || res.RequeueAfter == 0 {
+		t.Fatalf("expect requeue to wait unbound pvc, get: %v, %v", res, err)
+	}
+	if err := r.Get(context.Background(), mbName, &eggov1.MachineBinding{}); err == nil {
+		t.Fatalf("machine binding should not be created with unbound pvc")
+	}
+
+	r = newTestReconciler(t, machine, pvc, infra, secret)
+	cluster := newCluster()
+	if _, err := r.reconcileCreate(context.Background(), cluster); err != nil {
+		t.Fatalf("reconcile with valid secret and pvc failed: %v", err)
+	}
+	mb := &eggov1.MachineBinding{}
+	if err := r.Get(context.Background(), mbName, mb); err != nil {
+		t.Fatalf("expect machine binding created: %v", err)
+	}
+	if cluster.Status.MachineLoginSecretRef != nil || cluster.Status.PackagePersistentVolumeClaimRef != nil {
+		t.Fatalf("refs of secret and pvc should be set in later steps")
+	}
+}
+
+func TestSelectMachinesByNames(t *testing.T) {
+	ns := "default"
+	var objs []client.Object
+	for i, zone := range []string{"a", "a", "b"} {
+		m := &eggov1.Machine{}
+		m.Name, m.Namespace = fmt.Sprintf("machine%d", i), ns
+		m.Labels = map[string]string{"zone": zone}
+		objs = append(objs, m)
+	}
+	used := &eggov1.MachineBinding{}
+	used.Name, used.Namespace = "used", ns
+	used.AddMachine(*objs[2].(*eggov1.Machine), eggov1.UsageWorker)
+	r := newTestReconciler(t, append(objs, used)...)
+	binded, err := r.bindedSelectMachines(context.Background(), ns)
+	if err != nil {
+		t.Fatalf("select binded machines failed: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		require eggov1.RequireMachineConfig
+		expect  []string
+	}{
+		{"pinned", eggov1.RequireMachineConfig{Number: 1, MachineNames: []string{"machine1"}}, []string{"machine1"}},
+		{"pinned with features", eggov1.RequireMachineConfig{Number: 2, Features: map[string]string{"zone": "a"},
+			MachineNames: []string{"machine0", "machine1"}}, []string{"machine0", "machine1"}},
+		{"not match features", eggov1.RequireMachineConfig{Number: 1, Features: map[string]string{"zone": "b"},
+			MachineNames: []string{"machine0"}}, nil},
+		{"not found", eggov1.RequireMachineConfig{Number: 1, MachineNames: []string{"machine9"}}, nil},
+		{"already in use", eggov1.RequireMachineConfig{Number: 1, MachineNames: []string{"machine2"}}, nil},
+		{"not enough", eggov1.RequireMachineConfig{Number: 2, MachineNames: []string{"machine0"}}, nil},
+	}
+	for _, c := range cases {
+		machines, err := r.availableSelectMachines(context.Background(), ns, c.require, binded)
+		if c.expect == nil {
+			if err == nil {
+				t.Fatalf("case %s: expect select failed, get: %v", c.name, machines)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %s: select machines failed: %v", c.name, err)
+		}
+		if len(machines) != len(c.expect) {
+			t.Fatalf("case %s: expect %v, get: %v", c.name, c.expect, machines)
+		}
+		for _, name := range c.expect {
+			if _, ok := machines[name]; !ok {
+				t.Fatalf("case %s: expect %v, get: %v", c.name, c.expect, machines)
+			}
+		}
+	}
+}
+
+func TestClusterCreateEvents(t *testing.T) {
+	ns := "default"
+	port := int32(22)
+	machine := &eggov1.Machine{}
+	machine.Name, machine.Namespace = "machine0", ns
+	machine.Spec = eggov1.MachineSpec{HostName: "machine0", IP: "192.168.0.1", Port: &port, Arch: "amd64"}
+	pvc := &v1.PersistentVolumeClaim{}
+	pvc.Name, pvc.Namespace = "packages", ns
+	pvc.Status.Phase = v1.ClaimBound
+	infra := &eggov1.Infrastructure{}
+	infra.Name, infra.Namespace = "infra", ns
+	infra.Spec.PackagePersistentVolumeClaim = &v1.ObjectReference{Name: pvc.Name}
+	secret := &v1.Secret{
+		Type: v1.SecretTypeBasicAuth,
+		Data: map[string][]byte{v1.BasicAuthUsernameKey: []byte("root"), v1.BasicAuthPasswordKey: []byte("123456")},
+	}
+	secret.Name, secret.Namespace = "login", ns
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", ns
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 1}
+	cluster.Spec.MachineLoginSecret = &v1.ObjectReference{Name: secret.Name}
+	cluster.Spec.Infrastructure = &v1.ObjectReference{Name: infra.Name}
+
+	r := newTestReconciler(t, machine, pvc, infra, secret)
+	ctx := context.Background()
+	jobName := types.NamespacedName{Name: "test-cluster-create-job", Namespace: ns}
+	job := &batch.Job{}
+	for i := 0; i < 20 && !cluster.Status.HasCluster; i++ {
+		if _, err := r.reconcileCreate(ctx, cluster); err != nil {
+			t.Fatalf("reconcile create cluster failed: %v", err)
+		}
+		if cluster.Status.JobRef == nil {
+			continue
+		}
+		// make job of cluster succeeded
+		if err := r.Get(ctx, jobName, job); err != nil {
+			t.Fatalf("get create job failed: %v", err)
+		}
+		if len(job.Status.Conditions) == 0 {
+			job.Status.Conditions = []batch.JobCondition{{Type: batch.JobComplete, Status: v1.ConditionTrue}}
+			if err := r.Status().Update(ctx, job); err != nil {
+				t.Fatalf("update status of job failed: %v", err)
+			}
+		}
+	}
+	if !cluster.Status.HasCluster {
+		t.Fatalf("expect cluster created, status: %+v", cluster.Status)
+	}
+
+	recorder := r.Recorder.(*record.FakeRecorder)
+	close(recorder.Events)
+	var events []string
+	for e := range recorder.Events {
+		events = append(events, e)
+	}
+	expects := []string{
+		"Normal " + ReasonMachineBindingCreated + " machine binding machinebind-test-cluster created",
+		"Normal " + ReasonConfigGenerated + " eggo config saved into configmap",
+		"Normal " + ReasonJobStarted + " job test-cluster-create-job to create cluster started",
+		"Normal " + ReasonJobSucceeded + " job test-cluster-create-job to create cluster succeeded",
+	}
+	if len(events) != len(expects) {
+		t.Fatalf("expect events: %v, get: %v", expects, events)
+	}
+	for i, e := range expects {
+		if !strings.HasPrefix(events[i], e) {
+			t.Fatalf("expect event: %s, get: %s", e, events[i])
+		}
+	}
+}
+
+// raceClient modifies cluster by other writer, after reconciler gets it
+type raceClient struct {
+	client.Client
+	raced bool
+}
+
+func (c *raceClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if err := c.Client.Get(ctx, key, obj); err != nil {
+		return err
+	}
+	if _, ok := obj.(*eggov1.Cluster); !ok || c.raced {
+		return nil
+	}
+	c.raced = true
+	other := &eggov1.Cluster{}
+	if err := c.Client.Get(ctx, key, other); err != nil {
+		return err
+	}
+	other.Labels = map[string]string{"modified-by": "other"}
+	return c.Client.Update(ctx, other)
+}
+
+func TestPatchClusterWithConcurrentModification(t *testing.T) {
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", "default"
+	r := newTestReconciler(t, cluster)
+	rc := &raceClient{Client: r.Client}
+	r.Client = rc
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}}
+
+	// add finalizer
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile with concurrent modification failed: %v", err)
+	}
+	got := &eggov1.Cluster{}
+	if err := rc.Client.Get(ctx, req.NamespacedName, got); err != nil {
+		t.Fatalf("get cluster failed: %v", err)
+	}
+	if !rc.raced || got.Labels["modified-by"] != "other" || !foundString(got.Finalizers, ClusterFinalizerName) {
+		t.Fatalf("expect both changes are kept, get labels: %v, finalizers: %v", got.Labels, got.Finalizers)
+	}
+
+	// remove finalizer after cluster deleted
+	now := metav1.Now()
+	got.DeletionTimestamp = &now
+	if err := rc.Client.Update(ctx, got); err != nil {
+		t.Fatalf("update cluster failed: %v", err)
+	}
+	rc.raced = false
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile deleting cluster failed: %v", err)
+	}
+	if err := rc.Client.Get(ctx, req.NamespacedName, got); err != nil {
+		t.Fatalf("get cluster failed: %v", err)
+	}
+	if foundString(got.Finalizers, ClusterFinalizerName) || !got.Status.Deleted || got.Labels["modified-by"] != "other" {
+		t.Fatalf("expect finalizer removed, get finalizers: %v, status: %+v", got.Finalizers, got.Status)
+	}
+}
+
+func TestClusterWorkspaceNamespace(t *testing.T) {
+	ns, workspace := "default", "team-a"
+	port := int32(22)
+	machine := &eggov1.Machine{}
+	machine.Name, machine.Namespace = "machine0", ns
+	machine.Spec = eggov1.MachineSpec{HostName: "machine0", IP: "192.168.0.1", Port: &port, Arch: "amd64"}
+	pvc := &v1.PersistentVolumeClaim{}
+	pvc.Name, pvc.Namespace = "packages", workspace
+	pvc.Status.Phase = v1.ClaimBound
+	infra := &eggov1.Infrastructure{}
+	infra.Name, infra.Namespace = "infra", ns
+	infra.Spec.PackagePersistentVolumeClaim = &v1.ObjectReference{Name: pvc.Name}
+	secret := &v1.Secret{
+		Type: v1.SecretTypeBasicAuth,
+		Data: map[string][]byte{v1.BasicAuthUsernameKey: []byte("root"), v1.BasicAuthPasswordKey: []byte("123456")},
+	}
+	secret.Name, secret.Namespace = "login", workspace
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", ns
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 1}
+	cluster.Spec.MachineLoginSecret = &v1.ObjectReference{Name: secret.Name}
+	cluster.Spec.Infrastructure = &v1.ObjectReference{Name: infra.Name}
+	cluster.Spec.WorkspaceNamespace = workspace
+
+	r := newTestReconciler(t, cluster, machine, pvc, infra, secret)
+	ctx := context.Background()
+	createJob := types.NamespacedName{Name: "test-cluster-create-job", Namespace: workspace}
+	for i := 0; i < 20 && !cluster.Status.HasCluster; i++ {
+		if _, err := r.reconcileCreate(ctx, cluster); err != nil {
+			t.Fatalf("reconcile create cluster failed: %v", err)
+		}
+		if cluster.Status.JobRef == nil {
+			continue
+		}
+		job := &batch.Job{}
+		if err := r.Get(ctx, createJob, job); err != nil {
+			t.Fatalf("get create job in workspace failed: %v", err)
+		}
+		if len(job.Status.Conditions) == 0 {
+			job.Status.Conditions = []batch.JobCondition{{Type: batch.JobComplete, Status: v1.ConditionTrue}}
+			if err := r.Status().Update(ctx, job); err != nil {
+				t.Fatalf("update status of job failed: %v", err)
+			}
+		}
+	}
+	if !cluster.Status.HasCluster {
+		t.Fatalf("expect cluster created, status: %+v", cluster.Status)
+	}
+	refs := []*v1.ObjectReference{cluster.Status.MachineBindingRef, cluster.Status.ConfigRef, cluster.Status.JobRef,
+		cluster.Status.MachineLoginSecretRef, cluster.Status.PackagePersistentVolumeClaimRef}
+	for _, ref := range refs {
+		if ref.Namespace != workspace {
+			t.Fatalf("expect %s %s in workspace namespace, get: %s", ref.Kind, ref.Name, ref.Namespace)
+		}
+	}
+	if cluster.Status.InfrastructureRef.Namespace != ns {
+		t.Fatalf("expect infrastructure in namespace of cluster, get: %s", cluster.Status.InfrastructureRef.Namespace)
+	}
+
+	// machine binded in workspace is not available for other clusters
+	binded, err := r.bindedSelectMachines(ctx, ns)
+	if err != nil || !binded[machine.Name] {
+		t.Fatalf("expect machine binded, get: %v, err: %v", binded, err)
+	}
+
+	// delete path removes create job and runs delete job in workspace
+	stored := &eggov1.Cluster{}
+	if err = r.Get(ctx, types.NamespacedName{Name: cluster.Name, Namespace: ns}, stored); err != nil {
+		t.Fatalf("get cluster failed: %v", err)
+	}
+	stored.Status = cluster.Status
+	if err = r.Status().Update(ctx, stored); err != nil {
+		t.Fatalf("update status of cluster failed: %v", err)
+	}
+	cluster, base := stored, stored.DeepCopy()
+	for i := 0; i < 2; i++ {
+		if _, err := r.reconcileDelete(ctx, cluster, base); err != nil {
+			t.Fatalf("reconcile delete cluster failed: %v", err)
+		}
+	}
+	if err := r.Get(ctx, createJob, &batch.Job{}); client.IgnoreNotFound(err) != nil || err == nil {
+		t.Fatalf("expect create job removed, err: %v", err)
+	}
+	deleteJob := types.NamespacedName{Name: "test-cluster-delete-job", Namespace: workspace}
+	if err := r.Get(ctx, deleteJob, &batch.Job{}); err != nil {
+		t.Fatalf("expect delete job in workspace: %v", err)
+	}
+}
+
+func TestClusterPhaseTransitions(t *testing.T) {
+	ns := "default"
+	port := int32(22)
+	machine := &eggov1.Machine{}
+	machine.Name, machine.Namespace = "machine0", ns
+	machine.Spec = eggov1.MachineSpec{HostName: "machine0", IP: "192.168.0.1", Port: &port, Arch: "amd64"}
+	pvc := &v1.PersistentVolumeClaim{}
+	pvc.Name, pvc.Namespace = "packages", ns
+	pvc.Status.Phase = v1.ClaimBound
+	infra := &eggov1.Infrastructure{}
+	infra.Name, infra.Namespace = "infra", ns
+	infra.Spec.PackagePersistentVolumeClaim = &v1.ObjectReference{Name: pvc.Name}
+	secret := &v1.Secret{
+		Type: v1.SecretTypeBasicAuth,
+		Data: map[string][]byte{v1.BasicAuthUsernameKey: []byte("root"), v1.BasicAuthPasswordKey: []byte("123456")},
+	}
+	secret.Name, secret.Namespace = "login", ns
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", ns
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 1}
+	cluster.Spec.MachineLoginSecret = &v1.ObjectReference{Name: secret.Name}
+	cluster.Spec.Infrastructure = &v1.ObjectReference{Name: infra.Name}
+
+	r := newTestReconciler(t, cluster, machine, pvc, infra, secret)
+	ctx := context.Background()
+	jobName := types.NamespacedName{Name: "test-cluster-create-job", Namespace: ns}
+	var phases []eggov1.ClusterPhase
+	failed := false
+	for i := 0; i < 30 && !cluster.Status.HasCluster; i++ {
+		_, err := r.reconcile(ctx, cluster, cluster.DeepCopy())
+		if err != nil && cluster.Status.Phase != eggov1.ClusterPhaseFailed {
+			t.Fatalf("reconcile cluster failed: %v", err)
+		}
+		if len(phases) == 0 || phases[len(phases)-1] != cluster.Status.Phase {
+			phases = append(phases, cluster.Status.Phase)
+		}
+		if cluster.Status.JobRef == nil {
+			continue
+		}
+		// first job failed, and the retried one succeeded
+		job := &batch.Job{}
+		if err = r.Get(ctx, jobName, job); err != nil {
+			t.Fatalf("get create job failed: %v", err)
+		}
+		if len(job.Status.Conditions) == 0 {
+			condition := batch.JobCondition{Type: batch.JobComplete, Status: v1.ConditionTrue}
+			if !failed {
+				condition.Type, failed = batch.JobFailed, true
+			}
+			job.Status.Conditions = []batch.JobCondition{condition}
+			if err = r.Status().Update(ctx, job); err != nil {
+				t.Fatalf("update status of job failed: %v", err)
+			}
+		}
+	}
+
+	expects := []eggov1.ClusterPhase{eggov1.ClusterPhasePending, eggov1.ClusterPhaseBinding, eggov1.ClusterPhaseConfiguring,
+		eggov1.ClusterPhaseDeploying, eggov1.ClusterPhaseFailed, eggov1.ClusterPhaseDeploying, eggov1.ClusterPhaseRunning}
+	if fmt.Sprint(phases) != fmt.Sprint(expects) {
+		t.Fatalf("expect phases: %v, get: %v", expects, phases)
+	}
+	if cluster.Status.Nodes != 1 {
+		t.Fatalf("expect 1 node of cluster, get: %d", cluster.Status.Nodes)
+	}
+	got := &eggov1.Cluster{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cluster.Name, Namespace: ns}, got); err != nil {
+		t.Fatalf("get cluster failed: %v", err)
+	}
+	if got.Status.Phase != eggov1.ClusterPhaseRunning || len(got.Status.JobHistorys) != 1 {
+		t.Fatalf("expect running cluster with history of failed job, get: %+v", got.Status)
+	}
+}
+
+func TestEggoJobResourcesAndScheduling(t *testing.T) {
+	ns := "default"
+	secret := &v1.Secret{Type: v1.SecretTypeBasicAuth}
+	secret.Name, secret.Namespace = "login", ns
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", ns
+	cluster.Status.MachineLoginSecretRef = &v1.ObjectReference{Name: secret.Name, Namespace: ns}
+	r := newTestReconciler(t, secret)
+
+	newJob := func() *batch.Job {
+		job := createEggoJobConfig(ns, "test-job", "eggo", "eggo:latest", "/config", "config", "/package", "packages", []string{"eggo"})
+		if err := fillEggoJobConfig(r, context.Background(), cluster, job); err != nil {
+			t.Fatalf("fill eggo job config failed: %v", err)
+		}
+		return job
+	}
+
+	// default resources
+	job := newJob()
+	res := job.Spec.Template.Spec.Containers[0].Resources
+	if res.Requests.Cpu().String() != eggov1.DefaultJobCPURequest || res.Requests.Memory().String() != eggov1.DefaultJobMemoryRequest ||
+		res.Limits.Cpu().String() != eggov1.DefaultJobCPULimit || res.Limits.Memory().String() != eggov1.DefaultJobMemoryLimit {
+		t.Fatalf("expect default resources of job, get: %+v", res)
+	}
+	if job.Spec.Template.Spec.NodeSelector != nil || job.Spec.Template.Spec.Tolerations != nil {
+		t.Fatalf("expect no node selector and tolerations by default")
+	}
+
+	cluster.Spec.JobResources = &v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m"), v1.ResourceMemory: resource.MustParse("256Mi")},
+		Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+	}
+	cluster.Spec.EggoNodeSelector = map[string]string{"node-role.kubernetes.io/master": ""}
+	cluster.Spec.EggoTolerations = []v1.Toleration{
+		{Key: "node-role.kubernetes.io/master", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule},
+	}
+	job = newJob()
+	res = job.Spec.Template.Spec.Containers[0].Resources
+	if res.Requests.Cpu().String() != "500m" || res.Requests.Memory().String() != "256Mi" || res.Limits.Memory().String() != "2Gi" {
+		t.Fatalf("expect resources of cluster spec, get: %+v", res)
+	}
+	if _, ok := res.Limits[v1.ResourceCPU]; ok {
+		t.Fatalf("expect no cpu limit, get: %+v", res.Limits)
+	}
+	spec := job.Spec.Template.Spec
+	if _, ok := spec.NodeSelector["node-role.kubernetes.io/master"]; !ok || len(spec.Tolerations) != 1 ||
+		spec.Tolerations[0].Key != "node-role.kubernetes.io/master" {
+		t.Fatalf("expect node selector and tolerations of cluster spec, get: %v, %v", spec.NodeSelector, spec.Tolerations)
+	}
+}
+
+func TestEggoJobImagePullSecrets(t *testing.T) {
+	ns := "default"
+	secret := &v1.Secret{Type: v1.SecretTypeBasicAuth}
+	secret.Name, secret.Namespace = "login", ns
+	pullSecret := &v1.Secret{Type: v1.SecretTypeDockerConfigJson}
+	pullSecret.Name, pullSecret.Namespace = "registry", ns
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", ns
+	cluster.Status.MachineLoginSecretRef = &v1.ObjectReference{Name: secret.Name, Namespace: ns}
+	cluster.Spec.EggoImageVersion = "eggo:latest"
+	cluster.Spec.EggoImage = "registry.example.com/eggo:1.0.0"
+	cluster.Spec.ImagePullSecrets = []v1.LocalObjectReference{{Name: "not-exist"}}
+	r := newTestReconciler(t, secret, pullSecret)
+
+	newJob := func() *batch.Job {
+		return createEggoJobConfig(ns, "test-job", "eggo", GetEggoImage(cluster), "/config", "config", "/package", "packages", []string{"eggo"})
+	}
+	if err := fillEggoJobConfig(r, context.Background(), cluster, newJob()); err == nil || !strings.Contains(err.Error(), "not-exist") {
+		t.Fatalf("expect not found image pull secret, get: %v", err)
+	}
+
+	cluster.Spec.ImagePullSecrets = []v1.LocalObjectReference{{Name: pullSecret.Name}}
+	job := newJob()
+	if err := fillEggoJobConfig(r, context.Background(), cluster, job); err != nil {
+		t.Fatalf("fill eggo job config failed: %v", err)
+	}
+	spec := job.Spec.Template.Spec
+	if spec.Containers[0].Image != "registry.example.com/eggo:1.0.0" {
+		t.Fatalf("expect full image reference, get: %s", spec.Containers[0].Image)
+	}
+	if len(spec.ImagePullSecrets) != 1 || spec.ImagePullSecrets[0].Name != pullSecret.Name {
+		t.Fatalf("expect image pull secrets of cluster spec, get: %v", spec.ImagePullSecrets)
+	}
+
+	cluster.Spec.EggoImage = ""
+	if image := GetEggoImage(cluster); image != "eggo:latest" {
+		t.Fatalf("expect image of eggoImageVersion, get: %s", image)
+	}
+}
+
+func TestEggoHomeVolumeOfCluster(t *testing.T) {
+	ns := "default"
+	secret := &v1.Secret{Type: v1.SecretTypeBasicAuth}
+	secret.Name, secret.Namespace = "login", ns
+	storageClass := "local-storage"
+	infra := &eggov1.Infrastructure{}
+	infra.Name, infra.Namespace = "infra", ns
+	infra.Spec.EggoHomeStorageClassName = &storageClass
+	r := newTestReconciler(t, secret, infra)
+	ctx := context.Background()
+
+	homeOfJob := func(cluster *eggov1.Cluster) string {
+		job := createEggoJobConfig(ns, cluster.Name+"-job", "eggo", "eggo:latest", "/config", "config", "/package", "packages", []string{"eggo"})
+		if err := fillEggoJobConfig(r, ctx, cluster, job); err != nil {
+			t.Fatalf("fill eggo job config failed: %v", err)
+		}
+		var claim string
+		for _, vol := range job.Spec.Template.Spec.Volumes {
+			if vol.HostPath != nil {
+				t.Fatalf("job should not use host path: %v", vol)
+			}
+			if vol.Name == "eggo-home" && vol.PersistentVolumeClaim != nil {
+				claim = vol.PersistentVolumeClaim.ClaimName
+			}
+		}
+		for _, m := range job.Spec.Template.Spec.Containers[0].VolumeMounts {
+			if m.Name == "eggo-home" && m.MountPath == eggoHomePath {
+				return claim
+			}
+		}
+		t.Fatalf("eggo home is not mounted to %s", eggoHomePath)
+		return ""
+	}
+
+	clusterA, clusterB := &eggov1.Cluster{}, &eggov1.Cluster{}
+	clusterA.Name, clusterA.Namespace = "cluster-a", ns
+	clusterB.Name, clusterB.Namespace = "cluster-b", ns
+	for _, c := range []*eggov1.Cluster{clusterA, clusterB} {
+		c.Status.MachineLoginSecretRef = &v1.ObjectReference{Name: secret.Name, Namespace: ns}
+		c.Status.InfrastructureRef = &v1.ObjectReference{Name: infra.Name, Namespace: ns}
+	}
+
+	homeA := homeOfJob(clusterA)
+	if homeA != "eggo-home-cluster-a" || homeOfJob(clusterA) != homeA {
+		t.Fatalf("expect same pvc for jobs of cluster, get: %s", homeA)
+	}
+	if homeB := homeOfJob(clusterB); homeB == homeA {
+		t.Fatalf("clusters should not share eggo home: %s", homeB)
+	}
+	pvc := &v1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: homeA, Namespace: ns}, pvc); err != nil {
+		t.Fatalf("get eggo home pvc failed: %v", err)
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != storageClass {
+		t.Fatalf("expect storage class of infrastructure, get: %v", pvc.Spec.StorageClassName)
+	}
+
+	// removed in next reconcile after deleted
+	if removed, err := r.deleteEggoHomePVC(ctx, clusterA); err != nil || removed {
+		t.Fatalf("expect eggo home pvc deleted, get: %v, %v", removed, err)
+	}
+	if removed, err := r.deleteEggoHomePVC(ctx, clusterA); err != nil || !removed {
+		t.Fatalf("expect eggo home pvc removed, get: %v, %v", removed, err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "eggo-home-cluster-b", Namespace: ns}, pvc); err != nil {
+		t.Fatalf("eggo home pvc of other cluster should be kept: %v", err)
+	}
+}
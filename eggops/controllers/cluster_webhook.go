@@ -0,0 +1,170 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"isula.org/eggo/cmd"
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+// ClusterValidatePath is path of validating webhook of cluster
+const ClusterValidatePath = "/validate-eggo-isula-org-v1-cluster"
+
+// +kubebuilder:webhook:path=/validate-eggo-isula-org-v1-cluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=eggo.isula.org,resources=clusters,verbs=create;update,versions=v1,name=vcluster.eggo.isula.org,admissionReviewVersions={v1,v1beta1}
+
+// ClusterValidator rejects invalid specs of cluster at apply time, instead of failing deep in reconcile
+type ClusterValidator struct {
+	Client  client.Reader
+	decoder *admission.Decoder
+}
+
+// InjectDecoder injects decoder of admission requests
+func (v *ClusterValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+func (v *ClusterValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	cluster := &eggov1.Cluster{}
+	if err := v.decoder.Decode(req, cluster); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// referenced resources are checked only on create, they may be removed while cluster is deleting
+	checkRefs := true
+	if len(req.OldObject.Raw) != 0 {
+		old := &eggov1.Cluster{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// updates of finalizers and labels are always allowed
+		if equality.Semantic.DeepEqual(old.Spec, cluster.Spec) {
+			return admission.Allowed("")
+		}
+		// deployed nodes are not reconfigured, only membership and job settings may change after deployed
+		if old.IsCreated() {
+			if changed := changedDeployedFields(&old.Spec, &cluster.Spec); len(changed) != 0 {
+				return admission.Denied(fmt.Sprintf("%s of cluster %s can not be changed after deployed, deployed nodes are not reconfigured",
+					strings.Join(changed, ", "), cluster.Name))
+			}
+		}
+		checkRefs = false
+	}
+
+	errs := ValidateCluster(ctx, v.Client, cluster, checkRefs)
+	if len(errs) == 0 {
+		return admission.Allowed("")
+	}
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return admission.Denied(fmt.Sprintf("invalid spec of cluster %s: %s", cluster.Name, strings.Join(msgs, "; ")))
+}
+
+// SetupWithManager registers validating webhook of cluster into webhook server of manager
+func (v *ClusterValidator) SetupWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(ClusterValidatePath, &webhook.Admission{Handler: v})
+	return nil
+}
+
+func validateRequireMachine(name string, config eggov1.RequireMachineConfig) error {
+	if config.Number < 0 {
+		return fmt.Errorf("%s.number must not be negative", name)
+	}
+	if len(config.MachineNames) > int(config.Number) {
+		return fmt.Errorf("%s.machineNames has %d machines, more than number %d", name, len(config.MachineNames), config.Number)
+	}
+	return nil
+}
+
+// ValidateCluster returns all problems of spec of cluster, the same checker of eggo command is used for network;
+// existence of machine login secret and infrastructure is checked if checkRefs is true
+func ValidateCluster(ctx context.Context, c client.Reader, cluster *eggov1.Cluster, checkRefs bool) []error {
+	var errs []error
+	spec := cluster.Spec
+
+	if spec.MasterRequire.Number < 1 {
+		errs = append(errs, fmt.Errorf("masterRequire.number must be at least 1"))
+	}
+	requires := []struct {
+		name   string
+		config eggov1.RequireMachineConfig
+	}{
+		{name: "masterRequire", config: spec.MasterRequire},
+		{name: "workerRequire", config: spec.WorkerRequire},
+		{name: "loadbalanceRequires", config: spec.LoadbalanceRequires},
+	}
+	for _, r := range requires {
+		if err := validateRequireMachine(r.name, r.config); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if spec.LoadbalanceRequires.Number > 1 {
+		errs = append(errs, fmt.Errorf("loadbalanceRequires.number must be at most 1"))
+	}
+
+	service := cmd.ServiceClusterConfig{
+		CIDR:    spec.Network.ServiceCidr,
+		DNSAddr: spec.Network.ServiceDnsIp,
+		Gateway: spec.Network.ServiceGateway,
+	}
+	network := cmd.NetworkConfig{
+		PodCIDR:    spec.Network.PodCidr,
+		Plugin:     spec.Network.PodPlugin,
+		PluginArgs: spec.Network.PodPluginArgs,
+	}
+	if err := cmd.CheckNetworkConfig(service, network); err != nil {
+		errs = append(errs, err)
+	}
+
+	if spec.MachineLoginSecret == nil {
+		errs = append(errs, fmt.Errorf("machineLoginSecret is required"))
+	} else if checkRefs {
+		namespace := GetWorkspaceNamespace(cluster)
+		if spec.MachineLoginSecret.Namespace != "" && spec.MachineLoginSecret.Namespace != namespace {
+			errs = append(errs, fmt.Errorf("machineLoginSecret %s must be in namespace %s", spec.MachineLoginSecret.Name, namespace))
+		} else if err := c.Get(ctx, types.NamespacedName{Name: spec.MachineLoginSecret.Name, Namespace: namespace}, &v1.Secret{}); err != nil {
+			errs = append(errs, fmt.Errorf("get machineLoginSecret %s in namespace %s failed: %v", spec.MachineLoginSecret.Name, namespace, err))
+		}
+	}
+
+	if spec.Infrastructure == nil {
+		errs = append(errs, fmt.Errorf("infrastructure is required"))
+	} else if checkRefs {
+		if spec.Infrastructure.Namespace != "" && spec.Infrastructure.Namespace != cluster.Namespace {
+			errs = append(errs, fmt.Errorf("infrastructure %s must be in namespace %s", spec.Infrastructure.Name, cluster.Namespace))
+		} else if err := c.Get(ctx, types.NamespacedName{Name: spec.Infrastructure.Name, Namespace: cluster.Namespace}, &eggov1.Infrastructure{}); err != nil {
+			errs = append(errs, fmt.Errorf("get infrastructure %s in namespace %s failed: %v", spec.Infrastructure.Name, cluster.Namespace, err))
+		}
+	}
+
+	return errs
+}
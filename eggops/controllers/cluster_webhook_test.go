@@ -0,0 +1,92 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+func TestValidateCluster(t *testing.T) {
+	ns := "default"
+	secret := &v1.Secret{Type: v1.SecretTypeBasicAuth}
+	secret.Name, secret.Namespace = "login", ns
+	infra := &eggov1.Infrastructure{}
+	infra.Name, infra.Namespace = "infra", ns
+	r := newTestReconciler(t, secret, infra)
+
+	newCluster := func() *eggov1.Cluster {
+		cluster := &eggov1.Cluster{}
+		cluster.Name, cluster.Namespace = "test-cluster", ns
+		cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 1}
+		cluster.Spec.WorkerRequire = eggov1.RequireMachineConfig{Number: 2}
+		cluster.Spec.MachineLoginSecret = &v1.ObjectReference{Name: secret.Name}
+		cluster.Spec.Infrastructure = &v1.ObjectReference{Name: infra.Name}
+		cluster.Spec.Network = eggov1.ClusterNetworkConfig{
+			ServiceCidr:  "10.32.0.0/16",
+			ServiceDnsIp: "10.32.0.10",
+			PodCidr:      "10.244.0.0/16",
+		}
+		return cluster
+	}
+	if errs := ValidateCluster(context.Background(), r.Client, newCluster(), true); len(errs) != 0 {
+		t.Fatalf("expect valid cluster, get: %v", errs)
+	}
+
+	tcs := []struct {
+		name      string
+		modify    func(c *eggov1.Cluster)
+		checkRefs bool
+		expect    string
+	}{
+		{name: "zero masters", modify: func(c *eggov1.Cluster) { c.Spec.MasterRequire.Number = 0 }, expect: "masterRequire.number must be at least 1"},
+		{name: "negative workers", modify: func(c *eggov1.Cluster) { c.Spec.WorkerRequire.Number = -1 }, expect: "workerRequire.number must not be negative"},
+		{name: "too many machine names", modify: func(c *eggov1.Cluster) { c.Spec.MasterRequire.MachineNames = []string{"m0", "m1"} },
+			expect: "masterRequire.machineNames has 2 machines"},
+		{name: "two loadbalances", modify: func(c *eggov1.Cluster) { c.Spec.LoadbalanceRequires.Number = 2 }, expect: "loadbalanceRequires.number must be at most 1"},
+		{name: "invalid service cidr", modify: func(c *eggov1.Cluster) { c.Spec.Network.ServiceCidr = "10.32.0.0/33" }, expect: "invalid service cidr"},
+		{name: "overlapped cidrs", modify: func(c *eggov1.Cluster) { c.Spec.Network.PodCidr = "10.32.128.0/17" }, expect: "overlaps with service cidr"},
+		{name: "no secret", modify: func(c *eggov1.Cluster) { c.Spec.MachineLoginSecret = nil }, expect: "machineLoginSecret is required"},
+		{name: "nonexistent secret", modify: func(c *eggov1.Cluster) { c.Spec.MachineLoginSecret.Name = "not-exist" }, checkRefs: true,
+			expect: "get machineLoginSecret not-exist in namespace default failed"},
+		{name: "secret in other namespace", modify: func(c *eggov1.Cluster) { c.Spec.MachineLoginSecret.Namespace = "other" }, checkRefs: true,
+			expect: "machineLoginSecret login must be in namespace default"},
+		{name: "no infrastructure", modify: func(c *eggov1.Cluster) { c.Spec.Infrastructure = nil }, expect: "infrastructure is required"},
+		{name: "nonexistent infrastructure", modify: func(c *eggov1.Cluster) { c.Spec.Infrastructure.Name = "not-exist" }, checkRefs: true,
+			expect: "get infrastructure not-exist in namespace default failed"},
+	}
+	for _, tc := range tcs {
+		cluster := newCluster()
+		tc.modify(cluster)
+		errs := ValidateCluster(context.Background(), r.Client, cluster, tc.checkRefs)
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), tc.expect) {
+			t.Fatalf("%s: expect error %q, get: %v", tc.name, tc.expect, errs)
+		}
+	}
+
+	// references are not checked on update
+	cluster := newCluster()
+	cluster.Spec.MachineLoginSecret.Name = "not-exist"
+	if errs := ValidateCluster(context.Background(), r.Client, cluster, false); len(errs) != 0 {
+		t.Fatalf("expect references not checked, get: %v", errs)
+	}
+}
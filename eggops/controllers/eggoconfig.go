@@ -0,0 +1,139 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+const (
+	// sha256 of eggo config saved in configmap
+	ConfigHashAnnotation = "eggo.isula.org/config-hash"
+	// sha256 of fields of spec deployed into nodes, see deployedSpec
+	SpecHashAnnotation = "eggo.isula.org/spec-hash"
+)
+
+// fields of spec rendered into deployed nodes, keyed by json name; nodes are not reconfigured
+// when they change, so they are immutable after cluster deployed
+func deployedSpec(spec *eggov1.ClusterSpec) map[string]interface{} {
+	return map[string]interface{}{
+		"loadbalanceRequires":  spec.LoadbalanceRequires,
+		"loadbalance-bindport": spec.LoadbalanceBindPort,
+		"infrastructure":       spec.Infrastructure,
+		"apiendpoint":          spec.ApiEndpoint,
+		"runtime":              spec.Runtime,
+		"enableKubeletServing": spec.EnableKubeletServing,
+		"network":              spec.Network,
+		"addons":               spec.Addons,
+		"workspaceNamespace":   spec.WorkspaceNamespace,
+	}
+}
+
+// changedDeployedFields returns sorted names of deployed fields different between old and cur
+func changedDeployedFields(old, cur *eggov1.ClusterSpec) []string {
+	oldFields, curFields := deployedSpec(old), deployedSpec(cur)
+	var changed []string
+	for name, field := range curFields {
+		if !equality.Semantic.DeepEqual(oldFields[name], field) {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// machines are not in the hash, so membership changes do not change it
+func deployedSpecHash(spec *eggov1.ClusterSpec) (string, error) {
+	// keys of map are sorted by json marshal
+	data, err := json.Marshal(deployedSpec(spec))
+	if err != nil {
+		return "", err
+	}
+	return configHash(data), nil
+}
+
+// keys of map are sorted by yaml marshal, so hash of same cluster is stable
+func configHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func setEggoConfigData(cm *v1.ConfigMap, data []byte, specHash string) {
+	if cm.BinaryData == nil {
+		cm.BinaryData = make(map[string][]byte)
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+	cm.BinaryData[eggov1.ClusterConfigMapBinaryConfKey] = data
+	cm.Annotations[ConfigHashAnnotation] = configHash(data)
+	cm.Annotations[SpecHashAnnotation] = specHash
+}
+
+// regenerate eggo config of created cluster, and update configmap if machines or spec of cluster changed;
+// the new config is used by later join/cleanup jobs only. Deployed fields of spec are rejected by webhook,
+// if they are changed anyway (webhook disabled), warn it since deployed nodes are not reconfigured
+func (r *ClusterReconciler) syncEggoConfig(ctx context.Context, cluster *eggov1.Cluster, mb *eggov1.MachineBinding) (bool, error) {
+	secret := &v1.Secret{}
+	if err := r.Get(ctx, ReferenceToNamespacedName(cluster.Status.MachineLoginSecretRef), secret); err != nil {
+		return false, err
+	}
+	infrastructure := &eggov1.Infrastructure{}
+	if err := r.Get(ctx, ReferenceToNamespacedName(cluster.Status.InfrastructureRef), infrastructure); err != nil {
+		return false, err
+	}
+	data, err := ConvertClusterToEggoConfig(cluster, mb, secret, infrastructure)
+	if err != nil {
+		return false, err
+	}
+
+	cm := &v1.ConfigMap{}
+	if err = r.Get(ctx, ReferenceToNamespacedName(cluster.Status.ConfigRef), cm); err != nil {
+		return false, err
+	}
+	specHash, err := deployedSpecHash(&cluster.Spec)
+	if err != nil {
+		return false, err
+	}
+
+	oldSpecHash := cm.Annotations[SpecHashAnnotation]
+	if cm.Annotations[ConfigHashAnnotation] == configHash(data) && oldSpecHash == specHash {
+		return false, nil
+	}
+	setEggoConfigData(cm, data, specHash)
+	if err = r.Update(ctx, cm); err != nil {
+		return false, err
+	}
+
+	// configmap created by older controller has no spec hash, take current spec as deployed
+	if oldSpecHash != "" && oldSpecHash != specHash {
+		cluster.Status.Message = "deployed spec of cluster changed, deployed nodes are not reconfigured"
+		r.Recorder.Event(cluster, v1.EventTypeWarning, ReasonConfigChanged,
+			"deployed spec of cluster changed, it applies to nodes joined later only, deployed nodes are not reconfigured")
+	}
+	r.Log.Info("update cluster config in configmap success", "name", cluster.Name)
+	return true, nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+func TestSyncEggoConfig(t *testing.T) {
+	r, cluster, machines := newRunningCluster(t)
+	ctx := context.Background()
+	mb := &eggov1.MachineBinding{}
+	if err := r.Get(ctx, ReferenceToNamespacedName(cluster.Status.MachineBindingRef), mb); err != nil {
+		t.Fatalf("get machine binding failed: %v", err)
+	}
+
+	if changed, err := r.syncEggoConfig(ctx, cluster, mb); err != nil || !changed {
+		t.Fatalf("expect config generated, get: %v, %v", changed, err)
+	}
+	if hasEvent(r, ReasonConfigChanged) {
+		t.Fatalf("expect no event of config changed for configmap without spec hash")
+	}
+	if changed, err := r.syncEggoConfig(ctx, cluster, mb); err != nil || changed {
+		t.Fatalf("expect config unchanged, get: %v, %v", changed, err)
+	}
+
+	// membership changes update config without event
+	mb.AddMachine(*machines["worker1"], eggov1.UsageWorker)
+	if changed, err := r.syncEggoConfig(ctx, cluster, mb); err != nil || !changed {
+		t.Fatalf("expect config changed with machines, get: %v, %v", changed, err)
+	}
+	if hasEvent(r, ReasonConfigChanged) {
+		t.Fatalf("expect no event of config changed for membership change")
+	}
+
+	cluster.Spec.Network.PodPluginArgs = map[string]string{"Backend": "vxlan"}
+	if changed, err := r.syncEggoConfig(ctx, cluster, mb); err != nil || !changed {
+		t.Fatalf("expect config changed with spec, get: %v, %v", changed, err)
+	}
+	if !hasEvent(r, ReasonConfigChanged) {
+		t.Fatalf("expect event of config changed")
+	}
+}
+
+func TestChangedDeployedFields(t *testing.T) {
+	old := &eggov1.ClusterSpec{
+		WorkerRequire: eggov1.RequireMachineConfig{Number: 1},
+		Network:       eggov1.ClusterNetworkConfig{PodPlugin: "calico"},
+	}
+	cur := old.DeepCopy()
+	cur.WorkerRequire.Number = 3
+	cur.JobBackoffLimit = new(int32)
+	if changed := changedDeployedFields(old, cur); len(changed) != 0 {
+		t.Fatalf("expect membership and job settings changeable, get: %v", changed)
+	}
+
+	cur.Network.PodPluginArgs = map[string]string{"Backend": "vxlan"}
+	cur.EnableKubeletServing = true
+	if changed := changedDeployedFields(old, cur); !reflect.DeepEqual(changed, []string{"enableKubeletServing", "network"}) {
+		t.Fatalf("expect network and enableKubeletServing changed, get: %v", changed)
+	}
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/constants"
+)
+
+const (
+	// downloaded packages in home directory of cluster are not saved
+	clusterHomePackagesDir = "packages"
+
+	eggoHomeSeedPath = "/eggo-home-seed"
+)
+
+func eggoHomeSecretName(cluster *eggov1.Cluster) types.NamespacedName {
+	return types.NamespacedName{Name: fmt.Sprintf(eggov1.EggoHomeSecretFormat, cluster.Name), Namespace: GetWorkspaceNamespace(cluster)}
+}
+
+// archiveClusterHome returns tar.gz of home directory of cluster
+func archiveClusterHome(home string) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	err := filepath.Walk(home, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(home, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if info.IsDir() && rel == clusterHomePackagesDir {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err = tw.WriteHeader(hdr); err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = tw.Close(); err != nil {
+		return nil, err
+	}
+	if err = gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// extractClusterHome extracts archive of archiveClusterHome into home directory of cluster
+func extractClusterHome(home string, archive []byte) error {
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid path %s in archive", hdr.Name)
+		}
+		path := filepath.Join(home, name)
+		mode := os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, mode)
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(path), constants.EggoHomeDirMode); err != nil {
+				return err
+			}
+			var data []byte
+			if data, err = ioutil.ReadAll(tr); err != nil {
+				return err
+			}
+			err = ioutil.WriteFile(path, data, mode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// saveClusterHome saves home directory of cluster deployed in process into secret of cluster,
+// secret is removed if cluster is cleaned up
+func (r *ClusterReconciler) saveClusterHome(ctx context.Context, cluster *eggov1.Cluster) error {
+	name := eggoHomeSecretName(cluster)
+	secret := &v1.Secret{}
+	err := r.Get(ctx, name, secret)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	exist := err == nil
+
+	home := api.GetClusterHomePath(cluster.Name)
+	if _, err = os.Stat(home); os.IsNotExist(err) {
+		if exist {
+			return client.IgnoreNotFound(r.Delete(ctx, secret))
+		}
+		return nil
+	}
+	archive, err := archiveClusterHome(home)
+	if err != nil {
+		return err
+	}
+
+	secret.Name, secret.Namespace = name.Name, name.Namespace
+	secret.Type = v1.SecretTypeOpaque
+	secret.Data = map[string][]byte{eggov1.EggoHomeSecretKey: archive}
+	if exist {
+		return r.Update(ctx, secret)
+	}
+	return r.Create(ctx, secret)
+}
+
+// restoreClusterHome replaces home directory of cluster in process with the saved one, which
+// may be lost after restart of controller
+func (r *ClusterReconciler) restoreClusterHome(ctx context.Context, cluster *eggov1.Cluster) error {
+	secret := &v1.Secret{}
+	if err := r.Get(ctx, eggoHomeSecretName(cluster), secret); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	home := api.GetClusterHomePath(cluster.Name)
+	if err := os.RemoveAll(home); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(home, constants.EggoHomeDirMode); err != nil {
+		return err
+	}
+	return extractClusterHome(home, secret.Data[eggov1.EggoHomeSecretKey])
+}
+
+// addEggoHomeSeed seeds eggo home volume of job with home directory saved by deployment in process,
+// eggo home of job is not changed if cluster exists in it
+func (r *ClusterReconciler) addEggoHomeSeed(ctx context.Context, cluster *eggov1.Cluster, job *batch.Job) error {
+	secret := &v1.Secret{}
+	name := eggoHomeSecretName(cluster)
+	if err := r.Get(ctx, name, secret); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	home := filepath.Join(eggoHomePath, cluster.Name)
+	archive := filepath.Join(eggoHomeSeedPath, eggov1.EggoHomeSecretKey)
+	podSpec := &job.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes,
+		v1.Volume{
+			Name: "eggo-home-seed",
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: name.Name,
+				},
+			},
+		})
+	podSpec.InitContainers = append(podSpec.InitContainers,
+		v1.Container{
+			Name:    "seed-eggo-home",
+			Image:   podSpec.Containers[0].Image,
+			Command: []string{"sh", "-c", fmt.Sprintf("[ -e %[1]s ] || (mkdir -p %[1]s && tar -xzf %[2]s -C %[1]s)", home, archive)},
+			VolumeMounts: []v1.VolumeMount{
+				{
+					Name:      "eggo-home",
+					MountPath: eggoHomePath,
+				},
+				{
+					Name:      "eggo-home-seed",
+					MountPath: eggoHomeSeedPath,
+					ReadOnly:  true,
+				},
+			},
+			Resources: podSpec.Containers[0].Resources,
+		})
+	return nil
+}
+
+// deleteEggoHomeSecret removes home directory of cluster saved by deployment in process
+func (r *ClusterReconciler) deleteEggoHomeSecret(ctx context.Context, cluster *eggov1.Cluster) error {
+	secret := &v1.Secret{}
+	if err := r.Get(ctx, eggoHomeSecretName(cluster), secret); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	return client.IgnoreNotFound(r.Delete(ctx, secret))
+}
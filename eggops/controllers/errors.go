@@ -0,0 +1,65 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+
+	"isula.org/eggo/pkg/utils"
+)
+
+// errors of reconciling cluster, wrapped with details and matched by errors.Is
+var (
+	// machines matching requires of cluster are not enough, wait for new machines
+	ErrInsufficientMachines = errors.New("no enough machines")
+	// machine pinned by machineNames is not found or does not match features
+	ErrMachineNotMatch = errors.New("machine not found or not match features")
+	// machine pinned by machineNames is bound to other cluster
+	ErrMachineInUse = errors.New("machine is already in use")
+	// machine pinned by machineNames is unreachable, wait for it to recover
+	ErrMachineUnhealthy = errors.New("machine is unhealthy")
+	// machine pinned by machineNames is in maintenance, wait for it to finish
+	ErrMachineInMaintenance = errors.New("machine is in maintenance")
+	// machine selected is reserved by other cluster concurrently, select machines again
+	ErrMachineReserved = errors.New("machine is reserved by other cluster")
+	// machine login secret misses keys or has unsupported type
+	ErrInvalidSecret = errors.New("invalid secret")
+	// resource referred by cluster is required but not set
+	ErrMissingReference = errors.New("missing reference")
+	// resource referred by cluster is not in the required namespace
+	ErrNamespaceMismatch = errors.New("namespace mismatch")
+	// package persistentVolumeClaim is not bound yet, wait for it
+	ErrPVCNotBound = errors.New("persistentVolumeClaim is not bound")
+)
+
+// isWaitingError returns true if err is caused by resources not ready yet,
+// cluster is requeued to wait for them without reporting error
+func isWaitingError(err error) bool {
+	// aggregated errors are waiting only if all of them are waiting
+	var errs *utils.MultiError
+	if errors.As(err, &errs) {
+		for _, e := range errs.Errors() {
+			if !isWaitingError(e) {
+				return false
+			}
+		}
+		return len(errs.Errors()) > 0
+	}
+	return errors.Is(err, ErrInsufficientMachines) || errors.Is(err, ErrPVCNotBound) ||
+		errors.Is(err, ErrMachineUnhealthy) || errors.Is(err, ErrMachineReserved) ||
+		errors.Is(err, ErrMachineInMaintenance)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+func TestSecretErrors(t *testing.T) {
+	ns := "default"
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", ns
+
+	r := newTestReconciler(t)
+	if _, err := r.getLoginSecret(context.Background(), cluster); !errors.Is(err, ErrMissingReference) {
+		t.Fatalf("expect ErrMissingReference, get: %v", err)
+	}
+
+	cluster.Spec.MachineLoginSecret = &v1.ObjectReference{Name: "login", Namespace: "other"}
+	if _, err := r.getLoginSecret(context.Background(), cluster); !errors.Is(err, ErrNamespaceMismatch) {
+		t.Fatalf("expect ErrNamespaceMismatch, get: %v", err)
+	}
+
+	cluster.Spec.MachineLoginSecret = &v1.ObjectReference{Name: "login"}
+	for _, secret := range []*v1.Secret{
+		{Type: v1.SecretTypeOpaque},
+		{Type: v1.SecretTypeBasicAuth, Data: map[string][]byte{v1.BasicAuthPasswordKey: []byte("123456")}},
+		{Type: v1.SecretTypeBasicAuth, Data: map[string][]byte{v1.BasicAuthUsernameKey: []byte("root")}},
+		{Type: v1.SecretTypeSSHAuth},
+	} {
+		secret.Name, secret.Namespace = "login", ns
+		r = newTestReconciler(t, secret)
+		if _, err := r.getLoginSecret(context.Background(), cluster); !errors.Is(err, ErrInvalidSecret) {
+			t.Fatalf("expect ErrInvalidSecret of secret type %s, get: %v", secret.Type, err)
+		}
+	}
+}
+
+func TestPackageErrors(t *testing.T) {
+	ns := "default"
+	secret := &v1.Secret{
+		Type: v1.SecretTypeBasicAuth,
+		Data: map[string][]byte{v1.BasicAuthUsernameKey: []byte("root"), v1.BasicAuthPasswordKey: []byte("123456")},
+	}
+	secret.Name, secret.Namespace = "login", ns
+	pvc := &v1.PersistentVolumeClaim{}
+	pvc.Name, pvc.Namespace = "packages", ns
+	pvc.Status.Phase = v1.ClaimPending
+	infra := &eggov1.Infrastructure{}
+	infra.Name, infra.Namespace = "infra", ns
+
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", ns
+	cluster.Spec.MachineLoginSecret = &v1.ObjectReference{Name: secret.Name}
+
+	r := newTestReconciler(t, secret, pvc, infra)
+	if err := r.validateLoginAndPackage(context.Background(), cluster); !errors.Is(err, ErrMissingReference) {
+		t.Fatalf("expect ErrMissingReference without infrastructure, get: %v", err)
+	}
+
+	cluster.Spec.Infrastructure = &v1.ObjectReference{Name: infra.Name, Namespace: "other"}
+	if err := r.validateLoginAndPackage(context.Background(), cluster); !errors.Is(err, ErrNamespaceMismatch) {
+		t.Fatalf("expect ErrNamespaceMismatch, get: %v", err)
+	}
+
+	cluster.Spec.Infrastructure = &v1.ObjectReference{Name: infra.Name}
+	if err := r.validateLoginAndPackage(context.Background(), cluster); !errors.Is(err, ErrMissingReference) {
+		t.Fatalf("expect ErrMissingReference without pvc, get: %v", err)
+	}
+
+	infra.Spec.PackagePersistentVolumeClaim = &v1.ObjectReference{Name: pvc.Name}
+	r = newTestReconciler(t, secret, pvc, infra)
+	err := r.validateLoginAndPackage(context.Background(), cluster)
+	if !errors.Is(err, ErrPVCNotBound) || !isWaitingError(err) {
+		t.Fatalf("expect waiting ErrPVCNotBound, get: %v", err)
+	}
+}
+
+func TestMachineErrors(t *testing.T) {
+	ns := "default"
+	m0 := &eggov1.Machine{}
+	m0.Name, m0.Namespace = "machine0", ns
+	m1 := &eggov1.Machine{}
+	m1.Name, m1.Namespace = "machine1", ns
+	used := &eggov1.MachineBinding{}
+	used.Name, used.Namespace = "used", ns
+	used.AddMachine(*m1, eggov1.UsageWorker)
+
+	r := newTestReconciler(t, m0, m1, used)
+	binded, err := r.bindedSelectMachines(context.Background(), ns)
+	if err != nil {
+		t.Fatalf("select binded machines failed: %v", err)
+	}
+
+	cases := []struct {
+		require eggov1.RequireMachineConfig
+		expect  error
+	}{
+		{eggov1.RequireMachineConfig{Number: 1, MachineNames: []string{"machine9"}}, ErrMachineNotMatch},
+		{eggov1.RequireMachineConfig{Number: 1, MachineNames: []string{"machine1"}}, ErrMachineInUse},
+		{eggov1.RequireMachineConfig{Number: 3}, ErrInsufficientMachines},
+		{eggov1.RequireMachineConfig{Number: 2}, ErrInsufficientMachines},
+	}
+	for _, c := range cases {
+		_, err := r.availableSelectMachines(context.Background(), ns, c.require, binded)
+		if !errors.Is(err, c.expect) {
+			t.Fatalf("expect %v of require %v, get: %v", c.expect, c.require, err)
+		}
+	}
+
+	if !isWaitingError(ErrInsufficientMachines) || isWaitingError(ErrInvalidSecret) {
+		t.Fatalf("only errors of resources not ready should be waited")
+	}
+}
+
+func TestPinnedMachineErrors(t *testing.T) {
+	ctx := context.Background()
+	unhealthy, maintenance := newTestMachine("machine0", "192.168.0.1"), newTestMachine("machine1", "192.168.0.2")
+	unhealthy.Status.Health = eggov1.MachineUnhealthy
+	maintenance.Spec.Maintenance = true
+	r := newTestReconciler(t, unhealthy, maintenance, newTestMachine("machine2", "192.168.0.3"))
+
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", "default"
+
+	// problems of all pinned machines are reported
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 2, MachineNames: []string{"machine0", "machine3"}}
+	_, _, _, err := r.filterMachines(ctx, cluster)
+	if !errors.Is(err, ErrMachineUnhealthy) || !errors.Is(err, ErrMachineNotMatch) || !strings.Contains(err.Error(), "machine3") {
+		t.Fatalf("expect unhealthy machine0 and not found machine3, get: %v", err)
+	}
+	if isWaitingError(err) {
+		t.Fatalf("expect not waiting for not found machine: %v", err)
+	}
+
+	// waiting only if all problems are waiting
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 2, MachineNames: []string{"machine0", "machine1"}}
+	_, _, _, err = r.filterMachines(ctx, cluster)
+	if !errors.Is(err, ErrMachineUnhealthy) || !errors.Is(err, ErrMachineInMaintenance) || !isWaitingError(err) {
+		t.Fatalf("expect waiting for machine0 and machine1, get: %v", err)
+	}
+
+	// stop at the first problem with fail fast
+	r.FailFast = true
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 2, MachineNames: []string{"machine0", "machine3"}}
+	_, _, _, err = r.filterMachines(ctx, cluster)
+	if !errors.Is(err, ErrMachineUnhealthy) || errors.Is(err, ErrMachineNotMatch) {
+		t.Fatalf("expect only unhealthy machine0, get: %v", err)
+	}
+}
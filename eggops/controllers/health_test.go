@@ -0,0 +1,63 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+type fakeCache struct {
+	synced bool
+}
+
+func (c *fakeCache) WaitForCacheSync(ctx context.Context) bool {
+	return c.synced
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	cache := &fakeCache{}
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", http.StripPrefix("/healthz", &healthz.Handler{Checks: map[string]healthz.Checker{"healthz": healthz.Ping}}))
+	mux.Handle("/readyz", http.StripPrefix("/readyz", &healthz.Handler{Checks: map[string]healthz.Checker{"readyz": CacheSyncedChecker(cache)}}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	get := func(path string) int {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("get %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get("/healthz"); code != http.StatusOK {
+		t.Fatalf("expect healthz ok, get: %d", code)
+	}
+	if code := get("/readyz"); code == http.StatusOK {
+		t.Fatalf("expect not ready before cache synced")
+	}
+	cache.synced = true
+	if code := get("/readyz"); code != http.StatusOK {
+		t.Fatalf("expect ready after cache synced, get: %d", code)
+	}
+}
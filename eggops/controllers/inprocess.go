@@ -0,0 +1,323 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"isula.org/eggo/cmd"
+	eggov1 "isula.org/eggo/eggops/api/v1"
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/deploy"
+)
+
+// reasons of events recorded for cluster deployed in process
+const (
+	ReasonDeploySucceeded = "DeploySucceeded"
+	ReasonDeployFailed    = "DeployFailed"
+)
+
+// ClusterDeployer deploys and cleanups cluster in process of controller
+type ClusterDeployer interface {
+	Deploy(ctx context.Context, cc *api.ClusterConfig) error
+	Cleanup(ctx context.Context, cc *api.ClusterConfig) error
+}
+
+// libraryDeployer calls deploy library of eggo, same as eggo command in job
+type libraryDeployer struct{}
+
+func (d libraryDeployer) Deploy(ctx context.Context, cc *api.ClusterConfig) error {
+	cstatus, err := deploy.Run(ctx, cc, deploy.DeployOptions{EnableRollback: true})
+	if err != nil {
+		return err
+	}
+	if !cstatus.Working {
+		return fmt.Errorf("cluster %s is not working: %s", cc.Name, cstatus.Message)
+	}
+	return nil
+}
+
+func (d libraryDeployer) Cleanup(ctx context.Context, cc *api.ClusterConfig) error {
+	return deploy.Cleanup(ctx, cc)
+}
+
+func (r *ClusterReconciler) getDeployer() ClusterDeployer {
+	if r.Deployer != nil {
+		return r.Deployer
+	}
+	return libraryDeployer{}
+}
+
+// clusterConfigInProcess builds config of cluster deployment from machine binding and login secret of cluster
+func (r *ClusterReconciler) clusterConfigInProcess(ctx context.Context, cluster *eggov1.Cluster, op api.HookOperator) (*api.ClusterConfig, error) {
+	mb := &eggov1.MachineBinding{}
+	if err := r.Get(ctx, ReferenceToNamespacedName(cluster.Status.MachineBindingRef), mb); err != nil {
+		return nil, err
+	}
+	secret := &v1.Secret{}
+	if err := r.Get(ctx, ReferenceToNamespacedName(cluster.Status.MachineLoginSecretRef), secret); err != nil {
+		return nil, err
+	}
+	infrastructure := &eggov1.Infrastructure{}
+	if err := r.Get(ctx, ReferenceToNamespacedName(cluster.Status.InfrastructureRef), infrastructure); err != nil {
+		return nil, err
+	}
+	cc, err := ConvertClusterToClusterConfig(cluster, mb, secret, infrastructure, op)
+	if err != nil {
+		return nil, err
+	}
+	cc.FailFast = r.FailFast
+	return cc, nil
+}
+
+// inProcessLock serializes deployments in process, eggo library keeps nodes and home directory
+// of clusters in global state of process
+var inProcessLock sync.Mutex
+
+// inProcessTask is deployment or cleanup of cluster running in background of controller,
+// it waits in queue until deployments of other clusters finished
+type inProcessTask struct {
+	name   string
+	queued metav1.Time
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	lock     sync.Mutex
+	start    metav1.Time
+	running  bool
+	failures int
+	err      error
+}
+
+func (t *inProcessTask) setRunning() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.start = metav1.Now()
+	t.running = true
+}
+
+// started returns start time of task, and false if it is waiting in queue
+func (t *inProcessTask) started() (metav1.Time, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.start, t.running
+}
+
+func (t *inProcessTask) progress() (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.failures, t.err
+}
+
+func (t *inProcessTask) setResult(err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if err != nil {
+		t.failures++
+	}
+	t.err = err
+}
+
+func deployInProcessName(cluster *eggov1.Cluster) string {
+	return fmt.Sprintf("%s-create-inprocess", cluster.Name)
+}
+
+func cleanupInProcessName(cluster *eggov1.Cluster) string {
+	return fmt.Sprintf("%s-delete-inprocess", cluster.Name)
+}
+
+// inProcessTasks are running tasks of clusters, at most one for each cluster
+type inProcessTasks struct {
+	lock  sync.Mutex
+	tasks map[types.NamespacedName]*inProcessTask
+}
+
+// runInProcess starts task of cluster in background if not started, and returns finished task, or nil if it
+// is running. Like job, task is retried up to backoff limit of cluster and interrupted after active deadline,
+// which is counted after task waited for deployments of other clusters
+func (r *ClusterReconciler) runInProcess(cluster *eggov1.Cluster, name string, fn func(ctx context.Context) error) (*inProcessTask, bool) {
+	key := types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name}
+	r.inProcess.lock.Lock()
+	defer r.inProcess.lock.Unlock()
+	if r.inProcess.tasks == nil {
+		r.inProcess.tasks = make(map[types.NamespacedName]*inProcessTask)
+	}
+	if t, ok := r.inProcess.tasks[key]; ok {
+		select {
+		case <-t.done:
+			delete(r.inProcess.tasks, key)
+			if t.name == name {
+				return t, true
+			}
+		default:
+			// wait for other task of cluster
+			return t, false
+		}
+	}
+
+	deadline := time.Duration(GetJobActiveDeadlineSeconds(cluster)) * time.Second
+	backoffLimit := int(GetJobBackoffLimit(cluster))
+	interval, max := r.requeueIntervals()
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &inProcessTask{name: name, queued: metav1.Now(), cancel: cancel, done: make(chan struct{})}
+	r.inProcess.tasks[key] = t
+
+	go func() {
+		defer close(t.done)
+		defer cancel()
+		inProcessLock.Lock()
+		defer inProcessLock.Unlock()
+		if err := ctx.Err(); err != nil {
+			// canceled while waiting in queue
+			t.setResult(fmt.Errorf("%s is interrupted before run: %v", name, err))
+			return
+		}
+		t.setRunning()
+		ctx, cancelDeadline := context.WithTimeout(ctx, deadline)
+		defer cancelDeadline()
+		for {
+			err := fn(ctx)
+			t.setResult(err)
+			if failures, _ := t.progress(); err == nil || failures > backoffLimit {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				t.setResult(fmt.Errorf("%s is interrupted: %v, last error: %v", name, ctx.Err(), err))
+				return
+			case <-time.After(interval):
+			}
+			if interval *= 2; interval > max {
+				interval = max
+			}
+		}
+	}()
+	return t, false
+}
+
+// cancelInProcess interrupts task of cluster with name, returns true if it is not running
+func (r *ClusterReconciler) cancelInProcess(cluster *eggov1.Cluster, name string) bool {
+	key := types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name}
+	r.inProcess.lock.Lock()
+	defer r.inProcess.lock.Unlock()
+	t, ok := r.inProcess.tasks[key]
+	if !ok || t.name != name {
+		return true
+	}
+	t.cancel()
+	select {
+	case <-t.done:
+		delete(r.inProcess.tasks, key)
+		return true
+	default:
+		return false
+	}
+}
+
+// withClusterHome restores home directory of cluster before fn, and saves it after fn,
+// so config and certificates of cluster survive restart of controller
+func (r *ClusterReconciler) withClusterHome(cluster *eggov1.Cluster, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if err := r.restoreClusterHome(ctx, cluster); err != nil {
+			return fmt.Errorf("restore home of cluster %s failed: %v", cluster.Name, err)
+		}
+		err := fn(ctx)
+		// save even if ctx is done, files on nodes are changed already
+		if serr := r.saveClusterHome(context.Background(), cluster); serr != nil {
+			r.Log.Error(serr, "save home of cluster", "name", cluster.Name)
+			if err == nil {
+				err = fmt.Errorf("save home of cluster %s failed: %v", cluster.Name, serr)
+			}
+		}
+		return err
+	}
+}
+
+// deployClusterInProcess deploys cluster in background, returns true after deploy finished,
+// failure is saved into job histories like failed job
+func (r *ClusterReconciler) deployClusterInProcess(ctx context.Context, cluster *eggov1.Cluster) (bool, error) {
+	ccfg, err := r.clusterConfigInProcess(ctx, cluster, api.HookOpDeploy)
+	if err != nil {
+		// invalid config never deploys until spec of cluster is fixed, tell user by event
+		if errors.Is(err, cmd.ErrInvalidDeployConfig) {
+			r.Recorder.Event(cluster, v1.EventTypeWarning, ReasonDeployFailed, err.Error())
+		}
+		return false, err
+	}
+
+	deployer := r.getDeployer()
+	t, finished := r.runInProcess(cluster, deployInProcessName(cluster), r.withClusterHome(cluster, func(ctx context.Context) error {
+		return deployer.Deploy(ctx, ccfg)
+	}))
+	failures, err := t.progress()
+	start, running := t.started()
+	if !finished {
+		if !running {
+			cluster.Status.Message = fmt.Sprintf("waiting for deployments of other clusters in process since %s", t.queued.Format(time.RFC3339))
+			return false, nil
+		}
+		cluster.Status.Message = fmt.Sprintf("deploying cluster in process since %s", start.Format(time.RFC3339))
+		if failures > 0 {
+			cluster.Status.Message = fmt.Sprintf("deploy cluster in process retrying, failed %d times: %v", failures, err)
+		}
+		return false, nil
+	}
+
+	if err != nil {
+		if !running {
+			start = t.queued
+		}
+		finish := metav1.Now()
+		history := &eggov1.JobHistory{
+			Name:       t.name,
+			StartTime:  start,
+			FinishTime: &finish,
+			Message:    err.Error(),
+		}
+		r.addJobHistory(cluster, history)
+		r.Recorder.Event(cluster, v1.EventTypeWarning, ReasonDeployFailed, err.Error())
+		return true, err
+	}
+	r.Recorder.Eventf(cluster, v1.EventTypeNormal, ReasonDeploySucceeded, "deploy cluster %s in process succeeded", cluster.Name)
+	return true, nil
+}
+
+// cleanupClusterInProcess cleanups cluster in background, returns true after cleanup finished
+func (r *ClusterReconciler) cleanupClusterInProcess(ctx context.Context, cluster *eggov1.Cluster) (bool, error) {
+	ccfg, err := r.clusterConfigInProcess(ctx, cluster, api.HookOpCleanup)
+	if err != nil {
+		return false, err
+	}
+
+	deployer := r.getDeployer()
+	t, finished := r.runInProcess(cluster, cleanupInProcessName(cluster), r.withClusterHome(cluster, func(ctx context.Context) error {
+		return deployer.Cleanup(ctx, ccfg)
+	}))
+	if !finished {
+		return false, nil
+	}
+	_, err = t.progress()
+	return true, err
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+	"isula.org/eggo/pkg/api"
+)
+
+// fakeDeployer records configs of cluster, and fails first deploy if required,
+// deploy blocks until ctx is done if block is set
+type fakeDeployer struct {
+	lock      sync.Mutex
+	failFirst bool
+	block     bool
+	deployed  []*api.ClusterConfig
+	cleaned   []*api.ClusterConfig
+}
+
+func (d *fakeDeployer) Deploy(ctx context.Context, cc *api.ClusterConfig) error {
+	d.lock.Lock()
+	d.deployed = append(d.deployed, cc)
+	failed := d.failFirst && len(d.deployed) == 1
+	block := d.block
+	d.lock.Unlock()
+	if block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if failed {
+		return fmt.Errorf("deploy failed")
+	}
+	// certificates of cluster are generated in home directory
+	home := api.GetClusterHomePath(cc.Name)
+	if err := os.MkdirAll(filepath.Join(home, "pki"), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(home, "pki", "ca.crt"), []byte("ca of "+cc.Name), 0600)
+}
+
+func (d *fakeDeployer) Cleanup(ctx context.Context, cc *api.ClusterConfig) error {
+	d.lock.Lock()
+	d.cleaned = append(d.cleaned, cc)
+	d.lock.Unlock()
+	return os.RemoveAll(api.GetClusterHomePath(cc.Name))
+}
+
+func (d *fakeDeployer) counts() (int, int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return len(d.deployed), len(d.cleaned)
+}
+
+func TestDeployClusterInProcess(t *testing.T) {
+	ns := "default"
+	port := int32(22)
+	machine := &eggov1.Machine{}
+	machine.Name, machine.Namespace = "machine0", ns
+	machine.Spec = eggov1.MachineSpec{HostName: "machine0", IP: "192.168.0.1", Port: &port, Arch: "amd64"}
+	pvc := &v1.PersistentVolumeClaim{}
+	pvc.Name, pvc.Namespace = "packages", ns
+	pvc.Status.Phase = v1.ClaimBound
+	infra := &eggov1.Infrastructure{}
+	infra.Name, infra.Namespace = "infra", ns
+	infra.Spec.PackagePersistentVolumeClaim = &v1.ObjectReference{Name: pvc.Name}
+	secret := &v1.Secret{
+		Type: v1.SecretTypeSSHAuth,
+		Data: map[string][]byte{v1.SSHAuthPrivateKey: []byte("private key")},
+	}
+	secret.Name, secret.Namespace = "login", ns
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", ns
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 1}
+	cluster.Spec.MachineLoginSecret = &v1.ObjectReference{Name: secret.Name}
+	cluster.Spec.Infrastructure = &v1.ObjectReference{Name: infra.Name}
+
+	// failed deploy is not retried in the same run
+	var backoffLimit int32
+	cluster.Spec.JobBackoffLimit = &backoffLimit
+
+	api.EggoHomePath = t.TempDir()
+	r := newTestReconciler(t, cluster, machine, pvc, infra, secret)
+	deployer := &fakeDeployer{failFirst: true}
+	r.InProcessDeploy = true
+	r.Deployer = deployer
+	ctx := context.Background()
+	var phases []eggov1.ClusterPhase
+	for i := 0; i < 100 && !cluster.Status.HasCluster; i++ {
+		_, err := r.reconcile(ctx, cluster, cluster.DeepCopy())
+		if err != nil && cluster.Status.Phase != eggov1.ClusterPhaseFailed {
+			t.Fatalf("reconcile cluster failed: %v", err)
+		}
+		if len(phases) == 0 || phases[len(phases)-1] != cluster.Status.Phase {
+			phases = append(phases, cluster.Status.Phase)
+		}
+		// deploy runs in background
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	expects := []eggov1.ClusterPhase{eggov1.ClusterPhasePending, eggov1.ClusterPhaseBinding, eggov1.ClusterPhaseConfiguring,
+		eggov1.ClusterPhaseDeploying, eggov1.ClusterPhaseFailed, eggov1.ClusterPhaseRunning}
+	if fmt.Sprint(phases) != fmt.Sprint(expects) {
+		t.Fatalf("expect phases: %v, get: %v", expects, phases)
+	}
+	if deployed, _ := deployer.counts(); deployed != 2 {
+		t.Fatalf("expect deploy retried once, get %d deploys", deployed)
+	}
+	if len(cluster.Status.JobHistorys) != 1 || cluster.Status.JobHistorys[0].Message != "deploy failed" {
+		t.Fatalf("expect history of failed deploy, get: %+v", cluster.Status.JobHistorys)
+	}
+	jobs := &batch.JobList{}
+	if err := r.List(ctx, jobs); err != nil || len(jobs.Items) != 0 {
+		t.Fatalf("expect no job created in process, get: %v, err: %v", jobs.Items, err)
+	}
+
+	ccfg := deployer.deployed[1]
+	if ccfg.Name != "test-cluster" || len(ccfg.Nodes) != 1 || ccfg.Nodes[0].Address != "192.168.0.1" {
+		t.Fatalf("unexpect config of cluster: %+v", ccfg)
+	}
+	if ccfg.Nodes[0].PrivateKey != "private key" || ccfg.Nodes[0].PrivateKeyPath != "" {
+		t.Fatalf("expect private key read from secret, get key path: %s", ccfg.Nodes[0].PrivateKeyPath)
+	}
+
+	// home directory of cluster is saved, and restored after lost
+	home := api.GetClusterHomePath(cluster.Name)
+	if err := os.RemoveAll(home); err != nil {
+		t.Fatalf("remove home of cluster failed: %v", err)
+	}
+	if err := r.restoreClusterHome(ctx, cluster); err != nil {
+		t.Fatalf("restore home of cluster failed: %v", err)
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(home, "pki", "ca.crt")); err != nil || string(data) != "ca of test-cluster" {
+		t.Fatalf("expect certificate restored, get: %s, %v", string(data), err)
+	}
+
+	// jobs of cluster seed their eggo home with the saved one
+	job := createEggoJobConfig(ns, "test-job", "eggo", "eggo:latest", "/config", "config", "/package", "packages", []string{"eggo"})
+	if err := fillEggoJobConfig(r, ctx, cluster, job); err != nil {
+		t.Fatalf("fill eggo job config failed: %v", err)
+	}
+	if inits := job.Spec.Template.Spec.InitContainers; len(inits) != 1 || inits[0].Image != "eggo:latest" {
+		t.Fatalf("expect init container to seed eggo home, get: %+v", inits)
+	}
+
+	// cleanup cluster in process, instead of delete job
+	for i := 0; i < 100 && cluster.Status.HasCluster; i++ {
+		if _, err := r.reconcileDelete(ctx, cluster, cluster.DeepCopy()); err != nil {
+			t.Fatalf("reconcile delete failed: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, cleaned := deployer.counts(); cleaned != 1 || cluster.Status.HasCluster {
+		t.Fatalf("expect cluster cleaned in process, status: %+v", cluster.Status)
+	}
+	if err := r.Get(ctx, eggoHomeSecretName(cluster), &v1.Secret{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expect saved home removed after cleanup, get: %v", err)
+	}
+	if err := r.List(ctx, jobs); err != nil || len(jobs.Items) != 0 {
+		t.Fatalf("expect no delete job created, get: %v, err: %v", jobs.Items, err)
+	}
+}
+
+func TestDeployInProcessInterrupted(t *testing.T) {
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", "default"
+	var deadline int64 = 1
+	cluster.Spec.JobActiveDeadlineSeconds = &deadline
+
+	r := newTestReconciler(t)
+	deployer := &fakeDeployer{block: true}
+	run := func(ctx context.Context) error {
+		return deployer.Deploy(ctx, &api.ClusterConfig{Name: cluster.Name})
+	}
+
+	// reconcile is not blocked by running deploy
+	if task, finished := r.runInProcess(cluster, "deploy", run); task == nil || finished {
+		t.Fatalf("expect deploy running in background")
+	}
+	if _, finished := r.runInProcess(cluster, "deploy", run); finished {
+		t.Fatalf("expect deploy is still running")
+	}
+
+	// deploy is interrupted after deadline, and not retried after it
+	var task *inProcessTask
+	finished := false
+	for i := 0; i < 100 && !finished; i++ {
+		time.Sleep(50 * time.Millisecond)
+		task, finished = r.runInProcess(cluster, "deploy", run)
+	}
+	if !finished {
+		t.Fatalf("expect deploy interrupted after deadline")
+	}
+	if _, err := task.progress(); err == nil {
+		t.Fatalf("expect error of interrupted deploy")
+	}
+	if deployed, _ := deployer.counts(); deployed != 1 {
+		t.Fatalf("expect deploy not retried after deadline, get %d deploys", deployed)
+	}
+
+	// deleting cluster cancels running deploy
+	deadline = 3600
+	r.runInProcess(cluster, "deploy", run)
+	for i := 0; i < 100 && !r.cancelInProcess(cluster, "deploy"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !r.cancelInProcess(cluster, "deploy") {
+		t.Fatalf("expect deploy canceled")
+	}
+}
+
+func TestDeployInProcessQueued(t *testing.T) {
+	first, second := &eggov1.Cluster{}, &eggov1.Cluster{}
+	first.Name, first.Namespace = "first-cluster", "default"
+	second.Name, second.Namespace = "second-cluster", "default"
+	var deadline int64 = 1
+	second.Spec.JobActiveDeadlineSeconds = &deadline
+
+	r := newTestReconciler(t)
+	deployer := &fakeDeployer{block: true}
+	r.runInProcess(first, "deploy", func(ctx context.Context) error {
+		return deployer.Deploy(ctx, &api.ClusterConfig{Name: first.Name})
+	})
+	for i := 0; i < 100; i++ {
+		if deployed, _ := deployer.counts(); deployed == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// deploy of second cluster waits for the first one, without counting its deadline
+	ran := make(chan struct{}, 1)
+	run := func(ctx context.Context) error {
+		ran <- struct{}{}
+		return ctx.Err()
+	}
+	task, _ := r.runInProcess(second, "deploy", run)
+	time.Sleep(1500 * time.Millisecond)
+	if _, finished := r.runInProcess(second, "deploy", run); finished {
+		t.Fatalf("expect deploy of second cluster waiting in queue")
+	}
+	if _, running := task.started(); running {
+		t.Fatalf("expect deploy of second cluster not started")
+	}
+
+	// second cluster deploys after the first one finished
+	for i := 0; i < 100 && !r.cancelInProcess(first, "deploy"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	finished := false
+	for i := 0; i < 100 && !finished; i++ {
+		time.Sleep(10 * time.Millisecond)
+		task, finished = r.runInProcess(second, "deploy", run)
+	}
+	if !finished {
+		t.Fatalf("expect deploy of second cluster finished")
+	}
+	if _, err := task.progress(); err != nil {
+		t.Fatalf("expect deploy of second cluster succeeded, get: %v", err)
+	}
+	if len(ran) != 1 {
+		t.Fatalf("expect deploy of second cluster run once")
+	}
+}
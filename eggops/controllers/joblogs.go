@@ -0,0 +1,115 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+const (
+	DefaultJobLogLines int64 = 50
+	// logs are saved in status of cluster, so limit size of it
+	maxJobLogBytes int64 = 16 * 1024
+
+	DefaultJobHistoryLimit = 20
+)
+
+// addJobHistory appends history of job into status of cluster, and trims the oldest ones over limit
+func (r *ClusterReconciler) addJobHistory(cluster *eggov1.Cluster, history *eggov1.JobHistory) {
+	limit := r.JobHistoryLimit
+	if limit <= 0 {
+		limit = DefaultJobHistoryLimit
+	}
+
+	histories := append(cluster.Status.JobHistorys, history)
+	if len(histories) > limit {
+		histories = append([]*eggov1.JobHistory{}, histories[len(histories)-limit:]...)
+	}
+	cluster.Status.JobHistorys = histories
+}
+
+// pickJobPod returns the newest failed pod of job, or the newest pod if none failed
+func pickJobPod(pods []v1.Pod) *v1.Pod {
+	var newest, failed *v1.Pod
+	for i := range pods {
+		p := &pods[i]
+		if newest == nil || newest.CreationTimestamp.Before(&p.CreationTimestamp) {
+			newest = p
+		}
+		if p.Status.Phase == v1.PodFailed && (failed == nil || failed.CreationTimestamp.Before(&p.CreationTimestamp)) {
+			failed = p
+		}
+	}
+	if failed != nil {
+		return failed
+	}
+	return newest
+}
+
+// tailJobLogs keeps the last bytes of logs within limit, partial line at the head is dropped
+func tailJobLogs(logs []byte, limit int64) string {
+	if int64(len(logs)) <= limit {
+		return string(logs)
+	}
+	logs = logs[int64(len(logs))-limit:]
+	if i := bytes.IndexByte(logs, '\n'); i >= 0 {
+		logs = logs[i+1:]
+	}
+	return string(logs)
+}
+
+// get tail logs of eggo container in the failed pod of job,
+// must be called before job is deleted
+func (r *ClusterReconciler) getJobLogs(ctx context.Context, job *batch.Job) string {
+	if r.KubeClient == nil || len(job.Spec.Template.Spec.Containers) == 0 {
+		return ""
+	}
+
+	pods := &v1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		r.Log.Error(err, "list pods of job", "name", job.Name)
+		return ""
+	}
+	pod := pickJobPod(pods.Items)
+	if pod == nil {
+		return ""
+	}
+
+	lines := r.JobLogLines
+	if lines <= 0 {
+		lines = DefaultJobLogLines
+	}
+	// LimitBytes limits logs from the head, not the tail, so cut the tail by ourselves
+	logs, err := r.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &v1.PodLogOptions{
+		Container: job.Spec.Template.Spec.Containers[0].Name,
+		TailLines: &lines,
+	}).DoRaw(ctx)
+	if err != nil {
+		r.Log.Error(err, "get logs of pod", "name", pod.Name)
+		return fmt.Sprintf("get logs of pod %s failed: %v", pod.Name, err)
+	}
+
+	return tailJobLogs(logs, maxJobLogBytes)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+func TestAddJobHistory(t *testing.T) {
+	r := &ClusterReconciler{JobHistoryLimit: 5}
+	cluster := &eggov1.Cluster{}
+	for i := 0; i < 12; i++ {
+		history := &eggov1.JobHistory{Name: fmt.Sprintf("job-%d", i), Succeeded: i%2 == 0}
+		if !history.Succeeded {
+			history.Message = "failed"
+		}
+		r.addJobHistory(cluster, history)
+		if len(cluster.Status.JobHistorys) > 5 {
+			t.Fatalf("expect at most 5 histories, get: %d", len(cluster.Status.JobHistorys))
+		}
+	}
+	for i, h := range cluster.Status.JobHistorys {
+		if h.Name != fmt.Sprintf("job-%d", i+7) || h.Succeeded != ((i+7)%2 == 0) {
+			t.Fatalf("expect newest histories ordered newest-last, get %d: %+v", i, h)
+		}
+	}
+
+	// default limit
+	r = &ClusterReconciler{}
+	cluster = &eggov1.Cluster{}
+	for i := 0; i < 100; i++ {
+		r.addJobHistory(cluster, &eggov1.JobHistory{Name: fmt.Sprintf("job-%d", i), Succeeded: true})
+	}
+	histories := cluster.Status.JobHistorys
+	if len(histories) != DefaultJobHistoryLimit || histories[len(histories)-1].Name != "job-99" {
+		t.Fatalf("expect %d histories with newest last, get: %d", DefaultJobHistoryLimit, len(histories))
+	}
+}
+
+func TestPickJobPod(t *testing.T) {
+	now := time.Now()
+	newPod := func(name string, phase v1.PodPhase, age time.Duration) v1.Pod {
+		p := v1.Pod{}
+		p.Name = name
+		p.CreationTimestamp = metav1.NewTime(now.Add(-age))
+		p.Status.Phase = phase
+		return p
+	}
+
+	if pickJobPod(nil) != nil {
+		t.Fatalf("expect no pod picked")
+	}
+	pods := []v1.Pod{
+		newPod("failed-new", v1.PodFailed, time.Minute),
+		newPod("running", v1.PodRunning, 0),
+		newPod("failed-old", v1.PodFailed, time.Hour),
+	}
+	if p := pickJobPod(pods); p.Name != "failed-new" {
+		t.Fatalf("expect the newest failed pod, get: %s", p.Name)
+	}
+	if p := pickJobPod(pods[1:2]); p.Name != "running" {
+		t.Fatalf("expect the newest pod if none failed, get: %s", p.Name)
+	}
+}
+
+func TestTailJobLogs(t *testing.T) {
+	logs := "line1\nline2\nline3\n"
+	if got := tailJobLogs([]byte(logs), 100); got != logs {
+		t.Fatalf("expect logs not cut, get: %q", got)
+	}
+	// partial line at head is dropped, the tail is kept
+	if got := tailJobLogs([]byte(logs), 8); got != "line3\n" {
+		t.Fatalf("expect tail of logs, get: %q", got)
+	}
+	if got := tailJobLogs([]byte(strings.Repeat("x", 10)), 4); got != "xxxx" {
+		t.Fatalf("expect tail of long line, get: %q", got)
+	}
+}
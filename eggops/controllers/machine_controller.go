@@ -18,55 +18,109 @@ package controllers
 
 import (
 	"context"
-	"fmt"
+	"net"
+	"strconv"
+	"time"
 
 	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	eggov1 "isula.org/eggo/eggops/api/v1"
 )
 
+const (
+	DefaultMachineProbeInterval = time.Minute
+	// timeout of dialing ssh port of machine
+	machineProbeTimeout = 5 * time.Second
+)
+
+// MachineProber checks whether machine is reachable
+type MachineProber func(ctx context.Context, machine *eggov1.Machine) error
+
+// probeMachineSSH dials ssh port of machine
+func probeMachineSSH(ctx context.Context, machine *eggov1.Machine) error {
+	port := 22
+	if machine.Spec.Port != nil {
+		port = int(*machine.Spec.Port)
+	}
+	ctx, cancel := context.WithTimeout(ctx, machineProbeTimeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(machine.Spec.IP, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
 // MachineReconciler reconciles a Machine object
 type MachineReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	Log    logr.Logger
+	// interval to probe machines, default is DefaultMachineProbeInterval
+	ProbeInterval time.Duration
+	// probe reachability of machine, default dials ssh port of machine
+	Probe MachineProber
 }
 
 //+kubebuilder:rbac:groups=eggo.isula.org,resources=machines,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=eggo.isula.org,resources=machines/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=eggo.isula.org,resources=machines/finalizers,verbs=update
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the Machine object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.8.3/pkg/reconcile
+// Reconcile probes reachability of machine periodically, and records health of it into status,
+// so that unhealthy machines are not selected by clusters
 func (r *MachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
 	r.Log = log
 
-	// your logic here
 	var machine eggov1.Machine
 	if err := r.Get(ctx, req.NamespacedName, &machine); err != nil {
 		log.Error(err, "unable to get machine")
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
-	log.Info(fmt.Sprintf("get machine: %v", machine.Spec))
 
-	return ctrl.Result{}, nil
+	interval := r.ProbeInterval
+	if interval <= 0 {
+		interval = DefaultMachineProbeInterval
+	}
+	probe := r.Probe
+	if probe == nil {
+		probe = probeMachineSSH
+	}
+
+	base := machine.DeepCopy()
+	now := metav1.Now()
+	machine.Status.LastProbeTime = &now
+	if err := probe(ctx, &machine); err != nil {
+		if machine.Status.Health != eggov1.MachineUnhealthy {
+			log.Info("machine becomes unhealthy", "machine", machine.Name, "error", err.Error())
+		}
+		machine.Status.Health = eggov1.MachineUnhealthy
+		machine.Status.ErrorMessage = err.Error()
+	} else {
+		machine.Status.Health = eggov1.MachineHealthy
+		machine.Status.ErrorMessage = ""
+	}
+
+	if err := r.Status().Patch(ctx, &machine, client.MergeFrom(base)); err != nil {
+		log.Error(err, "unable to update status of machine")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: interval}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *MachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// update of status does not change generation, avoid probing again on it
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&eggov1.Machine{}).
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
 		Complete(r)
 }
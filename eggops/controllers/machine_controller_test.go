@@ -0,0 +1,110 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+func newTestMachine(name, ip string) *eggov1.Machine {
+	m := &eggov1.Machine{}
+	m.Name, m.Namespace = name, "default"
+	m.Spec = eggov1.MachineSpec{HostName: name, IP: ip, Arch: "amd64"}
+	return m
+}
+
+func TestMachineReconcile(t *testing.T) {
+	ctx := context.Background()
+	healthy, unhealthy := newTestMachine("machine0", "192.168.0.1"), newTestMachine("machine1", "192.168.0.2")
+	cr := newTestReconciler(t, healthy, unhealthy)
+
+	down := map[string]bool{"192.168.0.2": true}
+	mr := &MachineReconciler{
+		Client:        cr.Client,
+		Scheme:        cr.Scheme,
+		ProbeInterval: 10 * time.Second,
+		Probe: func(ctx context.Context, m *eggov1.Machine) error {
+			if down[m.Spec.IP] {
+				return fmt.Errorf("dial %s timeout", m.Spec.IP)
+			}
+			return nil
+		},
+	}
+	reconcileMachines := func() {
+		for _, m := range []*eggov1.Machine{healthy, unhealthy} {
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: m.Name, Namespace: m.Namespace}}
+			res, err := mr.Reconcile(ctx, req)
+			if err != nil || res.RequeueAfter != mr.ProbeInterval {
+				t.Fatalf("reconcile machine %s failed: %v, %v", m.Name, res, err)
+			}
+		}
+	}
+	health := func(name string) eggov1.MachineStatus {
+		var m eggov1.Machine
+		if err := cr.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, &m); err != nil {
+			t.Fatalf("get machine %s failed: %v", name, err)
+		}
+		if m.Status.LastProbeTime == nil {
+			t.Fatalf("probe time of machine %s is not recorded", name)
+		}
+		return m.Status
+	}
+
+	reconcileMachines()
+	if s := health("machine0"); s.Health != eggov1.MachineHealthy || s.ErrorMessage != "" {
+		t.Fatalf("expect machine0 healthy, get: %v", s)
+	}
+	if s := health("machine1"); s.Health != eggov1.MachineUnhealthy || s.ErrorMessage == "" {
+		t.Fatalf("expect machine1 unhealthy, get: %v", s)
+	}
+
+	// unhealthy machine is not selected
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", "default"
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 1}
+	masters, _, _, err := cr.filterMachines(ctx, cluster)
+	if err != nil || len(masters) != 1 || masters[0].Name != "machine0" {
+		t.Fatalf("expect healthy machine0 selected, get: %v, %v", eggov1.PrintMachineSlice(masters), err)
+	}
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 2}
+	if _, _, _, err = cr.filterMachines(ctx, cluster); !errors.Is(err, ErrInsufficientMachines) {
+		t.Fatalf("expect insufficient machines with unhealthy one, get: %v", err)
+	}
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 1, MachineNames: []string{"machine1"}}
+	if _, _, _, err = cr.filterMachines(ctx, cluster); !errors.Is(err, ErrMachineUnhealthy) || !isWaitingError(err) {
+		t.Fatalf("expect waiting for pinned unhealthy machine, get: %v", err)
+	}
+
+	// machine recovers
+	delete(down, "192.168.0.2")
+	reconcileMachines()
+	if s := health("machine1"); s.Health != eggov1.MachineHealthy || s.ErrorMessage != "" {
+		t.Fatalf("expect machine1 recovered, get: %v", s)
+	}
+	if masters, _, _, err = cr.filterMachines(ctx, cluster); err != nil || len(masters) != 1 {
+		t.Fatalf("expect recovered machine1 selected, get: %v", err)
+	}
+}
@@ -0,0 +1,421 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v1"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/reference"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"isula.org/eggo/cmd"
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+const (
+	// uids of machines which are joined or cleanup by job
+	JobMachinesAnnotation = "eggo.isula.org/machines"
+	// operation of job: join or cleanup
+	JobOperationAnnotation = "eggo.isula.org/operation"
+
+	JobOperationJoin    = "join"
+	JobOperationCleanup = "cleanup"
+)
+
+type usageMachine struct {
+	usage   int32
+	machine eggov1.Machine
+}
+
+type membershipDelta struct {
+	removed []usageMachine
+	joined  []usageMachine
+}
+
+func (d membershipDelta) empty() bool {
+	return len(d.removed) == 0 && len(d.joined) == 0
+}
+
+// compare machines binded to cluster with machines selected by features of cluster:
+// binded machine which is removed or not match features any more should be cleanup,
+// binded machine whose cleanup is not finished should be cleanup again,
+// surplus workers should be cleanup if binded workers is more than required,
+// and new machines should be joined if binded machines is less than required
+func (r *ClusterReconciler) diffMembership(ctx context.Context, cluster *eggov1.Cluster, mb *eggov1.MachineBinding) (membershipDelta, error) {
+	delta := membershipDelta{}
+	machineBinded, err := r.unavailableMachines(ctx, cluster)
+	if err != nil {
+		return delta, err
+	}
+
+	requires := []struct {
+		usage   int32
+		require eggov1.RequireMachineConfig
+	}{
+		{eggov1.UsageMaster, cluster.Spec.MasterRequire},
+		{eggov1.UsageWorker, cluster.Spec.WorkerRequire},
+	}
+	// machines selected to join in this round, avoid to select one machine twice
+	selectedToJoin := make(map[string]bool)
+	for _, req := range requires {
+		selected, err := r.labelSelectMachines(ctx, cluster.Namespace, req.require)
+		if err != nil {
+			return delta, err
+		}
+
+		var kept []*eggov1.Machine
+		var leaving []usageMachine
+		binded := mb.GetMachines(req.usage)
+		for _, m := range binded {
+			if mb.IsRemoving(string(m.UID)) {
+				// cleanup of machine is not finished, retry it
+				leaving = append(leaving, usageMachine{usage: req.usage, machine: *m})
+				continue
+			}
+			if sm, ok := selected[m.Name]; ok && sm.UID == m.UID {
+				if !sm.Spec.Maintenance {
+					kept = append(kept, m)
+					continue
+				}
+				// drain and remove machine in maintenance, new machine is joined to replace it in next round
+				r.Log.Info(fmt.Sprintf("machine %s in maintenance is removed", m.Name), "name", cluster.Name)
+			}
+			leaving = append(leaving, usageMachine{usage: req.usage, machine: *m})
+		}
+		if req.usage == eggov1.UsageMaster && !mastersRemovable(len(binded), len(leaving)) {
+			// keep masters binded, and join new masters to replace them, then remove them in later round
+			r.refuseRemoveMasters(cluster, len(binded), leaving)
+			leaving = nil
+		}
+		delta.removed = append(delta.removed, leaving...)
+
+		// scale down workers only, removing masters may break control plane and etcd
+		surplus := len(kept) - int(req.require.Number)
+		if surplus > 0 && req.usage == eggov1.UsageWorker {
+			// binded machines are in order of joining, remove the latest joined ones
+			var names []string
+			for _, m := range kept[len(kept)-surplus:] {
+				names = append(names, m.Name)
+				delta.removed = append(delta.removed, usageMachine{usage: req.usage, machine: *m})
+			}
+			r.Recorder.Eventf(cluster, v1.EventTypeNormal, ReasonScaleDownWorkers, "scale down workers %s joined latest", strings.Join(names, ","))
+			continue
+		}
+
+		need := int(req.require.Number) - len(kept)
+		if need <= 0 {
+			continue
+		}
+		var candidates []eggov1.Machine
+		for name, m := range selected {
+			if !machineBinded[name] && !selectedToJoin[name] && machineSchedulable(&m) {
+				candidates = append(candidates, m)
+			}
+		}
+		if len(candidates) < need {
+			r.Log.Info(fmt.Sprintf("%s require %d machines, but only %d machines available", getUsageName(req.usage), need, len(candidates)), "name", cluster.Name)
+			need = len(candidates)
+		}
+		// spread machines across domains, together with kept machines
+		var placed []eggov1.Machine
+		for _, m := range kept {
+			placed = append(placed, *m)
+		}
+		joined := pickMachines(candidates, nil, need, req.require.AntiAffinity, placed)
+		for _, m := range joined {
+			selectedToJoin[m.Name] = true
+			delta.joined = append(delta.joined, usageMachine{usage: req.usage, machine: m})
+		}
+		r.warnNotSpread(cluster, getUsageName(req.usage), req.require.AntiAffinity, append(placed, joined...))
+	}
+
+	return delta, nil
+}
+
+// masters are members of etcd, removing them is allowed only if the remaining masters keep quorum of etcd,
+// leaving masters may be unreachable already, so they are not counted as alive
+func mastersRemovable(binded, leaving int) bool {
+	return binded-leaving >= binded/2+1
+}
+
+func (r *ClusterReconciler) refuseRemoveMasters(cluster *eggov1.Cluster, binded int, leaving []usageMachine) {
+	var names []string
+	for _, um := range leaving {
+		names = append(names, um.machine.Name)
+	}
+	msg := fmt.Sprintf("refuse to remove masters %s: %d of %d masters remain, etcd quorum requires %d, join new masters first",
+		strings.Join(names, ","), binded-len(leaving), binded, binded/2+1)
+	r.Log.Info(msg, "name", cluster.Name)
+	r.Recorder.Event(cluster, v1.EventTypeWarning, ReasonRemoveMastersRefused, msg)
+	cluster.Status.Message = msg
+}
+
+func getUsageName(usage int32) string {
+	if usage == eggov1.UsageMaster {
+		return "master"
+	}
+	return "worker"
+}
+
+func machineUIDs(ums []usageMachine) string {
+	var uids []string
+	for _, um := range ums {
+		uids = append(uids, string(um.machine.UID))
+	}
+	return strings.Join(uids, ",")
+}
+
+// generate config of machines to join, used by "eggo join -f"
+func toJoinConfig(cluster *eggov1.Cluster, joined []usageMachine) ([]byte, error) {
+	conf := cmd.DeployConfig{ClusterID: cluster.GetName()}
+	for _, um := range joined {
+		hosts := toEggoHosts([]*eggov1.Machine{&um.machine})
+		if um.usage == eggov1.UsageMaster {
+			conf.Masters = append(conf.Masters, hosts...)
+		} else {
+			conf.Workers = append(conf.Workers, hosts...)
+		}
+	}
+	return yaml.Marshal(conf)
+}
+
+// update cluster config and join config in configmap, which will be used by job
+func (r *ClusterReconciler) updateEggoConfig(ctx context.Context, cluster *eggov1.Cluster, mb *eggov1.MachineBinding, joinData []byte) error {
+	if _, err := r.syncEggoConfig(ctx, cluster, mb); err != nil {
+		return err
+	}
+
+	cm := &v1.ConfigMap{}
+	if err := r.Get(ctx, ReferenceToNamespacedName(cluster.Status.ConfigRef), cm); err != nil {
+		return err
+	}
+	if joinData != nil {
+		cm.BinaryData[eggov1.ClusterConfigMapJoinConfKey] = joinData
+	} else if _, ok := cm.BinaryData[eggov1.ClusterConfigMapJoinConfKey]; ok {
+		delete(cm.BinaryData, eggov1.ClusterConfigMapJoinConfKey)
+	} else {
+		return nil
+	}
+	return r.Update(ctx, cm)
+}
+
+func (r *ClusterReconciler) createMembershipJob(ctx context.Context, cluster *eggov1.Cluster, operation string, ums []usageMachine) error {
+	packagePVC := v1.PersistentVolumeClaim{}
+	err := r.Get(ctx, ReferenceToNamespacedName(cluster.Status.PackagePersistentVolumeClaimRef), &packagePVC)
+	if err != nil {
+		r.Log.Error(err, "get package persistent volume claim for cluster", "name", cluster.Name)
+		return err
+	}
+
+	cmName := fmt.Sprintf(eggov1.ClusterConfigMapNameFormat, cluster.Name, "cmd-config")
+	configPath := fmt.Sprintf(eggov1.EggoConfigVolumeFormat, cluster.Name)
+	jobName := fmt.Sprintf("%s-%s-job-%d", cluster.Name, operation, time.Now().Unix())
+	var command []string
+	if operation == JobOperationJoin {
+		command = []string{"eggo", "-d", "join", "--id", cluster.Name, "-f", filepath.Join(configPath, eggov1.ClusterConfigMapJoinConfKey)}
+	} else {
+		// masters to remove are checked to keep quorum of etcd, so force to delete master
+		command = []string{"eggo", "-d", "delete", "--id", cluster.Name, "--force"}
+		if cluster.Spec.DrainGracePeriodSeconds != nil {
+			command = append(command, "--grace-period", strconv.Itoa(int(*cluster.Spec.DrainGracePeriodSeconds)))
+		}
+		for _, um := range ums {
+			command = append(command, "--node", um.machine.Spec.IP)
+		}
+	}
+	job := createEggoJobConfig(GetWorkspaceNamespace(cluster), jobName, fmt.Sprintf("eggo-%s-nodes", operation), GetEggoImage(cluster), configPath, cmName,
+		fmt.Sprintf(eggov1.PackageVolumeFormat, cluster.Name), packagePVC.Name, command)
+	job.Annotations[JobOperationAnnotation] = operation
+	job.Annotations[JobMachinesAnnotation] = machineUIDs(ums)
+
+	if err = fillEggoJobConfig(r, ctx, cluster, job); err != nil {
+		r.Log.Error(err, "fill eggo job config", "name", cluster.Name)
+		return err
+	}
+	if err = r.Create(ctx, job); err != nil {
+		return err
+	}
+	r.Recorder.Eventf(cluster, v1.EventTypeNormal, ReasonJobStarted, "job %s to %s nodes started", jobName, operation)
+
+	cluster.Status.JobRef, err = reference.GetReference(r.Scheme, job)
+	return err
+}
+
+// wait job of cluster finish, then record history and remove it,
+// machines are unbinded only if they are cleanup successfully
+func (r *ClusterReconciler) finishMembershipJob(ctx context.Context, cluster *eggov1.Cluster) (bool, error) {
+	job := &batch.Job{}
+	err := r.Get(ctx, ReferenceToNamespacedName(cluster.Status.JobRef), job)
+	if err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			// job is removed by others, just ignore it
+			cluster.Status.JobRef = nil
+		}
+		return false, err
+	}
+
+	finish, jobErr := jobIsFinished(job)
+	if !finish {
+		return false, nil
+	}
+
+	history := &eggov1.JobHistory{
+		Name:      job.GetName(),
+		StartTime: job.GetCreationTimestamp(),
+		Succeeded: jobErr == nil,
+	}
+	result := "success"
+	if jobErr != nil {
+		result = jobErr.Error()
+		history.Message = result
+		history.Logs = r.getJobLogs(ctx, job)
+		r.Recorder.Event(cluster, v1.EventTypeWarning, ReasonJobFailed, jobErr.Error())
+	} else {
+		r.Recorder.Eventf(cluster, v1.EventTypeNormal, ReasonJobSucceeded, "job %s succeeded", job.GetName())
+	}
+	background := metav1.DeletePropagationBackground
+	if err = r.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &background}); client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+	r.addJobHistory(cluster, history)
+	cluster.Status.JobRef = nil
+
+	// create job is not membership job, nothing need to update
+	operation := job.Annotations[JobOperationAnnotation]
+	if operation == "" {
+		return true, nil
+	}
+
+	mb := &eggov1.MachineBinding{}
+	if err = r.Get(ctx, ReferenceToNamespacedName(cluster.Status.MachineBindingRef), mb); err != nil {
+		return true, err
+	}
+	for _, uid := range strings.Split(job.Annotations[JobMachinesAnnotation], ",") {
+		usage, ok := mb.Spec.Usages[uid]
+		if !ok {
+			continue
+		}
+		if operation == JobOperationJoin && jobErr != nil {
+			// release machines failed to join, and retry with other machines in next round
+			mb.RemoveMachine(uid)
+			continue
+		}
+		if operation == JobOperationCleanup {
+			if jobErr == nil {
+				// machines are not members of cluster any more, they are released in next round
+				mb.RemoveMachine(uid)
+			} else {
+				// keep machines binded and reserved, cleanup is retried in next round
+				mb.MarkRemoving(uid, result)
+			}
+			continue
+		}
+		mb.UpdateCondition(eggov1.MachineCondition{UsagesStatus: usage, Message: result}, uid)
+	}
+	cluster.Status.Message = fmt.Sprintf("%s nodes job: %s", operation, result)
+
+	return true, r.Update(ctx, mb)
+}
+
+// reconcileMembership keep machines of cluster match with spec of cluster
+func (r *ClusterReconciler) reconcileMembership(ctx context.Context, cluster *eggov1.Cluster) (ctrl.Result, error) {
+	// Step 1: wait running job of cluster
+	if cluster.Status.JobRef != nil {
+		finish, err := r.finishMembershipJob(ctx, cluster)
+		if err != nil {
+			r.Log.Error(err, "finish job of cluster", "name", cluster.Name)
+			return r.requeueNotReady(cluster), nil
+		}
+		if !finish {
+			return r.requeueNotReady(cluster), nil
+		}
+		r.forgetBackoff(cluster)
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// Step 2: compare binded machines with required machines
+	mb := &eggov1.MachineBinding{}
+	if err := r.Get(ctx, ReferenceToNamespacedName(cluster.Status.MachineBindingRef), mb); err != nil {
+		return ctrl.Result{}, err
+	}
+	delta, err := r.diffMembership(ctx, cluster, mb)
+	if err != nil {
+		return r.requeueNotReady(cluster), err
+	}
+	if delta.empty() {
+		// machines cleanup or failed to join are not binded any more, release them
+		if err = r.releaseMachines(ctx, cluster, bindingMachineNames(mb)); err != nil {
+			return r.requeueNotReady(cluster), err
+		}
+		// spec of cluster maybe changed by user
+		if _, err = r.syncEggoConfig(ctx, cluster, mb); err != nil {
+			return r.requeueNotReady(cluster), err
+		}
+		r.forgetBackoff(cluster)
+		return ctrl.Result{}, nil
+	}
+
+	// Step 3: update machine binding, config and run job, cleanup removed machines before join new machines,
+	// removed machines are kept binded until cleanup job succeeded, so they are not taken by others
+	operation, ums := JobOperationCleanup, delta.removed
+	var joinData []byte
+	if len(delta.removed) != 0 {
+		for _, um := range delta.removed {
+			mb.MarkRemoving(string(um.machine.UID), "removing")
+		}
+	} else {
+		operation, ums = JobOperationJoin, delta.joined
+		var joined []eggov1.Machine
+		for _, um := range delta.joined {
+			joined = append(joined, um.machine)
+		}
+		if err = r.reserveMachines(ctx, cluster, joined); err != nil {
+			return r.requeueOnError(cluster, err)
+		}
+		for _, um := range delta.joined {
+			mb.AddMachine(um.machine, um.usage)
+			mb.UpdateCondition(eggov1.MachineCondition{UsagesStatus: mb.Spec.Usages[string(um.machine.UID)], Message: "joining"}, string(um.machine.UID))
+		}
+		if joinData, err = toJoinConfig(cluster, delta.joined); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	r.Log.Info(fmt.Sprintf("%s machines: %s", operation, machineUIDs(ums)), "name", cluster.Name)
+
+	if err = r.Update(ctx, mb); err != nil {
+		return r.requeueNotReady(cluster), err
+	}
+	cluster.Status.Nodes = int32(len(mb.Spec.Usages))
+	if err = r.updateEggoConfig(ctx, cluster, mb, joinData); err != nil {
+		return r.requeueNotReady(cluster), err
+	}
+	if err = r.createMembershipJob(ctx, cluster, operation, ums); err != nil {
+		return r.requeueNotReady(cluster), err
+	}
+
+	return r.requeueProgress(cluster), nil
+}
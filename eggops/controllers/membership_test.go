@@ -0,0 +1,380 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+func newRunningCluster(t *testing.T) (*ClusterReconciler, *eggov1.Cluster, map[string]*eggov1.Machine) {
+	ns := "default"
+	port := int32(22)
+	machines := make(map[string]*eggov1.Machine)
+	mb := &eggov1.MachineBinding{}
+	mb.Name, mb.Namespace = "machinebind-test-cluster", ns
+	objs := []client.Object{mb}
+	for i, name := range []string{"master0", "worker0", "worker1", "worker2"} {
+		m := &eggov1.Machine{}
+		m.Name, m.Namespace, m.UID = name, ns, types.UID("uid-"+name)
+		m.Spec = eggov1.MachineSpec{HostName: name, IP: fmt.Sprintf("192.168.0.%d", i+1), Port: &port, Arch: "amd64"}
+		machines[name] = m
+		objs = append(objs, m)
+	}
+	mb.AddMachine(*machines["master0"], eggov1.UsageMaster)
+	mb.AddMachine(*machines["worker0"], eggov1.UsageWorker)
+
+	pvc := &v1.PersistentVolumeClaim{}
+	pvc.Name, pvc.Namespace = "packages", ns
+	infra := &eggov1.Infrastructure{}
+	infra.Name, infra.Namespace = "infra", ns
+	secret := &v1.Secret{
+		Type: v1.SecretTypeBasicAuth,
+		Data: map[string][]byte{v1.BasicAuthUsernameKey: []byte("root"), v1.BasicAuthPasswordKey: []byte("123456")},
+	}
+	secret.Name, secret.Namespace = "login", ns
+	cm := &v1.ConfigMap{}
+	cm.Name, cm.Namespace = "test-cluster-cmd-config", ns
+
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", ns
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 1}
+	cluster.Spec.WorkerRequire = eggov1.RequireMachineConfig{Number: 1}
+	cluster.Status = eggov1.ClusterStatus{
+		MachineLoginSecretRef:           &v1.ObjectReference{Name: secret.Name, Namespace: ns},
+		InfrastructureRef:               &v1.ObjectReference{Name: infra.Name, Namespace: ns},
+		PackagePersistentVolumeClaimRef: &v1.ObjectReference{Name: pvc.Name, Namespace: ns},
+		MachineBindingRef:               &v1.ObjectReference{Name: mb.Name, Namespace: ns},
+		ConfigRef:                       &v1.ObjectReference{Name: cm.Name, Namespace: ns},
+		HasCluster:                      true,
+	}
+	objs = append(objs, pvc, infra, secret, cm)
+
+	return newTestReconciler(t, objs...), cluster, machines
+}
+
+// run reconcile of membership until job created, and return the job
+func reconcileMembershipJob(t *testing.T, r *ClusterReconciler, cluster *eggov1.Cluster) *batch.Job {
+	if _, err := r.reconcileMembership(context.Background(), cluster); err != nil {
+		t.Fatalf("reconcile membership failed: %v", err)
+	}
+	if cluster.Status.JobRef == nil {
+		t.Fatalf("expect membership job created")
+	}
+	job := &batch.Job{}
+	if err := r.Get(context.Background(), ReferenceToNamespacedName(cluster.Status.JobRef), job); err != nil {
+		t.Fatalf("get membership job failed: %v", err)
+	}
+	return job
+}
+
+// make job succeeded, and wait reconcile of membership finish it
+func finishMembershipJob(t *testing.T, r *ClusterReconciler, cluster *eggov1.Cluster, job *batch.Job) {
+	ctx := context.Background()
+	job.Status.Conditions = []batch.JobCondition{{Type: batch.JobComplete, Status: v1.ConditionTrue}}
+	if err := r.Status().Update(ctx, job); err != nil {
+		t.Fatalf("update status of job failed: %v", err)
+	}
+	if _, err := r.reconcileMembership(ctx, cluster); err != nil {
+		t.Fatalf("finish membership job failed: %v", err)
+	}
+	if cluster.Status.JobRef != nil {
+		t.Fatalf("expect ref of finished job removed")
+	}
+}
+
+func bindedMachines(t *testing.T, r *ClusterReconciler, cluster *eggov1.Cluster, usage int32) []string {
+	mb := &eggov1.MachineBinding{}
+	if err := r.Get(context.Background(), ReferenceToNamespacedName(cluster.Status.MachineBindingRef), mb); err != nil {
+		t.Fatalf("get machine binding failed: %v", err)
+	}
+	var names []string
+	for _, m := range mb.GetMachines(usage) {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+func bindedWorkers(t *testing.T, r *ClusterReconciler, cluster *eggov1.Cluster) []string {
+	return bindedMachines(t, r, cluster, eggov1.UsageWorker)
+}
+
+// hasEvent returns true if event of reason is recorded, recorded events are consumed
+func hasEvent(r *ClusterReconciler, reason string) bool {
+	found := false
+	for {
+		select {
+		case e := <-r.Recorder.(*record.FakeRecorder).Events:
+			found = found || strings.Contains(e, reason)
+		default:
+			return found
+		}
+	}
+}
+
+func TestScaleWorkers(t *testing.T) {
+	r, cluster, machines := newRunningCluster(t)
+	ctx := context.Background()
+
+	// nothing to do if binded machines match required
+	if _, err := r.reconcileMembership(ctx, cluster); err != nil || cluster.Status.JobRef != nil {
+		t.Fatalf("expect no job for unchanged cluster, err: %v", err)
+	}
+
+	// scale up: join only the new worker
+	cluster.Spec.WorkerRequire.Number = 2
+	job := reconcileMembershipJob(t, r, cluster)
+	if job.Annotations[JobOperationAnnotation] != JobOperationJoin ||
+		job.Annotations[JobMachinesAnnotation] != string(machines["worker1"].UID) {
+		t.Fatalf("expect join job for worker1, get annotations: %v", job.Annotations)
+	}
+	if workers := bindedWorkers(t, r, cluster); strings.Join(workers, ",") != "worker0,worker1" {
+		t.Fatalf("expect worker0 and worker1 binded, get: %v", workers)
+	}
+	cm := &v1.ConfigMap{}
+	if err := r.Get(ctx, ReferenceToNamespacedName(cluster.Status.ConfigRef), cm); err != nil {
+		t.Fatalf("get configmap failed: %v", err)
+	}
+	joinConf := string(cm.BinaryData[eggov1.ClusterConfigMapJoinConfKey])
+	if !strings.Contains(joinConf, machines["worker1"].Spec.IP) || strings.Contains(joinConf, machines["worker0"].Spec.IP) {
+		t.Fatalf("expect join config only contains worker1, get: %s", joinConf)
+	}
+	if !strings.Contains(string(cm.BinaryData[eggov1.ClusterConfigMapBinaryConfKey]), machines["worker1"].Spec.IP) {
+		t.Fatalf("expect cluster config regenerated with worker1")
+	}
+	finishMembershipJob(t, r, cluster, job)
+	if _, err := r.reconcileMembership(ctx, cluster); err != nil || cluster.Status.JobRef != nil {
+		t.Fatalf("expect no job after scale up, err: %v", err)
+	}
+
+	// scale down: cleanup the surplus worker
+	cluster.Spec.WorkerRequire.Number = 1
+	job = reconcileMembershipJob(t, r, cluster)
+	if job.Annotations[JobOperationAnnotation] != JobOperationCleanup ||
+		job.Annotations[JobMachinesAnnotation] != string(machines["worker1"].UID) {
+		t.Fatalf("expect cleanup job for worker1, get annotations: %v", job.Annotations)
+	}
+	command := strings.Join(job.Spec.Template.Spec.Containers[0].Command, " ")
+	if !strings.Contains(command, "--node "+machines["worker1"].Spec.IP) || strings.Contains(command, machines["worker0"].Spec.IP) {
+		t.Fatalf("expect cleanup worker1 only, get command: %s", command)
+	}
+	// worker1 is kept binded until it is cleanup
+	if workers := bindedWorkers(t, r, cluster); strings.Join(workers, ",") != "worker0,worker1" {
+		t.Fatalf("expect worker0 and worker1 binded, get: %v", workers)
+	}
+	finishMembershipJob(t, r, cluster, job)
+	if workers := bindedWorkers(t, r, cluster); strings.Join(workers, ",") != "worker0" {
+		t.Fatalf("expect worker0 binded, get: %v", workers)
+	}
+
+	// masters are not scaled down
+	cluster.Spec.MasterRequire.Number = 0
+	if _, err := r.reconcileMembership(ctx, cluster); err != nil || cluster.Status.JobRef != nil {
+		t.Fatalf("expect no job to scale down masters, err: %v", err)
+	}
+}
+
+func TestScaleDownLatestJoinedWorkers(t *testing.T) {
+	r, cluster, machines := newRunningCluster(t)
+	ctx := context.Background()
+
+	// worker2 joined before worker0
+	mb := &eggov1.MachineBinding{}
+	if err := r.Get(ctx, ReferenceToNamespacedName(cluster.Status.MachineBindingRef), mb); err != nil {
+		t.Fatalf("get machine binding failed: %v", err)
+	}
+	mb.RemoveMachine(string(machines["worker0"].UID))
+	mb.AddMachine(*machines["worker2"], eggov1.UsageWorker)
+	mb.AddMachine(*machines["worker0"], eggov1.UsageWorker)
+	if err := r.Update(ctx, mb); err != nil {
+		t.Fatalf("update machine binding failed: %v", err)
+	}
+
+	grace := int32(30)
+	cluster.Spec.DrainGracePeriodSeconds = &grace
+	job := reconcileMembershipJob(t, r, cluster)
+	if job.Annotations[JobMachinesAnnotation] != string(machines["worker0"].UID) {
+		t.Fatalf("expect cleanup the latest joined worker0, get annotations: %v", job.Annotations)
+	}
+	if command := strings.Join(job.Spec.Template.Spec.Containers[0].Command, " "); !strings.Contains(command, "--grace-period 30") {
+		t.Fatalf("expect drain grace period in command, get: %s", command)
+	}
+	if !hasEvent(r, ReasonScaleDownWorkers) {
+		t.Fatalf("expect event of workers to scale down")
+	}
+}
+
+func TestReplaceMachineInMaintenance(t *testing.T) {
+	r, cluster, machines := newRunningCluster(t)
+	ctx := context.Background()
+
+	// mark binded worker into maintenance
+	worker0 := &eggov1.Machine{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "worker0", Namespace: "default"}, worker0); err != nil {
+		t.Fatalf("get worker0 failed: %v", err)
+	}
+	worker0.Spec.Maintenance = true
+	if err := r.Update(ctx, worker0); err != nil {
+		t.Fatalf("update worker0 failed: %v", err)
+	}
+
+	// drain and cleanup the machine in maintenance first
+	job := reconcileMembershipJob(t, r, cluster)
+	if job.Annotations[JobOperationAnnotation] != JobOperationCleanup ||
+		job.Annotations[JobMachinesAnnotation] != string(machines["worker0"].UID) {
+		t.Fatalf("expect cleanup job for worker0, get annotations: %v", job.Annotations)
+	}
+	finishMembershipJob(t, r, cluster, job)
+	if workers := bindedWorkers(t, r, cluster); len(workers) != 0 {
+		t.Fatalf("expect worker0 removed, get: %v", workers)
+	}
+
+	// then replace it with a free machine
+	job = reconcileMembershipJob(t, r, cluster)
+	if job.Annotations[JobOperationAnnotation] != JobOperationJoin ||
+		job.Annotations[JobMachinesAnnotation] != string(machines["worker1"].UID) {
+		t.Fatalf("expect join job for worker1, get annotations: %v", job.Annotations)
+	}
+	finishMembershipJob(t, r, cluster, job)
+	if workers := bindedWorkers(t, r, cluster); strings.Join(workers, ",") != "worker1" {
+		t.Fatalf("expect worker1 replaces worker0, get: %v", workers)
+	}
+	if _, err := r.reconcileMembership(ctx, cluster); err != nil || cluster.Status.JobRef != nil {
+		t.Fatalf("expect no job after replacement, err: %v", err)
+	}
+
+	// machine in maintenance is not selected, and pinned one is waited
+	require := eggov1.RequireMachineConfig{Number: 1, MachineNames: []string{"worker0"}}
+	if _, err := r.availableSelectMachines(ctx, "default", require, nil); !errors.Is(err, ErrMachineInMaintenance) || !isWaitingError(err) {
+		t.Fatalf("expect waiting for machine in maintenance, get: %v", err)
+	}
+	require = eggov1.RequireMachineConfig{Number: 1}
+	available, err := r.availableSelectMachines(ctx, "default", require, nil)
+	if err != nil {
+		t.Fatalf("select machines failed: %v", err)
+	}
+	if _, ok := available["worker0"]; ok {
+		t.Fatalf("expect worker0 in maintenance not available, get: %v", available)
+	}
+}
+
+func TestRefuseRemoveMastersBreakingQuorum(t *testing.T) {
+	r, cluster, machines := newRunningCluster(t)
+	ctx := context.Background()
+
+	master0 := &eggov1.Machine{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "master0", Namespace: "default"}, master0); err != nil {
+		t.Fatalf("get master0 failed: %v", err)
+	}
+	master0.Spec.Maintenance = true
+	if err := r.Update(ctx, master0); err != nil {
+		t.Fatalf("update master0 failed: %v", err)
+	}
+
+	// the only master is kept, and a new master is joined to replace it
+	job := reconcileMembershipJob(t, r, cluster)
+	if job.Annotations[JobOperationAnnotation] != JobOperationJoin ||
+		strings.Contains(job.Annotations[JobMachinesAnnotation], string(machines["master0"].UID)) {
+		t.Fatalf("expect join job for new master, get annotations: %v", job.Annotations)
+	}
+	if !hasEvent(r, ReasonRemoveMastersRefused) || !strings.Contains(cluster.Status.Message, "refuse to remove masters master0") {
+		t.Fatalf("expect removal of master0 refused, get message: %s", cluster.Status.Message)
+	}
+	finishMembershipJob(t, r, cluster, job)
+
+	// 2 masters cannot lose one of them either
+	if _, err := r.reconcileMembership(ctx, cluster); err != nil || cluster.Status.JobRef != nil {
+		t.Fatalf("expect no job to remove master0 from 2 masters, err: %v", err)
+	}
+	if masters := bindedMachines(t, r, cluster, eggov1.UsageMaster); len(masters) != 2 || !hasEvent(r, ReasonRemoveMastersRefused) {
+		t.Fatalf("expect master0 kept in 2 masters, get: %v", masters)
+	}
+
+	// master0 is removed after more masters joined
+	cluster.Spec.MasterRequire.Number = 2
+	finishMembershipJob(t, r, cluster, reconcileMembershipJob(t, r, cluster))
+	job = reconcileMembershipJob(t, r, cluster)
+	if job.Annotations[JobOperationAnnotation] != JobOperationCleanup ||
+		job.Annotations[JobMachinesAnnotation] != string(machines["master0"].UID) {
+		t.Fatalf("expect cleanup job for master0, get annotations: %v", job.Annotations)
+	}
+	finishMembershipJob(t, r, cluster, job)
+	if masters := bindedMachines(t, r, cluster, eggov1.UsageMaster); len(masters) != 2 {
+		t.Fatalf("expect 2 masters left, get: %v", masters)
+	}
+}
+
+func TestRetryFailedCleanup(t *testing.T) {
+	r, cluster, _ := newRunningCluster(t)
+	ctx := context.Background()
+	worker0 := &eggov1.Machine{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "worker0", Namespace: "default"}, worker0); err != nil {
+		t.Fatalf("get worker0 failed: %v", err)
+	}
+	if err := r.reserveMachines(ctx, cluster, []eggov1.Machine{*worker0}); err != nil {
+		t.Fatalf("reserve worker0 failed: %v", err)
+	}
+
+	cluster.Spec.WorkerRequire.Number = 0
+	job := reconcileMembershipJob(t, r, cluster)
+	if job.Annotations[JobOperationAnnotation] != JobOperationCleanup ||
+		job.Annotations[JobMachinesAnnotation] != string(worker0.UID) {
+		t.Fatalf("expect cleanup job for worker0, get annotations: %v", job.Annotations)
+	}
+
+	// cleanup job failed, worker0 is still member of cluster
+	job.Status.Conditions = []batch.JobCondition{{Type: batch.JobFailed, Status: v1.ConditionTrue, Message: "drain failed"}}
+	if err := r.Status().Update(ctx, job); err != nil {
+		t.Fatalf("update status of job failed: %v", err)
+	}
+	if _, err := r.reconcileMembership(ctx, cluster); err != nil || cluster.Status.JobRef != nil {
+		t.Fatalf("expect failed job finished, err: %v", err)
+	}
+	if workers := bindedWorkers(t, r, cluster); strings.Join(workers, ",") != "worker0" {
+		t.Fatalf("expect worker0 kept binded after failed cleanup, get: %v", workers)
+	}
+	m := &eggov1.Machine{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "worker0", Namespace: "default"}, m); err != nil {
+		t.Fatalf("get worker0 failed: %v", err)
+	}
+	if m.Annotations[MachineReservedByAnnotation] != reservationKey(cluster) {
+		t.Fatalf("expect worker0 still reserved after failed cleanup, get: %v", m.Annotations)
+	}
+
+	// cleanup is retried, even if worker0 is required again
+	cluster.Spec.WorkerRequire.Number = 1
+	job = reconcileMembershipJob(t, r, cluster)
+	if job.Annotations[JobOperationAnnotation] != JobOperationCleanup ||
+		job.Annotations[JobMachinesAnnotation] != string(worker0.UID) {
+		t.Fatalf("expect cleanup of worker0 retried, get annotations: %v", job.Annotations)
+	}
+	finishMembershipJob(t, r, cluster, job)
+	if workers := bindedWorkers(t, r, cluster); len(workers) != 0 {
+		t.Fatalf("expect worker0 unbinded after cleanup succeeded, get: %v", workers)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+const (
+	DefaultRequeueBaseInterval = 2 * time.Second
+	DefaultRequeueMaxInterval  = 2 * time.Minute
+)
+
+// requeueBackoff counts successive not ready reconciles of clusters
+type requeueBackoff struct {
+	lock  sync.Mutex
+	steps map[types.NamespacedName]uint
+}
+
+func (r *ClusterReconciler) requeueIntervals() (base, max time.Duration) {
+	base, max = r.RequeueBaseInterval, r.RequeueMaxInterval
+	if base <= 0 {
+		base = DefaultRequeueBaseInterval
+	}
+	if max <= 0 {
+		max = DefaultRequeueMaxInterval
+	}
+	if max < base {
+		max = base
+	}
+	return
+}
+
+// requeueNotReady doubles requeue interval of cluster on each successive call, until max interval
+func (r *ClusterReconciler) requeueNotReady(cluster *eggov1.Cluster) ctrl.Result {
+	base, max := r.requeueIntervals()
+	key := types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name}
+
+	r.backoff.lock.Lock()
+	defer r.backoff.lock.Unlock()
+	if r.backoff.steps == nil {
+		r.backoff.steps = make(map[types.NamespacedName]uint)
+	}
+	step := r.backoff.steps[key]
+	interval := max
+	// avoid overflow of shift
+	if step < 32 && base<<step < max {
+		interval = base << step
+		r.backoff.steps[key] = step + 1
+	}
+	return ctrl.Result{RequeueAfter: interval}
+}
+
+// requeueProgress resets backoff of cluster which makes progress, and requeue after base interval
+func (r *ClusterReconciler) requeueProgress(cluster *eggov1.Cluster) ctrl.Result {
+	r.forgetBackoff(cluster)
+	base, _ := r.requeueIntervals()
+	return ctrl.Result{RequeueAfter: base}
+}
+
+// requeueOnError requeues cluster with backoff, err waiting for resources is not reported,
+// to avoid extra requeue of it by rate limiter of controller
+func (r *ClusterReconciler) requeueOnError(cluster *eggov1.Cluster, err error) (ctrl.Result, error) {
+	if isWaitingError(err) {
+		r.Recorder.Event(cluster, v1.EventTypeNormal, ReasonWaitingResources, err.Error())
+		return r.requeueNotReady(cluster), nil
+	}
+	return r.requeueNotReady(cluster), err
+}
+
+func (r *ClusterReconciler) forgetBackoff(cluster *eggov1.Cluster) {
+	r.backoff.lock.Lock()
+	defer r.backoff.lock.Unlock()
+	delete(r.backoff.steps, types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name})
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+func TestRequeueBackoff(t *testing.T) {
+	r := &ClusterReconciler{RequeueBaseInterval: time.Second, RequeueMaxInterval: 10 * time.Second}
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", "default"
+	other := cluster.DeepCopy()
+	other.Name = "other-cluster"
+
+	expects := []time.Duration{1, 2, 4, 8, 10, 10}
+	for i, e := range expects {
+		if got := r.requeueNotReady(cluster).RequeueAfter; got != e*time.Second {
+			t.Fatalf("expect interval %v in round %d, get: %v", e*time.Second, i, got)
+		}
+	}
+	// backoff of each cluster is independent
+	if got := r.requeueNotReady(other).RequeueAfter; got != time.Second {
+		t.Fatalf("expect base interval for other cluster, get: %v", got)
+	}
+	if got := r.requeueProgress(cluster).RequeueAfter; got != time.Second {
+		t.Fatalf("expect base interval after progress, get: %v", got)
+	}
+	if got := r.requeueNotReady(cluster).RequeueAfter; got != time.Second {
+		t.Fatalf("expect backoff reset after progress, get: %v", got)
+	}
+
+	// defaults
+	r = &ClusterReconciler{}
+	if got := r.requeueNotReady(cluster).RequeueAfter; got != DefaultRequeueBaseInterval {
+		t.Fatalf("expect default base interval, get: %v", got)
+	}
+	for i := 0; i < 100; i++ {
+		r.requeueNotReady(cluster)
+	}
+	if got := r.requeueNotReady(cluster).RequeueAfter; got != DefaultRequeueMaxInterval {
+		t.Fatalf("expect default max interval, get: %v", got)
+	}
+}
+
+func TestRequeueEscalatesOnNotReady(t *testing.T) {
+	ns := "default"
+	secret := &v1.Secret{Type: v1.SecretTypeOpaque}
+	secret.Name, secret.Namespace = "login", ns
+	r := newTestReconciler(t, secret)
+	r.RequeueBaseInterval, r.RequeueMaxInterval = time.Second, time.Minute
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", ns
+	cluster.Spec.MachineLoginSecret = &v1.ObjectReference{Name: secret.Name}
+
+	last := time.Duration(0)
+	for i := 0; i < 5; i++ {
+		res, err := r.reconcileCreate(context.Background(), cluster)
+		if err == nil {
+			t.Fatalf("expect reconcile with bad secret failed")
+		}
+		if res.RequeueAfter <= last {
+			t.Fatalf("expect requeue interval escalates, last: %v, get: %v", last, res.RequeueAfter)
+		}
+		last = res.RequeueAfter
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+const (
+	// namespace/name of cluster which reserves machine, set before machine is bound into machine binding
+	MachineReservedByAnnotation = "eggo.isula.org/reserved-by"
+)
+
+func reservationKey(cluster *eggov1.Cluster) string {
+	return cluster.Namespace + "/" + cluster.Name
+}
+
+// reservedByOthers returns true if machine is reserved by other cluster, reservation of
+// deleted cluster is stale and ignored
+func (r *ClusterReconciler) reservedByOthers(ctx context.Context, cluster *eggov1.Cluster, m *eggov1.Machine) (bool, error) {
+	holder := m.GetAnnotations()[MachineReservedByAnnotation]
+	if holder == "" || holder == reservationKey(cluster) {
+		return false, nil
+	}
+	parts := strings.SplitN(holder, "/", 2)
+	if len(parts) != 2 {
+		return false, nil
+	}
+	err := r.Get(ctx, types.NamespacedName{Namespace: parts[0], Name: parts[1]}, &eggov1.Cluster{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unavailableMachines returns machines in namespace of cluster, which are binded or reserved by other clusters
+func (r *ClusterReconciler) unavailableMachines(ctx context.Context, cluster *eggov1.Cluster) (map[string]bool, error) {
+	unavailable, err := r.bindedSelectMachines(ctx, cluster.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var mList eggov1.MachineList
+	if err = r.List(ctx, &mList, client.InNamespace(cluster.Namespace)); err != nil {
+		return nil, err
+	}
+	for i := range mList.Items {
+		reserved, err := r.reservedByOthers(ctx, cluster, &mList.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			unavailable[mList.Items[i].Name] = true
+		}
+	}
+	return unavailable, nil
+}
+
+func (r *ClusterReconciler) unreserveMachine(ctx context.Context, m *eggov1.Machine) error {
+	base := m.DeepCopy()
+	delete(m.Annotations, MachineReservedByAnnotation)
+	return r.Patch(ctx, m, client.MergeFrom(base))
+}
+
+// reserveMachines reserves selected machines for cluster, machine is updated with resource version
+// when it is selected, so if clusters select the same machine concurrently, only one of them wins,
+// others get ErrMachineReserved and select machines again
+func (r *ClusterReconciler) reserveMachines(ctx context.Context, cluster *eggov1.Cluster, machines []eggov1.Machine) error {
+	key := reservationKey(cluster)
+	var reserved []*eggov1.Machine
+	for i := range machines {
+		m := machines[i].DeepCopy()
+		if m.Annotations[MachineReservedByAnnotation] == key {
+			continue
+		}
+		if m.Annotations == nil {
+			m.Annotations = make(map[string]string)
+		}
+		m.Annotations[MachineReservedByAnnotation] = key
+		if err := r.Update(ctx, m); err != nil {
+			// release machines reserved in this round, they may be selected by the winner
+			for _, rm := range reserved {
+				if uerr := r.unreserveMachine(ctx, rm); uerr != nil {
+					r.Log.Error(uerr, "release reserved machine", "machine", rm.Name)
+				}
+			}
+			if apierrors.IsConflict(err) {
+				return fmt.Errorf("%w: %s", ErrMachineReserved, m.Name)
+			}
+			return err
+		}
+		reserved = append(reserved, m)
+	}
+	return nil
+}
+
+// releaseMachines removes reservations of cluster from machines which are not kept
+func (r *ClusterReconciler) releaseMachines(ctx context.Context, cluster *eggov1.Cluster, keep map[string]bool) error {
+	var mList eggov1.MachineList
+	if err := r.List(ctx, &mList, client.InNamespace(cluster.Namespace)); err != nil {
+		return err
+	}
+	key := reservationKey(cluster)
+	for i := range mList.Items {
+		m := &mList.Items[i]
+		if m.Annotations[MachineReservedByAnnotation] != key || keep[m.Name] {
+			continue
+		}
+		if err := r.unreserveMachine(ctx, m); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bindingMachineNames returns names of machines in machine binding
+func bindingMachineNames(mb *eggov1.MachineBinding) map[string]bool {
+	names := make(map[string]bool)
+	for _, ms := range mb.Spec.MachineSets {
+		for _, m := range ms.Machines {
+			names[m.Name] = true
+		}
+	}
+	return names
+}
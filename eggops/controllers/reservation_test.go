@@ -0,0 +1,94 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+func TestReserveMachinesConcurrently(t *testing.T) {
+	ctx := context.Background()
+	newCluster := func(name string) *eggov1.Cluster {
+		c := &eggov1.Cluster{}
+		c.Name, c.Namespace = name, "default"
+		c.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 1}
+		return c
+	}
+	clusterA, clusterB := newCluster("cluster-a"), newCluster("cluster-b")
+	r := newTestReconciler(t, newTestMachine("machine0", "192.168.0.1"), clusterA, clusterB)
+
+	// both clusters select the only free machine before any of them reserves it
+	mA, _, _, err := r.filterMachines(ctx, clusterA)
+	if err != nil || len(mA) != 1 {
+		t.Fatalf("cluster-a select machines failed: %v", err)
+	}
+	mB, _, _, err := r.filterMachines(ctx, clusterB)
+	if err != nil || len(mB) != 1 {
+		t.Fatalf("cluster-b select machines failed: %v", err)
+	}
+
+	if err = r.reserveMachines(ctx, clusterA, mA); err != nil {
+		t.Fatalf("cluster-a reserve machines failed: %v", err)
+	}
+	if err = r.reserveMachines(ctx, clusterB, mB); !errors.Is(err, ErrMachineReserved) || !isWaitingError(err) {
+		t.Fatalf("expect cluster-b waiting for reserved machine, get: %v", err)
+	}
+	var m eggov1.Machine
+	if err = r.Get(ctx, types.NamespacedName{Name: "machine0", Namespace: "default"}, &m); err != nil {
+		t.Fatalf("get machine failed: %v", err)
+	}
+	if holder := m.Annotations[MachineReservedByAnnotation]; holder != "default/cluster-a" {
+		t.Fatalf("expect machine reserved by cluster-a, get: %q", holder)
+	}
+
+	// loser selects again, and the reserved machine is not available
+	if _, _, _, err = r.filterMachines(ctx, clusterB); !errors.Is(err, ErrInsufficientMachines) {
+		t.Fatalf("expect insufficient machines for cluster-b, get: %v", err)
+	}
+	// reserve again by the winner is no-op
+	if mA, _, _, err = r.filterMachines(ctx, clusterA); err != nil || len(mA) != 1 {
+		t.Fatalf("cluster-a select reserved machine failed: %v", err)
+	}
+	if err = r.reserveMachines(ctx, clusterA, mA); err != nil {
+		t.Fatalf("cluster-a reserve machines again failed: %v", err)
+	}
+
+	// machine is free after the winner releases it
+	if err = r.releaseMachines(ctx, clusterA, nil); err != nil {
+		t.Fatalf("cluster-a release machines failed: %v", err)
+	}
+	if mB, _, _, err = r.filterMachines(ctx, clusterB); err != nil || len(mB) != 1 {
+		t.Fatalf("cluster-b select released machine failed: %v", err)
+	}
+	if err = r.reserveMachines(ctx, clusterB, mB); err != nil {
+		t.Fatalf("cluster-b reserve released machine failed: %v", err)
+	}
+
+	// reservation of deleted cluster is stale
+	if err = r.Delete(ctx, clusterB); err != nil {
+		t.Fatalf("delete cluster-b failed: %v", err)
+	}
+	if mA, _, _, err = r.filterMachines(ctx, clusterA); err != nil || len(mA) != 1 {
+		t.Fatalf("expect stale reservation ignored, get: %v", err)
+	}
+}
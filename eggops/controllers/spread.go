@@ -0,0 +1,104 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+// machineDomain returns domain of machine defined by anti-affinity, all machines are
+// in the same domain without anti-affinity
+func machineDomain(m *eggov1.Machine, anti *eggov1.MachineAntiAffinity) string {
+	if anti == nil {
+		return ""
+	}
+	return m.GetLabels()[anti.TopologyKey]
+}
+
+// pickMachines picks n machines from candidates, machines in unique are preferred in the same domain.
+// Each machine is picked from the domain with the fewest machines, counted with placed machines,
+// so machines are spread across domains as possible
+func pickMachines(candidates []eggov1.Machine, unique map[string]bool, n int, anti *eggov1.MachineAntiAffinity, placed []eggov1.Machine) []eggov1.Machine {
+	counts := make(map[string]int)
+	for i := range placed {
+		counts[machineDomain(&placed[i], anti)]++
+	}
+
+	domains := make(map[string][]eggov1.Machine)
+	var names []string
+	for i := range candidates {
+		d := machineDomain(&candidates[i], anti)
+		if _, ok := domains[d]; !ok {
+			names = append(names, d)
+		}
+		domains[d] = append(domains[d], candidates[i])
+	}
+	// keep selection stable
+	sort.Strings(names)
+	for _, d := range names {
+		ms := domains[d]
+		sort.SliceStable(ms, func(i, j int) bool {
+			if unique[ms[i].Name] != unique[ms[j].Name] {
+				return unique[ms[i].Name]
+			}
+			return ms[i].Name < ms[j].Name
+		})
+	}
+
+	var picked []eggov1.Machine
+	for len(picked) < n {
+		best, found := "", false
+		for _, d := range names {
+			if len(domains[d]) == 0 {
+				continue
+			}
+			if !found || counts[d] < counts[best] {
+				best, found = d, true
+			}
+		}
+		if !found {
+			break
+		}
+		picked = append(picked, domains[best][0])
+		domains[best] = domains[best][1:]
+		counts[best]++
+	}
+	return picked
+}
+
+// warnNotSpread records warning if some of machines are in the same domain of anti-affinity
+func (r *ClusterReconciler) warnNotSpread(cluster *eggov1.Cluster, role string, anti *eggov1.MachineAntiAffinity, machines []eggov1.Machine) {
+	if anti == nil {
+		return
+	}
+	domains := make(map[string]bool)
+	for i := range machines {
+		domains[machineDomain(&machines[i], anti)] = true
+	}
+	if len(domains) >= len(machines) {
+		return
+	}
+
+	msg := fmt.Sprintf("%d %s machines are spread across %d domains of %s only", len(machines), role, len(domains), anti.TopologyKey)
+	r.Log.Info(msg, "name", cluster.Name)
+	r.Recorder.Event(cluster, v1.EventTypeWarning, ReasonMachinesNotSpread, msg)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+func zonesOf(machines []eggov1.Machine) map[string]int {
+	zones := make(map[string]int)
+	for _, m := range machines {
+		zones[m.Labels["topology.zone"]]++
+	}
+	return zones
+}
+
+func TestSpreadMachinesAcrossZones(t *testing.T) {
+	ctx := context.Background()
+	var objs []client.Object
+	for i, zone := range []string{"a", "a", "a", "a", "b", "b", "c"} {
+		m := newTestMachine(fmt.Sprintf("machine%d", i), fmt.Sprintf("192.168.0.%d", i+1))
+		m.Labels = map[string]string{"topology.zone": zone}
+		objs = append(objs, m)
+	}
+	r := newTestReconciler(t, objs...)
+	anti := &eggov1.MachineAntiAffinity{TopologyKey: "topology.zone"}
+
+	cluster := &eggov1.Cluster{}
+	cluster.Name, cluster.Namespace = "test-cluster", "default"
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 3, AntiAffinity: anti}
+	cluster.Spec.WorkerRequire = eggov1.RequireMachineConfig{Number: 2, AntiAffinity: anti}
+	masters, workers, _, err := r.filterMachines(ctx, cluster)
+	if err != nil {
+		t.Fatalf("filter machines failed: %v", err)
+	}
+	if zones := zonesOf(masters); len(zones) != 3 {
+		t.Fatalf("expect masters spread across 3 zones, get: %v", zones)
+	}
+	// zone c is used up by masters
+	if zones := zonesOf(workers); len(zones) != 2 || zones["a"] != 1 || zones["b"] != 1 {
+		t.Fatalf("expect workers spread across zone a and b, get: %v", zones)
+	}
+
+	// without anti-affinity, machines are selected in order of names
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 3}
+	cluster.Spec.WorkerRequire = eggov1.RequireMachineConfig{}
+	if masters, _, _, err = r.filterMachines(ctx, cluster); err != nil {
+		t.Fatalf("filter machines failed: %v", err)
+	}
+	if zones := zonesOf(masters); zones["a"] != 3 {
+		t.Fatalf("expect masters in zone a, get: %v", zones)
+	}
+
+	// fall back to select machines in the same zone with warning
+	cluster.Spec.MasterRequire = eggov1.RequireMachineConfig{Number: 5, AntiAffinity: anti}
+	if masters, _, _, err = r.filterMachines(ctx, cluster); err != nil || len(masters) != 5 {
+		t.Fatalf("expect fall back to select 5 masters, get: %v", err)
+	}
+	if zones := zonesOf(masters); zones["a"] != 2 || zones["b"] != 2 || zones["c"] != 1 {
+		t.Fatalf("expect masters balanced across zones, get: %v", zones)
+	}
+	select {
+	case e := <-r.Recorder.(*record.FakeRecorder).Events:
+		if !strings.Contains(e, ReasonMachinesNotSpread) {
+			t.Fatalf("expect warning of machines not spread, get: %s", e)
+		}
+	default:
+		t.Fatalf("expect warning of machines not spread")
+	}
+}
+
+func TestPickMachinesWithPlaced(t *testing.T) {
+	newMachine := func(name, zone string) eggov1.Machine {
+		m := newTestMachine(name, "")
+		m.Labels = map[string]string{"topology.zone": zone}
+		return *m
+	}
+	anti := &eggov1.MachineAntiAffinity{TopologyKey: "topology.zone"}
+	placed := []eggov1.Machine{newMachine("machine0", "a"), newMachine("machine1", "b")}
+	candidates := []eggov1.Machine{newMachine("machine2", "a"), newMachine("machine3", "b"), newMachine("machine4", "c")}
+
+	// machine joined into cluster is spread together with machines already binded
+	picked := pickMachines(candidates, nil, 1, anti, placed)
+	if len(picked) != 1 || picked[0].Name != "machine4" {
+		t.Fatalf("expect machine4 in zone c picked, get: %v", eggov1.PrintMachineSlice(picked))
+	}
+
+	// machine only available for the role is preferred in the same zone
+	candidates = []eggov1.Machine{newMachine("machine2", "a"), newMachine("machine3", "a")}
+	picked = pickMachines(candidates, map[string]bool{"machine3": true}, 1, anti, nil)
+	if len(picked) != 1 || picked[0].Name != "machine3" {
+		t.Fatalf("expect unique machine3 picked, get: %v", eggov1.PrintMachineSlice(picked))
+	}
+}
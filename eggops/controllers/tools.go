@@ -9,10 +9,12 @@ import (
 
 	"gopkg.in/yaml.v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 
 	"isula.org/eggo/cmd"
 	eggov1 "isula.org/eggo/eggops/api/v1"
+	"isula.org/eggo/pkg/api"
 )
 
 func getEndpoint(conf eggov1.APIEndpointConfig) string {
@@ -140,8 +142,8 @@ func fillOpenPortsConfig(openPorts eggov1.OpenPortsConfig) map[string][]*cmd.Ope
 	return copy
 }
 
-func ConvertClusterToEggoConfig(cluster *eggov1.Cluster, mb *eggov1.MachineBinding, secret *v1.Secret, infrastructure *eggov1.Infrastructure) ([]byte, error) {
-	conf := cmd.DeployConfig{}
+func toEggoDeployConfig(cluster *eggov1.Cluster, mb *eggov1.MachineBinding, secret *v1.Secret, infrastructure *eggov1.Infrastructure) *cmd.DeployConfig {
+	conf := &cmd.DeployConfig{}
 	// set cluster config
 	conf.ClusterID = cluster.GetName()
 
@@ -151,6 +153,7 @@ func ConvertClusterToEggoConfig(cluster *eggov1.Cluster, mb *eggov1.MachineBindi
 		conf.Username = string(secret.Data[v1.BasicAuthUsernameKey])
 		conf.Password = string(secret.Data[v1.BasicAuthPasswordKey])
 	}
+	conf.SudoPassword = string(secret.Data[eggov1.MachineLoginSecretSudoPasswordKey])
 
 	packagePath := fmt.Sprintf(eggov1.PackageVolumeFormat, cluster.Name)
 	conf.InstallConfig = fillInstallConfig(infrastructure.Spec.InstallConfig, packagePath)
@@ -194,16 +197,14 @@ func ConvertClusterToEggoConfig(cluster *eggov1.Cluster, mb *eggov1.MachineBindi
 	}
 
 	// set machines
-	conf.Workers = make([]*cmd.HostConfig, 0)
+	var masters, workers, etcds []*eggov1.Machine
 	for _, set := range mb.Spec.MachineSets {
 		if set.MatchType(eggov1.UsageMaster) {
-			conf.Masters = toEggoHosts(set.Machines)
-			// set master machines as worker machines
-			conf.Workers = append(conf.Workers, conf.Masters...)
+			masters = append(masters, set.Machines...)
 		} else if set.MatchType(eggov1.UsageWorker) {
-			conf.Workers = append(conf.Workers, toEggoHosts(set.Machines)...)
+			workers = append(workers, set.Machines...)
 		} else if set.MatchType(eggov1.UsageEtcd) {
-			conf.Etcds = toEggoHosts(set.Machines)
+			etcds = append(etcds, set.Machines...)
 		} else if set.MatchType(eggov1.UsageLoadbalance) {
 			if len(set.Machines) != 1 {
 				continue
@@ -217,22 +218,99 @@ func ConvertClusterToEggoConfig(cluster *eggov1.Cluster, mb *eggov1.MachineBindi
 			}
 		}
 	}
+	// keep machines in order of binding, which is the order they joined, so nodes of deployed cluster,
+	// especially the first master, are not reordered when machines join or leave
+	conf.Masters = toEggoHosts(masters)
+	// set master machines as worker machines
+	conf.Workers = make([]*cmd.HostConfig, 0)
+	conf.Workers = append(conf.Workers, conf.Masters...)
+	conf.Workers = append(conf.Workers, toEggoHosts(workers)...)
+	conf.Etcds = toEggoHosts(etcds)
+
+	return conf
+}
 
-	d, err := yaml.Marshal(conf)
+// ConvertClusterToEggoConfig returns deploy config of eggo, output is byte-stable for same input,
+// maps are sorted by yaml and machines are in order of binding
+func ConvertClusterToEggoConfig(cluster *eggov1.Cluster, mb *eggov1.MachineBinding, secret *v1.Secret, infrastructure *eggov1.Infrastructure) ([]byte, error) {
+	d, err := yaml.Marshal(toEggoDeployConfig(cluster, mb, secret, infrastructure))
 	if err != nil {
 		return nil, err
 	}
 	return d, nil
 }
 
+// ConvertClusterToClusterConfig returns config of cluster deployment for deploy in process,
+// private key is read from secret directly, because it is not mounted into controller
+func ConvertClusterToClusterConfig(cluster *eggov1.Cluster, mb *eggov1.MachineBinding, secret *v1.Secret, infrastructure *eggov1.Infrastructure, op api.HookOperator) (*api.ClusterConfig, error) {
+	ccfg, err := cmd.ToClusterConfig(toEggoDeployConfig(cluster, mb, secret, infrastructure), op)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Type == v1.SecretTypeSSHAuth {
+		for _, n := range ccfg.Nodes {
+			n.PrivateKey = string(secret.Data[v1.SSHAuthPrivateKey])
+			n.PrivateKeyPath = ""
+		}
+	}
+	return ccfg, nil
+}
+
 func ReferenceToNamespacedName(ref *v1.ObjectReference) types.NamespacedName {
 	return types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
 }
 
-func GetEggoImageVersion(cluster *eggov1.Cluster) string {
+// GetWorkspaceNamespace returns namespace of resources generated for cluster
+func GetWorkspaceNamespace(cluster *eggov1.Cluster) string {
+	if cluster.Spec.WorkspaceNamespace != "" {
+		return cluster.Spec.WorkspaceNamespace
+	}
+
+	return cluster.Namespace
+}
+
+// GetEggoImage returns reference of eggo image, full reference is preferred
+func GetEggoImage(cluster *eggov1.Cluster) string {
+	if cluster.Spec.EggoImage != "" {
+		return cluster.Spec.EggoImage
+	}
 	if cluster.Spec.EggoImageVersion != "" {
 		return cluster.Spec.EggoImageVersion
 	}
 
 	return "eggo:" + eggov1.ImageVersion
 }
+
+func GetJobBackoffLimit(cluster *eggov1.Cluster) int32 {
+	if cluster.Spec.JobBackoffLimit != nil {
+		return *cluster.Spec.JobBackoffLimit
+	}
+
+	return eggov1.DefaultJobBackoffLimit
+}
+
+func GetJobActiveDeadlineSeconds(cluster *eggov1.Cluster) int64 {
+	if cluster.Spec.JobActiveDeadlineSeconds != nil {
+		return *cluster.Spec.JobActiveDeadlineSeconds
+	}
+
+	return eggov1.DefaultJobActiveDeadlineSeconds
+}
+
+// GetJobResources returns resources of eggo container, default values are used if not set
+func GetJobResources(cluster *eggov1.Cluster) v1.ResourceRequirements {
+	if cluster.Spec.JobResources != nil {
+		return *cluster.Spec.JobResources.DeepCopy()
+	}
+
+	return v1.ResourceRequirements{
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(eggov1.DefaultJobCPURequest),
+			v1.ResourceMemory: resource.MustParse(eggov1.DefaultJobMemoryRequest),
+		},
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(eggov1.DefaultJobCPULimit),
+			v1.ResourceMemory: resource.MustParse(eggov1.DefaultJobMemoryLimit),
+		},
+	}
+}
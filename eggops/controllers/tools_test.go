@@ -0,0 +1,111 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"testing"
+
+	"gopkg.in/yaml.v1"
+	v1 "k8s.io/api/core/v1"
+
+	"isula.org/eggo/cmd"
+	eggov1 "isula.org/eggo/eggops/api/v1"
+)
+
+func testMachine(name, ip string) *eggov1.Machine {
+	port := int32(22)
+	m := &eggov1.Machine{}
+	m.Name = name
+	m.Spec = eggov1.MachineSpec{HostName: name, IP: ip, Port: &port, Arch: "amd64"}
+	return m
+}
+
+// testMachineBinding binds master1 after master0, and worker1 before worker0
+func testMachineBinding(reverseSets bool) *eggov1.MachineBinding {
+	masters := []*eggov1.Machine{testMachine("master0", "192.168.0.1"), testMachine("master1", "192.168.0.2")}
+	workers := []*eggov1.Machine{testMachine("worker1", "192.168.0.4"), testMachine("worker0", "192.168.0.3")}
+	sets := []eggov1.MachineSetOfUsage{
+		{Usage: "Master", Machines: masters},
+		{Usage: "Worker", Machines: workers},
+	}
+	if reverseSets {
+		sets[0], sets[1] = sets[1], sets[0]
+	}
+	return &eggov1.MachineBinding{Spec: eggov1.MachineBindingSpec{MachineSets: sets}}
+}
+
+func TestConvertClusterToEggoConfig(t *testing.T) {
+	cluster := &eggov1.Cluster{}
+	cluster.Name = "test-cluster"
+	cluster.Spec.Network = eggov1.ClusterNetworkConfig{
+		PodCidr:       "10.244.0.0/16",
+		PodPlugin:     "calico",
+		PodPluginArgs: map[string]string{"NetworkYamlPath": "/etc/calico.yaml", "Backend": "vxlan", "Image": "calico:v3"},
+	}
+	secret := &v1.Secret{
+		Type: v1.SecretTypeBasicAuth,
+		Data: map[string][]byte{v1.BasicAuthUsernameKey: []byte("root"), v1.BasicAuthPasswordKey: []byte("123456")},
+	}
+	port := int32(2379)
+	infra := &eggov1.Infrastructure{}
+	infra.Spec.InstallConfig = eggov1.InstallConfig{
+		PackageSrc: &eggov1.PackageSrcConfig{
+			Type:        "tar.gz",
+			SrcPackages: map[string]string{"arm64": "arm64.tar.gz", "amd64": "amd64.tar.gz"},
+		},
+		Addition: eggov1.AdditionConfig{
+			Master: []*eggov1.PackageConfig{{Name: "calico.yaml", Type: "yaml"}},
+			Worker: []*eggov1.PackageConfig{{Name: "hostname", Type: "repo"}},
+		},
+	}
+	infra.Spec.OpenPorts = eggov1.OpenPortsConfig{
+		Master: []*eggov1.OpenPorts{{Port: &port, Protocol: "tcp"}},
+		ETCD:   []*eggov1.OpenPorts{{Port: &port, Protocol: "tcp"}},
+	}
+
+	expect, err := ConvertClusterToEggoConfig(cluster, testMachineBinding(false), secret, infra)
+	if err != nil {
+		t.Fatalf("convert cluster to eggo config failed: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		data, err := ConvertClusterToEggoConfig(cluster, testMachineBinding(i%2 == 1), secret, infra)
+		if err != nil {
+			t.Fatalf("convert cluster to eggo config failed: %v", err)
+		}
+		if !bytes.Equal(expect, data) {
+			t.Fatalf("expect stable eggo config:\n%s\nget:\n%s", expect, data)
+		}
+	}
+
+	conf := &cmd.DeployConfig{}
+	if err = yaml.Unmarshal(expect, conf); err != nil {
+		t.Fatalf("unmarshal eggo config failed: %v", err)
+	}
+	var names []string
+	for _, w := range conf.Workers {
+		names = append(names, w.Name)
+	}
+	// nodes are in order of binding, not reordered by name
+	if len(conf.Masters) != 2 || conf.Masters[0].Name != "master0" || len(names) != 4 ||
+		names[0] != "master0" || names[1] != "master1" || names[2] != "worker1" || names[3] != "worker0" {
+		t.Fatalf("unexpect order of nodes, masters: %v, workers: %v", conf.Masters, names)
+	}
+	if conf.Username != "root" || conf.NetWork.PluginArgs["Backend"] != "vxlan" {
+		t.Fatalf("unexpect eggo config:\n%s", expect)
+	}
+}
@@ -19,6 +19,7 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -26,6 +27,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
@@ -52,11 +54,31 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var jobLogLines int64
+	var jobHistoryLimit int
+	var requeueBaseInterval, requeueMaxInterval time.Duration
+	var inProcessDeploy bool
+	var machineProbeInterval time.Duration
+	var failFast bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.Int64Var(&jobLogLines, "job-log-lines", controllers.DefaultJobLogLines,
+		"Number of lines from the end of failed eggo job logs to save into status of cluster.")
+	flag.IntVar(&jobHistoryLimit, "job-history-limit", controllers.DefaultJobHistoryLimit,
+		"Max number of eggo job histories to save into status of cluster, the oldest ones are trimmed.")
+	flag.DurationVar(&requeueBaseInterval, "requeue-base-interval", controllers.DefaultRequeueBaseInterval,
+		"Initial interval to requeue cluster which is not ready, it doubles on each successive not ready reconcile.")
+	flag.DurationVar(&requeueMaxInterval, "requeue-max-interval", controllers.DefaultRequeueMaxInterval,
+		"Max interval to requeue cluster which is not ready.")
+	flag.BoolVar(&inProcessDeploy, "in-process-deploy", false,
+		"Deploy and cleanup clusters by eggo library in controller, instead of running eggo jobs.")
+	flag.DurationVar(&machineProbeInterval, "machine-probe-interval", controllers.DefaultMachineProbeInterval,
+		"Interval to probe reachability of machines, unhealthy machines are not selected by clusters.")
+	flag.BoolVar(&failFast, "fail-fast", false,
+		"Stop at the first problem of machines or nodes, instead of reporting all of them.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -65,7 +87,8 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	cfg := ctrl.GetConfigOrDie()
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
@@ -79,26 +102,44 @@ func main() {
 	}
 
 	if err = (&controllers.MachineReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		ProbeInterval: machineProbeInterval,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Machine")
 		os.Exit(1)
 	}
 	if err = (&controllers.ClusterReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:              mgr.GetClient(),
+		Scheme:              mgr.GetScheme(),
+		KubeClient:          kubernetes.NewForConfigOrDie(cfg),
+		JobLogLines:         jobLogLines,
+		JobHistoryLimit:     jobHistoryLimit,
+		Recorder:            mgr.GetEventRecorderFor("cluster-controller"),
+		RequeueBaseInterval: requeueBaseInterval,
+		RequeueMaxInterval:  requeueMaxInterval,
+		InProcessDeploy:     inProcessDeploy,
+		FailFast:            failFast,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cluster")
 		os.Exit(1)
 	}
+	// webhook server requires certificates, see config/webhook and config/certmanager
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err = (&controllers.ClusterValidator{
+			Client: mgr.GetClient(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Cluster")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	if err := mgr.AddReadyzCheck("readyz", controllers.CacheSyncedChecker(mgr.GetCache())); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
@@ -2,11 +2,14 @@ package api
 
 import (
 	"fmt"
+	"net"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/version"
 
 	"isula.org/eggo/pkg/constants"
 )
@@ -15,6 +18,32 @@ var (
 	EggoHomePath = "/etc/eggo/"
 )
 
+const (
+	ArchAMD64 = "amd64"
+	ArchARM64 = "arm64"
+)
+
+// aliases of arch supported by eggo
+var archAliases = map[string]string{
+	"amd64":   ArchAMD64,
+	"x86_64":  ArchAMD64,
+	"x86-64":  ArchAMD64,
+	"arm64":   ArchARM64,
+	"aarch64": ArchARM64,
+}
+
+// NormalizeArch returns canonical arch of eggo, empty arch is amd64 by default
+func NormalizeArch(arch string) (string, error) {
+	a := strings.ToLower(strings.TrimSpace(arch))
+	if a == "" {
+		return ArchAMD64, nil
+	}
+	if n, ok := archAliases[a]; ok {
+		return n, nil
+	}
+	return "", fmt.Errorf("unsupported arch: %s, support: %s(x86_64), %s(aarch64)", arch, ArchAMD64, ArchARM64)
+}
+
 func (c ClusterConfig) GetConfigDir() string {
 	if c.ConfigDir != "" {
 		if !filepath.IsAbs(c.ConfigDir) {
@@ -37,6 +66,150 @@ func (c ClusterConfig) GetCertDir() string {
 	return constants.DefaultK8SCertDir
 }
 
+// GetEncryptionConfigPath returns path of encryption config of apiserver on masters
+func (c ClusterConfig) GetEncryptionConfigPath() string {
+	return filepath.Join(c.GetConfigDir(), constants.EncryptionConfigName)
+}
+
+// GetEncryptionProvider returns provider of encryption at rest, default aescbc
+func (c ClusterConfig) GetEncryptionProvider() string {
+	if c.Encryption.Provider == "" {
+		return EncryptionProviderAESCBC
+	}
+	return c.Encryption.Provider
+}
+
+// GetEncryptionResources returns resources to encrypt at rest, default secrets
+func (c ClusterConfig) GetEncryptionResources() []string {
+	if len(c.Encryption.Resources) == 0 {
+		return []string{"secrets"}
+	}
+	return c.Encryption.Resources
+}
+
+// path must be absolute and without ".." element
+func isSafeDir(path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	for _, e := range strings.Split(filepath.ToSlash(path), "/") {
+		if e == ".." {
+			return false
+		}
+	}
+	return filepath.Clean(path) != "/"
+}
+
+// same dir or parent dir of target
+func isSameOrParentDir(dir, target string) bool {
+	rel, err := filepath.Rel(dir, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+func (c ClusterConfig) GetEtcdDataDir() string {
+	dataDir := c.EtcdCluster.DataDir
+	if dataDir == "" {
+		return constants.DefaultEtcdDataDir
+	}
+	if !isSafeDir(dataDir) {
+		logrus.Warnf("ignore invalid etcd data dir: %s, just use default", dataDir)
+		return constants.DefaultEtcdDataDir
+	}
+	dataDir = filepath.Clean(dataDir)
+	// data dir will be removed when cleanup etcd
+	for _, dir := range []string{c.GetConfigDir(), c.GetCertDir()} {
+		if isSameOrParentDir(dataDir, dir) {
+			logrus.Warnf("etcd data dir: %s conflict with %s, just use default", dataDir, dir)
+			return constants.DefaultEtcdDataDir
+		}
+	}
+	return dataDir
+}
+
+func (c CertificateConfig) externalCADir(path, legacySubDir string) string {
+	if path != "" {
+		return path
+	}
+	if c.ExternalCA && c.ExternalCAPath != "" {
+		return filepath.Join(c.ExternalCAPath, legacySubDir)
+	}
+	return ""
+}
+
+// ExternalKubernetesCADir returns dir of external kubernetes ca, empty means ca should be generated
+func (c CertificateConfig) ExternalKubernetesCADir() string {
+	return c.externalCADir(c.ExternalKubernetesCAPath, "")
+}
+
+// ExternalEtcdCADir returns dir of external etcd ca, empty means ca should be generated
+func (c CertificateConfig) ExternalEtcdCADir() string {
+	return c.externalCADir(c.ExternalEtcdCAPath, "etcd")
+}
+
+// ExternalFrontProxyCADir returns dir of external front proxy ca, empty means ca should be generated
+func (c CertificateConfig) ExternalFrontProxyCADir() string {
+	return c.externalCADir(c.ExternalFrontProxyCAPath, "")
+}
+
+// ParsePortRange parses port range in format of <base>-<max>
+func ParsePortRange(portRange string) (int, int, error) {
+	parts := strings.Split(portRange, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid port range: %s, should be in format of <base>-<max>", portRange)
+	}
+	base, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid base port of range: %s", portRange)
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid max port of range: %s", portRange)
+	}
+	if base <= 0 || max > 65535 || base > max {
+		return 0, 0, fmt.Errorf("invalid port range: %s, ports should be in [1, 65535] and base is not larger than max", portRange)
+	}
+	return base, max, nil
+}
+
+// GetNodePortRange returns range of node ports of services
+func (c ClusterConfig) GetNodePortRange() string {
+	if c.ServiceCluster.NodePortRange != "" {
+		return c.ServiceCluster.NodePortRange
+	}
+	return constants.DefaultNodePortRange
+}
+
+// GetDNSDomain returns domain of cluster dns, which is shared by coredns and kubelet
+func (c ClusterConfig) GetDNSDomain() string {
+	if c.ServiceCluster.DNS.Domain != "" {
+		return c.ServiceCluster.DNS.Domain
+	}
+	if c.WorkerConfig.KubeletConf != nil && c.WorkerConfig.KubeletConf.DNSDomain != "" {
+		return c.WorkerConfig.KubeletConf.DNSDomain
+	}
+	return constants.DefaultDNSDomain
+}
+
+// GetCgroupDriver returns cgroup driver shared by kubelet and container runtime
+func (k *Kubelet) GetCgroupDriver() string {
+	if k == nil || k.CgroupDriver == "" {
+		return constants.CgroupDriverSystemd
+	}
+	return k.CgroupDriver
+}
+
+func (c ClusterConfig) GetEtcdCertsDir() string {
+	certsDir := c.EtcdCluster.CertsDir
+	if certsDir == "" {
+		return c.GetCertDir()
+	}
+	if !isSafeDir(certsDir) {
+		logrus.Warnf("ignore invalid etcd certs dir: %s, just use default", certsDir)
+		return c.GetCertDir()
+	}
+	return filepath.Clean(certsDir)
+}
+
 func (c ClusterConfig) GetManifestDir() string {
 	if c.ConfigDir != "" {
 		if !filepath.IsAbs(c.ConfigDir) {
@@ -56,6 +229,19 @@ func (p PackageSrcConfig) GetPkgDstPath() string {
 	return p.DstPath
 }
 
+// GetImagePackageDstPath returns path of image package on nodes, empty if image package is not set
+func (p PackageSrcConfig) GetImagePackageDstPath(imagePackage string) string {
+	if imagePackage == "" {
+		return ""
+	}
+	return filepath.Join(p.GetPkgDstPath(), constants.DefaultImagePath, filepath.Base(imagePackage))
+}
+
+// IsRemotePackage return true if path of package source is http or https url
+func IsRemotePackage(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
 func (ep APIEndpoint) GetURL() string {
 	return fmt.Sprintf("%s/%v", ep.AdvertiseAddress, ep.BindPort)
 }
@@ -72,19 +258,142 @@ func GetEggoClusterPath() string {
 	return EggoHomePath
 }
 
-func GetEtcdServers(ecc *EtcdClusterConfig) string {
-	//etcd_servers="https://${MASTER_IPS[$i]}:2379"
-	//etcd_servers="$etcd_servers,https://${MASTER_IPS[$i]}:2379"
-	if ecc == nil || len(ecc.Nodes) == 0 {
-		return "https://127.0.0.1:2379"
+// EtcdURL returns https url of etcd with address and port, ipv6 address is bracketed
+func EtcdURL(address string, port int) string {
+	return "https://" + net.JoinHostPort(address, strconv.Itoa(port))
+}
+
+func getPortOrDefault(port, def int) int {
+	if port == 0 {
+		return def
 	}
-	var sb strings.Builder
+	return port
+}
+
+func (ecc EtcdClusterConfig) GetClientPort() int {
+	return getPortOrDefault(ecc.ClientPort, constants.EtcdClientPort)
+}
 
+func (ecc EtcdClusterConfig) GetPeerPort() int {
+	return getPortOrDefault(ecc.PeerPort, constants.EtcdPeerPort)
+}
+
+func (ecc EtcdClusterConfig) GetMetricsPort() int {
+	return getPortOrDefault(ecc.MetricsPort, constants.EtcdMetricsPort)
+}
+
+// ClientURL returns client url of etcd member with address
+func (ecc EtcdClusterConfig) ClientURL(address string) string {
+	return EtcdURL(address, ecc.GetClientPort())
+}
+
+// PeerURL returns peer url of etcd member with address
+func (ecc EtcdClusterConfig) PeerURL(address string) string {
+	return EtcdURL(address, ecc.GetPeerPort())
+}
+
+// ClientEndpoints returns client urls of etcd cluster, external endpoints are used for external etcd
+func (ecc EtcdClusterConfig) ClientEndpoints() []string {
+	if ecc.External && len(ecc.ExternalEndpoints) > 0 {
+		return append([]string{}, ecc.ExternalEndpoints...)
+	}
+	var eps []string
 	for _, n := range ecc.Nodes {
-		sb.WriteString(fmt.Sprintf("https://%s:2379,", n.Address))
+		eps = append(eps, ecc.ClientURL(n.Address))
 	}
-	ret := sb.String()
-	return ret[0 : len(ret)-1]
+	return eps
+}
+
+// PeerEndpoints returns peer urls of etcd members deployed by eggo, empty for external etcd
+func (ecc EtcdClusterConfig) PeerEndpoints() []string {
+	if ecc.External {
+		return nil
+	}
+	var eps []string
+	for _, n := range ecc.Nodes {
+		eps = append(eps, ecc.PeerURL(n.Address))
+	}
+	return eps
+}
+
+func GetEtcdServers(ecc *EtcdClusterConfig) string {
+	if ecc == nil {
+		return EtcdURL("127.0.0.1", constants.EtcdClientPort)
+	}
+	eps := ecc.ClientEndpoints()
+	if len(eps) == 0 {
+		return ecc.ClientURL("127.0.0.1")
+	}
+	return strings.Join(eps, ",")
+}
+
+// ReplaceImageRepository replace registry host of image with repo,
+// "k8s.gcr.io/pause:3.2" with repo "hub.example.com/k8s" will get "hub.example.com/k8s/pause:3.2"
+func ReplaceImageRepository(image, repo string) string {
+	repo = strings.TrimSuffix(repo, "/")
+	if repo == "" || image == "" {
+		return image
+	}
+	parts := strings.SplitN(image, "/", 2)
+	// first part of image is registry host if it contains "." or ":", or is "localhost"
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		image = parts[1]
+	}
+	return fmt.Sprintf("%s/%s", repo, image)
+}
+
+func (c ClusterConfig) GetImage(image string) string {
+	return ReplaceImageRepository(image, c.ImageRepository)
+}
+
+// ParseK8sVersion parses kubernetes version, such as v1.20.2 or 1.20.2
+func ParseK8sVersion(v string) (*version.Version, error) {
+	pv, err := version.ParseGeneric(strings.TrimSpace(v))
+	if err != nil {
+		return nil, err
+	}
+	if len(pv.Components()) != 3 {
+		return nil, fmt.Errorf("version %s is not in format of major.minor.patch", v)
+	}
+	return pv, nil
+}
+
+// MatchK8sVersion returns whether actual version, such as 1.20.2 in name of package or
+// v1.20.2 printed by kubelet, is same as expect version, suffix of actual version is ignored
+func MatchK8sVersion(expect, actual string) bool {
+	ev, err := ParseK8sVersion(expect)
+	if err != nil {
+		return false
+	}
+	av, err := version.ParseGeneric(strings.TrimSpace(actual))
+	if err != nil {
+		return false
+	}
+	return ev.Major() == av.Major() && ev.Minor() == av.Minor() && ev.Patch() == av.Patch()
+}
+
+// K8sVersionAtLeast returns true if kubernetes version of cluster is set and not older than min
+func (c ClusterConfig) K8sVersionAtLeast(min string) bool {
+	if c.KubernetesVersion == "" {
+		return false
+	}
+	v, err := ParseK8sVersion(c.KubernetesVersion)
+	if err != nil {
+		return false
+	}
+	return v.AtLeast(version.MustParseGeneric(min))
+}
+
+// K8sVersionLessThan returns true if kubernetes version of cluster is set and older than max
+func (c ClusterConfig) K8sVersionLessThan(max string) bool {
+	if c.KubernetesVersion == "" {
+		return false
+	}
+	v, err := ParseK8sVersion(c.KubernetesVersion)
+	if err != nil {
+		return false
+	}
+	return v.LessThan(version.MustParseGeneric(max))
 }
 
 func IsCleanupSchedule(schedule ScheduleType) bool {
@@ -133,51 +442,80 @@ func (cs *ClusterStatus) Show() string {
 
 type ClusterConfigOption func(conf *ClusterConfig) *ClusterConfig
 
+// merge extra args into dst, same key will be override by new value
+func mergeExtraArgs(dst map[string]string, eargs map[string]string) map[string]string {
+	if dst == nil {
+		dst = make(map[string]string, len(eargs))
+	}
+	for k, v := range eargs {
+		dst[k] = v
+	}
+	return dst
+}
+
 func WithEtcdExtrArgs(eargs map[string]string) ClusterConfigOption {
 	return func(conf *ClusterConfig) *ClusterConfig {
-		conf.EtcdCluster.ExtraArgs = eargs
+		conf.EtcdCluster.ExtraArgs = mergeExtraArgs(conf.EtcdCluster.ExtraArgs, eargs)
 		return conf
 	}
 }
 
 func WithAPIServerExtrArgs(eargs map[string]string) ClusterConfigOption {
 	return func(conf *ClusterConfig) *ClusterConfig {
-		conf.ControlPlane.APIConf.ExtraArgs = eargs
+		if conf.ControlPlane.APIConf == nil {
+			conf.ControlPlane.APIConf = &APIServer{}
+		}
+		conf.ControlPlane.APIConf.ExtraArgs = mergeExtraArgs(conf.ControlPlane.APIConf.ExtraArgs, eargs)
 		return conf
 	}
 }
 
 func WithControllerManagerExtrArgs(eargs map[string]string) ClusterConfigOption {
 	return func(conf *ClusterConfig) *ClusterConfig {
-		conf.ControlPlane.ManagerConf.ExtraArgs = eargs
+		if conf.ControlPlane.ManagerConf == nil {
+			conf.ControlPlane.ManagerConf = &ControlManager{}
+		}
+		conf.ControlPlane.ManagerConf.ExtraArgs = mergeExtraArgs(conf.ControlPlane.ManagerConf.ExtraArgs, eargs)
 		return conf
 	}
 }
 
 func WithSchedulerExtrArgs(eargs map[string]string) ClusterConfigOption {
 	return func(conf *ClusterConfig) *ClusterConfig {
-		conf.ControlPlane.SchedulerConf.ExtraArgs = eargs
+		if conf.ControlPlane.SchedulerConf == nil {
+			conf.ControlPlane.SchedulerConf = &Scheduler{}
+		}
+		conf.ControlPlane.SchedulerConf.ExtraArgs = mergeExtraArgs(conf.ControlPlane.SchedulerConf.ExtraArgs, eargs)
 		return conf
 	}
 }
 
 func WithKubeletExtrArgs(eargs map[string]string) ClusterConfigOption {
 	return func(conf *ClusterConfig) *ClusterConfig {
-		conf.WorkerConfig.KubeletConf.ExtraArgs = eargs
+		if conf.WorkerConfig.KubeletConf == nil {
+			conf.WorkerConfig.KubeletConf = &Kubelet{}
+		}
+		conf.WorkerConfig.KubeletConf.ExtraArgs = mergeExtraArgs(conf.WorkerConfig.KubeletConf.ExtraArgs, eargs)
 		return conf
 	}
 }
 
 func WithKubeProxyExtrArgs(eargs map[string]string) ClusterConfigOption {
 	return func(conf *ClusterConfig) *ClusterConfig {
-		conf.WorkerConfig.ProxyConf.ExtraArgs = eargs
+		if conf.WorkerConfig.ProxyConf == nil {
+			conf.WorkerConfig.ProxyConf = &KubeProxy{}
+		}
+		conf.WorkerConfig.ProxyConf.ExtraArgs = mergeExtraArgs(conf.WorkerConfig.ProxyConf.ExtraArgs, eargs)
 		return conf
 	}
 }
 
 func WithContainerEngineExtrArgs(eargs map[string]string) ClusterConfigOption {
 	return func(conf *ClusterConfig) *ClusterConfig {
-		conf.WorkerConfig.ContainerEngineConf.ExtraArgs = eargs
+		if conf.WorkerConfig.ContainerEngineConf == nil {
+			conf.WorkerConfig.ContainerEngineConf = &ContainerEngine{}
+		}
+		conf.WorkerConfig.ContainerEngineConf.ExtraArgs = mergeExtraArgs(conf.WorkerConfig.ContainerEngineConf.ExtraArgs, eargs)
 		return conf
 	}
 }
@@ -0,0 +1,280 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: api tools testcase
+ ******************************************************************************/
+
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/constants"
+)
+
+func TestNormalizeArch(t *testing.T) {
+	cases := map[string]string{
+		"":         ArchAMD64,
+		"amd64":    ArchAMD64,
+		"x86_64":   ArchAMD64,
+		"X86_64":   ArchAMD64,
+		"x86-64":   ArchAMD64,
+		" amd64 ":  ArchAMD64,
+		"arm64":    ArchARM64,
+		"aarch64":  ArchARM64,
+		"AArch64":  ArchARM64,
+		"arm":      "",
+		"riscv64":  "",
+		"amd64x":   "",
+		"i386":     "",
+		"aarch_64": "",
+	}
+	for arch, expect := range cases {
+		got, err := NormalizeArch(arch)
+		if expect == "" {
+			if err == nil {
+				t.Fatalf("expect error for arch %q, got %s", arch, got)
+			}
+			continue
+		}
+		if err != nil || got != expect {
+			t.Fatalf("normalize arch %q expect %s, got %s, err: %v", arch, expect, got, err)
+		}
+	}
+}
+
+func TestGetEtcdServers(t *testing.T) {
+	ecc := &EtcdClusterConfig{
+		Nodes: []*HostConfig{
+			{Address: "192.168.0.1"},
+			{Address: "192.168.0.2"},
+		},
+		ExternalEndpoints: []string{"https://10.0.0.1:2379", "https://10.0.0.2:2379"},
+	}
+	if got := GetEtcdServers(ecc); got != "https://192.168.0.1:2379,https://192.168.0.2:2379" {
+		t.Fatalf("internal etcd servers should be etcd nodes, get: %s", got)
+	}
+
+	ecc.External = true
+	if got := GetEtcdServers(ecc); got != "https://10.0.0.1:2379,https://10.0.0.2:2379" {
+		t.Fatalf("external etcd servers should be external endpoints, get: %s", got)
+	}
+
+	if got := GetEtcdServers(nil); got != "https://127.0.0.1:2379" {
+		t.Fatalf("expect default etcd server, get: %s", got)
+	}
+}
+
+func TestGetEtcdDataDir(t *testing.T) {
+	cases := map[string]string{
+		"":                            constants.DefaultEtcdDataDir,
+		"/data/etcd":                  "/data/etcd",
+		"/data//etcd/":                "/data/etcd",
+		"data/etcd":                   constants.DefaultEtcdDataDir,
+		"./etcd":                      constants.DefaultEtcdDataDir,
+		"/data/../etc/etcd":           constants.DefaultEtcdDataDir,
+		"/":                           constants.DefaultEtcdDataDir,
+		"/etc/kubernetes":             constants.DefaultEtcdDataDir,
+		"/etc/kubernetes/pki":         constants.DefaultEtcdDataDir,
+		"/etc":                        constants.DefaultEtcdDataDir,
+		"/etc/kubernetes/etcd":        "/etc/kubernetes/etcd",
+		"/etc/kubernetes/pki-etcd":    "/etc/kubernetes/pki-etcd",
+		"/var/lib/etcd/default.etcd/": constants.DefaultEtcdDataDir,
+	}
+	for dir, expect := range cases {
+		c := ClusterConfig{EtcdCluster: EtcdClusterConfig{DataDir: dir}}
+		if got := c.GetEtcdDataDir(); got != expect {
+			t.Fatalf("etcd data dir %q: expect %s, get %s", dir, expect, got)
+		}
+	}
+
+	c := ClusterConfig{
+		ConfigDir:   "/data/k8s",
+		Certificate: CertificateConfig{SavePath: "/data/pki"},
+		EtcdCluster: EtcdClusterConfig{DataDir: "/data/pki"},
+	}
+	if got := c.GetEtcdDataDir(); got != constants.DefaultEtcdDataDir {
+		t.Fatalf("etcd data dir same as cert dir should be rejected, get %s", got)
+	}
+	c.EtcdCluster.DataDir = "/data/k8s/"
+	if got := c.GetEtcdDataDir(); got != constants.DefaultEtcdDataDir {
+		t.Fatalf("etcd data dir same as config dir should be rejected, get %s", got)
+	}
+}
+
+func TestGetEtcdCertsDir(t *testing.T) {
+	cases := map[string]string{
+		"":                constants.DefaultK8SCertDir,
+		"/data/etcd/pki/": "/data/etcd/pki",
+		"etcd/pki":        constants.DefaultK8SCertDir,
+		"/data/../pki":    constants.DefaultK8SCertDir,
+		"/":               constants.DefaultK8SCertDir,
+	}
+	for dir, expect := range cases {
+		c := ClusterConfig{EtcdCluster: EtcdClusterConfig{CertsDir: dir}}
+		if got := c.GetEtcdCertsDir(); got != expect {
+			t.Fatalf("etcd certs dir %q: expect %s, get %s", dir, expect, got)
+		}
+	}
+}
+
+func TestExternalCADirs(t *testing.T) {
+	cases := []struct {
+		conf   CertificateConfig
+		expect [3]string // kubernetes, etcd, front proxy
+	}{
+		{CertificateConfig{}, [3]string{"", "", ""}},
+		{CertificateConfig{ExternalCAPath: "/opt/ca"}, [3]string{"", "", ""}},
+		{CertificateConfig{ExternalCA: true, ExternalCAPath: "/opt/ca"}, [3]string{"/opt/ca", "/opt/ca/etcd", "/opt/ca"}},
+		{CertificateConfig{ExternalKubernetesCAPath: "/opt/k8s"}, [3]string{"/opt/k8s", "", ""}},
+		{CertificateConfig{ExternalEtcdCAPath: "/opt/etcd"}, [3]string{"", "/opt/etcd", ""}},
+		{CertificateConfig{ExternalFrontProxyCAPath: "/opt/proxy"}, [3]string{"", "", "/opt/proxy"}},
+		{CertificateConfig{ExternalKubernetesCAPath: "/opt/k8s", ExternalEtcdCAPath: "/opt/etcd", ExternalFrontProxyCAPath: "/opt/proxy"},
+			[3]string{"/opt/k8s", "/opt/etcd", "/opt/proxy"}},
+		{CertificateConfig{ExternalCA: true, ExternalCAPath: "/opt/ca", ExternalEtcdCAPath: "/opt/etcd"},
+			[3]string{"/opt/ca", "/opt/etcd", "/opt/ca"}},
+	}
+	for _, c := range cases {
+		got := [3]string{c.conf.ExternalKubernetesCADir(), c.conf.ExternalEtcdCADir(), c.conf.ExternalFrontProxyCADir()}
+		if got != c.expect {
+			t.Fatalf("external ca dirs of %+v: expect %v, get %v", c.conf, c.expect, got)
+		}
+	}
+}
+
+func TestEtcdEndpoints(t *testing.T) {
+	ecc := EtcdClusterConfig{
+		Nodes: []*HostConfig{
+			{Name: "etcd0", Address: "192.168.0.1"},
+			{Name: "etcd1", Address: "fd00::1"},
+		},
+	}
+	expect := "https://192.168.0.1:2379,https://[fd00::1]:2379"
+	if got := strings.Join(ecc.ClientEndpoints(), ","); got != expect {
+		t.Fatalf("expect client endpoints %s, get %s", expect, got)
+	}
+	expect = "https://192.168.0.1:2380,https://[fd00::1]:2380"
+	if got := strings.Join(ecc.PeerEndpoints(), ","); got != expect {
+		t.Fatalf("expect peer endpoints %s, get %s", expect, got)
+	}
+
+	ecc.External = true
+	ecc.ExternalEndpoints = []string{"https://[fd00::100]:2379"}
+	if got := strings.Join(ecc.ClientEndpoints(), ","); got != "https://[fd00::100]:2379" {
+		t.Fatalf("expect external client endpoints, get %s", got)
+	}
+	if eps := ecc.PeerEndpoints(); len(eps) != 0 {
+		t.Fatalf("external etcd should have no peer endpoints, get %v", eps)
+	}
+
+	if got := GetEtcdServers(&EtcdClusterConfig{}); got != "https://127.0.0.1:2379" {
+		t.Fatalf("expect default etcd server, get %s", got)
+	}
+}
+
+func TestEtcdCustomPorts(t *testing.T) {
+	ecc := EtcdClusterConfig{
+		Nodes:      []*HostConfig{{Address: "192.168.0.1"}, {Address: "fd00::1"}},
+		ClientPort: 12379,
+		PeerPort:   12380,
+	}
+	if got := GetEtcdServers(&ecc); got != "https://192.168.0.1:12379,https://[fd00::1]:12379" {
+		t.Fatalf("expect etcd servers with custom client port, get %s", got)
+	}
+	if got := strings.Join(ecc.PeerEndpoints(), ","); got != "https://192.168.0.1:12380,https://[fd00::1]:12380" {
+		t.Fatalf("expect peer endpoints with custom peer port, get %s", got)
+	}
+	if ecc.GetMetricsPort() != constants.EtcdMetricsPort {
+		t.Fatalf("expect default metrics port, get %d", ecc.GetMetricsPort())
+	}
+	ecc.Nodes = nil
+	if got := GetEtcdServers(&ecc); got != "https://127.0.0.1:12379" {
+		t.Fatalf("expect local etcd server with custom client port, get %s", got)
+	}
+}
+
+func TestGetImagePackageDstPath(t *testing.T) {
+	p := PackageSrcConfig{}
+	if got := p.GetImagePackageDstPath(""); got != "" {
+		t.Fatalf("expect empty path without image package, get %s", got)
+	}
+	if got := p.GetImagePackageDstPath("/root/images.tar"); got != "/root/.eggo/package/image/images.tar" {
+		t.Fatalf("unexpect image package path: %s", got)
+	}
+	p.DstPath = "/opt/eggo"
+	if got := p.GetImagePackageDstPath("/root/images.tar"); got != "/opt/eggo/image/images.tar" {
+		t.Fatalf("unexpect image package path with dst path: %s", got)
+	}
+}
+
+func TestK8sVersion(t *testing.T) {
+	matches := []struct {
+		expect string
+		actual string
+		match  bool
+	}{
+		{expect: "v1.20.2", actual: "1.20.2", match: true},
+		{expect: "1.20.2", actual: "v1.20.2", match: true},
+		{expect: "v1.20.2", actual: "v1.20.2-dirty", match: true},
+		{expect: "v1.20.2", actual: "v1.20.3", match: false},
+		{expect: "v1.20", actual: "v1.20.0", match: false},
+		{expect: "v1.20.2", actual: "unknown", match: false},
+	}
+	for _, m := range matches {
+		if MatchK8sVersion(m.expect, m.actual) != m.match {
+			t.Fatalf("match %s with %s expect %v", m.expect, m.actual, m.match)
+		}
+	}
+
+	var c ClusterConfig
+	if c.K8sVersionAtLeast("v1.20.0") || c.K8sVersionLessThan("v1.20.0") {
+		t.Fatalf("unset version should not be compared")
+	}
+	c.KubernetesVersion = "v1.20.2"
+	if !c.K8sVersionAtLeast("v1.20.0") || c.K8sVersionAtLeast("v1.27.0") {
+		t.Fatalf("unexpect result of K8sVersionAtLeast")
+	}
+	if !c.K8sVersionLessThan("v1.24.0") || c.K8sVersionLessThan("v1.20.2") {
+		t.Fatalf("unexpect result of K8sVersionLessThan")
+	}
+}
+
+func TestParsePortRange(t *testing.T) {
+	valids := map[string][2]int{
+		"30000-32767": {30000, 32767},
+		"20000-20000": {20000, 20000},
+		"1-65535":     {1, 65535},
+	}
+	for r, expect := range valids {
+		base, max, err := ParsePortRange(r)
+		if err != nil || base != expect[0] || max != expect[1] {
+			t.Fatalf("parse port range %s failed: %d, %d, %v", r, base, max, err)
+		}
+	}
+
+	invalids := []string{"", "30000", "30000-", "-32767", "a-b", "30000-32767-40000", "0-100", "30000-65536", "32767-30000"}
+	for _, r := range invalids {
+		if _, _, err := ParsePortRange(r); err == nil {
+			t.Fatalf("expect invalid port range: %q", r)
+		}
+	}
+
+	var c ClusterConfig
+	if r := c.GetNodePortRange(); r != constants.DefaultNodePortRange {
+		t.Fatalf("expect default node port range, get: %s", r)
+	}
+	c.ServiceCluster.NodePortRange = "20000-22767"
+	if r := c.GetNodePortRange(); r != "20000-22767" {
+		t.Fatalf("expect custom node port range, get: %s", r)
+	}
+}
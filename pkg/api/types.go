@@ -16,6 +16,7 @@
 package api
 
 import (
+	"context"
 	"time"
 )
 
@@ -66,6 +67,8 @@ type HookRunConfig struct {
 
 	HookDir string
 	Hooks   []*PackageConfig
+	// failure of hooks is ignored
+	IgnoreFailure bool
 }
 
 type RoleInfra struct {
@@ -76,6 +79,8 @@ type RoleInfra struct {
 type OpenPorts struct {
 	Port     int    `json:"port"`
 	Protocol string `json:"protocol"` // tcp/udp
+	// ports from Port to EndPort are opened, only Port is opened if EndPort is not larger than Port
+	EndPort int `json:"end-port,omitempty"`
 }
 
 type PackageConfig struct {
@@ -89,7 +94,8 @@ type PackageConfig struct {
 type PackageSrcConfig struct {
 	Type    string            `json:"type"`     // tar.gz...
 	DstPath string            `json:"dst-path"` // untar path on dst node
-	SrcPath map[string]string `json:"srcpath"`  // key: arm/amd/risc-v...
+	SrcPath map[string]string `json:"srcpath"`  // key: arm/amd/risc-v..., value: local path or http(s) url
+	Sha256  map[string]string `json:"sha256"`   // key: arm/amd/risc-v..., value: sha256 of package
 }
 
 type HostConfig struct {
@@ -102,6 +108,16 @@ type HostConfig struct {
 	Password       string   `json:"password"`
 	PrivateKey     string   `json:"private-key"`
 	PrivateKeyPath string   `json:"private-key-path"`
+	// authenticate by ssh agent of SSH_AUTH_SOCK
+	UseSSHAgent bool `json:"use-ssh-agent"`
+	// password of sudo, default is password of login user
+	SudoPassword string `json:"sudo-password"`
+	// command to elevate privilege, default is sudo -E
+	Elevate string `json:"elevate"`
+	// apt, yum or dnf, detected if empty
+	PackageManager string `json:"package-manager"`
+	// host which eggo runs on, commands run locally instead of by ssh
+	Local bool `json:"local"`
 
 	// 0x1 is master, 0x2 is worker, 0x4 is etcd
 	// 0x3 is master and worker
@@ -109,6 +125,8 @@ type HostConfig struct {
 	Type uint16 `json:"type"`
 
 	Labels map[string]string `json:"labels"`
+	// taints of kubernetes node, format: key[=value]:effect
+	Taints []string `json:"taints,omitempty"`
 }
 
 type Sans struct {
@@ -143,6 +161,7 @@ type Kubelet struct {
 	CniBinDir     string            `json:"cni-bin-dir"`
 	CniConfDir    string            `json:"cni-conf-dir"`
 	EnableServer  bool              `json:"enable-server"`
+	CgroupDriver  string            `json:"cgroup-driver,omitempty"` // systemd or cgroupfs, shared with runtime
 	ExtraArgs     map[string]string `json:"extra-args,omitempty"`
 }
 
@@ -156,6 +175,16 @@ type ContainerEngine struct {
 	RegistryMirrors    []string          `json:"registry-mirrors"`
 	InsecureRegistries []string          `json:"insecure-registries"`
 	ExtraArgs          map[string]string `json:"extra-args"`
+	// local tarball of container images, distributed to workers and loaded into runtime
+	ImagePackage string `json:"image-package,omitempty"`
+	// credentials of private registries, written into auth config of runtime on workers
+	RegistryAuths []RegistryAuth `json:"registry-auths,omitempty"`
+}
+
+type RegistryAuth struct {
+	Registry string `json:"registry"`
+	Username string `json:"username"`
+	Password string `json:"password"`
 }
 
 type APIEndpoint struct {
@@ -166,18 +195,66 @@ type ControlPlaneConfig struct {
 	APIConf       *APIServer      `json:"apiconf,omitempty"`
 	ManagerConf   *ControlManager `json:"managerconf,omitempty"`
 	SchedulerConf *Scheduler      `json:"schedulerconf,omitempty"`
+	// masters which are also workers are tainted NoSchedule if false
+	ScheduleWorkloadsOnMaster bool `json:"schedule-workloads-on-master,omitempty"`
+}
+
+const (
+	// default provider of encryption at rest
+	EncryptionProviderAESCBC    = "aescbc"
+	EncryptionProviderSecretbox = "secretbox"
+)
+
+// EncryptionConfig describes how apiserver encrypts resources before write them into etcd,
+// key is generated when deploy cluster and saved in home of cluster
+type EncryptionConfig struct {
+	// aescbc or secretbox, default aescbc
+	Provider string `json:"provider,omitempty"`
+	// resources to encrypt, default secrets
+	Resources []string `json:"resources,omitempty"`
+}
+
+// AuditConfig describes audit logs of apiserver, default policy is used if no policy set
+type AuditConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// local path of policy file, read when deploy masters
+	PolicyFile string `json:"policy-file,omitempty"`
+	// inline content of policy, used before policy file
+	Policy string `json:"policy,omitempty"`
+	// path of audit log on masters, default /var/log/kubernetes/audit/audit.log
+	LogPath string `json:"log-path,omitempty"`
+	// days to retain old logs, default 30
+	MaxAge int `json:"max-age,omitempty"`
+	// number of old logs to retain, default 10
+	MaxBackup int `json:"max-backup,omitempty"`
+	// megabytes of log before rotated, default 100
+	MaxSize int `json:"max-size,omitempty"`
 }
 
 type CertificateConfig struct {
 	SavePath       string `json:"savepath"` // default is "/etc/kubernetes/pki"
 	ExternalCA     bool   `json:"external-ca"`
 	ExternalCAPath string `json:"external-ca-path"`
+	// dirs of external ca for each component, override ExternalCAPath;
+	// kubernetes and etcd dirs contain ca.crt and ca.key, front proxy dir contains front-proxy-ca.crt and front-proxy-ca.key
+	ExternalKubernetesCAPath string `json:"external-kubernetes-ca-path"`
+	ExternalEtcdCAPath       string `json:"external-etcd-ca-path"`
+	ExternalFrontProxyCAPath string `json:"external-front-proxy-ca-path"`
 }
 
 type DnsConfig struct {
 	CorednsType  string `json:"coredns-type"`
 	ImageVersion string `json:"image-version"`
 	Replicas     int    `json:"replicas"`
+	// cluster dns domain, default is dns domain of kubelet
+	Domain string `json:"domain,omitempty"`
+	// resources of coredns pod, such as 100m and 70Mi, empty means use default
+	CPURequest    string `json:"cpu-request,omitempty"`
+	CPULimit      string `json:"cpu-limit,omitempty"`
+	MemoryRequest string `json:"memory-request,omitempty"`
+	MemoryLimit   string `json:"memory-limit,omitempty"`
+	// upstream servers of coredns with format ip[:port], empty means use /etc/resolv.conf of node
+	UpstreamServers []string `json:"upstream-servers,omitempty"`
 }
 
 type ServiceClusterConfig struct {
@@ -185,6 +262,8 @@ type ServiceClusterConfig struct {
 	DNSAddr string    `json:"dns-address"`
 	Gateway string    `json:"gateway"`
 	DNS     DnsConfig `json:"dns"`
+	// range of node ports of services, such as 30000-32767
+	NodePortRange string `json:"node-port-range,omitempty"`
 }
 
 type EtcdClusterConfig struct {
@@ -194,6 +273,15 @@ type EtcdClusterConfig struct {
 	CertsDir  string            `json:"certs-dir"` // local certs dir in machine running eggo, default /etc/kubernetes/pki
 	External  bool              `json:"external"`  // if use external, eggo will ignore etcd deploy and cleanup
 	ExtraArgs map[string]string `json:"extra-args"`
+	// ports of etcd deployed by eggo, use 2379, 2380 and 2381 if not set
+	ClientPort  int `json:"client-port,omitempty"`
+	PeerPort    int `json:"peer-port,omitempty"`
+	MetricsPort int `json:"metrics-port,omitempty"`
+	// endpoints and client certificates of external etcd cluster, certificates are local files in machine running eggo
+	ExternalEndpoints []string `json:"external-endpoints"`
+	ExternalCAFile    string   `json:"external-ca-file"`
+	ExternalCertFile  string   `json:"external-cert-file"`
+	ExternalKeyFile   string   `json:"external-key-file"`
 	// TODO: add loadbalance configuration
 }
 
@@ -227,11 +315,53 @@ type ClusterRoleBindingConfig struct {
 type LoadBalancer struct {
 	IP   string `json:"ip"`
 	Port string `json:"port"`
+
+	// virtual ip of loadbalance provided by keepalived, disabled if empty
+	VIP       string `json:"vip,omitempty"`
+	Interface string `json:"interface,omitempty"`
+	VRID      int    `json:"vrid,omitempty"`
 }
 
+const (
+	// manifest of addon is a local file of eggo
+	AddonTypeFile = "file"
+	// manifest of addon is downloaded from url
+	AddonTypeURL = "url"
+	// manifest of addon is set in config
+	AddonTypeInline = "inline"
+)
+
 type AddonConfig struct {
+	// name of addon, manifest is saved as name.yaml in addons dir of master
+	Name     string `json:"name"`
 	Type     string `json:"type"`
-	Filename string `json:"filename"`
+	Filename string `json:"filename,omitempty"`
+	URL      string `json:"url,omitempty"`
+	// optional sha256 of manifest downloaded from url
+	Sha256  string `json:"sha256,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// HostAlias is a static entry of /etc/hosts on every node
+type HostAlias struct {
+	IP        string   `json:"ip"`
+	Hostnames []string `json:"hostnames"`
+}
+
+// PackageRepo is a yum repo written to every node before install repo packages
+type PackageRepo struct {
+	Name    string `json:"name"`
+	BaseURL string `json:"baseurl"`
+	GPGKey  string `json:"gpgkey,omitempty"`
+}
+
+// ExtraFile is a local file shipped to nodes of roles, during setup of infrastructure
+type ExtraFile struct {
+	Src  string `json:"src"`
+	Dst  string `json:"dst"`
+	Mode uint32 `json:"mode,omitempty"`
+	// roles of nodes which file is shipped to, 0 means all nodes
+	Roles uint16 `json:"roles,omitempty"`
 }
 
 type ClusterHookConf struct {
@@ -240,6 +370,8 @@ type ClusterHookConf struct {
 	Target     uint16
 	HookSrcDir string
 	HookFiles  []string
+	// failure of hook does not abort operator
+	IgnoreFailure bool
 }
 
 type ClusterConfig struct {
@@ -255,16 +387,80 @@ type ClusterConfig struct {
 	EtcdCluster     EtcdClusterConfig       `json:"etcdcluster,omitempty"`
 	Nodes           []*HostConfig           `json:"nodes,omitempty"`
 	BootStrapTokens []*BootstrapTokenConfig `json:"bootstrap-tokens"`
+	JoinToken       string                  `json:"join-token,omitempty"` // bootstrap token of joining workers, generated if empty
 	LoadBalancer    LoadBalancer            `json:"loadBalancer"`
 	WorkerConfig    WorkerConfig            `json:"workerconfig"`
 	RoleInfra       map[uint16]*RoleInfra   `json:"role-infra"`
+	ImageRepository string                  `json:"image-repository"` // replace registry of pause and addon images
+	SSHHostKey      SSHHostKeyConfig        `json:"ssh-host-key"`
+	SSHKeepAlive    SSHKeepAliveConfig      `json:"ssh-keepalive"`
+	ConnectTimeout  string                  `json:"connect-timeout,omitempty"` // timeout to dial node by ssh, default is 15s
+	Addons          []*AddonConfig          `json:"addons,omitempty"`
+	HostAliases     []HostAlias             `json:"host-aliases,omitempty"`
+	Repos           []PackageRepo           `json:"repos,omitempty"`
+	Files           []ExtraFile             `json:"files,omitempty"`
+	// systemd drop-in settings of [Service] section, key is unit of component, such as kubelet
+	SystemdDropIns map[string]map[string]string `json:"systemd-dropins,omitempty"`
+	// encryption at rest of resources in etcd
+	Encryption EncryptionConfig `json:"encryption,omitempty"`
+	// audit logs of apiserver
+	Audit AuditConfig `json:"audit,omitempty"`
+
+	// version of kubernetes in packages, such as v1.20.2, checked before deploy if set
+	KubernetesVersion string `json:"kubernetes-version,omitempty"`
 
 	// do not encode hooks, just set before use it
 	HooksConf []*ClusterHookConf `json:"-"`
+	// where to write admin kubeconfig for user, do not encode, just set before use it
+	KubeConfigOut string `json:"-"`
+	// how to drain workers before remove them, do not encode, just set before use it
+	Drain DrainConfig `json:"-"`
+	// stop at the first failed node instead of attempting all nodes, do not encode, just set before use it
+	FailFast bool `json:"-"`
 
 	// TODO: add other configurations at here
 }
 
+const (
+	// accept any host key of nodes, it is default for backward compatibility
+	HostKeyCheckingPermissive = "permissive"
+	// host key of nodes must exist in known_hosts and match
+	HostKeyCheckingStrict = "strict"
+	// trust on first use, unknown host key is added into known_hosts, mismatch is rejected
+	HostKeyCheckingTOFU = "tofu"
+)
+
+type SSHHostKeyConfig struct {
+	// permissive, strict or tofu, default is permissive
+	Checking       string `json:"checking"`
+	KnownHostsPath string `json:"known-hosts-path"`
+}
+
+type SSHKeepAliveConfig struct {
+	// interval to check idle ssh connection, such as 30s, default is 30s, 0 disables keepalive
+	Interval string `json:"interval,omitempty"`
+}
+
+type DrainConfig struct {
+	// seconds for pods to terminate gracefully, 0 uses grace period of pod
+	GracePeriod int
+	// timeout of evicting pods, default is 120s
+	Timeout time.Duration
+	// delete pods without eviction if eviction timeout, PodDisruptionBudgets are ignored
+	Force bool
+	// abort removal of node if drain failed, otherwise node is removed after drain failed
+	Strict bool
+}
+
+type UpgradeConfig struct {
+	// kubernetes version to upgrade, such as v1.21.1
+	TargetVersion string `json:"target-version"`
+	// packages of target version
+	PackageSrc PackageSrcConfig `json:"packagesource"`
+	// max time to wait node ready after upgrade
+	NodeReadyTimeout time.Duration `json:"node-ready-timeout"`
+}
+
 type ClusterStatus struct {
 	Message       string          `json:"message"`
 	ControlPlane  string          `json:"controlplane"`
@@ -274,47 +470,48 @@ type ClusterStatus struct {
 	FailureCnt    uint32          `json:"failureCnt"`
 }
 
+// ctx of apis is used to cancel deployment, such as timeout or interrupted by user
 type InfrastructureAPI interface {
 	// TODO: should add other dependence cluster configurations
-	MachineInfraSetup(machine *HostConfig) error
-	MachineInfraDestroy(machine *HostConfig) error
+	MachineInfraSetup(ctx context.Context, machine *HostConfig) error
+	MachineInfraDestroy(ctx context.Context, machine *HostConfig) error
 }
 
 type EtcdAPI interface {
 	// TODO: should add other dependence cluster configurations
-	EtcdClusterSetup() error
-	EtcdClusterDestroy() error
-	EtcdNodeSetup(machine *HostConfig) error
-	EtcdNodeDestroy(machine *HostConfig) error
+	EtcdClusterSetup(ctx context.Context) error
+	EtcdClusterDestroy(ctx context.Context) error
+	EtcdNodeSetup(ctx context.Context, machine *HostConfig) error
+	EtcdNodeDestroy(ctx context.Context, machine *HostConfig) error
 }
 
 type ClusterManagerAPI interface {
 	// TODO: should add other dependence cluster configurations
-	PreCreateClusterHooks() error
-	PostCreateClusterHooks(nodes []*HostConfig) error
-	PreDeleteClusterHooks()
-	PostDeleteClusterHooks()
+	PreCreateClusterHooks(ctx context.Context) error
+	PostCreateClusterHooks(ctx context.Context, nodes []*HostConfig) error
+	PreDeleteClusterHooks(ctx context.Context)
+	PostDeleteClusterHooks(ctx context.Context)
 
-	PreNodeJoinHooks(node *HostConfig) error
-	PostNodeJoinHooks(node *HostConfig) error
-	PreNodeCleanupHooks(node *HostConfig)
-	PostNodeCleanupHooks(node *HostConfig)
+	PreNodeJoinHooks(ctx context.Context, node *HostConfig) error
+	PostNodeJoinHooks(ctx context.Context, node *HostConfig) error
+	PreNodeCleanupHooks(ctx context.Context, node *HostConfig)
+	PostNodeCleanupHooks(ctx context.Context, node *HostConfig)
 
-	ClusterControlPlaneInit(node *HostConfig) error
-	ClusterNodeJoin(node *HostConfig) error
-	ClusterNodeCleanup(node *HostConfig, delType uint16) error
-	ClusterUpgrade() error
-	ClusterStatus() (*ClusterStatus, error)
-	AddonsSetup() error
-	AddonsDestroy() error
+	ClusterControlPlaneInit(ctx context.Context, node *HostConfig) error
+	ClusterNodeJoin(ctx context.Context, node *HostConfig) error
+	ClusterNodeCleanup(ctx context.Context, node *HostConfig, delType uint16) error
+	ClusterNodeUpgrade(ctx context.Context, node *HostConfig, conf *UpgradeConfig) error
+	ClusterStatus(ctx context.Context) (*ClusterStatus, error)
+	AddonsSetup(ctx context.Context) error
+	AddonsDestroy(ctx context.Context) error
 
-	CleanupLastStep(nodeName string) error
+	CleanupLastStep(ctx context.Context, nodeName string) error
 }
 
 type LoadBalancerAPI interface {
-	LoadBalancerSetup(lb *HostConfig) error
-	LoadBalancerUpdate(lb *HostConfig) error
-	LoadBalancerDestroy(lb *HostConfig) error
+	LoadBalancerSetup(ctx context.Context, lb *HostConfig) error
+	LoadBalancerUpdate(ctx context.Context, lb *HostConfig) error
+	LoadBalancerDestroy(ctx context.Context, lb *HostConfig) error
 }
 
 type ClusterDeploymentAPI interface {
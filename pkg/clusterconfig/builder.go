@@ -0,0 +1,320 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: builder to create cluster config programmatically
+ ******************************************************************************/
+
+package clusterconfig
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/constants"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/infra"
+)
+
+const (
+	defaultAPIServerPort = 6443
+)
+
+// DefaultClusterConfig return cluster config with default values
+func DefaultClusterConfig() *api.ClusterConfig {
+	return &api.ClusterConfig{
+		Name:      "k8s-cluster",
+		ConfigDir: constants.DefaultK8SRootDir,
+		Certificate: api.CertificateConfig{
+			SavePath: constants.DefaultK8SCertDir,
+		},
+		ServiceCluster: api.ServiceClusterConfig{
+			CIDR:    "10.32.0.0/16",
+			DNSAddr: "10.32.0.10",
+			Gateway: "10.32.0.1",
+		},
+		Network: api.NetworkConfig{
+			PodCIDR:    "10.244.0.0/16",
+			PluginArgs: make(map[string]string),
+		},
+		ControlPlane: api.ControlPlaneConfig{
+			APIConf: &api.APIServer{
+				Timeout: "120s",
+			},
+		},
+		WorkerConfig: api.WorkerConfig{
+			KubeletConf: &api.Kubelet{
+				DNSVip:        "10.32.0.10",
+				DNSDomain:     "cluster.local",
+				PauseImage:    "k8s.gcr.io/pause:3.2",
+				NetworkPlugin: "cni",
+				CniBinDir:     "/opt/cni/bin",
+				EnableServer:  false,
+				CgroupDriver:  constants.CgroupDriverSystemd,
+			},
+			ContainerEngineConf: &api.ContainerEngine{
+				RegistryMirrors:    []string{},
+				InsecureRegistries: []string{},
+				ExtraArgs:          make(map[string]string),
+			},
+		},
+		PackageSrc: api.PackageSrcConfig{},
+		EtcdCluster: api.EtcdClusterConfig{
+			Token:    "etcd-cluster",
+			DataDir:  "/var/lib/etcd/default.etcd",
+			CertsDir: constants.DefaultK8SCertDir,
+			External: false,
+		},
+		DeployDriver: "binary",
+		RoleInfra:    infra.RegisterInfra(),
+	}
+}
+
+// ClusterConfigBuilder build cluster config step by step, for example:
+// NewClusterConfigBuilder().WithName("k8s-cluster").AddMaster(master).AddWorker(worker).Build()
+type ClusterConfigBuilder struct {
+	conf *api.ClusterConfig
+	// index of node in conf.Nodes, key is address of node
+	cache map[string]int
+}
+
+func NewClusterConfigBuilder() *ClusterConfigBuilder {
+	return &ClusterConfigBuilder{
+		conf:  DefaultClusterConfig(),
+		cache: make(map[string]int),
+	}
+}
+
+func (b *ClusterConfigBuilder) WithName(name string) *ClusterConfigBuilder {
+	if name != "" {
+		b.conf.Name = name
+	}
+	return b
+}
+
+// add node with role, node with same address will be merged into one node with multiple roles
+func mergeTaints(taints, others []string) []string {
+	if len(others) == 0 {
+		return taints
+	}
+	merged := append([]string{}, taints...)
+	for _, o := range others {
+		found := false
+		for _, t := range taints {
+			if t == o {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, o)
+		}
+	}
+	return merged
+}
+
+func (b *ClusterConfigBuilder) addNode(h *api.HostConfig, role uint16) *ClusterConfigBuilder {
+	if h == nil {
+		return b
+	}
+	if idx, ok := b.cache[h.Address]; ok {
+		n := b.conf.Nodes[idx]
+		n.Type |= role
+		// same node in different roles, merge labels and taints of it
+		if len(h.Labels) > 0 {
+			labels := make(map[string]string, len(n.Labels)+len(h.Labels))
+			for k, v := range n.Labels {
+				labels[k] = v
+			}
+			for k, v := range h.Labels {
+				labels[k] = v
+			}
+			n.Labels = labels
+		}
+		n.Taints = mergeTaints(n.Taints, h.Taints)
+		n.Local = n.Local || h.Local
+		return b
+	}
+	h.Type |= role
+	b.cache[h.Address] = len(b.conf.Nodes)
+	b.conf.Nodes = append(b.conf.Nodes, h)
+	return b
+}
+
+func (b *ClusterConfigBuilder) AddMaster(h *api.HostConfig) *ClusterConfigBuilder {
+	return b.addNode(h, api.Master)
+}
+
+func (b *ClusterConfigBuilder) AddWorker(h *api.HostConfig) *ClusterConfigBuilder {
+	return b.addNode(h, api.Worker)
+}
+
+func (b *ClusterConfigBuilder) AddEtcd(h *api.HostConfig) *ClusterConfigBuilder {
+	return b.addNode(h, api.ETCD)
+}
+
+// AddLoadBalance add loadbalance node, and apiserver of cluster will be accessed by bindPort of it
+func (b *ClusterConfigBuilder) AddLoadBalance(h *api.HostConfig, bindPort int) *ClusterConfigBuilder {
+	if h == nil || bindPort <= 0 {
+		return b
+	}
+	b.conf.LoadBalancer.IP = h.Address
+	b.conf.LoadBalancer.Port = strconv.Itoa(bindPort)
+	return b.addNode(h, api.LoadBalance)
+}
+
+// WithLoadBalanceVIP set virtual ip of loadbalance, which is hold by keepalived on loadbalance node
+func (b *ClusterConfigBuilder) WithLoadBalanceVIP(vip, iface string, vrid int) *ClusterConfigBuilder {
+	if vip == "" {
+		return b
+	}
+	b.conf.LoadBalancer.VIP = vip
+	b.conf.LoadBalancer.Interface = iface
+	b.conf.LoadBalancer.VRID = vrid
+	return b
+}
+
+func (b *ClusterConfigBuilder) WithServiceCluster(cidr, dnsAddr, gateway string) *ClusterConfigBuilder {
+	if cidr != "" {
+		b.conf.ServiceCluster.CIDR = cidr
+	}
+	if dnsAddr != "" {
+		b.conf.ServiceCluster.DNSAddr = dnsAddr
+	}
+	if gateway != "" {
+		b.conf.ServiceCluster.Gateway = gateway
+	}
+	return b
+}
+
+func (b *ClusterConfigBuilder) WithNetwork(podCIDR, plugin string, pluginArgs map[string]string) *ClusterConfigBuilder {
+	if podCIDR != "" {
+		b.conf.Network.PodCIDR = podCIDR
+	}
+	if plugin != "" {
+		b.conf.Network.Plugin = plugin
+	}
+	for k, v := range pluginArgs {
+		b.conf.Network.PluginArgs[k] = v
+	}
+	return b
+}
+
+func (b *ClusterConfigBuilder) WithAPIEndpoint(host string, port int32) *ClusterConfigBuilder {
+	if host != "" && port > 0 {
+		b.conf.APIEndpoint.AdvertiseAddress = host
+		b.conf.APIEndpoint.BindPort = port
+	}
+	return b
+}
+
+func (b *ClusterConfigBuilder) WithOptions(opts ...api.ClusterConfigOption) *ClusterConfigBuilder {
+	for _, opt := range opts {
+		opt(b.conf)
+	}
+	return b
+}
+
+// Config return cluster config which fields depended on nodes are filled, without validation
+func (b *ClusterConfigBuilder) Config() *api.ClusterConfig {
+	b.conf.EtcdCluster.Nodes = nil
+	for _, node := range b.conf.Nodes {
+		if utils.IsType(node.Type, api.ETCD) {
+			b.conf.EtcdCluster.Nodes = append(b.conf.EtcdCluster.Nodes, node)
+		}
+	}
+
+	if b.conf.APIEndpoint.AdvertiseAddress == "" {
+		if b.conf.LoadBalancer.IP != "" {
+			if port, err := strconv.Atoi(b.conf.LoadBalancer.Port); err == nil {
+				b.conf.APIEndpoint.AdvertiseAddress = b.conf.LoadBalancer.IP
+				if b.conf.LoadBalancer.VIP != "" {
+					b.conf.APIEndpoint.AdvertiseAddress = b.conf.LoadBalancer.VIP
+				}
+				b.conf.APIEndpoint.BindPort = int32(port)
+			}
+		}
+	}
+	if b.conf.APIEndpoint.AdvertiseAddress == "" {
+		for _, node := range b.conf.Nodes {
+			if utils.IsType(node.Type, api.Master) {
+				b.conf.APIEndpoint.AdvertiseAddress = node.Address
+				b.conf.APIEndpoint.BindPort = defaultAPIServerPort
+				break
+			}
+		}
+	}
+
+	return b.conf
+}
+
+// Build return cluster config which is ready to deploy
+func (b *ClusterConfigBuilder) Build() (*api.ClusterConfig, error) {
+	conf := b.Config()
+	if err := Validate(conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func validateCIDR(name, cidr string) error {
+	if cidr == "" {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(cidr); err != nil {
+		return fmt.Errorf("invalid %s: %s", name, cidr)
+	}
+	return nil
+}
+
+// Validate check basic configurations of cluster
+func Validate(conf *api.ClusterConfig) error {
+	if conf == nil {
+		return fmt.Errorf("empty cluster config")
+	}
+	if errs := validation.IsDNS1123Subdomain(conf.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid cluster name: %v", errs)
+	}
+
+	hasMaster := false
+	names := make(map[string]bool, len(conf.Nodes))
+	for _, node := range conf.Nodes {
+		if errs := validation.IsDNS1123Subdomain(node.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid node name: %s, err: %v", node.Name, errs)
+		}
+		if _, ok := names[node.Name]; ok {
+			return fmt.Errorf("duplicate node name: %s", node.Name)
+		}
+		names[node.Name] = true
+		if ip := net.ParseIP(node.Address); ip == nil {
+			return fmt.Errorf("invalid address: %s of node: %s", node.Address, node.Name)
+		}
+		if utils.IsType(node.Type, api.Master) {
+			hasMaster = true
+		}
+	}
+	if !hasMaster {
+		return fmt.Errorf("no master, master node is require for cluster")
+	}
+
+	if err := validateCIDR("service cidr", conf.ServiceCluster.CIDR); err != nil {
+		return err
+	}
+	if err := validateCIDR("pod cidr", conf.Network.PodCIDR); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for cluster config builder
+ ******************************************************************************/
+
+package clusterconfig
+
+import (
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func TestClusterConfigBuilder(t *testing.T) {
+	conf, err := NewClusterConfigBuilder().
+		WithName("test-cluster").
+		AddMaster(&api.HostConfig{Name: "node0", Address: "192.168.0.2"}).
+		AddWorker(&api.HostConfig{Name: "node0", Address: "192.168.0.2"}).
+		AddWorker(&api.HostConfig{Name: "node1", Address: "192.168.0.3"}).
+		AddEtcd(&api.HostConfig{Name: "node0", Address: "192.168.0.2"}).
+		WithNetwork("10.100.0.0/16", "calico", map[string]string{"CalicoYaml": "/tmp/calico.yaml"}).
+		WithOptions(api.WithKubeletExtrArgs(map[string]string{"--max-pods": "200"})).
+		Build()
+	if err != nil {
+		t.Fatalf("build cluster config failed: %v", err)
+	}
+
+	if conf.Name != "test-cluster" || len(conf.Nodes) != 2 {
+		t.Fatalf("invalid cluster config: %v", conf)
+	}
+	if conf.Nodes[0].Type != api.Master|api.Worker|api.ETCD || conf.Nodes[1].Type != api.Worker {
+		t.Fatalf("invalid type of nodes: %d, %d", conf.Nodes[0].Type, conf.Nodes[1].Type)
+	}
+	if len(conf.EtcdCluster.Nodes) != 1 || conf.EtcdCluster.Nodes[0].Address != "192.168.0.2" {
+		t.Fatalf("invalid etcd nodes: %v", conf.EtcdCluster.Nodes)
+	}
+	if conf.APIEndpoint.AdvertiseAddress != "192.168.0.2" || conf.APIEndpoint.BindPort != defaultAPIServerPort {
+		t.Fatalf("invalid api endpoint: %v", conf.APIEndpoint)
+	}
+	if conf.Network.PodCIDR != "10.100.0.0/16" || conf.Network.Plugin != "calico" {
+		t.Fatalf("invalid network: %v", conf.Network)
+	}
+	if conf.WorkerConfig.KubeletConf.ExtraArgs["--max-pods"] != "200" {
+		t.Fatalf("invalid kubelet extra args: %v", conf.WorkerConfig.KubeletConf.ExtraArgs)
+	}
+}
+
+func TestClusterConfigBuilderLoadBalance(t *testing.T) {
+	conf, err := NewClusterConfigBuilder().
+		AddMaster(&api.HostConfig{Name: "master0", Address: "192.168.0.2"}).
+		AddLoadBalance(&api.HostConfig{Name: "lb", Address: "192.168.0.10"}, 8443).
+		Build()
+	if err != nil {
+		t.Fatalf("build cluster config failed: %v", err)
+	}
+	if conf.APIEndpoint.AdvertiseAddress != "192.168.0.10" || conf.APIEndpoint.BindPort != 8443 {
+		t.Fatalf("api endpoint should be loadbalance: %v", conf.APIEndpoint)
+	}
+
+	conf, err = NewClusterConfigBuilder().
+		AddMaster(&api.HostConfig{Name: "master0", Address: "192.168.0.2"}).
+		AddLoadBalance(&api.HostConfig{Name: "lb", Address: "192.168.0.10"}, 8443).
+		WithLoadBalanceVIP("192.168.0.100", "eth0", 51).
+		Build()
+	if err != nil {
+		t.Fatalf("build cluster config with vip failed: %v", err)
+	}
+	if conf.APIEndpoint.AdvertiseAddress != "192.168.0.100" || conf.APIEndpoint.BindPort != 8443 {
+		t.Fatalf("api endpoint should be vip of loadbalance: %v", conf.APIEndpoint)
+	}
+}
+
+func TestClusterConfigBuilderInvalid(t *testing.T) {
+	if _, err := NewClusterConfigBuilder().AddWorker(&api.HostConfig{Name: "worker0", Address: "192.168.0.3"}).Build(); err == nil {
+		t.Fatalf("cluster without master should be invalid")
+	}
+	if _, err := NewClusterConfigBuilder().AddMaster(&api.HostConfig{Name: "master0", Address: "192.168.0.777"}).Build(); err == nil {
+		t.Fatalf("node with invalid address should be invalid")
+	}
+	if _, err := NewClusterConfigBuilder().
+		AddMaster(&api.HostConfig{Name: "master0", Address: "192.168.0.2"}).
+		WithNetwork("10.100.0.0", "", nil).
+		Build(); err == nil {
+		t.Fatalf("invalid pod cidr should be invalid")
+	}
+}
@@ -1,13 +1,17 @@
 package addons
 
-import "isula.org/eggo/pkg/api"
+import (
+	"context"
+
+	"isula.org/eggo/pkg/api"
+)
 
 // TODO: support run apply addons in eggo, not run in master
 
-func SetupAddons(cluster *api.ClusterConfig) error {
-	return setupAddons(cluster)
+func SetupAddons(ctx context.Context, cluster *api.ClusterConfig) error {
+	return setupAddons(ctx, cluster)
 }
 
-func CleanupAddons(cluster *api.ClusterConfig) error {
-	return cleanupAddons(cluster)
+func CleanupAddons(ctx context.Context, cluster *api.ClusterConfig) error {
+	return cleanupAddons(ctx, cluster)
 }
@@ -0,0 +1,116 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: apply addons from local file, url and inline yaml
+ ******************************************************************************/
+
+package addons
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/constants"
+	"isula.org/eggo/pkg/utils/kubectl"
+	"isula.org/eggo/pkg/utils/runner"
+)
+
+const (
+	fetchTimeout = 5 * time.Minute
+)
+
+func fetchManifest(url string) ([]byte, error) {
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("download %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s failed: %s", url, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("download %s failed: %v", url, err)
+	}
+	return data, nil
+}
+
+// loadManifest returns manifest of addon, it is read or downloaded on the machine running eggo
+func loadManifest(addon *api.AddonConfig) ([]byte, error) {
+	switch addon.Type {
+	case api.AddonTypeFile:
+		return ioutil.ReadFile(addon.Filename)
+	case api.AddonTypeURL:
+		data, err := fetchManifest(addon.URL)
+		if err != nil {
+			return nil, err
+		}
+		if addon.Sha256 != "" {
+			if sum := fmt.Sprintf("%x", sha256.Sum256(data)); sum != addon.Sha256 {
+				return nil, fmt.Errorf("sha256 of %s is %s, expect %s", addon.URL, sum, addon.Sha256)
+			}
+		}
+		return data, nil
+	case api.AddonTypeInline:
+		if addon.Content == "" {
+			return nil, fmt.Errorf("empty content of addon %s", addon.Name)
+		}
+		return []byte(addon.Content), nil
+	}
+	return nil, fmt.Errorf("unsupport type %s of addon %s", addon.Type, addon.Name)
+}
+
+func manifestPath(addon *api.AddonConfig) string {
+	return filepath.Join(constants.DefaultK8SAddonsDir, fmt.Sprintf("%s.yaml", addon.Name))
+}
+
+func applyManifest(r runner.Runner, addon *api.AddonConfig, cluster *api.ClusterConfig) error {
+	data, err := loadManifest(addon)
+	if err != nil {
+		return fmt.Errorf("load manifest of addon %s failed: %v", addon.Name, err)
+	}
+	yamlFile := manifestPath(addon)
+	shell := fmt.Sprintf("sudo -E /bin/sh -c \"mkdir -p %s && echo %s | base64 -d > %s\"",
+		constants.DefaultK8SAddonsDir, base64.StdEncoding.EncodeToString(data), yamlFile)
+	if _, err = r.RunCommand(shell); err != nil {
+		return fmt.Errorf("write manifest of addon %s failed: %v", addon.Name, err)
+	}
+	return kubectl.OperatorByYaml(r, kubectl.ApplyOpKey, yamlFile, cluster)
+}
+
+func applyManifests(r runner.Runner, cluster *api.ClusterConfig) error {
+	for _, addon := range cluster.Addons {
+		if err := applyManifest(r, addon, cluster); err != nil {
+			return err
+		}
+		logrus.Infof("apply addon %s success", addon.Name)
+	}
+	return nil
+}
+
+// deleteManifests deletes addons by manifests saved on master, so url is not downloaded again
+func deleteManifests(r runner.Runner, cluster *api.ClusterConfig) {
+	for _, addon := range cluster.Addons {
+		if err := kubectl.OperatorByYaml(r, kubectl.DeleteOpKey, manifestPath(addon), cluster); err != nil {
+			logrus.Warnf("delete addon %s failed: %v", addon.Name, err)
+		}
+	}
+}
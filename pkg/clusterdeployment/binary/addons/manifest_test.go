@@ -0,0 +1,99 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for addons of manifest
+ ******************************************************************************/
+
+package addons
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner/runnertest"
+)
+
+const testManifest = "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: test\n"
+
+func TestApplyManifests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/test.yaml" {
+			http.NotFound(w, req)
+			return
+		}
+		fmt.Fprint(w, testManifest)
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "test.yaml")
+	if err := ioutil.WriteFile(file, []byte(testManifest), 0600); err != nil {
+		t.Fatalf("write manifest failed: %v", err)
+	}
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte(testManifest)))
+
+	ccfg := &api.ClusterConfig{
+		Addons: []*api.AddonConfig{
+			{Name: "from-file", Type: api.AddonTypeFile, Filename: file},
+			{Name: "from-url", Type: api.AddonTypeURL, URL: server.URL + "/test.yaml", Sha256: sum},
+			{Name: "from-inline", Type: api.AddonTypeInline, Content: testManifest},
+		},
+	}
+	r := &runnertest.Runner{}
+	if err := applyManifests(r, ccfg); err != nil {
+		t.Fatalf("apply manifests failed: %v", err)
+	}
+	if len(r.Commands) != 3 || len(r.Shells) != 3 {
+		t.Fatalf("expect 3 manifests written and applied, get commands: %v, shells: %v", r.Commands, r.Shells)
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte(testManifest))
+	for i, addon := range ccfg.Addons {
+		yamlFile := fmt.Sprintf("/etc/kubernetes/addons/%s.yaml", addon.Name)
+		if !strings.Contains(r.Commands[i], encoded) || !strings.HasSuffix(r.Commands[i], "> "+yamlFile+"\"") {
+			t.Fatalf("unexpect command to write manifest of %s: %s", addon.Type, r.Commands[i])
+		}
+		if !strings.Contains(r.Shells[i], "kubectl apply -f "+yamlFile) ||
+			!strings.Contains(r.Shells[i], "export KUBECONFIG=/etc/kubernetes/admin.conf") {
+			t.Fatalf("unexpect apply of %s: %s", addon.Type, r.Shells[i])
+		}
+	}
+
+	invalids := []*api.AddonConfig{
+		{Name: "not-exist", Type: api.AddonTypeFile, Filename: filepath.Join(t.TempDir(), "not-exist.yaml")},
+		{Name: "not-found", Type: api.AddonTypeURL, URL: server.URL + "/not-found.yaml"},
+		{Name: "mismatch", Type: api.AddonTypeURL, URL: server.URL + "/test.yaml", Sha256: strings.Repeat("0", 64)},
+		{Name: "empty", Type: api.AddonTypeInline},
+		{Name: "unknown", Type: "yaml"},
+	}
+	for _, addon := range invalids {
+		r = &runnertest.Runner{}
+		if err := applyManifests(r, &api.ClusterConfig{Addons: []*api.AddonConfig{addon}}); err == nil {
+			t.Fatalf("expect apply addon %s failed", addon.Name)
+		}
+		if len(r.Commands) != 0 || len(r.Shells) != 0 {
+			t.Fatalf("invalid addon %s should not be applied: %v, %v", addon.Name, r.Commands, r.Shells)
+		}
+	}
+
+	r = &runnertest.Runner{}
+	deleteManifests(r, ccfg)
+	if len(r.Shells) != 3 || !strings.Contains(r.Shells[1], "kubectl delete -f /etc/kubernetes/addons/from-url.yaml") {
+		t.Fatalf("unexpect delete of addons: %v", r.Shells)
+	}
+}
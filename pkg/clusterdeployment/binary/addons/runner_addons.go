@@ -1,6 +1,7 @@
 package addons
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"time"
@@ -19,6 +20,7 @@ type SetupAddonsTask struct {
 	yaml       []*api.PackageConfig
 	srcPath    string
 	kubeconfig string
+	cluster    *api.ClusterConfig
 }
 
 func (ct *SetupAddonsTask) Name() string {
@@ -28,8 +30,13 @@ func (ct *SetupAddonsTask) Name() string {
 func (ct *SetupAddonsTask) Run(r runner.Runner, hcf *api.HostConfig) error {
 	logrus.Info("do apply addons...")
 
-	yamlDep := dependency.NewDependencyYaml(ct.srcPath, ct.kubeconfig, ct.yaml)
-	if err := yamlDep.Install(r); err != nil {
+	if len(ct.yaml) != 0 {
+		yamlDep := dependency.NewDependencyYaml(ct.srcPath, ct.kubeconfig, ct.yaml)
+		if err := yamlDep.Install(r); err != nil {
+			return err
+		}
+	}
+	if err := applyManifests(r, ct.cluster); err != nil {
 		return err
 	}
 
@@ -48,13 +55,13 @@ func getAddons(cluster *api.ClusterConfig) []*api.PackageConfig {
 	return yaml
 }
 
-func setupAddons(cluster *api.ClusterConfig) error {
+func setupAddons(ctx context.Context, cluster *api.ClusterConfig) error {
 	if cluster == nil {
 		return fmt.Errorf("invalid cluster config")
 	}
 
 	yaml := getAddons(cluster)
-	if len(yaml) == 0 {
+	if len(yaml) == 0 && len(cluster.Addons) == 0 {
 		logrus.Warn("no addons load")
 		return nil
 	}
@@ -66,6 +73,7 @@ func setupAddons(cluster *api.ClusterConfig) error {
 		yaml:       yaml,
 		srcPath:    yamlPath,
 		kubeconfig: kubeconfig,
+		cluster:    cluster,
 	})
 	var masters []string
 	for _, n := range cluster.Nodes {
@@ -74,12 +82,12 @@ func setupAddons(cluster *api.ClusterConfig) error {
 		}
 	}
 
-	useMaster, err := nodemanager.RunTaskOnOneNode(t, masters)
+	useMaster, err := nodemanager.RunTaskOnOneNode(ctx, t, masters)
 	if err != nil {
 		return err
 	}
 
-	err = nodemanager.WaitNodesFinish([]string{useMaster}, time.Minute*constants.DefaultTaskWaitMinutes)
+	err = nodemanager.WaitNodesFinish(ctx, []string{useMaster}, time.Minute*constants.DefaultTaskWaitMinutes)
 	if err != nil {
 		return err
 	}
@@ -92,6 +100,7 @@ type CleanupAddonsTask struct {
 	yaml       []*api.PackageConfig
 	srcPath    string
 	kubeconfig string
+	cluster    *api.ClusterConfig
 }
 
 func (ct *CleanupAddonsTask) Name() string {
@@ -101,22 +110,25 @@ func (ct *CleanupAddonsTask) Name() string {
 func (ct *CleanupAddonsTask) Run(r runner.Runner, hcf *api.HostConfig) error {
 	logrus.Info("do remove addons...")
 
-	yamlDep := dependency.NewDependencyYaml(ct.srcPath, ct.kubeconfig, ct.yaml)
-	if err := yamlDep.Remove(r); err != nil {
-		return err
+	deleteManifests(r, ct.cluster)
+	if len(ct.yaml) != 0 {
+		yamlDep := dependency.NewDependencyYaml(ct.srcPath, ct.kubeconfig, ct.yaml)
+		if err := yamlDep.Remove(r); err != nil {
+			return err
+		}
 	}
 
 	logrus.Info("remove addons success")
 	return nil
 }
 
-func cleanupAddons(cluster *api.ClusterConfig) error {
+func cleanupAddons(ctx context.Context, cluster *api.ClusterConfig) error {
 	if cluster == nil {
 		return fmt.Errorf("invalid cluster config")
 	}
 
 	yaml := getAddons(cluster)
-	if len(yaml) == 0 {
+	if len(yaml) == 0 && len(cluster.Addons) == 0 {
 		logrus.Warn("no addons load")
 		return nil
 	}
@@ -128,6 +140,7 @@ func cleanupAddons(cluster *api.ClusterConfig) error {
 		yaml:       yaml,
 		srcPath:    yamlPath,
 		kubeconfig: kubeconfig,
+		cluster:    cluster,
 	})
 	var masters []string
 	for _, n := range cluster.Nodes {
@@ -136,11 +149,11 @@ func cleanupAddons(cluster *api.ClusterConfig) error {
 		}
 	}
 
-	useMaster, err := nodemanager.RunTaskOnOneNode(t, masters)
+	useMaster, err := nodemanager.RunTaskOnOneNode(ctx, t, masters)
 	if err != nil {
 		return err
 	}
-	err = nodemanager.WaitNodesFinishWithProgress([]string{useMaster}, time.Minute)
+	err = nodemanager.WaitNodesFinishWithProgress(ctx, []string{useMaster}, time.Minute)
 	if err != nil {
 		return err
 	}
@@ -15,8 +15,12 @@
 package binary
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"isula.org/eggo/pkg/api"
 	"isula.org/eggo/pkg/clusterdeployment/binary/addons"
@@ -28,10 +32,14 @@ import (
 	"isula.org/eggo/pkg/clusterdeployment/binary/etcdcluster"
 	"isula.org/eggo/pkg/clusterdeployment/binary/infrastructure"
 	"isula.org/eggo/pkg/clusterdeployment/binary/loadbalance"
+	"isula.org/eggo/pkg/clusterdeployment/binary/network"
+	"isula.org/eggo/pkg/clusterdeployment/binary/upgradecluster"
 	"isula.org/eggo/pkg/clusterdeployment/manager"
+	"isula.org/eggo/pkg/constants"
 	"isula.org/eggo/pkg/utils"
 	"isula.org/eggo/pkg/utils/dependency"
 	"isula.org/eggo/pkg/utils/kubectl"
+	"isula.org/eggo/pkg/utils/metrics"
 	"isula.org/eggo/pkg/utils/nodemanager"
 	"isula.org/eggo/pkg/utils/runner"
 	"isula.org/eggo/pkg/utils/task"
@@ -84,7 +92,7 @@ func (bcp *BinaryClusterDeployment) registerNode(hcf *api.HostConfig) error {
 		logrus.Debugf("node: %s is already registered", hcf.Address)
 		return nil
 	}
-	r, err := runner.NewSSHRunner(hcf)
+	r, err := runner.NewRunner(hcf, &bcp.config.SSHHostKey, &bcp.config.SSHKeepAlive, bcp.config.ConnectTimeout)
 	if err != nil {
 		logrus.Errorf("connect node: %s failed: %v", hcf.Address, err)
 		return err
@@ -99,40 +107,88 @@ func (bcp *BinaryClusterDeployment) registerNode(hcf *api.HostConfig) error {
 	return nil
 }
 
+// registerNodes connects all nodes, failures of nodes are reported together unless fail fast
 func (bcp *BinaryClusterDeployment) registerNodes() error {
-	var err error
-	defer func() {
-		if err != nil {
-			bcp.Finish()
+	errs := utils.NewMultiError(bcp.config.FailFast)
+	for _, cfg := range bcp.config.Nodes {
+		if err := bcp.registerNode(cfg); err != nil {
+			if errs.Add(fmt.Errorf("register node %s(%s) failed: %v", cfg.Name, cfg.Address, err)) {
+				break
+			}
 		}
-	}()
+	}
 
-	for _, cfg := range bcp.config.Nodes {
-		err = bcp.registerNode(cfg)
-		if err != nil {
-			return err
+	err := errs.ErrorOrNil()
+	if err != nil {
+		bcp.Finish()
+	}
+	return err
+}
+
+var masterTaint = kubectl.Taint{
+	Key:    "node-role.kubernetes.io/master",
+	Value:  "",
+	Effect: "NoSchedule",
+}
+
+// taints and labels of kubernetes node, master which is also worker is unschedulable
+// unless scheduleOnMaster is set, then the master taint is removed
+func nodeTaintsAndLabels(node *api.HostConfig, roles uint16, scheduleOnMaster bool) (map[string]string,
+	[]kubectl.Taint, []kubectl.Taint, error) {
+	labels := make(map[string]string)
+	var taints, removeTaints []kubectl.Taint
+	if utils.IsType(roles, (api.Master | api.Worker)) {
+		if scheduleOnMaster {
+			removeTaints = append(removeTaints, masterTaint)
+		} else {
+			taints = append(taints, masterTaint)
 		}
+		labels["node-role.kubernetes.io/master"] = ""
+		labels["node-role.kubernetes.io/control-plane"] = ""
 	}
-	return nil
+
+	for k, v := range node.Labels {
+		labels[k] = v
+	}
+	userTaints, err := kubectl.ParseTaints(node.Taints)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return labels, append(taints, userTaints...), removeTaints, nil
 }
 
-func taintAndLabelNode(clusterID string, name string) error {
-	taints := []kubectl.Taint{
-		{
-			Key:    "node-role.kubernetes.io/master",
-			Value:  "",
-			Effect: "NoSchedule",
-		},
+// isSingleWorkerMaster returns true if the only worker of cluster is also master, such as all-in-one cluster
+func isSingleWorkerMaster(ccfg *api.ClusterConfig) bool {
+	var workers []*api.HostConfig
+	for _, n := range ccfg.Nodes {
+		if utils.IsType(n.Type, api.Worker) {
+			workers = append(workers, n)
+		}
 	}
-	labels := make(map[string]string)
-	labels["node-role.kubernetes.io/master"] = ""
-	labels["node-role.kubernetes.io/control-plane"] = ""
-	err := kubectl.WaitNodeRegister(name, clusterID)
+	return len(workers) == 1 && utils.IsType(workers[0].Type, api.Master)
+}
+
+func taintAndLabelNode(ccfg *api.ClusterConfig, node *api.HostConfig, roles uint16) error {
+	// only worker register as node of kubernetes
+	if !utils.IsType(roles, api.Worker) {
+		return nil
+	}
+	// workloads of single node cluster can only run on the master
+	scheduleOnMaster := ccfg.ControlPlane.ScheduleWorkloadsOnMaster || isSingleWorkerMaster(ccfg)
+	labels, taints, removeTaints, err := nodeTaintsAndLabels(node, roles, scheduleOnMaster)
 	if err != nil {
-		logrus.Errorf("wait node: %s joined failed: %v", name, err)
 		return err
 	}
-	err = kubectl.NodeTaintAndLabel(clusterID, name, labels, taints)
+	if len(labels) == 0 && len(taints) == 0 && len(removeTaints) == 0 {
+		return nil
+	}
+
+	err = kubectl.WaitNodeRegister(node.Name, ccfg.Name)
+	if err != nil {
+		logrus.Errorf("wait node: %s joined failed: %v", node.Name, err)
+		return err
+	}
+	err = kubectl.NodeTaintAndLabel(ccfg.Name, node.Name, labels, taints, removeTaints)
 	if err != nil {
 		return err
 	}
@@ -140,21 +196,22 @@ func taintAndLabelNode(clusterID string, name string) error {
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) taintAndLabelMasterNodes() error {
+func (bcp *BinaryClusterDeployment) taintAndLabelNodes() error {
+	errs := utils.NewMultiError(bcp.config.FailFast)
 	for _, node := range bcp.config.Nodes {
-		if (node.Type&api.Master != 0) && (node.Type&api.Worker != 0) {
-			if err := taintAndLabelNode(bcp.config.Name, node.Name); err != nil {
-				return err
+		if err := taintAndLabelNode(bcp.config, node, node.Type); err != nil {
+			if errs.Add(fmt.Errorf("taint and label node %s failed: %v", node.Name, err)) {
+				break
 			}
 		}
 	}
 
-	return nil
+	return errs.ErrorOrNil()
 }
 
-func (bcp *BinaryClusterDeployment) prepareCoredns() error {
+func (bcp *BinaryClusterDeployment) prepareCoredns(ctx context.Context) error {
 	// Setup coredns at here, like need addons
-	if err := coredns.CorednsSetup(bcp.config); err != nil {
+	if err := coredns.CorednsSetup(ctx, bcp.config); err != nil {
 		logrus.Errorf("setup coredns failed: %v", err)
 		return err
 	}
@@ -162,9 +219,9 @@ func (bcp *BinaryClusterDeployment) prepareCoredns() error {
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) cleanupCoredns() error {
+func (bcp *BinaryClusterDeployment) cleanupCoredns(ctx context.Context) error {
 	// cleanup coredns at here
-	if err := coredns.CorednsCleanup(bcp.config); err != nil {
+	if err := coredns.CorednsCleanup(ctx, bcp.config); err != nil {
 		logrus.Errorf("cleanup coredns failed: %v", err)
 		return err
 	}
@@ -172,8 +229,60 @@ func (bcp *BinaryClusterDeployment) cleanupCoredns() error {
 	return nil
 }
 
+func nodeNames(hosts ...*api.HostConfig) []string {
+	var names []string
+	for _, h := range hosts {
+		if h != nil {
+			names = append(names, h.Name)
+		}
+	}
+	return names
+}
+
+func (bcp *BinaryClusterDeployment) nodeNamesOfType(role uint16) []string {
+	var names []string
+	for _, n := range bcp.config.Nodes {
+		if utils.IsType(n.Type, role) {
+			names = append(names, n.Name)
+		}
+	}
+	return names
+}
+
+// return all nodes of cluster, include hcf which may be not in cluster yet
+func (bcp *BinaryClusterDeployment) nodesWith(hcf *api.HostConfig) []*api.HostConfig {
+	nodes := []*api.HostConfig{hcf}
+	for _, n := range bcp.config.Nodes {
+		if n.Address != hcf.Address {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// prepareAndCheckPackageSrc prepares package sources of all arches of nodes, and checks
+// them together, so that version skew between arches is found before install any node,
+// kubernetes version in package sources must be k8sVersion if it is set
+func prepareAndCheckPackageSrc(pcfg *api.PackageSrcConfig, nodes []*api.HostConfig, dir string, k8sVersion string) error {
+	prepared := make(map[string]bool)
+	for _, n := range nodes {
+		arch := strings.ToLower(n.Arch)
+		if prepared[arch] {
+			continue
+		}
+		if err := infrastructure.PreparePackageSrc(pcfg, arch, dir); err != nil {
+			return fmt.Errorf("prepare package source of arch %s failed: %v", arch, err)
+		}
+		prepared[arch] = true
+	}
+
+	// check package archive before connect to node
+	return infrastructure.CheckPackageSrcK8sVersion(pcfg, nodes, k8sVersion)
+}
+
 // support new apis
-func (bcp *BinaryClusterDeployment) MachineInfraSetup(hcf *api.HostConfig) error {
+func (bcp *BinaryClusterDeployment) MachineInfraSetup(ctx context.Context, hcf *api.HostConfig) error {
+	defer metrics.StartPhase("MachineInfraSetup", nodeNames(hcf)...)()
 	if hcf == nil {
 		logrus.Warnf("empty host config")
 		return nil
@@ -181,28 +290,29 @@ func (bcp *BinaryClusterDeployment) MachineInfraSetup(hcf *api.HostConfig) error
 
 	logrus.Infof("do setup %s infrastructure...", hcf.Address)
 
+	// download package of http(s) url into cluster home, then distribute it to nodes as local package
+	pkgDir := filepath.Join(api.GetClusterHomePath(bcp.config.Name), "packages")
+	if err := prepareAndCheckPackageSrc(&bcp.config.PackageSrc, bcp.nodesWith(hcf), pkgDir, bcp.config.KubernetesVersion); err != nil {
+		logrus.Errorf("check package source failed: %v", err)
+		return err
+	}
+
 	if err := bcp.registerNode(hcf); err != nil {
 		logrus.Errorf("register node failed: %v", err)
 		return err
 	}
 
-	role := []uint16{api.Master, api.Worker, api.ETCD, api.LoadBalance}
-	for _, r := range role {
-		if !utils.IsType(hcf.Type, r) {
-			continue
-		}
-
-		err := infrastructure.NodeInfrastructureSetup(bcp.config, hcf.Address, r)
-		if err != nil {
-			return err
-		}
+	// node with multiple roles, such as all-in-one node, installs union of packages of roles once
+	if err := infrastructure.NodeInfrastructureSetup(ctx, bcp.config, hcf.Address, hcf.Type); err != nil {
+		return err
 	}
 
 	logrus.Infof("setup %s infrastructure success", hcf.Address)
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) MachineInfraDestroy(hcf *api.HostConfig) error {
+func (bcp *BinaryClusterDeployment) MachineInfraDestroy(ctx context.Context, hcf *api.HostConfig) error {
+	defer metrics.StartPhase("MachineInfraDestroy", nodeNames(hcf)...)()
 	if hcf == nil {
 		logrus.Warnf("empty host config")
 		return nil
@@ -210,7 +320,7 @@ func (bcp *BinaryClusterDeployment) MachineInfraDestroy(hcf *api.HostConfig) err
 
 	logrus.Infof("do destroy %s infrastructure...", hcf.Address)
 
-	err := infrastructure.NodeInfrastructureDestroy(bcp.config, hcf)
+	err := infrastructure.NodeInfrastructureDestroy(ctx, bcp.config, hcf)
 	if err != nil {
 		logrus.Errorf("role %d infrastructure destroy failed: %v", hcf.Type, err)
 	}
@@ -219,9 +329,18 @@ func (bcp *BinaryClusterDeployment) MachineInfraDestroy(hcf *api.HostConfig) err
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) EtcdClusterSetup() error {
+func (bcp *BinaryClusterDeployment) EtcdClusterSetup(ctx context.Context) error {
+	defer metrics.StartPhase("EtcdClusterSetup", bcp.nodeNamesOfType(api.ETCD)...)()
+	if bcp.config.EtcdCluster.External {
+		logrus.Info("use external etcd cluster, skip deploy etcd...")
+		if err := etcdcluster.InitExternal(bcp.config); err != nil {
+			return fmt.Errorf("use external etcd cluster failed: %v", err)
+		}
+		return nil
+	}
+
 	logrus.Info("do deploy etcd cluster...")
-	err := etcdcluster.Init(bcp.config)
+	err := etcdcluster.Init(ctx, bcp.config)
 	if err != nil {
 		logrus.Errorf("deploy etcd cluster failed: %v", err)
 	} else {
@@ -230,9 +349,10 @@ func (bcp *BinaryClusterDeployment) EtcdClusterSetup() error {
 	return err
 }
 
-func (bcp *BinaryClusterDeployment) EtcdClusterDestroy() error {
+func (bcp *BinaryClusterDeployment) EtcdClusterDestroy(ctx context.Context) error {
+	defer metrics.StartPhase("EtcdClusterDestroy", bcp.nodeNamesOfType(api.ETCD)...)()
 	logrus.Info("do etcd cluster destroy...")
-	if err := cleanupcluster.CleanupAllEtcds(bcp.config); err != nil {
+	if err := cleanupcluster.CleanupAllEtcds(ctx, bcp.config); err != nil {
 		return fmt.Errorf("etcd cluster destroy failed: %v", err)
 	}
 
@@ -240,9 +360,14 @@ func (bcp *BinaryClusterDeployment) EtcdClusterDestroy() error {
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) EtcdNodeSetup(machine *api.HostConfig) error {
+func (bcp *BinaryClusterDeployment) EtcdNodeSetup(ctx context.Context, machine *api.HostConfig) error {
+	defer metrics.StartPhase("EtcdNodeSetup", nodeNames(machine)...)()
+	if bcp.config.EtcdCluster.External {
+		logrus.Info("external etcd, ignore setup etcd node")
+		return nil
+	}
 	logrus.Info("do etcd node setup...")
-	if err := etcdcluster.AddMember(bcp.config, machine); err != nil {
+	if err := etcdcluster.AddMember(ctx, bcp.config, machine); err != nil {
 		return fmt.Errorf("etcd add member %v failed: %v", machine.Name, err)
 	}
 
@@ -250,9 +375,10 @@ func (bcp *BinaryClusterDeployment) EtcdNodeSetup(machine *api.HostConfig) error
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) EtcdNodeDestroy(machine *api.HostConfig) error {
+func (bcp *BinaryClusterDeployment) EtcdNodeDestroy(ctx context.Context, machine *api.HostConfig) error {
+	defer metrics.StartPhase("EtcdNodeDestroy", nodeNames(machine)...)()
 	logrus.Info("do etcd node destroy...")
-	if err := cleanupcluster.CleanupEtcdMember(bcp.config, machine); err != nil {
+	if err := cleanupcluster.CleanupEtcdMember(ctx, bcp.config, machine); err != nil {
 		return fmt.Errorf("cleanup etcd member %v failed: %v", machine.Name, err)
 	}
 
@@ -260,16 +386,34 @@ func (bcp *BinaryClusterDeployment) EtcdNodeDestroy(machine *api.HostConfig) err
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) ClusterControlPlaneInit(master *api.HostConfig) error {
+func (bcp *BinaryClusterDeployment) ClusterControlPlaneInit(ctx context.Context, master *api.HostConfig) error {
+	defer metrics.StartPhase("ClusterControlPlaneInit", nodeNames(master)...)()
 	logrus.Info("do init control plane...")
 	if !bcp.exists(master.Address) {
 		logrus.Errorf("cannot found master %s", master.Address)
 		return fmt.Errorf("cannot found master %s", master.Address)
 	}
-	return controlplane.Init(bcp.config, master.Address)
+	if err := controlplane.Init(ctx, bcp.config, master.Address); err != nil {
+		return err
+	}
+
+	if !network.IsBuiltinPlugin(bcp.config.Network.Plugin) {
+		return nil
+	}
+	// builtin network plugin depends on the initialized control plane
+	if err := nodemanager.WaitNodesFinish(ctx, []string{master.Address}, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
+		logrus.Errorf("wait control plane init on %s failed: %v", master.Address, err)
+		return err
+	}
+	if err := network.SetupNetwork(ctx, bcp.config); err != nil {
+		logrus.Errorf("[network] setup network plugin %s failed: %v", bcp.config.Network.Plugin, err)
+		return err
+	}
+	return nil
 }
 
-func (bcp *BinaryClusterDeployment) ClusterNodeJoin(node *api.HostConfig) error {
+func (bcp *BinaryClusterDeployment) ClusterNodeJoin(ctx context.Context, node *api.HostConfig) error {
+	defer metrics.StartPhase("ClusterNodeJoin", nodeNames(node)...)()
 	if node == nil {
 		logrus.Warnf("empty join node config")
 		return nil
@@ -278,7 +422,7 @@ func (bcp *BinaryClusterDeployment) ClusterNodeJoin(node *api.HostConfig) error
 	logrus.Infof("do join node %s...", node.Address)
 
 	if utils.IsType(node.Type, api.Master) {
-		err := bootstrap.JoinMaster(bcp.config, node)
+		err := bootstrap.JoinMaster(ctx, bcp.config, node)
 		if err != nil {
 			return err
 		}
@@ -297,7 +441,7 @@ func (bcp *BinaryClusterDeployment) ClusterNodeJoin(node *api.HostConfig) error
 			return fmt.Errorf("no useful controlPlane")
 		}
 
-		err := bootstrap.JoinWorker(bcp.config, controlPlane, node)
+		err := bootstrap.JoinWorker(ctx, bcp.config, controlPlane, node)
 		if err != nil {
 			return err
 		}
@@ -307,41 +451,61 @@ func (bcp *BinaryClusterDeployment) ClusterNodeJoin(node *api.HostConfig) error
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) ClusterNodeCleanup(node *api.HostConfig, delType uint16) error {
+func (bcp *BinaryClusterDeployment) ClusterNodeCleanup(ctx context.Context, node *api.HostConfig, delType uint16) error {
+	defer metrics.StartPhase("ClusterNodeCleanup", nodeNames(node)...)()
 	logrus.Info("do node cleanup...")
-	if err := cleanupcluster.CleanupNode(bcp.config, node, delType); err != nil {
+	if err := cleanupcluster.CleanupNode(ctx, bcp.config, node, delType); err != nil {
 		return fmt.Errorf("cleanup node %v failed: %v", node.Name, err)
 	}
 	logrus.Info("node cleanup success.")
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) ClusterUpgrade() error {
-	// TODO: add implement
+func (bcp *BinaryClusterDeployment) ClusterNodeUpgrade(ctx context.Context, node *api.HostConfig, conf *api.UpgradeConfig) error {
+	defer metrics.StartPhase("ClusterNodeUpgrade", nodeNames(node)...)()
+	if node == nil || conf == nil {
+		logrus.Warnf("empty upgrade node config")
+		return nil
+	}
+
+	logrus.Infof("do upgrade node %s to %s...", node.Address, conf.TargetVersion)
+
+	pkgDir := filepath.Join(api.GetClusterHomePath(bcp.config.Name), "packages", conf.TargetVersion)
+	if err := prepareAndCheckPackageSrc(&conf.PackageSrc, bcp.nodesWith(node), pkgDir, conf.TargetVersion); err != nil {
+		logrus.Errorf("check package source of upgrade failed: %v", err)
+		return err
+	}
+
+	if err := upgradecluster.UpgradeNode(ctx, bcp.config, conf, node); err != nil {
+		return fmt.Errorf("upgrade node %v failed: %v", node.Name, err)
+	}
+
+	logrus.Infof("upgrade node %s success", node.Address)
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) ClusterStatus() (*api.ClusterStatus, error) {
+func (bcp *BinaryClusterDeployment) ClusterStatus(ctx context.Context) (*api.ClusterStatus, error) {
 	// TODO: add implement
 	return nil, nil
 }
 
-func (bcp *BinaryClusterDeployment) AddonsSetup() error {
+func (bcp *BinaryClusterDeployment) AddonsSetup(ctx context.Context) error {
+	defer metrics.StartPhase("AddonsSetup", bcp.nodeNamesOfType(api.Master)...)()
 	logrus.Info("do apply addons...")
 	// taint and label master node before apply addons
-	err := bcp.taintAndLabelMasterNodes()
+	err := bcp.taintAndLabelNodes()
 	if err != nil {
-		logrus.Errorf("[addons] taint master node failed: %v", err)
+		logrus.Errorf("[addons] taint and label nodes failed: %v", err)
 		return err
 	}
 
-	err = bcp.prepareCoredns()
+	err = bcp.prepareCoredns(ctx)
 	if err != nil {
 		logrus.Errorf("[addons] prepare coredns failed: %v", err)
 		return err
 	}
 
-	err = addons.SetupAddons(bcp.config)
+	err = addons.SetupAddons(ctx, bcp.config)
 	if err != nil {
 		logrus.Errorf("[addons] setup addons failed: %v", err)
 		return err
@@ -351,22 +515,29 @@ func (bcp *BinaryClusterDeployment) AddonsSetup() error {
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) AddonsDestroy() error {
+func (bcp *BinaryClusterDeployment) AddonsDestroy(ctx context.Context) error {
+	defer metrics.StartPhase("AddonsDestroy", bcp.nodeNamesOfType(api.Master)...)()
 	logrus.Info("do destroy addons...")
-	err := addons.CleanupAddons(bcp.config)
+	err := addons.CleanupAddons(ctx, bcp.config)
 	if err != nil {
 		logrus.Errorf("[addons] destroy addons failed: %v", err)
 	}
-	err = bcp.cleanupCoredns()
+	err = bcp.cleanupCoredns(ctx)
 	if err != nil {
 		logrus.Errorf("[addons] cleanup coredns failed: %v", err)
 	}
+	if network.IsBuiltinPlugin(bcp.config.Network.Plugin) {
+		if err = network.CleanupNetwork(ctx, bcp.config); err != nil {
+			logrus.Errorf("[network] cleanup network plugin %s failed: %v", bcp.config.Network.Plugin, err)
+		}
+	}
 
 	logrus.Info("[addons] destroy addons success.")
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) LoadBalancerSetup(lb *api.HostConfig) error {
+func (bcp *BinaryClusterDeployment) LoadBalancerSetup(ctx context.Context, lb *api.HostConfig) error {
+	defer metrics.StartPhase("LoadBalancerSetup", nodeNames(lb)...)()
 	if lb == nil {
 		logrus.Warnf("empty loadbalancer config")
 		return nil
@@ -374,7 +545,7 @@ func (bcp *BinaryClusterDeployment) LoadBalancerSetup(lb *api.HostConfig) error
 
 	logrus.Info("do deploy loadbalancer...")
 
-	if err := loadbalance.SetupLoadBalancer(bcp.config, lb); err != nil {
+	if err := loadbalance.SetupLoadBalancer(ctx, bcp.config, lb); err != nil {
 		logrus.Errorf("bootstrap falied: %v", err)
 		return err
 	}
@@ -383,7 +554,8 @@ func (bcp *BinaryClusterDeployment) LoadBalancerSetup(lb *api.HostConfig) error
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) LoadBalancerUpdate(lb *api.HostConfig) error {
+func (bcp *BinaryClusterDeployment) LoadBalancerUpdate(ctx context.Context, lb *api.HostConfig) error {
+	defer metrics.StartPhase("LoadBalancerUpdate", nodeNames(lb)...)()
 	if lb == nil {
 		logrus.Warnf("empty loadbalancer config")
 		return nil
@@ -391,7 +563,7 @@ func (bcp *BinaryClusterDeployment) LoadBalancerUpdate(lb *api.HostConfig) error
 
 	logrus.Info("do deploy loadbalancer...")
 
-	if err := loadbalance.UpdateLoadBalancer(bcp.config, lb); err != nil {
+	if err := loadbalance.UpdateLoadBalancer(ctx, bcp.config, lb); err != nil {
 		logrus.Errorf("bootstrap falied: %v", err)
 		return err
 	}
@@ -400,13 +572,14 @@ func (bcp *BinaryClusterDeployment) LoadBalancerUpdate(lb *api.HostConfig) error
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) LoadBalancerDestroy(lb *api.HostConfig) error {
+func (bcp *BinaryClusterDeployment) LoadBalancerDestroy(ctx context.Context, lb *api.HostConfig) error {
+	defer metrics.StartPhase("LoadBalancerDestroy", nodeNames(lb)...)()
 	if lb == nil {
 		logrus.Warnf("empty loadbalancer config")
 		return nil
 	}
 
-	if terr := cleanupcluster.CleanupLoadBalance(bcp.config, lb); terr != nil {
+	if terr := cleanupcluster.CleanupLoadBalance(ctx, bcp.config, lb); terr != nil {
 		logrus.Warnf("clean up loadbalance failed: %v", terr)
 	}
 	return nil
@@ -421,65 +594,70 @@ func (bcp *BinaryClusterDeployment) Finish() {
 	logrus.Info("do finish binary deployment success")
 }
 
-func (bcp *BinaryClusterDeployment) PreCreateClusterHooks() error {
+func (bcp *BinaryClusterDeployment) PreCreateClusterHooks(ctx context.Context) error {
+	defer metrics.StartPhase("PreCreateClusterHooks", nodeNames(bcp.config.Nodes...)...)()
 	role := []uint16{api.LoadBalance, api.ETCD, api.Master, api.Worker}
-	if err := dependency.ExecuteCmdHooks(bcp.config, bcp.config.Nodes, api.HookOpDeploy, api.ClusterPrehookType); err != nil {
+	if err := dependency.ExecuteCmdHooks(ctx, bcp.config, bcp.config.Nodes, api.HookOpDeploy, api.ClusterPrehookType); err != nil {
 		return err
 	}
 
-	if err := dependency.HookSchedule(bcp.config, bcp.config.Nodes, role, api.SchedulePreJoin); err != nil {
+	if err := dependency.HookSchedule(ctx, bcp.config, bcp.config.Nodes, role, api.SchedulePreJoin); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) PostCreateClusterHooks(nodes []*api.HostConfig) error {
+func (bcp *BinaryClusterDeployment) PostCreateClusterHooks(ctx context.Context, nodes []*api.HostConfig) error {
+	defer metrics.StartPhase("PostCreateClusterHooks", nodeNames(nodes...)...)()
 	role := []uint16{api.LoadBalance, api.ETCD, api.Master, api.Worker}
-	if err := dependency.HookSchedule(bcp.config, nodes, role, api.SchedulePostJoin); err != nil {
+	if err := dependency.HookSchedule(ctx, bcp.config, nodes, role, api.SchedulePostJoin); err != nil {
 		return err
 	}
 
-	if err := checkK8sServices(nodes); err != nil {
+	if err := checkK8sServices(ctx, nodes); err != nil {
 		return err
 	}
-	if err := dependency.ExecuteCmdHooks(bcp.config, bcp.config.Nodes, api.HookOpDeploy, api.ClusterPosthookType); err != nil {
+	if err := dependency.ExecuteCmdHooks(ctx, bcp.config, bcp.config.Nodes, api.HookOpDeploy, api.ClusterPosthookType); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) PreDeleteClusterHooks() {
+func (bcp *BinaryClusterDeployment) PreDeleteClusterHooks(ctx context.Context) {
+	defer metrics.StartPhase("PreDeleteClusterHooks", nodeNames(bcp.config.Nodes...)...)()
 	role := []uint16{api.Worker, api.Master, api.ETCD, api.LoadBalance}
-	if err := dependency.ExecuteCmdHooks(bcp.config, bcp.config.Nodes, api.HookOpCleanup, api.ClusterPrehookType); err != nil {
+	if err := dependency.ExecuteCmdHooks(ctx, bcp.config, bcp.config.Nodes, api.HookOpCleanup, api.ClusterPrehookType); err != nil {
 		logrus.Warnf("Ignore: Delete cluster prehook failed:%v", err)
 	}
-	if err := dependency.HookSchedule(bcp.config, bcp.config.Nodes, role, api.SchedulePreCleanup); err != nil {
+	if err := dependency.HookSchedule(ctx, bcp.config, bcp.config.Nodes, role, api.SchedulePreCleanup); err != nil {
 		logrus.Warnf("Ignore: Delete cluster PreHook failed: %v", err)
 	}
 }
 
-func (bcp *BinaryClusterDeployment) PostDeleteClusterHooks() {
+func (bcp *BinaryClusterDeployment) PostDeleteClusterHooks(ctx context.Context) {
+	defer metrics.StartPhase("PostDeleteClusterHooks", nodeNames(bcp.config.Nodes...)...)()
 	role := []uint16{api.Worker, api.Master, api.ETCD, api.LoadBalance}
-	if err := dependency.HookSchedule(bcp.config, bcp.config.Nodes, role, api.SchedulePostCleanup); err != nil {
+	if err := dependency.HookSchedule(ctx, bcp.config, bcp.config.Nodes, role, api.SchedulePostCleanup); err != nil {
 		logrus.Warnf("Ignore: Delete cluster PostHook failed: %v", err)
 	}
-	if err := dependency.ExecuteCmdHooks(bcp.config, bcp.config.Nodes, api.HookOpCleanup, api.ClusterPosthookType); err != nil {
+	if err := dependency.ExecuteCmdHooks(ctx, bcp.config, bcp.config.Nodes, api.HookOpCleanup, api.ClusterPosthookType); err != nil {
 		logrus.Warnf("Ignore: Delete cluster posthook failed:%v", err)
 	}
 }
 
-func (bcp *BinaryClusterDeployment) PreNodeJoinHooks(node *api.HostConfig) error {
+func (bcp *BinaryClusterDeployment) PreNodeJoinHooks(ctx context.Context, node *api.HostConfig) error {
+	defer metrics.StartPhase("PreNodeJoinHooks", nodeNames(node)...)()
 	role := []uint16{api.Master, api.Worker, api.ETCD}
-	if err := dependency.ExecuteCmdHooks(bcp.config, []*api.HostConfig{node}, api.HookOpJoin, api.PreHookType); err != nil {
+	if err := dependency.ExecuteCmdHooks(ctx, bcp.config, []*api.HostConfig{node}, api.HookOpJoin, api.PreHookType); err != nil {
 		return err
 	}
-	if err := dependency.HookSchedule(bcp.config, []*api.HostConfig{node}, role, api.SchedulePreJoin); err != nil {
+	if err := dependency.HookSchedule(ctx, bcp.config, []*api.HostConfig{node}, role, api.SchedulePreJoin); err != nil {
 		return err
 	}
 	return nil
 }
 
-func checkWorkerServices(workers []string) error {
+func checkWorkerServices(ctx context.Context, workers []string) error {
 	if len(workers) == 0 {
 		return nil
 	}
@@ -497,10 +675,10 @@ exit 0
 		},
 	)
 
-	return nodemanager.RunTaskOnNodes(checker, workers)
+	return nodemanager.RunTaskOnNodes(ctx, checker, workers)
 }
 
-func checkMasterServices(masters []string) error {
+func checkMasterServices(ctx context.Context, masters []string) error {
 	if len(masters) == 0 {
 		return nil
 	}
@@ -520,10 +698,10 @@ exit 0
 		},
 	)
 
-	return nodemanager.RunTaskOnNodes(checker, masters)
+	return nodemanager.RunTaskOnNodes(ctx, checker, masters)
 }
 
-func checkK8sServices(nodes []*api.HostConfig) error {
+func checkK8sServices(ctx context.Context, nodes []*api.HostConfig) error {
 	var wokers, masters []string
 
 	for _, n := range nodes {
@@ -534,22 +712,23 @@ func checkK8sServices(nodes []*api.HostConfig) error {
 			wokers = append(wokers, n.Address)
 		}
 	}
-	if err := checkWorkerServices(wokers); err != nil {
+	if err := checkWorkerServices(ctx, wokers); err != nil {
 		return err
 	}
-	return checkMasterServices(masters)
+	return checkMasterServices(ctx, masters)
 }
 
-func (bcp *BinaryClusterDeployment) PostNodeJoinHooks(node *api.HostConfig) error {
+func (bcp *BinaryClusterDeployment) PostNodeJoinHooks(ctx context.Context, node *api.HostConfig) error {
+	defer metrics.StartPhase("PostNodeJoinHooks", nodeNames(node)...)()
 	role := []uint16{api.Master, api.Worker, api.ETCD}
-	if err := dependency.HookSchedule(bcp.config, []*api.HostConfig{node}, role, api.SchedulePostJoin); err != nil {
+	if err := dependency.HookSchedule(ctx, bcp.config, []*api.HostConfig{node}, role, api.SchedulePostJoin); err != nil {
 		return err
 	}
-	if err := dependency.ExecuteCmdHooks(bcp.config, []*api.HostConfig{node}, api.HookOpJoin, api.PostHookType); err != nil {
+	if err := dependency.ExecuteCmdHooks(ctx, bcp.config, []*api.HostConfig{node}, api.HookOpJoin, api.PostHookType); err != nil {
 		return err
 	}
 
-	// taint and label for master node
+	// taint and label for node
 	roles := node.Type
 	for _, n := range bcp.config.Nodes {
 		if n.Name == node.Name {
@@ -557,46 +736,45 @@ func (bcp *BinaryClusterDeployment) PostNodeJoinHooks(node *api.HostConfig) erro
 			break
 		}
 	}
-
-	// check whether the node is worker and master
-	if utils.IsType(roles, (api.Master | api.Worker)) {
-		if err := taintAndLabelNode(bcp.config.Name, node.Name); err != nil {
-			return err
-		}
+	if err := taintAndLabelNode(bcp.config, node, roles); err != nil {
+		return err
 	}
 
 	// check node status
-	if err := checkK8sServices([]*api.HostConfig{node}); err != nil {
+	if err := checkK8sServices(ctx, []*api.HostConfig{node}); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (bcp *BinaryClusterDeployment) PreNodeCleanupHooks(node *api.HostConfig) {
+func (bcp *BinaryClusterDeployment) PreNodeCleanupHooks(ctx context.Context, node *api.HostConfig) {
+	defer metrics.StartPhase("PreNodeCleanupHooks", nodeNames(node)...)()
 	role := []uint16{api.Worker, api.Master, api.ETCD}
-	if err := dependency.ExecuteCmdHooks(bcp.config, []*api.HostConfig{node}, api.HookOpDelete, api.PreHookType); err != nil {
+	if err := dependency.ExecuteCmdHooks(ctx, bcp.config, []*api.HostConfig{node}, api.HookOpDelete, api.PreHookType); err != nil {
 		logrus.Warnf("Ignore: Delete Node Cmd Prehook failed: %v", err)
 	}
-	if err := dependency.HookSchedule(bcp.config, []*api.HostConfig{node}, role, api.SchedulePreCleanup); err != nil {
+	if err := dependency.HookSchedule(ctx, bcp.config, []*api.HostConfig{node}, role, api.SchedulePreCleanup); err != nil {
 		logrus.Warnf("Ignore: Delete Node PreHook failed: %v", err)
 	}
 }
 
-func (bcp *BinaryClusterDeployment) PostNodeCleanupHooks(node *api.HostConfig) {
+func (bcp *BinaryClusterDeployment) PostNodeCleanupHooks(ctx context.Context, node *api.HostConfig) {
+	defer metrics.StartPhase("PostNodeCleanupHooks", nodeNames(node)...)()
 	role := []uint16{api.Worker, api.Master, api.ETCD}
-	if err := dependency.HookSchedule(bcp.config, []*api.HostConfig{node}, role, api.SchedulePostCleanup); err != nil {
+	if err := dependency.HookSchedule(ctx, bcp.config, []*api.HostConfig{node}, role, api.SchedulePostCleanup); err != nil {
 		logrus.Warnf("Ignore: Delete Node PostHook failed: %v", err)
 	}
-	if err := dependency.ExecuteCmdHooks(bcp.config, []*api.HostConfig{node}, api.HookOpDelete, api.PostHookType); err != nil {
+	if err := dependency.ExecuteCmdHooks(ctx, bcp.config, []*api.HostConfig{node}, api.HookOpDelete, api.PostHookType); err != nil {
 		logrus.Warnf("Ignore: Delete Node Cmd Posthook failed: %v", err)
 	}
 }
 
-func (bcp *BinaryClusterDeployment) CleanupLastStep(nodeName string) error {
+func (bcp *BinaryClusterDeployment) CleanupLastStep(ctx context.Context, nodeName string) error {
+	defer metrics.StartPhase("CleanupLastStep", nodeName)()
 	itask := task.NewTaskInstance(&cleanupcluster.CleanupTempDirTask{})
 
-	if err := nodemanager.RunTaskOnNodes(itask, []string{nodeName}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, itask, []string{nodeName}); err != nil {
 		return fmt.Errorf("cleanup user temp dir failed: %v", err)
 	}
 
@@ -0,0 +1,105 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for binary cluster deployment
+ ******************************************************************************/
+
+package binary
+
+import (
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/kubectl"
+)
+
+func hasTaint(taints []kubectl.Taint, t kubectl.Taint) bool {
+	for _, tt := range taints {
+		if tt == t {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNodeTaintsAndLabels(t *testing.T) {
+	node := &api.HostConfig{
+		Name:   "master0",
+		Labels: map[string]string{"example.com/zone": "zone-a"},
+		Taints: []string{"dedicated=gpu:NoSchedule"},
+	}
+	userTaint := kubectl.Taint{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}
+
+	// master is unschedulable by default
+	labels, taints, removes, err := nodeTaintsAndLabels(node, api.Master|api.Worker, false)
+	if err != nil {
+		t.Fatalf("get taints and labels failed: %v", err)
+	}
+	if !hasTaint(taints, masterTaint) || !hasTaint(taints, userTaint) || len(removes) != 0 {
+		t.Fatalf("master should be tainted NoSchedule, get taints: %v, removes: %v", taints, removes)
+	}
+	if _, ok := labels["node-role.kubernetes.io/master"]; !ok || labels["example.com/zone"] != "zone-a" {
+		t.Fatalf("unexpect labels: %v", labels)
+	}
+
+	// schedule workloads on master
+	_, taints, removes, err = nodeTaintsAndLabels(node, api.Master|api.Worker, true)
+	if err != nil {
+		t.Fatalf("get taints and labels failed: %v", err)
+	}
+	if hasTaint(taints, masterTaint) || !hasTaint(removes, masterTaint) || !hasTaint(taints, userTaint) {
+		t.Fatalf("master taint should be removed, get taints: %v, removes: %v", taints, removes)
+	}
+
+	// worker is not affected by the flag
+	for _, schedule := range []bool{true, false} {
+		labels, taints, removes, err = nodeTaintsAndLabels(node, api.Worker, schedule)
+		if err != nil {
+			t.Fatalf("get taints and labels failed: %v", err)
+		}
+		if hasTaint(taints, masterTaint) || len(removes) != 0 {
+			t.Fatalf("worker should not be tainted as master, get taints: %v, removes: %v", taints, removes)
+		}
+		if _, ok := labels["node-role.kubernetes.io/master"]; ok {
+			t.Fatalf("worker should not be labeled as master: %v", labels)
+		}
+	}
+
+	node.Taints = []string{"invalid"}
+	if _, _, _, err = nodeTaintsAndLabels(node, api.Worker, false); err == nil {
+		t.Fatalf("expect invalid taint failed")
+	}
+}
+
+func TestIsSingleWorkerMaster(t *testing.T) {
+	ccfg := &api.ClusterConfig{
+		Nodes: []*api.HostConfig{
+			{Name: "node0", Address: "192.168.0.2", Type: api.Master | api.Worker | api.ETCD},
+		},
+	}
+	if !isSingleWorkerMaster(ccfg) {
+		t.Fatalf("all-in-one node should be schedulable")
+	}
+
+	ccfg.Nodes = append(ccfg.Nodes, &api.HostConfig{Name: "node1", Address: "192.168.0.3", Type: api.Worker})
+	if isSingleWorkerMaster(ccfg) {
+		t.Fatalf("master should be tainted if there are other workers")
+	}
+
+	ccfg.Nodes = []*api.HostConfig{
+		{Name: "node0", Address: "192.168.0.2", Type: api.Master},
+		{Name: "node1", Address: "192.168.0.3", Type: api.Worker},
+	}
+	if isSingleWorkerMaster(ccfg) {
+		t.Fatalf("single worker is not master")
+	}
+}
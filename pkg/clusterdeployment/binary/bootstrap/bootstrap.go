@@ -16,6 +16,7 @@
 package bootstrap
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/base64"
 	"fmt"
@@ -30,6 +31,7 @@ import (
 	"isula.org/eggo/pkg/clusterdeployment/binary/controlplane"
 	"isula.org/eggo/pkg/clusterdeployment/runtime"
 	"isula.org/eggo/pkg/constants"
+	"isula.org/eggo/pkg/utils"
 	"isula.org/eggo/pkg/utils/certs"
 	"isula.org/eggo/pkg/utils/endpoint"
 	"isula.org/eggo/pkg/utils/nodemanager"
@@ -195,28 +197,7 @@ func genKubeletBootstrapAndConfig(r runner.Runner, ccfg *api.ClusterConfig, toke
 }
 
 func genKubeletBootstrap(r runner.Runner, ccfg *api.ClusterConfig, token, apiEndpoint string) error {
-	var sb strings.Builder
-	sb.WriteString("sudo -E /bin/sh -c \"cd /etc/kubernetes/ && ")
-	sb.WriteString("kubectl config set-cluster " + ccfg.Name +
-		" --certificate-authority=/etc/kubernetes/pki/ca.crt" +
-		" --embed-certs=true" +
-		" --server=" + apiEndpoint +
-		" --kubeconfig=kubelet-bootstrap.kubeconfig")
-	sb.WriteString(" && ")
-	sb.WriteString("kubectl config set-credentials kubelet-bootstrap" +
-		" --token=" + token +
-		" --kubeconfig=kubelet-bootstrap.kubeconfig")
-	sb.WriteString(" && ")
-	sb.WriteString("kubectl config set-context default" +
-		" --cluster=" + ccfg.Name +
-		" --user=kubelet-bootstrap" +
-		" --kubeconfig=kubelet-bootstrap.kubeconfig")
-	sb.WriteString(" && ")
-	sb.WriteString("kubectl config use-context default" +
-		" --kubeconfig=kubelet-bootstrap.kubeconfig")
-	sb.WriteString("\"")
-
-	if _, err := r.RunCommand(sb.String()); err != nil {
+	if _, err := r.RunCommand(utils.AddSudo(commontools.KubeletBootstrapCommand(ccfg.Name, apiEndpoint, token))); err != nil {
 		return err
 	}
 
@@ -238,6 +219,7 @@ authorization:
 clusterDNS:
 - {{ .DnsVip }}
 clusterDomain: {{ .DnsDomain }}
+cgroupDriver: {{ .CgroupDriver }}
 rotateCertificates: true
 runtimeRequestTimeout: "15m"
 {{- if .EnableServer }}
@@ -247,8 +229,9 @@ serverTLSBootstrap: true
 
 	datastore := make(map[string]interface{})
 	datastore["DnsVip"] = ccfg.WorkerConfig.KubeletConf.DNSVip
-	datastore["DnsDomain"] = ccfg.WorkerConfig.KubeletConf.DNSDomain
+	datastore["DnsDomain"] = ccfg.GetDNSDomain()
 	datastore["EnableServer"] = ccfg.WorkerConfig.KubeletConf.EnableServer
+	datastore["CgroupDriver"] = ccfg.WorkerConfig.KubeletConf.GetCgroupDriver()
 
 	config, err := template.TemplateRender(kubeletConfig, datastore)
 	if err != nil {
@@ -342,7 +325,7 @@ mode: "iptables"
 	return nil
 }
 
-func JoinMaster(config *api.ClusterConfig, master *api.HostConfig) error {
+func JoinMaster(ctx context.Context, config *api.ClusterConfig, master *api.HostConfig) error {
 	joinMasterTasks := []task.Task{
 		task.NewTaskInstance(
 			&commontools.CopyCaCertificatesTask{
@@ -355,23 +338,24 @@ func JoinMaster(config *api.ClusterConfig, master *api.HostConfig) error {
 		),
 	}
 
-	if err := nodemanager.RunTasksOnNodes(joinMasterTasks, []string{master.Address}); err != nil {
+	if err := nodemanager.RunTasksOnNodes(ctx, joinMasterTasks, []string{master.Address}); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func JoinWorker(config *api.ClusterConfig, controlPlane *api.HostConfig, worker *api.HostConfig) error {
+func JoinWorker(ctx context.Context, config *api.ClusterConfig, controlPlane *api.HostConfig, worker *api.HostConfig) error {
 	if tokenTask == nil {
 		tokenTask = &GetTokenTask{
-			cluster: config,
+			tokenStr: config.JoinToken,
+			cluster:  config,
 		}
 
-		if err := nodemanager.RunTaskOnNodes(task.NewTaskInstance(tokenTask), []string{controlPlane.Address}); err != nil {
+		if err := nodemanager.RunTaskOnNodes(ctx, task.NewTaskInstance(tokenTask), []string{controlPlane.Address}); err != nil {
 			return err
 		}
-		if err := nodemanager.WaitNodesFinish([]string{controlPlane.Address}, time.Minute*2); err != nil {
+		if err := nodemanager.WaitNodesFinish(ctx, []string{controlPlane.Address}, time.Minute*2); err != nil {
 			return err
 		}
 	}
@@ -393,7 +377,7 @@ func JoinWorker(config *api.ClusterConfig, controlPlane *api.HostConfig, worker
 		),
 	}
 
-	if err := nodemanager.RunTasksOnNodes(joinWorkerTasks, []string{worker.Address}); err != nil {
+	if err := nodemanager.RunTasksOnNodes(ctx, joinWorkerTasks, []string{worker.Address}); err != nil {
 		return err
 	}
 
@@ -16,6 +16,7 @@
 package bootstrap
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -34,6 +35,11 @@ func (m *MockRunner) Copy(src, dst string) error {
 	return nil
 }
 
+func (m *MockRunner) CopyDir(srcDir, dstDir string) error {
+	logrus.Infof("copy dir %s to %s", srcDir, dstDir)
+	return nil
+}
+
 func (m *MockRunner) RunCommand(cmd string) (string, error) {
 	logrus.Infof("run command: %s", cmd)
 	return "", nil
@@ -53,7 +59,7 @@ func (m *MockRunner) Close() {
 	logrus.Infof("close")
 }
 
-func TestJoinMaster(t *testing.T) {
+func TestJoinMaster(ctx context.Context, t *testing.T) {
 	lr := &runner.LocalRunner{}
 	masterNode := api.HostConfig{
 		Arch:     "arm64",
@@ -113,13 +119,13 @@ func TestJoinMaster(t *testing.T) {
 		fmt.Sprintf("sudo mkdir -p -m 0777 %s/%s/pki", api.EggoHomePath, conf.Name)); err != nil {
 		t.Fatalf("run command failed: %v", err)
 	}
-	if err := JoinMaster(conf, &masterNode); err != nil {
+	if err := JoinMaster(ctx, conf, &masterNode); err != nil {
 		t.Fatalf("do bootstrap init failed: %v", err)
 	}
 	t.Logf("do bootstrap init success")
 }
 
-func TestJoinWorker(t *testing.T) {
+func TestJoinWorker(ctx context.Context, t *testing.T) {
 	lr := &runner.LocalRunner{}
 	controlplane := api.HostConfig{
 		Arch:     "x86_64",
@@ -180,7 +186,7 @@ func TestJoinWorker(t *testing.T) {
 		fmt.Sprintf("sudo mkdir -p -m 0777 %s/%s/pki", api.EggoHomePath, conf.Name)); err != nil {
 		t.Fatalf("run command failed: %v", err)
 	}
-	if err := JoinWorker(conf, &controlplane, &workerNode); err != nil {
+	if err := JoinWorker(ctx, conf, &controlplane, &workerNode); err != nil {
 		t.Fatalf("do bootstrap init failed: %v", err)
 	}
 	t.Logf("do bootstrap init success")
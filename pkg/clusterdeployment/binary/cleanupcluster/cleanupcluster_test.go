@@ -16,6 +16,7 @@
 package cleanupcluster
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -40,6 +41,11 @@ func (r *fakeRunner) Copy(src, dst string) error {
 	return nil
 }
 
+func (r *fakeRunner) CopyDir(srcDir, dstDir string) error {
+	logrus.Infof("copy dir %v to %v", srcDir, dstDir)
+	return nil
+}
+
 func (r *fakeRunner) RunCommand(cmd string) (string, error) {
 	logrus.Infof("run command:[%v]", cmd)
 
@@ -163,7 +169,7 @@ func TestRemoveWorkerTask(t *testing.T) {
 	}
 }
 
-func TestCleanupAllTypes(t *testing.T) {
+func TestCleanupAllTypes(ctx context.Context, t *testing.T) {
 	nodes := []*api.HostConfig{
 		{
 			Arch:    "amd64",
@@ -201,19 +207,19 @@ func TestCleanupAllTypes(t *testing.T) {
 		t.Fatalf("register fakerunner for worker1 failed")
 	}
 
-	if err := CleanupAllEtcds(conf); err != nil {
+	if err := CleanupAllEtcds(ctx, conf); err != nil {
 		t.Fatalf("test cleanup all etcds failed")
 	}
 
-	if err := CleanupEtcdMember(conf, nodes[0]); err != nil {
+	if err := CleanupEtcdMember(ctx, conf, nodes[0]); err != nil {
 		t.Fatalf("test cleanup etcd member failed")
 	}
 
-	if err := CleanupNode(conf, nodes[0], api.Worker); err != nil {
+	if err := CleanupNode(ctx, conf, nodes[0], api.Worker); err != nil {
 		t.Fatalf("test cleanup node failed")
 	}
 
-	if err := CleanupLoadBalance(conf, nodes[0]); err != nil {
+	if err := CleanupLoadBalance(ctx, conf, nodes[0]); err != nil {
 		t.Fatalf("test cleanup loadbalance failed")
 	}
 }
@@ -16,6 +16,7 @@
 package cleanupcluster
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"time"
@@ -23,6 +24,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment/binary/commontools"
 	"isula.org/eggo/pkg/clusterdeployment/binary/etcdcluster"
 	"isula.org/eggo/pkg/utils"
 	"isula.org/eggo/pkg/utils/nodemanager"
@@ -42,20 +44,14 @@ func (t *cleanupEtcdMemberTask) Name() string {
 	return "cleanupEtcdMemberTask"
 }
 
-func getEtcdDataDir(dataDir string) string {
-	if dataDir != "" {
-		return dataDir
-	}
-	return etcdcluster.DefaultEtcdDataDir
-}
-
 func getEtcdPathes(ccfg *api.ClusterConfig) []string {
 	return []string{
 		filepath.Join(ccfg.GetCertDir(), "etcd"),
-		getEtcdDataDir(ccfg.EtcdCluster.DataDir),
+		ccfg.GetEtcdDataDir(),
 		"/etc/etcd",
 		"/var/lib/etcd",
 		"/usr/lib/systemd/system/etcd.service",
+		commontools.SystemdDropInPath("etcd"),
 	}
 }
 
@@ -75,14 +71,14 @@ func (t *cleanupEtcdMemberTask) Run(r runner.Runner, hostConfig *api.HostConfig)
 	return nil
 }
 
-func CleanupEtcdMember(conf *api.ClusterConfig, hostconfig *api.HostConfig) error {
+func CleanupEtcdMember(ctx context.Context, conf *api.ClusterConfig, hostconfig *api.HostConfig) error {
 	if conf.EtcdCluster.External {
 		logrus.Info("external etcd, ignore remove etcds")
 		return nil
 	}
 
 	// delete etcd member
-	if err := etcdcluster.ExecRemoveMemberTask(conf, hostconfig); err != nil {
+	if err := etcdcluster.ExecRemoveMemberTask(ctx, conf, hostconfig); err != nil {
 		return fmt.Errorf("remove etcd member %v failed: %v", hostconfig.Name, err)
 	}
 
@@ -93,11 +89,11 @@ func CleanupEtcdMember(conf *api.ClusterConfig, hostconfig *api.HostConfig) erro
 		},
 	)
 
-	if err := nodemanager.RunTaskOnNodes(taskCleanupEtcdMember, []string{hostconfig.Address}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, taskCleanupEtcdMember, []string{hostconfig.Address}); err != nil {
 		return fmt.Errorf("run task for cleanup etcd member failed: %v", err)
 	}
 
-	if err := nodemanager.WaitNodesFinish([]string{hostconfig.Address}, time.Minute); err != nil {
+	if err := nodemanager.WaitNodesFinish(ctx, []string{hostconfig.Address}, time.Minute); err != nil {
 		return fmt.Errorf("wait for cleanup etcd member task finish failed: %v", err)
 	}
 
@@ -105,13 +101,13 @@ func CleanupEtcdMember(conf *api.ClusterConfig, hostconfig *api.HostConfig) erro
 }
 
 // cleanup all etcds
-func CleanupAllEtcds(conf *api.ClusterConfig) error {
+func CleanupAllEtcds(ctx context.Context, conf *api.ClusterConfig) error {
 	if conf.EtcdCluster.External {
 		logrus.Info("external etcd, ignore remove etcds")
 		return nil
 	}
 
-	if err := etcdcluster.ExecRemoveEtcdsTask(conf); err != nil {
+	if err := etcdcluster.ExecRemoveEtcdsTask(ctx, conf); err != nil {
 		logrus.Errorf("remove etcds failed: %v", err)
 	}
 
@@ -123,7 +119,7 @@ func CleanupAllEtcds(conf *api.ClusterConfig) error {
 	)
 
 	nodes := utils.GetAllIPs(conf.EtcdCluster.Nodes)
-	if err := nodemanager.RunTaskOnNodes(taskCleanupAllEtcds, nodes); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, taskCleanupAllEtcds, nodes); err != nil {
 		return fmt.Errorf("run task for cleanup all etcds failed: %v", err)
 	}
 
@@ -16,6 +16,7 @@
 package cleanupcluster
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
@@ -38,31 +39,44 @@ func (t *cleanupLoadBalanceTask) Name() string {
 	return "cleanupLoadBalanceTask"
 }
 
-func getLoadBalancePathes() []string {
-	return []string{"/etc/nginx", "/usr/lib/systemd/system/nginx.service"}
+func getLoadBalancePathes(lb *api.LoadBalancer) []string {
+	pathes := []string{"/etc/nginx", "/usr/lib/systemd/system/nginx.service"}
+	if lb.VIP != "" {
+		pathes = append(pathes, "/etc/keepalived/keepalived.conf")
+	}
+	return pathes
+}
+
+func getLoadBalanceServices(lb *api.LoadBalancer) []string {
+	services := LoadBalanceService
+	if lb.VIP != "" {
+		// release vip before nginx stopped
+		services = append([]string{"keepalived"}, services...)
+	}
+	return services
 }
 
 func (t *cleanupLoadBalanceTask) Run(r runner.Runner, hostConfig *api.HostConfig) error {
 	// stop service before remove dependences
-	if err := stopServices(r, LoadBalanceService); err != nil {
+	if err := stopServices(r, getLoadBalanceServices(&t.ccfg.LoadBalancer)); err != nil {
 		logrus.Errorf("stop loadbalance service failed: %v", err)
 	}
 
-	removePathes(r, getLoadBalancePathes())
+	removePathes(r, getLoadBalancePathes(&t.ccfg.LoadBalancer))
 
 	PostCleanup(r)
 
 	return nil
 }
 
-func CleanupLoadBalance(conf *api.ClusterConfig, lb *api.HostConfig) error {
+func CleanupLoadBalance(ctx context.Context, conf *api.ClusterConfig, lb *api.HostConfig) error {
 	taskCleanupLoadBalance := task.NewTaskIgnoreErrInstance(
 		&cleanupLoadBalanceTask{
 			ccfg: conf,
 		},
 	)
 
-	if err := nodemanager.RunTaskOnNodes(taskCleanupLoadBalance, []string{lb.Address}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, taskCleanupLoadBalance, []string{lb.Address}); err != nil {
 		return fmt.Errorf("run task for cleanup loadbalance failed: %v", err)
 	}
 
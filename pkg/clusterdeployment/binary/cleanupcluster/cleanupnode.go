@@ -16,6 +16,7 @@
 package cleanupcluster
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -23,9 +24,11 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment/binary/commontools"
 	"isula.org/eggo/pkg/clusterdeployment/runtime"
 	"isula.org/eggo/pkg/constants"
 	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/kubectl"
 	"isula.org/eggo/pkg/utils/nodemanager"
 	"isula.org/eggo/pkg/utils/runner"
 	"isula.org/eggo/pkg/utils/task"
@@ -87,6 +90,8 @@ func getWorkerPathes(r runner.Runner, ccfg *api.ClusterConfig) []string {
 		"/var/lib/cni", "/etc/cni", "/opt/cni",
 		"/usr/lib/systemd/system/kubelet.service",
 		"/usr/lib/systemd/system/kube-proxy.service",
+		commontools.SystemdDropInPath("kubelet"),
+		commontools.SystemdDropInPath("kube-proxy"),
 	}
 	runtime := runtime.GetRuntime(ccfg.WorkerConfig.ContainerEngineConf.Runtime)
 	if runtime != nil {
@@ -137,6 +142,9 @@ func getMasterPathes(ccfg *api.ClusterConfig) []string {
 		"/usr/lib/systemd/system/kube-apiserver.service",
 		"/usr/lib/systemd/system/kube-scheduler.service",
 		"/usr/lib/systemd/system/kube-controller-manager.service",
+		commontools.SystemdDropInPath("kube-apiserver"),
+		commontools.SystemdDropInPath("kube-scheduler"),
+		commontools.SystemdDropInPath("kube-controller-manager"),
 	}
 }
 
@@ -209,12 +217,19 @@ func getFirstMaster(nodes []*api.HostConfig) string {
 	return ""
 }
 
-func runRemoveWorker(configDir string, r runner.Runner, worker string) error {
-	var sb strings.Builder
+func runRemoveWorker(ccfg *api.ClusterConfig, r runner.Runner, worker string) error {
+	// cordon and evict pods on worker before remove it
+	if err := kubectl.DrainNode(r, ccfg, worker); err != nil {
+		if ccfg.Drain.Strict {
+			logrus.Errorf("drain worker %v failed, abort remove it: %v", worker, err)
+			return err
+		}
+		logrus.Warnf("drain worker %v failed: %v", worker, err)
+	}
 
-	sb.WriteString(fmt.Sprintf("KUBECONFIG=%s/%s kubectl delete node %v --force --grace-period=0",
-		configDir, constants.KubeConfigFileNameAdmin, worker))
-	if output, err := r.RunCommand(utils.AddSudo(sb.String())); err != nil {
+	cmd := fmt.Sprintf("KUBECONFIG=%s/%s kubectl delete node %v --force --grace-period=0",
+		ccfg.GetConfigDir(), constants.KubeConfigFileNameAdmin, worker)
+	if output, err := r.RunCommand(utils.AddSudo(cmd)); err != nil {
 		logrus.Errorf("remove workder %v failed: %v\noutput: %v", worker, err, output)
 		return err
 	}
@@ -223,40 +238,47 @@ func runRemoveWorker(configDir string, r runner.Runner, worker string) error {
 }
 
 func (t *removeWorkerTask) Run(r runner.Runner, hostConfig *api.HostConfig) error {
-	if err := runRemoveWorker(t.ccfg.GetConfigDir(), r, t.workerName); err != nil {
+	if err := runRemoveWorker(t.ccfg, r, t.workerName); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func execRemoveWorkerTask(conf *api.ClusterConfig, hostconfig *api.HostConfig) error {
+func execRemoveWorkerTask(ctx context.Context, conf *api.ClusterConfig, hostconfig *api.HostConfig) error {
 	taskRemoveWorker := task.NewTaskIgnoreErrInstance(
 		&removeWorkerTask{
 			ccfg:       conf,
 			workerName: hostconfig.Name,
 		},
 	)
+	// failure of drain aborts the removal of node in strict mode
+	if conf.Drain.Strict {
+		taskRemoveWorker = task.NewTaskInstance(taskRemoveWorker.TaskRun)
+	}
 
 	master := getFirstMaster(conf.Nodes)
 	if master == "" {
 		return fmt.Errorf("failed to get first master")
 	}
 
-	if err := nodemanager.RunTaskOnNodes(taskRemoveWorker, []string{master}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, taskRemoveWorker, []string{master}); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func CleanupNode(conf *api.ClusterConfig, hostconfig *api.HostConfig, delType uint16) error {
+func CleanupNode(ctx context.Context, conf *api.ClusterConfig, hostconfig *api.HostConfig, delType uint16) error {
 	if conf == nil || hostconfig == nil {
 		return fmt.Errorf("invalid null config")
 	}
 
 	if utils.IsType(delType, api.Worker) {
-		if err := execRemoveWorkerTask(conf, hostconfig); err != nil {
+		if err := execRemoveWorkerTask(ctx, conf, hostconfig); err != nil {
+			if conf.Drain.Strict {
+				return fmt.Errorf("remove worker %v failed: %v", hostconfig.Name, err)
+			}
 			logrus.Warnf("ignore: remove workers failed: %v", err)
 		}
 	}
@@ -268,7 +290,7 @@ func CleanupNode(conf *api.ClusterConfig, hostconfig *api.HostConfig, delType ui
 		},
 	)
 
-	if err := nodemanager.RunTaskOnNodes(taskCleanupNode, []string{hostconfig.Address}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, taskCleanupNode, []string{hostconfig.Address}); err != nil {
 		return fmt.Errorf("run task for cleanup cluster failed: %v", err)
 	}
 
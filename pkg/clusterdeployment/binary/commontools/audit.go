@@ -0,0 +1,181 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: audit logs of apiserver
+ ******************************************************************************/
+
+package commontools
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v1"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner"
+	"isula.org/eggo/pkg/utils/template"
+)
+
+const (
+	auditPolicyFileName = "audit-policy.yaml"
+	auditAPIVersion     = "audit.k8s.io/v1"
+
+	DefaultAuditLogPath   = "/var/log/kubernetes/audit/audit.log"
+	DefaultAuditMaxAge    = 30
+	DefaultAuditMaxBackup = 10
+	DefaultAuditMaxSize   = 100
+
+	// DefaultAuditPolicy skips noisy requests, logs only metadata of sensitive resources,
+	// and logs requests of writes
+	DefaultAuditPolicy = `apiVersion: audit.k8s.io/v1
+kind: Policy
+omitStages:
+  - "RequestReceived"
+rules:
+  - level: None
+    nonResourceURLs:
+      - "/healthz*"
+      - "/livez*"
+      - "/readyz*"
+      - "/version"
+  - level: None
+    resources:
+      - group: ""
+        resources: ["events"]
+      - group: "coordination.k8s.io"
+        resources: ["leases"]
+  - level: Metadata
+    resources:
+      - group: ""
+        resources: ["secrets", "configmaps", "serviceaccounts/token"]
+      - group: "authentication.k8s.io"
+        resources: ["tokenreviews"]
+  - level: Request
+    verbs: ["create", "update", "patch", "delete", "deletecollection"]
+  - level: Metadata
+`
+)
+
+type auditPolicy struct {
+	APIVersion string        `yaml:"apiVersion"`
+	Kind       string        `yaml:"kind"`
+	Rules      []interface{} `yaml:"rules"`
+}
+
+// CheckAuditPolicy checks policy is yaml of audit policy with rules
+func CheckAuditPolicy(policy string) error {
+	var p auditPolicy
+	if err := yaml.Unmarshal([]byte(policy), &p); err != nil {
+		return fmt.Errorf("parse audit policy failed: %v", err)
+	}
+	if p.APIVersion != auditAPIVersion || p.Kind != "Policy" {
+		return fmt.Errorf("audit policy must be kind Policy of %s, get: %s of %s", auditAPIVersion, p.Kind, p.APIVersion)
+	}
+	if len(p.Rules) == 0 {
+		return errors.New("no rules in audit policy")
+	}
+	return nil
+}
+
+// GetAuditPolicy returns inline policy, content of policy file, or default policy in order
+func GetAuditPolicy(audit *api.AuditConfig) (string, error) {
+	if audit.Policy != "" {
+		return audit.Policy, nil
+	}
+	if audit.PolicyFile != "" {
+		data, err := ioutil.ReadFile(audit.PolicyFile)
+		if err != nil {
+			return "", fmt.Errorf("read audit policy %s failed: %v", audit.PolicyFile, err)
+		}
+		return string(data), nil
+	}
+	return DefaultAuditPolicy, nil
+}
+
+// GetAuditPolicyPath returns path of audit policy on masters
+func GetAuditPolicyPath(ccfg *api.ClusterConfig) string {
+	return filepath.Join(ccfg.GetConfigDir(), auditPolicyFileName)
+}
+
+func getAuditLogPath(audit *api.AuditConfig) string {
+	if audit.LogPath == "" {
+		return DefaultAuditLogPath
+	}
+	return audit.LogPath
+}
+
+func defaultInt(v, def int) string {
+	if v <= 0 {
+		v = def
+	}
+	return strconv.Itoa(v)
+}
+
+// getAuditArgs returns args of apiserver for audit logs, empty if audit is disabled
+func getAuditArgs(ccfg *api.ClusterConfig) map[string]string {
+	audit := &ccfg.Audit
+	if !audit.Enabled {
+		return nil
+	}
+	return map[string]string{
+		"--audit-policy-file":   GetAuditPolicyPath(ccfg),
+		"--audit-log-path":      getAuditLogPath(audit),
+		"--audit-log-maxage":    defaultInt(audit.MaxAge, DefaultAuditMaxAge),
+		"--audit-log-maxbackup": defaultInt(audit.MaxBackup, DefaultAuditMaxBackup),
+		"--audit-log-maxsize":   defaultInt(audit.MaxSize, DefaultAuditMaxSize),
+	}
+}
+
+func auditPolicyShell(ccfg *api.ClusterConfig) (string, error) {
+	policy, err := GetAuditPolicy(&ccfg.Audit)
+	if err != nil {
+		return "", err
+	}
+	if err = CheckAuditPolicy(policy); err != nil {
+		return "", err
+	}
+
+	shell := `
+#!/bin/bash
+mkdir -p {{ .policyDir }} && echo {{ .policy }} | base64 -d > {{ .policyPath }} || exit 1
+chmod 600 {{ .policyPath }}
+mkdir -p -m 0700 {{ .logDir }} || exit 1
+exit 0
+`
+	policyPath := GetAuditPolicyPath(ccfg)
+	datastore := make(map[string]interface{})
+	datastore["policyDir"] = filepath.Dir(policyPath)
+	datastore["policyPath"] = policyPath
+	datastore["policy"] = base64.StdEncoding.EncodeToString([]byte(policy))
+	datastore["logDir"] = filepath.Dir(getAuditLogPath(&ccfg.Audit))
+	return template.TemplateRender(shell, datastore)
+}
+
+// SetupAuditPolicy writes audit policy and creates dir of audit log on master, if audit is enabled
+func SetupAuditPolicy(r runner.Runner, ccfg *api.ClusterConfig) error {
+	if !ccfg.Audit.Enabled {
+		return nil
+	}
+	shell, err := auditPolicyShell(ccfg)
+	if err != nil {
+		return err
+	}
+	if _, err = r.RunShell(shell, "auditPolicy"); err != nil {
+		return fmt.Errorf("setup audit policy failed: %v", err)
+	}
+	return nil
+}
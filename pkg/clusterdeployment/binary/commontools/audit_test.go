@@ -0,0 +1,97 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for audit logs of apiserver
+ ******************************************************************************/
+
+package commontools
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func TestCheckAuditPolicy(t *testing.T) {
+	if err := CheckAuditPolicy(DefaultAuditPolicy); err != nil {
+		t.Fatalf("check default audit policy failed: %v", err)
+	}
+
+	invalids := []string{
+		"apiVersion: audit.k8s.io/v1\nkind: Policy\nrules: [",
+		"apiVersion: audit.k8s.io/v1\nkind: Policy\n",
+		"apiVersion: v1\nkind: ConfigMap\nrules:\n- level: Metadata\n",
+	}
+	for _, p := range invalids {
+		if err := CheckAuditPolicy(p); err == nil {
+			t.Fatalf("expect invalid audit policy:\n%s", p)
+		}
+	}
+}
+
+func TestGetAPIServerAuditArgs(t *testing.T) {
+	ccfg := &api.ClusterConfig{}
+	for _, a := range getAPIServerArgs(ccfg, &api.HostConfig{}) {
+		if strings.HasPrefix(a, "--audit-") {
+			t.Fatalf("expect no audit args if audit disabled, get: %s", a)
+		}
+	}
+
+	ccfg.Audit = api.AuditConfig{Enabled: true, MaxAge: 7}
+	ccfg.ControlPlane.APIConf = &api.APIServer{ExtraArgs: map[string]string{"--audit-log-maxsize": "500"}}
+	args := strings.Join(getAPIServerArgs(ccfg, &api.HostConfig{}), " ")
+	expects := []string{
+		"--audit-policy-file=/etc/kubernetes/audit-policy.yaml",
+		"--audit-log-path=" + DefaultAuditLogPath,
+		"--audit-log-maxage=7",
+		"--audit-log-maxbackup=10",
+		// extra args of user override audit args
+		"--audit-log-maxsize=500",
+	}
+	for _, e := range expects {
+		if !strings.Contains(args, e) {
+			t.Fatalf("expect apiserver arg %s, get: %s", e, args)
+		}
+	}
+}
+
+func TestAuditPolicyShell(t *testing.T) {
+	ccfg := &api.ClusterConfig{
+		Audit: api.AuditConfig{Enabled: true, LogPath: "/data/audit/apiserver.log"},
+	}
+	shell, err := auditPolicyShell(ccfg)
+	if err != nil {
+		t.Fatalf("render audit policy shell failed: %v", err)
+	}
+	// default policy is used if no policy set
+	policy := base64.StdEncoding.EncodeToString([]byte(DefaultAuditPolicy))
+	for _, e := range []string{"echo " + policy + " | base64 -d > /etc/kubernetes/audit-policy.yaml", "mkdir -p -m 0700 /data/audit"} {
+		if !strings.Contains(shell, e) {
+			t.Fatalf("expect %q in shell:\n%s", e, shell)
+		}
+	}
+
+	ccfg.Audit.Policy = "apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n- level: RequestResponse\n"
+	if shell, err = auditPolicyShell(ccfg); err != nil {
+		t.Fatalf("render audit policy shell with inline policy failed: %v", err)
+	}
+	if !strings.Contains(shell, base64.StdEncoding.EncodeToString([]byte(ccfg.Audit.Policy))) {
+		t.Fatalf("expect inline policy in shell:\n%s", shell)
+	}
+
+	ccfg.Audit.Policy = "kind: Policy"
+	if _, err = auditPolicyShell(ccfg); err == nil {
+		t.Fatalf("expect invalid inline policy failed")
+	}
+}
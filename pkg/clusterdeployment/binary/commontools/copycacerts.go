@@ -16,12 +16,16 @@ package commontools
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 
 	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils"
 	"isula.org/eggo/pkg/utils/runner"
 )
 
@@ -89,6 +93,73 @@ func getRequireCerts(hostType uint16) []string {
 	return ret
 }
 
+// stageRequireCerts collects required certs into a temp dir with the same layout of cert store
+func stageRequireCerts(cluster string, requireCerts []string) (string, error) {
+	stageDir, err := ioutil.TempDir("", "eggo-certs-")
+	if err != nil {
+		return "", err
+	}
+	// mode of temp dir is 0700, use the same mode as created by mkdir
+	if err = os.Chmod(stageDir, 0755); err != nil {
+		os.RemoveAll(stageDir)
+		return "", err
+	}
+
+	homeDir := api.GetCertificateStorePath(cluster)
+	for _, cert := range requireCerts {
+		if err = copyLocalFile(filepath.Join(homeDir, cert), filepath.Join(stageDir, cert)); err != nil {
+			os.RemoveAll(stageDir)
+			return "", err
+		}
+	}
+	return stageDir, nil
+}
+
+func copyLocalFile(src, dst string) error {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// verifyCerts compares sha256 of certs on node with local certs, to find out corrupt copy
+func verifyCerts(r runner.Runner, cluster, savePath string, requireCerts []string) error {
+	homeDir := api.GetCertificateStorePath(cluster)
+	for _, cert := range requireCerts {
+		expect, err := utils.FileSha256(filepath.Join(homeDir, cert))
+		if err != nil {
+			return fmt.Errorf("calculate sha256 of %s failed: %v", cert, err)
+		}
+		remote := filepath.Join(savePath, cert)
+		output, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"sha256sum %s\"", remote))
+		if err != nil {
+			return fmt.Errorf("calculate sha256 of %s on node failed: %v", remote, err)
+		}
+		fields := strings.Fields(output)
+		if len(fields) == 0 || fields[0] != expect {
+			return fmt.Errorf("sha256 of %s mismatch, expect: %s, get: %s", remote, expect, strings.TrimSpace(output))
+		}
+	}
+	return nil
+}
+
 func (ct *CopyCaCertificatesTask) Run(r runner.Runner, hcf *api.HostConfig) error {
 	hostType := hcf.Type | ct.JoinType
 
@@ -96,21 +167,19 @@ func (ct *CopyCaCertificatesTask) Run(r runner.Runner, hcf *api.HostConfig) erro
 	if !checkCaExists(ct.Cluster.Name, requireCerts) {
 		return fmt.Errorf("[certs] cannot find ca certificates")
 	}
-	cmd := fmt.Sprintf("sudo -E /bin/sh -c \"mkdir -p %s\"", ct.Cluster.Certificate.SavePath)
-	if (hostType&api.ETCD) != 0 || (hostType&api.Master) != 0 {
-		cmd = fmt.Sprintf("sudo -E /bin/sh -c \"mkdir -p %s/etcd\"", ct.Cluster.Certificate.SavePath)
+	stageDir, err := stageRequireCerts(ct.Cluster.Name, requireCerts)
+	if err != nil {
+		return fmt.Errorf("prepare certs for host: %s failed: %v", hcf.Name, err)
 	}
+	defer os.RemoveAll(stageDir)
 
-	if _, err := r.RunCommand(cmd); err != nil {
+	if err = r.CopyDir(stageDir, ct.Cluster.Certificate.SavePath); err != nil {
+		logrus.Errorf("copy certs to host: %s failed: %v", hcf.Name, err)
 		return err
 	}
-
-	homeDir := api.GetCertificateStorePath(ct.Cluster.Name)
-	for _, cert := range requireCerts {
-		if err := r.Copy(filepath.Join(homeDir, cert), filepath.Join(ct.Cluster.Certificate.SavePath, cert)); err != nil {
-			logrus.Errorf("copy cert: %s to host: %s failed: %v", cert, hcf.Name, err)
-			return err
-		}
+	if err = verifyCerts(r, ct.Cluster.Name, ct.Cluster.Certificate.SavePath, requireCerts); err != nil {
+		logrus.Errorf("verify certs on host: %s failed: %v", hcf.Name, err)
+		return err
 	}
 	logrus.Infof("copy certs to host: %s success", hcf.Name)
 
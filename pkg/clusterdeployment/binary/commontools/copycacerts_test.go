@@ -0,0 +1,75 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for copy ca certificates
+ ******************************************************************************/
+
+package commontools
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/runner/runnertest"
+)
+
+// sumOutput sets output of sha256sum of remote file
+func sumOutput(r *runnertest.Runner, file, sum string) {
+	r.Outputs[fmt.Sprintf("sudo -E /bin/sh -c \"sha256sum %s\"", file)] = fmt.Sprintf("%s  %s\n", sum, file)
+}
+
+func TestCopyCaCertificatesVerify(t *testing.T) {
+	homePath := api.EggoHomePath
+	api.EggoHomePath = t.TempDir()
+	defer func() {
+		api.EggoHomePath = homePath
+	}()
+
+	ccfg := &api.ClusterConfig{Name: "test-cluster"}
+	ccfg.Certificate.SavePath = "/etc/kubernetes/pki"
+	storePath := api.GetCertificateStorePath(ccfg.Name)
+	// commands other than sha256sum are not expected
+	r := &runnertest.Runner{Outputs: make(map[string]string), Strict: true}
+	for _, cert := range getRequireCerts(api.Master | api.Worker | api.ETCD) {
+		p := filepath.Join(storePath, cert)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("mkdir for %s failed: %v", cert, err)
+		}
+		if err := ioutil.WriteFile(p, []byte(cert), 0600); err != nil {
+			t.Fatalf("write %s failed: %v", cert, err)
+		}
+		sum, err := utils.FileSha256(p)
+		if err != nil {
+			t.Fatalf("calculate sha256 of %s failed: %v", cert, err)
+		}
+		sumOutput(r, filepath.Join(ccfg.Certificate.SavePath, cert), sum)
+	}
+
+	task := &CopyCaCertificatesTask{Cluster: ccfg}
+	hcf := &api.HostConfig{Name: "master0", Type: api.Master | api.Worker | api.ETCD}
+	if err := task.Run(r, hcf); err != nil {
+		t.Fatalf("copy certs failed: %v", err)
+	}
+
+	// corrupt ca on node
+	sumOutput(r, "/etc/kubernetes/pki/etcd/ca.crt", strings.Repeat("0", 64))
+	err := task.Run(r, hcf)
+	if err == nil || !strings.Contains(err.Error(), "/etc/kubernetes/pki/etcd/ca.crt") {
+		t.Fatalf("expect sha256 mismatch of etcd/ca.crt, get: %v", err)
+	}
+}
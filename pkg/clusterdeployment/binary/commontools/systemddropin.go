@@ -0,0 +1,149 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: systemd drop-in overrides of components
+ ******************************************************************************/
+
+package commontools
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"isula.org/eggo/pkg/utils/runner"
+	"isula.org/eggo/pkg/utils/template"
+)
+
+const (
+	systemdDropInDir  = "/etc/systemd/system"
+	systemdDropInFile = "eggo.conf"
+)
+
+var (
+	// units of components deployed by eggo, which support drop-in overrides
+	dropInUnits = map[string]bool{
+		"kubelet":                 true,
+		"kube-proxy":              true,
+		"kube-apiserver":          true,
+		"kube-controller-manager": true,
+		"kube-scheduler":          true,
+		"etcd":                    true,
+	}
+	// keys of [Service] section which are allowed to override
+	dropInKeys = map[string]bool{
+		"Restart":         true,
+		"RestartSec":      true,
+		"LimitNOFILE":     true,
+		"LimitNPROC":      true,
+		"LimitCORE":       true,
+		"LimitMEMLOCK":    true,
+		"TasksMax":        true,
+		"TimeoutStartSec": true,
+		"TimeoutStopSec":  true,
+		"Environment":     true,
+		"CPUQuota":        true,
+		"MemoryLimit":     true,
+		"MemoryMax":       true,
+		"Nice":            true,
+		"OOMScoreAdjust":  true,
+	}
+)
+
+// SystemdDropInPath returns path of drop-in written by eggo for unit
+func SystemdDropInPath(unit string) string {
+	return fmt.Sprintf("%s/%s.service.d/%s", systemdDropInDir, unit, systemdDropInFile)
+}
+
+// CheckSystemdDropIns checks units and keys of drop-ins against allowlist
+func CheckSystemdDropIns(dropIns map[string]map[string]string) error {
+	for unit, settings := range dropIns {
+		if !dropInUnits[unit] {
+			return fmt.Errorf("unsupport systemd drop-in of unit %s", unit)
+		}
+		for k, v := range settings {
+			if !dropInKeys[k] {
+				return fmt.Errorf("unsupport key %s in systemd drop-in of unit %s", k, unit)
+			}
+			if v == "" || strings.ContainsAny(v, "\r\n") {
+				return fmt.Errorf("invalid value of %s in systemd drop-in of unit %s", k, unit)
+			}
+		}
+	}
+	return nil
+}
+
+func dropInContent(settings map[string]string) string {
+	if len(settings) == 0 {
+		return ""
+	}
+	var keys []string
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	// keep order of keys stable, avoid unnecessary change of drop-in
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString("[Service]\n")
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("%s=%s\n", k, settings[k]))
+	}
+	return sb.String()
+}
+
+type dropIn struct {
+	Path    string
+	Content string
+}
+
+// systemdDropInsShell writes drop-ins of units, and removes drop-in of unit without settings,
+// so that removed settings take effect when deploy again
+func systemdDropInsShell(dropIns map[string]map[string]string, units ...string) (string, error) {
+	shell := `
+#!/bin/bash
+{{- range $i, $v := .dropIns }}
+{{- if $v.Content }}
+mkdir -p $(dirname {{ $v.Path }}) && echo {{ $v.Content }} | base64 -d > {{ $v.Path }} || exit 1
+{{- else }}
+rm -f {{ $v.Path }}
+{{- end }}
+{{- end }}
+systemctl daemon-reload || exit 1
+exit 0
+`
+	var ds []dropIn
+	for _, unit := range units {
+		d := dropIn{Path: SystemdDropInPath(unit)}
+		if content := dropInContent(dropIns[unit]); content != "" {
+			d.Content = base64.StdEncoding.EncodeToString([]byte(content))
+		}
+		ds = append(ds, d)
+	}
+
+	datastore := make(map[string]interface{})
+	datastore["dropIns"] = ds
+	return template.TemplateRender(shell, datastore)
+}
+
+// SetupSystemdDropIns writes drop-ins of units and reloads systemd, caller restarts units to apply them
+func SetupSystemdDropIns(r runner.Runner, dropIns map[string]map[string]string, units ...string) error {
+	shell, err := systemdDropInsShell(dropIns, units...)
+	if err != nil {
+		return err
+	}
+	if _, err := r.RunShell(shell, "systemdDropIns"); err != nil {
+		return fmt.Errorf("setup systemd drop-ins of %v failed: %v", units, err)
+	}
+	return nil
+}
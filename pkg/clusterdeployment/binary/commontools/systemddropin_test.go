@@ -0,0 +1,98 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for systemd drop-ins
+ ******************************************************************************/
+
+package commontools
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner/runnertest"
+)
+
+func TestCheckSystemdDropIns(t *testing.T) {
+	valid := map[string]map[string]string{
+		"kubelet": {"Restart": "always", "LimitNOFILE": "1048576"},
+		"etcd":    {"TimeoutStartSec": "0"},
+	}
+	if err := CheckSystemdDropIns(valid); err != nil {
+		t.Fatalf("check valid drop-ins failed: %v", err)
+	}
+
+	invalids := []map[string]map[string]string{
+		{"sshd": {"Restart": "always"}},
+		{"kubelet": {"ExecStart": "/bin/sh"}},
+		{"kubelet": {"Restart": ""}},
+		{"kubelet": {"Restart": "always\nExecStartPre=/bin/sh"}},
+	}
+	for _, d := range invalids {
+		if err := CheckSystemdDropIns(d); err == nil {
+			t.Fatalf("expect invalid drop-ins: %v", d)
+		}
+	}
+}
+
+func TestSystemdDropInsShell(t *testing.T) {
+	dropIns := map[string]map[string]string{
+		"kubelet": {"Restart": "always", "LimitNOFILE": "1048576"},
+	}
+	shell, err := systemdDropInsShell(dropIns, "kubelet", "kube-proxy")
+	if err != nil {
+		t.Fatalf("render drop-ins shell failed: %v", err)
+	}
+
+	content := base64.StdEncoding.EncodeToString([]byte("[Service]\nLimitNOFILE=1048576\nRestart=always\n"))
+	expects := []string{
+		"echo " + content + " | base64 -d > /etc/systemd/system/kubelet.service.d/eggo.conf",
+		// drop-in of unit without settings is removed
+		"rm -f /etc/systemd/system/kube-proxy.service.d/eggo.conf",
+		"systemctl daemon-reload",
+	}
+	last := -1
+	for _, e := range expects {
+		idx := strings.Index(shell, e)
+		if idx <= last {
+			t.Fatalf("expect %q in order in shell:\n%s", e, shell)
+		}
+		last = idx
+	}
+}
+
+func TestSetupWorkerServicesWithDropIns(t *testing.T) {
+	ccfg := &api.ClusterConfig{
+		WorkerConfig: api.WorkerConfig{
+			KubeletConf:         &api.Kubelet{},
+			ContainerEngineConf: &api.ContainerEngine{Runtime: "docker"},
+		},
+		SystemdDropIns: map[string]map[string]string{
+			"kube-proxy": {"Restart": "always"},
+		},
+	}
+	r := &runnertest.Runner{}
+	if err := SetupWorkerServices(r, ccfg, &api.HostConfig{Name: "worker0"}); err != nil {
+		t.Fatalf("setup worker services failed: %v", err)
+	}
+
+	// drop-ins are written after services, and applied by restart
+	expect := []string{"kubelet", "kube-proxy", "systemdDropIns", "sudo -E /bin/sh -c \"systemctl restart kubelet kube-proxy\""}
+	if strings.Join(r.Steps, ",") != strings.Join(expect, ",") {
+		t.Fatalf("expect steps %v, get: %v", expect, r.Steps)
+	}
+	if !strings.Contains(r.Shell("systemdDropIns"), "kube-proxy.service.d/eggo.conf") {
+		t.Fatalf("drop-in of kube-proxy is not written: %s", r.Shell("systemdDropIns"))
+	}
+}
@@ -17,6 +17,7 @@ package commontools
 import (
 	"encoding/base64"
 	"fmt"
+	"sort"
 
 	"github.com/sirupsen/logrus"
 
@@ -30,7 +31,7 @@ const (
 	SystemdServiceConfigPath = "/usr/lib/systemd/system"
 )
 
-func SetupAPIServerService(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.HostConfig) error {
+func getAPIServerArgs(ccfg *api.ClusterConfig, hcf *api.HostConfig) []string {
 	defaultArgs := map[string]string{
 		"--advertise-address":                  hcf.Address,
 		"--allow-privileged":                   "true",
@@ -53,13 +54,20 @@ func SetupAPIServerService(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.Ho
 		"--service-account-issuer":             "https://kubernetes.default.svc.cluster.local",
 		"--service-account-key-file":           "/etc/kubernetes/pki/sa.pub",
 		"--service-account-signing-key-file":   "/etc/kubernetes/pki/sa.key",
-		"--service-node-port-range":            "30000-32767",
+		"--service-node-port-range":            ccfg.GetNodePortRange(),
 		"--requestheader-allowed-names":        "front-proxy-client",
 		"--requestheader-client-ca-file":       "/etc/kubernetes/pki/front-proxy-ca.crt",
 		"--requestheader-extra-headers-prefix": "X-Remote-Extra-",
 		"--requestheader-group-headers":        "X-Remote-Group",
 		"--requestheader-username-headers":     "X-Remote-User",
-		"--encryption-provider-config":         "/etc/kubernetes/encryption-config.yaml",
+		"--encryption-provider-config":         ccfg.GetEncryptionConfigPath(),
+	}
+	// insecure port is enabled by default before v1.20
+	if ccfg.K8sVersionLessThan("v1.20.0") {
+		defaultArgs["--insecure-port"] = "0"
+	}
+	for k, v := range getAuditArgs(ccfg) {
+		defaultArgs[k] = v
 	}
 	if ccfg.ControlPlane.APIConf != nil {
 		for k, v := range ccfg.ControlPlane.APIConf.ExtraArgs {
@@ -71,7 +79,20 @@ func SetupAPIServerService(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.Ho
 	for k, v := range defaultArgs {
 		args = append(args, fmt.Sprintf("%s=%s", k, v))
 	}
+	// keep order of args stable, avoid unnecessary change of service file
+	sort.Strings(args)
+
+	return args
+}
 
+func SetupAPIServerService(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.HostConfig) error {
+	// policy is required by apiserver before start
+	if err := SetupAuditPolicy(r, ccfg); err != nil {
+		logrus.Errorf("setup audit policy failed: %v", err)
+		return err
+	}
+
+	args := getAPIServerArgs(ccfg, hcf)
 	conf := &template.SystemdServiceConfig{
 		Description:   "Kubernetes API Server",
 		Documentation: "https://kubernetes.io/docs/reference/generated/kube-apiserver/",
@@ -221,7 +242,12 @@ func SetupMasterServices(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.Host
 		return err
 	}
 
-	_, err := r.RunCommand("sudo systemctl restart kube-apiserver kube-controller-manager kube-scheduler")
+	if err := SetupSystemdDropIns(r, ccfg.SystemdDropIns, "kube-apiserver", "kube-controller-manager", "kube-scheduler"); err != nil {
+		logrus.Errorf("setup drop-ins of k8s master services failed: %v", err)
+		return err
+	}
+
+	_, err := r.RunCommand("sudo -E /bin/sh -c \"systemctl restart kube-apiserver kube-controller-manager kube-scheduler\"")
 	if err != nil {
 		logrus.Errorf("start k8s master services failed: %v", err)
 	}
@@ -229,7 +255,7 @@ func SetupMasterServices(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.Host
 	return nil
 }
 
-func SetupKubeletService(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.HostConfig) error {
+func getKubeletArgs(ccfg *api.ClusterConfig, hcf *api.HostConfig) []string {
 	defaultArgs := map[string]string{
 		"--config":               "/etc/kubernetes/kubelet_config.yaml",
 		"--kubeconfig":           "/etc/kubernetes/kubelet.kubeconfig",
@@ -240,10 +266,13 @@ func SetupKubeletService(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.Host
 	}
 
 	configArgs := map[string]string{
-		"--pod-infra-container-image": ccfg.WorkerConfig.KubeletConf.PauseImage,
+		"--pod-infra-container-image": ccfg.GetImage(ccfg.WorkerConfig.KubeletConf.PauseImage),
 	}
 	if !utils.IsDocker(ccfg.WorkerConfig.ContainerEngineConf.Runtime) {
-		configArgs["--container-runtime"] = "remote"
+		// remote is the only runtime since v1.24, and flag is removed since v1.27
+		if !ccfg.K8sVersionAtLeast("v1.27.0") {
+			configArgs["--container-runtime"] = "remote"
+		}
 		configArgs["--container-runtime-endpoint"] = ccfg.WorkerConfig.ContainerEngineConf.RuntimeEndpoint
 	}
 	for k, v := range configArgs {
@@ -252,6 +281,7 @@ func SetupKubeletService(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.Host
 		}
 	}
 
+	// extra args of user will override default args
 	for k, v := range ccfg.WorkerConfig.KubeletConf.ExtraArgs {
 		defaultArgs[k] = v
 	}
@@ -260,7 +290,14 @@ func SetupKubeletService(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.Host
 	for k, v := range defaultArgs {
 		args = append(args, fmt.Sprintf("%s=%s", k, v))
 	}
+	// keep order of args stable, avoid unnecessary change of service file
+	sort.Strings(args)
+
+	return args
+}
 
+func SetupKubeletService(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.HostConfig) error {
+	args := getKubeletArgs(ccfg, hcf)
 	conf := &template.SystemdServiceConfig{
 		Description:   "The Kubernetes Node Agent",
 		Documentation: "https://kubernetes.io/docs/reference/generated/kubelet/",
@@ -345,6 +382,11 @@ func SetupWorkerServices(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.Host
 		return err
 	}
 
+	if err := SetupSystemdDropIns(r, ccfg.SystemdDropIns, "kubelet", "kube-proxy"); err != nil {
+		logrus.Errorf("setup drop-ins of k8s worker services failed: %v", err)
+		return err
+	}
+
 	_, err := r.RunCommand("sudo -E /bin/sh -c \"systemctl restart kubelet kube-proxy\"")
 	if err != nil {
 		logrus.Errorf("start k8s worker services failed: %v", err)
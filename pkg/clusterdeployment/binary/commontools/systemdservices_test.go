@@ -0,0 +1,160 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for systemd services
+ ******************************************************************************/
+package commontools
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func TestGetKubeletArgs(t *testing.T) {
+	ccfg := &api.ClusterConfig{
+		WorkerConfig: api.WorkerConfig{
+			KubeletConf: &api.Kubelet{
+				PauseImage: "k8s.gcr.io/pause:3.2",
+				ExtraArgs: map[string]string{
+					"--max-pods":      "200",
+					"--eviction-hard": "memory.available<5%",
+					"--v":             "4",
+				},
+			},
+			ContainerEngineConf: &api.ContainerEngine{
+				Runtime: "docker",
+			},
+		},
+	}
+	hcf := &api.HostConfig{
+		Name: "worker0",
+	}
+
+	args := getKubeletArgs(ccfg, hcf)
+	if !sort.StringsAreSorted(args) {
+		t.Fatalf("kubelet args are not sorted: %v", args)
+	}
+
+	expects := []string{
+		"--max-pods=200",
+		"--eviction-hard=memory.available<5%",
+		"--v=4",
+		"--hostname-override=worker0",
+		"--pod-infra-container-image=k8s.gcr.io/pause:3.2",
+	}
+	for _, e := range expects {
+		found := false
+		for _, a := range args {
+			if a == e {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("expect kubelet arg: %s, get: %v", e, args)
+		}
+	}
+	for _, a := range args {
+		if a == "--v=2" {
+			t.Fatalf("default arg should be override by extra args: %v", args)
+		}
+	}
+
+	ccfg.ImageRepository = "hub.example.com:5000/k8s"
+	expect := "--pod-infra-container-image=hub.example.com:5000/k8s/pause:3.2"
+	found := false
+	for _, a := range getKubeletArgs(ccfg, hcf) {
+		found = found || a == expect
+	}
+	if !found {
+		t.Fatalf("expect kubelet arg: %s, get: %v", expect, getKubeletArgs(ccfg, hcf))
+	}
+}
+
+func TestGetKubeletArgsOfVersion(t *testing.T) {
+	ccfg := &api.ClusterConfig{
+		WorkerConfig: api.WorkerConfig{
+			KubeletConf: &api.Kubelet{},
+			ContainerEngineConf: &api.ContainerEngine{
+				Runtime:         "containerd",
+				RuntimeEndpoint: "unix:///run/containerd/containerd.sock",
+			},
+		},
+	}
+	hcf := &api.HostConfig{
+		Name: "worker0",
+	}
+
+	hasArg := func(args []string, arg string) bool {
+		for _, a := range args {
+			if a == arg {
+				return true
+			}
+		}
+		return false
+	}
+	cases := []struct {
+		version string
+		remote  bool
+	}{
+		{version: "", remote: true},
+		{version: "v1.20.2", remote: true},
+		{version: "v1.27.1", remote: false},
+	}
+	for _, c := range cases {
+		ccfg.KubernetesVersion = c.version
+		args := getKubeletArgs(ccfg, hcf)
+		if hasArg(args, "--container-runtime=remote") != c.remote {
+			t.Fatalf("version %s expect --container-runtime=remote: %v, get: %v", c.version, c.remote, args)
+		}
+		if !hasArg(args, "--container-runtime-endpoint=unix:///run/containerd/containerd.sock") {
+			t.Fatalf("version %s expect runtime endpoint, get: %v", c.version, args)
+		}
+	}
+}
+
+func TestGetAPIServerArgs(t *testing.T) {
+	ccfg := &api.ClusterConfig{
+		ConfigDir: "/data/kubernetes",
+		ControlPlane: api.ControlPlaneConfig{
+			APIConf: &api.APIServer{
+				ExtraArgs: map[string]string{"--v": "4"},
+			},
+		},
+	}
+	args := getAPIServerArgs(ccfg, &api.HostConfig{Name: "master0", Address: "192.168.0.1"})
+	if !sort.StringsAreSorted(args) {
+		t.Fatalf("apiserver args are not sorted: %v", args)
+	}
+
+	expects := map[string]bool{
+		"--encryption-provider-config=/data/kubernetes/encryption-config.yaml": true,
+		"--advertise-address=192.168.0.1":                                      true,
+		"--service-node-port-range=30000-32767":                                true,
+		"--v=4":                                                                true,
+	}
+	for _, a := range args {
+		delete(expects, a)
+	}
+	if len(expects) != 0 {
+		t.Fatalf("expect apiserver args: %v, get: %v", expects, args)
+	}
+
+	ccfg.ServiceCluster.NodePortRange = "20000-22767"
+	args = getAPIServerArgs(ccfg, &api.HostConfig{Name: "master0", Address: "192.168.0.1"})
+	if !strings.Contains(strings.Join(args, " "), "--service-node-port-range=20000-22767") {
+		t.Fatalf("expect custom node port range in apiserver args: %v", args)
+	}
+}
@@ -16,19 +16,21 @@ package commontools
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
-	"text/template"
 	"time"
 
 	"isula.org/eggo/pkg/api"
 	"isula.org/eggo/pkg/constants"
+	"isula.org/eggo/pkg/utils"
 	"isula.org/eggo/pkg/utils/runner"
+	"isula.org/eggo/pkg/utils/template"
 
-	kkutil "github.com/kubesphere/kubekey/pkg/util"
-	"github.com/lithammer/dedent"
 	"github.com/sirupsen/logrus"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
 	bootstraputil "k8s.io/cluster-bootstrap/token/util"
 )
 
@@ -45,7 +47,7 @@ stringData:
   token-secret: {{ .Secret }}
   expiration: {{ .Expiration }}
   {{- range $i, $v := .Usages }}
-  {{ $v }}
+  usage-bootstrap-{{ $v }}: "true"
   {{- end }}
   {{- if .AuthExtraGroups }}
   auth-extra-groups: {{ .AuthExtraGroups }}
@@ -53,41 +55,108 @@ stringData:
 `
 )
 
-func CreateBootstrapToken(r runner.Runner, bconf *api.BootstrapTokenConfig, kubeconfig, manifestDir string) error {
-	var sb strings.Builder
-	var usages []string
-	now := time.Now()
-	tmpl := template.Must(template.New("bootstrap token").Parse(dedent.Dedent(TokenTemplate)))
+const (
+	defaultBootstrapTokenTTL = 24 * time.Hour
+	maxBootstrapTokenTTL     = 365 * 24 * time.Hour
+
+	bootstrapTokenUsagePrefix = "usage-bootstrap-"
+)
+
+var (
+	DefaultBootstrapTokenUsages = []string{"authentication", "signing"}
+	DefaultBootstrapTokenGroups = []string{"system:bootstrappers:worker", "system:bootstrappers:ingress"}
+)
+
+// BootstrapTokenInfo is bootstrap token in cluster, secret of token is not included
+type BootstrapTokenInfo struct {
+	ID              string
+	Description     string
+	Expiration      string
+	Usages          []string
+	AuthExtraGroups []string
+}
+
+func getBootstrapTokenTTL(bconf *api.BootstrapTokenConfig) (time.Duration, error) {
+	if bconf == nil {
+		return 0, fmt.Errorf("empty bootstrap token config")
+	}
+	if !bootstraputil.IsValidBootstrapToken(bootstraputil.TokenFromIDAndSecret(bconf.ID, bconf.Secret)) {
+		return 0, fmt.Errorf("invalid bootstrap token id: %s or secret", bconf.ID)
+	}
+	if bconf.TTL == nil {
+		return defaultBootstrapTokenTTL, nil
+	}
+	ttl := *bconf.TTL
+	if ttl <= 0 || ttl > maxBootstrapTokenTTL {
+		return 0, fmt.Errorf("invalid ttl %v of bootstrap token %s, should be in (0, %v]", ttl, bconf.ID, maxBootstrapTokenTTL)
+	}
+	return ttl, nil
+}
+
+// CheckBootstrapTokenUsagesAndGroups checks usages are known usages of bootstrap token,
+// and extra groups are in format of system:bootstrappers:<name>
+func CheckBootstrapTokenUsagesAndGroups(usages, groups []string) error {
+	if err := bootstraputil.ValidateUsages(usages); err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, u := range usages {
+		if seen[u] {
+			return fmt.Errorf("duplicate usage of bootstrap token: %s", u)
+		}
+		seen[u] = true
+	}
+	for _, g := range groups {
+		if err := bootstraputil.ValidateBootstrapGroupName(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderBootstrapToken(bconf *api.BootstrapTokenConfig, now time.Time) (string, error) {
+	ttl, err := getBootstrapTokenTTL(bconf)
+	if err != nil {
+		return "", err
+	}
+	if err = CheckBootstrapTokenUsagesAndGroups(bconf.Usages, bconf.AuthExtraGroups); err != nil {
+		return "", err
+	}
 	datastore := map[string]interface{}{}
 	datastore["Description"] = bconf.Description
 	datastore["ID"] = bconf.ID
 	datastore["Secret"] = bconf.Secret
-	// default set ttl 24 hours
-	ttl := 24 * time.Hour
-	if bconf.TTL != nil {
-		ttl = *bconf.TTL
-	}
 	datastore["Expiration"] = now.Add(ttl).Format(time.RFC3339)
-	for _, usage := range bconf.Usages {
-		usages = append(usages, fmt.Sprintf("usage-bootstrap-%s: \"true\"", usage))
-	}
-	datastore["Usages"] = usages
+	datastore["Usages"] = bconf.Usages
 	if len(bconf.AuthExtraGroups) > 0 {
 		datastore["AuthExtraGroups"] = strings.Join(bconf.AuthExtraGroups, ",")
 	}
-	coreConfig, err := kkutil.Render(tmpl, datastore)
-	if err != nil {
-		logrus.Errorf("rend core config failed: %v", err)
-		return err
-	}
+	data, err := template.RenderYaml("bootstrap token", TokenTemplate, datastore)
+	return string(data), err
+}
+
+// bootstrapTokenApplyShell writes token yaml into an unique temp dir under manifestDir,
+// the temp dir is removed whether apply success or not, to avoid leaving secret on node
+func bootstrapTokenApplyShell(tokenYaml, kubeconfig, manifestDir string) string {
+	var sb strings.Builder
 	sb.WriteString("sudo -E /bin/sh -c \"")
 	sb.WriteString(fmt.Sprintf("mkdir -p %s", manifestDir))
-	tokenYamlBase64 := base64.StdEncoding.EncodeToString([]byte(coreConfig))
-	sb.WriteString(fmt.Sprintf(" && echo %s | base64 -d > %s/bootstrap_token.yaml", tokenYamlBase64, manifestDir))
-	sb.WriteString(fmt.Sprintf(" && KUBECONFIG=%s kubectl apply -f %s/bootstrap_token.yaml", kubeconfig, manifestDir))
+	sb.WriteString(fmt.Sprintf(" && tmpdir=\\$(mktemp -d %s/.bootstrap_token.XXXXXX)", manifestDir))
+	tokenYamlBase64 := base64.StdEncoding.EncodeToString([]byte(tokenYaml))
+	sb.WriteString(fmt.Sprintf(" && echo %s | base64 -d > \\$tmpdir/bootstrap_token.yaml", tokenYamlBase64))
+	sb.WriteString(fmt.Sprintf(" && KUBECONFIG=%s kubectl apply -f \\$tmpdir/bootstrap_token.yaml", kubeconfig))
+	sb.WriteString("; ret=\\$?; rm -rf \\$tmpdir; exit \\$ret")
 	sb.WriteString("\"")
+	return sb.String()
+}
 
-	_, err = r.RunCommand(sb.String())
+func CreateBootstrapToken(r runner.Runner, bconf *api.BootstrapTokenConfig, kubeconfig, manifestDir string) error {
+	coreConfig, err := renderBootstrapToken(bconf, time.Now())
+	if err != nil {
+		logrus.Errorf("rend core config failed: %v", err)
+		return err
+	}
+	_, err = r.RunCommand(bootstrapTokenApplyShell(coreConfig, kubeconfig, manifestDir))
 	if err != nil {
 		logrus.Errorf("create core config failed: %v", err)
 		return err
@@ -115,14 +184,105 @@ func GetBootstrapToken(r runner.Runner, tokenStr string, kubeconfig, manifestDir
 		Description:     "bootstrap token for eggo",
 		ID:              id,
 		Secret:          secret,
-		Usages:          []string{"authentication", "signing"},
-		AuthExtraGroups: []string{"system:bootstrappers:worker,system:bootstrappers:ingress"},
+		Usages:          DefaultBootstrapTokenUsages,
+		AuthExtraGroups: DefaultBootstrapTokenGroups,
 	}
 	err = CreateBootstrapToken(r, bconf, kubeconfig, manifestDir)
 
 	return token, err
 }
 
+// parseBootstrapTokens parses data of bootstrap token secrets, which are json objects one by one
+func parseBootstrapTokens(output string) ([]BootstrapTokenInfo, error) {
+	var tokens []BootstrapTokenInfo
+	dec := json.NewDecoder(strings.NewReader(output))
+	for dec.More() {
+		var data map[string]string
+		if err := dec.Decode(&data); err != nil {
+			return nil, fmt.Errorf("parse bootstrap token failed: %v", err)
+		}
+		var info BootstrapTokenInfo
+		for k, v := range data {
+			value, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, fmt.Errorf("decode %s of bootstrap token failed: %v", k, err)
+			}
+			switch {
+			case k == "token-id":
+				info.ID = string(value)
+			case k == "description":
+				info.Description = string(value)
+			case k == "expiration":
+				info.Expiration = string(value)
+			case k == "auth-extra-groups":
+				info.AuthExtraGroups = strings.Split(string(value), ",")
+			case strings.HasPrefix(k, bootstrapTokenUsagePrefix) && string(value) == "true":
+				info.Usages = append(info.Usages, strings.TrimPrefix(k, bootstrapTokenUsagePrefix))
+			}
+		}
+		if info.ID == "" {
+			continue
+		}
+		sort.Strings(info.Usages)
+		tokens = append(tokens, info)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].ID < tokens[j].ID
+	})
+	return tokens, nil
+}
+
+// ListBootstrapTokens lists bootstrap tokens in cluster
+func ListBootstrapTokens(r runner.Runner, kubeconfig string) ([]BootstrapTokenInfo, error) {
+	cmd := fmt.Sprintf("KUBECONFIG=%s kubectl -n kube-system get secrets --field-selector type=%s -o jsonpath='{range .items[*]}{.data}{end}'",
+		kubeconfig, bootstrapapi.SecretTypeBootstrapToken)
+	output, err := r.RunCommand(utils.AddSudo(cmd))
+	if err != nil {
+		return nil, fmt.Errorf("list bootstrap tokens failed: %v", err)
+	}
+	return parseBootstrapTokens(output)
+}
+
+// DeleteBootstrapToken deletes bootstrap token by id or token string
+func DeleteBootstrapToken(r runner.Runner, idOrToken, kubeconfig string) error {
+	id := idOrToken
+	if splitStrs := bootstraputil.BootstrapTokenRegexp.FindStringSubmatch(idOrToken); len(splitStrs) == 3 {
+		id = splitStrs[1]
+	}
+	if !bootstraputil.IsValidBootstrapTokenID(id) {
+		return fmt.Errorf("invalid bootstrap token id: %s", idOrToken)
+	}
+	cmd := fmt.Sprintf("KUBECONFIG=%s kubectl -n kube-system delete secret %s", kubeconfig, bootstraputil.BootstrapTokenSecretName(id))
+	if _, err := r.RunCommand(utils.AddSudo(cmd)); err != nil {
+		return fmt.Errorf("delete bootstrap token %s failed: %v", id, err)
+	}
+	return nil
+}
+
+// KubeletBootstrapCommand returns command to generate bootstrap kubeconfig of kubelet with token
+func KubeletBootstrapCommand(clusterName, apiEndpoint, token string) string {
+	var sb strings.Builder
+	sb.WriteString("cd /etc/kubernetes/ && ")
+	sb.WriteString("kubectl config set-cluster " + clusterName +
+		" --certificate-authority=/etc/kubernetes/pki/ca.crt" +
+		" --embed-certs=true" +
+		" --server=" + apiEndpoint +
+		" --kubeconfig=kubelet-bootstrap.kubeconfig")
+	sb.WriteString(" && ")
+	sb.WriteString("kubectl config set-credentials kubelet-bootstrap" +
+		" --token=" + token +
+		" --kubeconfig=kubelet-bootstrap.kubeconfig")
+	sb.WriteString(" && ")
+	sb.WriteString("kubectl config set-context default" +
+		" --cluster=" + clusterName +
+		" --user=kubelet-bootstrap" +
+		" --kubeconfig=kubelet-bootstrap.kubeconfig")
+	sb.WriteString(" && ")
+	sb.WriteString("kubectl config use-context default" +
+		" --kubeconfig=kubelet-bootstrap.kubeconfig")
+	return sb.String()
+}
+
 func ParseBootstrapTokenStr(useToken string) (token, id, secret string, err error) {
 	if useToken == "" {
 		tokenStr, err := bootstraputil.GenerateBootstrapToken()
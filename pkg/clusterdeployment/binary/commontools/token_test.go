@@ -15,8 +15,16 @@
 package commontools
 
 import (
+	"encoding/base64"
+	"fmt"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
+
+	"gopkg.in/yaml.v1"
+
+	"isula.org/eggo/pkg/api"
 )
 
 func TestGenerateBootstrapToken(t *testing.T) {
@@ -37,3 +45,189 @@ func TestGenerateBootstrapToken(t *testing.T) {
 		t.Fatalf("invalid token: %s", token)
 	}
 }
+
+func TestRenderBootstrapToken(t *testing.T) {
+	ttl := time.Hour
+	bconf := &api.BootstrapTokenConfig{
+		Description:     "bootstrap token for eggo",
+		ID:              "abcdef",
+		Secret:          "0123456789abcdef",
+		TTL:             &ttl,
+		Usages:          []string{"authentication", "signing"},
+		AuthExtraGroups: []string{"system:bootstrappers:worker", "system:bootstrappers:ingress"},
+	}
+	now := time.Now()
+	manifest, err := renderBootstrapToken(bconf, now)
+	if err != nil {
+		t.Fatalf("render bootstrap token failed: %v", err)
+	}
+
+	var secret struct {
+		Kind       string            `yaml:"kind"`
+		StringData map[string]string `yaml:"stringData"`
+	}
+	if err = yaml.Unmarshal([]byte(manifest), &secret); err != nil {
+		t.Fatalf("bootstrap token manifest is invalid yaml: %v\n%s", err, manifest)
+	}
+	if secret.Kind != "Secret" {
+		t.Fatalf("invalid kind of bootstrap token: %s", secret.Kind)
+	}
+	expects := map[string]string{
+		"token-id":          bconf.ID,
+		"token-secret":      bconf.Secret,
+		"expiration":        now.Add(ttl).Format(time.RFC3339),
+		"auth-extra-groups": strings.Join(bconf.AuthExtraGroups, ","),
+	}
+	for _, usage := range bconf.Usages {
+		expects[fmt.Sprintf("usage-bootstrap-%s", usage)] = "true"
+	}
+	for k, v := range expects {
+		if secret.StringData[k] != v {
+			t.Fatalf("expect %s: %s in bootstrap token, got: %s", k, v, secret.StringData[k])
+		}
+	}
+}
+
+func TestBootstrapTokenTTL(t *testing.T) {
+	bconf := &api.BootstrapTokenConfig{
+		ID:     "abcdef",
+		Secret: "0123456789abcdef",
+	}
+	now := time.Now()
+	manifest, err := renderBootstrapToken(bconf, now)
+	if err != nil {
+		t.Fatalf("render bootstrap token with nil ttl failed: %v", err)
+	}
+	expect := fmt.Sprintf("expiration: %s", now.Add(24*time.Hour).Format(time.RFC3339))
+	if !strings.Contains(manifest, expect) {
+		t.Fatalf("default ttl is not applied, expect: %s", expect)
+	}
+
+	for _, ttl := range []time.Duration{0, -time.Hour, 366 * 24 * time.Hour} {
+		invalid := ttl
+		bconf.TTL = &invalid
+		if _, err = renderBootstrapToken(bconf, now); err == nil {
+			t.Fatalf("render bootstrap token with invalid ttl %v should failed", ttl)
+		}
+	}
+
+	bconf.TTL = nil
+	bconf.Secret = "invalid"
+	if _, err = renderBootstrapToken(bconf, now); err == nil {
+		t.Fatalf("render bootstrap token with invalid secret should failed")
+	}
+	if _, err = renderBootstrapToken(nil, now); err == nil {
+		t.Fatalf("render nil bootstrap token should failed")
+	}
+}
+
+func TestBootstrapTokenApplyShell(t *testing.T) {
+	shell := bootstrapTokenApplyShell("kind: Secret", "/etc/kubernetes/admin.conf", "/etc/kubernetes/manifests")
+	expects := []string{
+		"tmpdir=\\$(mktemp -d /etc/kubernetes/manifests/.bootstrap_token.XXXXXX)",
+		"> \\$tmpdir/bootstrap_token.yaml",
+		"kubectl apply -f \\$tmpdir/bootstrap_token.yaml",
+		"; ret=\\$?; rm -rf \\$tmpdir; exit \\$ret",
+	}
+	last := -1
+	for _, expect := range expects {
+		idx := strings.Index(shell, expect)
+		if idx <= last {
+			t.Fatalf("expect %q after previous step in shell: %s", expect, shell)
+		}
+		last = idx
+	}
+	if strings.Contains(shell, "/etc/kubernetes/manifests/bootstrap_token.yaml") {
+		t.Fatalf("token yaml should not be written to fixed path: %s", shell)
+	}
+}
+
+func TestParseBootstrapTokens(t *testing.T) {
+	enc := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	output := fmt.Sprintf(`{"token-id":"%s","token-secret":"%s","expiration":"%s","usage-bootstrap-signing":"%s","usage-bootstrap-authentication":"%s","auth-extra-groups":"%s"}{"token-id":"%s","token-secret":"%s","description":"%s","usage-bootstrap-signing":"%s"}`,
+		enc("zzzzzz"), enc("0123456789abcdef"), enc("2021-11-06T00:00:00Z"), enc("true"), enc("true"), enc("system:bootstrappers:worker,system:bootstrappers:ingress"),
+		enc("abcdef"), enc("0123456789abcdef"), enc("bootstrap token for eggo"), enc("false"))
+	tokens, err := parseBootstrapTokens(output)
+	if err != nil {
+		t.Fatalf("parse bootstrap tokens failed: %v", err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("expect 2 tokens, get: %v", tokens)
+	}
+	// tokens are sorted by id, usage set to false is ignored
+	if tokens[0].ID != "abcdef" || tokens[0].Description != "bootstrap token for eggo" || len(tokens[0].Usages) != 0 {
+		t.Fatalf("invalid token: %+v", tokens[0])
+	}
+	if tokens[1].ID != "zzzzzz" || tokens[1].Expiration != "2021-11-06T00:00:00Z" ||
+		strings.Join(tokens[1].Usages, ",") != "authentication,signing" || len(tokens[1].AuthExtraGroups) != 2 {
+		t.Fatalf("invalid token: %+v", tokens[1])
+	}
+
+	if tokens, err = parseBootstrapTokens(""); err != nil || len(tokens) != 0 {
+		t.Fatalf("expect no token, get: %v, %v", tokens, err)
+	}
+	if _, err = parseBootstrapTokens(`{"token-id":"abc`); err == nil {
+		t.Fatalf("expect invalid output failed")
+	}
+}
+
+func TestKubeletBootstrapCommand(t *testing.T) {
+	cmd := KubeletBootstrapCommand("k8s-cluster", "https://192.168.0.1:6443", "abcdef.0123456789abcdef")
+	expects := []string{
+		"kubectl config set-cluster k8s-cluster --certificate-authority=/etc/kubernetes/pki/ca.crt --embed-certs=true --server=https://192.168.0.1:6443",
+		"kubectl config set-credentials kubelet-bootstrap --token=abcdef.0123456789abcdef",
+		"kubectl config set-context default --cluster=k8s-cluster --user=kubelet-bootstrap",
+		"kubectl config use-context default --kubeconfig=kubelet-bootstrap.kubeconfig",
+	}
+	last := -1
+	for _, e := range expects {
+		idx := strings.Index(cmd, e)
+		if idx <= last {
+			t.Fatalf("expect %q in order in command: %s", e, cmd)
+		}
+		last = idx
+	}
+}
+
+func TestRenderBootstrapTokenUsagesAndGroups(t *testing.T) {
+	bconf := &api.BootstrapTokenConfig{
+		ID:              "abcdef",
+		Secret:          "0123456789abcdef",
+		Usages:          []string{"signing"},
+		AuthExtraGroups: []string{"system:bootstrappers:eggo"},
+	}
+	manifest, err := renderBootstrapToken(bconf, time.Now())
+	if err != nil {
+		t.Fatalf("render bootstrap token failed: %v", err)
+	}
+	var secret struct {
+		StringData map[string]string `yaml:"stringData"`
+	}
+	if err = yaml.Unmarshal([]byte(manifest), &secret); err != nil {
+		t.Fatalf("bootstrap token manifest is invalid yaml: %v\n%s", err, manifest)
+	}
+	// only requested usages are set
+	var usages []string
+	for k, v := range secret.StringData {
+		if strings.HasPrefix(k, "usage-bootstrap-") && v == "true" {
+			usages = append(usages, strings.TrimPrefix(k, "usage-bootstrap-"))
+		}
+	}
+	if strings.Join(usages, ",") != "signing" {
+		t.Fatalf("expect only signing usage, get: %v", usages)
+	}
+	if secret.StringData["auth-extra-groups"] != "system:bootstrappers:eggo" {
+		t.Fatalf("expect requested groups, get: %s", secret.StringData["auth-extra-groups"])
+	}
+
+	bconf.Usages = []string{"signing", "unknown"}
+	if _, err = renderBootstrapToken(bconf, time.Now()); err == nil {
+		t.Fatalf("expect unknown usage failed")
+	}
+	bconf.Usages, bconf.AuthExtraGroups = []string{"signing"}, []string{"system:nodes"}
+	if _, err = renderBootstrapToken(bconf, time.Now()); err == nil {
+		t.Fatalf("expect invalid group failed")
+	}
+}
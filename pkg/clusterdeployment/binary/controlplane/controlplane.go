@@ -16,6 +16,7 @@
 package controlplane
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/x509"
 	"encoding/base64"
@@ -130,14 +131,20 @@ func (ct *ControlPlaneTask) Name() string {
 
 func (ct *ControlPlaneTask) copyEncryConfig(r runner.Runner) error {
 	src := filepath.Join(api.GetClusterHomePath(ct.ccfg.Name), constants.EncryptionConfigName)
-	dst := filepath.Join(ct.ccfg.GetConfigDir(), constants.EncryptionConfigName)
+	dst := ct.ccfg.GetEncryptionConfigPath()
 
 	err := r.Copy(src, dst)
 	if err != nil {
 		logrus.Errorf("copy encry config failed: %v", err)
+		return err
 	}
 
-	return err
+	// encryption config contains key, only readable by root
+	if _, err = r.RunCommand(utils.AddSudo(fmt.Sprintf("chmod 600 %s", dst))); err != nil {
+		logrus.Errorf("chmod encry config failed: %v", err)
+		return err
+	}
+	return nil
 }
 
 func (ct *ControlPlaneTask) Run(r runner.Runner, hcf *api.HostConfig) error {
@@ -201,6 +208,9 @@ func generateApiServerCertificate(savePath string, cg certs.CertGenerator, ccfg
 	if ccfg.LoadBalancer.IP != "" {
 		ips = append(ips, ccfg.LoadBalancer.IP)
 	}
+	if ccfg.LoadBalancer.VIP != "" {
+		ips = append(ips, ccfg.LoadBalancer.VIP)
+	}
 
 	ips = append(ips, ccfg.APIEndpoint.AdvertiseAddress)
 	ips = append(ips, hcf.Address)
@@ -295,17 +305,14 @@ func prepareCAs(lcg certs.CertGenerator, savePath string, ccfg *api.ClusterConfi
 		return err
 	}
 
-	if ccfg.Certificate.ExternalCA {
-		getStrCmd := func(name string) string {
-			return fmt.Sprintf("cp -f %s/%s %s/%s %s", ccfg.Certificate.ExternalCAPath, certs.GetKeyName(name),
-				ccfg.Certificate.ExternalCAPath, certs.GetCertName(name), savePath)
-		}
-
-		if _, err := lcg.RunCommand(getStrCmd(RootCAName)); err != nil {
+	// use external ca if provided, CreateCA will skip exist ca
+	if dir := ccfg.Certificate.ExternalKubernetesCADir(); dir != "" {
+		if err := certs.InstallExternalCA(dir, RootCAName, savePath); err != nil {
 			return err
 		}
-
-		if _, err := lcg.RunCommand(getStrCmd(FrontProxyCAName)); err != nil {
+	}
+	if dir := ccfg.Certificate.ExternalFrontProxyCADir(); dir != "" {
+		if err := certs.InstallExternalCA(dir, FrontProxyCAName, savePath); err != nil {
 			return err
 		}
 	}
@@ -365,6 +372,31 @@ func prepareCredentials(clusterName string, ccfg *api.ClusterConfig) error {
 	return createAdminKubeConfigForEggo(lcg, caPath, api.GetClusterHomePath(clusterName), ccfg)
 }
 
+func writeAdminKubeConfigForUser(ccfg *api.ClusterConfig) error {
+	if ccfg.KubeConfigOut == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(api.GetClusterHomePath(ccfg.Name), constants.KubeConfigFileNameAdmin))
+	if err != nil {
+		logrus.Errorf("read admin kubeconfig of eggo failed: %v", err)
+		return err
+	}
+
+	dst := filepath.Clean(ccfg.KubeConfigOut)
+	if err = os.MkdirAll(filepath.Dir(dst), constants.EggoDirMode); err != nil {
+		logrus.Errorf("create dir for admin kubeconfig failed: %v", err)
+		return err
+	}
+	if err = ioutil.WriteFile(dst, data, constants.KubeConfigFileMode); err != nil {
+		logrus.Errorf("write admin kubeconfig to %s failed: %v", dst, err)
+		return err
+	}
+
+	logrus.Infof("[certs] write admin kubeconfig to %s success", dst)
+	return nil
+}
+
 func generateKubeConfigs(rootPath, certPath string, cg certs.CertGenerator, ccfg *api.ClusterConfig) (err error) {
 	// create temp certificates and keys for kubeconfigs
 	if err = generateAdminCertificate(certPath, cg); err != nil {
@@ -410,33 +442,55 @@ func getRandSecret() (string, error) {
 	return encoded, nil
 }
 
-func generateEncryption(savePath string) error {
-	const encry = `kind: EncryptionConfig
-apiVersion: v1
+// renderEncryptionConfig renders EncryptionConfiguration of apiserver, resources are encrypted by
+// provider with secret, identity provider is kept to read resources written before encryption
+func renderEncryptionConfig(ccfg *api.ClusterConfig, secret string) (string, error) {
+	const encry = `kind: EncryptionConfiguration
+apiVersion: apiserver.config.k8s.io/v1
 resources:
   - resources:
-      - secrets
+{{- range .Resources }}
+      - {{ . }}
+{{- end }}
     providers:
-      - aescbc:
+      - {{ .Provider }}:
           keys:
             - name: key1
               secret: {{ .Secret }}
       - identity: {}
 `
 	datastore := make(map[string]interface{})
+	datastore["Resources"] = ccfg.GetEncryptionResources()
+	datastore["Provider"] = ccfg.GetEncryptionProvider()
+	datastore["Secret"] = secret
+	return template.TemplateRender(encry, datastore)
+}
+
+func generateEncryption(savePath string, ccfg *api.ClusterConfig) error {
+	fname := filepath.Join(savePath, constants.EncryptionConfigName)
+	// key of existing cluster must not be changed, otherwise encrypted resources can not be read
+	if exist, err := utils.CheckPathExist(fname); err != nil {
+		return err
+	} else if exist {
+		logrus.Infof("reuse encryption config: %s", fname)
+		return nil
+	}
+
 	randSecret, err := getRandSecret()
 	if err != nil {
 		return err
 	}
-	datastore["Secret"] = randSecret
-	encryStr, err := template.TemplateRender(encry, datastore)
+	encryStr, err := renderEncryptionConfig(ccfg, randSecret)
 	if err != nil {
 		logrus.Errorf("render encry yaml failed: %v", err)
 		return err
 	}
 
-	fname := filepath.Join(savePath, constants.EncryptionConfigName)
-	return ioutil.WriteFile(fname, []byte(encryStr), constants.EncryptionConfigFileMode)
+	if err = ioutil.WriteFile(fname, []byte(encryStr), constants.EncryptionConfigFileMode); err != nil {
+		return err
+	}
+	logrus.Warnf("key to encrypt %v in etcd is saved in %s, backup it safely, and rotate it periodically", ccfg.GetEncryptionResources(), fname)
+	return nil
 }
 
 func generateCertsAndKubeConfigs(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.HostConfig) (err error) {
@@ -474,7 +528,7 @@ func runKubernetesServices(r runner.Runner, ccfg *api.ClusterConfig, hcf *api.Ho
 	return nil
 }
 
-func JoinMaterNode(conf *api.ClusterConfig, masterID string) error {
+func JoinMaterNode(ctx context.Context, conf *api.ClusterConfig, masterID string) error {
 	joinMasterTasks := []task.Task{
 		task.NewTaskInstance(
 			&commontools.CopyCaCertificatesTask{
@@ -488,7 +542,7 @@ func JoinMaterNode(conf *api.ClusterConfig, masterID string) error {
 		),
 	}
 
-	err := nodemanager.RunTasksOnNode(joinMasterTasks, masterID)
+	err := nodemanager.RunTasksOnNode(ctx, joinMasterTasks, masterID)
 	if err != nil {
 		return err
 	}
@@ -496,9 +550,9 @@ func JoinMaterNode(conf *api.ClusterConfig, masterID string) error {
 	return nil
 }
 
-func Init(conf *api.ClusterConfig, master string) error {
+func Init(ctx context.Context, conf *api.ClusterConfig, master string) error {
 	// create encryption for cluster
-	err := generateEncryption(api.GetClusterHomePath(conf.Name))
+	err := generateEncryption(api.GetClusterHomePath(conf.Name), conf)
 	if err != nil {
 		return err
 	}
@@ -510,7 +564,11 @@ func Init(conf *api.ClusterConfig, master string) error {
 		return err
 	}
 
-	if err = JoinMaterNode(conf, master); err != nil {
+	if err = writeAdminKubeConfigForUser(conf); err != nil {
+		return err
+	}
+
+	if err = JoinMaterNode(ctx, conf, master); err != nil {
 		return err
 	}
 
@@ -519,7 +577,7 @@ func Init(conf *api.ClusterConfig, master string) error {
 			cluster: conf,
 		},
 	)
-	err = nodemanager.RunTaskOnNodes(post, []string{master})
+	err = nodemanager.RunTaskOnNodes(ctx, post, []string{master})
 	if err != nil {
 		return err
 	}
@@ -15,14 +15,24 @@
 package controlplane
 
 import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/clientcmd"
 
 	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/constants"
 	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/certs"
 	"isula.org/eggo/pkg/utils/nodemanager"
 	"isula.org/eggo/pkg/utils/runner"
 )
@@ -35,6 +45,11 @@ func (m *MockRunner) Copy(src, dst string) error {
 	return nil
 }
 
+func (m *MockRunner) CopyDir(srcDir, dstDir string) error {
+	logrus.Infof("copy dir %s to %s", srcDir, dstDir)
+	return nil
+}
+
 func (m *MockRunner) RunCommand(cmd string) (string, error) {
 	logrus.Infof("run command: %s", cmd)
 	return "", nil
@@ -54,7 +69,7 @@ func (m *MockRunner) Close() {
 	logrus.Infof("close")
 }
 
-func TestInit(t *testing.T) {
+func TestInit(ctx context.Context, t *testing.T) {
 	lr := &runner.LocalRunner{}
 	conf := &api.ClusterConfig{
 		Name: "test-cluster",
@@ -116,7 +131,7 @@ func TestInit(t *testing.T) {
 	if _, err := lr.RunCommand(sb.String()); err != nil {
 		t.Fatalf("run command failed: %v", err)
 	}
-	if err := Init(conf, master); err != nil {
+	if err := Init(ctx, conf, master); err != nil {
 		t.Fatalf("do control plane init failed: %v", err)
 	}
 
@@ -125,3 +140,191 @@ func TestInit(t *testing.T) {
 	}
 	t.Logf("do control plane init success")
 }
+
+func TestWriteAdminKubeConfigForUser(t *testing.T) {
+	api.EggoHomePath = "/tmp/eggo-kubeconfig"
+	conf := &api.ClusterConfig{
+		Name: "test-cluster",
+		APIEndpoint: api.APIEndpoint{
+			AdvertiseAddress: "192.168.1.100",
+			BindPort:         8443,
+		},
+		KubeConfigOut: "/tmp/eggo-kubeconfig-out/test-cluster/" + constants.KubeConfigFileNameUser,
+	}
+	defer func() {
+		os.RemoveAll(api.EggoHomePath)
+		os.RemoveAll(filepath.Dir(filepath.Dir(conf.KubeConfigOut)))
+	}()
+
+	if err := os.MkdirAll(api.GetClusterHomePath(conf.Name), constants.EggoHomeDirMode); err != nil {
+		t.Fatalf("create cluster home failed: %v", err)
+	}
+	if err := prepareCredentials(conf.Name, conf); err != nil {
+		t.Fatalf("prepare credentials failed: %v", err)
+	}
+	if err := writeAdminKubeConfigForUser(conf); err != nil {
+		t.Fatalf("write admin kubeconfig failed: %v", err)
+	}
+
+	kubeconfig, err := clientcmd.LoadFromFile(conf.KubeConfigOut)
+	if err != nil {
+		t.Fatalf("load admin kubeconfig failed: %v", err)
+	}
+	cluster, ok := kubeconfig.Clusters[conf.Name]
+	if !ok {
+		t.Fatalf("cannot found cluster: %s in admin kubeconfig", conf.Name)
+	}
+	if cluster.Server != "https://192.168.1.100:8443" {
+		t.Fatalf("expect https://192.168.1.100:8443, get %s", cluster.Server)
+	}
+	if len(cluster.CertificateAuthorityData) == 0 {
+		t.Fatalf("expect ca data in admin kubeconfig")
+	}
+	t.Logf("write admin kubeconfig for user success")
+}
+
+func TestPrepareExternalCAs(t *testing.T) {
+	extDir := t.TempDir()
+	lcg := certs.NewLocalCertGenerator()
+	for _, name := range []string{RootCAName, FrontProxyCAName} {
+		if err := lcg.CreateCA(&certs.CertConfig{CommonName: "external-" + name, PublicKeyAlgorithm: x509.ECDSA}, extDir, name); err != nil {
+			t.Fatalf("create external %s failed: %v", name, err)
+		}
+	}
+	isSameFile := func(a, b string) bool {
+		da, _ := ioutil.ReadFile(a)
+		db, _ := ioutil.ReadFile(b)
+		return len(da) != 0 && bytes.Equal(da, db)
+	}
+
+	for _, c := range []struct{ rootExternal, proxyExternal bool }{
+		{false, false}, {true, false}, {false, true}, {true, true},
+	} {
+		ccfg := &api.ClusterConfig{Name: "test-cluster"}
+		if c.rootExternal {
+			ccfg.Certificate.ExternalKubernetesCAPath = extDir
+		}
+		if c.proxyExternal {
+			ccfg.Certificate.ExternalFrontProxyCAPath = extDir
+		}
+		savePath := filepath.Join(t.TempDir(), "pki")
+		if err := prepareCAs(lcg, savePath, ccfg); err != nil {
+			t.Fatalf("prepare cas with %+v failed: %v", c, err)
+		}
+		expects := map[string]bool{RootCAName: c.rootExternal, FrontProxyCAName: c.proxyExternal}
+		for name, external := range expects {
+			if err := certs.ValidateCA(filepath.Join(savePath, certs.GetCertName(name)), filepath.Join(savePath, certs.GetKeyName(name))); err != nil {
+				t.Fatalf("invalid %s with %+v: %v", name, c, err)
+			}
+			if isSameFile(filepath.Join(extDir, certs.GetCertName(name)), filepath.Join(savePath, certs.GetCertName(name))) != external {
+				t.Fatalf("%s with %+v, expect external: %v", name, c, external)
+			}
+		}
+	}
+
+	// external ca without key
+	noKeyDir := t.TempDir()
+	data, _ := ioutil.ReadFile(filepath.Join(extDir, "ca.crt"))
+	if err := ioutil.WriteFile(filepath.Join(noKeyDir, "ca.crt"), data, 0644); err != nil {
+		t.Fatalf("write ca failed: %v", err)
+	}
+	ccfg := &api.ClusterConfig{Name: "test-cluster"}
+	ccfg.Certificate.ExternalKubernetesCAPath = noKeyDir
+	if err := prepareCAs(lcg, filepath.Join(t.TempDir(), "pki"), ccfg); err == nil {
+		t.Fatalf("expect external ca without key failed")
+	}
+}
+
+func TestGenerateEncryption(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "eggo-encryption-")
+	if err != nil {
+		t.Fatalf("create tempdir failed: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	conf := &api.ClusterConfig{
+		Name: "test-cluster",
+		Encryption: api.EncryptionConfig{
+			Provider:  api.EncryptionProviderSecretbox,
+			Resources: []string{"secrets", "configmaps"},
+		},
+	}
+	if err = generateEncryption(tempdir, conf); err != nil {
+		t.Fatalf("generate encryption failed: %v", err)
+	}
+	fname := filepath.Join(tempdir, constants.EncryptionConfigName)
+	fi, err := os.Stat(fname)
+	if err != nil || fi.Mode().Perm() != constants.EncryptionConfigFileMode {
+		t.Fatalf("expect encryption config with mode %v, get: %v, %v", constants.EncryptionConfigFileMode, fi, err)
+	}
+	data, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Fatalf("read encryption config failed: %v", err)
+	}
+	content := string(data)
+	for _, e := range []string{"kind: EncryptionConfiguration", "- secrets\n", "- configmaps\n", "- secretbox:", "- identity: {}"} {
+		if !strings.Contains(content, e) {
+			t.Fatalf("expect %q in encryption config:\n%s", e, content)
+		}
+	}
+	var secret []byte
+	for _, line := range strings.Split(content, "\n") {
+		if s := strings.TrimSpace(line); strings.HasPrefix(s, "secret: ") {
+			if secret, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(s, "secret: ")); err != nil {
+				t.Fatalf("invalid secret: %v", err)
+			}
+		}
+	}
+	// secretbox requires 32 bytes key
+	if len(secret) != 32 {
+		t.Fatalf("expect 32 bytes secret, get: %d", len(secret))
+	}
+
+	// key of existing cluster is kept
+	conf.Encryption = api.EncryptionConfig{}
+	if err = generateEncryption(tempdir, conf); err != nil {
+		t.Fatalf("generate encryption again failed: %v", err)
+	}
+	if again, err := ioutil.ReadFile(fname); err != nil || !bytes.Equal(again, data) {
+		t.Fatalf("expect encryption config unchanged, err: %v", err)
+	}
+
+	// default provider and resources
+	def, err := renderEncryptionConfig(&api.ClusterConfig{}, "key")
+	if err != nil {
+		t.Fatalf("render default encryption config failed: %v", err)
+	}
+	if !strings.Contains(def, "- secrets\n") || !strings.Contains(def, "- aescbc:") || strings.Contains(def, "configmaps") {
+		t.Fatalf("unexpect default encryption config:\n%s", def)
+	}
+}
+
+func TestApiServerCertificateSans(t *testing.T) {
+	savePath := t.TempDir()
+	lcg := certs.NewLocalCertGenerator()
+	if err := lcg.CreateCA(&certs.CertConfig{CommonName: "test-ca"}, savePath, RootCAName); err != nil {
+		t.Fatalf("create ca failed: %v", err)
+	}
+	ccfg := &api.ClusterConfig{
+		Name:         "test-cluster",
+		APIEndpoint:  api.APIEndpoint{AdvertiseAddress: "192.168.0.100", BindPort: 8443},
+		LoadBalancer: api.LoadBalancer{IP: "192.168.0.10", Port: "8443", VIP: "192.168.0.100", Interface: "eth0", VRID: 51},
+	}
+	if err := generateApiServerCertificate(savePath, lcg, ccfg, &api.HostConfig{Address: "192.168.0.2"}); err != nil {
+		t.Fatalf("generate apiserver certificate failed: %v", err)
+	}
+
+	cert, err := certs.ReadCertFromFile(filepath.Join(savePath, certs.GetCertName(APIServerCertName)))
+	if err != nil {
+		t.Fatalf("read apiserver certificate failed: %v", err)
+	}
+	sans := make(map[string]bool)
+	for _, ip := range cert.IPAddresses {
+		sans[ip.String()] = true
+	}
+	for _, ip := range []string{"192.168.0.10", "192.168.0.100", "192.168.0.2"} {
+		if !sans[ip] {
+			t.Fatalf("expect %s in sans of apiserver certificate: %v", ip, cert.IPAddresses)
+		}
+	}
+}
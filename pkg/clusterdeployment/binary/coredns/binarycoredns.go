@@ -15,6 +15,7 @@
 package coredns
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"path/filepath"
@@ -41,14 +42,14 @@ const (
 		lameduck 5s
 	}
 	ready
-	kubernetes cluster.local in-addr.arpa ip6.arpa {
+	kubernetes {{ .DNSDomain }} in-addr.arpa ip6.arpa {
 		pods insecure
 		endpoint {{ .Endpoint }}
 		kubeconfig {{ .AdminConf }} default-system
 		fallthrough in-addr.arpa ip6.arpa
 	}
 	prometheus :9153
-	forward . /etc/resolv.conf {
+	forward . {{ .Upstreams }} {
 		max_concurrent 1000
 	}
 	cache 30
@@ -197,17 +198,23 @@ func (ct *BinaryCorednsSetupTask) Name() string {
 	return "CorednsSetupTask"
 }
 
-func (ct *BinaryCorednsSetupTask) createCoreConfigTemplate(r runner.Runner) error {
-	var sb strings.Builder
+func renderCoreConfig(cluster *api.ClusterConfig) (string, error) {
 	datastore := map[string]interface{}{}
-	useEndPoint, err := endpoint.GetAPIServerEndpoint(ct.Cluster)
+	useEndPoint, err := endpoint.GetAPIServerEndpoint(cluster)
 	if err != nil {
 		logrus.Errorf("get api server endpoint failed: %v", err)
-		return err
+		return "", err
 	}
 	datastore["Endpoint"] = useEndPoint
-	datastore["AdminConf"] = fmt.Sprintf("%s/%s", ct.Cluster.GetConfigDir(), constants.KubeConfigFileNameAdmin)
-	coreConfig, err := template.TemplateRender(CoreConfigTemp, datastore)
+	datastore["AdminConf"] = fmt.Sprintf("%s/%s", cluster.GetConfigDir(), constants.KubeConfigFileNameAdmin)
+	datastore["DNSDomain"] = cluster.GetDNSDomain()
+	datastore["Upstreams"] = getUpstreams(cluster)
+	return template.TemplateRender(CoreConfigTemp, datastore)
+}
+
+func (ct *BinaryCorednsSetupTask) createCoreConfigTemplate(r runner.Runner) error {
+	var sb strings.Builder
+	coreConfig, err := renderCoreConfig(ct.Cluster)
 	if err != nil {
 		logrus.Errorf("rend core config failed: %v", err)
 		return err
@@ -264,7 +271,7 @@ func (ct *BinaryCorednsSetupTask) Run(r runner.Runner, hcf *api.HostConfig) erro
 type BinaryCoredns struct {
 }
 
-func (bc *BinaryCoredns) Setup(cluster *api.ClusterConfig) error {
+func (bc *BinaryCoredns) Setup(ctx context.Context, cluster *api.ClusterConfig) error {
 	masterIPs := utils.GetMasterIPList(cluster)
 	if len(masterIPs) == 0 {
 		return fmt.Errorf("no master host found, can not setup coredns service")
@@ -276,7 +283,7 @@ func (bc *BinaryCoredns) Setup(cluster *api.ClusterConfig) error {
 		},
 	)
 
-	err := nodemanager.RunTaskOnNodes(st, masterIPs)
+	err := nodemanager.RunTaskOnNodes(ctx, st, masterIPs)
 	if err != nil {
 		return err
 	}
@@ -288,12 +295,12 @@ func (bc *BinaryCoredns) Setup(cluster *api.ClusterConfig) error {
 		},
 	)
 
-	err = nodemanager.RunTaskOnNodes(sst, masterIPs[0:1])
+	err = nodemanager.RunTaskOnNodes(ctx, sst, masterIPs[0:1])
 	if err != nil {
 		return err
 	}
 
-	if err = nodemanager.WaitNodesFinish(masterIPs, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
+	if err = nodemanager.WaitNodesFinish(ctx, masterIPs, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		logrus.Errorf("coredns setup failed: %v", err)
 		return err
 	}
@@ -357,7 +364,7 @@ exit 0
 	return nil
 }
 
-func (bc *BinaryCoredns) Cleanup(cluster *api.ClusterConfig) error {
+func (bc *BinaryCoredns) Cleanup(ctx context.Context, cluster *api.ClusterConfig) error {
 	masterIPs := utils.GetMasterIPList(cluster)
 	if len(masterIPs) == 0 {
 		logrus.Warn("no master host found, can not cleanup coredns service")
@@ -370,13 +377,13 @@ func (bc *BinaryCoredns) Cleanup(cluster *api.ClusterConfig) error {
 		},
 	)
 
-	err := nodemanager.RunTaskOnNodes(sst, masterIPs)
+	err := nodemanager.RunTaskOnNodes(ctx, sst, masterIPs)
 	if err != nil {
 		logrus.Warnf("run cleanup coredns task failed: %v", err)
 		return nil
 	}
 
-	if err = nodemanager.WaitNodesFinish(masterIPs, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
+	if err = nodemanager.WaitNodesFinish(ctx, masterIPs, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		logrus.Warnf("wait to coredns cleanup failed: %v", err)
 		return nil
 	}
@@ -397,7 +404,7 @@ func (cs *BinaryCorednsServerJoinTask) Run(r runner.Runner, hcf *api.HostConfig)
 	return createCoreEndpointTemplate(cs.Cluster, r, cs.NodeIPs)
 }
 
-func (bc *BinaryCoredns) JoinNode(nodeAddr string, cluster *api.ClusterConfig) error {
+func (bc *BinaryCoredns) JoinNode(ctx context.Context, nodeAddr string, cluster *api.ClusterConfig) error {
 	// TODO: should get coredns ip list from status of cluster
 	masterIPs := utils.GetMasterIPList(cluster)
 	if len(masterIPs) == 0 {
@@ -410,12 +417,12 @@ func (bc *BinaryCoredns) JoinNode(nodeAddr string, cluster *api.ClusterConfig) e
 		},
 	)
 
-	err := nodemanager.RunTaskOnNodes(st, []string{nodeAddr})
+	err := nodemanager.RunTaskOnNodes(ctx, st, []string{nodeAddr})
 	if err != nil {
 		return err
 	}
 
-	if err = nodemanager.WaitNodesFinish([]string{nodeAddr}, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
+	if err = nodemanager.WaitNodesFinish(ctx, []string{nodeAddr}, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		logrus.Errorf("wait to coredns service running failed: %v", err)
 		return err
 	}
@@ -427,12 +434,12 @@ func (bc *BinaryCoredns) JoinNode(nodeAddr string, cluster *api.ClusterConfig) e
 		},
 	)
 
-	useMaster, err := nodemanager.RunTaskOnOneNode(sst, masterIPs)
+	useMaster, err := nodemanager.RunTaskOnOneNode(ctx, sst, masterIPs)
 	if err != nil {
 		return err
 	}
 
-	if err = nodemanager.WaitNodesFinish([]string{useMaster}, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
+	if err = nodemanager.WaitNodesFinish(ctx, []string{useMaster}, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		logrus.Errorf("wait to join new coredns node failed: %v", err)
 		return err
 	}
@@ -440,7 +447,7 @@ func (bc *BinaryCoredns) JoinNode(nodeAddr string, cluster *api.ClusterConfig) e
 	return nil
 }
 
-func (bc *BinaryCoredns) CleanNode(nodeAddr string, cluster *api.ClusterConfig) error {
+func (bc *BinaryCoredns) CleanNode(ctx context.Context, nodeAddr string, cluster *api.ClusterConfig) error {
 	sst := task.NewTaskInstance(
 		&BinaryCorednsCleanupTask{
 			Cluster:   cluster,
@@ -448,13 +455,13 @@ func (bc *BinaryCoredns) CleanNode(nodeAddr string, cluster *api.ClusterConfig)
 		},
 	)
 
-	err := nodemanager.RunTaskOnNodes(sst, []string{nodeAddr})
+	err := nodemanager.RunTaskOnNodes(ctx, sst, []string{nodeAddr})
 	if err != nil {
 		logrus.Warnf("run cleanup coredns task failed: %v", err)
 		return nil
 	}
 
-	if err = nodemanager.WaitNodesFinish([]string{nodeAddr}, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
+	if err = nodemanager.WaitNodesFinish(ctx, []string{nodeAddr}, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		logrus.Warnf("wait to coredns cleanup failed: %v", err)
 		return nil
 	}
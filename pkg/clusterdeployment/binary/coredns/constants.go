@@ -75,11 +75,11 @@ data:
           lameduck 5s
         }
         ready
-        kubernetes cluster.local in-addr.arpa ip6.arpa {
+        kubernetes {{ .DNSDomain }} in-addr.arpa ip6.arpa {
           fallthrough in-addr.arpa ip6.arpa
         }
         prometheus :9153
-        forward . /etc/resolv.conf {
+        forward . {{ .Upstreams }} {
           max_concurrent 1000
         }
         cache 30
@@ -132,14 +132,17 @@ spec:
                topologyKey: kubernetes.io/hostname
       containers:
       - name: coredns
-        image: coredns/coredns:{{ .ImageVersion }}
+        image: {{ .Image }}
         imagePullPolicy: IfNotPresent
         resources:
           limits:
-            memory: 170Mi
+{{- if .CPULimit }}
+            cpu: {{ .CPULimit }}
+{{- end }}
+            memory: {{ .MemoryLimit }}
           requests:
-            cpu: 100m
-            memory: 70Mi
+            cpu: {{ .CPURequest }}
+            memory: {{ .MemoryRequest }}
         args: [ "-conf", "/etc/coredns/Corefile" ]
         volumeMounts:
         - name: config-volume
@@ -15,7 +15,9 @@
 package coredns
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"isula.org/eggo/pkg/api"
 )
@@ -23,6 +25,8 @@ import (
 const (
 	CorednsTypeOfPod    = "pod"
 	CorednsTypeOfBinary = "binary"
+
+	defaultUpstream = "/etc/resolv.conf"
 )
 
 var cbs map[string]CorednsOps
@@ -49,25 +53,33 @@ func getTypeOfCoredns(configType string) string {
 	return CorednsTypeOfBinary
 }
 
-func CorednsSetup(cluster *api.ClusterConfig) error {
+// getUpstreams returns servers which coredns forwards to, default is resolv.conf of node
+func getUpstreams(cluster *api.ClusterConfig) string {
+	if len(cluster.ServiceCluster.DNS.UpstreamServers) == 0 {
+		return defaultUpstream
+	}
+	return strings.Join(cluster.ServiceCluster.DNS.UpstreamServers, " ")
+}
+
+func CorednsSetup(ctx context.Context, cluster *api.ClusterConfig) error {
 	useType := getTypeOfCoredns(cluster.ServiceCluster.DNS.CorednsType)
 	if cb, ok := cbs[useType]; ok {
-		return cb.Setup(cluster)
+		return cb.Setup(ctx, cluster)
 	}
 	return fmt.Errorf("unsupport coredns type %s", useType)
 }
 
-func CorednsCleanup(cluster *api.ClusterConfig) error {
+func CorednsCleanup(ctx context.Context, cluster *api.ClusterConfig) error {
 	useType := getTypeOfCoredns(cluster.ServiceCluster.DNS.CorednsType)
 	if cb, ok := cbs[useType]; ok {
-		return cb.Cleanup(cluster)
+		return cb.Cleanup(ctx, cluster)
 	}
 	return fmt.Errorf("unsupport coredns type %s", useType)
 }
 
 type CorednsOps interface {
-	Setup(cluster *api.ClusterConfig) error
-	Cleanup(cluster *api.ClusterConfig) error
-	JoinNode(node string, cluster *api.ClusterConfig) error
-	CleanNode(node string, cluster *api.ClusterConfig) error
+	Setup(ctx context.Context, cluster *api.ClusterConfig) error
+	Cleanup(ctx context.Context, cluster *api.ClusterConfig) error
+	JoinNode(ctx context.Context, node string, cluster *api.ClusterConfig) error
+	CleanNode(ctx context.Context, node string, cluster *api.ClusterConfig) error
 }
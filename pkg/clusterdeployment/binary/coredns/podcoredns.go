@@ -15,6 +15,7 @@
 package coredns
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"path/filepath"
@@ -33,10 +34,46 @@ import (
 )
 
 const (
-	defaultCorednsImageVersion = "1.8.4"
-	defaultCorednsReplicas     = 2
+	defaultCorednsImageVersion  = "1.8.4"
+	defaultCorednsReplicas      = 2
+	defaultCorednsCPURequest    = "100m"
+	defaultCorednsMemoryRequest = "70Mi"
+	defaultCorednsMemoryLimit   = "170Mi"
 )
 
+func getCorednsImage(ccfg *api.ClusterConfig) string {
+	version := defaultCorednsImageVersion
+	if ccfg.ServiceCluster.DNS.ImageVersion != "" {
+		version = ccfg.ServiceCluster.DNS.ImageVersion
+	}
+	return ccfg.GetImage(fmt.Sprintf("coredns/coredns:%s", version))
+}
+
+func strOrDefault(s, def string) string {
+	if s != "" {
+		return s
+	}
+	return def
+}
+
+func renderPodCorednsYaml(ccfg *api.ClusterConfig) (string, error) {
+	dns := ccfg.ServiceCluster.DNS
+	datastore := make(map[string]interface{})
+	datastore["Replicas"] = defaultCorednsReplicas
+	if dns.Replicas > 0 {
+		datastore["Replicas"] = dns.Replicas
+	}
+	datastore["Image"] = getCorednsImage(ccfg)
+	datastore["ClusterIP"] = ccfg.ServiceCluster.DNSAddr
+	datastore["DNSDomain"] = ccfg.GetDNSDomain()
+	datastore["Upstreams"] = getUpstreams(ccfg)
+	datastore["CPURequest"] = strOrDefault(dns.CPURequest, defaultCorednsCPURequest)
+	datastore["CPULimit"] = dns.CPULimit
+	datastore["MemoryRequest"] = strOrDefault(dns.MemoryRequest, defaultCorednsMemoryRequest)
+	datastore["MemoryLimit"] = strOrDefault(dns.MemoryLimit, defaultCorednsMemoryLimit)
+	return template.TemplateRender(podCorednsTmpl, datastore)
+}
+
 type PodCorednsSetupTask struct {
 	Cluster *api.ClusterConfig
 }
@@ -46,17 +83,7 @@ func (ct *PodCorednsSetupTask) Name() string {
 }
 
 func (ct *PodCorednsSetupTask) Run(r runner.Runner, hcf *api.HostConfig) error {
-	datastore := make(map[string]interface{})
-	datastore["Replicas"] = defaultCorednsReplicas
-	datastore["ImageVersion"] = defaultCorednsImageVersion
-	if ct.Cluster.ServiceCluster.DNS.ImageVersion != "" {
-		datastore["ImageVersion"] = ct.Cluster.ServiceCluster.DNS.ImageVersion
-	}
-	if ct.Cluster.ServiceCluster.DNS.Replicas > 0 {
-		datastore["Replicas"] = ct.Cluster.ServiceCluster.DNS.Replicas
-	}
-	datastore["ClusterIP"] = ct.Cluster.ServiceCluster.DNSAddr
-	corednsYaml, err := template.TemplateRender(podCorednsTmpl, datastore)
+	corednsYaml, err := renderPodCorednsYaml(ct.Cluster)
 	if err != nil {
 		return err
 	}
@@ -91,17 +118,7 @@ func (ct *PodCorednsCleanupTask) Name() string {
 }
 
 func (ct *PodCorednsCleanupTask) Run(r runner.Runner, hcf *api.HostConfig) error {
-	datastore := make(map[string]interface{})
-	datastore["Replicas"] = defaultCorednsReplicas
-	datastore["ImageVersion"] = defaultCorednsImageVersion
-	if ct.Cluster.ServiceCluster.DNS.ImageVersion != "" {
-		datastore["ImageVersion"] = ct.Cluster.ServiceCluster.DNS.ImageVersion
-	}
-	if ct.Cluster.ServiceCluster.DNS.Replicas > 0 {
-		datastore["Replicas"] = ct.Cluster.ServiceCluster.DNS.Replicas
-	}
-	datastore["ClusterIP"] = ct.Cluster.ServiceCluster.DNSAddr
-	corednsYaml, err := template.TemplateRender(podCorednsTmpl, datastore)
+	corednsYaml, err := renderPodCorednsYaml(ct.Cluster)
 	if err != nil {
 		return err
 	}
@@ -123,7 +140,7 @@ func (ct *PodCorednsCleanupTask) Run(r runner.Runner, hcf *api.HostConfig) error
 type PodCoredns struct {
 }
 
-func (pc *PodCoredns) Setup(cluster *api.ClusterConfig) error {
+func (pc *PodCoredns) Setup(ctx context.Context, cluster *api.ClusterConfig) error {
 	if cluster == nil {
 		return fmt.Errorf("invalid cluster config")
 	}
@@ -135,11 +152,11 @@ func (pc *PodCoredns) Setup(cluster *api.ClusterConfig) error {
 		}
 	}
 
-	useMaster, err := nodemanager.RunTaskOnOneNode(t, masters)
+	useMaster, err := nodemanager.RunTaskOnOneNode(ctx, t, masters)
 	if err != nil {
 		return err
 	}
-	err = nodemanager.WaitNodesFinish([]string{useMaster}, time.Minute*constants.DefaultTaskWaitMinutes)
+	err = nodemanager.WaitNodesFinish(ctx, []string{useMaster}, time.Minute*constants.DefaultTaskWaitMinutes)
 	if err != nil {
 		return err
 	}
@@ -148,7 +165,7 @@ func (pc *PodCoredns) Setup(cluster *api.ClusterConfig) error {
 	return nil
 }
 
-func (pc *PodCoredns) Cleanup(cluster *api.ClusterConfig) error {
+func (pc *PodCoredns) Cleanup(ctx context.Context, cluster *api.ClusterConfig) error {
 	if cluster == nil {
 		return fmt.Errorf("invalid cluster config")
 	}
@@ -160,11 +177,11 @@ func (pc *PodCoredns) Cleanup(cluster *api.ClusterConfig) error {
 		}
 	}
 
-	useMaster, err := nodemanager.RunTaskOnOneNode(t, masters)
+	useMaster, err := nodemanager.RunTaskOnOneNode(ctx, t, masters)
 	if err != nil {
 		return err
 	}
-	err = nodemanager.WaitNodesFinish([]string{useMaster}, time.Minute*constants.DefaultTaskWaitMinutes)
+	err = nodemanager.WaitNodesFinish(ctx, []string{useMaster}, time.Minute*constants.DefaultTaskWaitMinutes)
 	if err != nil {
 		return err
 	}
@@ -173,12 +190,12 @@ func (pc *PodCoredns) Cleanup(cluster *api.ClusterConfig) error {
 	return nil
 }
 
-func (bc *PodCoredns) JoinNode(node string, cluster *api.ClusterConfig) error {
+func (bc *PodCoredns) JoinNode(ctx context.Context, node string, cluster *api.ClusterConfig) error {
 	// nothing need to do
 	return nil
 }
 
-func (bc *PodCoredns) CleanNode(node string, cluster *api.ClusterConfig) error {
+func (bc *PodCoredns) CleanNode(ctx context.Context, node string, cluster *api.ClusterConfig) error {
 	// nothing need to do
 	return nil
 }
@@ -0,0 +1,103 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for pod coredns
+ ******************************************************************************/
+
+package coredns
+
+import (
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func TestRenderPodCorednsYaml(t *testing.T) {
+	ccfg := &api.ClusterConfig{}
+	ccfg.ServiceCluster.DNSAddr = "10.32.0.10"
+	yaml, err := renderPodCorednsYaml(ccfg)
+	if err != nil {
+		t.Fatalf("render coredns yaml failed: %v", err)
+	}
+	for _, expect := range []string{"replicas: 2", "kubernetes cluster.local in-addr.arpa", "clusterIP: 10.32.0.10",
+		"cpu: 100m", "memory: 70Mi", "memory: 170Mi"} {
+		if !strings.Contains(yaml, expect) {
+			t.Fatalf("expect %q in default coredns yaml:\n%s", expect, yaml)
+		}
+	}
+	if strings.Count(yaml, "cpu:") != 1 {
+		t.Fatalf("cpu limit should not be set by default:\n%s", yaml)
+	}
+
+	ccfg.WorkerConfig.KubeletConf = &api.Kubelet{DNSDomain: "kubelet.local"}
+	ccfg.ServiceCluster.DNS = api.DnsConfig{
+		Replicas:      3,
+		Domain:        "eggo.local",
+		CPURequest:    "200m",
+		CPULimit:      "1",
+		MemoryRequest: "128Mi",
+		MemoryLimit:   "512Mi",
+	}
+	if yaml, err = renderPodCorednsYaml(ccfg); err != nil {
+		t.Fatalf("render coredns yaml failed: %v", err)
+	}
+	expects := []string{"replicas: 3", "kubernetes eggo.local in-addr.arpa",
+		"limits:\n            cpu: 1\n            memory: 512Mi",
+		"requests:\n            cpu: 200m\n            memory: 128Mi"}
+	for _, expect := range expects {
+		if !strings.Contains(yaml, expect) {
+			t.Fatalf("expect %q in coredns yaml:\n%s", expect, yaml)
+		}
+	}
+
+	// domain of kubelet is used if dns domain is not set
+	ccfg.ServiceCluster.DNS.Domain = ""
+	if yaml, err = renderPodCorednsYaml(ccfg); err != nil {
+		t.Fatalf("render coredns yaml failed: %v", err)
+	}
+	if !strings.Contains(yaml, "kubernetes kubelet.local in-addr.arpa") {
+		t.Fatalf("expect domain of kubelet in coredns yaml:\n%s", yaml)
+	}
+}
+
+func TestCorefileUpstreams(t *testing.T) {
+	ccfg := &api.ClusterConfig{}
+	ccfg.ServiceCluster.DNSAddr = "10.32.0.10"
+	ccfg.APIEndpoint.AdvertiseAddress = "192.168.0.1"
+	ccfg.APIEndpoint.BindPort = 6443
+
+	// resolv.conf of node is used by default
+	yaml, err := renderPodCorednsYaml(ccfg)
+	if err != nil {
+		t.Fatalf("render coredns yaml failed: %v", err)
+	}
+	if !strings.Contains(yaml, "forward . /etc/resolv.conf {") {
+		t.Fatalf("expect forward to resolv.conf in coredns yaml:\n%s", yaml)
+	}
+
+	ccfg.ServiceCluster.DNS.UpstreamServers = []string{"10.0.0.53", "10.0.1.53:5353"}
+	if yaml, err = renderPodCorednsYaml(ccfg); err != nil {
+		t.Fatalf("render coredns yaml failed: %v", err)
+	}
+	expect := "forward . 10.0.0.53 10.0.1.53:5353 {"
+	if !strings.Contains(yaml, expect) {
+		t.Fatalf("expect %q in coredns yaml:\n%s", expect, yaml)
+	}
+	corefile, err := renderCoreConfig(ccfg)
+	if err != nil {
+		t.Fatalf("render corefile of binary coredns failed: %v", err)
+	}
+	if !strings.Contains(corefile, expect) {
+		t.Fatalf("expect %q in corefile:\n%s", expect, corefile)
+	}
+}
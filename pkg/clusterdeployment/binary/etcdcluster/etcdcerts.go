@@ -17,7 +17,6 @@ package etcdcluster
 
 import (
 	"crypto/x509"
-	"fmt"
 	"path/filepath"
 
 	"isula.org/eggo/pkg/api"
@@ -100,13 +99,8 @@ func generateCaAndApiserverEtcdCerts(ccfg *api.ClusterConfig) error {
 		CommonName: "etcd-ca",
 	}
 
-	if ccfg.Certificate.ExternalCA {
-		_, err := lcg.RunCommand(fmt.Sprintf("mkdir -p -m 0700 %s && cp -f %s/etcd/%s %s", etcdCertsPath, ccfg.Certificate.ExternalCAPath, certs.GetCertName("ca"), etcdCertsPath))
-		if err != nil {
-			return err
-		}
-		_, err = lcg.RunCommand(fmt.Sprintf("cp -f %s/etcd/%s %s", ccfg.Certificate.ExternalCAPath, certs.GetKeyName("ca"), etcdCertsPath))
-		if err != nil {
+	if dir := ccfg.Certificate.ExternalEtcdCADir(); dir != "" {
+		if err := certs.InstallExternalCA(dir, "ca", etcdCertsPath); err != nil {
 			return err
 		}
 	}
@@ -16,6 +16,7 @@
 package etcdcluster
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"path/filepath"
@@ -36,7 +37,7 @@ import (
 const (
 	EtcdConfFile       = "/etc/etcd/etcd.conf"
 	EtcdServiceFile    = "/usr/lib/systemd/system/etcd.service"
-	DefaultEtcdDataDir = "/var/lib/etcd/default.etcd"
+	DefaultEtcdDataDir = constants.DefaultEtcdDataDir
 )
 
 type copyInfo struct {
@@ -116,6 +117,10 @@ func (t *EtcdDeployEtcdsTask) Run(r runner.Runner, hostConfig *api.HostConfig) e
 		return err
 	}
 
+	if err := commontools.SetupSystemdDropIns(r, t.ccfg.SystemdDropIns, "etcd"); err != nil {
+		return err
+	}
+
 	shell, err := commontools.GetSystemdServiceShell("etcd", "", true)
 	if err != nil {
 		logrus.Errorf("get etcd systemd service shell failed: %v", err)
@@ -137,8 +142,8 @@ func (t *EtcdPostDeployEtcdsTask) Name() string {
 	return "EtcdPostDeployEtcdsTask"
 }
 
-func healthcheck(r runner.Runner, etcdCertsDir string, ip string) error {
-	cmd := fmt.Sprintf("ETCDCTL_API=3 etcdctl endpoint health --endpoints=https://%v:2379 --cacert=%v/ca.crt --cert=%v/server.crt --key=%v/server.key", ip, etcdCertsDir, etcdCertsDir, etcdCertsDir)
+func healthcheck(r runner.Runner, etcdCertsDir string, endpoint string) error {
+	cmd := fmt.Sprintf("ETCDCTL_API=3 etcdctl endpoint health --endpoints=%v --cacert=%v/ca.crt --cert=%v/server.crt --key=%v/server.key", endpoint, etcdCertsDir, etcdCertsDir, etcdCertsDir)
 	if output, err := r.RunCommand(utils.AddSudo(cmd)); err != nil {
 		return fmt.Errorf("etcd in %v healthcheck failed: %v\noutput: %v", ip, err, output)
 	}
@@ -153,7 +158,7 @@ func (t *EtcdPostDeployEtcdsTask) Run(r runner.Runner, hostConfig *api.HostConfi
 	var err error
 	retry := 10
 	for retry != 0 {
-		if err = healthcheck(r, getDstEtcdCertsDir(t.ccfg), hostConfig.Address); err == nil {
+		if err = healthcheck(r, getDstEtcdCertsDir(t.ccfg), t.ccfg.EtcdCluster.ClientURL(hostConfig.Address)); err == nil {
 			return nil
 		}
 		retry--
@@ -180,10 +185,7 @@ func prepareEtcdDir(r runner.Runner) error {
 func prepareEtcdConfigs(ccfg *api.ClusterConfig, r runner.Runner, hostConfig *api.HostConfig, initialCluster string,
 	confPath string, servicePath string) error {
 	var peerAddresses string
-	dataDir := ccfg.EtcdCluster.DataDir
-	if dataDir == "" {
-		dataDir = DefaultEtcdDataDir
-	}
+	dataDir := ccfg.GetEtcdDataDir()
 
 	nodes := ccfg.EtcdCluster.Nodes
 	if len(nodes) == 0 {
@@ -194,11 +196,12 @@ func prepareEtcdConfigs(ccfg *api.ClusterConfig, r runner.Runner, hostConfig *ap
 		state = "existing"
 		peerAddresses = initialCluster
 	} else {
+		peers := ccfg.EtcdCluster.PeerEndpoints()
 		for i, node := range nodes {
 			if i != 0 {
 				peerAddresses += ","
 			}
-			peerAddresses += node.Name + "=https://" + node.Address + ":2380"
+			peerAddresses += node.Name + "=" + peers[i]
 		}
 	}
 
@@ -211,6 +214,9 @@ func prepareEtcdConfigs(ccfg *api.ClusterConfig, r runner.Runner, hostConfig *ap
 		PeerAddresses: peerAddresses,
 		DataDir:       dataDir,
 		CertsDir:      ccfg.GetCertDir(),
+		ClientPort:    ccfg.EtcdCluster.GetClientPort(),
+		PeerPort:      ccfg.EtcdCluster.GetPeerPort(),
+		MetricsPort:   ccfg.EtcdCluster.GetMetricsPort(),
 		ExtraArgs:     ccfg.EtcdCluster.ExtraArgs,
 	}
 
@@ -231,7 +237,7 @@ func prepareEtcdConfigs(ccfg *api.ClusterConfig, r runner.Runner, hostConfig *ap
 	return nil
 }
 
-func Init(conf *api.ClusterConfig) error {
+func Init(ctx context.Context, conf *api.ClusterConfig) error {
 	// generate ca certificates and kube-apiserver-etcd-client certificates
 	if err := generateCaAndApiserverEtcdCerts(conf); err != nil {
 		return err
@@ -244,11 +250,11 @@ func Init(conf *api.ClusterConfig) error {
 	)
 
 	nodes := utils.GetAllIPs(conf.EtcdCluster.Nodes)
-	if err := nodemanager.RunTaskOnNodes(taskDeployEtcds, nodes); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, taskDeployEtcds, nodes); err != nil {
 		return fmt.Errorf("run task on nodes failed: %v", err)
 	}
 
-	if err := nodemanager.WaitNodesFinish(nodes, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
+	if err := nodemanager.WaitNodesFinish(ctx, nodes, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		return fmt.Errorf("wait for deploy etcds task finish failed: %v", err)
 	}
 
@@ -258,11 +264,11 @@ func Init(conf *api.ClusterConfig) error {
 		},
 	)
 
-	if err := nodemanager.RunTaskOnNodes(taskPostDeployEtcds, nodes); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, taskPostDeployEtcds, nodes); err != nil {
 		return fmt.Errorf("run task on nodes failed: %v", err)
 	}
 
-	if err := nodemanager.WaitNodesFinish(nodes, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
+	if err := nodemanager.WaitNodesFinish(ctx, nodes, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		return fmt.Errorf("wait for post deploy etcds task finish failed: %v", err)
 	}
 
@@ -16,6 +16,7 @@
 package etcdcluster
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -83,6 +84,11 @@ func (r *fakeRunner) Copy(src, dst string) error {
 	return nil
 }
 
+func (r *fakeRunner) CopyDir(srcDir, dstDir string) error {
+	logrus.Infof("copy dir %v to %v", srcDir, dstDir)
+	return nil
+}
+
 func (r *fakeRunner) RunCommand(cmd string) (string, error) {
 	logrus.Infof("run command:[%v]", cmd)
 
@@ -223,7 +229,7 @@ func TestEtcdCertsAndConfig(t *testing.T) {
 	}
 }
 
-func TestDeployEtcd(t *testing.T) {
+func TestDeployEtcd(ctx context.Context, t *testing.T) {
 	tempdir, err := ioutil.TempDir("", "etcdcluster-test-")
 	if err != nil {
 		t.Fatalf("create tempdir for cmd configs failed: %v", err)
@@ -233,7 +239,59 @@ func TestDeployEtcd(t *testing.T) {
 
 	registerFakeRunner(t)
 
-	if err := Init(conf); err != nil {
+	if err := Init(ctx, conf); err != nil {
 		t.Fatalf("deploy etcd cluster failed")
 	}
 }
+
+func TestCreateEtcdEnvIPv6(t *testing.T) {
+	env := createEtcdEnv(&etcdEnvConfig{
+		Arch:        "amd64",
+		Ip:          "fd00::1",
+		Hostname:    "etcd0",
+		CertsDir:    "/etc/kubernetes/pki",
+		ClientPort:  2379,
+		PeerPort:    2380,
+		MetricsPort: 2381,
+	})
+	for _, expect := range []string{
+		"ETCD_ADVERTISE_CLIENT_URLS=https://[fd00::1]:2379\n",
+		"ETCD_LISTEN_CLIENT_URLS=https://127.0.0.1:2379,https://[fd00::1]:2379\n",
+		"ETCD_LISTEN_PEER_URLS=https://[fd00::1]:2380\n",
+		"ETCD_LISTEN_METRICS_URLS=https://[fd00::1]:2381\n",
+	} {
+		if !strings.Contains(env, expect) {
+			t.Fatalf("expect %q in etcd env:\n%s", expect, env)
+		}
+	}
+}
+
+func TestCreateEtcdEnvCustomPorts(t *testing.T) {
+	ecc := api.EtcdClusterConfig{ClientPort: 12379, PeerPort: 12380, MetricsPort: 12381}
+	env := createEtcdEnv(&etcdEnvConfig{
+		Arch:        "amd64",
+		Ip:          "192.168.0.1",
+		Hostname:    "etcd0",
+		CertsDir:    "/etc/kubernetes/pki",
+		ClientPort:  ecc.GetClientPort(),
+		PeerPort:    ecc.GetPeerPort(),
+		MetricsPort: ecc.GetMetricsPort(),
+	})
+	for _, expect := range []string{
+		"ETCD_ADVERTISE_CLIENT_URLS=https://192.168.0.1:12379\n",
+		"ETCD_LISTEN_CLIENT_URLS=https://127.0.0.1:12379,https://192.168.0.1:12379\n",
+		"ETCD_INITIAL_ADVERTISE_PEER_URLS=https://192.168.0.1:12380\n",
+		"ETCD_LISTEN_PEER_URLS=https://192.168.0.1:12380\n",
+		"ETCD_LISTEN_METRICS_URLS=https://192.168.0.1:12381\n",
+		"ETCDCTL_ENDPOINTS=https://127.0.0.1:12379\n",
+	} {
+		if !strings.Contains(env, expect) {
+			t.Fatalf("expect %q in etcd env:\n%s", expect, env)
+		}
+	}
+
+	ccfg := &api.ClusterConfig{EtcdCluster: ecc}
+	if opts := getEtcdctlOpts(ccfg); !strings.Contains(opts, "--endpoints=https://127.0.0.1:12379 ") {
+		t.Fatalf("etcdctl should connect to custom client port, get: %s", opts)
+	}
+}
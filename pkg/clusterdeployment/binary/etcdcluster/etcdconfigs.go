@@ -18,6 +18,8 @@ package etcdcluster
 import (
 	"fmt"
 	"path/filepath"
+
+	"isula.org/eggo/pkg/api"
 )
 
 type etcdEnvConfig struct {
@@ -29,18 +31,21 @@ type etcdEnvConfig struct {
 	PeerAddresses string
 	DataDir       string
 	CertsDir      string
+	ClientPort    int
+	PeerPort      int
+	MetricsPort   int
 	ExtraArgs     map[string]string
 }
 
 func createEtcdEnv(conf *etcdEnvConfig) string {
 	args := map[string]string{
-		"ETCD_ADVERTISE_CLIENT_URLS":       "https://" + conf.Ip + ":2379",
+		"ETCD_ADVERTISE_CLIENT_URLS":       api.EtcdURL(conf.Ip, conf.ClientPort),
 		"ETCD_DATA_DIR":                    conf.DataDir,
-		"ETCD_INITIAL_ADVERTISE_PEER_URLS": "https://" + conf.Ip + ":2380",
+		"ETCD_INITIAL_ADVERTISE_PEER_URLS": api.EtcdURL(conf.Ip, conf.PeerPort),
 		"ETCD_INITIAL_CLUSTER":             conf.PeerAddresses,
-		"ETCD_LISTEN_CLIENT_URLS":          "https://127.0.0.1:2379,https://" + conf.Ip + ":2379",
-		"ETCD_LISTEN_METRICS_URLS":         "https://" + conf.Ip + ":2381",
-		"ETCD_LISTEN_PEER_URLS":            "https://" + conf.Ip + ":2380",
+		"ETCD_LISTEN_CLIENT_URLS":          api.EtcdURL("127.0.0.1", conf.ClientPort) + "," + api.EtcdURL(conf.Ip, conf.ClientPort),
+		"ETCD_LISTEN_METRICS_URLS":         api.EtcdURL(conf.Ip, conf.MetricsPort),
+		"ETCD_LISTEN_PEER_URLS":            api.EtcdURL(conf.Ip, conf.PeerPort),
 		"ETCD_NAME":                        conf.Hostname,
 		"ETCD_SNAPSHOT_COUNT":              "10000",
 		"ETCD_INITIAL_CLUSTER_STATE":       conf.State,
@@ -53,7 +58,7 @@ func createEtcdEnv(conf *etcdEnvConfig) string {
 		"ETCD_PEER_TRUSTED_CA_FILE":        filepath.Join(conf.CertsDir, "etcd", "ca.crt"),
 		"ETCD_PEER_CERT_FILE":              filepath.Join(conf.CertsDir, "etcd", "peer.crt"),
 		"ETCD_PEER_KEY_FILE":               filepath.Join(conf.CertsDir, "etcd", "peer.key"),
-		"ETCDCTL_ENDPOINTS":                "https://127.0.0.1:2379",
+		"ETCDCTL_ENDPOINTS":                api.EtcdURL("127.0.0.1", conf.ClientPort),
 		"ETCDCTL_CA_FILE":                  filepath.Join(conf.CertsDir, "etcd", "ca.crt"),
 		"ETCDCTL_KEY_FILE":                 filepath.Join(conf.CertsDir, "etcd", "healthcheck-client.crt"),
 		"ETCDCTL_CERT_FILE":                filepath.Join(conf.CertsDir, "etcd", "healthcheck-client.key"),
@@ -16,6 +16,7 @@
 package etcdcluster
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -25,8 +26,8 @@ import (
 	"isula.org/eggo/pkg/utils/task"
 )
 
-func AddMember(conf *api.ClusterConfig, hostconfig *api.HostConfig) error {
-	initialCluster, err := ExecAddMemberTask(conf, hostconfig)
+func AddMember(ctx context.Context, conf *api.ClusterConfig, hostconfig *api.HostConfig) error {
+	initialCluster, err := ExecAddMemberTask(ctx, conf, hostconfig)
 	if err != nil {
 		return err
 	}
@@ -45,11 +46,11 @@ func AddMember(conf *api.ClusterConfig, hostconfig *api.HostConfig) error {
 		),
 	}
 
-	if err := nodemanager.RunTasksOnNode(tasks, hostconfig.Address); err != nil {
+	if err := nodemanager.RunTasksOnNode(ctx, tasks, hostconfig.Address); err != nil {
 		return fmt.Errorf("run task on nodes failed: %v", err)
 	}
 
-	if err := nodemanager.WaitNodesFinish([]string{hostconfig.Address},
+	if err := nodemanager.WaitNodesFinish(ctx, []string{hostconfig.Address},
 		time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		return fmt.Errorf("wait for post deploy etcds task finish failed: %v", err)
 	}
@@ -16,6 +16,7 @@
 package etcdcluster
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -23,7 +24,7 @@ import (
 	"isula.org/eggo/pkg/api"
 )
 
-func TestAddMember(t *testing.T) {
+func TestAddMember(ctx context.Context, t *testing.T) {
 	node0 := &api.HostConfig{
 		Arch:    "amd64",
 		Name:    "worker0",
@@ -62,7 +63,7 @@ func TestAddMember(t *testing.T) {
 
 	registerFakeRunner(t)
 
-	if err := AddMember(conf, node1); err != nil {
+	if err := AddMember(ctx, conf, node1); err != nil {
 		t.Fatalf("deploy etcd cluster failed")
 	}
 }
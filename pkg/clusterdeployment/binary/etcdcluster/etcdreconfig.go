@@ -16,6 +16,7 @@
 package etcdcluster
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -71,7 +72,7 @@ func (t *EtcdEtcdReconfigTask) Run(r runner.Runner, hostConfig *api.HostConfig)
 	}
 
 	if t.reconfigType == "remove" {
-		etcds := getEtcdMembers(t.ccfg.GetCertDir(), r)
+		etcds := getEtcdMembers(t.ccfg, r)
 		if etcds == nil {
 			return fmt.Errorf("get etcds failed")
 		}
@@ -82,12 +83,12 @@ func (t *EtcdEtcdReconfigTask) Run(r runner.Runner, hostConfig *api.HostConfig)
 			return nil
 		}
 
-		if err := removeEtcd(r, t.ccfg.GetCertDir(), id); err != nil {
+		if err := removeEtcd(r, t.ccfg, id); err != nil {
 			return err
 		}
 	}
 	if t.reconfigType == "add" {
-		output, err := addEtcd(r, t.ccfg.GetCertDir(), t.reconfigHost.Name, t.reconfigHost.Address)
+		output, err := addEtcd(r, t.ccfg, t.reconfigHost.Name, t.reconfigHost.Address)
 		if err != nil {
 			return err
 		}
@@ -104,7 +105,7 @@ func (t *EtcdEtcdReconfigTask) Run(r runner.Runner, hostConfig *api.HostConfig)
 	return nil
 }
 
-func etcdReconfig(conf *api.ClusterConfig, hostconfig *api.HostConfig, reconfigType string) (string, error) {
+func etcdReconfig(ctx context.Context, conf *api.ClusterConfig, hostconfig *api.HostConfig, reconfigType string) (string, error) {
 	if len(conf.EtcdCluster.Nodes) == 0 {
 		return "", fmt.Errorf("invalid null etcd node")
 	}
@@ -122,20 +123,20 @@ func etcdReconfig(conf *api.ClusterConfig, hostconfig *api.HostConfig, reconfigT
 	taskEtcdReconfig := task.NewTaskInstance(t)
 
 	nodes := []string{conf.EtcdCluster.Nodes[0].Address}
-	if err := nodemanager.RunTaskOnNodes(taskEtcdReconfig, nodes); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, taskEtcdReconfig, nodes); err != nil {
 		return "", fmt.Errorf("run task on nodes failed: %v", err)
 	}
 
-	if err := nodemanager.WaitNodesFinish(nodes, time.Minute); err != nil {
+	if err := nodemanager.WaitNodesFinish(ctx, nodes, time.Minute); err != nil {
 		return "", fmt.Errorf("wait for etcd reconfig task finish failed: %v", err)
 	}
 
 	return t.initialCluster, nil
 }
 
-func ExecRemoveMemberTask(conf *api.ClusterConfig, hostconfig *api.HostConfig) error {
+func ExecRemoveMemberTask(ctx context.Context, conf *api.ClusterConfig, hostconfig *api.HostConfig) error {
 	if !conf.EtcdCluster.External {
-		_, ret := etcdReconfig(conf, hostconfig, "remove")
+		_, ret := etcdReconfig(ctx, conf, hostconfig, "remove")
 		return ret
 	} else {
 		logrus.Info("external etcd, ignore remove etcds")
@@ -143,9 +144,9 @@ func ExecRemoveMemberTask(conf *api.ClusterConfig, hostconfig *api.HostConfig) e
 	}
 }
 
-func ExecAddMemberTask(conf *api.ClusterConfig, hostconfig *api.HostConfig) (string, error) {
+func ExecAddMemberTask(ctx context.Context, conf *api.ClusterConfig, hostconfig *api.HostConfig) (string, error) {
 	if !conf.EtcdCluster.External {
-		return etcdReconfig(conf, hostconfig, "add")
+		return etcdReconfig(ctx, conf, hostconfig, "add")
 	} else {
 		logrus.Info("external etcd, ignore add etcds")
 		return "", nil
@@ -191,8 +192,8 @@ func parseEtcdMemberList(output string) []*etcdMember {
 	return members
 }
 
-func getEtcdMembers(certDir string, r runner.Runner) []*etcdMember {
-	cmd := fmt.Sprintf("ETCDCTL_API=3 etcdctl %v member list", getEtcdCertsOpts(certDir))
+func getEtcdMembers(ccfg *api.ClusterConfig, r runner.Runner) []*etcdMember {
+	cmd := fmt.Sprintf("ETCDCTL_API=3 etcdctl %v member list", getEtcdctlOpts(ccfg))
 	output, err := r.RunCommand(utils.AddSudo(cmd))
 	if err != nil {
 		logrus.Errorf("get etcd members failed: %v\noutput: %v", err, output)
@@ -201,14 +202,16 @@ func getEtcdMembers(certDir string, r runner.Runner) []*etcdMember {
 	return parseEtcdMemberList(output)
 }
 
-func getEtcdCertsOpts(certsPath string) string {
-	return fmt.Sprintf("--cert=%v/etcd/server.crt --key=%v/etcd/server.key --cacert=%v/etcd/ca.crt",
-		certsPath, certsPath, certsPath)
+// etcdctl run on etcd node and connect to local etcd
+func getEtcdctlOpts(ccfg *api.ClusterConfig) string {
+	certsPath := ccfg.GetCertDir()
+	return fmt.Sprintf("--endpoints=%v --cert=%v/etcd/server.crt --key=%v/etcd/server.key --cacert=%v/etcd/ca.crt",
+		ccfg.EtcdCluster.ClientURL("127.0.0.1"), certsPath, certsPath, certsPath)
 }
 
-func removeEtcd(r runner.Runner, certDir string, id string) error {
+func removeEtcd(r runner.Runner, ccfg *api.ClusterConfig, id string) error {
 	cmd := fmt.Sprintf("ETCDCTL_API=3 etcdctl %v member remove %v",
-		getEtcdCertsOpts(certDir), id)
+		getEtcdctlOpts(ccfg), id)
 	logrus.Debugf("remove etcd command: %v", cmd)
 	if output, err := r.RunCommand(utils.AddSudo(cmd)); err != nil {
 		logrus.Errorf("remove etcd %v failed: %v\noutput: %v", id, err, output)
@@ -217,9 +220,9 @@ func removeEtcd(r runner.Runner, certDir string, id string) error {
 	return nil
 }
 
-func addEtcd(r runner.Runner, certDir string, name string, ip string) (string, error) {
-	cmd := fmt.Sprintf("ETCDCTL_API=3 etcdctl %v member add %v --peer-urls=https://%v:2380",
-		getEtcdCertsOpts(certDir), name, ip)
+func addEtcd(r runner.Runner, ccfg *api.ClusterConfig, name string, ip string) (string, error) {
+	cmd := fmt.Sprintf("ETCDCTL_API=3 etcdctl %v member add %v --peer-urls=%v",
+		getEtcdctlOpts(ccfg), name, ccfg.EtcdCluster.PeerURL(ip))
 	logrus.Debugf("add etcd command: %v", cmd)
 
 	var err error
@@ -239,13 +242,13 @@ func addEtcd(r runner.Runner, certDir string, name string, ip string) (string, e
 }
 
 func (t *removeEtcdsTask) Run(r runner.Runner, hostConfig *api.HostConfig) error {
-	etcds := getEtcdMembers(t.ccfg.GetCertDir(), r)
+	etcds := getEtcdMembers(t.ccfg, r)
 	for _, member := range etcds {
 		// do not delete self
 		if member.name == hostConfig.Name {
 			continue
 		}
-		if err := removeEtcd(r, t.ccfg.GetCertDir(), member.id); err != nil {
+		if err := removeEtcd(r, t.ccfg, member.id); err != nil {
 			logrus.Errorf("remove etcd %v failed", member.id)
 		}
 	}
@@ -253,19 +256,19 @@ func (t *removeEtcdsTask) Run(r runner.Runner, hostConfig *api.HostConfig) error
 	return nil
 }
 
-func execRemoveEtcdsTask(conf *api.ClusterConfig, node string) error {
+func execRemoveEtcdsTask(ctx context.Context, conf *api.ClusterConfig, node string) error {
 	taskRemoveEtcds := task.NewTaskIgnoreErrInstance(
 		&removeEtcdsTask{
 			ccfg: conf,
 		},
 	)
 
-	if err := nodemanager.RunTaskOnNodes(taskRemoveEtcds, []string{node}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, taskRemoveEtcds, []string{node}); err != nil {
 		logrus.Errorf("run task for remove etcds failed: %v", err)
 		return err
 	}
 
-	if err := nodemanager.WaitNodesFinish([]string{node}, time.Minute*2); err != nil {
+	if err := nodemanager.WaitNodesFinish(ctx, []string{node}, time.Minute*2); err != nil {
 		logrus.Warnf("wait remove etcds task finish failed: %v", err)
 		return err
 	}
@@ -301,7 +304,7 @@ func getNodeIpByName(nodes []*api.HostConfig, name string) string {
 }
 
 func (t *getEtcdLeaderTask) Run(r runner.Runner, hostConfig *api.HostConfig) error {
-	etcds := getEtcdMembers(t.ccfg.GetCertDir(), r)
+	etcds := getEtcdMembers(t.ccfg, r)
 	for _, member := range etcds {
 		if member.leader {
 			t.leader = getNodeIpByName(t.ccfg.Nodes, member.name)
@@ -312,16 +315,16 @@ func (t *getEtcdLeaderTask) Run(r runner.Runner, hostConfig *api.HostConfig) err
 	return nil
 }
 
-func getEtcdLeader(conf *api.ClusterConfig, node string) string {
+func getEtcdLeader(ctx context.Context, conf *api.ClusterConfig, node string) string {
 	t := &getEtcdLeaderTask{ccfg: conf}
 	taskGetEtcdLeader := task.NewTaskInstance(t)
 
-	if err := nodemanager.RunTaskOnNodes(taskGetEtcdLeader, []string{node}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, taskGetEtcdLeader, []string{node}); err != nil {
 		logrus.Errorf("run task for get etcd leader failed: %v", err)
 		return ""
 	}
 
-	if err := nodemanager.WaitNodesFinish([]string{node}, time.Minute*2); err != nil {
+	if err := nodemanager.WaitNodesFinish(ctx, []string{node}, time.Minute*2); err != nil {
 		logrus.Warnf("wait get etcd leader task finish failed: %v", err)
 		return ""
 	}
@@ -329,11 +332,11 @@ func getEtcdLeader(conf *api.ClusterConfig, node string) string {
 	return t.leader
 }
 
-func ExecRemoveEtcdsTask(conf *api.ClusterConfig) error {
+func ExecRemoveEtcdsTask(ctx context.Context, conf *api.ClusterConfig) error {
 	firstEtcdNode := getFirstEtcd(conf.Nodes)
-	execNode := getEtcdLeader(conf, firstEtcdNode)
+	execNode := getEtcdLeader(ctx, conf, firstEtcdNode)
 	if execNode == "" {
 		execNode = firstEtcdNode
 	}
-	return execRemoveEtcdsTask(conf, execNode)
+	return execRemoveEtcdsTask(ctx, conf, execNode)
 }
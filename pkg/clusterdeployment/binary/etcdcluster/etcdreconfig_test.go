@@ -16,19 +16,20 @@
 package etcdcluster
 
 import (
+	"context"
 	"testing"
 )
 
-func TestExecRemoveEtcdsTask(t *testing.T) {
+func TestExecRemoveEtcdsTask(ctx context.Context, t *testing.T) {
 	registerFakeRunner(t)
-	if err := ExecRemoveEtcdsTask(conf); err != nil {
+	if err := ExecRemoveEtcdsTask(ctx, conf); err != nil {
 		t.Fatalf("test exec remove etcds task failed")
 	}
 }
 
-func TestExecRemoveMemberTask(t *testing.T) {
+func TestExecRemoveMemberTask(ctx context.Context, t *testing.T) {
 	registerFakeRunner(t)
-	if err := ExecRemoveMemberTask(conf, nodes[1]); err != nil {
+	if err := ExecRemoveMemberTask(ctx, conf, nodes[1]); err != nil {
 		t.Fatalf("test exec remove member task failed")
 	}
 }
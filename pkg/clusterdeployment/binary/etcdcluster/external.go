@@ -0,0 +1,145 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: use existing external etcd cluster
+ ******************************************************************************/
+
+package etcdcluster
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/certs"
+)
+
+const (
+	externalEtcdCheckTimeout = 10 * time.Second
+)
+
+func externalEtcdTLSConfig(ecc *api.EtcdClusterConfig) (*tls.Config, error) {
+	caData, err := ioutil.ReadFile(ecc.ExternalCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read external etcd ca failed: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return nil, fmt.Errorf("invalid external etcd ca: %s", ecc.ExternalCAFile)
+	}
+
+	cert, err := tls.LoadX509KeyPair(ecc.ExternalCertFile, ecc.ExternalKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load external etcd client certificate failed: %v", err)
+	}
+
+	return &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{cert},
+	}, nil
+}
+
+func checkEtcdEndpointHealth(client *http.Client, endpoint string) error {
+	resp, err := client.Get(strings.TrimSuffix(endpoint, "/") + "/health")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s, body: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var health struct {
+		Health string `json:"health"`
+	}
+	if err := json.Unmarshal(body, &health); err != nil {
+		return fmt.Errorf("invalid health output: %s", strings.TrimSpace(string(body)))
+	}
+	if health.Health != "true" {
+		return fmt.Errorf("unhealthy: %s", strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// CheckExternalEtcd check all endpoints of external etcd are reachable and authenticated by client certificates
+func CheckExternalEtcd(ecc *api.EtcdClusterConfig) error {
+	if len(ecc.ExternalEndpoints) == 0 {
+		return fmt.Errorf("no endpoints of external etcd")
+	}
+	if ecc.ExternalCAFile == "" || ecc.ExternalCertFile == "" || ecc.ExternalKeyFile == "" {
+		return fmt.Errorf("ca, cert and key of external etcd are required")
+	}
+
+	tlsConfig, err := externalEtcdTLSConfig(ecc)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Timeout:   externalEtcdCheckTimeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+	defer client.CloseIdleConnections()
+
+	for _, ep := range ecc.ExternalEndpoints {
+		if err := checkEtcdEndpointHealth(client, ep); err != nil {
+			return fmt.Errorf("check external etcd endpoint %s failed: %v", ep, err)
+		}
+	}
+	return nil
+}
+
+// install certificates of external etcd as certificates used by apiserver to access etcd
+func installExternalEtcdCerts(ccfg *api.ClusterConfig) error {
+	savePath := api.GetCertificateStorePath(ccfg.Name)
+	etcdCertsPath := filepath.Join(savePath, "etcd")
+	ecc := &ccfg.EtcdCluster
+	lcg := certs.NewLocalCertGenerator()
+
+	cmds := []string{
+		fmt.Sprintf("mkdir -p -m 0700 %s", etcdCertsPath),
+		fmt.Sprintf("cp -f %s %s", ecc.ExternalCAFile, filepath.Join(etcdCertsPath, certs.GetCertName("ca"))),
+		fmt.Sprintf("cp -f %s %s", ecc.ExternalCertFile, filepath.Join(savePath, certs.GetCertName("apiserver-etcd-client"))),
+		fmt.Sprintf("cp -f %s %s", ecc.ExternalKeyFile, filepath.Join(savePath, certs.GetKeyName("apiserver-etcd-client"))),
+	}
+	if output, err := lcg.RunCommand(strings.Join(cmds, " && ")); err != nil {
+		return fmt.Errorf("install external etcd certificates failed: %v, output: %s", err, output)
+	}
+	return nil
+}
+
+// InitExternal use existing external etcd cluster, skip deploy etcd
+func InitExternal(ccfg *api.ClusterConfig) error {
+	if !ccfg.EtcdCluster.External {
+		return fmt.Errorf("etcd cluster is not external")
+	}
+
+	if err := CheckExternalEtcd(&ccfg.EtcdCluster); err != nil {
+		return err
+	}
+	logrus.Infof("external etcd %s is healthy", api.GetEtcdServers(&ccfg.EtcdCluster))
+
+	return installExternalEtcdCerts(ccfg)
+}
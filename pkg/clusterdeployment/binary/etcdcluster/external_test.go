@@ -0,0 +1,227 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for external etcd cluster
+ ******************************************************************************/
+
+package etcdcluster
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/certs"
+)
+
+func createTestCerts(t *testing.T, dir string) {
+	lcg := certs.NewLocalCertGenerator()
+	for _, ca := range []string{"ca", "other-ca"} {
+		if err := lcg.CreateCA(&certs.CertConfig{CommonName: ca}, dir, ca); err != nil {
+			t.Fatalf("create %s failed: %v", ca, err)
+		}
+	}
+	gen := func(ca, name string, conf *certs.CertConfig) {
+		if err := lcg.CreateCertAndKey(filepath.Join(dir, certs.GetCertName(ca)), filepath.Join(dir, certs.GetKeyName(ca)),
+			conf, dir, name); err != nil {
+			t.Fatalf("create %s failed: %v", name, err)
+		}
+	}
+	gen("ca", "server", &certs.CertConfig{
+		CommonName: "etcd-server",
+		AltNames:   certs.AltNames{IPs: []string{"127.0.0.1"}},
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	gen("ca", "client", &certs.CertConfig{
+		CommonName: "kube-apiserver-etcd-client",
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	gen("other-ca", "other-client", &certs.CertConfig{
+		CommonName: "kube-apiserver-etcd-client",
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+}
+
+func startTestEtcdServer(t *testing.T, dir string) *httptest.Server {
+	caData, err := ioutil.ReadFile(filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		t.Fatalf("read ca failed: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caData)
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatalf("load server cert failed: %v", err)
+	}
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"health":"true","reason":""}`))
+	}))
+	s.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	s.StartTLS()
+	return s
+}
+
+func TestCheckExternalEtcd(t *testing.T) {
+	dir := t.TempDir()
+	createTestCerts(t, dir)
+	s := startTestEtcdServer(t, dir)
+	defer s.Close()
+
+	ecc := &api.EtcdClusterConfig{
+		External:          true,
+		ExternalEndpoints: []string{s.URL},
+		ExternalCAFile:    filepath.Join(dir, "ca.crt"),
+		ExternalCertFile:  filepath.Join(dir, "client.crt"),
+		ExternalKeyFile:   filepath.Join(dir, "client.key"),
+	}
+	if err := CheckExternalEtcd(ecc); err != nil {
+		t.Fatalf("check healthy external etcd failed: %v", err)
+	}
+
+	// client certificate not signed by ca of etcd
+	ecc.ExternalCertFile = filepath.Join(dir, "other-client.crt")
+	ecc.ExternalKeyFile = filepath.Join(dir, "other-client.key")
+	if err := CheckExternalEtcd(ecc); err == nil {
+		t.Fatalf("expect authenticate failed with untrusted client certificate")
+	}
+	ecc.ExternalCertFile = filepath.Join(dir, "client.crt")
+	ecc.ExternalKeyFile = filepath.Join(dir, "client.key")
+
+	// unreachable endpoint
+	closed := httptest.NewTLSServer(http.NotFoundHandler())
+	closed.Close()
+	ecc.ExternalEndpoints = []string{s.URL, closed.URL}
+	if err := CheckExternalEtcd(ecc); err == nil {
+		t.Fatalf("expect check unreachable external etcd failed")
+	}
+
+	ecc.ExternalEndpoints = nil
+	if err := CheckExternalEtcd(ecc); err == nil {
+		t.Fatalf("expect check external etcd without endpoints failed")
+	}
+}
+
+func TestInitExternal(t *testing.T) {
+	dir := t.TempDir()
+	createTestCerts(t, dir)
+	s := startTestEtcdServer(t, dir)
+	defer s.Close()
+
+	homePath := api.EggoHomePath
+	api.EggoHomePath = filepath.Join(dir, "eggo")
+	defer func() {
+		api.EggoHomePath = homePath
+	}()
+
+	ccfg := &api.ClusterConfig{
+		Name: "test-cluster",
+		EtcdCluster: api.EtcdClusterConfig{
+			ExternalEndpoints: []string{s.URL},
+			ExternalCAFile:    filepath.Join(dir, "ca.crt"),
+			ExternalCertFile:  filepath.Join(dir, "client.crt"),
+			ExternalKeyFile:   filepath.Join(dir, "client.key"),
+		},
+	}
+	if err := InitExternal(ccfg); err == nil {
+		t.Fatalf("expect init external failed for internal etcd cluster")
+	}
+
+	ccfg.EtcdCluster.External = true
+	if err := InitExternal(ccfg); err != nil {
+		t.Fatalf("init external etcd failed: %v", err)
+	}
+	savePath := api.GetCertificateStorePath(ccfg.Name)
+	for _, f := range []string{"etcd/ca.crt", "apiserver-etcd-client.crt", "apiserver-etcd-client.key"} {
+		if _, err := os.Stat(filepath.Join(savePath, f)); err != nil {
+			t.Fatalf("certificate %s of external etcd not installed: %v", f, err)
+		}
+	}
+}
+
+func TestExternalEtcdCA(t *testing.T) {
+	dir := t.TempDir()
+	createTestCerts(t, dir)
+
+	homePath := api.EggoHomePath
+	api.EggoHomePath = filepath.Join(dir, "eggo")
+	defer func() {
+		api.EggoHomePath = homePath
+	}()
+
+	// external kubernetes ca does not affect etcd ca
+	ccfg := &api.ClusterConfig{Name: "test-cluster"}
+	ccfg.Certificate.ExternalKubernetesCAPath = dir
+	if err := generateCaAndApiserverEtcdCerts(ccfg); err != nil {
+		t.Fatalf("generate etcd ca failed: %v", err)
+	}
+	etcdCA, err := certs.ReadCertFromFile(filepath.Join(api.GetCertificateStorePath(ccfg.Name), "etcd", "ca.crt"))
+	if err != nil {
+		t.Fatalf("read etcd ca failed: %v", err)
+	}
+	if etcdCA.Subject.CommonName != "etcd-ca" {
+		t.Fatalf("expect generated etcd ca, get: %s", etcdCA.Subject.CommonName)
+	}
+
+	ccfg = &api.ClusterConfig{Name: "test-external-ca"}
+	ccfg.Certificate.ExternalEtcdCAPath = dir
+	if err = generateCaAndApiserverEtcdCerts(ccfg); err != nil {
+		t.Fatalf("generate certs with external etcd ca failed: %v", err)
+	}
+	savePath := api.GetCertificateStorePath(ccfg.Name)
+	etcdCA, err = certs.ReadCertFromFile(filepath.Join(savePath, "etcd", "ca.crt"))
+	if err != nil {
+		t.Fatalf("read etcd ca failed: %v", err)
+	}
+	if etcdCA.Subject.CommonName != "ca" {
+		t.Fatalf("expect external etcd ca, get: %s", etcdCA.Subject.CommonName)
+	}
+	client, err := certs.ReadCertFromFile(filepath.Join(savePath, "apiserver-etcd-client.crt"))
+	if err != nil {
+		t.Fatalf("read apiserver etcd client cert failed: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(etcdCA)
+	if _, err = client.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Fatalf("apiserver etcd client cert should be signed by external etcd ca: %v", err)
+	}
+
+	// client certificate is not a ca
+	ccfg = &api.ClusterConfig{Name: "test-invalid-ca"}
+	ccfg.Certificate.ExternalEtcdCAPath = filepath.Join(dir, "invalid")
+	if err = os.MkdirAll(ccfg.Certificate.ExternalEtcdCAPath, 0700); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	for src, dst := range map[string]string{"client.crt": "ca.crt", "client.key": "ca.key"} {
+		data, _ := ioutil.ReadFile(filepath.Join(dir, src))
+		if err = ioutil.WriteFile(filepath.Join(ccfg.Certificate.ExternalEtcdCAPath, dst), data, 0600); err != nil {
+			t.Fatalf("write %s failed: %v", dst, err)
+		}
+	}
+	if err = generateCaAndApiserverEtcdCerts(ccfg); err == nil {
+		t.Fatalf("expect invalid external etcd ca failed")
+	}
+}
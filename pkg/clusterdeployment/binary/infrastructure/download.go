@@ -0,0 +1,144 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: download package source from http(s) server
+ ******************************************************************************/
+
+package infrastructure
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils"
+)
+
+const (
+	downloadTimeout = 30 * time.Minute
+)
+
+var (
+	// downloaded packages, key is url, value is local path. Package source config is
+	// shared by tasks of nodes running concurrently, so it is never modified, local
+	// path of url is looked up here instead
+	downloadedPackages = make(map[string]string)
+	downloadLock       sync.Mutex
+)
+
+func downloadFile(url, dst string) error {
+	client := &http.Client{Timeout: downloadTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("download %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download %s failed: %s", url, resp.Status)
+	}
+
+	tmp := dst + ".download"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("download %s failed: %v", url, err)
+	}
+
+	return os.Rename(tmp, dst)
+}
+
+func downloadPackage(url, sum, dir string) (string, error) {
+	// integrity of package downloaded from network must be verified
+	if sum == "" {
+		return "", fmt.Errorf("no sha256 for package %s", url)
+	}
+
+	downloadLock.Lock()
+	defer downloadLock.Unlock()
+	if p, ok := downloadedPackages[url]; ok {
+		return p, nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, path.Base(strings.Split(url, "?")[0]))
+
+	// reuse package downloaded before, if it is not changed
+	if s, err := utils.FileSha256(dst); err == nil && s == sum {
+		logrus.Infof("use downloaded package %s for %s", dst, url)
+		downloadedPackages[url] = dst
+		return dst, nil
+	}
+
+	logrus.Infof("download package %s to %s", url, dst)
+	if err := downloadFile(url, dst); err != nil {
+		return "", err
+	}
+
+	s, err := utils.FileSha256(dst)
+	if err != nil {
+		return "", err
+	}
+	if s != sum {
+		os.Remove(dst)
+		return "", fmt.Errorf("sha256 of package %s mismatch, expect: %s, get: %s", url, sum, s)
+	}
+
+	downloadedPackages[url] = dst
+	return dst, nil
+}
+
+// PreparePackageSrc download package of arch into dir if it is http(s) url,
+// the local path is returned by LocalPackageSrcPath after that
+func PreparePackageSrc(pcfg *api.PackageSrcConfig, arch string, dir string) error {
+	if pcfg == nil {
+		return nil
+	}
+	arch = strings.ToLower(arch)
+	url, ok := pcfg.SrcPath[arch]
+	if !ok || !api.IsRemotePackage(url) {
+		return nil
+	}
+
+	_, err := downloadPackage(url, strings.ToLower(pcfg.Sha256[arch]), dir)
+	return err
+}
+
+// LocalPackageSrcPath returns local path of package of arch, it is the downloaded package
+// if source path is http(s) url, or empty if the url is not prepared
+func LocalPackageSrcPath(pcfg *api.PackageSrcConfig, arch string) string {
+	src := pcfg.SrcPath[strings.ToLower(arch)]
+	if !api.IsRemotePackage(src) {
+		return src
+	}
+
+	downloadLock.Lock()
+	defer downloadLock.Unlock()
+	return downloadedPackages[src]
+}
@@ -0,0 +1,95 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for download package source
+ ******************************************************************************/
+
+package infrastructure
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func TestPreparePackageSrc(t *testing.T) {
+	content := []byte("eggo packages")
+	sum := fmt.Sprintf("%x", sha256.Sum256(content))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/packages.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	pcfg := &api.PackageSrcConfig{
+		SrcPath: map[string]string{
+			"x86_64": srv.URL + "/packages.tar.gz",
+			"arm64":  "/root/packages-arm64.tar.gz",
+		},
+		Sha256: map[string]string{
+			"x86_64": sum,
+		},
+	}
+	if err := PreparePackageSrc(pcfg, "X86_64", dir); err != nil {
+		t.Fatalf("prepare package source failed: %v", err)
+	}
+	local := LocalPackageSrcPath(pcfg, "x86_64")
+	data, err := ioutil.ReadFile(local)
+	if err != nil || string(data) != string(content) {
+		t.Fatalf("invalid downloaded package: %s, err: %v", local, err)
+	}
+	// shared config is not modified
+	if pcfg.SrcPath["x86_64"] != srv.URL+"/packages.tar.gz" {
+		t.Fatalf("package source should not be changed: %s", pcfg.SrcPath["x86_64"])
+	}
+
+	// local path is used directly
+	if err := PreparePackageSrc(pcfg, "arm64", dir); err != nil || LocalPackageSrcPath(pcfg, "arm64") != "/root/packages-arm64.tar.gz" {
+		t.Fatalf("local package source should not be changed: %s, err: %v", LocalPackageSrcPath(pcfg, "arm64"), err)
+	}
+
+	noSum := &api.PackageSrcConfig{
+		SrcPath: map[string]string{"x86_64": srv.URL + "/packages.tar.gz?nosum"},
+	}
+	if err := PreparePackageSrc(noSum, "x86_64", dir); err == nil || !strings.Contains(err.Error(), "no sha256") {
+		t.Fatalf("expect package without sha256 rejected, get: %v", err)
+	}
+	if LocalPackageSrcPath(noSum, "x86_64") != "" {
+		t.Fatalf("package without sha256 should not be downloaded")
+	}
+
+	mismatch := &api.PackageSrcConfig{
+		SrcPath: map[string]string{"x86_64": srv.URL + "/packages.tar.gz?mismatch"},
+		Sha256:  map[string]string{"x86_64": strings.Repeat("0", 64)},
+	}
+	if err := PreparePackageSrc(mismatch, "x86_64", t.TempDir()); err == nil || !strings.Contains(err.Error(), "mismatch") {
+		t.Fatalf("expect sha256 mismatch, get: %v", err)
+	}
+
+	notFound := &api.PackageSrcConfig{
+		SrcPath: map[string]string{"x86_64": srv.URL + "/notfound.tar.gz"},
+		Sha256:  map[string]string{"x86_64": sum},
+	}
+	if err := PreparePackageSrc(notFound, "x86_64", dir); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expect download failed, get: %v", err)
+	}
+}
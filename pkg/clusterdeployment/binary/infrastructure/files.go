@@ -0,0 +1,86 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: extra files shipped to nodes
+ ******************************************************************************/
+
+package infrastructure
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner"
+)
+
+const defaultExtraFileMode = 0644
+
+// filesOfRoles returns files shipped to node with roles, file without roles is shipped to all nodes
+func filesOfRoles(files []api.ExtraFile, roles uint16) []api.ExtraFile {
+	var res []api.ExtraFile
+	for _, f := range files {
+		if f.Roles == 0 || f.Roles&roles != 0 {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// removedFiles returns files of deleted roles, which are not required by remain roles
+func removedFiles(files []api.ExtraFile, delRoles, remainRoles uint16) []api.ExtraFile {
+	var res []api.ExtraFile
+	for _, f := range filesOfRoles(files, delRoles) {
+		if remainRoles != 0 && (f.Roles == 0 || f.Roles&remainRoles != 0) {
+			continue
+		}
+		res = append(res, f)
+	}
+	return res
+}
+
+func extraFileMode(f api.ExtraFile) string {
+	mode := f.Mode
+	if mode == 0 {
+		mode = defaultExtraFileMode
+	}
+	return fmt.Sprintf("%04o", mode)
+}
+
+func copyFiles(r runner.Runner, files []api.ExtraFile) error {
+	for _, f := range files {
+		if _, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"mkdir -p %s\"", filepath.Dir(f.Dst))); err != nil {
+			return err
+		}
+		if err := r.Copy(f.Src, f.Dst); err != nil {
+			return fmt.Errorf("copy from %s to %s failed: %v", f.Src, f.Dst, err)
+		}
+		if _, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"chown root:root %s && chmod %s %s\"",
+			f.Dst, extraFileMode(f), f.Dst)); err != nil {
+			return fmt.Errorf("set mode of %s failed: %v", f.Dst, err)
+		}
+	}
+	return nil
+}
+
+func removeFiles(r runner.Runner, files []api.ExtraFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+	var dsts []string
+	for _, f := range files {
+		dsts = append(dsts, f.Dst)
+	}
+	_, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"rm -f %s\"", strings.Join(dsts, " ")))
+	return err
+}
@@ -0,0 +1,93 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for extra files shipped to nodes
+ ******************************************************************************/
+
+package infrastructure
+
+import (
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+var testExtraFiles = []api.ExtraFile{
+	{Src: "/root/files/config.toml", Dst: "/etc/containerd/config.toml", Roles: api.Worker},
+	{Src: "/root/files/audit.yaml", Dst: "/etc/kubernetes/audit.yaml", Mode: 0600, Roles: api.Master},
+	{Src: "/root/files/motd", Dst: "/etc/motd"},
+}
+
+func dstsOf(files []api.ExtraFile) string {
+	var dsts []string
+	for _, f := range files {
+		dsts = append(dsts, f.Dst)
+	}
+	return strings.Join(dsts, ",")
+}
+
+func TestFilesOfRoles(t *testing.T) {
+	cases := []struct {
+		roles  uint16
+		expect string
+	}{
+		{api.Worker, "/etc/containerd/config.toml,/etc/motd"},
+		{api.Master, "/etc/kubernetes/audit.yaml,/etc/motd"},
+		{api.Master | api.Worker, "/etc/containerd/config.toml,/etc/kubernetes/audit.yaml,/etc/motd"},
+		{api.ETCD, "/etc/motd"},
+	}
+	for _, c := range cases {
+		if get := dstsOf(filesOfRoles(testExtraFiles, c.roles)); get != c.expect {
+			t.Fatalf("expect files %s of roles %d, get: %s", c.expect, c.roles, get)
+		}
+	}
+
+	// files required by remain roles are kept
+	if get := dstsOf(removedFiles(testExtraFiles, api.Worker, api.Master)); get != "/etc/containerd/config.toml" {
+		t.Fatalf("expect only file of worker removed, get: %s", get)
+	}
+	if get := dstsOf(removedFiles(testExtraFiles, api.Worker|api.Master, 0)); get != "/etc/containerd/config.toml,/etc/kubernetes/audit.yaml,/etc/motd" {
+		t.Fatalf("expect all files of node removed, get: %s", get)
+	}
+}
+
+func TestCopyAndRemoveFiles(t *testing.T) {
+	r := newRecordRunner()
+	if err := copyFiles(r, filesOfRoles(testExtraFiles, api.Master)); err != nil {
+		t.Fatalf("copy files failed: %v", err)
+	}
+	if strings.Join(r.Copies, ",") != "/root/files/audit.yaml /etc/kubernetes/audit.yaml,/root/files/motd /etc/motd" {
+		t.Fatalf("unexpected copies: %v", r.Copies)
+	}
+	for _, c := range []string{
+		"mkdir -p /etc/kubernetes\"",
+		"chown root:root /etc/kubernetes/audit.yaml && chmod 0600 /etc/kubernetes/audit.yaml",
+		"chown root:root /etc/motd && chmod 0644 /etc/motd",
+	} {
+		if !r.Contains(c) {
+			t.Fatalf("expect command %q, get: %v", c, r.Commands)
+		}
+	}
+
+	r = newRecordRunner()
+	if err := removeFiles(r, filesOfRoles(testExtraFiles, api.Master)); err != nil {
+		t.Fatalf("remove files failed: %v", err)
+	}
+	if len(r.Commands) != 1 || !r.Contains("rm -f /etc/kubernetes/audit.yaml /etc/motd") {
+		t.Fatalf("unexpected remove commands: %v", r.Commands)
+	}
+	r = newRecordRunner()
+	if err := removeFiles(r, nil); err != nil || len(r.Commands) != 0 {
+		t.Fatalf("expect nothing removed without files, get: %v, %v", r.Commands, err)
+	}
+}
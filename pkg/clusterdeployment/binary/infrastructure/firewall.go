@@ -16,7 +16,6 @@
 package infrastructure
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -25,34 +24,116 @@ import (
 	"isula.org/eggo/pkg/api"
 	"isula.org/eggo/pkg/utils"
 	"isula.org/eggo/pkg/utils/runner"
+	"isula.org/eggo/pkg/utils/template"
 )
 
+const (
+	firewallBackendNone      = ""
+	firewallBackendFirewalld = "firewalld"
+	firewallBackendIptables  = "iptables"
+
+	// comment of iptables rules added by eggo
+	firewallOwnerComment = "eggo"
+	// firewalld port has no comment, so record ports opened by eggo on node
+	firewalldPortsRecord = "/var/lib/eggo/firewalld-ports"
+)
+
+// port which is already opened by others is not recorded, so it is kept after cleanup
+const firewalldAddTmpl = `#!/bin/bash
+mkdir -p $(dirname {{ .Record }}) && touch {{ .Record }} || exit 1
+for p in {{ .Ports }}; do
+	if firewall-cmd --zone=public --query-port=$p > /dev/null 2>&1; then
+		continue
+	fi
+	firewall-cmd --zone=public --add-port=$p || exit 1
+	grep -qx "$p" {{ .Record }} || echo "$p" >> {{ .Record }}
+done
+firewall-cmd --runtime-to-permanent
+`
+
+const firewalldRemoveTmpl = `#!/bin/bash
+[ -f {{ .Record }} ] || exit 0
+for p in {{ .Ports }}; do
+	grep -qx "$p" {{ .Record }} || continue
+	firewall-cmd --zone=public --remove-port=$p
+	sed -i "\\#^$p\$#d" {{ .Record }}
+done
+firewall-cmd --runtime-to-permanent
+`
+
+// shell may be run by dash, so port range is converted to <base>:<max> without bash substitution
+const iptablesAddTmpl = `#!/bin/bash
+for p in {{ .Ports }}; do
+	port=${p%/*}
+	rule="INPUT -p ${p#*/} --dport $(echo "$port" | tr - :) -m comment --comment {{ .Comment }} -j ACCEPT"
+	iptables -C $rule > /dev/null 2>&1 || iptables -I $rule || exit 1
+done
+`
+
+const iptablesRemoveTmpl = `#!/bin/bash
+for p in {{ .Ports }}; do
+	port=${p%/*}
+	rule="INPUT -p ${p#*/} --dport $(echo "$port" | tr - :) -m comment --comment {{ .Comment }} -j ACCEPT"
+	while iptables -D $rule > /dev/null 2>&1; do :; done
+done
+exit 0
+`
+
 func getPorts(openPorts []*api.OpenPorts) []string {
 	ports := []string{}
 
 	for _, p := range openPorts {
-		ports = append(ports, strconv.Itoa(p.Port)+"/"+p.Protocol)
+		port := strconv.Itoa(p.Port)
+		// firewalld uses <base>-<max> as port range, which is converted to <base>:<max> for iptables
+		if p.EndPort > p.Port {
+			port += "-" + strconv.Itoa(p.EndPort)
+		}
+		ports = append(ports, port+"/"+p.Protocol)
 	}
 
 	return ports
 }
 
-func exposePorts(r runner.Runner, ports []string) error {
-	if _, err := r.RunCommand(utils.AddSudo("systemctl status firewalld | grep running")); err != nil {
-		logrus.Warnf("firewall is disable: %v, just ignore", err)
-		return nil
+func detectFirewallBackend(r runner.Runner) string {
+	if _, err := r.RunCommand(utils.AddSudo("systemctl status firewalld | grep running")); err == nil {
+		return firewallBackendFirewalld
+	}
+	if _, err := r.RunCommand(utils.AddSudo("command -v iptables")); err == nil {
+		return firewallBackendIptables
+	}
+	return firewallBackendNone
+}
+
+func renderFirewallShell(backend string, add bool, ports []string) (string, error) {
+	tmpl := iptablesRemoveTmpl
+	switch {
+	case backend == firewallBackendFirewalld && add:
+		tmpl = firewalldAddTmpl
+	case backend == firewallBackendFirewalld:
+		tmpl = firewalldRemoveTmpl
+	case add:
+		tmpl = iptablesAddTmpl
 	}
 
-	var sb strings.Builder
-	sb.WriteString("sudo -E /bin/sh -c \"")
+	datastore := make(map[string]interface{})
+	datastore["Ports"] = strings.Join(utils.RemoveDupString(ports), " ")
+	datastore["Record"] = firewalldPortsRecord
+	datastore["Comment"] = firewallOwnerComment
+	return template.TemplateRender(tmpl, datastore)
+}
 
-	rPorts := utils.RemoveDupString(ports)
-	for _, p := range rPorts {
-		sb.WriteString(fmt.Sprintf("firewall-cmd --zone=public --add-port=%s && ", p))
+func exposePorts(r runner.Runner, ports []string) error {
+	backend := detectFirewallBackend(r)
+	if backend == firewallBackendNone {
+		logrus.Warnf("firewall is disable, just ignore")
+		return nil
 	}
 
-	sb.WriteString("firewall-cmd --runtime-to-permanent \"")
-	if _, err := r.RunCommand(sb.String()); err != nil {
+	shell, err := renderFirewallShell(backend, true, ports)
+	if err != nil {
+		return err
+	}
+	if _, err := r.RunShell(shell, "exposePorts"); err != nil {
 		return err
 	}
 
@@ -60,21 +141,18 @@ func exposePorts(r runner.Runner, ports []string) error {
 }
 
 func shieldPorts(r runner.Runner, ports []string) {
-	if _, err := r.RunCommand(utils.AddSudo("systemctl status firewalld | grep running")); err != nil {
-		logrus.Warnf("firewall is disable: %v, just ignore", err)
+	backend := detectFirewallBackend(r)
+	if backend == firewallBackendNone {
+		logrus.Warnf("firewall is disable, just ignore")
 		return
 	}
 
-	var sb strings.Builder
-	sb.WriteString("sudo -E /bin/sh -c \"")
-
-	rPorts := utils.RemoveDupString(ports)
-	for _, p := range rPorts {
-		sb.WriteString(fmt.Sprintf("firewall-cmd --zone=public --remove-port=%s ; ", p))
+	shell, err := renderFirewallShell(backend, false, ports)
+	if err != nil {
+		logrus.Errorf("render shield port shell failed: %v", err)
+		return
 	}
-
-	sb.WriteString("firewall-cmd --runtime-to-permanent \"")
-	if _, err := r.RunCommand(sb.String()); err != nil {
+	if _, err := r.RunShell(shell, "shieldPorts"); err != nil {
 		logrus.Errorf("shield port failed: %v", err)
 	}
 }
@@ -0,0 +1,110 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo firewall testcase
+ ******************************************************************************/
+
+package infrastructure
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/infra"
+)
+
+func TestRenderFirewallShell(t *testing.T) {
+	ports := []string{"6443/tcp", "53/udp", "6443/tcp"}
+	cases := []struct {
+		backend string
+		add     bool
+		expects []string
+	}{
+		{firewallBackendFirewalld, true, []string{"--query-port=$p", "--add-port=$p", firewalldPortsRecord}},
+		{firewallBackendFirewalld, false, []string{"grep -qx \"$p\" " + firewalldPortsRecord + " || continue", "--remove-port=$p"}},
+		{firewallBackendIptables, true, []string{"--comment " + firewallOwnerComment, "iptables -C $rule", "iptables -I $rule"}},
+		{firewallBackendIptables, false, []string{"--comment " + firewallOwnerComment, "iptables -D $rule"}},
+	}
+	for _, c := range cases {
+		shell, err := renderFirewallShell(c.backend, c.add, ports)
+		if err != nil {
+			t.Fatalf("render %s shell failed: %v", c.backend, err)
+		}
+		if !strings.Contains(shell, "for p in 6443/tcp 53/udp; do") {
+			t.Fatalf("ports of %s shell should be deduplicated: %s", c.backend, shell)
+		}
+		for _, expect := range c.expects {
+			if !strings.Contains(shell, expect) {
+				t.Fatalf("%s shell(add: %v) does not contain %q: %s", c.backend, c.add, expect, shell)
+			}
+		}
+	}
+}
+
+func TestEtcdCustomPortsFirewall(t *testing.T) {
+	ecc := &api.EtcdClusterConfig{ClientPort: 12379, PeerPort: 12380, MetricsPort: 12381}
+	for _, backend := range []string{firewallBackendFirewalld, firewallBackendIptables} {
+		shell, err := renderFirewallShell(backend, true, getPorts(infra.GetEtcdPorts(ecc)))
+		if err != nil {
+			t.Fatalf("render %s shell failed: %v", backend, err)
+		}
+		if !strings.Contains(shell, "for p in 12379/tcp 12380/tcp 12381/tcp; do") {
+			t.Fatalf("%s shell should open custom etcd ports: %s", backend, shell)
+		}
+	}
+}
+
+func TestPortRangeFirewall(t *testing.T) {
+	ports := getPorts([]*api.OpenPorts{
+		{Port: 30000, EndPort: 32767, Protocol: "tcp"},
+		{Port: 10250, EndPort: 10250, Protocol: "tcp"},
+	})
+	if strings.Join(ports, " ") != "30000-32767/tcp 10250/tcp" {
+		t.Fatalf("invalid ports: %v", ports)
+	}
+
+	// iptables uses <base>:<max> as port range
+	shell, err := renderFirewallShell(firewallBackendIptables, true, ports)
+	if err != nil {
+		t.Fatalf("render iptables shell failed: %v", err)
+	}
+	if !strings.HasPrefix(shell, "#!/bin/bash\n") {
+		t.Fatalf("shebang should be the first line: %s", shell)
+	}
+
+	// run by /bin/sh, which is dash on debian and ubuntu, with fake iptables recording its arguments
+	tempdir := t.TempDir()
+	record := filepath.Join(tempdir, "record")
+	fake := "#!/bin/sh\n[ \"$1\" = \"-C\" ] && exit 1\necho \"$@\" >> " + record + "\n"
+	if err = ioutil.WriteFile(filepath.Join(tempdir, "iptables"), []byte(fake), 0700); err != nil {
+		t.Fatalf("write fake iptables failed: %v", err)
+	}
+	cmd := exec.Command("/bin/sh", "-c", shell)
+	cmd.Env = append(os.Environ(), "PATH="+tempdir+":"+os.Getenv("PATH"))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("run iptables shell failed: %v, output: %s", err, string(output))
+	}
+	data, err := ioutil.ReadFile(record)
+	if err != nil {
+		t.Fatalf("read record of fake iptables failed: %v", err)
+	}
+	for _, expect := range []string{"--dport 30000:32767 ", "--dport 10250 "} {
+		if !strings.Contains(string(data), expect) {
+			t.Fatalf("iptables rules %q do not contain %q", string(data), expect)
+		}
+	}
+}
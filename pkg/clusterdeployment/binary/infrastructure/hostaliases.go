@@ -0,0 +1,95 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo host aliases implement
+ ******************************************************************************/
+
+package infrastructure
+
+import (
+	"fmt"
+	"strings"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner"
+	"isula.org/eggo/pkg/utils/template"
+)
+
+const (
+	hostsFile            = "/etc/hosts"
+	hostAliasesBeginMark = "# BEGIN eggo host aliases"
+	hostAliasesEndMark   = "# END eggo host aliases"
+)
+
+// hostAliasesBlock returns entries of host aliases wrapped by eggo marks
+func hostAliasesBlock(aliases []api.HostAlias) string {
+	if len(aliases) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(hostAliasesBeginMark + "\n")
+	for _, a := range aliases {
+		sb.WriteString(fmt.Sprintf("%s %s\n", a.IP, strings.Join(a.Hostnames, " ")))
+	}
+	sb.WriteString(hostAliasesEndMark + "\n")
+	return sb.String()
+}
+
+// hostAliasesShell removes old eggo block of hosts file, and appends the new one,
+// so it is idempotent; empty aliases just remove the block
+func hostAliasesShell(hosts string, aliases []api.HostAlias) (string, error) {
+	shell := `
+#!/bin/bash
+if [ -f {{ .Hosts }} ]; then
+	sed -i '/^{{ .Begin }}$/,/^{{ .End }}$/d' {{ .Hosts }}
+	if [ $? -ne 0 ]; then
+		echo "remove eggo host aliases from {{ .Hosts }} failed" 1>&2
+		exit 1
+	fi
+fi
+{{- if .Block }}
+cat >> {{ .Hosts }} << 'EOF'
+{{ .Block }}EOF
+if [ $? -ne 0 ]; then
+	echo "add eggo host aliases to {{ .Hosts }} failed" 1>&2
+	exit 1
+fi
+{{- end }}
+exit 0
+`
+	datastore := make(map[string]interface{})
+	datastore["Hosts"] = hosts
+	datastore["Begin"] = hostAliasesBeginMark
+	datastore["End"] = hostAliasesEndMark
+	datastore["Block"] = hostAliasesBlock(aliases)
+
+	return template.TemplateRender(shell, datastore)
+}
+
+func setHostAliases(r runner.Runner, aliases []api.HostAlias) error {
+	shell, err := hostAliasesShell(hostsFile, aliases)
+	if err != nil {
+		return err
+	}
+	_, err = r.RunShell(shell, "setHostAliases")
+	return err
+}
+
+func removeHostAliases(r runner.Runner) error {
+	shell, err := hostAliasesShell(hostsFile, nil)
+	if err != nil {
+		return err
+	}
+	_, err = r.RunShell(shell, "removeHostAliases")
+	return err
+}
@@ -0,0 +1,82 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo host aliases testcase
+ ******************************************************************************/
+
+package infrastructure
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+const testHosts = "127.0.0.1 localhost\n192.168.0.1 master\n"
+
+func runHostAliasesShell(t *testing.T, hosts string, aliases []api.HostAlias) string {
+	shell, err := hostAliasesShell(hosts, aliases)
+	if err != nil {
+		t.Fatalf("render host aliases shell failed: %v", err)
+	}
+	if output, err := exec.Command("/bin/sh", "-c", shell).CombinedOutput(); err != nil {
+		t.Fatalf("run host aliases shell failed: %v, output: %s", err, output)
+	}
+	data, err := ioutil.ReadFile(hosts)
+	if err != nil {
+		t.Fatalf("read hosts failed: %v", err)
+	}
+	return string(data)
+}
+
+func TestHostAliases(t *testing.T) {
+	aliases := []api.HostAlias{
+		{IP: "192.168.0.100", Hostnames: []string{"registry.local", "hub.local"}},
+		{IP: "192.168.0.200", Hostnames: []string{"apiserver.local"}},
+	}
+	block := "# BEGIN eggo host aliases\n" +
+		"192.168.0.100 registry.local hub.local\n" +
+		"192.168.0.200 apiserver.local\n" +
+		"# END eggo host aliases\n"
+	if got := hostAliasesBlock(aliases); got != block {
+		t.Fatalf("expect block:\n%s\nget:\n%s", block, got)
+	}
+	if got := hostAliasesBlock(nil); got != "" {
+		t.Fatalf("expect empty block without aliases, get: %s", got)
+	}
+
+	if _, err := exec.LookPath("sed"); err != nil {
+		t.Skip("sed is required to run host aliases shell")
+	}
+	hosts := filepath.Join(t.TempDir(), "hosts")
+	if err := ioutil.WriteFile(hosts, []byte(testHosts), 0644); err != nil {
+		t.Fatalf("write hosts failed: %v", err)
+	}
+	if got := runHostAliasesShell(t, hosts, aliases); got != testHosts+block {
+		t.Fatalf("expect hosts:\n%s\nget:\n%s", testHosts+block, got)
+	}
+	// rerun with changed aliases replaces the block
+	aliases = aliases[:1]
+	expect := testHosts + "# BEGIN eggo host aliases\n192.168.0.100 registry.local hub.local\n# END eggo host aliases\n"
+	for i := 0; i < 2; i++ {
+		if got := runHostAliasesShell(t, hosts, aliases); got != expect {
+			t.Fatalf("expect hosts:\n%s\nget:\n%s", expect, got)
+		}
+	}
+	// cleanup only removes the block of eggo
+	if got := runHostAliasesShell(t, hosts, nil); got != testHosts {
+		t.Fatalf("expect hosts after cleanup:\n%s\nget:\n%s", testHosts, got)
+	}
+}
@@ -16,6 +16,7 @@
 package infrastructure
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"fmt"
@@ -29,6 +30,7 @@ import (
 	"isula.org/eggo/pkg/clusterdeployment/binary/cleanupcluster"
 	"isula.org/eggo/pkg/utils"
 	"isula.org/eggo/pkg/utils/dependency"
+	"isula.org/eggo/pkg/utils/infra"
 	"isula.org/eggo/pkg/utils/nodemanager"
 	"isula.org/eggo/pkg/utils/runner"
 	"isula.org/eggo/pkg/utils/task"
@@ -44,8 +46,16 @@ var (
 )
 
 type SetupInfraTask struct {
-	packageSrc *api.PackageSrcConfig
-	roleInfra  *api.RoleInfra
+	packageSrc  *api.PackageSrcConfig
+	roleInfra   *api.RoleInfra
+	hostAliases []api.HostAlias
+	repos       []api.PackageRepo
+	files       []api.ExtraFile
+	// image package is only distributed to workers, which run container engine
+	imagePackage string
+	// version of installed kubernetes components of roles is checked if it is set
+	roles      uint16
+	k8sVersion string
 }
 
 func (it *SetupInfraTask) Name() string {
@@ -68,16 +78,42 @@ func (it *SetupInfraTask) Run(r runner.Runner, hcg *api.HostConfig) error {
 		return err
 	}
 
+	if err := copyImagePackage(r, hcg, it.imagePackage, it.packageSrc.GetImagePackageDstPath(it.imagePackage)); err != nil {
+		logrus.Errorf("prepare image package failed: %v", err)
+		return err
+	}
+
+	// repo packages may be installed from repos of user
+	if err := setRepos(r, it.repos); err != nil {
+		logrus.Errorf("set repos failed: %v", err)
+		return err
+	}
+
 	if err := dependency.InstallBaseDependency(r, it.roleInfra, hcg, it.packageSrc.GetPkgDstPath()); err != nil {
 		logrus.Errorf("install dependency failed: %v", err)
 		return err
 	}
 
+	if err := checkInstalledK8sVersion(r, it.roles, it.k8sVersion); err != nil {
+		logrus.Errorf("check kubernetes version failed: %v", err)
+		return err
+	}
+
 	if err := addHostNameIP(r, hcg); err != nil {
 		logrus.Errorf("add host name ip failed: %v", err)
 		return err
 	}
 
+	if err := setHostAliases(r, it.hostAliases); err != nil {
+		logrus.Errorf("set host aliases failed: %v", err)
+		return err
+	}
+
+	if err := copyFiles(r, it.files); err != nil {
+		logrus.Errorf("copy extra files failed: %v", err)
+		return err
+	}
+
 	if err := addFirewallPort(r, it.roleInfra.OpenPorts); err != nil {
 		logrus.Errorf("add firewall port failed: %v", err)
 		return err
@@ -108,6 +144,36 @@ func check(r runner.Runner, hcg *api.HostConfig, packageSrc *api.PackageSrcConfi
 	return nil
 }
 
+// k8sVersionCommands returns commands print version of installed kubernetes components of roles,
+// output is such as "Kubernetes v1.20.2"
+func k8sVersionCommands(roles uint16) []string {
+	var cmds []string
+	if utils.IsType(roles, api.Master) {
+		cmds = append(cmds, "kube-apiserver --version")
+	}
+	if utils.IsType(roles, api.Worker) {
+		cmds = append(cmds, "kubelet --version")
+	}
+	return cmds
+}
+
+func checkInstalledK8sVersion(r runner.Runner, roles uint16, expect string) error {
+	if expect == "" {
+		return nil
+	}
+	for _, cmd := range k8sVersionCommands(roles) {
+		output, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"%s\"", cmd))
+		if err != nil {
+			return fmt.Errorf("get version by %s failed: %v", cmd, err)
+		}
+		fields := strings.Fields(output)
+		if len(fields) == 0 || !api.MatchK8sVersion(expect, fields[len(fields)-1]) {
+			return fmt.Errorf("%w: output of %s is %s, expect %s", ErrK8sVersionMismatch, cmd, strings.TrimSpace(output), expect)
+		}
+	}
+	return nil
+}
+
 func setNetBridge(r runner.Runner) error {
 	const netBridgeNfCallIptablesConf = `net.bridge.bridge-nf-call-ip6tables = 1
 net.bridge.bridge-nf-call-iptables = 1
@@ -155,13 +221,12 @@ exit 0
 	return nil
 }
 
-func getPackageSrcPath(arch string, pcfg *api.PackageSrcConfig) string {
-	return pcfg.SrcPath[strings.ToLower(arch)]
-}
-
 func copyPackage(r runner.Runner, hcg *api.HostConfig, pcfg *api.PackageSrcConfig) error {
-	src := getPackageSrcPath(hcg.Arch, pcfg)
+	src := LocalPackageSrcPath(pcfg, hcg.Arch)
 	if src == "" {
+		if url := pcfg.SrcPath[strings.ToLower(hcg.Arch)]; url != "" {
+			return fmt.Errorf("package %s is not downloaded", url)
+		}
 		logrus.Warnf("no package source path")
 		return nil
 	}
@@ -276,6 +341,33 @@ exit 0
 	return nil
 }
 
+func copyImagePackage(r runner.Runner, hcg *api.HostConfig, src, dstPath string) error {
+	if src == "" {
+		return nil
+	}
+
+	md5, err := pmd.getMD5(src)
+	if err != nil {
+		return fmt.Errorf("get MD5 failed: %v", err)
+	}
+	if checkMD5(r, md5, dstPath) {
+		logrus.Warnf("image package already exist on remote host")
+		return nil
+	}
+
+	if _, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"mkdir -p %s\"", filepath.Dir(dstPath))); err != nil {
+		return err
+	}
+	if err := r.Copy(src, dstPath); err != nil {
+		return fmt.Errorf("copy from %s to %s for %s failed: %v", src, dstPath, hcg.Address, err)
+	}
+	if !checkMD5(r, md5, dstPath) {
+		return fmt.Errorf("%s MD5 has changed after copy, maybe it is corrupted", filepath.Base(src))
+	}
+
+	return nil
+}
+
 func checkMD5(r runner.Runner, md5, path string) bool {
 	output, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"md5sum %s | awk '{print \\$1}'\"", path))
 	if err != nil {
@@ -287,23 +379,87 @@ func checkMD5(r runner.Runner, md5, path string) bool {
 	return md5 == output
 }
 
-func NodeInfrastructureSetup(config *api.ClusterConfig, nodeID string, role uint16) error {
+// roleInfraOf returns infrastructure of role, ports of etcd are opened with ports in etcd cluster config
+func roleInfraOf(ccfg *api.ClusterConfig, role uint16) *api.RoleInfra {
+	roleInfra := ccfg.RoleInfra[role]
+	if roleInfra == nil || role != api.ETCD {
+		return roleInfra
+	}
+	return &api.RoleInfra{
+		Softwares: roleInfra.Softwares,
+		OpenPorts: append(infra.GetEtcdPorts(&ccfg.EtcdCluster), roleInfra.OpenPorts...),
+	}
+}
+
+// mergeRoleInfra returns union of packages and ports of roles, packages shared by roles are installed once
+func mergeRoleInfra(config *api.ClusterConfig, roles uint16) (*api.RoleInfra, error) {
+	var merged api.RoleInfra
+	for _, r := range []uint16{api.Master, api.Worker, api.ETCD, api.LoadBalance} {
+		if !utils.IsType(roles, r) {
+			continue
+		}
+		roleInfra := roleInfraOf(config, r)
+		if roleInfra == nil {
+			return nil, fmt.Errorf("do not register %d roleinfra", r)
+		}
+		for _, p := range roleInfra.OpenPorts {
+			if !containsPort(merged.OpenPorts, p) {
+				merged.OpenPorts = append(merged.OpenPorts, p)
+			}
+		}
+		for _, s := range roleInfra.Softwares {
+			if !containsSoftware(merged.Softwares, s) {
+				merged.Softwares = append(merged.Softwares, s)
+			}
+		}
+	}
+	return &merged, nil
+}
+
+func containsPort(ports []*api.OpenPorts, port *api.OpenPorts) bool {
+	for _, p := range ports {
+		if p.Port == port.Port && p.EndPort == port.EndPort && p.Protocol == port.Protocol {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSoftware(softwares []*api.PackageConfig, software *api.PackageConfig) bool {
+	for _, s := range softwares {
+		if s.Name == software.Name && s.Type == software.Type && s.Dst == software.Dst {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeInfrastructureSetup setups infrastructure of all roles of node at once
+func NodeInfrastructureSetup(ctx context.Context, config *api.ClusterConfig, nodeID string, roles uint16) error {
 	if config == nil {
 		return fmt.Errorf("empty cluster config")
 	}
 
-	roleInfra := config.RoleInfra[role]
-	if roleInfra == nil {
-		return fmt.Errorf("do not register %d roleinfra", role)
+	roleInfra, err := mergeRoleInfra(config, roles)
+	if err != nil {
+		return err
 	}
 
-	itask := task.NewTaskInstance(
-		&SetupInfraTask{
-			packageSrc: &config.PackageSrc,
-			roleInfra:  roleInfra,
-		})
+	setupTask := &SetupInfraTask{
+		packageSrc:  &config.PackageSrc,
+		roleInfra:   roleInfra,
+		hostAliases: config.HostAliases,
+		repos:       config.Repos,
+		files:       filesOfRoles(config.Files, roles),
+		roles:       roles,
+		k8sVersion:  config.KubernetesVersion,
+	}
+	if utils.IsType(roles, api.Worker) && config.WorkerConfig.ContainerEngineConf != nil {
+		setupTask.imagePackage = config.WorkerConfig.ContainerEngineConf.ImagePackage
+	}
+	itask := task.NewTaskInstance(setupTask)
 
-	if err := nodemanager.RunTaskOnNodes(itask, []string{nodeID}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, itask, []string{nodeID}); err != nil {
 		return fmt.Errorf("setup infrastructure Task failed: %v", err)
 	}
 
@@ -313,6 +469,7 @@ func NodeInfrastructureSetup(config *api.ClusterConfig, nodeID string, role uint
 type DestroyInfraTask struct {
 	packageSrc   *api.PackageSrcConfig
 	roleInfra    *api.RoleInfra
+	files        []api.ExtraFile
 	k8sConfigDir string
 }
 
@@ -331,6 +488,18 @@ func (it *DestroyInfraTask) Run(r runner.Runner, hcg *api.HostConfig) error {
 		logrus.Errorf("remove host name ip failed: %v", err)
 	}
 
+	if err := removeHostAliases(r); err != nil {
+		logrus.Errorf("remove host aliases failed: %v", err)
+	}
+
+	if err := removeRepos(r); err != nil {
+		logrus.Errorf("remove repos failed: %v", err)
+	}
+
+	if err := removeFiles(r, it.files); err != nil {
+		logrus.Errorf("remove extra files failed: %v", err)
+	}
+
 	removeFirewallPort(r, it.roleInfra.OpenPorts)
 
 	cleanupcluster.PostCleanup(r)
@@ -365,11 +534,20 @@ func deleteSoftwareIfExist(infras *api.RoleInfra, delSoftware *api.PackageConfig
 	}
 }
 
+func nodeRoles(ccfg *api.ClusterConfig, ip string) uint16 {
+	for _, node := range ccfg.Nodes {
+		if node.Address == ip {
+			return node.Type
+		}
+	}
+	return 0
+}
+
 func getRoleInfra(ccfg *api.ClusterConfig, ip string, delRoles uint16) *api.RoleInfra {
 	var infras api.RoleInfra
 	for _, r := range []uint16{api.Worker, api.Master, api.LoadBalance, api.ETCD} {
 		if utils.IsType(delRoles, r) {
-			roleInfra := ccfg.RoleInfra[r]
+			roleInfra := roleInfraOf(ccfg, r)
 			if roleInfra == nil {
 				logrus.Errorf("have not register %d roleinfra", delRoles)
 				return nil
@@ -379,14 +557,7 @@ func getRoleInfra(ccfg *api.ClusterConfig, ip string, delRoles uint16) *api.Role
 		}
 	}
 
-	var allRoles uint16
-	for _, node := range ccfg.Nodes {
-		if node.Address == ip {
-			allRoles = node.Type
-			break
-		}
-	}
-	remainRoles := allRoles &^ delRoles
+	remainRoles := nodeRoles(ccfg, ip) &^ delRoles
 	// if not found, it means no role remain, so delete all
 	if remainRoles == 0 {
 		return &infras
@@ -396,7 +567,7 @@ func getRoleInfra(ccfg *api.ClusterConfig, ip string, delRoles uint16) *api.Role
 		if !utils.IsType(remainRoles, r) {
 			continue
 		}
-		roleInfra := ccfg.RoleInfra[r]
+		roleInfra := roleInfraOf(ccfg, r)
 		if roleInfra == nil {
 			logrus.Errorf("have not register %d roleinfra", r)
 			return nil
@@ -410,7 +581,7 @@ func getRoleInfra(ccfg *api.ClusterConfig, ip string, delRoles uint16) *api.Role
 	return &infras
 }
 
-func NodeInfrastructureDestroy(config *api.ClusterConfig, hostconfig *api.HostConfig) error {
+func NodeInfrastructureDestroy(ctx context.Context, config *api.ClusterConfig, hostconfig *api.HostConfig) error {
 	if config == nil {
 		return fmt.Errorf("empty cluster config")
 	}
@@ -424,10 +595,11 @@ func NodeInfrastructureDestroy(config *api.ClusterConfig, hostconfig *api.HostCo
 		&DestroyInfraTask{
 			packageSrc:   &config.PackageSrc,
 			roleInfra:    roleInfra,
+			files:        removedFiles(config.Files, hostconfig.Type, nodeRoles(config, hostconfig.Address)&^hostconfig.Type),
 			k8sConfigDir: config.GetConfigDir(),
 		})
 
-	if err := nodemanager.RunTaskOnNodes(itask, []string{hostconfig.Address}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, itask, []string{hostconfig.Address}); err != nil {
 		return fmt.Errorf("destroy infrastructure Task failed: %v", err)
 	}
 
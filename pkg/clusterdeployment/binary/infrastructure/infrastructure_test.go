@@ -16,7 +16,10 @@
 package infrastructure
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -24,6 +27,7 @@ import (
 	"isula.org/eggo/pkg/api"
 	"isula.org/eggo/pkg/utils/dependency"
 	"isula.org/eggo/pkg/utils/nodemanager"
+	"isula.org/eggo/pkg/utils/runner/runnertest"
 )
 
 type MockRunner struct {
@@ -34,11 +38,14 @@ func (m *MockRunner) Copy(src, dst string) error {
 	return nil
 }
 
+func (m *MockRunner) CopyDir(srcDir, dstDir string) error {
+	logrus.Infof("copy dir %s to %s", srcDir, dstDir)
+	return nil
+}
+
 func (m *MockRunner) RunCommand(cmd string) (string, error) {
 	logrus.Infof("run command: %s", cmd)
 	if cmd == fmt.Sprintf("sudo -E /bin/sh -c \"%s\"", dependency.PmTest) {
-		return "dpkg", nil
-	} else if cmd == fmt.Sprintf("sudo -E /bin/sh -c \"%s\"", dependency.PrmTest) {
 		return "apt", nil
 	}
 
@@ -70,7 +77,7 @@ func addNodes(hcfs []*api.HostConfig) error {
 	return nil
 }
 
-func TestPrepareInfrastructure(t *testing.T) {
+func TestPrepareInfrastructure(ctx context.Context, t *testing.T) {
 	ccfg := &api.ClusterConfig{
 		Nodes: []*api.HostConfig{
 			{
@@ -181,13 +188,151 @@ func TestPrepareInfrastructure(t *testing.T) {
 	if err := addNodes(ccfg.Nodes); err != nil {
 		t.Fatalf("add nodes failed: %v", err)
 	}
-	if err := NodeInfrastructureSetup(ccfg, ccfg.Nodes[0].Address, ccfg.Nodes[0].Type); err != nil {
+	if err := NodeInfrastructureSetup(ctx, ccfg, ccfg.Nodes[0].Address, ccfg.Nodes[0].Type); err != nil {
 		t.Fatalf("test NodeInfrastructureSetup failed: %v\n", err)
 	}
 
-	if err := NodeInfrastructureDestroy(ccfg, ccfg.Nodes[0]); err != nil {
+	if err := NodeInfrastructureDestroy(ctx, ccfg, ccfg.Nodes[0]); err != nil {
 		t.Fatalf("test NodeInfrastructureDestroy failed: %v\n", err)
 	}
 
 	nodemanager.UnRegisterAllNodes()
 }
+
+func TestMergeRoleInfra(t *testing.T) {
+	shared := &api.PackageConfig{Name: "kubernetes-client", Type: "repo"}
+	ccfg := &api.ClusterConfig{
+		RoleInfra: map[uint16]*api.RoleInfra{
+			api.Master: {
+				OpenPorts: []*api.OpenPorts{{Port: 6443, Protocol: "tcp"}, {Port: 10250, Protocol: "tcp"}},
+				Softwares: []*api.PackageConfig{shared, {Name: "kubernetes-master", Type: "repo"}},
+			},
+			api.Worker: {
+				OpenPorts: []*api.OpenPorts{{Port: 10250, Protocol: "tcp"}},
+				Softwares: []*api.PackageConfig{{Name: "kubernetes-client", Type: "repo"}, {Name: "kubernetes-node", Type: "repo"}},
+			},
+			api.ETCD: {
+				OpenPorts: []*api.OpenPorts{{Port: 10250, Protocol: "tcp"}},
+				Softwares: []*api.PackageConfig{{Name: "etcd", Type: "repo"}},
+			},
+		},
+	}
+
+	merged, err := mergeRoleInfra(ccfg, api.Master|api.Worker|api.ETCD)
+	if err != nil {
+		t.Fatalf("merge role infra failed: %v", err)
+	}
+	// default ports of etcd are 2379, 2380 and 2381
+	if len(merged.Softwares) != 4 || len(merged.OpenPorts) != 5 {
+		t.Fatalf("packages and ports shared by roles should be merged, get %d packages, %d ports",
+			len(merged.Softwares), len(merged.OpenPorts))
+	}
+
+	// ports of etcd cluster config are opened, with ports configured for role of etcd
+	ccfg.EtcdCluster = api.EtcdClusterConfig{ClientPort: 12379, PeerPort: 12380, MetricsPort: 12381}
+	if merged, err = mergeRoleInfra(ccfg, api.ETCD); err != nil {
+		t.Fatalf("merge role infra of etcd failed: %v", err)
+	}
+	if ports := getPorts(merged.OpenPorts); strings.Join(ports, " ") != "12379/tcp 12380/tcp 12381/tcp 10250/tcp" {
+		t.Fatalf("expect custom etcd ports opened, get: %v", ports)
+	}
+	if len(ccfg.RoleInfra[api.ETCD].OpenPorts) != 1 {
+		t.Fatalf("expect role infra of config not changed, get: %v", ccfg.RoleInfra[api.ETCD].OpenPorts)
+	}
+
+	if _, err = mergeRoleInfra(ccfg, api.LoadBalance); err == nil {
+		t.Fatalf("expect unregistered role infra failed")
+	}
+}
+
+func TestCheckInstalledK8sVersion(t *testing.T) {
+	r := &runnertest.Runner{
+		Strict: true,
+		Outputs: map[string]string{
+			"sudo -E /bin/sh -c \"kube-apiserver --version\"": "Kubernetes v1.20.2\n",
+			"sudo -E /bin/sh -c \"kubelet --version\"":        "Kubernetes v1.21.1\n",
+		},
+	}
+
+	if err := checkInstalledK8sVersion(r, api.Master|api.Worker, ""); err != nil {
+		t.Fatalf("version is not checked if not set, get: %v", err)
+	}
+	if err := checkInstalledK8sVersion(r, api.Master|api.ETCD, "v1.20.2"); err != nil {
+		t.Fatalf("check version of master failed: %v", err)
+	}
+	if err := checkInstalledK8sVersion(r, api.ETCD, "v1.20.2"); err != nil {
+		t.Fatalf("kubernetes version of etcd should not be checked: %v", err)
+	}
+	err := checkInstalledK8sVersion(r, api.Master|api.Worker, "v1.20.2")
+	if !errors.Is(err, ErrK8sVersionMismatch) {
+		t.Fatalf("expect version mismatch of kubelet, get: %v", err)
+	}
+	delete(r.Outputs, "sudo -E /bin/sh -c \"kubelet --version\"")
+	if err = checkInstalledK8sVersion(r, api.Worker, "v1.21.1"); err == nil || errors.Is(err, ErrK8sVersionMismatch) {
+		t.Fatalf("expect failure of get version, get: %v", err)
+	}
+}
+
+// newRecordRunner returns runner of node with dpkg and apt, same as MockRunner
+func newRecordRunner() *runnertest.Runner {
+	return &runnertest.Runner{Outputs: map[string]string{
+		fmt.Sprintf("sudo -E /bin/sh -c \"%s\"", dependency.PmTest):  "dpkg",
+		fmt.Sprintf("sudo -E /bin/sh -c \"%s\"", dependency.PrmTest): "apt",
+	}}
+}
+
+func TestSetupInfraTaskRun(t *testing.T) {
+	ccfg := &api.ClusterConfig{
+		PackageSrc: api.PackageSrcConfig{DstPath: "/root/.eggo/package"},
+		RoleInfra: map[uint16]*api.RoleInfra{
+			api.Master: {
+				OpenPorts: []*api.OpenPorts{{Port: 6443, Protocol: "tcp"}},
+				Softwares: []*api.PackageConfig{
+					{Name: "kubernetes-master", Type: "repo"},
+					{Name: "kubectl", Type: "bin", Dst: "/usr/bin"},
+				},
+			},
+			api.Worker: {
+				OpenPorts: []*api.OpenPorts{{Port: 10250, Protocol: "tcp"}, {Port: 8472, Protocol: "udp"}},
+				Softwares: []*api.PackageConfig{
+					{Name: "conntrack-tools", Type: "pkg"},
+					{Name: "10-calico.conflist", Type: "file", Dst: "/etc/cni/net.d"},
+				},
+			},
+		},
+	}
+	nodes := []*api.HostConfig{
+		{Name: "master0", Address: "192.168.0.1", Type: api.Master},
+		{Name: "worker0", Address: "192.168.0.2", Type: api.Worker},
+	}
+	expects := map[string][]string{
+		"master0": {"apt install -y kubernetes-master", "install -D -m 0755 kubectl /usr/bin/kubectl", "6443/tcp"},
+		"worker0": {"dpkg --force-all -i conntrack-tools*", "cp -r 10-calico.conflist /etc/cni/net.d/10-calico.conflist", "10250/tcp 8472/udp"},
+	}
+	unexpects := map[string][]string{
+		"master0": {"conntrack-tools", "10250/tcp"},
+		"worker0": {"kubernetes-master", "6443/tcp"},
+	}
+
+	for _, n := range nodes {
+		roleInfra, err := mergeRoleInfra(ccfg, n.Type)
+		if err != nil {
+			t.Fatalf("merge role infra of %s failed: %v", n.Name, err)
+		}
+		it := &SetupInfraTask{packageSrc: &ccfg.PackageSrc, roleInfra: roleInfra, roles: n.Type}
+		r := newRecordRunner()
+		if err := it.Run(r, n); err != nil {
+			t.Fatalf("setup infrastructure of %s failed: %v", n.Name, err)
+		}
+		for _, e := range expects[n.Name] {
+			if !r.Contains(e) {
+				t.Fatalf("expect %q run on %s, commands: %v", e, n.Name, r.Commands)
+			}
+		}
+		for _, e := range unexpects[n.Name] {
+			if r.Contains(e) {
+				t.Fatalf("unexpect %q run on %s", e, n.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,210 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: preflight check of package source archives
+ ******************************************************************************/
+
+package infrastructure
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"isula.org/eggo/pkg/api"
+)
+
+// ErrK8sVersionMismatch is wrapped by errors of kubernetes version of packages mismatch with config
+var ErrK8sVersionMismatch = errors.New("kubernetes version mismatch")
+
+var (
+	// archives which are checked success, value is kubernetes version in archive
+	checkedPackages = make(map[string]string)
+	checkedLock     sync.Mutex
+
+	// such as pkg/kubernetes-master-1.20.2-4.oe1.aarch64.rpm or pkg/kubernetes-node_1.20.2-00_amd64.deb
+	k8sPackageRegexp = regexp.MustCompile(`^kubernetes-(?:client|master|node|kubelet)[-_]v?(\d+\.\d+\.\d+)`)
+)
+
+func checkGzipArchive(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("not a gzip archive: %v", err)
+	}
+	defer gr.Close()
+
+	// read whole archive to find out truncated file
+	if _, err := io.Copy(ioutil.Discard, gr); err != nil {
+		return fmt.Errorf("corrupted gzip archive: %v", err)
+	}
+	return nil
+}
+
+// getArchiveK8sVersion returns version of kubernetes packages in archive, empty if not found
+func getArchiveK8sVersion(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("not a gzip archive: %v", err)
+	}
+	defer gr.Close()
+
+	versions := make(map[string]bool)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// not a tar archive, version is unknown
+			return "", nil
+		}
+		if m := k8sPackageRegexp.FindStringSubmatch(filepath.Base(hdr.Name)); m != nil {
+			versions[m[1]] = true
+		}
+	}
+
+	var found []string
+	for v := range versions {
+		found = append(found, v)
+	}
+	if len(found) > 1 {
+		sort.Strings(found)
+		return "", fmt.Errorf("multiple kubernetes versions %v in archive", found)
+	}
+	if len(found) == 0 {
+		return "", nil
+	}
+	return found[0], nil
+}
+
+func checkPackageArchive(path string, pkgType string) (string, error) {
+	checkedLock.Lock()
+	defer checkedLock.Unlock()
+	if version, ok := checkedPackages[path]; ok {
+		return version, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("not a regular file")
+	}
+
+	var version string
+	switch pkgType {
+	case "tar.gz", "":
+		if err := checkGzipArchive(path); err != nil {
+			return "", err
+		}
+		if version, err = getArchiveK8sVersion(path); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("cannot support package type %s", pkgType)
+	}
+
+	checkedPackages[path] = version
+	return version, nil
+}
+
+// CheckPackageSrc check package archives required by nodes are exist and match type of package source,
+// and kubernetes packages in archives of different arches are same version
+func CheckPackageSrc(pcfg *api.PackageSrcConfig, nodes []*api.HostConfig) error {
+	_, err := checkPackageSrc(pcfg, nodes)
+	return err
+}
+
+// CheckPackageSrcK8sVersion checks package archives as CheckPackageSrc, and kubernetes version
+// in archives must be expect version, unknown version of archives is checked on nodes after install
+func CheckPackageSrcK8sVersion(pcfg *api.PackageSrcConfig, nodes []*api.HostConfig, expect string) error {
+	version, err := checkPackageSrc(pcfg, nodes)
+	if err != nil {
+		return err
+	}
+	if expect == "" || version == "" {
+		return nil
+	}
+	if !api.MatchK8sVersion(expect, version) {
+		return fmt.Errorf("%w: version in package source is %s, expect %s", ErrK8sVersionMismatch, version, expect)
+	}
+	return nil
+}
+
+// checkPackageSrc returns kubernetes version in archives, empty if unknown
+func checkPackageSrc(pcfg *api.PackageSrcConfig, nodes []*api.HostConfig) (string, error) {
+	if pcfg == nil || len(pcfg.SrcPath) == 0 {
+		return "", nil
+	}
+
+	archNodes := make(map[string][]string)
+	for _, n := range nodes {
+		arch := strings.ToLower(n.Arch)
+		archNodes[arch] = append(archNodes[arch], n.Name)
+	}
+
+	var arches []string
+	for arch := range archNodes {
+		arches = append(arches, arch)
+	}
+	sort.Strings(arches)
+
+	var refArch, refVersion string
+	for _, arch := range arches {
+		names := strings.Join(archNodes[arch], ",")
+		if _, ok := pcfg.SrcPath[arch]; !ok {
+			return "", fmt.Errorf("no package source for arch %s, required by nodes: %s", arch, names)
+		}
+		path := LocalPackageSrcPath(pcfg, arch)
+		if path == "" {
+			return "", fmt.Errorf("package source %s for arch %s is not downloaded, required by nodes: %s", pcfg.SrcPath[arch], arch, names)
+		}
+		version, err := checkPackageArchive(path, pcfg.Type)
+		if err != nil {
+			return "", fmt.Errorf("invalid package source %s for arch %s, required by nodes: %s: %v", path, arch, names, err)
+		}
+		if version == "" {
+			continue
+		}
+		// kubernetes of all arches must be same version, avoid version skew between nodes
+		if refVersion != "" && version != refVersion {
+			return "", fmt.Errorf("kubernetes version %s in package source of arch %s mismatch with version %s of arch %s",
+				version, arch, refVersion, refArch)
+		}
+		refArch, refVersion = arch, version
+	}
+
+	return refVersion, nil
+}
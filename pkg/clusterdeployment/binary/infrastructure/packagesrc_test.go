@@ -0,0 +1,200 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for preflight check of package source archives
+ ******************************************************************************/
+
+package infrastructure
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func writeGzipFile(t *testing.T, path string, truncate bool) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(bytes.Repeat([]byte("eggo package"), 1024)); err != nil {
+		t.Fatalf("write gzip failed: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("close gzip failed: %v", err)
+	}
+	data := buf.Bytes()
+	if truncate {
+		data = data[:len(data)/2]
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("write file %s failed: %v", path, err)
+	}
+}
+
+func TestCheckPackageSrc(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "packages-x86_64.tar.gz")
+	truncated := filepath.Join(dir, "packages-truncated.tar.gz")
+	plain := filepath.Join(dir, "packages-plain.tar.gz")
+	writeGzipFile(t, good, false)
+	writeGzipFile(t, truncated, true)
+	if err := ioutil.WriteFile(plain, []byte("not a gzip file"), 0600); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+
+	nodes := []*api.HostConfig{
+		{Name: "master0", Arch: "x86_64"},
+		{Name: "worker0", Arch: "arm64"},
+		{Name: "worker1", Arch: "arm64"},
+	}
+
+	cases := []struct {
+		name    string
+		srcPath map[string]string
+		expect  string
+	}{
+		{
+			name:    "valid",
+			srcPath: map[string]string{"x86_64": good, "arm64": good},
+		},
+		{
+			name:    "missing arch",
+			srcPath: map[string]string{"x86_64": good},
+			expect:  "no package source for arch arm64, required by nodes: worker0,worker1",
+		},
+		{
+			name:    "not exist",
+			srcPath: map[string]string{"x86_64": good, "arm64": filepath.Join(dir, "notexist.tar.gz")},
+			expect:  "required by nodes: worker0,worker1",
+		},
+		{
+			name:    "not gzip",
+			srcPath: map[string]string{"x86_64": plain, "arm64": good},
+			expect:  "not a gzip archive",
+		},
+		{
+			name:    "truncated",
+			srcPath: map[string]string{"x86_64": truncated, "arm64": good},
+			expect:  "corrupted gzip archive",
+		},
+	}
+
+	for _, c := range cases {
+		err := CheckPackageSrc(&api.PackageSrcConfig{Type: "tar.gz", SrcPath: c.srcPath}, nodes)
+		if c.expect == "" {
+			if err != nil {
+				t.Fatalf("case %s: expect success, get: %v", c.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.expect) {
+			t.Fatalf("case %s: expect error contains %q, get: %v", c.name, c.expect, err)
+		}
+	}
+
+	if err := CheckPackageSrc(&api.PackageSrcConfig{Type: "zip", SrcPath: map[string]string{"x86_64": plain}}, nodes[:1]); err == nil {
+		t.Fatalf("unsupported package type should be invalid")
+	}
+	if err := CheckPackageSrc(&api.PackageSrcConfig{}, nodes); err != nil {
+		t.Fatalf("empty package source should be ignored: %v", err)
+	}
+}
+
+func writeTarGzFile(t *testing.T, path string, names []string) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(name))}); err != nil {
+			t.Fatalf("write tar header failed: %v", err)
+		}
+		if _, err := tw.Write([]byte(name)); err != nil {
+			t.Fatalf("write tar failed: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar failed: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("close gzip failed: %v", err)
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("write file %s failed: %v", path, err)
+	}
+}
+
+func TestCheckPackageSrcVersion(t *testing.T) {
+	dir := t.TempDir()
+	amd := filepath.Join(dir, "packages-amd64.tar.gz")
+	arm := filepath.Join(dir, "packages-arm64.tar.gz")
+	armSkew := filepath.Join(dir, "packages-arm64-skew.tar.gz")
+	mixed := filepath.Join(dir, "packages-mixed.tar.gz")
+	writeTarGzFile(t, amd, []string{"pkg/kubernetes-master-1.20.2-4.oe1.x86_64.rpm", "pkg/kubernetes-node-1.20.2-4.oe1.x86_64.rpm"})
+	writeTarGzFile(t, arm, []string{"pkg/kubernetes-node-1.20.2-4.oe1.aarch64.rpm", "pkg/etcd-3.4.14-2.aarch64.rpm"})
+	writeTarGzFile(t, armSkew, []string{"pkg/kubernetes-node-1.21.1-1.oe1.aarch64.rpm"})
+	writeTarGzFile(t, mixed, []string{"pkg/kubernetes-node-1.20.2-4.oe1.x86_64.rpm", "pkg/kubernetes-kubelet-1.21.1-1.oe1.x86_64.rpm"})
+
+	version, err := getArchiveK8sVersion(amd)
+	if err != nil || version != "1.20.2" {
+		t.Fatalf("expect version 1.20.2, get: %s, err: %v", version, err)
+	}
+
+	nodes := []*api.HostConfig{
+		{Name: "master0", Arch: "amd64"},
+		{Name: "worker0", Arch: "arm64"},
+	}
+	if err = CheckPackageSrc(&api.PackageSrcConfig{SrcPath: map[string]string{"amd64": amd, "arm64": arm}}, nodes); err != nil {
+		t.Fatalf("same kubernetes version of arches should be valid: %v", err)
+	}
+	err = CheckPackageSrc(&api.PackageSrcConfig{SrcPath: map[string]string{"amd64": amd, "arm64": armSkew}}, nodes)
+	if err == nil || !strings.Contains(err.Error(), "mismatch") {
+		t.Fatalf("expect version mismatch error, get: %v", err)
+	}
+	err = CheckPackageSrc(&api.PackageSrcConfig{SrcPath: map[string]string{"amd64": mixed}}, nodes[:1])
+	if err == nil || !strings.Contains(err.Error(), "multiple kubernetes versions") {
+		t.Fatalf("expect multiple versions error, get: %v", err)
+	}
+}
+
+func TestCheckPackageSrcK8sVersion(t *testing.T) {
+	dir := t.TempDir()
+	amd := filepath.Join(dir, "packages-amd64.tar.gz")
+	unknown := filepath.Join(dir, "packages-unknown.tar.gz")
+	writeTarGzFile(t, amd, []string{"pkg/kubernetes-master-1.20.2-4.oe1.x86_64.rpm", "pkg/kubernetes-node-1.20.2-4.oe1.x86_64.rpm"})
+	writeTarGzFile(t, unknown, []string{"pkg/etcd-3.4.14-2.x86_64.rpm"})
+
+	nodes := []*api.HostConfig{
+		{Name: "master0", Arch: "amd64"},
+	}
+	pcfg := &api.PackageSrcConfig{SrcPath: map[string]string{"amd64": amd}}
+	for _, v := range []string{"", "v1.20.2", "1.20.2"} {
+		if err := CheckPackageSrcK8sVersion(pcfg, nodes, v); err != nil {
+			t.Fatalf("version %q should match packages: %v", v, err)
+		}
+	}
+	err := CheckPackageSrcK8sVersion(pcfg, nodes, "v1.21.1")
+	if !errors.Is(err, ErrK8sVersionMismatch) || !strings.Contains(err.Error(), "1.20.2") {
+		t.Fatalf("expect version mismatch error, get: %v", err)
+	}
+
+	// version of packages is unknown, checked on nodes after install
+	pcfg = &api.PackageSrcConfig{SrcPath: map[string]string{"amd64": unknown}}
+	if err = CheckPackageSrcK8sVersion(pcfg, nodes, "v1.21.1"); err != nil {
+		t.Fatalf("unknown version of packages should not fail: %v", err)
+	}
+}
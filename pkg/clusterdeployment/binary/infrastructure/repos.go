@@ -0,0 +1,101 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo yum repos implement
+ ******************************************************************************/
+
+package infrastructure
+
+import (
+	"fmt"
+	"strings"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner"
+	"isula.org/eggo/pkg/utils/template"
+)
+
+const (
+	yumReposDir  = "/etc/yum.repos.d"
+	eggoRepoFile = "eggo.repo"
+)
+
+// repoFileContent returns content of yum repo file, repo id is prefixed by eggo
+func repoFileContent(repos []api.PackageRepo) string {
+	var sb strings.Builder
+	for i, r := range repos {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("[eggo-%s]\n", r.Name))
+		sb.WriteString(fmt.Sprintf("name=%s\n", r.Name))
+		sb.WriteString(fmt.Sprintf("baseurl=%s\n", r.BaseURL))
+		sb.WriteString("enabled=1\n")
+		if r.GPGKey == "" {
+			sb.WriteString("gpgcheck=0\n")
+			continue
+		}
+		sb.WriteString("gpgcheck=1\n")
+		sb.WriteString(fmt.Sprintf("gpgkey=%s\n", r.GPGKey))
+	}
+	return sb.String()
+}
+
+// reposShell writes repo file of eggo into repos dir; empty repos just remove the file
+func reposShell(dir string, repos []api.PackageRepo) (string, error) {
+	shell := `
+#!/bin/bash
+{{- if .Content }}
+if [ ! -d {{ .Dir }} ]; then
+	echo "{{ .Dir }} is not exist, repos are only supported by yum" 1>&2
+	exit 1
+fi
+cat > {{ .Dir }}/{{ .File }} << 'EOF'
+{{ .Content }}EOF
+if [ $? -ne 0 ]; then
+	echo "write {{ .Dir }}/{{ .File }} failed" 1>&2
+	exit 1
+fi
+chmod 0644 {{ .Dir }}/{{ .File }}
+{{- else }}
+rm -f {{ .Dir }}/{{ .File }}
+{{- end }}
+exit 0
+`
+	datastore := make(map[string]interface{})
+	datastore["Dir"] = dir
+	datastore["File"] = eggoRepoFile
+	datastore["Content"] = repoFileContent(repos)
+
+	return template.TemplateRender(shell, datastore)
+}
+
+func setRepos(r runner.Runner, repos []api.PackageRepo) error {
+	if len(repos) == 0 {
+		return nil
+	}
+	shell, err := reposShell(yumReposDir, repos)
+	if err != nil {
+		return err
+	}
+	_, err = r.RunShell(shell, "setRepos")
+	return err
+}
+
+func removeRepos(r runner.Runner) error {
+	shell, err := reposShell(yumReposDir, nil)
+	if err != nil {
+		return err
+	}
+	_, err = r.RunShell(shell, "removeRepos")
+	return err
+}
@@ -0,0 +1,86 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: eggo yum repos testcase
+ ******************************************************************************/
+
+package infrastructure
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func runReposShell(t *testing.T, dir string, repos []api.PackageRepo) error {
+	shell, err := reposShell(dir, repos)
+	if err != nil {
+		t.Fatalf("render repos shell failed: %v", err)
+	}
+	if output, err := exec.Command("/bin/sh", "-c", shell).CombinedOutput(); err != nil {
+		t.Logf("run repos shell failed: %v, output: %s", err, output)
+		return err
+	}
+	return nil
+}
+
+func TestRepos(t *testing.T) {
+	repos := []api.PackageRepo{
+		{Name: "everything", BaseURL: "http://mirror.local/openEuler/everything/x86_64/", GPGKey: "http://mirror.local/RPM-GPG-KEY"},
+		{Name: "update", BaseURL: "file:///mnt/update"},
+	}
+	content := "[eggo-everything]\n" +
+		"name=everything\n" +
+		"baseurl=http://mirror.local/openEuler/everything/x86_64/\n" +
+		"enabled=1\n" +
+		"gpgcheck=1\n" +
+		"gpgkey=http://mirror.local/RPM-GPG-KEY\n" +
+		"\n" +
+		"[eggo-update]\n" +
+		"name=update\n" +
+		"baseurl=file:///mnt/update\n" +
+		"enabled=1\n" +
+		"gpgcheck=0\n"
+	if got := repoFileContent(repos); got != content {
+		t.Fatalf("expect repo file:\n%s\nget:\n%s", content, got)
+	}
+	if got := repoFileContent(nil); got != "" {
+		t.Fatalf("expect empty repo file without repos, get: %s", got)
+	}
+
+	dir := t.TempDir()
+	repoFile := filepath.Join(dir, eggoRepoFile)
+	for i := 0; i < 2; i++ {
+		if err := runReposShell(t, dir, repos); err != nil {
+			t.Fatalf("set repos failed: %v", err)
+		}
+		data, err := ioutil.ReadFile(repoFile)
+		if err != nil || string(data) != content {
+			t.Fatalf("expect repo file:\n%s\nget:\n%s, err: %v", content, data, err)
+		}
+	}
+	// cleanup removes repo file of eggo
+	if err := runReposShell(t, dir, nil); err != nil {
+		t.Fatalf("remove repos failed: %v", err)
+	}
+	if _, err := os.Stat(repoFile); !os.IsNotExist(err) {
+		t.Fatalf("expect repo file removed, get: %v", err)
+	}
+	// repos dir is required
+	if err := runReposShell(t, filepath.Join(dir, "not-exist"), repos); err == nil {
+		t.Fatalf("expect set repos failed without repos dir")
+	}
+}
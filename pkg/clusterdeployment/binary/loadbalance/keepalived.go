@@ -0,0 +1,130 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: keepalived provides virtual ip of loadbalance
+ ******************************************************************************/
+
+package loadbalance
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment/binary/commontools"
+	"isula.org/eggo/pkg/utils/runner"
+	"isula.org/eggo/pkg/utils/template"
+)
+
+const (
+	KeepalivedSoftware   = "keepalived"
+	KeepalivedConfigPath = "/etc/keepalived/keepalived.conf"
+)
+
+func renderKeepalivedConfig(lbConfig *api.LoadBalancer) (string, error) {
+	// vip is released if nginx is not active
+	keepalivedConfig := `global_defs {
+    router_id eggo_{{ .vrid }}
+    enable_script_security
+    script_user root
+}
+
+vrrp_script check_nginx {
+    script "/usr/bin/systemctl is-active --quiet nginx"
+    interval 2
+    fall 3
+    rise 2
+}
+
+vrrp_instance VI_EGGO {
+    state MASTER
+    interface {{ .interface }}
+    virtual_router_id {{ .vrid }}
+    priority 100
+    advert_int 1
+    virtual_ipaddress {
+        {{ .vip }}
+    }
+    track_script {
+        check_nginx
+    }
+}
+`
+	datastore := map[string]interface{}{}
+	datastore["vip"] = lbConfig.VIP
+	datastore["interface"] = lbConfig.Interface
+	datastore["vrid"] = lbConfig.VRID
+	return template.TemplateRender(keepalivedConfig, datastore)
+}
+
+func checkVIPShell(vip string) (string, error) {
+	shell := `
+#!/bin/bash
+ip -o addr show | grep -q " {{ .vip }}/"
+if [ $? -eq 0 ]; then
+	echo "vip {{ .vip }} is already assigned to this node"
+	exit 1
+fi
+ping -c 1 -W 1 {{ .vip }} > /dev/null 2>&1
+if [ $? -eq 0 ]; then
+	echo "vip {{ .vip }} is already assigned to other host"
+	exit 1
+fi
+exit 0
+`
+	datastore := map[string]interface{}{}
+	datastore["vip"] = vip
+	return template.TemplateRender(shell, datastore)
+}
+
+// checkVIP checks vip is not assigned, before keepalived holds it
+func checkVIP(r runner.Runner, lbConfig *api.LoadBalancer) error {
+	shell, err := checkVIPShell(lbConfig.VIP)
+	if err != nil {
+		return err
+	}
+	if output, err := r.RunShell(shell, "checkVIP"); err != nil {
+		return fmt.Errorf("check vip %s failed: %v, %s", lbConfig.VIP, err, output)
+	}
+	return nil
+}
+
+func setupKeepalived(r runner.Runner, lbConfig *api.LoadBalancer) error {
+	if _, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"which %s\"", KeepalivedSoftware)); err != nil {
+		logrus.Errorf("check software: %s, failed: %v\n", KeepalivedSoftware, err)
+		return err
+	}
+
+	config, err := renderKeepalivedConfig(lbConfig)
+	if err != nil {
+		return err
+	}
+	configBase64 := base64.StdEncoding.EncodeToString([]byte(config))
+	if _, err = r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"mkdir -p /etc/keepalived && echo %s | base64 -d > %s\"",
+		configBase64, KeepalivedConfigPath)); err != nil {
+		return err
+	}
+
+	// keepalived service is installed by package
+	shell, err := commontools.GetSystemdServiceShell(KeepalivedSoftware, "", true)
+	if err != nil {
+		return err
+	}
+	if _, err = r.RunShell(shell, KeepalivedSoftware); err != nil {
+		return fmt.Errorf("start keepalived failed: %v", err)
+	}
+
+	logrus.Debugf("setup keepalived with vip %s success", lbConfig.VIP)
+	return nil
+}
@@ -0,0 +1,55 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for keepalived of loadbalance
+ ******************************************************************************/
+
+package loadbalance
+
+import (
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func TestRenderKeepalivedConfig(t *testing.T) {
+	lb := &api.LoadBalancer{IP: "192.168.0.10", Port: "8443", VIP: "192.168.0.100", Interface: "eth0", VRID: 51}
+	config, err := renderKeepalivedConfig(lb)
+	if err != nil {
+		t.Fatalf("render keepalived config failed: %v", err)
+	}
+
+	expects := []string{
+		"interface eth0\n",
+		"virtual_router_id 51\n",
+		"virtual_ipaddress {\n        192.168.0.100\n    }",
+		"script \"/usr/bin/systemctl is-active --quiet nginx\"",
+		"track_script {\n        check_nginx\n    }",
+	}
+	for _, e := range expects {
+		if !strings.Contains(config, e) {
+			t.Fatalf("expect %q in keepalived config:\n%s", e, config)
+		}
+	}
+}
+
+func TestCheckVIPShell(t *testing.T) {
+	shell, err := checkVIPShell("192.168.0.100")
+	if err != nil {
+		t.Fatalf("render check vip shell failed: %v", err)
+	}
+	// 192.168.0.1000 is not matched as 192.168.0.100
+	if !strings.Contains(shell, `grep -q " 192.168.0.100/"`) || !strings.Contains(shell, "ping -c 1 -W 1 192.168.0.100") {
+		t.Fatalf("invalid check vip shell:\n%s", shell)
+	}
+}
@@ -16,6 +16,7 @@
 package loadbalance
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"strings"
@@ -57,6 +58,12 @@ func (it *LoadBalanceTask) Run(r runner.Runner, hcg *api.HostConfig) error {
 		logrus.Errorf("check failed: %v", err)
 		return err
 	}
+	if it.lbConfig.VIP != "" {
+		if err := checkVIP(r, it.lbConfig); err != nil {
+			logrus.Errorf("check vip failed: %v", err)
+			return err
+		}
+	}
 
 	if _, err := r.RunCommand("sudo -E /bin/sh -c \"mkdir -p /etc/kubernetes\""); err != nil {
 		return fmt.Errorf("mkdir failed")
@@ -74,6 +81,14 @@ func (it *LoadBalanceTask) Run(r runner.Runner, hcg *api.HostConfig) error {
 		return err
 	}
 
+	// keepalived holds vip after nginx is ready
+	if it.lbConfig.VIP != "" {
+		if err := setupKeepalived(r, it.lbConfig); err != nil {
+			logrus.Errorf("setup keepalived failed: %v", err)
+			return err
+		}
+	}
+
 	logrus.Info("prepare loadbalancer success\n")
 	return nil
 }
@@ -161,7 +176,7 @@ func getModulePath(r runner.Runner) (string, error) {
 	return path, nil
 }
 
-func SetupLoadBalancer(config *api.ClusterConfig, lb *api.HostConfig) error {
+func SetupLoadBalancer(ctx context.Context, config *api.ClusterConfig, lb *api.HostConfig) error {
 	masterIPs := utils.GetMasterIPList(config)
 	if len(masterIPs) == 0 {
 		return fmt.Errorf("no master host found, can not setup loadbalance")
@@ -176,11 +191,11 @@ func SetupLoadBalancer(config *api.ClusterConfig, lb *api.HostConfig) error {
 		},
 	)
 
-	if err := nodemanager.RunTaskOnNodes(taskSetupLoadBalancer, []string{lb.Address}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, taskSetupLoadBalancer, []string{lb.Address}); err != nil {
 		return err
 	}
 
-	if err := nodemanager.WaitNodesFinish([]string{lb.Address}, time.Minute*2); err != nil {
+	if err := nodemanager.WaitNodesFinish(ctx, []string{lb.Address}, time.Minute*2); err != nil {
 		logrus.Errorf("wait to deploy loadbalancer finish failed: %v", err)
 		return err
 	}
@@ -222,7 +237,7 @@ func (it *UpdateLoadBalanceTask) Run(r runner.Runner, hcg *api.HostConfig) error
 	return nil
 }
 
-func UpdateLoadBalancer(config *api.ClusterConfig, lb *api.HostConfig) error {
+func UpdateLoadBalancer(ctx context.Context, config *api.ClusterConfig, lb *api.HostConfig) error {
 	// update loadbalance when join/drop master
 
 	masterIPs := utils.GetMasterIPList(config)
@@ -237,11 +252,11 @@ func UpdateLoadBalancer(config *api.ClusterConfig, lb *api.HostConfig) error {
 		},
 	)
 
-	if err := nodemanager.RunTaskOnNodes(taskUpdateLoadBalancer, []string{lb.Address}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, taskUpdateLoadBalancer, []string{lb.Address}); err != nil {
 		return err
 	}
 
-	if err := nodemanager.WaitNodesFinish([]string{lb.Address}, time.Minute*2); err != nil {
+	if err := nodemanager.WaitNodesFinish(ctx, []string{lb.Address}, time.Minute*2); err != nil {
 		logrus.Errorf("wait to update loadbalancer finish failed: %v", err)
 		return err
 	}
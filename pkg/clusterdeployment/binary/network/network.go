@@ -15,6 +15,8 @@
 package network
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 	"path/filepath"
 	"time"
@@ -33,6 +35,32 @@ const (
 	defaultNetwork = "calico"
 )
 
+// preparePluginYaml returns yaml path of network plugin, user-supplied yaml has priority,
+// otherwise manifest of builtin plugin is rendered into addons dir of master
+func preparePluginYaml(r runner.Runner, plugin string, cluster *api.ClusterConfig) (string, error) {
+	if f, ok := cluster.Network.PluginArgs[constants.NetworkPluginArgKeyYamlPath]; ok {
+		return f, nil
+	}
+	pluginYaml := filepath.Join(constants.DefaultK8SAddonsDir, fmt.Sprintf("%s.yaml", plugin))
+	if !IsBuiltinPlugin(plugin) {
+		return pluginYaml, nil
+	}
+
+	manifest, err := renderPluginManifest(cluster)
+	if err != nil {
+		logrus.Errorf("render manifest of network plugin %s failed: %v", plugin, err)
+		return "", err
+	}
+	manifestBase64 := base64.StdEncoding.EncodeToString([]byte(manifest))
+	shell := fmt.Sprintf("sudo -E /bin/sh -c \"mkdir -p %s && echo %s | base64 -d > %s\"",
+		constants.DefaultK8SAddonsDir, manifestBase64, pluginYaml)
+	if _, err = r.RunCommand(shell); err != nil {
+		logrus.Errorf("write manifest of network plugin %s failed: %v", plugin, err)
+		return "", err
+	}
+	return pluginYaml, nil
+}
+
 type ApplyNetworkTask struct {
 	Cluster *api.ClusterConfig
 }
@@ -51,12 +79,12 @@ func applyNetwork(r runner.Runner, cluster *api.ClusterConfig) error {
 		plugin = cluster.Network.Plugin
 	}
 	// TODO: network yaml maybe need to store in a excusive dir
-	pluginYaml := filepath.Join(constants.DefaultK8SAddonsDir, fmt.Sprintf("%s.yaml", plugin))
-	if f, ok := cluster.Network.PluginArgs[constants.NetworkPluginArgKeyYamlPath]; ok {
-		pluginYaml = f
+	pluginYaml, err := preparePluginYaml(r, plugin, cluster)
+	if err != nil {
+		return err
 	}
 
-	err := kubectl.OperatorByYaml(r, kubectl.ApplyOpKey, pluginYaml, cluster)
+	err = kubectl.OperatorByYaml(r, kubectl.ApplyOpKey, pluginYaml, cluster)
 	if err != nil {
 		return err
 	}
@@ -64,7 +92,7 @@ func applyNetwork(r runner.Runner, cluster *api.ClusterConfig) error {
 	return nil
 }
 
-func SetupNetwork(cluster *api.ClusterConfig) error {
+func SetupNetwork(ctx context.Context, cluster *api.ClusterConfig) error {
 	if cluster == nil {
 		return fmt.Errorf("invalid cluster config")
 	}
@@ -76,11 +104,11 @@ func SetupNetwork(cluster *api.ClusterConfig) error {
 		}
 	}
 
-	useMaster, err := nodemanager.RunTaskOnOneNode(t, masters)
+	useMaster, err := nodemanager.RunTaskOnOneNode(ctx, t, masters)
 	if err != nil {
 		return err
 	}
-	err = nodemanager.WaitNodesFinish([]string{useMaster}, time.Minute*constants.DefaultTaskWaitMinutes)
+	err = nodemanager.WaitNodesFinish(ctx, []string{useMaster}, time.Minute*constants.DefaultTaskWaitMinutes)
 	if err != nil {
 		return err
 	}
@@ -105,12 +133,12 @@ func deleteNetwork(r runner.Runner, cluster *api.ClusterConfig) error {
 	if cluster.Network.Plugin != "" {
 		plugin = cluster.Network.Plugin
 	}
-	pluginYaml := filepath.Join(constants.DefaultK8SAddonsDir, fmt.Sprintf("%s.yaml", plugin))
-	if f, ok := cluster.Network.PluginArgs[constants.NetworkPluginArgKeyYamlPath]; ok {
-		pluginYaml = f
+	pluginYaml, err := preparePluginYaml(r, plugin, cluster)
+	if err != nil {
+		return err
 	}
 
-	err := kubectl.OperatorByYaml(r, kubectl.DeleteOpKey, pluginYaml, cluster)
+	err = kubectl.OperatorByYaml(r, kubectl.DeleteOpKey, pluginYaml, cluster)
 	if err != nil {
 		return err
 	}
@@ -118,7 +146,7 @@ func deleteNetwork(r runner.Runner, cluster *api.ClusterConfig) error {
 	return nil
 }
 
-func CleanupNetwork(cluster *api.ClusterConfig) error {
+func CleanupNetwork(ctx context.Context, cluster *api.ClusterConfig) error {
 	if cluster == nil {
 		return fmt.Errorf("invalid cluster config")
 	}
@@ -130,11 +158,11 @@ func CleanupNetwork(cluster *api.ClusterConfig) error {
 		}
 	}
 
-	useMaster, err := nodemanager.RunTaskOnOneNode(t, masters)
+	useMaster, err := nodemanager.RunTaskOnOneNode(ctx, t, masters)
 	if err != nil {
 		return err
 	}
-	err = nodemanager.WaitNodesFinish([]string{useMaster}, time.Minute*constants.DefaultTaskWaitMinutes)
+	err = nodemanager.WaitNodesFinish(ctx, []string{useMaster}, time.Minute*constants.DefaultTaskWaitMinutes)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,134 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: builtin network plugins
+ ******************************************************************************/
+
+package network
+
+import (
+	"fmt"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/template"
+)
+
+const (
+	PluginFlannel = "flannel"
+	PluginCilium  = "cilium"
+
+	PluginArgKeyBackend       = "Backend"
+	PluginArgKeyTunnel        = "Tunnel"
+	PluginArgKeyImage         = "Image"
+	PluginArgKeyOperatorImage = "OperatorImage"
+
+	defaultCniBinDir  = "/opt/cni/bin"
+	defaultCniConfDir = "/etc/cni/net.d"
+)
+
+type builtinPlugin struct {
+	template string
+	// required plugin args and their valid values
+	requiredArgs map[string][]string
+	// optional image args and their default images
+	images map[string]string
+}
+
+var builtinPlugins = map[string]builtinPlugin{
+	PluginFlannel: {
+		template: flannelTemplate,
+		requiredArgs: map[string][]string{
+			PluginArgKeyBackend: {"vxlan", "host-gw"},
+		},
+		images: map[string]string{
+			PluginArgKeyImage: defaultFlannelImage,
+		},
+	},
+	PluginCilium: {
+		template: ciliumTemplate,
+		requiredArgs: map[string][]string{
+			PluginArgKeyTunnel: {"vxlan", "geneve", "disabled"},
+		},
+		images: map[string]string{
+			PluginArgKeyImage:         defaultCiliumImage,
+			PluginArgKeyOperatorImage: defaultCiliumOperatorImage,
+		},
+	},
+}
+
+func IsBuiltinPlugin(plugin string) bool {
+	_, ok := builtinPlugins[plugin]
+	return ok
+}
+
+func CheckPluginArgs(plugin string, args map[string]string) error {
+	p, ok := builtinPlugins[plugin]
+	if !ok {
+		return fmt.Errorf("unsupport builtin network plugin: %s", plugin)
+	}
+	for key, valids := range p.requiredArgs {
+		val, ok := args[key]
+		if !ok {
+			return fmt.Errorf("plugin-args %s is required by network plugin %s", key, plugin)
+		}
+		found := false
+		for _, v := range valids {
+			if v == val {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid plugin-args %s: %s of network plugin %s, valid values: %v", key, val, plugin, valids)
+		}
+	}
+	return nil
+}
+
+func renderPluginManifest(cluster *api.ClusterConfig) (string, error) {
+	plugin := cluster.Network.Plugin
+	p, ok := builtinPlugins[plugin]
+	if !ok {
+		return "", fmt.Errorf("unsupport builtin network plugin: %s", plugin)
+	}
+	if err := CheckPluginArgs(plugin, cluster.Network.PluginArgs); err != nil {
+		return "", err
+	}
+	if cluster.Network.PodCIDR == "" {
+		return "", fmt.Errorf("pod cidr is required by network plugin %s", plugin)
+	}
+
+	datastore := map[string]interface{}{}
+	datastore["PodCIDR"] = cluster.Network.PodCIDR
+	datastore["ClusterName"] = cluster.Name
+	datastore["CniBinDir"] = defaultCniBinDir
+	datastore["CniConfDir"] = defaultCniConfDir
+	if kc := cluster.WorkerConfig.KubeletConf; kc != nil {
+		if kc.CniBinDir != "" {
+			datastore["CniBinDir"] = kc.CniBinDir
+		}
+		if kc.CniConfDir != "" {
+			datastore["CniConfDir"] = kc.CniConfDir
+		}
+	}
+	for key := range p.requiredArgs {
+		datastore[key] = cluster.Network.PluginArgs[key]
+	}
+	for key, image := range p.images {
+		if v, ok := cluster.Network.PluginArgs[key]; ok && v != "" {
+			image = v
+		}
+		datastore[key] = cluster.GetImage(image)
+	}
+
+	return template.TemplateRender(p.template, datastore)
+}
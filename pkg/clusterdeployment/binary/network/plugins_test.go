@@ -0,0 +1,90 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: builtin network plugins testcase
+ ******************************************************************************/
+
+package network
+
+import (
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func TestCheckPluginArgs(t *testing.T) {
+	cases := []struct {
+		plugin string
+		args   map[string]string
+		valid  bool
+	}{
+		{PluginFlannel, map[string]string{PluginArgKeyBackend: "vxlan"}, true},
+		{PluginFlannel, map[string]string{PluginArgKeyBackend: "udp"}, false},
+		{PluginFlannel, nil, false},
+		{PluginCilium, map[string]string{PluginArgKeyTunnel: "geneve"}, true},
+		{PluginCilium, map[string]string{PluginArgKeyBackend: "vxlan"}, false},
+		{"calico", map[string]string{}, false},
+	}
+	for _, c := range cases {
+		err := CheckPluginArgs(c.plugin, c.args)
+		if (err == nil) != c.valid {
+			t.Fatalf("check plugin %s with args %v expect valid %v, got err: %v", c.plugin, c.args, c.valid, err)
+		}
+	}
+}
+
+func TestRenderPluginManifest(t *testing.T) {
+	cluster := &api.ClusterConfig{
+		Name:            "test-cluster",
+		ImageRepository: "hub.example.com",
+		Network: api.NetworkConfig{
+			PodCIDR:    "10.244.0.0/16",
+			Plugin:     PluginFlannel,
+			PluginArgs: map[string]string{PluginArgKeyBackend: "host-gw"},
+		},
+		WorkerConfig: api.WorkerConfig{
+			KubeletConf: &api.Kubelet{CniConfDir: "/etc/test/net.d"},
+		},
+	}
+	manifest, err := renderPluginManifest(cluster)
+	if err != nil {
+		t.Fatalf("render flannel manifest failed: %v", err)
+	}
+	for _, expect := range []string{`"Network": "10.244.0.0/16"`, `"Type": "host-gw"`,
+		"image: hub.example.com/coreos/flannel:v0.14.0", "path: /etc/test/net.d"} {
+		if !strings.Contains(manifest, expect) {
+			t.Fatalf("flannel manifest does not contain: %s", expect)
+		}
+	}
+
+	cluster.Network.Plugin = PluginCilium
+	cluster.Network.PluginArgs = map[string]string{
+		PluginArgKeyTunnel: "vxlan",
+		PluginArgKeyImage:  "cilium/cilium:test",
+	}
+	manifest, err = renderPluginManifest(cluster)
+	if err != nil {
+		t.Fatalf("render cilium manifest failed: %v", err)
+	}
+	for _, expect := range []string{`cluster-pool-ipv4-cidr: "10.244.0.0/16"`, "tunnel: vxlan",
+		"image: hub.example.com/cilium/cilium:test", "image: hub.example.com/cilium/operator-generic:v1.10.5"} {
+		if !strings.Contains(manifest, expect) {
+			t.Fatalf("cilium manifest does not contain: %s", expect)
+		}
+	}
+
+	cluster.Network.PodCIDR = ""
+	if _, err = renderPluginManifest(cluster); err == nil {
+		t.Fatalf("render cilium manifest without pod cidr should failed")
+	}
+}
@@ -0,0 +1,189 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: upgrade kubernetes components of node
+ ******************************************************************************/
+
+package upgradecluster
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment/binary/commontools"
+	"isula.org/eggo/pkg/clusterdeployment/binary/controlplane"
+	"isula.org/eggo/pkg/clusterdeployment/binary/infrastructure"
+	"isula.org/eggo/pkg/constants"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/kubectl"
+	"isula.org/eggo/pkg/utils/nodemanager"
+	"isula.org/eggo/pkg/utils/task"
+)
+
+const (
+	drainTimeout = "300s"
+)
+
+var (
+	masterServices = []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler"}
+	workerServices = []string{"kubelet", "kube-proxy"}
+)
+
+func nodeServices(node *api.HostConfig) []string {
+	var services []string
+	if utils.IsType(node.Type, api.Master) {
+		services = append(services, masterServices...)
+	}
+	if utils.IsType(node.Type, api.Worker) {
+		services = append(services, workerServices...)
+	}
+	return services
+}
+
+func runShellOnNodes(ctx context.Context, name, shell string, nodes []string, timeout time.Duration) error {
+	t := task.NewTaskInstance(
+		&commontools.RunShellTask{
+			ShellName: name,
+			Shell:     shell,
+		},
+	)
+	if err := nodemanager.RunTaskOnNodes(ctx, t, nodes); err != nil {
+		return err
+	}
+	return nodemanager.WaitNodesFinish(ctx, nodes, timeout)
+}
+
+// run kubectl on other master if possible, because apiserver of upgrading master will be restarted
+func kubectlNode(ccfg *api.ClusterConfig, node *api.HostConfig) string {
+	for _, n := range ccfg.Nodes {
+		if utils.IsType(n.Type, api.Master) && n.Address != node.Address {
+			return n.Address
+		}
+	}
+	return node.Address
+}
+
+func drainNode(ctx context.Context, ccfg *api.ClusterConfig, node *api.HostConfig) error {
+	shell := fmt.Sprintf(`#!/bin/bash
+export KUBECONFIG=%s
+kubectl drain %s --ignore-daemonsets --delete-emptydir-data --force --timeout=%s
+`, filepath.Join(ccfg.GetConfigDir(), constants.KubeConfigFileNameAdmin), node.Name, drainTimeout)
+	return runShellOnNodes(ctx, "drainNode", shell, []string{kubectlNode(ccfg, node)}, time.Minute*constants.DefaultTaskWaitMinutes)
+}
+
+func stopServices(ctx context.Context, node *api.HostConfig) error {
+	shell := fmt.Sprintf(`#!/bin/bash
+systemctl stop %s
+`, strings.Join(nodeServices(node), " "))
+	return runShellOnNodes(ctx, "stopK8sServices", shell, []string{node.Address}, time.Minute*constants.DefaultTaskWaitMinutes)
+}
+
+func startServices(ctx context.Context, node *api.HostConfig) error {
+	services := nodeServices(node)
+	shell := fmt.Sprintf(`#!/bin/bash
+systemctl daemon-reload
+systemctl restart %s
+[[ $? -ne 0 ]] && exit 1
+for s in %s; do
+	systemctl is-active $s > /dev/null || exit 1
+done
+exit 0
+`, strings.Join(services, " "), strings.Join(services, " "))
+	return runShellOnNodes(ctx, "startK8sServices", shell, []string{node.Address}, time.Minute*constants.DefaultTaskWaitMinutes)
+}
+
+// wait kube-apiserver of master ready to serve, by readyz of its local endpoint
+func waitAPIServerReady(ctx context.Context, ccfg *api.ClusterConfig, node *api.HostConfig, timeout time.Duration) error {
+	shell := fmt.Sprintf(`#!/bin/bash
+export KUBECONFIG=%s
+end=$((SECONDS + %d))
+while [ $SECONDS -lt $end ]; do
+	kubectl --server=%s get --raw=/readyz > /dev/null 2>&1 && exit 0
+	sleep 2
+done
+echo "kube-apiserver is not ready in %s" 1>&2
+exit 1
+`, filepath.Join(ccfg.GetConfigDir(), constants.KubeConfigFileNameAdmin), int(timeout.Seconds()), controlplane.LocalEndpoint, timeout.String())
+	// wait shell a little longer than its own timeout
+	return runShellOnNodes(ctx, "waitAPIServerReady", shell, []string{node.Address}, timeout+time.Minute)
+}
+
+// install binaries of target version by infrastructure with package source of upgrade
+func installPackages(ctx context.Context, ccfg *api.ClusterConfig, conf *api.UpgradeConfig, node *api.HostConfig) error {
+	upgradeCfg := *ccfg
+	upgradeCfg.PackageSrc = conf.PackageSrc
+	if upgradeCfg.PackageSrc.DstPath == "" {
+		upgradeCfg.PackageSrc.DstPath = ccfg.PackageSrc.DstPath
+	}
+	for _, role := range []uint16{api.Master, api.Worker} {
+		if !utils.IsType(node.Type, role) {
+			continue
+		}
+		if err := infrastructure.NodeInfrastructureSetup(ctx, &upgradeCfg, node.Address, role); err != nil {
+			return err
+		}
+	}
+	return nodemanager.WaitNodesFinish(ctx, []string{node.Address}, time.Minute*constants.DefaultTaskWaitMinutes)
+}
+
+// UpgradeNode upgrade kubernetes components of node to target version:
+// drain, stop services, replace binaries, restart services, wait ready and uncordon;
+// kube-apiserver of master must be ready before node is ready, so next master is upgraded
+// only if this one serves again; if node is not ready in timeout, it will be keep cordoned
+func UpgradeNode(ctx context.Context, ccfg *api.ClusterConfig, conf *api.UpgradeConfig, node *api.HostConfig) error {
+	isWorker := utils.IsType(node.Type, api.Worker)
+	// master without kubelet is not a kubernetes node, nothing to drain
+	if isWorker {
+		if err := drainNode(ctx, ccfg, node); err != nil {
+			return fmt.Errorf("drain node %s failed: %v", node.Name, err)
+		}
+	}
+
+	if err := stopServices(ctx, node); err != nil {
+		return fmt.Errorf("stop services of node %s failed: %v", node.Name, err)
+	}
+
+	if err := installPackages(ctx, ccfg, conf, node); err != nil {
+		return fmt.Errorf("install packages of %s on node %s failed: %v", conf.TargetVersion, node.Name, err)
+	}
+
+	if err := startServices(ctx, node); err != nil {
+		return fmt.Errorf("start services of node %s failed: %v", node.Name, err)
+	}
+
+	if utils.IsType(node.Type, api.Master) {
+		if err := waitAPIServerReady(ctx, ccfg, node, conf.NodeReadyTimeout); err != nil {
+			logrus.Errorf("kube-apiserver of master %s is not ready after upgrade, stop upgrading", node.Name)
+			return err
+		}
+	}
+	if !isWorker {
+		return nil
+	}
+
+	if err := kubectl.WaitNodeReady(ctx, ccfg.Name, node.Name, conf.TargetVersion, conf.NodeReadyTimeout); err != nil {
+		logrus.Errorf("node %s is not ready after upgrade, keep it cordoned", node.Name)
+		return err
+	}
+
+	if err := kubectl.SetNodeUnschedulable(ccfg.Name, node.Name, false); err != nil {
+		return fmt.Errorf("uncordon node %s failed: %v", node.Name, err)
+	}
+
+	return nil
+}
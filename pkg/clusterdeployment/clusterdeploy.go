@@ -16,6 +16,7 @@
 package clusterdeployment
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sync"
@@ -30,8 +31,22 @@ import (
 	"isula.org/eggo/pkg/utils"
 	"isula.org/eggo/pkg/utils/certs"
 	"isula.org/eggo/pkg/utils/nodemanager"
+	"isula.org/eggo/pkg/utils/progress"
 )
 
+// CreateClusterPhases is count of phases reported by progress of creating cluster
+const CreateClusterPhases = 8
+
+func nodeAddresses(nodes ...*api.HostConfig) []string {
+	var ids []string
+	for _, n := range nodes {
+		if n != nil {
+			ids = append(ids, n.Address)
+		}
+	}
+	return ids
+}
+
 func splitNodes(nodes []*api.HostConfig) (*api.HostConfig, []*api.HostConfig, []*api.HostConfig, []string) {
 	var lb *api.HostConfig
 	var masters []*api.HostConfig
@@ -82,25 +97,25 @@ func approveServingCsr(cc *api.ClusterConfig, nodes []*api.HostConfig) {
 	}
 }
 
-func doJoinNodeOfCluster(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, masters, workers []*api.HostConfig) ([]string, []*api.HostConfig, []*api.HostConfig) {
+func doJoinNodeOfCluster(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, masters, workers []*api.HostConfig) ([]string, []*api.HostConfig, []*api.HostConfig) {
 	var joinedNodeIDs []string
 	var joinedNodes, failedNodes []*api.HostConfig
 	for _, node := range workers {
-		if err := handler.ClusterNodeJoin(node); err != nil {
+		if err := handler.ClusterNodeJoin(ctx, node); err != nil {
 			failedNodes = append(failedNodes, node)
 			continue
 		}
 		joinedNodeIDs = append(joinedNodeIDs, node.Address)
 	}
 	for _, node := range masters {
-		if err := handler.ClusterNodeJoin(node); err != nil {
+		if err := handler.ClusterNodeJoin(ctx, node); err != nil {
 			failedNodes = append(failedNodes, node)
 			continue
 		}
 		joinedNodeIDs = append(joinedNodeIDs, node.Address)
 	}
 	// wait all nodes ready
-	if err := nodemanager.WaitNodesFinishWithProgress(joinedNodeIDs,
+	if err := nodemanager.WaitNodesFinishWithProgress(ctx, joinedNodeIDs,
 		time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		tFailedNodes, successNodes := nodemanager.CheckNodesStatus(joinedNodeIDs)
 		// update joined and failed nodes
@@ -125,7 +140,7 @@ func doJoinNodeOfCluster(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig
 	return joinedNodeIDs, joinedNodes, failedNodes
 }
 
-func doCreateCluster(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, cstatus *api.ClusterStatus) ([]*api.HostConfig, error) {
+func doCreateCluster(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, cstatus *api.ClusterStatus) ([]*api.HostConfig, error) {
 	loadbalancer, masters, workers, etcdNodes := splitNodes(cc.Nodes)
 
 	if len(masters) == 0 {
@@ -139,56 +154,61 @@ func doCreateCluster(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, cs
 	masters = masters[1:]
 
 	// Step1: setup infrastructure for all nodes in the cluster
-	for _, n := range cc.Nodes {
-		if err = handler.MachineInfraSetup(n); err != nil {
-			return nil, err
-		}
+	progress.StartPhase("infrastructure", "nodes", nodeAddresses(cc.Nodes...)...)
+	if err = setupNodesInfra(ctx, handler, cc); err != nil {
+		return nil, err
 	}
 
 	// Step2: run precreate cluster hooks
-	if err = handler.PreCreateClusterHooks(); err != nil {
+	progress.StartPhase("precreate-hooks", "")
+	if err = handler.PreCreateClusterHooks(ctx); err != nil {
 		return nil, err
 	}
 
 	// Step3: setup etcd cluster
+	progress.StartPhase("etcd", "etcds", etcdNodes...)
 	// wait infrastructure task success on nodes of etcd cluster
-	if err = nodemanager.WaitNodesFinishWithProgress(etcdNodes,
+	if err = nodemanager.WaitNodesFinishWithProgress(ctx, etcdNodes,
 		time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		return nil, err
 	}
-	if err = handler.EtcdClusterSetup(); err != nil {
+	if err = handler.EtcdClusterSetup(ctx); err != nil {
 		return nil, err
 	}
 
 	// Step4: setup loadbalance for cluster
-	if err = handler.LoadBalancerSetup(loadbalancer); err != nil {
+	progress.StartPhase("loadbalance", "loadbalances", nodeAddresses(loadbalancer)...)
+	if err = handler.LoadBalancerSetup(ctx, loadbalancer); err != nil {
 		return nil, err
 	}
 
 	// Step5: setup control plane for cluster
-	if err = handler.ClusterControlPlaneInit(controlPlaneNode); err != nil {
+	progress.StartPhase("control-plane", "masters", controlPlaneNode.Address)
+	if err = handler.ClusterControlPlaneInit(ctx, controlPlaneNode); err != nil {
 		return nil, err
 	}
 	// wait controlplane setup task success
-	if err = nodemanager.WaitNodesFinish([]string{controlPlaneNode.Address},
+	if err = nodemanager.WaitNodesFinish(ctx, []string{controlPlaneNode.Address},
 		time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		return nil, err
 	}
 	if utils.IsType(controlPlaneNode.Type, api.Worker) {
 		controlPlaneNode.Type = utils.ClearType(controlPlaneNode.Type, api.Master)
-		if err = handler.ClusterNodeJoin(controlPlaneNode); err != nil {
+		if err = handler.ClusterNodeJoin(ctx, controlPlaneNode); err != nil {
 			return nil, err
 		}
 	}
 
 	// Step6: setup left nodes for cluster
-	joinedNodeIDs, joinedNodes, failedNodes := doJoinNodeOfCluster(handler, cc, masters, workers)
+	progress.StartPhase("join", "nodes", append(nodeAddresses(masters...), nodeAddresses(workers...)...)...)
+	joinedNodeIDs, joinedNodes, failedNodes := doJoinNodeOfCluster(ctx, handler, cc, masters, workers)
 	if len(joinedNodeIDs) == 0 {
 		logrus.Warnln("all join nodes failed")
 	}
 
 	// Step7: setup addons for cluster
-	if err = handler.AddonsSetup(); err != nil {
+	progress.StartPhase("addons", "")
+	if err = handler.AddonsSetup(ctx); err != nil {
 		return nil, err
 	}
 
@@ -196,11 +216,12 @@ func doCreateCluster(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, cs
 	approveServingCsr(cc, append(joinedNodes, controlPlaneNode))
 
 	// Step9: run postcreate cluster hooks
-	if err = handler.PostCreateClusterHooks(cc.Nodes); err != nil {
+	progress.StartPhase("postcreate-hooks", "nodes", append(joinedNodeIDs, controlPlaneNode.Address)...)
+	if err = handler.PostCreateClusterHooks(ctx, cc.Nodes); err != nil {
 		return nil, err
 	}
 
-	if err = nodemanager.WaitNodesFinishWithProgress(append(joinedNodeIDs, controlPlaneNode.Address),
+	if err = nodemanager.WaitNodesFinishWithProgress(ctx, append(joinedNodeIDs, controlPlaneNode.Address),
 		time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		return nil, err
 	}
@@ -214,34 +235,34 @@ func doCreateCluster(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, cs
 	return failedNodes, nil
 }
 
-func rollbackFailedNoeds(handler api.ClusterDeploymentAPI, nodes []*api.HostConfig) {
+func rollbackFailedNoeds(ctx context.Context, handler api.ClusterDeploymentAPI, nodes []*api.HostConfig) {
 	if nodes == nil {
 		return
 	}
 	var rollIDs []string
 	for _, n := range nodes {
 		// do best to cleanup, if error, just ignore
-		if terr := handler.ClusterNodeCleanup(n, n.Type); terr != nil {
+		if terr := handler.ClusterNodeCleanup(ctx, n, n.Type); terr != nil {
 			logrus.Warnf("cluster node cleanup failed: %v", terr)
 		}
 
-		if terr := handler.MachineInfraDestroy(n); terr != nil {
+		if terr := handler.MachineInfraDestroy(ctx, n); terr != nil {
 			logrus.Warnf("machine infrastructure destroy failed: %v", terr)
 		}
 
-		if terr := handler.CleanupLastStep(n.Name); terr != nil {
+		if terr := handler.CleanupLastStep(ctx, n.Name); terr != nil {
 			logrus.Warnf("cleanup last step failed: %v", terr)
 		}
 		rollIDs = append(rollIDs, n.Address)
 	}
 
-	if err := nodemanager.WaitNodesFinishWithProgress(rollIDs,
+	if err := nodemanager.WaitNodesFinishWithProgress(ctx, rollIDs,
 		time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		logrus.Warnf("rollback failed: %v", err)
 	}
 }
 
-func CreateCluster(cc *api.ClusterConfig, deployEnableRollback bool) (api.ClusterStatus, error) {
+func CreateCluster(ctx context.Context, cc *api.ClusterConfig, deployEnableRollback bool) (api.ClusterStatus, error) {
 	cstatus := api.ClusterStatus{
 		StatusOfNodes: make(map[string]bool),
 	}
@@ -266,12 +287,23 @@ func CreateCluster(cc *api.ClusterConfig, deployEnableRollback bool) (api.Cluste
 		return cstatus, err
 	}
 
-	failedNodes, err := doCreateCluster(handler, cc, &cstatus)
+	failedNodes, err := doCreateCluster(ctx, handler, cc, &cstatus)
 	if err != nil {
-		doRemoveCluster(handler, cc)
+		if ctx.Err() != nil {
+			// all tasks will be rejected after ctx is done, user should cleanup cluster by hand
+			logrus.Warnf("[cluster] create cluster: %s is interrupted, please run 'eggo cleanup' to cleanup it", cc.Name)
+			cstatus.Message = err.Error()
+			return cstatus, err
+		}
+		doRemoveCluster(ctx, handler, cc)
 		if terr := os.RemoveAll(api.GetClusterHomePath(cc.Name)); terr != nil {
 			logrus.Warnf("[cluster] cleanup eggo config directory failed: %v", terr)
 		}
+		if cc.KubeConfigOut != "" {
+			if terr := os.Remove(cc.KubeConfigOut); terr != nil && !os.IsNotExist(terr) {
+				logrus.Warnf("[cluster] cleanup admin kubeconfig failed: %v", terr)
+			}
+		}
 
 		logrus.Warnf("rollbacked cluster: %s", cc.Name)
 		cstatus.Message = err.Error()
@@ -279,7 +311,7 @@ func CreateCluster(cc *api.ClusterConfig, deployEnableRollback bool) (api.Cluste
 	}
 	// rollback failed nodes
 	if deployEnableRollback {
-		rollbackFailedNoeds(handler, failedNodes)
+		rollbackFailedNoeds(ctx, handler, failedNodes)
 	}
 	// update status of cluster
 	if failedNodes != nil {
@@ -298,42 +330,42 @@ func CreateCluster(cc *api.ClusterConfig, deployEnableRollback bool) (api.Cluste
 	return cstatus, nil
 }
 
-func doJoinNode(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, hostconfig *api.HostConfig) error {
-	if err := handler.MachineInfraSetup(hostconfig); err != nil {
+func doJoinNode(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, hostconfig *api.HostConfig) error {
+	if err := handler.MachineInfraSetup(ctx, hostconfig); err != nil {
 		return err
 	}
 
 	// Pre node join Hooks
-	if err := handler.PreNodeJoinHooks(hostconfig); err != nil {
+	if err := handler.PreNodeJoinHooks(ctx, hostconfig); err != nil {
 		return err
 	}
 
 	// wait infrastructure task success on node
-	if err := nodemanager.WaitNodesFinish([]string{hostconfig.Address},
+	if err := nodemanager.WaitNodesFinish(ctx, []string{hostconfig.Address},
 		time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		return err
 	}
 
 	// join etcd to cluster
 	if utils.IsType(hostconfig.Type, api.ETCD) {
-		if err := handler.EtcdNodeSetup(hostconfig); err != nil {
+		if err := handler.EtcdNodeSetup(ctx, hostconfig); err != nil {
 			logrus.Errorf("add etcd %s failed: %v", hostconfig.Name, err)
 			return err
 		}
 	}
 
 	// join node to cluster
-	if err := handler.ClusterNodeJoin(hostconfig); err != nil {
+	if err := handler.ClusterNodeJoin(ctx, hostconfig); err != nil {
 		return err
 	}
 
 	// Post node join Hooks
-	if err := handler.PostNodeJoinHooks(hostconfig); err != nil {
+	if err := handler.PostNodeJoinHooks(ctx, hostconfig); err != nil {
 		return err
 	}
 
 	// wait node ready
-	if err := nodemanager.WaitNodesFinishWithProgress([]string{hostconfig.Address},
+	if err := nodemanager.WaitNodesFinishWithProgress(ctx, []string{hostconfig.Address},
 		time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		return err
 	}
@@ -341,7 +373,7 @@ func doJoinNode(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, hostcon
 	return nil
 }
 
-func JoinNodes(cc *api.ClusterConfig, hostconfigs []*api.HostConfig) (api.ClusterStatus, error) {
+func JoinNodes(ctx context.Context, cc *api.ClusterConfig, hostconfigs []*api.HostConfig) (api.ClusterStatus, error) {
 	cstatus := api.ClusterStatus{
 		StatusOfNodes: make(map[string]bool),
 	}
@@ -378,7 +410,7 @@ func JoinNodes(cc *api.ClusterConfig, hostconfigs []*api.HostConfig) (api.Cluste
 
 	// join nodes with etcd
 	for _, h := range withEtcd {
-		if err := doJoinNode(handler, cc, h); err != nil {
+		if err := doJoinNode(ctx, handler, cc, h); err != nil {
 			failedNodes = append(failedNodes, h)
 			logrus.Errorf("join node with etcd failed: %v", err)
 			continue
@@ -395,7 +427,7 @@ func JoinNodes(cc *api.ClusterConfig, hostconfigs []*api.HostConfig) (api.Cluste
 	for _, h := range withoutEtcd {
 		go func(hostconfig *api.HostConfig) {
 			defer wg.Done()
-			if err := doJoinNode(handler, cc, hostconfig); err != nil {
+			if err := doJoinNode(ctx, handler, cc, hostconfig); err != nil {
 				lock.Lock()
 				failedNodes = append(failedNodes, hostconfig)
 				lock.Unlock()
@@ -444,43 +476,43 @@ func JoinNodes(cc *api.ClusterConfig, hostconfigs []*api.HostConfig) (api.Cluste
 	return cstatus, fmt.Errorf("some nodes failed to join to cluster")
 }
 
-func doDeleteNode(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, h *api.HostConfig) error {
+func doDeleteNode(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, h *api.HostConfig) error {
 	// Pre node delete Hooks
-	handler.PreNodeCleanupHooks(h)
+	handler.PreNodeCleanupHooks(ctx, h)
 
 	if utils.IsType(h.Type, api.Worker) {
-		if err := handler.ClusterNodeCleanup(h, api.Worker); err != nil {
+		if err := handler.ClusterNodeCleanup(ctx, h, api.Worker); err != nil {
 			return fmt.Errorf("delete worker %s failed: %v", h.Name, err)
 		}
 	}
 
 	if utils.IsType(h.Type, api.Master) {
-		if err := handler.ClusterNodeCleanup(h, api.Master); err != nil {
+		if err := handler.ClusterNodeCleanup(ctx, h, api.Master); err != nil {
 			return fmt.Errorf("delete master %s failed: %v", h.Name, err)
 		}
 	}
 
 	if utils.IsType(h.Type, api.ETCD) {
-		if err := handler.EtcdNodeDestroy(h); err != nil {
+		if err := handler.EtcdNodeDestroy(ctx, h); err != nil {
 			logrus.Errorf("delete etcd of node %s failed: %v", h.Name, err)
 			return err
 		}
 	}
 
 	// Post node delete Hooks
-	handler.PostNodeCleanupHooks(h)
+	handler.PostNodeCleanupHooks(ctx, h)
 
-	if err := handler.MachineInfraDestroy(h); err != nil {
+	if err := handler.MachineInfraDestroy(ctx, h); err != nil {
 		logrus.Warnf("cleanup infrastructure for node: %s failed: %v", h.Name, err)
 		return err
 	}
 
-	if err := handler.CleanupLastStep(h.Name); err != nil {
+	if err := handler.CleanupLastStep(ctx, h.Name); err != nil {
 		logrus.Warnf("cleanup user temp dir for node %s failed: %v", h.Name, err)
 		return err
 	}
 
-	if err := nodemanager.WaitNodesFinishWithProgress([]string{h.Address},
+	if err := nodemanager.WaitNodesFinishWithProgress(ctx, []string{h.Address},
 		time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		logrus.Warnf("wait cleanup finish failed: %v", err)
 	}
@@ -488,7 +520,7 @@ func doDeleteNode(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, h *ap
 	return nil
 }
 
-func DeleteNodes(cc *api.ClusterConfig, hostconfigs []*api.HostConfig) error {
+func DeleteNodes(ctx context.Context, cc *api.ClusterConfig, hostconfigs []*api.HostConfig) error {
 	if cc == nil {
 		return fmt.Errorf("[cluster] cluster config is required")
 	}
@@ -521,7 +553,7 @@ func DeleteNodes(cc *api.ClusterConfig, hostconfigs []*api.HostConfig) error {
 	for _, h := range nodes {
 		go func(hostconfig *api.HostConfig) {
 			defer wg.Done()
-			if terr := doDeleteNode(handler, cc, hostconfig); terr != nil {
+			if terr := doDeleteNode(ctx, handler, cc, hostconfig); terr != nil {
 				logrus.Errorf("[cluster] delete '%s' from cluster failed", hostconfig.Name)
 				return
 			}
@@ -532,7 +564,7 @@ func DeleteNodes(cc *api.ClusterConfig, hostconfigs []*api.HostConfig) error {
 
 	// delete node with etcds
 	for _, h := range etcds {
-		if err = doDeleteNode(handler, cc, h); err != nil {
+		if err = doDeleteNode(ctx, handler, cc, h); err != nil {
 			logrus.Errorf("[cluster] delete '%s' with etcd from cluster failed", h.Name)
 			return err
 		}
@@ -542,25 +574,25 @@ func DeleteNodes(cc *api.ClusterConfig, hostconfigs []*api.HostConfig) error {
 	return err
 }
 
-func doRemoveCluster(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) {
+func doRemoveCluster(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) {
 	// Step1: Pre delete cluster Hooks
-	handler.PreDeleteClusterHooks()
+	handler.PreDeleteClusterHooks(ctx)
 
 	// Step2: cleanup addons
-	err := handler.AddonsDestroy()
+	err := handler.AddonsDestroy(ctx)
 	if err != nil {
 		logrus.Warnf("[cluster] cleanup addons failed: %v", err)
 	}
 
 	allNodes := utils.GetAllIPs(cc.Nodes)
-	if err = nodemanager.WaitNodesFinish(allNodes, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
+	if err = nodemanager.WaitNodesFinish(ctx, allNodes, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		logrus.Warnf("[cluster] wait cleanup addons failed: %v", err)
 	}
 
 	// Step3: cleanup workers
 	for _, n := range cc.Nodes {
 		if utils.IsType(n.Type, api.Worker) {
-			err = handler.ClusterNodeCleanup(n, api.Worker)
+			err = handler.ClusterNodeCleanup(ctx, n, api.Worker)
 			if err != nil {
 				logrus.Warnf("[cluster] cleanup node: %s failed: %v", n.Name, err)
 			}
@@ -570,7 +602,7 @@ func doRemoveCluster(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) {
 	// Step4: cleanup masters
 	for _, n := range cc.Nodes {
 		if utils.IsType(n.Type, api.Master) {
-			err = handler.ClusterNodeCleanup(n, api.Master)
+			err = handler.ClusterNodeCleanup(ctx, n, api.Master)
 			if err != nil {
 				logrus.Warnf("[cluster] cleanup master: %s failed: %v", n.Name, err)
 			}
@@ -580,7 +612,7 @@ func doRemoveCluster(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) {
 	//Step5: cleanup loadbalance
 	for _, n := range cc.Nodes {
 		if utils.IsType(n.Type, api.LoadBalance) {
-			err = handler.LoadBalancerDestroy(n)
+			err = handler.LoadBalancerDestroy(ctx, n)
 			if err != nil {
 				logrus.Warnf("[cluster] cleanup loadbalance failed: %v", err)
 			}
@@ -589,17 +621,17 @@ func doRemoveCluster(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) {
 	}
 
 	// Step6: cleanup etcd cluster
-	err = handler.EtcdClusterDestroy()
+	err = handler.EtcdClusterDestroy(ctx)
 	if err != nil {
 		logrus.Warnf("[cluster] cleanup etcd cluster failed: %v", err)
 	}
 
 	// Step7: Post delete cluster Hooks
-	handler.PostDeleteClusterHooks()
+	handler.PostDeleteClusterHooks(ctx)
 
 	// Step8: cleanup infrastructure
 	for _, n := range cc.Nodes {
-		err = handler.MachineInfraDestroy(n)
+		err = handler.MachineInfraDestroy(ctx, n)
 		if err != nil {
 			logrus.Warnf("[cluster] cleanup infrastructure for node: %s failed: %v", n.Name, err)
 		}
@@ -607,18 +639,18 @@ func doRemoveCluster(handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) {
 
 	// Step9: cleanup user temp dir
 	for _, n := range cc.Nodes {
-		err = handler.CleanupLastStep(n.Name)
+		err = handler.CleanupLastStep(ctx, n.Name)
 		if err != nil {
 			logrus.Warnf("[cluster] cleanup user temp dir for node: %s failed: %v", n.Name, err)
 		}
 	}
 
-	if err = nodemanager.WaitNodesFinishWithProgress(allNodes, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
+	if err = nodemanager.WaitNodesFinishWithProgress(ctx, allNodes, time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
 		logrus.Warnf("[cluster] wait all cleanup finish failed: %v", err)
 	}
 }
 
-func RemoveCluster(cc *api.ClusterConfig) error {
+func RemoveCluster(ctx context.Context, cc *api.ClusterConfig) error {
 	if cc == nil {
 		return fmt.Errorf("cluster config is required")
 	}
@@ -635,7 +667,7 @@ func RemoveCluster(cc *api.ClusterConfig) error {
 	defer handler.Finish()
 
 	// cleanup cluster
-	doRemoveCluster(handler, cc)
+	doRemoveCluster(ctx, handler, cc)
 
 	// cleanup eggo config directory
 	if err := os.RemoveAll(api.GetClusterHomePath(cc.Name)); err != nil {
@@ -0,0 +1,221 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: run single phase of cluster deployment
+ ******************************************************************************/
+
+package clusterdeployment
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment/manager"
+	"isula.org/eggo/pkg/constants"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/nodemanager"
+)
+
+const (
+	PhaseInfrastructure = "infrastructure"
+	PhaseEtcd           = "etcd"
+	PhaseLoadBalance    = "loadbalance"
+	PhaseControlPlane   = "controlplane"
+	PhaseJoin           = "join"
+	PhaseAddons         = "addons"
+)
+
+type phaseFunc func(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) error
+
+// deployPhases is in order of creating cluster
+var deployPhases = []struct {
+	name string
+	run  phaseFunc
+}{
+	{name: PhaseInfrastructure, run: runInfrastructurePhase},
+	{name: PhaseEtcd, run: runEtcdPhase},
+	{name: PhaseLoadBalance, run: runLoadBalancePhase},
+	{name: PhaseControlPlane, run: runControlPlanePhase},
+	{name: PhaseJoin, run: runJoinPhase},
+	{name: PhaseAddons, run: runAddonsPhase},
+}
+
+func DeployPhases() []string {
+	var names []string
+	for _, p := range deployPhases {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+func getPhaseFunc(phase string) (phaseFunc, error) {
+	for _, p := range deployPhases {
+		if p.name == phase {
+			return p.run, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid phase: %s, support: %s", phase, strings.Join(DeployPhases(), ", "))
+}
+
+func CheckDeployPhase(phase string) error {
+	_, err := getPhaseFunc(phase)
+	return err
+}
+
+// setupNodesInfra setups infrastructure of all nodes, failures of nodes are reported together unless fail fast
+func setupNodesInfra(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) error {
+	errs := utils.NewMultiError(cc.FailFast)
+	for _, n := range cc.Nodes {
+		// remaining nodes are not attempted after deploy is canceled
+		if err := ctx.Err(); err != nil {
+			errs.Add(err)
+			break
+		}
+		if err := handler.MachineInfraSetup(ctx, n); err != nil {
+			if errs.Add(fmt.Errorf("setup infrastructure of node %s failed: %v", n.Address, err)) {
+				break
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+func runInfrastructurePhase(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) error {
+	return setupNodesInfra(ctx, handler, cc)
+}
+
+func runEtcdPhase(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) error {
+	return handler.EtcdClusterSetup(ctx)
+}
+
+func runLoadBalancePhase(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) error {
+	lb, _, _, _ := splitNodes(cc.Nodes)
+	return handler.LoadBalancerSetup(ctx, lb)
+}
+
+func runControlPlanePhase(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) error {
+	_, masters, _, _ := splitNodes(cc.Nodes)
+	if len(masters) == 0 {
+		return fmt.Errorf("no master found")
+	}
+	controlPlaneNode, err := masters[0].DeepCopy()
+	if err != nil {
+		return err
+	}
+	if err = handler.ClusterControlPlaneInit(ctx, controlPlaneNode); err != nil {
+		return err
+	}
+	if !utils.IsType(controlPlaneNode.Type, api.Worker) {
+		return nil
+	}
+	if err = nodemanager.WaitNodesFinish(ctx, []string{controlPlaneNode.Address},
+		time.Minute*constants.DefaultTaskWaitMinutes); err != nil {
+		return err
+	}
+	controlPlaneNode.Type = utils.ClearType(controlPlaneNode.Type, api.Master)
+	return handler.ClusterNodeJoin(ctx, controlPlaneNode)
+}
+
+func runJoinPhase(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) error {
+	_, masters, workers, _ := splitNodes(cc.Nodes)
+	if len(masters) == 0 {
+		return fmt.Errorf("no master found")
+	}
+	_, _, failedNodes := doJoinNodeOfCluster(ctx, handler, cc, masters[1:], workers)
+	if len(failedNodes) == 0 {
+		return nil
+	}
+	var ids []string
+	for _, n := range failedNodes {
+		ids = append(ids, n.Address)
+	}
+	return fmt.Errorf("join nodes: %v failed", ids)
+}
+
+func runAddonsPhase(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig) error {
+	return handler.AddonsSetup(ctx)
+}
+
+// warnPhasePrerequisites warns if former phases seem not run, phase is run anyway
+func warnPhasePrerequisites(cc *api.ClusterConfig, phase string) {
+	if phase == PhaseInfrastructure {
+		return
+	}
+	if exist, err := utils.CheckPathExist(api.GetClusterHomePath(cc.Name)); err != nil || !exist {
+		logrus.Warnf("[cluster] cluster: %s is not deployed before, prerequisites of phase %s may be unmet", cc.Name, phase)
+		return
+	}
+	if phase != PhaseJoin && phase != PhaseAddons {
+		return
+	}
+	adminConf := filepath.Join(api.GetClusterHomePath(cc.Name), constants.KubeConfigFileNameAdmin)
+	if exist, err := utils.CheckPathExist(adminConf); err != nil || !exist {
+		logrus.Warnf("[cluster] %s is not exist, phase %s requires phase %s done", adminConf, phase, PhaseControlPlane)
+	}
+}
+
+func doRunDeployPhase(ctx context.Context, handler api.ClusterDeploymentAPI, cc *api.ClusterConfig, phase string) error {
+	run, err := getPhaseFunc(phase)
+	if err != nil {
+		return err
+	}
+	if err = run(ctx, handler, cc); err != nil {
+		return err
+	}
+
+	var nodes []string
+	for _, n := range cc.Nodes {
+		nodes = append(nodes, n.Address)
+	}
+	return nodemanager.WaitNodesFinishWithProgress(ctx, nodes, time.Minute*constants.DefaultTaskWaitMinutes)
+}
+
+// RunDeployPhase only runs one phase of creating cluster, assume that former phases are done;
+// nothing is rollbacked if failed
+func RunDeployPhase(ctx context.Context, cc *api.ClusterConfig, phase string) error {
+	if cc == nil {
+		return fmt.Errorf("[cluster] cluster config is required")
+	}
+	if err := CheckDeployPhase(phase); err != nil {
+		return err
+	}
+	warnPhasePrerequisites(cc, phase)
+	if err := os.MkdirAll(api.GetClusterHomePath(cc.Name), constants.EggoHomeDirMode); err != nil {
+		return err
+	}
+
+	creator, err := manager.GetClusterDeploymentDriver(cc.DeployDriver)
+	if err != nil {
+		logrus.Errorf("[cluster] get cluster deployment driver: %s failed: %v", cc.DeployDriver, err)
+		return err
+	}
+	handler, err := creator(cc)
+	if err != nil {
+		logrus.Errorf("[cluster] create cluster deployment instance with driver: %s, failed: %v", cc.DeployDriver, err)
+		return err
+	}
+	defer handler.Finish()
+
+	if err = doRunDeployPhase(ctx, handler, cc, phase); err != nil {
+		logrus.Errorf("[cluster] run phase %s of cluster: %s failed: %v", phase, cc.Name, err)
+		return err
+	}
+	logrus.Infof("[cluster] run phase %s of cluster: %s success", phase, cc.Name)
+	return nil
+}
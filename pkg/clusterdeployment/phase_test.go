@@ -0,0 +1,158 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase of single phase of cluster deployment
+ ******************************************************************************/
+
+package clusterdeployment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/nodemanager"
+	"isula.org/eggo/pkg/utils/runner/runnertest"
+)
+
+// phaseHandler records calls of apis, other apis are not expected to be called
+type phaseHandler struct {
+	api.ClusterDeploymentAPI
+	calls []string
+	// infrastructure of these nodes fails
+	failed map[string]bool
+}
+
+func (h *phaseHandler) MachineInfraSetup(ctx context.Context, machine *api.HostConfig) error {
+	h.calls = append(h.calls, "MachineInfraSetup:"+machine.Name)
+	if h.failed[machine.Name] {
+		return fmt.Errorf("install packages on %s failed", machine.Name)
+	}
+	return nil
+}
+
+func (h *phaseHandler) EtcdClusterSetup(ctx context.Context) error {
+	h.calls = append(h.calls, "EtcdClusterSetup")
+	return nil
+}
+
+func (h *phaseHandler) LoadBalancerSetup(ctx context.Context, lb *api.HostConfig) error {
+	h.calls = append(h.calls, "LoadBalancerSetup:"+lb.Name)
+	return nil
+}
+
+func (h *phaseHandler) ClusterControlPlaneInit(ctx context.Context, node *api.HostConfig) error {
+	h.calls = append(h.calls, "ClusterControlPlaneInit:"+node.Name)
+	return nil
+}
+
+func (h *phaseHandler) ClusterNodeJoin(ctx context.Context, node *api.HostConfig) error {
+	h.calls = append(h.calls, "ClusterNodeJoin:"+node.Name)
+	return nil
+}
+
+func (h *phaseHandler) AddonsSetup(ctx context.Context) error {
+	h.calls = append(h.calls, "AddonsSetup")
+	return nil
+}
+
+func TestRunDeployPhase(t *testing.T) {
+	cc := &api.ClusterConfig{
+		Name: "phase-test",
+		Nodes: []*api.HostConfig{
+			{Name: "master0", Address: "192.168.0.1", Type: api.Master | api.Worker | api.ETCD},
+			{Name: "master1", Address: "192.168.0.2", Type: api.Master},
+			{Name: "worker0", Address: "192.168.0.3", Type: api.Worker},
+			{Name: "lb", Address: "192.168.0.4", Type: api.LoadBalance},
+		},
+	}
+	for _, n := range cc.Nodes {
+		if err := nodemanager.RegisterNode(n, &runnertest.Runner{}); err != nil {
+			t.Fatalf("register node failed: %v", err)
+		}
+	}
+	defer nodemanager.UnRegisterAllNodes()
+
+	tcs := []struct {
+		phase  string
+		expect []string
+	}{
+		{phase: PhaseInfrastructure, expect: []string{"MachineInfraSetup:master0", "MachineInfraSetup:master1", "MachineInfraSetup:worker0", "MachineInfraSetup:lb"}},
+		{phase: PhaseEtcd, expect: []string{"EtcdClusterSetup"}},
+		{phase: PhaseLoadBalance, expect: []string{"LoadBalancerSetup:lb"}},
+		{phase: PhaseControlPlane, expect: []string{"ClusterControlPlaneInit:master0", "ClusterNodeJoin:master0"}},
+		{phase: PhaseJoin, expect: []string{"ClusterNodeJoin:worker0", "ClusterNodeJoin:master1"}},
+		{phase: PhaseAddons, expect: []string{"AddonsSetup"}},
+	}
+	if len(tcs) != len(DeployPhases()) {
+		t.Fatalf("expect all phases tested, phases: %v", DeployPhases())
+	}
+	for _, tc := range tcs {
+		h := &phaseHandler{}
+		if err := doRunDeployPhase(context.Background(), h, cc, tc.phase); err != nil {
+			t.Fatalf("run phase %s failed: %v", tc.phase, err)
+		}
+		if fmt.Sprint(h.calls) != fmt.Sprint(tc.expect) {
+			t.Fatalf("phase %s: expect calls %v, get: %v", tc.phase, tc.expect, h.calls)
+		}
+	}
+
+	if err := CheckDeployPhase("kubelet"); err == nil || !strings.Contains(err.Error(), "invalid phase") {
+		t.Fatalf("expect invalid phase, get: %v", err)
+	}
+	if err := doRunDeployPhase(context.Background(), &phaseHandler{}, cc, "kubelet"); err == nil {
+		t.Fatalf("expect run invalid phase failed")
+	}
+}
+
+func TestSetupNodesInfra(t *testing.T) {
+	cc := &api.ClusterConfig{
+		Nodes: []*api.HostConfig{
+			{Name: "master0", Address: "192.168.0.1"},
+			{Name: "master1", Address: "192.168.0.2"},
+			{Name: "worker0", Address: "192.168.0.3"},
+			{Name: "lb", Address: "192.168.0.4"},
+		},
+	}
+	failed := map[string]bool{"master1": true, "lb": true}
+
+	// all nodes are attempted, and failures are reported together
+	h := &phaseHandler{failed: failed}
+	err := setupNodesInfra(context.Background(), h, cc)
+	if len(h.calls) != 4 {
+		t.Fatalf("expect all nodes attempted, get: %v", h.calls)
+	}
+	if err == nil || !strings.Contains(err.Error(), "192.168.0.2") || !strings.Contains(err.Error(), "192.168.0.4") {
+		t.Fatalf("expect failures of master1 and lb, get: %v", err)
+	}
+
+	// stop at the first failed node with fail fast
+	cc.FailFast = true
+	h = &phaseHandler{failed: failed}
+	err = setupNodesInfra(context.Background(), h, cc)
+	if fmt.Sprint(h.calls) != fmt.Sprint([]string{"MachineInfraSetup:master0", "MachineInfraSetup:master1"}) {
+		t.Fatalf("expect stop at master1, get: %v", h.calls)
+	}
+	if err == nil || strings.Contains(err.Error(), "192.168.0.4") {
+		t.Fatalf("expect only failure of master1, get: %v", err)
+	}
+
+	// no node is attempted after canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	h = &phaseHandler{}
+	if err = setupNodesInfra(ctx, h, cc); err == nil || len(h.calls) != 0 {
+		t.Fatalf("expect canceled before any node, get: %v, %v", h.calls, err)
+	}
+}
@@ -0,0 +1,61 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: load offline image package into container engine
+ ******************************************************************************/
+
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner"
+)
+
+const (
+	defaultPauseImage = "k8s.gcr.io/pause:3.2"
+)
+
+func getPauseImage(workerConfig *api.WorkerConfig) string {
+	if workerConfig.KubeletConf != nil && workerConfig.KubeletConf.PauseImage != "" {
+		return workerConfig.KubeletConf.PauseImage
+	}
+	return defaultPauseImage
+}
+
+func loadImagePackageCommand(rt Runtime, imagePackage string) string {
+	return fmt.Sprintf("sudo -E /bin/sh -c \"%s %s\"", rt.GetRuntimeLoadImageCommand(), imagePackage)
+}
+
+func imageCheckCommand(rt Runtime, image string) string {
+	return fmt.Sprintf("sudo -E /bin/sh -c \"%s\"", rt.GetRuntimeImageCheckCommand(image))
+}
+
+// loadImagePackage loads images of package into runtime, and pause image must exist after load
+func loadImagePackage(r runner.Runner, rt Runtime, imagePackage, pauseImage string) error {
+	if imagePackage == "" {
+		return nil
+	}
+
+	if output, err := r.RunCommand(loadImagePackageCommand(rt, imagePackage)); err != nil {
+		return fmt.Errorf("%s load %s failed: %v, output: %s", rt.GetRuntimeClient(), imagePackage, err, output)
+	}
+	if _, err := r.RunCommand(imageCheckCommand(rt, pauseImage)); err != nil {
+		return fmt.Errorf("pause image %s not found after load %s", pauseImage, imagePackage)
+	}
+
+	logrus.Infof("load image package %s success", imagePackage)
+	return nil
+}
@@ -0,0 +1,103 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for load offline image package
+ ******************************************************************************/
+
+package runtime
+
+import (
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner/runnertest"
+)
+
+func TestLoadImagePackage(t *testing.T) {
+	imagePackage := "/root/.eggo/package/image/images.tar"
+	pauseImage := "k8s.gcr.io/pause:3.2"
+	cases := []struct {
+		runtime string
+		load    string
+		check   string
+	}{
+		{
+			runtime: "isulad",
+			load:    "sudo -E /bin/sh -c \"isula load -i /root/.eggo/package/image/images.tar\"",
+			check:   "sudo -E /bin/sh -c \"isula inspect k8s.gcr.io/pause:3.2\"",
+		},
+		{
+			runtime: "docker",
+			load:    "sudo -E /bin/sh -c \"docker load -i /root/.eggo/package/image/images.tar\"",
+			check:   "sudo -E /bin/sh -c \"docker image inspect k8s.gcr.io/pause:3.2\"",
+		},
+		{
+			runtime: "",
+			load:    "sudo -E /bin/sh -c \"docker load -i /root/.eggo/package/image/images.tar\"",
+			check:   "sudo -E /bin/sh -c \"docker image inspect k8s.gcr.io/pause:3.2\"",
+		},
+		{
+			runtime: "containerd",
+			load:    "sudo -E /bin/sh -c \"ctr -n k8s.io images import /root/.eggo/package/image/images.tar\"",
+			check:   "sudo -E /bin/sh -c \"ctr -n k8s.io images ls -q name==k8s.gcr.io/pause:3.2 | grep -q .\"",
+		},
+	}
+
+	for _, c := range cases {
+		rt := GetRuntime(c.runtime)
+		r := &runnertest.Runner{}
+		if err := loadImagePackage(r, rt, imagePackage, pauseImage); err != nil {
+			t.Fatalf("load image package with %q failed: %v", c.runtime, err)
+		}
+		if len(r.Commands) != 2 || r.Commands[0] != c.load || r.Commands[1] != c.check {
+			t.Fatalf("unexpect commands with %q: %v", c.runtime, r.Commands)
+		}
+
+		r = &runnertest.Runner{Fail: func(cmd string) bool { return cmd == c.load }}
+		if err := loadImagePackage(r, rt, imagePackage, pauseImage); err == nil || len(r.Commands) != 1 {
+			t.Fatalf("expect load failed with %q, commands: %v", c.runtime, r.Commands)
+		}
+		r = &runnertest.Runner{Fail: func(cmd string) bool { return cmd == c.check }}
+		if err := loadImagePackage(r, rt, imagePackage, pauseImage); err == nil {
+			t.Fatalf("expect pause image not found with %q", c.runtime)
+		}
+	}
+
+	// nothing to do without image package
+	r := &runnertest.Runner{}
+	if err := loadImagePackage(r, GetRuntime("docker"), "", pauseImage); err != nil || len(r.Commands) != 0 {
+		t.Fatalf("expect no command without image package, err: %v, commands: %v", err, r.Commands)
+	}
+
+	wc := &api.WorkerConfig{}
+	if getPauseImage(wc) != defaultPauseImage {
+		t.Fatalf("expect default pause image, get: %s", getPauseImage(wc))
+	}
+	wc.KubeletConf = &api.Kubelet{PauseImage: "hub.local/pause:3.5"}
+	if getPauseImage(wc) != "hub.local/pause:3.5" {
+		t.Fatalf("expect configured pause image, get: %s", getPauseImage(wc))
+	}
+
+	// runtime uses pause image in image repository of cluster, config of cluster is not changed
+	ccfg := &api.ClusterConfig{ImageRepository: "hub.example.com/k8s", WorkerConfig: *wc}
+	task := NewDeployRuntimeTask(ccfg)
+	if image := getPauseImage(task.workerConfig); image != "hub.example.com/k8s/pause:3.5" {
+		t.Fatalf("expect pause image in image repository, get: %s", image)
+	}
+	if wc.KubeletConf.PauseImage != "hub.local/pause:3.5" {
+		t.Fatalf("expect pause image of cluster config not changed, get: %s", wc.KubeletConf.PauseImage)
+	}
+	ccfg.WorkerConfig.KubeletConf = nil
+	if image := getPauseImage(NewDeployRuntimeTask(ccfg).workerConfig); image != "hub.example.com/k8s/pause:3.2" {
+		t.Fatalf("expect default pause image in image repository, get: %s", image)
+	}
+}
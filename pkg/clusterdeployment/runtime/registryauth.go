@@ -0,0 +1,107 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: credentials of private registries for container runtime
+ ******************************************************************************/
+
+package runtime
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/runner"
+)
+
+const (
+	// docker config read by kubelet when pull images, credentials are passed to runtime by CRI
+	kubeletRegistryAuthPath = "/var/lib/kubelet/config.json"
+	containerdConfigPath    = "/etc/containerd/config.toml"
+)
+
+type dockerAuthEntry struct {
+	Auth string `json:"auth"`
+}
+
+type dockerConfig struct {
+	Auths map[string]dockerAuthEntry `json:"auths"`
+}
+
+// dockerConfigJSON generates docker config with credentials of registries
+func dockerConfigJSON(auths []api.RegistryAuth) ([]byte, error) {
+	conf := dockerConfig{Auths: make(map[string]dockerAuthEntry, len(auths))}
+	for _, a := range auths {
+		conf.Auths[a.Registry] = dockerAuthEntry{
+			Auth: base64.StdEncoding.EncodeToString([]byte(a.Username + ":" + a.Password)),
+		}
+	}
+	return json.MarshalIndent(conf, "", "  ")
+}
+
+// containerdRegistryAuths quotes fields of auths as strings of toml
+func containerdRegistryAuths(auths []api.RegistryAuth) []api.RegistryAuth {
+	var quoted []api.RegistryAuth
+	for _, a := range auths {
+		quoted = append(quoted, api.RegistryAuth{
+			Registry: strconv.Quote(a.Registry),
+			Username: strconv.Quote(a.Username),
+			Password: strconv.Quote(a.Password),
+		})
+	}
+	return quoted
+}
+
+// writeSecretFile copies content to dst of node with mode 0600, content with credentials
+// is not written by command, because command may be logged
+func writeSecretFile(r runner.Runner, content []byte, dst string) error {
+	f, err := ioutil.TempFile("", "eggo-secret-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	if _, err = r.RunCommand(utils.AddSudo(fmt.Sprintf("mkdir -p %s", filepath.Dir(dst)))); err != nil {
+		return err
+	}
+	if err = r.Copy(f.Name(), dst); err != nil {
+		return fmt.Errorf("copy secret file to %s failed: %v", dst, err)
+	}
+	if _, err = r.RunCommand(utils.AddSudo(fmt.Sprintf("chmod 600 %s", dst))); err != nil {
+		return err
+	}
+	return nil
+}
+
+func prepareKubeletRegistryAuths(r runner.Runner, auths []api.RegistryAuth) error {
+	if len(auths) == 0 {
+		return nil
+	}
+	content, err := dockerConfigJSON(auths)
+	if err != nil {
+		return err
+	}
+	return writeSecretFile(r, content, kubeletRegistryAuthPath)
+}
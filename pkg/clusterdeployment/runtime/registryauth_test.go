@@ -0,0 +1,99 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for credentials of private registries
+ ******************************************************************************/
+
+package runtime
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner/runnertest"
+)
+
+func testWorkerConfig(auths []api.RegistryAuth) *api.WorkerConfig {
+	return &api.WorkerConfig{
+		KubeletConf:         &api.Kubelet{},
+		ContainerEngineConf: &api.ContainerEngine{RegistryAuths: auths},
+	}
+}
+
+func checkNoCredentialsInCommands(t *testing.T, r *runnertest.Runner, secrets ...string) {
+	for _, cmd := range r.Commands {
+		for _, s := range secrets {
+			if strings.Contains(cmd, s) {
+				t.Fatalf("command %s contains credentials", cmd)
+			}
+		}
+	}
+}
+
+func TestKubeletRegistryAuths(t *testing.T) {
+	auths := []api.RegistryAuth{
+		{Registry: "registry.example.com:5000", Username: "admin", Password: "p@ss\"word"},
+		{Registry: "hub.example.org", Username: "robot", Password: "token"},
+	}
+	secret := base64.StdEncoding.EncodeToString([]byte("admin:p@ss\"word"))
+
+	for _, rt := range []Runtime{&dockerRuntime{}, &isuladRuntime{}} {
+		r := &runnertest.Runner{}
+		if err := rt.PrepareRegistryAuths(r, testWorkerConfig(auths)); err != nil {
+			t.Fatalf("prepare auths of %s failed: %v", rt.GetRuntimeService(), err)
+		}
+		var conf dockerConfig
+		if err := json.Unmarshal([]byte(r.Files[kubeletRegistryAuthPath]), &conf); err != nil {
+			t.Fatalf("invalid auth config of %s: %v", rt.GetRuntimeService(), err)
+		}
+		if len(conf.Auths) != 2 || conf.Auths["registry.example.com:5000"].Auth != secret {
+			t.Fatalf("unexpect auth config of %s: %v", rt.GetRuntimeService(), conf)
+		}
+		checkNoCredentialsInCommands(t, r, "p@ss", secret)
+
+		found := false
+		for _, p := range rt.GetRemovedPath() {
+			found = found || p == kubeletRegistryAuthPath
+		}
+		if !found {
+			t.Fatalf("auth config of %s is not removed by cleanup", rt.GetRuntimeService())
+		}
+
+		// nothing is written without auths
+		r = &runnertest.Runner{}
+		if err := rt.PrepareRegistryAuths(r, testWorkerConfig(nil)); err != nil || len(r.Files) != 0 || len(r.Commands) != 0 {
+			t.Fatalf("expect nothing to do without auths, err: %v, files: %v", err, r.Files)
+		}
+	}
+}
+
+func TestContainerdRegistryAuths(t *testing.T) {
+	auths := []api.RegistryAuth{{Registry: "registry.example.com:5000", Username: "admin", Password: "p@ss\"word"}}
+	r := &runnertest.Runner{}
+	if err := prepareContainerdConfig(r, testWorkerConfig(auths)); err != nil {
+		t.Fatalf("prepare containerd config failed: %v", err)
+	}
+	config := r.Files[containerdConfigPath]
+	for _, expect := range []string{
+		`[plugins."io.containerd.grpc.v1.cri".registry.configs."registry.example.com:5000".auth]`,
+		`username = "admin"`,
+		`password = "p@ss\"word"`,
+	} {
+		if !strings.Contains(config, expect) {
+			t.Fatalf("expect %s in config of containerd, get: %s", expect, config)
+		}
+	}
+	checkNoCredentialsInCommands(t, r, "p@ss")
+}
@@ -9,6 +9,7 @@ import (
 
 	"isula.org/eggo/pkg/api"
 	"isula.org/eggo/pkg/clusterdeployment/binary/commontools"
+	"isula.org/eggo/pkg/constants"
 	"isula.org/eggo/pkg/utils/dependency"
 	"isula.org/eggo/pkg/utils/runner"
 	"isula.org/eggo/pkg/utils/template"
@@ -26,8 +27,16 @@ type Runtime interface {
 	GetRuntimeSoftwares() []string
 	GetRuntimeClient() string
 	GetRuntimeLoadImageCommand() string
+	// command to check whether image exists in runtime
+	GetRuntimeImageCheckCommand(image string) string
 	GetRuntimeService() string
 	PrepareRuntimeService(r runner.Runner, workerConfig *api.WorkerConfig) error
+	// write credentials of private registries into auth config read when pull images
+	PrepareRegistryAuths(r runner.Runner, workerConfig *api.WorkerConfig) error
+	// cgroup driver set by extra args of runtime, empty if it is not set
+	GetCgroupDriverOfArgs(extraArgs map[string]string) string
+	// command to print cgroup driver of running runtime
+	GetCgroupDriverCommand() string
 
 	GetRemovedPath() []string
 }
@@ -47,6 +56,10 @@ func (ir *isuladRuntime) GetRuntimeLoadImageCommand() string {
 	return "isula load -i"
 }
 
+func (ir *isuladRuntime) GetRuntimeImageCheckCommand(image string) string {
+	return fmt.Sprintf("isula inspect %s", image)
+}
+
 func (ir *isuladRuntime) GetRuntimeService() string {
 	return "isulad"
 }
@@ -64,6 +77,9 @@ ExecStart=/usr/bin/isulad \
         --network-plugin cni \
         --cni-bin-dir {{ .cniBinDir }} \
         --cni-conf-dir {{ .cniConfDir }} \
+{{- if .cgroupDriver }}
+        --native.cgroupdriver {{ .cgroupDriver }} \
+{{- end }}
 {{- range $i, $v := .registry }}
         --registry-mirrors {{ $v }} \
 {{- end }}
@@ -116,6 +132,7 @@ WantedBy=multi-user.target
 	datastore["pauseImage"] = pauseImage
 	datastore["cniBinDir"] = cniBinDir
 	datastore["cniConfDir"] = cniConfDir
+	datastore["cgroupDriver"] = cgroupDriverToRender(ir, workerConfig)
 	datastore["registry"] = registry
 	datastore["insecure"] = insecure
 	datastore["addition"] = addition
@@ -139,9 +156,23 @@ WantedBy=multi-user.target
 	return nil
 }
 
+// isulad gets credentials of registries from kubelet by CRI
+func (ir *isuladRuntime) PrepareRegistryAuths(r runner.Runner, workerConfig *api.WorkerConfig) error {
+	return prepareKubeletRegistryAuths(r, workerConfig.ContainerEngineConf.RegistryAuths)
+}
+
+func (ir *isuladRuntime) GetCgroupDriverOfArgs(extraArgs map[string]string) string {
+	return extraArgs["--native.cgroupdriver"]
+}
+
+func (ir *isuladRuntime) GetCgroupDriverCommand() string {
+	return "isula info | awk -F': ' '/Cgroup Driver/{print $2}'"
+}
+
 func (ir *isuladRuntime) GetRemovedPath() []string {
 	return []string{
 		"/usr/lib/systemd/system/isulad.service",
+		kubeletRegistryAuthPath,
 	}
 }
 
@@ -160,6 +191,10 @@ func (dr *dockerRuntime) GetRuntimeLoadImageCommand() string {
 	return "docker load -i"
 }
 
+func (dr *dockerRuntime) GetRuntimeImageCheckCommand(image string) string {
+	return fmt.Sprintf("docker image inspect %s", image)
+}
+
 func (dr *dockerRuntime) GetRuntimeService() string {
 	return "docker"
 }
@@ -174,6 +209,9 @@ After=network.target
 Type=notify
 EnvironmentFile=-/etc/sysconfig/docker
 ExecStart=/usr/bin/dockerd \
+{{- if .cgroupDriver }}
+        --exec-opt native.cgroupdriver={{ .cgroupDriver }} \
+{{- end }}
 {{- range $i, $v := .registry }}
         --registry-mirror {{ $v }} \
 {{- end }}
@@ -211,6 +249,7 @@ WantedBy=multi-user.target
 	}
 
 	datastore := map[string]interface{}{}
+	datastore["cgroupDriver"] = cgroupDriverToRender(dr, workerConfig)
 	datastore["registry"] = registry
 	datastore["insecure"] = insecure
 	datastore["addition"] = addition
@@ -234,9 +273,27 @@ WantedBy=multi-user.target
 	return nil
 }
 
+// dockershim of kubelet pulls images with credentials of registries
+func (dr *dockerRuntime) PrepareRegistryAuths(r runner.Runner, workerConfig *api.WorkerConfig) error {
+	return prepareKubeletRegistryAuths(r, workerConfig.ContainerEngineConf.RegistryAuths)
+}
+
+func (dr *dockerRuntime) GetCgroupDriverOfArgs(extraArgs map[string]string) string {
+	opt := extraArgs["--exec-opt"]
+	if !strings.HasPrefix(opt, "native.cgroupdriver=") {
+		return ""
+	}
+	return strings.TrimPrefix(opt, "native.cgroupdriver=")
+}
+
+func (dr *dockerRuntime) GetCgroupDriverCommand() string {
+	return "docker info --format '{{ .CgroupDriver }}'"
+}
+
 func (dr *dockerRuntime) GetRemovedPath() []string {
 	return []string{
 		"/usr/lib/systemd/system/docker.service",
+		kubeletRegistryAuthPath,
 	}
 }
 
@@ -252,7 +309,12 @@ func (cr *containerdRuntime) GetRuntimeClient() string {
 }
 
 func (cr *containerdRuntime) GetRuntimeLoadImageCommand() string {
-	return "ctr cri load"
+	// images of kubelet are in namespace k8s.io
+	return "ctr -n k8s.io images import"
+}
+
+func (cr *containerdRuntime) GetRuntimeImageCheckCommand(image string) string {
+	return fmt.Sprintf("ctr -n k8s.io images ls -q name==%s | grep -q .", image)
 }
 
 func (cr *containerdRuntime) GetRuntimeService() string {
@@ -307,10 +369,25 @@ WantedBy=multi-user.target
 	return nil
 }
 
+// credentials of registries are written into config.toml by PrepareRuntimeService
+func (cr *containerdRuntime) PrepareRegistryAuths(r runner.Runner, workerConfig *api.WorkerConfig) error {
+	return nil
+}
+
+// extra args of containerd are lines of config.toml, cgroup driver is always set by config of kubelet
+func (cr *containerdRuntime) GetCgroupDriverOfArgs(extraArgs map[string]string) string {
+	return ""
+}
+
+func (cr *containerdRuntime) GetCgroupDriverCommand() string {
+	return fmt.Sprintf("containerd config dump | grep -q 'SystemdCgroup = true' && echo %s || echo %s",
+		constants.CgroupDriverSystemd, constants.CgroupDriverCgroupfs)
+}
+
 func (cr *containerdRuntime) GetRemovedPath() []string {
 	return []string{
 		"/usr/lib/systemd/system/containerd.service",
-		"/etc/containerd/config.toml",
+		containerdConfigPath,
 	}
 }
 
@@ -318,6 +395,8 @@ func prepareContainerdConfig(r runner.Runner, workerConfig *api.WorkerConfig) er
 	containerdConfig := `
 [plugins.cri]
   sandbox_image = "{{ .pauseImage }}"
+[plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runc.options]
+  SystemdCgroup = {{ .systemdCgroup }}
 {{- $alen := len .registryAggregate }}
 {{- if ne $alen 0 }}
 [plugins."io.containerd.grpc.v1.cri".registry]
@@ -335,6 +414,11 @@ func prepareContainerdConfig(r runner.Runner, workerConfig *api.WorkerConfig) er
       insecure_skip_verify = true
 {{- end }}
 {{- end }}
+{{- range $i, $v := .auths }}
+  [plugins."io.containerd.grpc.v1.cri".registry.configs.{{ $v.Registry }}.auth]
+    username = {{ $v.Username }}
+    password = {{ $v.Password }}
+{{- end }}
 {{- range $i, $v := .addition }}
 {{ .addition }}
 {{- end }}
@@ -370,18 +454,25 @@ func prepareContainerdConfig(r runner.Runner, workerConfig *api.WorkerConfig) er
 
 	datastore := map[string]interface{}{}
 	datastore["pauseImage"] = pauseImage
+	datastore["systemdCgroup"] = workerConfig.KubeletConf.GetCgroupDriver() == constants.CgroupDriverSystemd
 	datastore["registryAggregate"] = registryAggregate
 	datastore["insecure"] = insecureTmp
+	datastore["auths"] = containerdRegistryAuths(workerConfig.ContainerEngineConf.RegistryAuths)
 	datastore["addition"] = addition
 	containerdConf, err := template.TemplateRender(containerdConfig, datastore)
 	if err != nil {
 		return err
 	}
 
+	// command with credentials may be logged, so copy config to node
+	if len(workerConfig.ContainerEngineConf.RegistryAuths) != 0 {
+		return writeSecretFile(r, []byte(containerdConf), containerdConfigPath)
+	}
+
 	var sb strings.Builder
 	containerdBase64 := base64.StdEncoding.EncodeToString([]byte(containerdConf))
 	sb.WriteString(fmt.Sprintf("sudo -E /bin/sh -c \"mkdir -p /etc/containerd && echo %s | base64 -d > %s\"",
-		containerdBase64, "/etc/containerd/config.toml"))
+		containerdBase64, containerdConfigPath))
 	_, err = r.RunCommand(sb.String())
 	if err != nil {
 		return err
@@ -398,8 +489,17 @@ type DeployRuntimeTask struct {
 }
 
 func NewDeployRuntimeTask(ccfg *api.ClusterConfig) *DeployRuntimeTask {
+	// pause image rendered into config of runtime is in image repository of cluster, same as kubelet
+	workerConfig := ccfg.WorkerConfig
+	kubelet := api.Kubelet{}
+	if workerConfig.KubeletConf != nil {
+		kubelet = *workerConfig.KubeletConf
+	}
+	kubelet.PauseImage = ccfg.GetImage(getPauseImage(&ccfg.WorkerConfig))
+	workerConfig.KubeletConf = &kubelet
+
 	return &DeployRuntimeTask{
-		workerConfig: &ccfg.WorkerConfig,
+		workerConfig: &workerConfig,
 		workerInfra:  ccfg.RoleInfra[api.Worker],
 		packageSrc:   &ccfg.PackageSrc,
 	}
@@ -433,12 +533,23 @@ func (ct *DeployRuntimeTask) Run(r runner.Runner, hcg *api.HostConfig) error {
 		return err
 	}
 
+	if err := ct.runtime.PrepareRegistryAuths(r, ct.workerConfig); err != nil {
+		logrus.Errorf("prepare auths of registries failed: %v", err)
+		return err
+	}
+
 	if err := dependency.InstallImageDependency(r, ct.workerInfra, ct.packageSrc, ct.runtime.GetRuntimeService(),
 		ct.runtime.GetRuntimeClient(), ct.runtime.GetRuntimeLoadImageCommand()); err != nil {
 		logrus.Errorf("load images failed: %v", err)
 		return err
 	}
 
+	imagePackage := ct.packageSrc.GetImagePackageDstPath(ct.workerConfig.ContainerEngineConf.ImagePackage)
+	if err := loadImagePackage(r, ct.runtime, imagePackage, getPauseImage(ct.workerConfig)); err != nil {
+		logrus.Errorf("load image package on %s failed: %v", hcg.Address, err)
+		return fmt.Errorf("load image package on %s failed: %v", hcg.Address, err)
+	}
+
 	logrus.Info("deploy container engine success\n")
 	return nil
 }
@@ -458,6 +569,33 @@ func (ct *DeployRuntimeTask) check(r runner.Runner) error {
 	return nil
 }
 
+// cgroupDriverToRender returns cgroup driver of kubelet, empty if it has been set by extra args of runtime
+func cgroupDriverToRender(rt Runtime, workerConfig *api.WorkerConfig) string {
+	if rt.GetCgroupDriverOfArgs(workerConfig.ContainerEngineConf.ExtraArgs) != "" {
+		return ""
+	}
+	return workerConfig.KubeletConf.GetCgroupDriver()
+}
+
+// CheckCgroupDriver checks cgroup driver of kubelet is valid and matches driver set by extra args of runtime
+func CheckCgroupDriver(driver string, runtimeName string, extraArgs map[string]string) error {
+	if driver == "" {
+		driver = constants.CgroupDriverSystemd
+	}
+	if driver != constants.CgroupDriverSystemd && driver != constants.CgroupDriverCgroupfs {
+		return fmt.Errorf("invalid cgroup driver: %s, support %s and %s", driver,
+			constants.CgroupDriverSystemd, constants.CgroupDriverCgroupfs)
+	}
+	rt := GetRuntime(runtimeName)
+	if rt == nil {
+		return nil
+	}
+	if rd := rt.GetCgroupDriverOfArgs(extraArgs); rd != "" && rd != driver {
+		return fmt.Errorf("cgroup driver %s of runtime %s mismatch with cgroup driver %s of kubelet", rd, rt.GetRuntimeService(), driver)
+	}
+	return nil
+}
+
 func GetRuntime(runtime string) Runtime {
 	if runtime == "" {
 		return mapRuntime["docker"]
@@ -0,0 +1,94 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for cgroup driver of container runtime
+ ******************************************************************************/
+
+package runtime
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner/runnertest"
+)
+
+func TestCheckCgroupDriver(t *testing.T) {
+	cases := []struct {
+		driver  string
+		runtime string
+		args    map[string]string
+		valid   bool
+	}{
+		{driver: "", runtime: "", valid: true},
+		{driver: "cgroupfs", runtime: "containerd", valid: true},
+		{driver: "systemd", runtime: "docker", args: map[string]string{"--exec-opt": "native.cgroupdriver=systemd"}, valid: true},
+		{driver: "", runtime: "iSulad", args: map[string]string{"--native.cgroupdriver": "systemd"}, valid: true},
+		{driver: "cgroupfs", runtime: "docker", args: map[string]string{"--exec-opt": "native.umask=normal"}, valid: true},
+		{driver: "systemd", runtime: "docker", args: map[string]string{"--exec-opt": "native.cgroupdriver=cgroupfs"}, valid: false},
+		{driver: "", runtime: "isulad", args: map[string]string{"--native.cgroupdriver": "cgroupfs"}, valid: false},
+		{driver: "cgroup", runtime: "containerd", valid: false},
+	}
+	for _, c := range cases {
+		err := CheckCgroupDriver(c.driver, c.runtime, c.args)
+		if (err == nil) != c.valid {
+			t.Fatalf("check cgroup driver %q of runtime %q with args %v, expect valid: %v, get: %v",
+				c.driver, c.runtime, c.args, c.valid, err)
+		}
+	}
+}
+
+func TestCgroupDriverToRender(t *testing.T) {
+	wc := &api.WorkerConfig{
+		KubeletConf:         &api.Kubelet{},
+		ContainerEngineConf: &api.ContainerEngine{ExtraArgs: map[string]string{}},
+	}
+	if d := cgroupDriverToRender(&dockerRuntime{}, wc); d != "systemd" {
+		t.Fatalf("expect default cgroup driver systemd, get: %s", d)
+	}
+	wc.KubeletConf.CgroupDriver = "cgroupfs"
+	if d := cgroupDriverToRender(&isuladRuntime{}, wc); d != "cgroupfs" {
+		t.Fatalf("expect cgroup driver cgroupfs of kubelet, get: %s", d)
+	}
+	// do not render cgroup driver twice if it is set by extra args
+	wc.ContainerEngineConf.ExtraArgs["--exec-opt"] = "native.cgroupdriver=cgroupfs"
+	if d := cgroupDriverToRender(&dockerRuntime{}, wc); d != "" {
+		t.Fatalf("expect no cgroup driver to render, get: %s", d)
+	}
+}
+
+func TestContainerdConfigCgroupDriver(t *testing.T) {
+	wc := &api.WorkerConfig{
+		KubeletConf:         &api.Kubelet{},
+		ContainerEngineConf: &api.ContainerEngine{},
+	}
+	for driver, expect := range map[string]string{"": "SystemdCgroup = true", "cgroupfs": "SystemdCgroup = false"} {
+		wc.KubeletConf.CgroupDriver = driver
+		r := &runnertest.Runner{}
+		if err := prepareContainerdConfig(r, wc); err != nil {
+			t.Fatalf("prepare containerd config failed: %v", err)
+		}
+		if len(r.Commands) != 1 {
+			t.Fatalf("expect one command to write config, get: %v", r.Commands)
+		}
+		encoded := strings.TrimPrefix(strings.Split(r.Commands[0], " | base64")[0], "sudo -E /bin/sh -c \"mkdir -p /etc/containerd && echo ")
+		config, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatalf("decode config failed: %v", err)
+		}
+		if !strings.Contains(string(config), expect) {
+			t.Fatalf("expect %q in config of cgroup driver %q, get: %s", expect, driver, config)
+		}
+	}
+}
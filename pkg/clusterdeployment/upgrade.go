@@ -0,0 +1,175 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: rolling upgrade of cluster
+ ******************************************************************************/
+
+package clusterdeployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/version"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment/manager"
+	"isula.org/eggo/pkg/constants"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/kubectl"
+)
+
+// progress of upgrade, used to resume interrupted upgrade
+type upgradeState struct {
+	TargetVersion string   `json:"target-version"`
+	Upgraded      []string `json:"upgraded"`
+}
+
+func upgradeStatePath(cluster string) string {
+	return filepath.Join(api.GetClusterHomePath(cluster), "upgrade.json")
+}
+
+// return nil if no upgrade in progress
+func loadUpgradeState(path string) (*upgradeState, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	state := &upgradeState{}
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid upgrade state %s: %v", path, err)
+	}
+	return state, nil
+}
+
+func (s *upgradeState) save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, constants.UpgradeStateFileMode)
+}
+
+func (s *upgradeState) isUpgraded(address string) bool {
+	for _, a := range s.Upgraded {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckUpgradeVersion target version must be newer than current version
+func CheckUpgradeVersion(current, target string) error {
+	cv, err := version.ParseGeneric(current)
+	if err != nil {
+		return fmt.Errorf("invalid current version %s: %v", current, err)
+	}
+	tv, err := version.ParseGeneric(target)
+	if err != nil {
+		return fmt.Errorf("invalid target version %s: %v", target, err)
+	}
+	if !cv.LessThan(tv) {
+		return fmt.Errorf("target version %s is not newer than current version %s", target, current)
+	}
+	return nil
+}
+
+// upgrade masters one by one first, then workers
+func upgradeOrder(nodes []*api.HostConfig) []*api.HostConfig {
+	var masters, workers []*api.HostConfig
+	for _, n := range nodes {
+		if utils.IsType(n.Type, api.Master) {
+			masters = append(masters, n)
+		} else if utils.IsType(n.Type, api.Worker) {
+			workers = append(workers, n)
+		}
+	}
+	return append(masters, workers...)
+}
+
+func UpgradeCluster(ctx context.Context, cc *api.ClusterConfig, conf *api.UpgradeConfig) error {
+	if cc == nil || conf == nil {
+		return fmt.Errorf("[cluster] cluster config and upgrade config are required")
+	}
+	if conf.NodeReadyTimeout <= 0 {
+		conf.NodeReadyTimeout = time.Minute * constants.DefaultNodeReadyWaitMinutes
+	}
+
+	statePath := upgradeStatePath(cc.Name)
+	state, err := loadUpgradeState(statePath)
+	if err != nil {
+		return err
+	}
+	if state != nil && state.TargetVersion != conf.TargetVersion {
+		return fmt.Errorf("[cluster] upgrade to %s is not finished, please continue it first", state.TargetVersion)
+	}
+	if state == nil {
+		current, err := kubectl.GetServerVersion(cc.Name)
+		if err != nil {
+			return fmt.Errorf("[cluster] get current version of cluster failed: %v", err)
+		}
+		if err = CheckUpgradeVersion(current, conf.TargetVersion); err != nil {
+			return err
+		}
+		state = &upgradeState{TargetVersion: conf.TargetVersion}
+		if err = state.save(statePath); err != nil {
+			return err
+		}
+	} else {
+		logrus.Infof("[cluster] resume upgrade to %s, upgraded nodes: %v", state.TargetVersion, state.Upgraded)
+	}
+
+	creator, err := manager.GetClusterDeploymentDriver(cc.DeployDriver)
+	if err != nil {
+		logrus.Errorf("[cluster] get cluster deployment driver: %s failed: %v", cc.DeployDriver, err)
+		return err
+	}
+	handler, err := creator(cc)
+	if err != nil {
+		logrus.Errorf("[cluster] create cluster deployment instance with driver: %s, failed: %v", cc.DeployDriver, err)
+		return err
+	}
+	defer handler.Finish()
+
+	for _, n := range upgradeOrder(cc.Nodes) {
+		if state.isUpgraded(n.Address) {
+			logrus.Infof("[cluster] node %s is upgraded, skip it", n.Name)
+			continue
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("[cluster] upgrade is interrupted: %v, run 'eggo upgrade' again to continue", ctx.Err())
+		}
+		if err = handler.ClusterNodeUpgrade(ctx, n, conf); err != nil {
+			return fmt.Errorf("[cluster] %v, fix it and run 'eggo upgrade' again to continue", err)
+		}
+		state.Upgraded = append(state.Upgraded, n.Address)
+		if err = state.save(statePath); err != nil {
+			return err
+		}
+		logrus.Infof("[cluster] upgrade node %s to %s success", n.Name, conf.TargetVersion)
+	}
+
+	if err = os.Remove(statePath); err != nil {
+		logrus.Warnf("[cluster] remove upgrade state failed: %v", err)
+	}
+	return nil
+}
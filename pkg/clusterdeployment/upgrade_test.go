@@ -0,0 +1,84 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: cluster upgrade testcase
+ ******************************************************************************/
+
+package clusterdeployment
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func TestCheckUpgradeVersion(t *testing.T) {
+	cases := []struct {
+		current string
+		target  string
+		valid   bool
+	}{
+		{"v1.20.2", "v1.21.1", true},
+		{"v1.20.2", "v1.20.10", true},
+		{"v1.20.2", "v1.20.2", false},
+		{"v1.21.1", "v1.20.2", false},
+		{"v1.20.2", "invalid", false},
+	}
+	for _, c := range cases {
+		err := CheckUpgradeVersion(c.current, c.target)
+		if (err == nil) != c.valid {
+			t.Fatalf("check upgrade from %s to %s, expect valid: %v, get err: %v", c.current, c.target, c.valid, err)
+		}
+	}
+}
+
+func TestUpgradeOrder(t *testing.T) {
+	nodes := []*api.HostConfig{
+		{Name: "worker0", Type: api.Worker},
+		{Name: "master0", Type: api.Master | api.Worker},
+		{Name: "etcd0", Type: api.ETCD},
+		{Name: "master1", Type: api.Master},
+	}
+	order := upgradeOrder(nodes)
+	if len(order) != 3 || order[0].Name != "master0" || order[1].Name != "master1" || order[2].Name != "worker0" {
+		t.Fatalf("invalid upgrade order: %v", order)
+	}
+}
+
+func TestUpgradeState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eggo-upgrade")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "upgrade.json")
+
+	state, err := loadUpgradeState(path)
+	if err != nil || state != nil {
+		t.Fatalf("expect no upgrade state, get: %v, %v", state, err)
+	}
+
+	state = &upgradeState{TargetVersion: "v1.21.1", Upgraded: []string{"192.168.0.2"}}
+	if err = state.save(path); err != nil {
+		t.Fatalf("save upgrade state failed: %v", err)
+	}
+	state, err = loadUpgradeState(path)
+	if err != nil || state == nil {
+		t.Fatalf("load upgrade state failed: %v", err)
+	}
+	if state.TargetVersion != "v1.21.1" || !state.isUpgraded("192.168.0.2") || state.isUpgraded("192.168.0.3") {
+		t.Fatalf("invalid upgrade state: %v", state)
+	}
+}
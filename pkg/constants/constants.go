@@ -9,7 +9,23 @@ const (
 	DefaultK8SManifestsDir = "/etc/kubernetes/manifests"
 	DefaultK8SAddonsDir    = "/etc/kubernetes/addons"
 
+	// etcd relate constants
+	DefaultEtcdDataDir = "/var/lib/etcd/default.etcd"
+	EtcdClientPort     = 2379
+	EtcdPeerPort       = 2380
+	EtcdMetricsPort    = 2381
+
+	// dns relate constants
+	DefaultDNSDomain = "cluster.local"
+	// default range of node ports of services
+	DefaultNodePortRange = "30000-32767"
+
+	// cgroup drivers of kubelet and container runtime
+	CgroupDriverSystemd  = "systemd"
+	CgroupDriverCgroupfs = "cgroupfs"
+
 	KubeConfigFileNameAdmin      = "admin.conf"
+	KubeConfigFileNameUser       = "admin.kubeconfig"
 	KubeConfigFileNameController = "controller-manager.conf"
 	KubeConfigFileNameScheduler  = "scheduler.conf"
 	EncryptionConfigName         = "encryption-config.yaml"
@@ -39,7 +55,14 @@ const (
 	DeployConfigFileMode     os.FileMode = 0640
 	ProcessFileMode          os.FileMode = 0640
 	EncryptionConfigFileMode os.FileMode = 0600
+	KubeConfigFileMode       os.FileMode = 0600
+	MetricsFileMode          os.FileMode = 0640
+	UpgradeStateFileMode     os.FileMode = 0640
+	ClusterStateFileMode     os.FileMode = 0640
 
 	// default task wait time in minute
 	DefaultTaskWaitMinutes = 5
+
+	// default time to wait node ready after upgrade in minute
+	DefaultNodeReadyWaitMinutes = 5
 )
@@ -0,0 +1,53 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: library entrypoint of cluster deployment
+ ******************************************************************************/
+
+// Package deploy exposes cluster deployment of eggo for embedders, without cobra.
+//
+// Side effects on local host:
+//   - cluster home directory api.GetClusterHomePath(name), under api.EggoHomePath
+//     ("/etc/eggo/" by default), stores certificates and configs of cluster. It is
+//     created by Run and removed by Cleanup, or by Run itself if deploy failed and rolled back;
+//   - admin kubeconfig is written to DeployOptions.KubeConfigOut, skip it if empty.
+//
+// Embedders can change api.EggoHomePath before call Run to keep files out of system directory.
+// Run never writes ~/.eggo, saving deploy config and process place holder is work of eggo command.
+package deploy
+
+import (
+	"context"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment"
+)
+
+type DeployOptions struct {
+	// rollback whole cluster if deploy failed, and cleanup failed nodes if partial success
+	EnableRollback bool
+	// path to write admin kubeconfig, empty means not write
+	KubeConfigOut string
+}
+
+// Run deploys cluster with cfg, status of cluster is returned even if deploy failed
+func Run(ctx context.Context, cfg *api.ClusterConfig, opts DeployOptions) (api.ClusterStatus, error) {
+	if cfg != nil {
+		cfg.KubeConfigOut = opts.KubeConfigOut
+	}
+	return clusterdeployment.CreateCluster(ctx, cfg, opts.EnableRollback)
+}
+
+// Cleanup removes cluster deployed by Run, and cluster home directory on local host
+func Cleanup(ctx context.Context, cfg *api.ClusterConfig) error {
+	return clusterdeployment.RemoveCluster(ctx, cfg)
+}
@@ -0,0 +1,155 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase of library entrypoint of cluster deployment
+ ******************************************************************************/
+
+package deploy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/clusterdeployment/manager"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/nodemanager"
+	"isula.org/eggo/pkg/utils/runner/runnertest"
+)
+
+const testDriver = "deploy-test"
+
+// fakeHandler does nothing on nodes, other apis are not expected to be called
+type fakeHandler struct {
+	api.ClusterDeploymentAPI
+	kubeconfig string
+	joined     []string
+	cleaned    []string
+}
+
+func (h *fakeHandler) MachineInfraSetup(ctx context.Context, machine *api.HostConfig) error {
+	return nil
+}
+func (h *fakeHandler) MachineInfraDestroy(ctx context.Context, machine *api.HostConfig) error {
+	return nil
+}
+func (h *fakeHandler) CleanupLastStep(ctx context.Context, nodeName string) error {
+	h.cleaned = append(h.cleaned, nodeName)
+	return nil
+}
+func (h *fakeHandler) EtcdClusterSetup(ctx context.Context) error   { return nil }
+func (h *fakeHandler) EtcdClusterDestroy(ctx context.Context) error { return nil }
+func (h *fakeHandler) PreCreateClusterHooks(ctx context.Context) error {
+	return nil
+}
+func (h *fakeHandler) PostCreateClusterHooks(ctx context.Context, nodes []*api.HostConfig) error {
+	return nil
+}
+func (h *fakeHandler) PreDeleteClusterHooks(ctx context.Context)  {}
+func (h *fakeHandler) PostDeleteClusterHooks(ctx context.Context) {}
+func (h *fakeHandler) LoadBalancerSetup(ctx context.Context, lb *api.HostConfig) error {
+	return nil
+}
+func (h *fakeHandler) LoadBalancerDestroy(ctx context.Context, lb *api.HostConfig) error {
+	return nil
+}
+func (h *fakeHandler) ClusterControlPlaneInit(ctx context.Context, node *api.HostConfig) error {
+	return nil
+}
+func (h *fakeHandler) ClusterNodeJoin(ctx context.Context, node *api.HostConfig) error {
+	h.joined = append(h.joined, node.Name)
+	return nil
+}
+func (h *fakeHandler) ClusterNodeCleanup(ctx context.Context, node *api.HostConfig, delType uint16) error {
+	return nil
+}
+func (h *fakeHandler) AddonsSetup(ctx context.Context) error   { return nil }
+func (h *fakeHandler) AddonsDestroy(ctx context.Context) error { return nil }
+func (h *fakeHandler) Finish()                                 { nodemanager.UnRegisterAllNodes() }
+
+var handler *fakeHandler
+
+func init() {
+	if err := manager.RegisterClusterDeploymentDriver(testDriver, func(cc *api.ClusterConfig) (api.ClusterDeploymentAPI, error) {
+		for _, n := range cc.Nodes {
+			if err := nodemanager.RegisterNode(n, &runnertest.Runner{}); err != nil {
+				return nil, err
+			}
+		}
+		handler = &fakeHandler{kubeconfig: cc.KubeConfigOut}
+		return handler, nil
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func testClusterConfig() *api.ClusterConfig {
+	return &api.ClusterConfig{
+		Name:         "deploy-test",
+		DeployDriver: testDriver,
+		Nodes: []*api.HostConfig{
+			{Name: "master0", Address: "192.168.0.1", Type: api.Master | api.Worker | api.ETCD},
+			{Name: "worker0", Address: "192.168.0.2", Type: api.Worker},
+		},
+	}
+}
+
+func TestRunAndCleanup(t *testing.T) {
+	homePath := api.EggoHomePath
+	api.EggoHomePath = t.TempDir()
+	defer func() {
+		api.EggoHomePath = homePath
+	}()
+
+	kubeconfig := filepath.Join(t.TempDir(), "admin.conf")
+	cc := testClusterConfig()
+	cstatus, err := Run(context.Background(), cc, DeployOptions{KubeConfigOut: kubeconfig})
+	if err != nil {
+		t.Fatalf("run deploy failed: %v", err)
+	}
+	if !cstatus.Working || cstatus.FailureCnt != 0 || cstatus.ControlPlane != "192.168.0.1" {
+		t.Fatalf("unexpect cluster status: %+v", cstatus)
+	}
+	if handler.kubeconfig != kubeconfig {
+		t.Fatalf("expect kubeconfig out %s, get: %s", kubeconfig, handler.kubeconfig)
+	}
+	if len(handler.joined) != 2 || handler.joined[0] != "master0" || handler.joined[1] != "worker0" {
+		t.Fatalf("unexpect joined nodes: %v", handler.joined)
+	}
+	if exist, _ := utils.CheckPathExist(api.GetClusterHomePath(cc.Name)); !exist {
+		t.Fatalf("expect cluster home created")
+	}
+
+	if err = Cleanup(context.Background(), cc); err != nil {
+		t.Fatalf("run cleanup failed: %v", err)
+	}
+	if len(handler.cleaned) != 2 {
+		t.Fatalf("expect all nodes cleaned, get: %v", handler.cleaned)
+	}
+	if _, err = os.Stat(api.GetClusterHomePath(cc.Name)); !os.IsNotExist(err) {
+		t.Fatalf("expect cluster home removed, get: %v", err)
+	}
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	if _, err := Run(context.Background(), nil, DeployOptions{}); err == nil {
+		t.Fatalf("expect nil cluster config failed")
+	}
+
+	cc := testClusterConfig()
+	cc.DeployDriver = "unknown"
+	if _, err := Run(context.Background(), cc, DeployOptions{}); err == nil {
+		t.Fatalf("expect unknown driver failed")
+	}
+}
@@ -79,7 +79,7 @@ func (o *OpensshBinCertGenerator) CleanAll(savePath string) error {
 	}
 
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("sudo rm -rf %s", savePath))
+	sb.WriteString(fmt.Sprintf("sudo -E /bin/sh -c \"rm -rf %s\"", savePath))
 
 	_, err := o.r.RunCommand(sb.String())
 	if err != nil {
@@ -0,0 +1,84 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: validate and install external ca
+ ******************************************************************************/
+
+package certs
+
+import (
+	"crypto"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ValidateCA checks certFile is a valid PEM ca certificate and keyFile is the private key of it
+func ValidateCA(certFile, keyFile string) error {
+	cert, err := ReadCertFromFile(certFile)
+	if err != nil {
+		return fmt.Errorf("read ca certificate %s failed: %v", certFile, err)
+	}
+	if !cert.BasicConstraintsValid || !cert.IsCA {
+		return fmt.Errorf("certificate %s is not a ca", certFile)
+	}
+	if time.Now().After(cert.NotAfter) {
+		return fmt.Errorf("ca certificate %s is expired at %v", certFile, cert.NotAfter)
+	}
+
+	key, err := ReadKeyFromFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("read ca key %s failed: %v", keyFile, err)
+	}
+	pub, ok := cert.PublicKey.(interface {
+		Equal(crypto.PublicKey) bool
+	})
+	if !ok || !pub.Equal(key.Public()) {
+		return fmt.Errorf("ca key %s does not match certificate %s", keyFile, certFile)
+	}
+	return nil
+}
+
+// InstallExternalCA validates ca named name in srcDir, and copies it to dstDir
+func InstallExternalCA(srcDir, name, dstDir string) error {
+	certFile := filepath.Join(srcDir, GetCertName(name))
+	keyFile := filepath.Join(srcDir, GetKeyName(name))
+	if err := ValidateCA(certFile, keyFile); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dstDir, 0700); err != nil {
+		return err
+	}
+	copies := []struct {
+		src  string
+		mode os.FileMode
+	}{
+		{src: certFile, mode: 0644},
+		{src: keyFile, mode: 0600},
+	}
+	for _, c := range copies {
+		data, err := ioutil.ReadFile(c.src)
+		if err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(filepath.Join(dstDir, filepath.Base(c.src)), data, c.mode); err != nil {
+			return err
+		}
+	}
+	logrus.Infof("[certs] using external %s ca from %s", name, srcDir)
+	return nil
+}
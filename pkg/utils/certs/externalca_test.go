@@ -0,0 +1,77 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for external ca
+ ******************************************************************************/
+
+package certs
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateCA(t *testing.T) {
+	dir := t.TempDir()
+	lcg := NewLocalCertGenerator()
+	for _, ca := range []string{"ca", "other-ca"} {
+		if err := lcg.CreateCA(&CertConfig{CommonName: ca, PublicKeyAlgorithm: x509.ECDSA}, dir, ca); err != nil {
+			t.Fatalf("create %s failed: %v", ca, err)
+		}
+	}
+	leafConfig := &CertConfig{
+		CommonName: "leaf",
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if err := lcg.CreateCertAndKey(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"), leafConfig, dir, "leaf"); err != nil {
+		t.Fatalf("create leaf cert failed: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "invalid.crt"), []byte("invalid pem"), 0644); err != nil {
+		t.Fatalf("write invalid cert failed: %v", err)
+	}
+
+	if err := ValidateCA(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")); err != nil {
+		t.Fatalf("validate ca failed: %v", err)
+	}
+	invalids := [][2]string{
+		{"leaf.crt", "leaf.key"},
+		{"ca.crt", "other-ca.key"},
+		{"invalid.crt", "ca.key"},
+		{"ca.crt", "not-exist.key"},
+	}
+	for _, in := range invalids {
+		if err := ValidateCA(filepath.Join(dir, in[0]), filepath.Join(dir, in[1])); err == nil {
+			t.Fatalf("expect invalid ca: %v", in)
+		}
+	}
+
+	dst := filepath.Join(dir, "install")
+	if err := InstallExternalCA(dir, "leaf", dst); err == nil {
+		t.Fatalf("expect install leaf cert as ca failed")
+	}
+	if err := InstallExternalCA(dir, "other-ca", dst); err != nil {
+		t.Fatalf("install external ca failed: %v", err)
+	}
+	fi, err := os.Stat(filepath.Join(dst, "other-ca.key"))
+	if err != nil {
+		t.Fatalf("stat installed ca key failed: %v", err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("expect mode of installed ca key is 0600, get: %v", fi.Mode().Perm())
+	}
+	if err = ValidateCA(filepath.Join(dst, "other-ca.crt"), filepath.Join(dst, "other-ca.key")); err != nil {
+		t.Fatalf("validate installed ca failed: %v", err)
+	}
+}
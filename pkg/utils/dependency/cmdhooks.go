@@ -16,6 +16,7 @@
 package dependency
 
 import (
+	"context"
 	"fmt"
 	"path"
 
@@ -23,7 +24,6 @@ import (
 
 	"isula.org/eggo/pkg/api"
 	"isula.org/eggo/pkg/constants"
-	"isula.org/eggo/pkg/utils"
 	"isula.org/eggo/pkg/utils/nodemanager"
 	"isula.org/eggo/pkg/utils/runner"
 	"isula.org/eggo/pkg/utils/task"
@@ -51,21 +51,38 @@ func (ch *CopyHooksTask) Run(r runner.Runner, hcg *api.HostConfig) error {
 	return nil
 }
 
-func ExecuteCmdHooks(ccfg *api.ClusterConfig, nodes []*api.HostConfig, op api.HookOperator, ty api.HookType) error {
-	for _, hooks := range ccfg.HooksConf {
+// cmdHooksOfPhase returns hooks of operator and type, in order of config
+func cmdHooksOfPhase(hooksConf []*api.ClusterHookConf, op api.HookOperator, ty api.HookType) []*api.ClusterHookConf {
+	var res []*api.ClusterHookConf
+	for _, hooks := range hooksConf {
+		if hooks.Operator == op && hooks.Type == ty {
+			res = append(res, hooks)
+		}
+	}
+	return res
+}
+
+func ExecuteCmdHooks(ctx context.Context, ccfg *api.ClusterConfig, nodes []*api.HostConfig, op api.HookOperator, ty api.HookType) error {
+	for _, hooks := range cmdHooksOfPhase(ccfg.HooksConf, op, ty) {
+		shell := getCmdShell(hooks)
 		for _, node := range nodes {
-			if !utils.IsType(node.Type, hooks.Target) {
+			// target of hooks may contain several roles
+			if node.Type&hooks.Target == 0 {
 				continue
 			}
 
-			shell := getCmdShell(hooks, hooks.Target, op, ty)
-			if shell == nil {
-				return nil
-			}
-			if err := doCopyHooks(hooks, node); err != nil {
+			if err := doCopyHooks(ctx, hooks, node); err != nil {
+				if hooks.IgnoreFailure {
+					logrus.Warnf("ignore failure of copy hooks %v to %s: %v", hooks.HookFiles, node.Address, err)
+					continue
+				}
 				return err
 			}
-			if err := executeCmdHooks(ccfg, hooks, node, shell); err != nil {
+			if err := executeCmdHooks(ctx, ccfg, hooks, node, shell); err != nil {
+				if hooks.IgnoreFailure {
+					logrus.Warnf("ignore failure of hooks %v on %s: %v", hooks.HookFiles, node.Address, err)
+					continue
+				}
 				return err
 			}
 		}
@@ -73,7 +90,7 @@ func ExecuteCmdHooks(ccfg *api.ClusterConfig, nodes []*api.HostConfig, op api.Ho
 	return nil
 }
 
-func executeCmdHooks(ccfg *api.ClusterConfig, hooks *api.ClusterHookConf, hcf *api.HostConfig, shell []*api.PackageConfig) error {
+func executeCmdHooks(ctx context.Context, ccfg *api.ClusterConfig, hooks *api.ClusterHookConf, hcf *api.HostConfig, shell []*api.PackageConfig) error {
 	hookConf := &api.HookRunConfig{
 		ClusterID:          ccfg.Name,
 		ClusterAPIEndpoint: ccfg.APIEndpoint.GetURL(),
@@ -83,17 +100,14 @@ func executeCmdHooks(ccfg *api.ClusterConfig, hooks *api.ClusterHookConf, hcf *a
 		Node:               hcf,
 		HookDir:            path.Join(ccfg.PackageSrc.GetPkgDstPath(), constants.DefaultHookPath),
 		Hooks:              shell,
+		IgnoreFailure:      hooks.IgnoreFailure,
 	}
 
-	return ExecuteHooks(hookConf)
+	return ExecuteHooks(ctx, hookConf)
 }
 
-func getCmdShell(hooks *api.ClusterHookConf, target uint16, op api.HookOperator, ty api.HookType) []*api.PackageConfig {
+func getCmdShell(hooks *api.ClusterHookConf) []*api.PackageConfig {
 	res := make([]*api.PackageConfig, len(hooks.HookFiles))
-
-	if hooks.Target != target || hooks.Operator != op || hooks.Type != ty {
-		return nil
-	}
 	for i, v := range hooks.HookFiles {
 		res[i] = &api.PackageConfig{
 			Name:    v,
@@ -103,12 +117,12 @@ func getCmdShell(hooks *api.ClusterHookConf, target uint16, op api.HookOperator,
 	return res
 }
 
-func doCopyHooks(hcc *api.ClusterHookConf, node *api.HostConfig) error {
+func doCopyHooks(ctx context.Context, hcc *api.ClusterHookConf, node *api.HostConfig) error {
 	copyHooksTask := task.NewTaskInstance(&CopyHooksTask{
 		hooks: hcc,
 	})
 
-	if err := nodemanager.RunTaskOnNodes(copyHooksTask, []string{node.Address}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, copyHooksTask, []string{node.Address}); err != nil {
 		logrus.Errorf("Copy hooks failed with:%v", err)
 		return err
 	}
@@ -1,6 +1,7 @@
 package dependency
 
 import (
+	"context"
 	"testing"
 
 	"isula.org/eggo/pkg/api"
@@ -25,7 +26,7 @@ func TestCopyHooks(t *testing.T) {
 	}
 }
 
-func TestExecuteCmdHooks(t *testing.T) {
+func TestExecuteCmdHooks(ctx context.Context, t *testing.T) {
 	hooks := &api.ClusterHookConf{
 		Target:   api.Master,
 		Operator: api.HookOpDeploy,
@@ -37,7 +38,33 @@ func TestExecuteCmdHooks(t *testing.T) {
 	ccfg := &api.ClusterConfig{
 		HooksConf: []*api.ClusterHookConf{hooks},
 	}
-	if err := ExecuteCmdHooks(ccfg, []*api.HostConfig{host}, api.HookOpJoin, api.PostHookType); err != nil {
+	if err := ExecuteCmdHooks(ctx, ccfg, []*api.HostConfig{host}, api.HookOpJoin, api.PostHookType); err != nil {
 		t.Fatalf("run test failed: %v", err)
 	}
 }
+
+func TestCmdHooksOfPhase(t *testing.T) {
+	all := uint16(api.Master | api.Worker | api.ETCD | api.LoadBalance)
+	hooksConf := []*api.ClusterHookConf{
+		{Type: api.ClusterPrehookType, Operator: api.HookOpDeploy, Target: all, HookFiles: []string{"a.sh"}},
+		{Type: api.ClusterPosthookType, Operator: api.HookOpDeploy, Target: api.Worker, HookFiles: []string{"b.sh"}, IgnoreFailure: true},
+		{Type: api.ClusterPrehookType, Operator: api.HookOpCleanup, Target: all, HookFiles: []string{"c.sh"}},
+		{Type: api.ClusterPrehookType, Operator: api.HookOpDeploy, Target: api.Master, HookFiles: []string{"d.sh", "e.sh"}},
+	}
+
+	hooks := cmdHooksOfPhase(hooksConf, api.HookOpDeploy, api.ClusterPrehookType)
+	if len(hooks) != 2 || hooks[0] != hooksConf[0] || hooks[1] != hooksConf[3] {
+		t.Fatalf("expect pre deploy hooks in order of config, get: %v", hooks)
+	}
+	if hooks = cmdHooksOfPhase(hooksConf, api.HookOpDeploy, api.ClusterPosthookType); len(hooks) != 1 || hooks[0] != hooksConf[1] {
+		t.Fatalf("expect post deploy hooks, get: %v", hooks)
+	}
+	if hooks = cmdHooksOfPhase(hooksConf, api.HookOpJoin, api.PreHookType); len(hooks) != 0 {
+		t.Fatalf("expect no join hooks, get: %v", hooks)
+	}
+
+	shell := getCmdShell(hooksConf[3])
+	if len(shell) != 2 || shell[0].Name != "d.sh" || shell[1].Name != "e.sh" {
+		t.Fatalf("expect shells in order of hook files, get: %v", shell)
+	}
+}
@@ -17,6 +17,8 @@ package dependency
 
 import (
 	"fmt"
+	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -27,69 +29,63 @@ import (
 )
 
 const (
-	PrmTest = "if [ x != x$(which apt 2>/dev/null) ]; then echo apt ; elif [ x != x$(which yum 2>/dev/null) ]; then echo yum ; fi"
-	PmTest  = "if [ x != x$(which dpkg 2>/dev/null) ]; then echo dpkg ; elif [ x != x$(which rpm 2>/dev/null) ]; then echo rpm ; fi"
+	// debian family is detected by os-release, then prefer dnf to yum
+	PmTest = "if [ -f /etc/os-release ] && grep -qiE '^ID(_LIKE)?=.*(debian|ubuntu)' /etc/os-release; then echo apt ; " +
+		"elif [ x != x$(which dnf 2>/dev/null) ]; then echo dnf ; elif [ x != x$(which yum 2>/dev/null) ]; then echo yum ; " +
+		"elif [ x != x$(which apt 2>/dev/null) ]; then echo apt ; fi"
+
+	PackageManagerApt = "apt"
+	PackageManagerYum = "yum"
+	PackageManagerDnf = "dnf"
 )
 
 type managerCommand struct {
+	// install and remove packages of repo
 	installCommand string
 	removeCommand  string
+	// install local package files of pkg type
+	pkgInstallCommand string
 }
 
-func getPackageRepoManager(r runner.Runner) (*managerCommand, error) {
-	packageRepoManagerCommand := map[string]*managerCommand{
-		"apt": {
-			installCommand: "apt install -y",
-			removeCommand:  "apt remove -y",
-		},
-		"yum": {
-			installCommand: "yum install -y",
-			removeCommand:  "yum remove -y",
-		},
-	}
-
-	output, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"%s\"", PrmTest))
-	if err != nil {
-		logrus.Errorf("package repo manager test failed: %v", err)
-		return nil, err
-	}
-
-	if strings.Contains(output, "apt") {
-		return packageRepoManagerCommand["apt"], nil
-	}
-	if strings.Contains(output, "yum") {
-		return packageRepoManagerCommand["yum"], nil
-	}
-
-	return nil, fmt.Errorf("invalid package repo manager %s", output)
+var packageManagerCommand = map[string]*managerCommand{
+	PackageManagerApt: {
+		installCommand:    "DEBIAN_FRONTEND=noninteractive apt install -y",
+		removeCommand:     "DEBIAN_FRONTEND=noninteractive apt remove -y",
+		pkgInstallCommand: "dpkg --force-all -i",
+	},
+	PackageManagerYum: {
+		installCommand:    "yum install -y",
+		removeCommand:     "yum remove -y",
+		pkgInstallCommand: "rpm -ivh --force --nodeps",
+	},
+	PackageManagerDnf: {
+		installCommand:    "dnf install -y",
+		removeCommand:     "dnf remove -y",
+		pkgInstallCommand: "rpm -ivh --force --nodeps",
+	},
 }
 
-func getPackageManager(r runner.Runner) (*managerCommand, error) {
-	packageManagerCommand := map[string]*managerCommand{
-		"dpkg": {
-			installCommand: "dpkg --force-all -i",
-			removeCommand:  "apt remove -y",
-		},
-		"rpm": {
-			installCommand: "rpm -ivh --force --nodeps",
-			removeCommand:  "yum remove -y",
-		},
-	}
+func IsSupportPackageManager(manager string) bool {
+	_, ok := packageManagerCommand[manager]
+	return ok
+}
 
-	output, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"%s\"", PmTest))
-	if err != nil {
-		logrus.Errorf("package manager test failed: %v", err)
-		return nil, err
+// getPackageManager returns commands of package manager of host, detect it if not set
+func getPackageManager(r runner.Runner, manager string) (*managerCommand, error) {
+	if manager == "" {
+		output, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"%s\"", PmTest))
+		if err != nil {
+			logrus.Errorf("package manager test failed: %v", err)
+			return nil, err
+		}
+		manager = path.Base(strings.TrimSpace(output))
 	}
 
-	if strings.Contains(output, "dpkg") {
-		return packageManagerCommand["dpkg"], nil
-	}
-	if strings.Contains(output, "rpm") {
-		return packageManagerCommand["rpm"], nil
+	if mc, ok := packageManagerCommand[manager]; ok {
+		return mc, nil
 	}
 
-	return nil, fmt.Errorf("invalid package manager %s", output)
+	return nil, fmt.Errorf("invalid package manager %s", manager)
 }
 
 type dependency interface {
@@ -98,7 +94,8 @@ type dependency interface {
 }
 
 type dependencyRepo struct {
-	software []*api.PackageConfig
+	packageManager string
+	software       []*api.PackageConfig
 }
 
 func (dr *dependencyRepo) Install(r runner.Runner) error {
@@ -106,7 +103,7 @@ func (dr *dependencyRepo) Install(r runner.Runner) error {
 		return nil
 	}
 
-	prManager, err := getPackageRepoManager(r)
+	prManager, err := getPackageManager(r, dr.packageManager)
 	if err != nil {
 		return err
 	}
@@ -127,7 +124,7 @@ func (dr *dependencyRepo) Remove(r runner.Runner) error {
 		return nil
 	}
 
-	prManager, err := getPackageRepoManager(r)
+	prManager, err := getPackageManager(r, dr.packageManager)
 	if err != nil {
 		return err
 	}
@@ -136,7 +133,7 @@ func (dr *dependencyRepo) Remove(r runner.Runner) error {
 	for _, s := range dr.software {
 		join += s.Name + " "
 	}
-	if _, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"%s remove -y %s\"", prManager.removeCommand, join)); err != nil {
+	if _, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"%s %s\"", prManager.removeCommand, join)); err != nil {
 		return fmt.Errorf("%s failed: %v", prManager.removeCommand, err)
 	}
 
@@ -144,8 +141,9 @@ func (dr *dependencyRepo) Remove(r runner.Runner) error {
 }
 
 type dependencyPkg struct {
-	srcPath  string
-	software []*api.PackageConfig
+	packageManager string
+	srcPath        string
+	software       []*api.PackageConfig
 }
 
 func (dp *dependencyPkg) Install(r runner.Runner) error {
@@ -153,7 +151,7 @@ func (dp *dependencyPkg) Install(r runner.Runner) error {
 		return nil
 	}
 
-	pManager, err := getPackageManager(r)
+	pManager, err := getPackageManager(r, dp.packageManager)
 	if err != nil {
 		return err
 	}
@@ -164,8 +162,8 @@ func (dp *dependencyPkg) Install(r runner.Runner) error {
 	}
 
 	if _, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"cd %s && %s %s\"",
-		dp.srcPath, pManager.installCommand, join)); err != nil {
-		return fmt.Errorf("%s failed: %v", pManager.installCommand, err)
+		dp.srcPath, pManager.pkgInstallCommand, join)); err != nil {
+		return fmt.Errorf("%s failed: %v", pManager.pkgInstallCommand, err)
 	}
 
 	return nil
@@ -176,18 +174,18 @@ func (dp *dependencyPkg) Remove(r runner.Runner) error {
 		return nil
 	}
 
-	pManager, err := getPackageManager(r)
+	pManager, err := getPackageManager(r, dp.packageManager)
 	if err != nil {
 		return err
 	}
 
+	// remove by names of packages, not files of packages
 	join := ""
 	for _, s := range dp.software {
-		join += s.Name + "* "
+		join += s.Name + " "
 	}
 
-	if _, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"cd %s && %s %s\"",
-		dp.srcPath, pManager.removeCommand, join)); err != nil {
+	if _, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"%s %s\"", pManager.removeCommand, join)); err != nil {
 		return fmt.Errorf("%s remove failed: %v", pManager.removeCommand, err)
 	}
 
@@ -201,30 +199,44 @@ type dependencyFileDir struct {
 	software   []*api.PackageConfig
 }
 
+type fileDirTarget struct {
+	Name   string
+	Target string
+	Dir    string
+}
+
 func (df *dependencyFileDir) Install(r runner.Runner) error {
 	if len(df.software) == 0 {
 		return nil
 	}
 
+	// package is placed at dst/name, parent dirs are created, existed one is kept
 	shell := `
 #!/bin/bash
-cd {{ .srcPath }}
-
-{{- if .executable }}
-{{- range $i, $v := .software }}
-chmod +x {{ $v.Name }}
-{{- end }}
+cd {{ .srcPath }} || exit 1
+{{- range $i, $v := .targets }}
+if [ ! -e {{ $v.Target }} ]; then
+{{- if $.executable }}
+    install -D -m 0755 {{ $v.Name }} {{ $v.Target }} || exit 1
+{{- else }}
+    mkdir -p {{ $v.Dir }} && cp -r {{ $v.Name }} {{ $v.Target }} || exit 1
 {{- end }}
-
-{{- range $i, $v := .software }}
-if [ ! -e {{ JoinPath $v.Dst $v.Name }} ]; then
-    mkdir -p {{ $v.Dst }} && cp -r {{ $v.Name }} {{ $v.Dst }}
 fi
 {{- end }}
+exit 0
 `
+	var targets []fileDirTarget
+	for _, s := range df.software {
+		if err := CheckPackageDst(s.Name, s.Dst); err != nil {
+			return err
+		}
+		target := filepath.Join(s.Dst, s.Name)
+		targets = append(targets, fileDirTarget{Name: s.Name, Target: target, Dir: filepath.Dir(target)})
+	}
+
 	datastore := make(map[string]interface{})
 	datastore["srcPath"] = df.srcPath
-	datastore["software"] = df.software
+	datastore["targets"] = targets
 	datastore["executable"] = df.executable
 
 	shellStr, err := template.TemplateRender(shell, datastore)
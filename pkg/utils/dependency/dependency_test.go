@@ -1,11 +1,15 @@
 package dependency
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/sirupsen/logrus"
 
 	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner"
+	"isula.org/eggo/pkg/utils/runner/runnertest"
 )
 
 type MockRunner struct {
@@ -16,6 +20,11 @@ func (m *MockRunner) Copy(src, dst string) error {
 	return nil
 }
 
+func (m *MockRunner) CopyDir(srcDir, dstDir string) error {
+	logrus.Infof("copy dir %s to %s", srcDir, dstDir)
+	return nil
+}
+
 func (m *MockRunner) RunCommand(cmd string) (string, error) {
 	logrus.Infof("run command: %s", cmd)
 	return "", nil
@@ -51,3 +60,134 @@ func TestNewDependencyShell(t *testing.T) {
 		t.Fatalf("run test failed: %v", err)
 	}
 }
+
+var pmTestCommand = fmt.Sprintf("sudo -E /bin/sh -c \"%s\"", PmTest)
+
+// detectRunner outputs manager for detection of package manager
+func detectRunner(manager string) *runnertest.Runner {
+	return &runnertest.Runner{Outputs: map[string]string{pmTestCommand: manager + "\n"}}
+}
+
+func TestPackageManagerCommands(t *testing.T) {
+	software := []*api.PackageConfig{{Name: "conntrack"}, {Name: "socat"}}
+	tcs := []struct {
+		detected   string
+		configured string
+		expect     []string
+	}{
+		{
+			detected: "apt",
+			expect: []string{
+				"sudo -E /bin/sh -c \"DEBIAN_FRONTEND=noninteractive apt install -y conntrack socat \"",
+				"sudo -E /bin/sh -c \"DEBIAN_FRONTEND=noninteractive apt remove -y conntrack socat \"",
+				"sudo -E /bin/sh -c \"cd /pkg && dpkg --force-all -i conntrack* socat* \"",
+				"sudo -E /bin/sh -c \"DEBIAN_FRONTEND=noninteractive apt remove -y conntrack socat \"",
+			},
+		},
+		{
+			detected: "yum",
+			expect: []string{
+				"sudo -E /bin/sh -c \"yum install -y conntrack socat \"",
+				"sudo -E /bin/sh -c \"yum remove -y conntrack socat \"",
+				"sudo -E /bin/sh -c \"cd /pkg && rpm -ivh --force --nodeps conntrack* socat* \"",
+				"sudo -E /bin/sh -c \"yum remove -y conntrack socat \"",
+			},
+		},
+		{
+			// configured package manager of host is used without detection
+			detected:   "invalid",
+			configured: "dnf",
+			expect: []string{
+				"sudo -E /bin/sh -c \"dnf install -y conntrack socat \"",
+				"sudo -E /bin/sh -c \"dnf remove -y conntrack socat \"",
+				"sudo -E /bin/sh -c \"cd /pkg && rpm -ivh --force --nodeps conntrack* socat* \"",
+				"sudo -E /bin/sh -c \"dnf remove -y conntrack socat \"",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		r := detectRunner(tc.detected)
+		repo := &dependencyRepo{packageManager: tc.configured, software: software}
+		pkg := &dependencyPkg{packageManager: tc.configured, srcPath: "/pkg", software: software}
+		for _, f := range []func(r runner.Runner) error{repo.Install, repo.Remove, pkg.Install, pkg.Remove} {
+			if err := f(r); err != nil {
+				t.Fatalf("run commands of %s failed: %v", tc.detected, err)
+			}
+		}
+		var commands []string
+		for _, c := range r.Commands {
+			if c != pmTestCommand {
+				commands = append(commands, c)
+			}
+		}
+		if fmt.Sprint(commands) != fmt.Sprint(tc.expect) {
+			t.Fatalf("expect commands:\n%v\nget:\n%v", tc.expect, commands)
+		}
+	}
+
+	r := detectRunner("pacman")
+	if err := (&dependencyRepo{software: software}).Install(r); err == nil {
+		t.Fatalf("expect unsupport package manager failed")
+	}
+}
+
+func TestFileDirInstall(t *testing.T) {
+	r := &runnertest.Runner{}
+	bin := &dependencyFileDir{
+		executable: true,
+		srcPath:    "/root/.eggo/package/bin",
+		software:   []*api.PackageConfig{{Name: "kubectl", Dst: "/usr/bin"}},
+	}
+	dir := &dependencyFileDir{
+		srcPath:  "/root/.eggo/package/dir",
+		software: []*api.PackageConfig{{Name: "calico/conf", Dst: "/etc/cni/net.d"}},
+	}
+	for _, d := range []*dependencyFileDir{bin, dir} {
+		if err := d.Install(r); err != nil {
+			t.Fatalf("install failed: %v", err)
+		}
+	}
+	expects := []string{
+		"if [ ! -e /usr/bin/kubectl ]; then\n    install -D -m 0755 kubectl /usr/bin/kubectl || exit 1\nfi",
+		"if [ ! -e /etc/cni/net.d/calico/conf ]; then\n    mkdir -p /etc/cni/net.d/calico && cp -r calico/conf /etc/cni/net.d/calico/conf || exit 1\nfi",
+	}
+	for i, e := range expects {
+		if !strings.Contains(r.Shells[i], e) {
+			t.Fatalf("expect %q in shell:\n%s", e, r.Shells[i])
+		}
+	}
+
+	bin.software[0].Dst = "/usr/bin/../sbin"
+	if err := bin.Install(r); err == nil {
+		t.Fatalf("expect invalid dst failed")
+	}
+}
+
+func TestCheckPackageDst(t *testing.T) {
+	valids := [][2]string{
+		{"kubectl", "/usr/bin"},
+		{"calico", "/opt/cni/bin"},
+		{"calico/calico.conflist", "/etc/cni/net.d"},
+	}
+	for _, v := range valids {
+		if err := CheckPackageDst(v[0], v[1]); err != nil {
+			t.Fatalf("check valid dst %v failed: %v", v, err)
+		}
+	}
+
+	invalids := [][2]string{
+		{"kubectl", ""},
+		{"kubectl", "usr/bin"},
+		{"kubectl", "/usr//bin"},
+		{"", "/usr/bin"},
+		{"/kubectl", "/usr/bin"},
+		{"../../etc/passwd", "/usr/bin"},
+		{"bin", "/usr"},
+	}
+	for _, v := range invalids {
+		if err := CheckPackageDst(v[0], v[1]); err == nil {
+			t.Fatalf("expect invalid dst %v", v)
+		}
+	}
+}
@@ -16,6 +16,7 @@
 package dependency
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"path/filepath"
@@ -31,7 +32,7 @@ import (
 	"isula.org/eggo/pkg/utils/task"
 )
 
-func newBaseDependency(roleInfra *api.RoleInfra, packagePath string) map[string]dependency {
+func newBaseDependency(roleInfra *api.RoleInfra, hcf *api.HostConfig, packagePath string) map[string]dependency {
 	packages := map[string][]*api.PackageConfig{
 		"repo": {},
 		"pkg":  {},
@@ -49,11 +50,13 @@ func newBaseDependency(roleInfra *api.RoleInfra, packagePath string) map[string]
 
 	baseDependency := map[string]dependency{
 		"repo": &dependencyRepo{
-			software: packages["repo"],
+			packageManager: hcf.PackageManager,
+			software:       packages["repo"],
 		},
 		"pkg": &dependencyPkg{
-			srcPath:  path.Join(packagePath, constants.DefaultPkgPath),
-			software: packages["pkg"],
+			packageManager: hcf.PackageManager,
+			srcPath:        path.Join(packagePath, constants.DefaultPkgPath),
+			software:       packages["pkg"],
 		},
 		"bin": &dependencyFileDir{
 			executable: true,
@@ -63,6 +66,7 @@ func newBaseDependency(roleInfra *api.RoleInfra, packagePath string) map[string]
 		"file": &dependencyFileDir{
 			executable: false,
 			srcPath:    path.Join(packagePath, constants.DefaultFilePath),
+			software:   packages["file"],
 		},
 		"dir": &dependencyFileDir{
 			executable: false,
@@ -76,7 +80,7 @@ func newBaseDependency(roleInfra *api.RoleInfra, packagePath string) map[string]
 
 // install base dependency, include repo, pkg, bin, file, dir
 func InstallBaseDependency(r runner.Runner, roleInfra *api.RoleInfra, hcf *api.HostConfig, packagePath string) error {
-	baseDependency := newBaseDependency(roleInfra, packagePath)
+	baseDependency := newBaseDependency(roleInfra, hcf, packagePath)
 
 	for _, dep := range baseDependency {
 		if err := dep.Install(r); err != nil {
@@ -89,7 +93,7 @@ func InstallBaseDependency(r runner.Runner, roleInfra *api.RoleInfra, hcf *api.H
 }
 
 func RemoveBaseDependency(r runner.Runner, roleInfra *api.RoleInfra, hcf *api.HostConfig, packagePath string) {
-	baseDependency := newBaseDependency(roleInfra, packagePath)
+	baseDependency := newBaseDependency(roleInfra, hcf, packagePath)
 
 	for _, dep := range baseDependency {
 		if err := dep.Remove(r); err != nil {
@@ -162,7 +166,7 @@ func getShell(roleInfra *api.RoleInfra, schedule api.ScheduleType) []*api.Packag
 	return shell
 }
 
-func ExecuteHooks(hookConf *api.HookRunConfig) error {
+func ExecuteHooks(ctx context.Context, hookConf *api.HookRunConfig) error {
 	if hookConf == nil || len(hookConf.Hooks) == 0 {
 		return nil
 	}
@@ -195,10 +199,10 @@ func ExecuteHooks(hookConf *api.HookRunConfig) error {
 		dp: dp,
 	})
 
-	if api.IsCleanupSchedule(hookConf.Scheduler) {
+	if api.IsCleanupSchedule(hookConf.Scheduler) || hookConf.IgnoreFailure {
 		task.SetIgnoreErrorFlag(dependencyTask)
 	}
-	if err := nodemanager.RunTaskOnNodes(dependencyTask, []string{hookConf.Node.Address}); err != nil {
+	if err := nodemanager.RunTaskOnNodes(ctx, dependencyTask, []string{hookConf.Node.Address}); err != nil {
 		logrus.Errorf("Hook %s failed for %s: %v", string(api.SchedulePreJoin), hookConf.Node.Address, err)
 		return err
 	}
@@ -206,7 +210,7 @@ func ExecuteHooks(hookConf *api.HookRunConfig) error {
 	return nil
 }
 
-func executeShell(ccfg *api.ClusterConfig, role uint16, hcf *api.HostConfig, schedule api.ScheduleType) error {
+func executeShell(ctx context.Context, ccfg *api.ClusterConfig, role uint16, hcf *api.HostConfig, schedule api.ScheduleType) error {
 	shell := getShell(ccfg.RoleInfra[role], schedule)
 	if len(shell) == 0 {
 		return nil
@@ -232,17 +236,17 @@ func executeShell(ccfg *api.ClusterConfig, role uint16, hcf *api.HostConfig, sch
 		Hooks:              shell,
 	}
 
-	return ExecuteHooks(hookConf)
+	return ExecuteHooks(ctx, hookConf)
 }
 
-func HookSchedule(ccfg *api.ClusterConfig, nodes []*api.HostConfig, role []uint16, schedule api.ScheduleType) error {
+func HookSchedule(ctx context.Context, ccfg *api.ClusterConfig, nodes []*api.HostConfig, role []uint16, schedule api.ScheduleType) error {
 	for _, n := range nodes {
 		for _, r := range role {
 			if !utils.IsType(n.Type, r) {
 				continue
 			}
 
-			if err := executeShell(ccfg, r, n, schedule); err != nil {
+			if err := executeShell(ctx, ccfg, r, n, schedule); err != nil {
 				if api.IsCleanupSchedule(schedule) {
 					logrus.Errorf("execute shell failed for %s at %s: %v", n.Address, string(schedule), err)
 				} else {
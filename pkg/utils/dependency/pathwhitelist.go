@@ -16,6 +16,7 @@
 package dependency
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
 )
@@ -52,3 +53,21 @@ func CheckPath(path string) bool {
 
 	return false
 }
+
+// CheckPackageDst checks dst of package of bin/file/dir type, package is placed at dst/name,
+// which must be in white list, so that it can be removed by cleanup
+func CheckPackageDst(name, dst string) error {
+	if dst == "" {
+		return fmt.Errorf("empty dst path of package %s", name)
+	}
+	if !filepath.IsAbs(dst) || filepath.Clean(dst) != dst {
+		return fmt.Errorf("dst path %s of package %s must be clean absolute path", dst, name)
+	}
+	if name == "" || filepath.IsAbs(name) || filepath.Clean(name) != name || strings.HasPrefix(name, "..") {
+		return fmt.Errorf("invalid name of package %s, must be relative path in package", name)
+	}
+	if path := filepath.Join(dst, name); !CheckPath(path) {
+		return fmt.Errorf("path %s of package is not in white list", path)
+	}
+	return nil
+}
@@ -10,23 +10,6 @@ var (
 			Type: "repo",
 		},
 	}
-	EtcdPorts = []*api.OpenPorts{
-		// etcd api
-		{
-			Port:     2379,
-			Protocol: "tcp",
-		},
-		// etcd peer
-		{
-			Port:     2380,
-			Protocol: "tcp",
-		},
-		// etcd metric
-		{
-			Port:     2381,
-			Protocol: "tcp",
-		},
-	}
 
 	// kubernetes master
 	MasterPackages = []*api.PackageConfig{
@@ -132,6 +115,24 @@ var (
 	}
 )
 
+// GetEtcdPorts returns open ports of etcd with ports in etcd cluster config
+func GetEtcdPorts(ecc *api.EtcdClusterConfig) []*api.OpenPorts {
+	return []*api.OpenPorts{
+		{
+			Port:     ecc.GetClientPort(),
+			Protocol: "tcp",
+		},
+		{
+			Port:     ecc.GetPeerPort(),
+			Protocol: "tcp",
+		},
+		{
+			Port:     ecc.GetMetricsPort(),
+			Protocol: "tcp",
+		},
+	}
+}
+
 func RegisterInfra() map[uint16]*api.RoleInfra {
 	return map[uint16]*api.RoleInfra{
 		api.Master: {
@@ -144,7 +145,8 @@ func RegisterInfra() map[uint16]*api.RoleInfra {
 		},
 		api.ETCD: {
 			Softwares: []*api.PackageConfig{},
-			OpenPorts: EtcdPorts,
+			// ports of etcd are configurable, opened by GetEtcdPorts
+			OpenPorts: []*api.OpenPorts{},
 		},
 		api.LoadBalance: {
 			Softwares: []*api.PackageConfig{},
@@ -0,0 +1,80 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: drain node before remove it
+ ******************************************************************************/
+
+package kubectl
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/constants"
+	"isula.org/eggo/pkg/utils"
+	"isula.org/eggo/pkg/utils/runner"
+)
+
+const (
+	DefaultDrainTimeout = 120 * time.Second
+)
+
+func drainTimeout(conf *api.DrainConfig) time.Duration {
+	if conf.Timeout <= 0 {
+		return DefaultDrainTimeout
+	}
+	return conf.Timeout
+}
+
+// drainCommands returns commands to cordon node, evict pods of node which respects PodDisruptionBudgets,
+// and delete pods of node without eviction which is used if eviction timeout and force is set
+func drainCommands(kubeconfig string, node string, conf *api.DrainConfig) (string, string, string) {
+	cordon := fmt.Sprintf("KUBECONFIG=%s kubectl cordon %s", kubeconfig, node)
+	// --force of kubectl deletes pods which are not managed by controllers
+	evict := fmt.Sprintf("KUBECONFIG=%s kubectl drain %s --ignore-daemonsets --delete-emptydir-data --force --timeout=%s",
+		kubeconfig, node, drainTimeout(conf))
+	if conf.GracePeriod > 0 {
+		evict += fmt.Sprintf(" --grace-period=%d", conf.GracePeriod)
+	}
+	return cordon, evict, evict + " --disable-eviction"
+}
+
+// DrainNode cordons node and evicts its pods by kubectl on runner of master,
+// pods are deleted without eviction if eviction timeout and force is set
+func DrainNode(r runner.Runner, ccfg *api.ClusterConfig, node string) error {
+	kubeconfig := filepath.Join(ccfg.GetConfigDir(), constants.KubeConfigFileNameAdmin)
+	cordon, evict, deletePods := drainCommands(kubeconfig, node, &ccfg.Drain)
+
+	if output, err := r.RunCommand(utils.AddSudo(cordon)); err != nil {
+		return fmt.Errorf("cordon node %s failed: %v, output: %s", node, err, output)
+	}
+
+	output, err := r.RunCommand(utils.AddSudo(evict))
+	if err == nil {
+		logrus.Infof("drain node %s success", node)
+		return nil
+	}
+	if !ccfg.Drain.Force {
+		return fmt.Errorf("evict pods of node %s failed: %v, output: %s", node, err, output)
+	}
+
+	logrus.Warnf("evict pods of node %s failed: %v, delete pods ignore PodDisruptionBudgets", node, err)
+	if output, err = r.RunCommand(utils.AddSudo(deletePods)); err != nil {
+		return fmt.Errorf("delete pods of node %s failed: %v, output: %s", node, err, output)
+	}
+	logrus.Infof("drain node %s with force success", node)
+	return nil
+}
@@ -0,0 +1,90 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for drain node
+ ******************************************************************************/
+
+package kubectl
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/runner/runnertest"
+)
+
+func TestDrainCommands(t *testing.T) {
+	cordon, evict, deletePods := drainCommands("/etc/kubernetes/admin.conf", "worker0", &api.DrainConfig{})
+	if cordon != "KUBECONFIG=/etc/kubernetes/admin.conf kubectl cordon worker0" {
+		t.Fatalf("invalid cordon command: %s", cordon)
+	}
+	if !strings.Contains(evict, "kubectl drain worker0 ") || !strings.Contains(evict, "--timeout=2m0s") {
+		t.Fatalf("invalid evict command: %s", evict)
+	}
+	if strings.Contains(evict, "--grace-period") || strings.Contains(evict, "--disable-eviction") {
+		t.Fatalf("evict command should use grace period of pod and respect PodDisruptionBudgets: %s", evict)
+	}
+	if deletePods != evict+" --disable-eviction" {
+		t.Fatalf("invalid delete pods command: %s", deletePods)
+	}
+
+	_, evict, _ = drainCommands("/etc/kubernetes/admin.conf", "worker0", &api.DrainConfig{GracePeriod: 30, Timeout: 10 * time.Second})
+	if !strings.Contains(evict, "--timeout=10s") || !strings.Contains(evict, "--grace-period=30") {
+		t.Fatalf("invalid evict command with timeout and grace period: %s", evict)
+	}
+}
+
+func TestDrainNode(t *testing.T) {
+	ccfg := &api.ClusterConfig{}
+
+	// cordon and evict success
+	r := &runnertest.Runner{}
+	if err := DrainNode(r, ccfg, "worker0"); err != nil {
+		t.Fatalf("drain node failed: %v", err)
+	}
+	if len(r.Commands) != 2 || !strings.Contains(r.Commands[0], "kubectl cordon") || !strings.Contains(r.Commands[1], "kubectl drain") {
+		t.Fatalf("invalid commands of drain: %v", r.Commands)
+	}
+
+	// cordon failed, no pod is evicted
+	r = &runnertest.Runner{Fail: func(cmd string) bool { return strings.Contains(cmd, "kubectl cordon") }}
+	if err := DrainNode(r, ccfg, "worker0"); err == nil || len(r.Commands) != 1 {
+		t.Fatalf("expect drain abort when cordon failed, commands: %v", r.Commands)
+	}
+
+	// eviction timeout without force, pods are not deleted
+	evictFail := func(cmd string) bool {
+		return strings.Contains(cmd, "kubectl drain") && !strings.Contains(cmd, "--disable-eviction")
+	}
+	r = &runnertest.Runner{Fail: evictFail}
+	if err := DrainNode(r, ccfg, "worker0"); err == nil || len(r.Commands) != 2 {
+		t.Fatalf("expect drain failed when eviction timeout, commands: %v", r.Commands)
+	}
+
+	// eviction timeout with force, delete pods ignore PodDisruptionBudgets
+	ccfg.Drain.Force = true
+	r = &runnertest.Runner{Fail: evictFail}
+	if err := DrainNode(r, ccfg, "worker0"); err != nil {
+		t.Fatalf("drain node with force failed: %v", err)
+	}
+	if len(r.Commands) != 3 || !strings.Contains(r.Commands[2], "--disable-eviction") {
+		t.Fatalf("invalid commands of drain with force: %v", r.Commands)
+	}
+
+	// delete pods failed with force
+	r = &runnertest.Runner{Fail: func(cmd string) bool { return strings.Contains(cmd, "kubectl drain") }}
+	if err := DrainNode(r, ccfg, "worker0"); err == nil || len(r.Commands) != 3 {
+		t.Fatalf("expect drain failed when delete pods failed, commands: %v", r.Commands)
+	}
+}
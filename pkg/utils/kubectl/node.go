@@ -0,0 +1,108 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: kubernetes node operations
+ ******************************************************************************/
+
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	k8scorev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/constants"
+)
+
+func getClusterKubeClient(cluster string) (*kubernetes.Clientset, error) {
+	path := filepath.Join(api.GetClusterHomePath(cluster), constants.KubeConfigFileNameAdmin)
+	cs, err := GetKubeClient(path)
+	if err != nil {
+		return nil, fmt.Errorf("get kube client for cluster: %s failed: %v", cluster, err)
+	}
+	return cs, nil
+}
+
+// GetServerVersion return git version of kube-apiserver, such as v1.20.2
+func GetServerVersion(cluster string) (string, error) {
+	cs, err := getClusterKubeClient(cluster)
+	if err != nil {
+		return "", err
+	}
+	info, err := cs.Discovery().ServerVersion()
+	if err != nil {
+		return "", err
+	}
+	return info.GitVersion, nil
+}
+
+// SetNodeUnschedulable cordon or uncordon node
+func SetNodeUnschedulable(cluster string, name string, unschedulable bool) error {
+	cs, err := getClusterKubeClient(cluster)
+	if err != nil {
+		return err
+	}
+	patch := fmt.Sprintf(`{"spec":{"unschedulable":%v}}`, unschedulable)
+	_, err = cs.CoreV1().Nodes().Patch(context.TODO(), name, types.StrategicMergePatchType, []byte(patch), v1.PatchOptions{})
+	return err
+}
+
+func isNodeReady(n *k8scorev1.Node) bool {
+	for _, c := range n.Status.Conditions {
+		if c.Type == k8scorev1.NodeReady {
+			return c.Status == k8scorev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// WaitNodeReady wait node become ready and kubelet of node run with version, ignore version if it is empty
+func WaitNodeReady(ctx context.Context, cluster string, name string, version string, timeout time.Duration) error {
+	cs, err := getClusterKubeClient(cluster)
+	if err != nil {
+		return err
+	}
+
+	finish := time.After(timeout)
+	for {
+		select {
+		case t := <-finish:
+			return fmt.Errorf("timeout %s for wait node: %s ready", t.String(), name)
+		case <-ctx.Done():
+			return fmt.Errorf("interrupted for wait node: %s ready: %v", name, ctx.Err())
+		default:
+			n, err := cs.CoreV1().Nodes().Get(ctx, name, v1.GetOptions{})
+			if err != nil {
+				logrus.Debugf("get node %s, failed: %s", name, err)
+				break
+			}
+			if !isNodeReady(n) {
+				logrus.Debugf("node: %s is not ready", name)
+				break
+			}
+			if version != "" && n.Status.NodeInfo.KubeletVersion != version {
+				logrus.Debugf("node: %s run with kubelet %s, expect %s", name, n.Status.NodeInfo.KubeletVersion, version)
+				break
+			}
+			return nil
+		}
+		time.Sleep(time.Second * 2)
+	}
+}
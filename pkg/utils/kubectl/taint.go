@@ -3,13 +3,16 @@ package kubectl
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	k8scorev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"isula.org/eggo/pkg/api"
 	"isula.org/eggo/pkg/constants"
@@ -21,24 +24,82 @@ type Taint struct {
 	Effect string
 }
 
-func NodeTaintAndLabel(cluster string, objectName string, labels map[string]string, taints []Taint) error {
-	path := filepath.Join(api.GetClusterHomePath(cluster), constants.KubeConfigFileNameAdmin)
-	cs, err := GetKubeClient(path)
-	if err != nil {
-		return err
+// ParseTaint parse taint with format: key[=value]:effect
+func ParseTaint(s string) (Taint, error) {
+	var t Taint
+	idx := strings.LastIndex(s, ":")
+	if idx < 0 {
+		return t, fmt.Errorf("invalid taint: %s, format should be key[=value]:effect", s)
+	}
+	kv, effect := s[:idx], s[idx+1:]
+	switch k8scorev1.TaintEffect(effect) {
+	case k8scorev1.TaintEffectNoSchedule, k8scorev1.TaintEffectPreferNoSchedule, k8scorev1.TaintEffectNoExecute:
+	default:
+		return t, fmt.Errorf("invalid effect: %s of taint: %s", effect, s)
+	}
+	parts := strings.SplitN(kv, "=", 2)
+	if errs := validation.IsQualifiedName(parts[0]); len(errs) > 0 {
+		return t, fmt.Errorf("invalid key of taint: %s, %v", s, errs)
 	}
+	t.Key, t.Effect = parts[0], effect
+	if len(parts) == 2 {
+		if errs := validation.IsValidLabelValue(parts[1]); len(errs) > 0 {
+			return t, fmt.Errorf("invalid value of taint: %s, %v", s, errs)
+		}
+		t.Value = parts[1]
+	}
+	return t, nil
+}
 
-	n, err := cs.CoreV1().Nodes().Get(context.TODO(), objectName, v1.GetOptions{})
-	if err != nil {
-		return err
+// ParseTaints parse taints with format: key[=value]:effect
+func ParseTaints(strs []string) ([]Taint, error) {
+	var taints []Taint
+	for _, s := range strs {
+		t, err := ParseTaint(s)
+		if err != nil {
+			return nil, err
+		}
+		taints = append(taints, t)
+	}
+	return taints, nil
+}
+
+// ValidateLabels check key and value of kubernetes labels
+func ValidateLabels(labels map[string]string) error {
+	for k, v := range labels {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return fmt.Errorf("invalid label key: %s, %v", k, errs)
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return fmt.Errorf("invalid value of label: %s, %v", k, errs)
+		}
 	}
+	return nil
+}
+
+// taintAndLabelPatch create patch of node with labels and taints, taint with same key and effect is updated,
+// and removes taints with same key and effect of removeTaints, so patch is empty if node has been tainted and labeled
+func taintAndLabelPatch(n *k8scorev1.Node, labels map[string]string, taints []Taint, removeTaints []Taint) ([]byte, error) {
 	oldData, err := json.Marshal(n)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var ktaints []k8scorev1.Taint
-
+	n = n.DeepCopy()
+	var kept []k8scorev1.Taint
+	for _, tt := range n.Spec.Taints {
+		removed := false
+		for _, r := range removeTaints {
+			if tt.Key == r.Key && string(tt.Effect) == r.Effect {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			kept = append(kept, tt)
+		}
+	}
+	n.Spec.Taints = kept
 	for _, taint := range taints {
 		t := k8scorev1.Taint{
 			Key:    taint.Key,
@@ -46,8 +107,9 @@ func NodeTaintAndLabel(cluster string, objectName string, labels map[string]stri
 			Effect: k8scorev1.TaintEffect(taint.Effect),
 		}
 		flag := false
-		for _, tt := range n.Spec.Taints {
-			if tt == t {
+		for i, tt := range n.Spec.Taints {
+			if tt.Key == t.Key && tt.Effect == t.Effect {
+				n.Spec.Taints[i].Value = t.Value
 				flag = true
 				break
 			}
@@ -55,21 +117,41 @@ func NodeTaintAndLabel(cluster string, objectName string, labels map[string]stri
 		if flag {
 			continue
 		}
-		ktaints = append(ktaints, t)
+		n.Spec.Taints = append(n.Spec.Taints, t)
+	}
+	if n.Labels == nil && len(labels) > 0 {
+		n.Labels = make(map[string]string, len(labels))
 	}
-	n.Spec.Taints = append(n.Spec.Taints, ktaints...)
 	for k, v := range labels {
 		n.Labels[k] = v
 	}
 
 	newData, err := json.Marshal(n)
+	if err != nil {
+		return nil, err
+	}
+	return strategicpatch.CreateTwoWayMergePatch(oldData, newData, k8scorev1.Node{})
+}
+
+func NodeTaintAndLabel(cluster string, objectName string, labels map[string]string, taints []Taint, removeTaints []Taint) error {
+	path := filepath.Join(api.GetClusterHomePath(cluster), constants.KubeConfigFileNameAdmin)
+	cs, err := GetKubeClient(path)
+	if err != nil {
+		return err
+	}
+
+	n, err := cs.CoreV1().Nodes().Get(context.TODO(), objectName, v1.GetOptions{})
 	if err != nil {
 		return err
 	}
-	patchBytes, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, k8scorev1.Node{})
+	patchBytes, err := taintAndLabelPatch(n, labels, taints, removeTaints)
 	if err != nil {
 		return err
 	}
+	if string(patchBytes) == "{}" {
+		logrus.Infof("node: %s has been tainted and labeled", n.Name)
+		return nil
+	}
 
 	rs, err := cs.CoreV1().Nodes().Patch(context.TODO(), n.Name, types.StrategicMergePatchType, patchBytes, v1.PatchOptions{})
 	if err != nil {
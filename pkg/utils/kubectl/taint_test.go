@@ -0,0 +1,124 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for taint and label of node
+ ******************************************************************************/
+
+package kubectl
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	k8scorev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+func TestParseTaint(t *testing.T) {
+	valids := map[string]Taint{
+		"dedicated=gpu:NoSchedule":       {Key: "dedicated", Value: "gpu", Effect: "NoSchedule"},
+		"example.com/maintain:NoExecute": {Key: "example.com/maintain", Effect: "NoExecute"},
+		"spot=:PreferNoSchedule":         {Key: "spot", Effect: "PreferNoSchedule"},
+	}
+	for s, expect := range valids {
+		got, err := ParseTaint(s)
+		if err != nil {
+			t.Fatalf("parse taint %s failed: %v", s, err)
+		}
+		if got != expect {
+			t.Fatalf("parse taint %s, expect %v, get %v", s, expect, got)
+		}
+	}
+
+	for _, s := range []string{"dedicated=gpu", "dedicated=gpu:Forbid", ":NoSchedule", "bad key=gpu:NoSchedule", "dedicated=bad value:NoSchedule"} {
+		if _, err := ParseTaint(s); err == nil {
+			t.Fatalf("expect invalid taint: %s", s)
+		}
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	if err := ValidateLabels(map[string]string{"example.com/zone": "zone-a", "gpu": ""}); err != nil {
+		t.Fatalf("validate valid labels failed: %v", err)
+	}
+	if err := ValidateLabels(map[string]string{"bad key": "a"}); err == nil {
+		t.Fatalf("expect invalid label key")
+	}
+	if err := ValidateLabels(map[string]string{"zone": "bad value"}); err == nil {
+		t.Fatalf("expect invalid label value")
+	}
+}
+
+func TestTaintAndLabelPatch(t *testing.T) {
+	n := &k8scorev1.Node{}
+	n.Name = "worker0"
+	n.Spec.Taints = []k8scorev1.Taint{{Key: "dedicated", Value: "cpu", Effect: k8scorev1.TaintEffectNoSchedule}}
+
+	labels := map[string]string{"example.com/zone": "zone-a", "node-role.kubernetes.io/gpu": ""}
+	taints, err := ParseTaints([]string{"dedicated=gpu:NoSchedule", "example.com/maintain:NoExecute"})
+	if err != nil {
+		t.Fatalf("parse taints failed: %v", err)
+	}
+	patch, err := taintAndLabelPatch(n, labels, taints, nil)
+	if err != nil {
+		t.Fatalf("create patch failed: %v", err)
+	}
+	for _, expect := range []string{`"example.com/zone":"zone-a"`, `"node-role.kubernetes.io/gpu":""`,
+		`"key":"dedicated"`, `"value":"gpu"`, `"key":"example.com/maintain"`, `"effect":"NoExecute"`} {
+		if !strings.Contains(string(patch), expect) {
+			t.Fatalf("patch %s does not contain %s", patch, expect)
+		}
+	}
+	if len(n.Labels) != 0 || n.Spec.Taints[0].Value != "cpu" {
+		t.Fatalf("origin node should not be modified: %v", n)
+	}
+
+	// apply patch, then patch again should be empty
+	oldData, _ := json.Marshal(n)
+	newData, err := strategicpatch.StrategicMergePatch(oldData, patch, k8scorev1.Node{})
+	if err != nil {
+		t.Fatalf("apply patch failed: %v", err)
+	}
+	patched := &k8scorev1.Node{}
+	if err = json.Unmarshal(newData, patched); err != nil {
+		t.Fatalf("unmarshal patched node failed: %v", err)
+	}
+	if len(patched.Spec.Taints) != 2 {
+		t.Fatalf("taint with same key and effect should be updated, get: %v", patched.Spec.Taints)
+	}
+	if patch, err = taintAndLabelPatch(patched, labels, taints, nil); err != nil || string(patch) != "{}" {
+		t.Fatalf("patch again should be empty, get: %s, err: %v", patch, err)
+	}
+}
+
+func TestRemoveTaintPatch(t *testing.T) {
+	n := &k8scorev1.Node{}
+	n.Name = "master0"
+	n.Spec.Taints = []k8scorev1.Taint{
+		{Key: "node-role.kubernetes.io/master", Effect: k8scorev1.TaintEffectNoSchedule},
+		{Key: "dedicated", Value: "gpu", Effect: k8scorev1.TaintEffectNoSchedule},
+	}
+	remove := []Taint{{Key: "node-role.kubernetes.io/master", Effect: "NoSchedule"}}
+	patch, err := taintAndLabelPatch(n, nil, nil, remove)
+	if err != nil {
+		t.Fatalf("create patch failed: %v", err)
+	}
+	if strings.Contains(string(patch), "node-role.kubernetes.io/master") || !strings.Contains(string(patch), `"key":"dedicated"`) {
+		t.Fatalf("patch should only keep taint dedicated: %s", patch)
+	}
+
+	n.Spec.Taints = n.Spec.Taints[1:]
+	if patch, err = taintAndLabelPatch(n, nil, nil, remove); err != nil || string(patch) != "{}" {
+		t.Fatalf("remove taint not exist should be empty patch, get: %s, err: %v", patch, err)
+	}
+}
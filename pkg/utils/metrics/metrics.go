@@ -0,0 +1,202 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: timing metrics of deployment phases and tasks
+ ******************************************************************************/
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type PhaseMetric struct {
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"-"`
+	Seconds  float64       `json:"seconds"`
+	Nodes    []string      `json:"nodes,omitempty"`
+}
+
+type TaskMetric struct {
+	Name     string        `json:"name"`
+	Node     string        `json:"node"`
+	Duration time.Duration `json:"-"`
+	Seconds  float64       `json:"seconds"`
+	Success  bool          `json:"success"`
+}
+
+type DeployMetrics struct {
+	Start        time.Time     `json:"start"`
+	Total        time.Duration `json:"-"`
+	TotalSeconds float64       `json:"totalSeconds"`
+	Phases       []PhaseMetric `json:"phases"`
+	Tasks        []TaskMetric  `json:"tasks"`
+}
+
+var (
+	lock    sync.Mutex
+	current = DeployMetrics{Start: time.Now()}
+)
+
+// Reset drop all recorded metrics, and restart the wall-clock
+func Reset() {
+	lock.Lock()
+	defer lock.Unlock()
+	current = DeployMetrics{Start: time.Now()}
+}
+
+// StartPhase start timing of phase, return function to stop it
+func StartPhase(name string, nodes ...string) func() {
+	start := time.Now()
+	return func() {
+		d := time.Since(start)
+		lock.Lock()
+		defer lock.Unlock()
+		current.Phases = append(current.Phases, PhaseMetric{
+			Name:     name,
+			Start:    start,
+			Duration: d,
+			Seconds:  d.Seconds(),
+			Nodes:    nodes,
+		})
+	}
+}
+
+func RecordTask(name, node string, d time.Duration, err error) {
+	lock.Lock()
+	defer lock.Unlock()
+	current.Tasks = append(current.Tasks, TaskMetric{
+		Name:     name,
+		Node:     node,
+		Duration: d,
+		Seconds:  d.Seconds(),
+		Success:  err == nil,
+	})
+}
+
+// Snapshot return copy of recorded metrics, total is wall-clock time since last reset
+func Snapshot() DeployMetrics {
+	lock.Lock()
+	defer lock.Unlock()
+	m := DeployMetrics{
+		Start:  current.Start,
+		Total:  time.Since(current.Start),
+		Phases: append([]PhaseMetric{}, current.Phases...),
+		Tasks:  append([]TaskMetric{}, current.Tasks...),
+	}
+	m.TotalSeconds = m.Total.Seconds()
+	return m
+}
+
+type phaseSummary struct {
+	name string
+	// wall-clock time of phase, overlapped runs of phase on nodes in parallel are counted once
+	duration time.Duration
+	nodes    map[string]bool
+	// end of runs merged into duration
+	end time.Time
+}
+
+// merge phases with same name, phases are ordered by first start time
+func (m DeployMetrics) summary() []*phaseSummary {
+	var result []*phaseSummary
+	index := make(map[string]*phaseSummary)
+	phases := append([]PhaseMetric{}, m.Phases...)
+	sort.SliceStable(phases, func(i, j int) bool {
+		return phases[i].Start.Before(phases[j].Start)
+	})
+	for _, p := range phases {
+		ps, ok := index[p.Name]
+		if !ok {
+			ps = &phaseSummary{name: p.Name, nodes: make(map[string]bool)}
+			index[p.Name] = ps
+			result = append(result, ps)
+		}
+		// runs are ordered by start, so only the part after end of previous runs is added
+		start, end := p.Start, p.Start.Add(p.Duration)
+		if start.Before(ps.end) {
+			start = ps.end
+		}
+		if end.After(start) {
+			ps.duration += end.Sub(start)
+			ps.end = end
+		}
+		for _, n := range p.Nodes {
+			ps.nodes[n] = true
+		}
+	}
+	return result
+}
+
+type nodeSummary struct {
+	name   string
+	tasks  int
+	failed int
+	// tasks of node run one by one, so it is time node is busy
+	duration time.Duration
+}
+
+// merge tasks by node, nodes are ordered by name
+func (m DeployMetrics) nodeSummary() []*nodeSummary {
+	var result []*nodeSummary
+	index := make(map[string]*nodeSummary)
+	for _, t := range m.Tasks {
+		ns, ok := index[t.Node]
+		if !ok {
+			ns = &nodeSummary{name: t.Node}
+			index[t.Node] = ns
+			result = append(result, ns)
+		}
+		ns.tasks++
+		ns.duration += t.Duration
+		if !t.Success {
+			ns.failed++
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].name < result[j].name
+	})
+	return result
+}
+
+// Show return summary table of phases and tasks of nodes
+func (m DeployMetrics) Show() string {
+	var sb strings.Builder
+	sb.WriteString("-------------------------------\n")
+	sb.WriteString("phase\t\t\tduration\tnodes\n")
+	for _, ps := range m.summary() {
+		var nodes []string
+		for n := range ps.nodes {
+			nodes = append(nodes, n)
+		}
+		sort.Strings(nodes)
+		nodeStr := strings.Join(nodes, ",")
+		if nodeStr == "" {
+			nodeStr = "-"
+		}
+		sb.WriteString(fmt.Sprintf("%-24s%s\t\t%s\n", ps.name, ps.duration.Round(time.Millisecond).String(), nodeStr))
+	}
+	if nodes := m.nodeSummary(); len(nodes) > 0 {
+		sb.WriteString("\nnode\t\t\ttasks\tfailed\tduration\n")
+		for _, ns := range nodes {
+			sb.WriteString(fmt.Sprintf("%-24s%d\t%d\t%s\n", ns.name, ns.tasks, ns.failed, ns.duration.Round(time.Millisecond).String()))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("total: %s\n", m.Total.Round(time.Millisecond).String()))
+	sb.WriteString("-------------------------------\n")
+	return sb.String()
+}
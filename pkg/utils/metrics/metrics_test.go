@@ -0,0 +1,95 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for timing metrics
+ ******************************************************************************/
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	Reset()
+
+	StartPhase("MachineInfraSetup", "master0")()
+	StartPhase("EtcdClusterSetup", "master0")()
+	StartPhase("MachineInfraSetup", "worker0")()
+	RecordTask("SetupInfraTask", "master0", time.Second, nil)
+	RecordTask("SetupInfraTask", "worker0", time.Second, fmt.Errorf("failed"))
+
+	m := Snapshot()
+	if len(m.Phases) != 3 || len(m.Tasks) != 2 {
+		t.Fatalf("invalid metrics: %v", m)
+	}
+	if m.Tasks[0].Seconds != 1 || !m.Tasks[0].Success || m.Tasks[1].Success {
+		t.Fatalf("invalid task metrics: %v", m.Tasks)
+	}
+
+	summary := m.summary()
+	if len(summary) != 2 || summary[0].name != "MachineInfraSetup" || summary[1].name != "EtcdClusterSetup" {
+		t.Fatalf("invalid summary of phases: %v", summary)
+	}
+	if !summary[0].nodes["master0"] || !summary[0].nodes["worker0"] {
+		t.Fatalf("invalid nodes of phase: %v", summary[0].nodes)
+	}
+
+	nodes := m.nodeSummary()
+	if len(nodes) != 2 || nodes[0].name != "master0" || nodes[0].tasks != 1 || nodes[0].failed != 0 ||
+		nodes[1].name != "worker0" || nodes[1].failed != 1 || nodes[1].duration != time.Second {
+		t.Fatalf("invalid summary of nodes: %v", nodes)
+	}
+
+	show := m.Show()
+	if !strings.Contains(show, "master0,worker0") || !strings.Contains(show, "worker0                 1\t1\t1s") ||
+		!strings.Contains(show, "total: ") {
+		t.Fatalf("invalid summary table: %s", show)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("marshal metrics failed: %v", err)
+	}
+	if !strings.Contains(string(data), "totalSeconds") {
+		t.Fatalf("invalid json of metrics: %s", string(data))
+	}
+
+	Reset()
+	if m = Snapshot(); len(m.Phases) != 0 || len(m.Tasks) != 0 {
+		t.Fatalf("metrics should be empty after reset: %v", m)
+	}
+}
+
+func TestPhaseWallClock(t *testing.T) {
+	start := time.Now()
+	phase := func(name, node string, offset, d time.Duration) PhaseMetric {
+		return PhaseMetric{Name: name, Start: start.Add(offset), Duration: d, Nodes: []string{node}}
+	}
+	m := DeployMetrics{Phases: []PhaseMetric{
+		// join of 3 nodes in parallel, then join another node later
+		phase("ClusterNodeJoin", "worker0", 0, 10*time.Second),
+		phase("ClusterNodeJoin", "worker1", time.Second, 10*time.Second),
+		phase("ClusterNodeJoin", "worker2", 2*time.Second, 5*time.Second),
+		phase("AddonsSetup", "master0", 11*time.Second, 4*time.Second),
+		phase("ClusterNodeJoin", "worker3", 20*time.Second, 3*time.Second),
+	}}
+	summary := m.summary()
+	if len(summary) != 2 || summary[0].name != "ClusterNodeJoin" || summary[0].duration != 14*time.Second ||
+		summary[1].duration != 4*time.Second || len(summary[0].nodes) != 4 {
+		t.Fatalf("expect wall-clock time of phases, get: %+v, %+v", summary[0], summary[1])
+	}
+}
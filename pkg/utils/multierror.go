@@ -0,0 +1,75 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: accumulation of errors of multiple nodes
+ ******************************************************************************/
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MultiError collects errors of operations on nodes, so all nodes are attempted and
+// failures are reported together. With fail fast, operations stop at the first error
+type MultiError struct {
+	failFast bool
+	errs     []error
+}
+
+func NewMultiError(failFast bool) *MultiError {
+	return &MultiError{failFast: failFast}
+}
+
+// Add collects err if it is not nil, returns true if the caller should stop
+func (m *MultiError) Add(err error) bool {
+	if err == nil {
+		return false
+	}
+	m.errs = append(m.errs, err)
+	return m.failFast
+}
+
+func (m *MultiError) Errors() []error {
+	return m.errs
+}
+
+// ErrorOrNil returns nil if no error is collected, the error itself if only one is collected
+func (m *MultiError) ErrorOrNil() error {
+	switch len(m.errs) {
+	case 0:
+		return nil
+	case 1:
+		return m.errs[0]
+	}
+	return m
+}
+
+func (m *MultiError) Error() string {
+	var msgs []string
+	for _, err := range m.errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d errors occurred: %s", len(m.errs), strings.Join(msgs, "; "))
+}
+
+// Is reports whether any of collected errors matches target
+func (m *MultiError) Is(target error) bool {
+	for _, err := range m.errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for multi error
+ ******************************************************************************/
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var errTest = errors.New("test error")
+
+func TestMultiError(t *testing.T) {
+	nodes := []string{"node0", "node1", "node2"}
+	failed := map[string]bool{"node0": true, "node2": true}
+	run := func(failFast bool) ([]string, error) {
+		var attempted []string
+		errs := NewMultiError(failFast)
+		for _, n := range nodes {
+			attempted = append(attempted, n)
+			var err error
+			if failed[n] {
+				err = fmt.Errorf("node %s: %w", n, errTest)
+			}
+			if errs.Add(err) {
+				break
+			}
+		}
+		return attempted, errs.ErrorOrNil()
+	}
+
+	// all nodes are attempted, and failures are aggregated
+	attempted, err := run(false)
+	if len(attempted) != 3 {
+		t.Fatalf("expect all nodes attempted, get: %v", attempted)
+	}
+	if err == nil || !strings.Contains(err.Error(), "node node0") || !strings.Contains(err.Error(), "node node2") {
+		t.Fatalf("expect failures of node0 and node2, get: %v", err)
+	}
+	if !errors.Is(err, errTest) {
+		t.Fatalf("expect aggregated error is test error")
+	}
+
+	// stop at the first error with fail fast
+	attempted, err = run(true)
+	if len(attempted) != 1 {
+		t.Fatalf("expect only node0 attempted, get: %v", attempted)
+	}
+	if err == nil || err.Error() != "node node0: test error" {
+		t.Fatalf("expect only failure of node0, get: %v", err)
+	}
+
+	if err = NewMultiError(false).ErrorOrNil(); err != nil {
+		t.Fatalf("expect nil without errors, get: %v", err)
+	}
+}
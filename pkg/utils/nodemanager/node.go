@@ -16,6 +16,7 @@
 package nodemanager
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -24,6 +25,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/metrics"
 	"isula.org/eggo/pkg/utils/runner"
 	"isula.org/eggo/pkg/utils/task"
 )
@@ -37,9 +39,8 @@ const (
 )
 
 const (
-	nodeQueueCapability  = 16
-	runTaskTimeOutSecond = 300
-	waitTaskMillisecond  = 200
+	nodeQueueCapability = 16
+	waitTaskMillisecond = 200
 )
 
 type NodeStatus struct {
@@ -69,12 +70,18 @@ type taskSummary struct {
 	status  string
 }
 
+// queuedTask is run with ctx it is pushed with
+type queuedTask struct {
+	ctx context.Context
+	t   task.Task
+}
+
 type Node struct {
 	host *api.HostConfig
 	r    runner.Runner
 	stop chan bool
 	// work on up to 10 tasks at a time
-	queue  chan task.Task
+	queue  chan queuedTask
 	lock   sync.RWMutex
 	status NodeStatus
 
@@ -93,6 +100,7 @@ func (n *Node) addHistory(t task.Task, err error, useTime time.Duration) {
 		}
 	}
 	n.tasksHistory = append(n.tasksHistory, ts)
+	metrics.RecordTask(ts.name, n.host.Name, useTime, err)
 }
 
 func (n *Node) ShowTaskList() string {
@@ -111,12 +119,14 @@ func (n *Node) GetStatus() NodeStatus {
 	return n.status
 }
 
-func (n *Node) WaitNodeTasksFinish(timeout time.Duration) error {
+func (n *Node) WaitNodeTasksFinish(ctx context.Context, timeout time.Duration) error {
 	finish := time.After(timeout)
 	for {
 		select {
 		case t := <-finish:
 			return fmt.Errorf("timeout %s for wait node: %s", t.String(), n.host.Name)
+		case <-ctx.Done():
+			return fmt.Errorf("interrupted for wait node: %s: %v", n.host.Name, ctx.Err())
 		default:
 			n.lock.RLock()
 			s := n.status
@@ -156,7 +166,8 @@ func (n *Node) updateNodeStatus(message string, status int) {
 	}
 }
 
-func (n *Node) PushTask(t task.Task) bool {
+// PushTask queues t on node, commands of t are killed when ctx is done
+func (n *Node) PushTask(ctx context.Context, t task.Task) bool {
 	// only run ignore error tasks to cleanup node
 	if n.status.HasError() && !task.IsIgnoreError(t) {
 		logrus.Debugf("node finished with error: %v", n.status.Message)
@@ -164,7 +175,7 @@ func (n *Node) PushTask(t task.Task) bool {
 	}
 
 	select {
-	case n.queue <- t:
+	case n.queue <- queuedTask{ctx: ctx, t: t}:
 		n.updateTotalCnt()
 		return true
 	default:
@@ -179,19 +190,22 @@ func (n *Node) Finish() {
 	logrus.Infof(n.ShowTaskList())
 }
 
-func doRunTask(n *Node, t task.Task) {
+func doRunTask(n *Node, qt queuedTask) {
 	start := time.Now()
-	echan := make(chan error)
+	ctx, t := qt.ctx, qt.t
+	echan := make(chan error, 1)
 	go func(ec chan error) {
-		select {
-		// TODO: maybe we need get timeout from task
-		case <-time.After(time.Second * runTaskTimeOutSecond):
-			ec <- fmt.Errorf("timeout to run task")
-		case ec <- t.Run(n.r, n.host):
-		}
+		// commands of task are killed on node when ctx is done
+		ec <- t.Run(runner.WithContext(ctx, n.r), n.host)
 	}(echan)
 
-	err := <-echan
+	var err error
+	// TODO: maybe we need get timeout from task
+	select {
+	case err = <-echan:
+	case <-ctx.Done():
+		err = fmt.Errorf("task is interrupted: %v", ctx.Err())
+	}
 	finish := time.Now()
 
 	if err != nil {
@@ -220,7 +234,7 @@ func NewNode(hcf *api.HostConfig, r runner.Runner) (*Node, error) {
 		host:  hcf,
 		r:     r,
 		stop:  make(chan bool),
-		queue: make(chan task.Task, nodeQueueCapability),
+		queue: make(chan queuedTask, nodeQueueCapability),
 	}
 
 	go func(n *Node) {
@@ -228,8 +242,8 @@ func NewNode(hcf *api.HostConfig, r runner.Runner) (*Node, error) {
 			select {
 			case <-n.stop:
 				return
-			case t := <-n.queue:
-				doRunTask(n, t)
+			case qt := <-n.queue:
+				doRunTask(n, qt)
 			}
 		}
 	}(n)
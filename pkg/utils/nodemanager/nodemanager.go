@@ -16,6 +16,7 @@
 package nodemanager
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -24,6 +25,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"isula.org/eggo/pkg/api"
+	"isula.org/eggo/pkg/utils/progress"
 	"isula.org/eggo/pkg/utils/runner"
 	"isula.org/eggo/pkg/utils/task"
 )
@@ -38,6 +40,13 @@ var manager = &NodeManager{
 	nodes: make(map[string]*Node, 2),
 }
 
+func checkContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("nodemanager is interrupted: %v", err)
+	}
+	return nil
+}
+
 // return: key is node IP; value true is failed, false is success
 func CheckNodesStatus(checkNodes []string) ([]*api.HostConfig, []string) {
 	var failures []*api.HostConfig
@@ -99,12 +108,12 @@ func UnRegisterAllNodes() {
 	manager.nodes = make(map[string]*Node, 2)
 }
 
-func doRetryPushTask(t task.Task, retryNodes []*Node) error {
+func doRetryPushTask(ctx context.Context, t task.Task, retryNodes []*Node) error {
 	for _, n := range retryNodes {
 		pushed := false
 		for i := 0; i < 5 && !pushed; i++ {
 			time.Sleep(time.Second)
-			pushed = n.PushTask(t)
+			pushed = n.PushTask(ctx, t)
 		}
 		if !pushed {
 			// retry failed, just return error
@@ -114,13 +123,17 @@ func doRetryPushTask(t task.Task, retryNodes []*Node) error {
 	return nil
 }
 
-func RunTaskOnNodes(t task.Task, nodes []string) error {
+// RunTaskOnNodes runs t on nodes, running commands of t are killed when ctx is done
+func RunTaskOnNodes(ctx context.Context, t task.Task, nodes []string) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
 	manager.lock.Lock()
 	defer manager.lock.Unlock()
 	var retryNodes []*Node
 	for _, id := range nodes {
 		if n, ok := manager.nodes[id]; ok {
-			if n.PushTask(t) {
+			if n.PushTask(ctx, t) {
 				continue
 			}
 			logrus.Warnf("node: %s work with too much tasks, will retry it", id)
@@ -130,27 +143,34 @@ func RunTaskOnNodes(t task.Task, nodes []string) error {
 		}
 	}
 
-	return doRetryPushTask(t, retryNodes)
+	return doRetryPushTask(ctx, t, retryNodes)
 }
 
-func RunTaskOnAll(t task.Task) error {
+func RunTaskOnAll(ctx context.Context, t task.Task) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
 	var retryNodes []*Node
 	manager.lock.Lock()
 	defer manager.lock.Unlock()
 	for id, n := range manager.nodes {
-		if n.PushTask(t) {
+		if n.PushTask(ctx, t) {
 			continue
 		}
 		logrus.Warnf("node: %s work with too much tasks, will retry it", id)
 		retryNodes = append(retryNodes, n)
 	}
 
-	return doRetryPushTask(t, retryNodes)
+	return doRetryPushTask(ctx, t, retryNodes)
 }
 
-func RunTasksOnNode(tasks []task.Task, node string) error {
+func RunTasksOnNode(ctx context.Context, tasks []task.Task, node string) error {
 	const pushTaskInterval = 6
 
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
 	manager.lock.Lock()
 	defer manager.lock.Unlock()
 
@@ -158,7 +178,7 @@ func RunTasksOnNode(tasks []task.Task, node string) error {
 		if n, ok := manager.nodes[node]; ok {
 			i := 0
 			for ; i < 5; i++ {
-				if n.PushTask(t) {
+				if n.PushTask(ctx, t) {
 					break
 				}
 				time.Sleep(time.Second * pushTaskInterval)
@@ -176,9 +196,9 @@ func RunTasksOnNode(tasks []task.Task, node string) error {
 	return nil
 }
 
-func RunTasksOnNodes(tasks []task.Task, nodes []string) error {
+func RunTasksOnNodes(ctx context.Context, tasks []task.Task, nodes []string) error {
 	for _, n := range nodes {
-		if err := RunTasksOnNode(tasks, n); err != nil {
+		if err := RunTasksOnNode(ctx, tasks, n); err != nil {
 			logrus.Errorf("run tasks on node %s failed: %v", n, err)
 			return fmt.Errorf("run tasks on node %s failed: %v", n, err)
 		}
@@ -187,7 +207,10 @@ func RunTasksOnNodes(tasks []task.Task, nodes []string) error {
 	return nil
 }
 
-func RunTaskOnOneNode(t task.Task, nodes []string) (string, error) {
+func RunTaskOnOneNode(ctx context.Context, t task.Task, nodes []string) (string, error) {
+	if err := checkContext(ctx); err != nil {
+		return "", err
+	}
 	manager.lock.Lock()
 	defer manager.lock.Unlock()
 
@@ -197,7 +220,7 @@ func RunTaskOnOneNode(t task.Task, nodes []string) (string, error) {
 			logrus.Warnf("unknown node %s for task %s", id, t.Name())
 			continue
 		}
-		if n.PushTask(t) {
+		if n.PushTask(ctx, t) {
 			return n.host.Address, nil
 		}
 	}
@@ -222,7 +245,8 @@ func checkNodeFinish(nodeID string) (bool, string, error) {
 	return false, s.ShowCounts(), nil
 }
 
-func WaitNodesFinishWithProgress(nodes []string, timeout time.Duration) error {
+// WaitNodesFinishWithProgress waits tasks of nodes finished, until timeout or ctx is done
+func WaitNodesFinishWithProgress(ctx context.Context, nodes []string, timeout time.Duration) error {
 	var errmsg string
 	unfinishedNodes := nodes
 
@@ -232,6 +256,8 @@ outfor:
 		select {
 		case t := <-finish:
 			return fmt.Errorf("timeout %s for WaitNodesFinishWithProgress", t.String())
+		case <-ctx.Done():
+			return fmt.Errorf("interrupted for WaitNodesFinishWithProgress: %v", ctx.Err())
 		default:
 			if len(unfinishedNodes) == 0 {
 				break outfor
@@ -242,6 +268,8 @@ outfor:
 				f, show, err := checkNodeFinish(id)
 				if err != nil {
 					errmsg = fmt.Sprintf("node: %s with error: %v\n%s", id, err, errmsg)
+				} else if f {
+					progress.NodeReady(id)
 				}
 				sb.WriteString("\nnode:")
 				sb.WriteString(id + " ")
@@ -265,7 +293,7 @@ outfor:
 	return nil
 }
 
-func WaitNodesFinish(nodes []string, timeout time.Duration) error {
+func WaitNodesFinish(ctx context.Context, nodes []string, timeout time.Duration) error {
 	manager.lock.RLock()
 	defer manager.lock.RUnlock()
 	var errmsg string
@@ -275,10 +303,12 @@ func WaitNodesFinish(nodes []string, timeout time.Duration) error {
 		if !ok {
 			return fmt.Errorf("unknown node %s", id)
 		}
-		err := n.WaitNodeTasksFinish(timeout)
+		err := n.WaitNodeTasksFinish(ctx, timeout)
 		if err != nil {
 			errmsg = fmt.Sprintf("node: %s with error: %v\n%s", id, err, errmsg)
+			continue
 		}
+		progress.NodeReady(id)
 	}
 	if errmsg != "" {
 		return fmt.Errorf("%s", errmsg)
@@ -286,12 +316,12 @@ func WaitNodesFinish(nodes []string, timeout time.Duration) error {
 	return nil
 }
 
-func WaitAllNodesFinished(timeout time.Duration) error {
+func WaitAllNodesFinished(ctx context.Context, timeout time.Duration) error {
 	manager.lock.RLock()
 	var nodes []string
 	for id := range manager.nodes {
 		nodes = append(nodes, id)
 	}
 	manager.lock.RUnlock()
-	return WaitNodesFinish(nodes, timeout)
+	return WaitNodesFinish(ctx, nodes, timeout)
 }
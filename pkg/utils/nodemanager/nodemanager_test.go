@@ -16,6 +16,7 @@
 package nodemanager
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"testing"
@@ -36,6 +37,11 @@ func (m *MockRunner) Copy(src, dst string) error {
 	return nil
 }
 
+func (m *MockRunner) CopyDir(srcDir, dstDir string) error {
+	logrus.Infof("copy dir %s to %s", srcDir, dstDir)
+	return nil
+}
+
 func (m *MockRunner) RunCommand(cmd string) (string, error) {
 	logrus.Infof("run command: %s", cmd)
 	return "", nil
@@ -131,12 +137,12 @@ func TestRunTaskOnNodes(t *testing.T) {
 			name: "precheck",
 		})
 	nodes := []string{"192.168.0.1", "192.168.0.2"}
-	err := RunTaskOnNodes(tt, nodes)
+	err := RunTaskOnNodes(context.Background(), tt, nodes)
 	if err != nil {
 		t.Fatalf("run task on ondes failed: %v\n", err)
 	}
 
-	err = WaitNodesFinish(nodes, time.Second*30)
+	err = WaitNodesFinish(context.Background(), nodes, time.Second*30)
 	if err != nil {
 		t.Fatalf("run task on ondes failed: %v\n", err)
 	}
@@ -145,11 +151,11 @@ func TestRunTaskOnNodes(t *testing.T) {
 		&ErrorTask{
 			name: "ErrorTask",
 		})
-	err = RunTaskOnNodes(errTask, nodes)
+	err = RunTaskOnNodes(context.Background(), errTask, nodes)
 	if err != nil {
 		t.Fatalf("run err task failed: %v", err)
 	}
-	err = WaitNodesFinish(nodes, time.Second*30)
+	err = WaitNodesFinish(context.Background(), nodes, time.Second*30)
 	if err == nil {
 		t.Fatal("run error task on ondes success")
 	}
@@ -166,17 +172,104 @@ func TestRunTaskOnAll(t *testing.T) {
 			name: "precheck",
 		},
 	)
-	err := RunTaskOnAll(tt)
+	err := RunTaskOnAll(context.Background(), tt)
 	if err != nil {
 		t.Fatalf("run task on all node failed: %v\n", err)
 	}
-	err = WaitAllNodesFinished(time.Second * 30)
+	err = WaitAllNodesFinished(context.Background(), time.Second*30)
 	if err != nil {
 		t.Fatal("run task on all ondes failed\n")
 	}
 	UnRegisterAllNodes()
 }
 
+func TestRunTaskWithCanceledContext(t *testing.T) {
+	if err := addNodes(); err != nil {
+		t.Fatalf("add nodes failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tt := task.NewTaskInstance(
+		&MockTask{
+			name: "precheck",
+		})
+	nodes := []string{"192.168.0.1", "192.168.0.2"}
+	if err := RunTaskOnNodes(ctx, tt, nodes); err == nil {
+		t.Fatalf("run task with canceled context should failed")
+	}
+
+	// context of other tasks is not affected
+	if err := RunTaskOnNodes(context.Background(), tt, nodes); err != nil {
+		t.Fatalf("run task with other context failed: %v", err)
+	}
+	if err := WaitNodesFinish(context.Background(), nodes, time.Second*30); err != nil {
+		t.Fatalf("run task on ondes failed: %v", err)
+	}
+	releaseNodes(nodes)
+}
+
+// blockTask runs command until it is interrupted
+type blockTask struct {
+	started chan struct{}
+}
+
+func (b *blockTask) Run(r runner.Runner, hcf *api.HostConfig) error {
+	b.started <- struct{}{}
+	_, err := r.RunCommand("sleep infinity")
+	return err
+}
+
+func (b *blockTask) Name() string {
+	return "blockTask"
+}
+
+type blockRunner struct {
+	MockRunner
+}
+
+func (b *blockRunner) RunCommand(cmd string) (string, error) {
+	select {}
+}
+
+func TestInterruptTaskOfContext(t *testing.T) {
+	nodes := []string{"192.168.0.1", "192.168.0.2"}
+	for _, n := range nodes {
+		if err := RegisterNode(&api.HostConfig{Name: n, Address: n}, &blockRunner{}); err != nil {
+			t.Fatalf("register node failed: %v", err)
+		}
+	}
+	defer releaseNodes(nodes)
+
+	// tasks of two deployments run with different contexts
+	ctx, cancel := context.WithCancel(context.Background())
+	other, cancelOther := context.WithCancel(context.Background())
+	defer cancelOther()
+	bt := &blockTask{started: make(chan struct{}, 2)}
+	if err := RunTaskOnNodes(ctx, task.NewTaskInstance(bt), nodes[:1]); err != nil {
+		t.Fatalf("run task failed: %v", err)
+	}
+	if err := RunTaskOnNodes(other, task.NewTaskInstance(bt), nodes[1:]); err != nil {
+		t.Fatalf("run task failed: %v", err)
+	}
+	<-bt.started
+	<-bt.started
+
+	cancel()
+	if err := WaitNodesFinish(context.Background(), nodes[:1], time.Second*10); err == nil {
+		t.Fatalf("task of canceled context should be interrupted")
+	}
+	// task of other context is still running
+	if err := WaitNodesFinish(context.Background(), nodes[1:], time.Second); err == nil {
+		t.Fatalf("task of other context should not be interrupted")
+	}
+	cancelOther()
+	if err := WaitNodesFinish(context.Background(), nodes[1:], time.Second*10); err == nil {
+		t.Fatalf("task should be interrupted after its context is done")
+	}
+}
+
 type ErrorTask struct {
 	// some need data
 	name string
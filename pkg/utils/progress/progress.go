@@ -0,0 +1,144 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: progress reporter of deploying cluster
+ ******************************************************************************/
+
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Reporter prints phase x of n, and count of ready nodes of current phase
+type Reporter struct {
+	lock sync.Mutex
+	out  io.Writer
+	// rewrite one line instead of printing new lines, for terminal
+	live  bool
+	total int
+	index int
+	phase string
+	// kind of nodes waited in phase, such as masters
+	unit  string
+	nodes map[string]bool
+	ready int
+}
+
+func NewReporter(out io.Writer, live bool, total int) *Reporter {
+	return &Reporter{out: out, live: live, total: total}
+}
+
+// IsTerminal returns true if f is a terminal, live line is only used for it
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// StartPhase moves to next phase, nodes are waited to be ready in it
+func (r *Reporter) StartPhase(name, unit string, nodes ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.index++
+	if r.index > r.total {
+		r.total = r.index
+	}
+	r.phase, r.unit = name, unit
+	r.nodes = make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		r.nodes[n] = false
+	}
+	r.ready = 0
+	r.print()
+}
+
+// NodeReady marks node ready in current phase, node not in the phase is ignored
+func (r *Reporter) NodeReady(node string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if ready, ok := r.nodes[node]; !ok || ready {
+		return
+	}
+	r.nodes[node] = true
+	r.ready++
+	r.print()
+}
+
+// Finish ends the live line, and prints result of deploy
+func (r *Reporter) Finish(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.live {
+		fmt.Fprint(r.out, "\r\033[K")
+	}
+	if err != nil {
+		fmt.Fprintf(r.out, "%s: failed: %v\n", r.line(), err)
+		return
+	}
+	fmt.Fprintf(r.out, "[%d/%d] finished\n", r.index, r.total)
+}
+
+func (r *Reporter) String() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.line()
+}
+
+func (r *Reporter) line() string {
+	s := fmt.Sprintf("[%d/%d] %s", r.index, r.total, r.phase)
+	if len(r.nodes) > 0 {
+		s += fmt.Sprintf(": %d/%d %s ready", r.ready, len(r.nodes), r.unit)
+	}
+	return s
+}
+
+func (r *Reporter) print() {
+	if r.live {
+		fmt.Fprintf(r.out, "\r\033[K%s", r.line())
+		return
+	}
+	fmt.Fprintln(r.out, r.line())
+}
+
+var (
+	lock sync.RWMutex
+	// reporter of current deploy, nothing is reported if nil
+	current *Reporter
+)
+
+// SetReporter sets reporter of current deploy, nil disables reporting
+func SetReporter(r *Reporter) {
+	lock.Lock()
+	defer lock.Unlock()
+	current = r
+}
+
+func getReporter() *Reporter {
+	lock.RLock()
+	defer lock.RUnlock()
+	return current
+}
+
+func StartPhase(name, unit string, nodes ...string) {
+	if r := getReporter(); r != nil {
+		r.StartPhase(name, unit, nodes...)
+	}
+}
+
+func NodeReady(node string) {
+	if r := getReporter(); r != nil {
+		r.NodeReady(node)
+	}
+}
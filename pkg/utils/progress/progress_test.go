@@ -0,0 +1,83 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: progress reporter testcase
+ ******************************************************************************/
+
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReporter(t *testing.T) {
+	var out bytes.Buffer
+	r := NewReporter(&out, false, 3)
+
+	r.StartPhase("etcd", "etcds", "192.168.0.2", "192.168.0.3")
+	if got := r.String(); got != "[1/3] etcd: 0/2 etcds ready" {
+		t.Fatalf("unexpect progress: %s", got)
+	}
+	r.NodeReady("192.168.0.2")
+	// ready again and node of other phase are ignored
+	r.NodeReady("192.168.0.2")
+	r.NodeReady("192.168.0.9")
+	if got := r.String(); got != "[1/3] etcd: 1/2 etcds ready" {
+		t.Fatalf("unexpect progress: %s", got)
+	}
+	r.NodeReady("192.168.0.3")
+
+	r.StartPhase("control-plane", "masters", "192.168.0.2")
+	if got := r.String(); got != "[2/3] control-plane: 0/1 masters ready" {
+		t.Fatalf("unexpect progress: %s", got)
+	}
+	r.StartPhase("addons", "")
+	if got := r.String(); got != "[3/3] addons" {
+		t.Fatalf("unexpect progress: %s", got)
+	}
+	// more phases than expected
+	r.StartPhase("hooks", "")
+	if got := r.String(); got != "[4/4] hooks" {
+		t.Fatalf("unexpect progress: %s", got)
+	}
+	r.Finish(nil)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 7 || lines[2] != "[1/3] etcd: 2/2 etcds ready" || lines[6] != "[4/4] finished" {
+		t.Fatalf("unexpect output:\n%s", out.String())
+	}
+}
+
+func TestLiveReporter(t *testing.T) {
+	var out bytes.Buffer
+	r := NewReporter(&out, true, 1)
+	SetReporter(r)
+	defer SetReporter(nil)
+
+	StartPhase("join", "nodes", "192.168.0.3")
+	NodeReady("192.168.0.3")
+	r.Finish(errors.New("timeout"))
+	if strings.Count(out.String(), "\n") != 1 || !strings.Contains(out.String(), "\r\033[K[1/1] join: 1/1 nodes ready") ||
+		!strings.HasSuffix(out.String(), "[1/1] join: 1/1 nodes ready: failed: timeout\n") {
+		t.Fatalf("unexpect live output: %q", out.String())
+	}
+
+	SetReporter(nil)
+	// nothing is reported without reporter
+	StartPhase("addons", "")
+	if r.String() != "[1/1] join: 1/1 nodes ready" {
+		t.Fatalf("progress should not change without reporter: %s", r.String())
+	}
+}
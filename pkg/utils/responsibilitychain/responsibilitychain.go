@@ -23,3 +23,15 @@ func RunChainOfResponsibility(res Responsibility) error {
 
 	return nil
 }
+
+// CollectChainOfResponsibility executes all responsibilities of chain, and returns all errors
+func CollectChainOfResponsibility(res Responsibility) []error {
+	var errs []error
+	for r := res; r != nil; r = r.Nexter() {
+		if err := r.Execute(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,103 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: runner bound to context, running commands are killed when it is done
+ ******************************************************************************/
+
+package runner
+
+import (
+	"context"
+	"fmt"
+)
+
+// contextRunner is implemented by runners which kill running command when ctx is done
+type contextRunner interface {
+	copyContext(ctx context.Context, src, dst string) error
+	copyDirContext(ctx context.Context, localDir, remoteDir string) error
+	runCommandContext(ctx context.Context, cmd string) (string, error)
+	runShellContext(ctx context.Context, shell string, name string) (string, error)
+}
+
+// RunCommandWithContext return when command finished or ctx is done, command on node is
+// killed if runner supports it, otherwise it may still running on node after ctx is done
+func RunCommandWithContext(ctx context.Context, r Runner, cmd string) (string, error) {
+	if cr, ok := r.(contextRunner); ok {
+		return cr.runCommandContext(ctx, cmd)
+	}
+
+	type result struct {
+		output string
+		err    error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		output, err := r.RunCommand(cmd)
+		ch <- result{output: output, err: err}
+	}()
+
+	select {
+	case res := <-ch:
+		return res.output, res.err
+	case <-ctx.Done():
+		return "", fmt.Errorf("run command is interrupted: %v", ctx.Err())
+	}
+}
+
+// boundRunner runs all commands of runner with ctx
+type boundRunner struct {
+	Runner
+	ctx context.Context
+}
+
+// WithContext returns runner whose commands are killed when ctx is done,
+// and new commands fail after that
+func WithContext(ctx context.Context, r Runner) Runner {
+	if br, ok := r.(*boundRunner); ok {
+		r = br.Runner
+	}
+	return &boundRunner{Runner: r, ctx: ctx}
+}
+
+func (r *boundRunner) Copy(src, dst string) error {
+	if cr, ok := r.Runner.(contextRunner); ok {
+		return cr.copyContext(r.ctx, src, dst)
+	}
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	return r.Runner.Copy(src, dst)
+}
+
+func (r *boundRunner) CopyDir(localDir, remoteDir string) error {
+	if cr, ok := r.Runner.(contextRunner); ok {
+		return cr.copyDirContext(r.ctx, localDir, remoteDir)
+	}
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	return r.Runner.CopyDir(localDir, remoteDir)
+}
+
+func (r *boundRunner) RunCommand(cmd string) (string, error) {
+	return RunCommandWithContext(r.ctx, r.Runner, cmd)
+}
+
+func (r *boundRunner) RunShell(shell string, name string) (string, error) {
+	if cr, ok := r.Runner.(contextRunner); ok {
+		return cr.runShellContext(r.ctx, shell, name)
+	}
+	if err := r.ctx.Err(); err != nil {
+		return "", err
+	}
+	return r.Runner.RunShell(shell, name)
+}
@@ -0,0 +1,110 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: copy local directory to node recursively
+ ******************************************************************************/
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+type copyEntry struct {
+	src  string
+	dst  string
+	mode os.FileMode
+}
+
+// planCopyDir walks srcDir and returns dirs and regular files to copy, parent dirs are before children
+func planCopyDir(srcDir, dstDir string) ([]copyEntry, []copyEntry, error) {
+	fi, err := os.Stat(srcDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !fi.IsDir() {
+		return nil, nil, fmt.Errorf("%s is not a directory", srcDir)
+	}
+
+	var dirs, files []copyEntry
+	err = filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		entry := copyEntry{
+			src:  path,
+			dst:  filepath.Join(dstDir, rel),
+			mode: info.Mode().Perm(),
+		}
+		switch {
+		case info.IsDir():
+			dirs = append(dirs, entry)
+		case info.Mode().IsRegular():
+			files = append(files, entry)
+		default:
+			logrus.Warnf("ignore %s which is not regular file", path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return dirs, files, nil
+}
+
+func chmodCommand(entries []copyEntry) string {
+	var cmds []string
+	for _, e := range entries {
+		cmds = append(cmds, fmt.Sprintf("chmod %04o %s", e.mode, e.dst))
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// copyDirByFiles creates dirs and copies files of srcDir to dstDir one by one with r,
+// then restores modes of copied files, modes of dstDir and dirs in it are not changed
+func copyDirByFiles(r Runner, srcDir, dstDir string) error {
+	dirs, files, err := planCopyDir(srcDir, dstDir)
+	if err != nil {
+		return err
+	}
+
+	var mkdirs []string
+	for _, d := range dirs {
+		mkdirs = append(mkdirs, d.dst)
+	}
+	if _, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"mkdir -p %s\"", strings.Join(mkdirs, " "))); err != nil {
+		return fmt.Errorf("create dirs of %s failed: %v", dstDir, err)
+	}
+
+	for _, f := range files {
+		if err := r.Copy(f.src, f.dst); err != nil {
+			return fmt.Errorf("copy %s to %s failed: %v", f.src, f.dst, err)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil
+	}
+	if _, err := r.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"%s\"", chmodCommand(files))); err != nil {
+		return fmt.Errorf("restore modes of %s failed: %v", dstDir, err)
+	}
+	return nil
+}
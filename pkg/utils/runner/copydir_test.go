@@ -0,0 +1,123 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: testcase for copy directory recursively
+ ******************************************************************************/
+
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type recordRunner struct {
+	copies   map[string]string
+	commands []string
+}
+
+func (r *recordRunner) Copy(src, dst string) error {
+	r.copies[src] = dst
+	return nil
+}
+
+func (r *recordRunner) CopyDir(srcDir, dstDir string) error {
+	return copyDirByFiles(r, srcDir, dstDir)
+}
+
+func (r *recordRunner) RunCommand(cmd string) (string, error) {
+	r.commands = append(r.commands, cmd)
+	return "", nil
+}
+
+func (r *recordRunner) RunShell(shell string, name string) (string, error) {
+	return "", nil
+}
+
+func (r *recordRunner) Reconnect() error {
+	return nil
+}
+
+func (r *recordRunner) Close() {
+}
+
+func TestCopyDir(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]os.FileMode{
+		"ca.crt":                0644,
+		"ca.key":                0600,
+		"etcd/ca.crt":           0644,
+		"etcd/peers/server.key": 0600,
+	}
+	for f, mode := range files {
+		p := filepath.Join(src, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("mkdir for %s failed: %v", f, err)
+		}
+		if err := ioutil.WriteFile(p, []byte(f), mode); err != nil {
+			t.Fatalf("write %s failed: %v", f, err)
+		}
+		if err := os.Chmod(p, mode); err != nil {
+			t.Fatalf("chmod %s failed: %v", f, err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(src, "etcd/peers"), 0700); err != nil {
+		t.Fatalf("chmod dir failed: %v", err)
+	}
+
+	r := &recordRunner{copies: make(map[string]string)}
+	if err := r.CopyDir(src, "/etc/kubernetes/pki"); err != nil {
+		t.Fatalf("copy dir failed: %v", err)
+	}
+
+	if len(r.copies) != len(files) {
+		t.Fatalf("expect copy %d files, get: %v", len(files), r.copies)
+	}
+	for f := range files {
+		if dst := r.copies[filepath.Join(src, f)]; dst != filepath.Join("/etc/kubernetes/pki", f) {
+			t.Fatalf("expect copy %s to %s, get: %s", f, filepath.Join("/etc/kubernetes/pki", f), dst)
+		}
+	}
+
+	if len(r.commands) != 2 {
+		t.Fatalf("expect mkdir and chmod commands, get: %v", r.commands)
+	}
+	mkdir := r.commands[0]
+	for _, d := range []string{"/etc/kubernetes/pki ", "/etc/kubernetes/pki/etcd ", "/etc/kubernetes/pki/etcd/peers"} {
+		if !strings.Contains(mkdir, d) {
+			t.Fatalf("mkdir command %s does not create %s", mkdir, d)
+		}
+	}
+	// parent dir must be created before child
+	if strings.Index(mkdir, "/etc/kubernetes/pki/etcd ") > strings.Index(mkdir, "/etc/kubernetes/pki/etcd/peers") {
+		t.Fatalf("parent dir should be created first: %s", mkdir)
+	}
+	for _, expect := range []string{"chmod 0600 /etc/kubernetes/pki/ca.key", "chmod 0644 /etc/kubernetes/pki/etcd/ca.crt",
+		"chmod 0600 /etc/kubernetes/pki/etcd/peers/server.key"} {
+		if !strings.Contains(r.commands[1], expect) {
+			t.Fatalf("chmod command %s does not contain %s", r.commands[1], expect)
+		}
+	}
+	// modes of dirs are not changed
+	for _, unexpect := range []string{"/etc/kubernetes/pki ", "/etc/kubernetes/pki/etcd ", "/etc/kubernetes/pki/etcd/peers "} {
+		if strings.Contains(r.commands[1]+" ", unexpect) {
+			t.Fatalf("chmod command %s should not change mode of dir %s", r.commands[1], unexpect)
+		}
+	}
+
+	if err := r.CopyDir(filepath.Join(src, "ca.crt"), "/etc/kubernetes/pki"); err == nil {
+		t.Fatalf("expect copy file as dir failed")
+	}
+}
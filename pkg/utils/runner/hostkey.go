@@ -0,0 +1,113 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: verify host key of nodes by known_hosts
+ ******************************************************************************/
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"isula.org/eggo/pkg/api"
+)
+
+const (
+	knownHostsFileMode = 0600
+)
+
+var (
+	// serialize updates of known_hosts when connect nodes concurrently
+	knownHostsLock sync.Mutex
+)
+
+func CheckHostKeyMode(mode string) error {
+	switch mode {
+	case "", api.HostKeyCheckingPermissive, api.HostKeyCheckingStrict, api.HostKeyCheckingTOFU:
+		return nil
+	}
+	return fmt.Errorf("invalid host key checking mode: %s, support: %s, %s, %s", mode,
+		api.HostKeyCheckingPermissive, api.HostKeyCheckingStrict, api.HostKeyCheckingTOFU)
+}
+
+func appendKnownHost(path string, hostport string, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, knownHostsFileMode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(knownhosts.Line([]string{knownhosts.Normalize(hostport)}, key) + "\n")
+	return err
+}
+
+func verifyKnownHost(conf *api.SSHHostKeyConfig, hostport string, remote net.Addr, key ssh.PublicKey) error {
+	knownHostsLock.Lock()
+	defer knownHostsLock.Unlock()
+
+	if conf.Checking == api.HostKeyCheckingTOFU {
+		if _, err := os.Stat(conf.KnownHostsPath); os.IsNotExist(err) {
+			logrus.Warnf("add unknown host key of %s into %s", hostport, conf.KnownHostsPath)
+			return appendKnownHost(conf.KnownHostsPath, hostport, key)
+		}
+	}
+
+	callback, err := knownhosts.New(conf.KnownHostsPath)
+	if err != nil {
+		return fmt.Errorf("load known hosts %s failed: %v", conf.KnownHostsPath, err)
+	}
+	err = callback(hostport, remote, key)
+	if err == nil {
+		return nil
+	}
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return fmt.Errorf("verify host key of %s failed: %v", hostport, err)
+	}
+	if len(keyErr.Want) > 0 {
+		return fmt.Errorf("host key of %s mismatch with %s:%d, maybe man-in-the-middle attack",
+			hostport, keyErr.Want[0].Filename, keyErr.Want[0].Line)
+	}
+	if conf.Checking != api.HostKeyCheckingTOFU {
+		return fmt.Errorf("host key of %s is not found in %s", hostport, conf.KnownHostsPath)
+	}
+	logrus.Warnf("add unknown host key of %s into %s", hostport, conf.KnownHostsPath)
+	return appendKnownHost(conf.KnownHostsPath, hostport, key)
+}
+
+// hostKeyCallback returns callback to verify host key in handshake of connection which runs commands,
+// any host key is accepted in permissive mode
+func hostKeyCallback(conf *api.SSHHostKeyConfig) (ssh.HostKeyCallback, error) {
+	if conf == nil || conf.Checking == "" || conf.Checking == api.HostKeyCheckingPermissive {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	if err := CheckHostKeyMode(conf.Checking); err != nil {
+		return nil, err
+	}
+	if conf.KnownHostsPath == "" {
+		return nil, fmt.Errorf("known hosts path is required by host key checking mode %s", conf.Checking)
+	}
+	return func(hostport string, remote net.Addr, key ssh.PublicKey) error {
+		return verifyKnownHost(conf, hostport, remote, key)
+	}, nil
+}
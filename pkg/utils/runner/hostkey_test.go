@@ -0,0 +1,106 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: host key verification testcase
+ ******************************************************************************/
+
+package runner
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	kkv1alpha1 "github.com/kubesphere/kubekey/apis/kubekey/v1alpha1"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func newHostKey(t *testing.T) ssh.Signer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate host key failed: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("create signer failed: %v", err)
+	}
+	return signer
+}
+
+func TestHostKeyChecking(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "eggo-hostkey-test-")
+	if err != nil {
+		t.Fatalf("create tempdir failed: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	hostKey := newHostKey(t)
+	host := startMockSSHServer(t, hostKey)
+	hostport := net.JoinHostPort(host.Address, strconv.Itoa(host.Port))
+	knownHosts := filepath.Join(tempdir, "known_hosts")
+	conf := &api.SSHHostKeyConfig{Checking: api.HostKeyCheckingStrict, KnownHostsPath: knownHosts}
+
+	// permissive accepts any host key
+	if err = dialAndClose(host, &api.SSHHostKeyConfig{}); err != nil {
+		t.Fatalf("permissive mode should accept host key: %v", err)
+	}
+
+	// strict rejects unknown host
+	if err = dialAndClose(host, conf); err == nil {
+		t.Fatalf("strict mode should reject unknown host")
+	}
+
+	// tofu adds unknown host into known_hosts
+	conf.Checking = api.HostKeyCheckingTOFU
+	if err = dialAndClose(host, conf); err != nil {
+		t.Fatalf("tofu mode should accept first seen host: %v", err)
+	}
+
+	// strict accepts host in known_hosts
+	conf.Checking = api.HostKeyCheckingStrict
+	if err = dialAndClose(host, conf); err != nil {
+		t.Fatalf("strict mode should accept known host: %v", err)
+	}
+
+	// both strict and tofu reject mismatch host key
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostport)}, newHostKey(t).PublicKey())
+	if err = ioutil.WriteFile(knownHosts, []byte(line+"\n"), knownHostsFileMode); err != nil {
+		t.Fatalf("write known hosts failed: %v", err)
+	}
+	for _, mode := range []string{api.HostKeyCheckingStrict, api.HostKeyCheckingTOFU} {
+		conf.Checking = mode
+		if err = dialAndClose(host, conf); err == nil {
+			t.Fatalf("%s mode should reject mismatch host key", mode)
+		}
+	}
+
+	if err = dialAndClose(host, &api.SSHHostKeyConfig{Checking: "invalid"}); err == nil {
+		t.Fatalf("invalid mode should be rejected")
+	}
+}
+
+func dialAndClose(host *kkv1alpha1.HostCfg, conf *api.SSHHostKeyConfig) error {
+	conn, err := dialSSH(host, "", conf, defaultTestTimeout)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
@@ -0,0 +1,134 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: keep ssh connection alive, and redial it after it is broken
+ ******************************************************************************/
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"isula.org/eggo/pkg/api"
+)
+
+const (
+	DefaultSSHKeepAliveInterval = 30 * time.Second
+)
+
+// messages of errors caused by broken connection, rather than failed command
+var connectionErrors = []string{
+	"broken pipe",
+	"connection reset",
+	"use of closed network connection",
+	"connection timed out",
+	"EOF",
+}
+
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+	msg := err.Error()
+	for _, e := range connectionErrors {
+		if strings.Contains(msg, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeepAliveInterval returns interval of ssh keepalive, 0 means disabled
+func KeepAliveInterval(cfg *api.SSHKeepAliveConfig) (time.Duration, error) {
+	if cfg == nil || cfg.Interval == "" {
+		return DefaultSSHKeepAliveInterval, nil
+	}
+	d, err := time.ParseDuration(cfg.Interval)
+	if err != nil || d < 0 {
+		return 0, errors.New("invalid ssh keepalive interval: " + cfg.Interval)
+	}
+	return d, nil
+}
+
+// ensureConnected redials connection found broken, by stored config of host
+func (ssh *SSHRunner) ensureConnected() error {
+	ssh.lock.Lock()
+	defer ssh.lock.Unlock()
+	if !ssh.broken {
+		if ssh.Conn == nil {
+			return errors.New("SSH runner is not connected")
+		}
+		return nil
+	}
+	logrus.Infof("[%s] connection is broken, redial it", ssh.Host.Name)
+	conn, err := connect(ssh.Host, ssh.AgentSocket, ssh.HostKey, ssh.connectTimeout())
+	if err != nil {
+		logrus.Errorf("[%s] redial failed: %v", ssh.Host.Name, err)
+		return err
+	}
+	if ssh.Conn != nil {
+		ssh.Conn.Close()
+	}
+	ssh.Conn, ssh.broken = conn, false
+	ssh.lastActive = time.Now()
+	return nil
+}
+
+// checkConnection marks connection broken if err is caused by it, next command will redial
+func (ssh *SSHRunner) checkConnection(err error) {
+	ssh.lock.Lock()
+	defer ssh.lock.Unlock()
+	ssh.lastActive = time.Now()
+	if isConnectionError(err) {
+		logrus.Warnf("[%s] connection is broken: %v", ssh.Host.Name, err)
+		ssh.broken = true
+	}
+}
+
+// keepAlive runs noop command on idle connection, so connection is not dropped by NAT or firewall,
+// and broken connection is found before next command
+func (ssh *SSHRunner) keepAlive(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ssh.lock.Lock()
+			conn, idle := ssh.Conn, !ssh.broken && time.Since(ssh.lastActive) >= interval
+			ssh.lock.Unlock()
+			if conn == nil || !idle {
+				continue
+			}
+			_, err := conn.Exec(context.Background(), "true", nil)
+			ssh.checkConnection(err)
+		}
+	}
+}
+
+func (ssh *SSHRunner) startKeepAlive(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ssh.stopKeepAlive = make(chan struct{})
+	go ssh.keepAlive(interval, ssh.stopKeepAlive)
+}
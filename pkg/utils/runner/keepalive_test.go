@@ -0,0 +1,165 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: ssh keepalive and redial testcase
+ ******************************************************************************/
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	kkv1alpha1 "github.com/kubesphere/kubekey/apis/kubekey/v1alpha1"
+
+	"isula.org/eggo/pkg/api"
+)
+
+// fakeConn simulates transport of ssh, which can be dropped by NAT
+type fakeConn struct {
+	Connection
+	lock    sync.Mutex
+	dropped bool
+	execs   []string
+}
+
+func (c *fakeConn) Exec(ctx context.Context, cmd string, stdin io.Reader) (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.dropped {
+		return "", errors.New("write tcp 192.168.0.1:22: write: broken pipe")
+	}
+	c.execs = append(c.execs, cmd)
+	return "ok", nil
+}
+
+func (c *fakeConn) Close() {
+	// nothing to do
+}
+
+func (c *fakeConn) drop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.dropped = true
+}
+
+func (c *fakeConn) execCount() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.execs)
+}
+
+func mockConnect(t *testing.T) *[]*fakeConn {
+	var dialed []*fakeConn
+	origin := connect
+	connect = func(host *kkv1alpha1.HostCfg, agentSocket string, hostKey *api.SSHHostKeyConfig, timeout time.Duration) (Connection, error) {
+		c := &fakeConn{}
+		dialed = append(dialed, c)
+		return c, nil
+	}
+	t.Cleanup(func() { connect = origin })
+	return &dialed
+}
+
+func TestRedialBrokenConnection(t *testing.T) {
+	dialed := mockConnect(t)
+	conn := &fakeConn{}
+	r := &SSHRunner{Host: &kkv1alpha1.HostCfg{Name: "node0"}, Conn: conn}
+
+	if _, err := r.RunCommand("echo eggo"); err != nil {
+		t.Fatalf("run command failed: %v", err)
+	}
+
+	conn.drop()
+	if _, err := r.RunCommand("echo eggo"); err == nil {
+		t.Fatalf("expect command failed on dropped connection")
+	}
+	if len(*dialed) != 0 {
+		t.Fatalf("command should not be retried on new connection")
+	}
+
+	// connection is redialed before next command
+	output, err := r.RunCommand("echo eggo")
+	if err != nil || output != "ok" {
+		t.Fatalf("run command after redial failed: %v, output: %s", err, output)
+	}
+	if len(*dialed) != 1 || (*dialed)[0].execCount() != 1 {
+		t.Fatalf("expect command run on redialed connection, dialed: %d", len(*dialed))
+	}
+
+	// runner never connected is not redialed
+	if _, err = (&SSHRunner{Host: &kkv1alpha1.HostCfg{Name: "node1"}}).RunCommand("echo eggo"); err == nil {
+		t.Fatalf("expect not connected runner failed")
+	}
+}
+
+func TestKeepAliveFindsDroppedConnection(t *testing.T) {
+	dialed := mockConnect(t)
+	conn := &fakeConn{}
+	r := &SSHRunner{Host: &kkv1alpha1.HostCfg{Name: "node0"}, Conn: conn}
+	r.startKeepAlive(10 * time.Millisecond)
+	defer r.Close()
+
+	// idle connection is kept alive by noop command
+	deadline := time.Now().Add(time.Second)
+	for conn.execCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("keepalive is not sent on idle connection")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	conn.drop()
+	for {
+		r.lock.Lock()
+		broken := r.broken
+		r.lock.Unlock()
+		if broken {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("keepalive does not find dropped connection")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := r.RunCommand("echo eggo"); err != nil {
+		t.Fatalf("run command after connection dropped failed: %v", err)
+	}
+	if len(*dialed) != 1 {
+		t.Fatalf("expect connection redialed once, get %d", len(*dialed))
+	}
+}
+
+func TestKeepAliveInterval(t *testing.T) {
+	cases := []struct {
+		interval string
+		expect   time.Duration
+		valid    bool
+	}{
+		{"", DefaultSSHKeepAliveInterval, true},
+		{"10s", 10 * time.Second, true},
+		{"0", 0, true},
+		{"-1s", 0, false},
+		{"abc", 0, false},
+	}
+	for _, c := range cases {
+		d, err := KeepAliveInterval(&api.SSHKeepAliveConfig{Interval: c.interval})
+		if (err == nil) != c.valid || d != c.expect {
+			t.Fatalf("interval %q: expect %v, valid %v, get %v, %v", c.interval, c.expect, c.valid, d, err)
+		}
+	}
+}
@@ -16,30 +16,39 @@
 package runner
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	kkv1alpha1 "github.com/kubesphere/kubekey/apis/kubekey/v1alpha1"
-	"github.com/kubesphere/kubekey/pkg/util/ssh"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh/agent"
 
 	"isula.org/eggo/pkg/api"
 )
 
 const (
 	RunnerShellPrefix = "eggo-shell-"
+	SSHAgentSocketEnv = "SSH_AUTH_SOCK"
+
+	DefaultSSHConnectTimeout = 15 * time.Second
 )
 
 type Runner interface {
 	// only copy file, do not support copy dir
 	Copy(src, dst string) error
+	// copy local dir recursively, keep relative structure and file modes, create parent dirs if not exist
+	CopyDir(localDir, remoteDir string) error
 	RunCommand(cmd string) (string, error)
 	// content is what shell contain, name is file name of shell
 	RunShell(content string, name string) (string, error)
@@ -47,42 +56,113 @@ type Runner interface {
 	Close()
 }
 
+// LocalRunner runs commands on the host which eggo runs on, without ssh
 type LocalRunner struct {
+	// command to elevate privilege, commands are run as they are if empty
+	Elevate string
+	// password of sudo, never log it
+	SudoPassword string
 }
 
-func (r *LocalRunner) copyDir(srcDir, dstDir string) error {
-	output, err := exec.Command("/bin/sh", "-c", fmt.Sprintf("cp -rf %v %v", srcDir, dstDir)).CombinedOutput()
-	if err != nil {
-		logrus.Errorf("[local] copy %s to %s failed: %v\noutput: %v\n", srcDir, dstDir, err, string(output))
-		return err
+// NewLocalRunner creates runner of local host, elevate is not required if eggo runs as root
+func NewLocalRunner(hcfg *api.HostConfig) (Runner, error) {
+	if err := CheckElevate(hcfg.Elevate); err != nil {
+		return nil, err
 	}
-	logrus.Debugf("[local] copy %s to %s success", srcDir, dstDir)
-	return nil
+	elevate := hcfg.Elevate
+	if elevate == "" {
+		elevate = DefaultElevate
+	}
+	if os.Geteuid() == 0 {
+		elevate = ElevateNone
+	}
+	sudoPassword := probeSudoPassword(elevate, hcfg.SudoPassword, func(cmd string) (string, error) {
+		output, err := exec.Command("/bin/sh", "-c", cmd).CombinedOutput()
+		return string(output), err
+	})
+	return &LocalRunner{Elevate: elevate, SudoPassword: sudoPassword}, nil
+}
+
+// IsLocalHost returns true if hcfg is the host which eggo runs on;
+// address of host must be an ip, so loopback ips are matched but not localhost
+func IsLocalHost(hcfg *api.HostConfig) bool {
+	if hcfg.Local {
+		return true
+	}
+	switch hcfg.Address {
+	case "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// NewRunner creates local runner for local host, and ssh runner for others
+func NewRunner(hcfg *api.HostConfig, hostKey *api.SSHHostKeyConfig, keepAlive *api.SSHKeepAliveConfig, connectTimeout string) (Runner, error) {
+	if IsLocalHost(hcfg) {
+		return NewLocalRunner(hcfg)
+	}
+	return NewSSHRunner(hcfg, hostKey, keepAlive, connectTimeout)
+}
+
+// elevate adds sudo prefix to cmd, it is replaced by elevate command of runner
+func (r *LocalRunner) elevate(cmd string) string {
+	if r.Elevate == "" {
+		return cmd
+	}
+	return fmt.Sprintf("%s/bin/sh -c \"%s\"", sudoPrefix, cmd)
 }
 
 func (r *LocalRunner) Copy(src, dst string) error {
+	return r.copyContext(context.Background(), src, dst)
+}
+
+func (r *LocalRunner) copyContext(ctx context.Context, src, dst string) error {
 	fi, err := os.Stat(src)
 	if err != nil {
-		logrus.Errorf("[local] check src dir: %s failed: %v", src, err)
+		logrus.Errorf("[local] check src: %s failed: %v", src, err)
 		return err
 	}
-	if !fi.IsDir() {
-		// just copy file
-		return r.copyDir(src, dst)
+	cmd := fmt.Sprintf("cp -f %s %s", src, dst)
+	if fi.IsDir() {
+		// copy content of dir, same as dst exists or not
+		cmd = fmt.Sprintf("mkdir -p %s && cp -rf %s/. %s", dst, src, dst)
 	}
-	output, err := exec.Command("/bin/sh", "-c", fmt.Sprintf("cp -f %v %v", src, dst)).CombinedOutput()
-	if err != nil {
-		logrus.Errorf("[local] copy %s to %s failed: %v\noutput: %v\n", src, dst, err, string(output))
-	} else {
-		logrus.Debugf("[local] copy %s to %s success", src, dst)
+	if _, err = r.runCommandContext(ctx, r.elevate(cmd)); err != nil {
+		logrus.Errorf("[local] copy %s to %s failed: %v", src, dst, err)
+		return err
 	}
-	return err
+	logrus.Debugf("[local] copy %s to %s success", src, dst)
+	return nil
+}
+
+func (r *LocalRunner) CopyDir(localDir, remoteDir string) error {
+	return r.copyDirContext(context.Background(), localDir, remoteDir)
+}
+
+func (r *LocalRunner) copyDirContext(ctx context.Context, localDir, remoteDir string) error {
+	if err := copyDirByFiles(WithContext(ctx, r), localDir, remoteDir); err != nil {
+		logrus.Errorf("[local] copy dir %s to %s failed: %v", localDir, remoteDir, err)
+		return err
+	}
+	logrus.Debugf("[local] copy dir %s to %s success", localDir, remoteDir)
+	return nil
 }
 
 func (r *LocalRunner) RunCommand(cmd string) (string, error) {
-	output, err := exec.Command("/bin/sh", "-c", cmd).CombinedOutput()
-	if err != nil {
-		logrus.Errorf("[local] run command: %s, failed: %v", cmd, err)
+	return r.runCommandContext(context.Background(), cmd)
+}
+
+func (r *LocalRunner) runCommandContext(ctx context.Context, cmd string) (string, error) {
+	elevated, stdin := cmd, io.Reader(nil)
+	if r.Elevate != "" {
+		elevated, stdin = elevateCommand(cmd, r.Elevate, r.SudoPassword)
+	}
+	// log cmd before elevated
+	c := exec.CommandContext(ctx, "/bin/sh", "-c", elevated)
+	c.Stdin = stdin
+	output, err := c.CombinedOutput()
+	if err = sudoError(string(output), err, r.SudoPassword); err != nil {
+		logrus.Errorf("[local] run command: %s, failed: %v\noutput: %s", cmd, err, string(output))
 	} else {
 		logrus.Debugf("[local] run command: %s, success", cmd)
 	}
@@ -90,7 +170,23 @@ func (r *LocalRunner) RunCommand(cmd string) (string, error) {
 }
 
 func (r *LocalRunner) RunShell(shell string, name string) (string, error) {
-	return "", nil
+	return r.runShellContext(context.Background(), shell, name)
+}
+
+func (r *LocalRunner) runShellContext(ctx context.Context, shell string, name string) (string, error) {
+	tmpDir, err := ioutil.TempDir("", RunnerShellPrefix)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	output, err := r.runCommandContext(ctx, r.elevate(shellCommand(tmpDir, shell, name)))
+	if err != nil {
+		logrus.Errorf("[local] run shell '%s' failed: %v", name, err)
+		return "", err
+	}
+	logrus.Debugf("[local] run shell '%s' success, output: %s", name, output)
+	return output, nil
 }
 
 func (r *LocalRunner) Reconnect() error {
@@ -104,20 +200,75 @@ func (r *LocalRunner) Close() {
 
 type SSHRunner struct {
 	Host *kkv1alpha1.HostCfg
-	Conn ssh.Connection
+	Conn Connection
+	// socket of ssh agent, empty means not use ssh agent
+	AgentSocket string
+	HostKey     *api.SSHHostKeyConfig
+	// password of sudo, never log it
+	SudoPassword string
+	// command to elevate privilege, such as sudo -E, doas
+	Elevate string
+	// timeout to dial node, when connect or redial
+	ConnectTimeout time.Duration
+
+	// lock protects connection, which is redialed after broken
+	lock       sync.Mutex
+	broken     bool
+	lastActive time.Time
+	// close it to stop keepalive
+	stopKeepAlive chan struct{}
 }
 
-func connect(host *kkv1alpha1.HostCfg) (ssh.Connection, error) {
-	opts := ssh.Cfg{
-		Username:   host.User,
-		Port:       host.Port,
-		Address:    host.Address,
-		Password:   host.Password,
-		PrivateKey: host.PrivateKey,
-		KeyFile:    host.PrivateKeyPath,
-		Timeout:    30 * time.Minute,
+// replaced in testcase
+var connect = dialSSH
+
+// ConnectTimeout returns timeout to dial node by ssh, default is 15s
+func ConnectTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return DefaultSSHConnectTimeout, nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil || d <= 0 {
+		return 0, errors.New("invalid ssh connect timeout: " + timeout)
 	}
-	return ssh.NewConnection(opts)
+	return d, nil
+}
+
+func checkSSHAgent(socket string) error {
+	if socket == "" {
+		return fmt.Errorf("no ssh agent socket found, %s is not set", SSHAgentSocketEnv)
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return fmt.Errorf("connect ssh agent socket %s failed: %v", socket, err)
+	}
+	defer conn.Close()
+	keys, err := agent.NewClient(conn).List()
+	if err != nil {
+		return fmt.Errorf("list keys of ssh agent %s failed: %v", socket, err)
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no identity in ssh agent %s", socket)
+	}
+	return nil
+}
+
+// getSSHAgentSocket returns socket of ssh agent if host uses it,
+// empty socket means fall back to password or private key
+func getSSHAgentSocket(hcfg *api.HostConfig) (string, error) {
+	if !hcfg.UseSSHAgent {
+		return "", nil
+	}
+	socket := os.Getenv(SSHAgentSocketEnv)
+	err := checkSSHAgent(socket)
+	if err == nil {
+		return socket, nil
+	}
+	if hcfg.Password == "" && hcfg.PrivateKey == "" && hcfg.PrivateKeyPath == "" {
+		return "", fmt.Errorf("[%s] use ssh agent failed: %v", hcfg.Name, err)
+	}
+	logrus.Warnf("[%s] ssh agent is unavailable: %v, fall back to password or private key", hcfg.Name, err)
+	return "", nil
 }
 
 func HostConfigToKKCfg(hcfg *api.HostConfig) *kkv1alpha1.HostCfg {
@@ -132,34 +283,81 @@ func HostConfigToKKCfg(hcfg *api.HostConfig) *kkv1alpha1.HostCfg {
 	}
 }
 
-func NewSSHRunner(hcfg *api.HostConfig) (Runner, error) {
+// NewSSHRunner connects host by ssh, keepalive is disabled if it is nil,
+// and connect timeout is 15s if it is empty
+func NewSSHRunner(hcfg *api.HostConfig, hostKey *api.SSHHostKeyConfig, keepAlive *api.SSHKeepAliveConfig, connectTimeout string) (Runner, error) {
 	host := HostConfigToKKCfg(hcfg)
-	conn, err := connect(host)
+	agentSocket, err := getSSHAgentSocket(hcfg)
 	if err != nil {
 		return nil, err
 	}
-	if err = prepareUserTempDir(conn, host); err != nil {
+	var interval time.Duration
+	if keepAlive != nil {
+		if interval, err = KeepAliveInterval(keepAlive); err != nil {
+			return nil, err
+		}
+	}
+	timeout, err := ConnectTimeout(connectTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := connect(host, agentSocket, hostKey, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if err = CheckElevate(hcfg.Elevate); err != nil {
+		return nil, err
+	}
+	// probe once per runner, sudoers of node is not changed by redial
+	sudoPassword := probeSudoPassword(hcfg.Elevate, hcfg.SudoPassword, func(cmd string) (string, error) {
+		return conn.Exec(context.Background(), cmd, nil)
+	})
+	if err = prepareUserTempDir(conn, host, hcfg.Elevate, sudoPassword); err != nil {
 		logrus.Errorf("[%s] prepare user temp dir failed: %v", host.Name, err)
 		return nil, err
 	}
-	return &SSHRunner{Host: host, Conn: conn}, nil
+	r := &SSHRunner{Host: host, Conn: conn, AgentSocket: agentSocket, HostKey: hostKey,
+		SudoPassword: sudoPassword, Elevate: hcfg.Elevate, ConnectTimeout: timeout, lastActive: time.Now()}
+	r.startKeepAlive(interval)
+	return r, nil
+}
+
+func (ssh *SSHRunner) connectTimeout() time.Duration {
+	if ssh.ConnectTimeout <= 0 {
+		return DefaultSSHConnectTimeout
+	}
+	return ssh.ConnectTimeout
 }
 
 func (ssh *SSHRunner) Close() {
-	// TODO: wait kubekey support close for Connection
-	logrus.Debugf("TODO: wait kubekey support close for Connection")
+	ssh.lock.Lock()
+	defer ssh.lock.Unlock()
+	if ssh.stopKeepAlive != nil {
+		close(ssh.stopKeepAlive)
+		ssh.stopKeepAlive = nil
+	}
+	if ssh.Conn != nil {
+		ssh.Conn.Close()
+		ssh.Conn = nil
+	}
 }
 
 func (ssh *SSHRunner) Reconnect() error {
-	conn, err := connect(ssh.Host)
+	conn, err := connect(ssh.Host, ssh.AgentSocket, ssh.HostKey, ssh.connectTimeout())
 	if err != nil {
-		return nil
+		return err
 	}
-	ssh.Conn = conn
+	ssh.lock.Lock()
+	defer ssh.lock.Unlock()
+	if ssh.Conn != nil {
+		ssh.Conn.Close()
+	}
+	ssh.Conn, ssh.broken = conn, false
+	ssh.lastActive = time.Now()
 	return nil
 }
 
-func prepareUserTempDir(conn ssh.Connection, host *kkv1alpha1.HostCfg) error {
+func prepareUserTempDir(conn Connection, host *kkv1alpha1.HostCfg, elevate, sudoPassword string) error {
 	// scp to tmp file
 	dir := api.GetUserTempDir(host.User)
 	var sb strings.Builder
@@ -168,8 +366,9 @@ func prepareUserTempDir(conn ssh.Connection, host *kkv1alpha1.HostCfg) error {
 	// chown .eggo dir
 	sb.WriteString(fmt.Sprintf(" && chown -R %s:%s %s", host.User, host.User, filepath.Dir(dir)))
 	sb.WriteString("\"")
-	_, err := conn.Exec(sb.String(), host)
-	if err != nil {
+	elevated, stdin := elevateCommand(sb.String(), elevate, sudoPassword)
+	output, err := conn.Exec(context.Background(), elevated, stdin)
+	if err = sudoError(output, err, sudoPassword); err != nil {
 		logrus.Errorf("[%s] prepare temp dir: %s failed: %v", host.Name, dir, err)
 		return err
 	}
@@ -177,19 +376,20 @@ func prepareUserTempDir(conn ssh.Connection, host *kkv1alpha1.HostCfg) error {
 	return nil
 }
 
-func (ssh *SSHRunner) copyFile(src, dst string) error {
-	if ssh.Conn == nil {
-		return fmt.Errorf("[%s] SSH runner is not connected", ssh.Host.Name)
+func (ssh *SSHRunner) copyFile(ctx context.Context, src, dst string) error {
+	if err := ssh.ensureConnected(); err != nil {
+		return fmt.Errorf("[%s] %v", ssh.Host.Name, err)
 	}
 	tempDir := api.GetUserTempDir(ssh.Host.User)
 	// scp to tmp file
 	tempCpyFile := filepath.Join(tempDir, filepath.Base(src))
-	err := ssh.Conn.Scp(src, tempCpyFile)
+	err := ssh.Conn.Scp(ctx, src, tempCpyFile)
+	ssh.checkConnection(err)
 	if err != nil {
 		logrus.Errorf("[%s] Copy %s to tempfile %s failed: %v", ssh.Host.Name, src, tempCpyFile, err)
 		return err
 	}
-	_, err = ssh.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"mv %s %s\"", tempCpyFile, dst))
+	_, err = ssh.runCommandContext(ctx, fmt.Sprintf("sudo -E /bin/sh -c \"mv %s %s\"", tempCpyFile, dst))
 	if err != nil {
 		logrus.Errorf("[%s] untar tmp tar failed: %v", ssh.Host.Name, err)
 		return err
@@ -199,6 +399,10 @@ func (ssh *SSHRunner) copyFile(src, dst string) error {
 }
 
 func (ssh *SSHRunner) Copy(src, dst string) error {
+	return ssh.copyContext(context.Background(), src, dst)
+}
+
+func (ssh *SSHRunner) copyContext(ctx context.Context, src, dst string) error {
 	fi, err := os.Stat(src)
 	if err != nil {
 		logrus.Errorf("[%s] check src dir: %s failed: %v", ssh.Host.Name, src, err)
@@ -206,14 +410,27 @@ func (ssh *SSHRunner) Copy(src, dst string) error {
 	}
 	if !fi.IsDir() {
 		// just copy file
-		return ssh.copyFile(src, dst)
+		return ssh.copyFile(ctx, src, dst)
 	}
 
 	// copy dir
-	return ssh.copyDir(src, dst)
+	return ssh.copyDir(ctx, src, dst)
+}
+
+func (ssh *SSHRunner) CopyDir(localDir, remoteDir string) error {
+	return ssh.copyDirContext(context.Background(), localDir, remoteDir)
+}
+
+func (ssh *SSHRunner) copyDirContext(ctx context.Context, localDir, remoteDir string) error {
+	if err := copyDirByFiles(WithContext(ctx, ssh), localDir, remoteDir); err != nil {
+		logrus.Errorf("[%s] copy dir %s to %s failed: %v", ssh.Host.Name, localDir, remoteDir, err)
+		return err
+	}
+	logrus.Debugf("[%s] copy dir %s to %s success", ssh.Host.Name, localDir, remoteDir)
+	return nil
 }
 
-func (ssh *SSHRunner) copyDir(srcDir, dstDir string) error {
+func (ssh *SSHRunner) copyDir(ctx context.Context, srcDir, dstDir string) error {
 	tmpDir, err := ioutil.TempDir("", "eggo-certs-")
 	if err != nil {
 		logrus.Errorf("[%s] create tempdir failed: %v", ssh.Host.Name, err)
@@ -222,7 +439,7 @@ func (ssh *SSHRunner) copyDir(srcDir, dstDir string) error {
 	tmpPkgFile := filepath.Join(tmpDir, "pkg.tar")
 	lr := &LocalRunner{}
 	// tar src dir
-	_, err = lr.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"mkdir -p %s && cd %s && tar -cf %s *\"", tmpDir, srcDir, tmpPkgFile))
+	_, err = lr.runCommandContext(ctx, fmt.Sprintf("sudo -E /bin/sh -c \"mkdir -p %s && cd %s && tar -cf %s *\"", tmpDir, srcDir, tmpPkgFile))
 	if err != nil {
 		logrus.Errorf("[%s] create cert tmp tar failed: %v", ssh.Host.Name, err)
 		return err
@@ -230,13 +447,13 @@ func (ssh *SSHRunner) copyDir(srcDir, dstDir string) error {
 	tmpCpyDir := api.GetUserTempDir(ssh.Host.User)
 	tmpPkiFile := filepath.Join(tmpCpyDir, "remote-pkg.tar")
 	// scp to user home directory
-	err = ssh.Copy(tmpPkgFile, tmpPkiFile)
+	err = ssh.copyFile(ctx, tmpPkgFile, tmpPkiFile)
 	if err != nil {
 		logrus.Errorf("[%s] copy tmp tar failed: %v", ssh.Host.Name, err)
 		return err
 	}
 	// untar tmp file
-	_, err = ssh.RunCommand(fmt.Sprintf("sudo -E /bin/sh -c \"cd %s && mv %s . && tar -xf %s && rm -rf %s\"", dstDir, tmpPkiFile, "remote-pkg.tar", "remote-pkg.tar"))
+	_, err = ssh.runCommandContext(ctx, fmt.Sprintf("sudo -E /bin/sh -c \"cd %s && mv %s . && tar -xf %s && rm -rf %s\"", dstDir, tmpPkiFile, "remote-pkg.tar", "remote-pkg.tar"))
 	if err != nil {
 		logrus.Errorf("[%s] untar tmp tar failed: %v", ssh.Host.Name, err)
 		return err
@@ -246,11 +463,18 @@ func (ssh *SSHRunner) copyDir(srcDir, dstDir string) error {
 }
 
 func (ssh *SSHRunner) RunCommand(cmd string) (string, error) {
-	if ssh.Conn == nil {
-		return "", errors.New("SSH runner is not connected")
+	return ssh.runCommandContext(context.Background(), cmd)
+}
+
+func (ssh *SSHRunner) runCommandContext(ctx context.Context, cmd string) (string, error) {
+	if err := ssh.ensureConnected(); err != nil {
+		return "", err
 	}
-	output, err := ssh.Conn.Exec(cmd, ssh.Host)
-	if err != nil {
+	// log cmd before elevated
+	elevated, stdin := elevateCommand(cmd, ssh.Elevate, ssh.SudoPassword)
+	output, err := ssh.Conn.Exec(ctx, elevated, stdin)
+	ssh.checkConnection(err)
+	if err = sudoError(output, err, ssh.SudoPassword); err != nil {
 		logrus.Errorf("[%s] run '%s' failed: %v\n", ssh.Host.Name, cmd, err)
 		return "", err
 	}
@@ -260,21 +484,16 @@ func (ssh *SSHRunner) RunCommand(cmd string) (string, error) {
 }
 
 func (ssh *SSHRunner) RunShell(shell string, name string) (string, error) {
+	return ssh.runShellContext(context.Background(), shell, name)
+}
+
+func (ssh *SSHRunner) runShellContext(ctx context.Context, shell string, name string) (string, error) {
 	tmpDir, err := ioutil.TempDir("", RunnerShellPrefix)
 	if err != nil {
 		return "", err
 	}
 	defer os.RemoveAll(tmpDir)
-	var sb strings.Builder
-	sb.WriteString("sudo -E /bin/sh -c \"")
-	sb.WriteString(fmt.Sprintf("mkdir -p %s", tmpDir))
-	roleBase64 := base64.StdEncoding.EncodeToString([]byte(shell))
-	sb.WriteString(fmt.Sprintf(" && echo %s | base64 -d > %s/%s", roleBase64, tmpDir, name))
-	sb.WriteString(fmt.Sprintf(" && chmod +x %s/%s", tmpDir, name))
-	sb.WriteString(fmt.Sprintf(" && %s/%s > /dev/null", tmpDir, name))
-	sb.WriteString("\"")
-
-	output, err := ssh.RunCommand(sb.String())
+	output, err := ssh.runCommandContext(ctx, fmt.Sprintf("%s/bin/sh -c \"%s\"", sudoPrefix, shellCommand(tmpDir, shell, name)))
 	if err != nil {
 		logrus.Errorf("[%s] run shell '%s' failed: %v", ssh.Host.Name, name, err)
 		return "", err
@@ -282,3 +501,14 @@ func (ssh *SSHRunner) RunShell(shell string, name string) (string, error) {
 	logrus.Debugf("[%s] run shell '%s' success, output: %s", ssh.Host.Name, name, output)
 	return output, nil
 }
+
+// shellCommand writes shell into dir and runs it
+func shellCommand(dir, shell, name string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("mkdir -p %s", dir))
+	roleBase64 := base64.StdEncoding.EncodeToString([]byte(shell))
+	sb.WriteString(fmt.Sprintf(" && echo %s | base64 -d > %s/%s", roleBase64, dir, name))
+	sb.WriteString(fmt.Sprintf(" && chmod +x %s/%s", dir, name))
+	sb.WriteString(fmt.Sprintf(" && %s/%s > /dev/null", dir, name))
+	return sb.String()
+}
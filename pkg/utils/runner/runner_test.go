@@ -0,0 +1,200 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: runner testcase
+ ******************************************************************************/
+
+package runner
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh/agent"
+
+	"isula.org/eggo/pkg/api"
+)
+
+func startMockAgent(t *testing.T, socket string) agent.Agent {
+	keyring := agent.NewKeyring()
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen mock agent socket failed: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	return keyring
+}
+
+func TestGetSSHAgentSocket(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "eggo-runner-test-")
+	if err != nil {
+		t.Fatalf("create tempdir failed: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	oldSocket, hasSocket := os.LookupEnv(SSHAgentSocketEnv)
+	defer func() {
+		if hasSocket {
+			os.Setenv(SSHAgentSocketEnv, oldSocket)
+		} else {
+			os.Unsetenv(SSHAgentSocketEnv)
+		}
+	}()
+
+	hcfg := &api.HostConfig{Name: "test", UserName: "root"}
+	if socket, err := getSSHAgentSocket(hcfg); err != nil || socket != "" {
+		t.Fatalf("ssh agent should not be used when disabled, socket: %s, err: %v", socket, err)
+	}
+
+	hcfg.UseSSHAgent = true
+	os.Unsetenv(SSHAgentSocketEnv)
+	if _, err = getSSHAgentSocket(hcfg); err == nil {
+		t.Fatalf("expect error when no agent socket found")
+	}
+	hcfg.Password = "123456"
+	if socket, err := getSSHAgentSocket(hcfg); err != nil || socket != "" {
+		t.Fatalf("expect fall back to password, socket: %s, err: %v", socket, err)
+	}
+	hcfg.Password = ""
+
+	socket := filepath.Join(tempdir, "agent.sock")
+	keyring := startMockAgent(t, socket)
+	os.Setenv(SSHAgentSocketEnv, socket)
+	if _, err = getSSHAgentSocket(hcfg); err == nil {
+		t.Fatalf("expect error when ssh agent has no identity")
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	if err = keyring.Add(agent.AddedKey{PrivateKey: priv, Comment: "eggo-test"}); err != nil {
+		t.Fatalf("add key to mock agent failed: %v", err)
+	}
+	got, err := getSSHAgentSocket(hcfg)
+	if err != nil {
+		t.Fatalf("get ssh agent socket failed: %v", err)
+	}
+	if got != socket {
+		t.Fatalf("expect agent socket %s, got %s", socket, got)
+	}
+}
+
+func TestIsLocalHost(t *testing.T) {
+	cases := []struct {
+		hcfg   api.HostConfig
+		expect bool
+	}{
+		{api.HostConfig{Address: "localhost"}, false},
+		{api.HostConfig{Address: "127.0.0.1"}, true},
+		{api.HostConfig{Address: "::1"}, true},
+		{api.HostConfig{Address: "192.168.0.2", Local: true}, true},
+		{api.HostConfig{Address: "192.168.0.2"}, false},
+	}
+	for _, c := range cases {
+		if got := IsLocalHost(&c.hcfg); got != c.expect {
+			t.Errorf("IsLocalHost of %s: expect %v, got %v", c.hcfg.Address, c.expect, got)
+		}
+	}
+}
+
+func TestLocalRunner(t *testing.T) {
+	tempdir := t.TempDir()
+	r := &LocalRunner{Elevate: ElevateNone}
+
+	output, err := r.RunCommand("sudo -E /bin/sh -c \"echo hello\"")
+	if err != nil || output != "hello\n" {
+		t.Fatalf("run command failed: %v, output: %s", err, output)
+	}
+
+	src := filepath.Join(tempdir, "src")
+	if err = ioutil.WriteFile(src, []byte("eggo"), 0644); err != nil {
+		t.Fatalf("write src failed: %v", err)
+	}
+	dst := filepath.Join(tempdir, "dst")
+	if err = r.Copy(src, dst); err != nil {
+		t.Fatalf("copy file failed: %v", err)
+	}
+	if data, err := ioutil.ReadFile(dst); err != nil || string(data) != "eggo" {
+		t.Fatalf("read copied file failed: %v, content: %s", err, string(data))
+	}
+
+	srcDir := filepath.Join(tempdir, "srcdir")
+	if err = os.MkdirAll(filepath.Join(srcDir, "sub"), 0755); err != nil {
+		t.Fatalf("create src dir failed: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(srcDir, "sub", "file"), []byte("eggo"), 0644); err != nil {
+		t.Fatalf("write file of src dir failed: %v", err)
+	}
+	dstDir := filepath.Join(tempdir, "dstdir")
+	if err = r.Copy(srcDir, dstDir); err != nil {
+		t.Fatalf("copy dir failed: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dstDir, "sub", "file")); err != nil {
+		t.Fatalf("check copied dir failed: %v", err)
+	}
+
+	shellOut := filepath.Join(tempdir, "shell-out")
+	if _, err = r.RunShell("#!/bin/sh\necho shell > "+shellOut, "test.sh"); err != nil {
+		t.Fatalf("run shell failed: %v", err)
+	}
+	if data, err := ioutil.ReadFile(shellOut); err != nil || string(data) != "shell\n" {
+		t.Fatalf("check result of shell failed: %v, content: %s", err, string(data))
+	}
+}
+
+func TestConnectTimeout(t *testing.T) {
+	if d, err := ConnectTimeout(""); err != nil || d != DefaultSSHConnectTimeout {
+		t.Fatalf("expect default connect timeout, get: %v, %v", d, err)
+	}
+	if d, err := ConnectTimeout("3s"); err != nil || d != 3*time.Second {
+		t.Fatalf("expect connect timeout 3s, get: %v, %v", d, err)
+	}
+	for _, v := range []string{"3", "0s", "-1s"} {
+		if _, err := ConnectTimeout(v); err == nil {
+			t.Fatalf("expect invalid connect timeout: %s", v)
+		}
+	}
+
+	// 10.255.255.1 is not routable, dial fails after timeout rather than tcp timeout of os
+	hcfg := &api.HostConfig{Name: "node0", Address: "10.255.255.1", Port: 22, UserName: "root", Password: "eggo"}
+	start := time.Now()
+	_, err := NewSSHRunner(hcfg, nil, nil, "1s")
+	if err == nil {
+		t.Fatalf("expect connect unreachable node failed")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("connect timeout is not honored, elapsed: %s", elapsed)
+	}
+	if !strings.Contains(err.Error(), "[node0]") {
+		t.Fatalf("expect node in error: %v", err)
+	}
+}
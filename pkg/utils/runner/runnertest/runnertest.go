@@ -0,0 +1,126 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: fake runner of node for testcases
+ ******************************************************************************/
+
+// Package runnertest provides fake runner for testcases of tasks running on nodes
+package runnertest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// Runner implements runner.Runner without node, it records commands, shells and copied files;
+// commands output nothing and succeed, unless they are set in Outputs or failed by Strict and Fail
+type Runner struct {
+	// outputs of commands, key is command
+	Outputs map[string]string
+	// commands not in Outputs fail if Strict is true
+	Strict bool
+	// commands fail if Fail returns true
+	Fail func(cmd string) bool
+	// called after shell is run, to mock changes of node made by shell
+	AfterShell func(shell, name string)
+
+	lock sync.Mutex
+	// commands run on node, in order
+	Commands []string
+	// contents of shells run on node, in order
+	Shells []string
+	// commands and names of shells, in order
+	Steps []string
+	// copied files, in format of "src dst"
+	Copies []string
+	// contents of copied files which are readable, key is dst
+	Files map[string]string
+	// contents of shells, key is name of shell
+	named map[string]string
+}
+
+func (r *Runner) Copy(src, dst string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Copies = append(r.Copies, src+" "+dst)
+	if content, err := ioutil.ReadFile(src); err == nil {
+		if r.Files == nil {
+			r.Files = make(map[string]string)
+		}
+		r.Files[dst] = string(content)
+	}
+	return nil
+}
+
+func (r *Runner) CopyDir(srcDir, dstDir string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Copies = append(r.Copies, srcDir+" "+dstDir)
+	return nil
+}
+
+func (r *Runner) RunCommand(cmd string) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Commands = append(r.Commands, cmd)
+	r.Steps = append(r.Steps, cmd)
+	output, ok := r.Outputs[cmd]
+	if (r.Fail != nil && r.Fail(cmd)) || (!ok && r.Strict) {
+		return "", fmt.Errorf("run command %s failed", cmd)
+	}
+	return output, nil
+}
+
+func (r *Runner) RunShell(shell string, name string) (string, error) {
+	r.lock.Lock()
+	r.Shells = append(r.Shells, shell)
+	r.Steps = append(r.Steps, name)
+	if r.named == nil {
+		r.named = make(map[string]string)
+	}
+	r.named[name] = shell
+	r.lock.Unlock()
+
+	// AfterShell may change Outputs
+	if r.AfterShell != nil {
+		r.AfterShell(shell, name)
+	}
+	return "", nil
+}
+
+func (r *Runner) Reconnect() error {
+	return nil
+}
+
+func (r *Runner) Close() {
+}
+
+// Shell returns content of the last shell run with name
+func (r *Runner) Shell(name string) string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.named[name]
+}
+
+// Contains returns true if any command or shell run contains s
+func (r *Runner) Contains(s string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, c := range append(append([]string{}, r.Commands...), r.Shells...) {
+		if strings.Contains(c, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: ssh connection of nodes, host key is verified in its handshake
+ ******************************************************************************/
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	kkv1alpha1 "github.com/kubesphere/kubekey/apis/kubekey/v1alpha1"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"isula.org/eggo/pkg/api"
+)
+
+const (
+	defaultSSHPort = 22
+)
+
+// Connection runs commands and copies files on node by ssh,
+// remote command is killed and its session is closed when ctx is done
+type Connection interface {
+	// run cmd in new session with stdin, combined output is returned, stdin is empty if it is nil
+	Exec(ctx context.Context, cmd string, stdin io.Reader) (string, error)
+	// copy local file to remote path, mode of file is kept
+	Scp(ctx context.Context, src, dst string) error
+	Close()
+}
+
+type sshConnection struct {
+	client *ssh.Client
+}
+
+// shellQuote quotes s as one word of shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// run runs cmd in sess until it exits or ctx is done
+func run(ctx context.Context, sess *ssh.Session, cmd string) (string, error) {
+	type result struct {
+		output []byte
+		err    error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		output, err := sess.CombinedOutput(cmd)
+		ch <- result{output: output, err: err}
+	}()
+
+	select {
+	case res := <-ch:
+		return strings.TrimSpace(string(res.output)), res.err
+	case <-ctx.Done():
+		// signal is not supported by old sshd, closing session hangs up the command too
+		if err := sess.Signal(ssh.SIGKILL); err != nil {
+			logrus.Debugf("signal interrupted command failed: %v", err)
+		}
+		// do not wait for output, node may be unreachable
+		sess.Close()
+		return "", fmt.Errorf("command is interrupted: %v", ctx.Err())
+	}
+}
+
+func (c *sshConnection) Exec(ctx context.Context, cmd string, stdin io.Reader) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	sess, err := c.client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer sess.Close()
+	sess.Stdin = stdin
+	return run(ctx, sess, cmd)
+}
+
+func (c *sshConnection) Scp(ctx context.Context, src, dst string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	sess, err := c.client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+	sess.Stdin = f
+	cmd := fmt.Sprintf("cat > %s && chmod %o %s", shellQuote(dst), fi.Mode().Perm(), shellQuote(dst))
+	if output, err := run(ctx, sess, cmd); err != nil {
+		return fmt.Errorf("copy %s to %s failed: %v, output: %s", src, dst, err, output)
+	}
+	return nil
+}
+
+func (c *sshConnection) Close() {
+	c.client.Close()
+}
+
+// sshAuthMethods returns auth methods of host, the returned closer releases ssh agent after handshake
+func sshAuthMethods(host *kkv1alpha1.HostCfg, agentSocket string) ([]ssh.AuthMethod, func(), error) {
+	var methods []ssh.AuthMethod
+	closer := func() {}
+	if agentSocket != "" {
+		conn, err := net.Dial("unix", agentSocket)
+		if err != nil {
+			return nil, nil, fmt.Errorf("connect ssh agent socket %s failed: %v", agentSocket, err)
+		}
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		closer = func() { conn.Close() }
+	}
+
+	key := []byte(host.PrivateKey)
+	var keyErr error
+	if len(key) == 0 && host.PrivateKeyPath != "" {
+		// other methods are tried if private key is unreadable
+		key, keyErr = ioutil.ReadFile(host.PrivateKeyPath)
+	}
+	if len(key) > 0 {
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			closer()
+			return nil, nil, fmt.Errorf("parse private key failed: %v", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if host.Password != "" {
+		methods = append(methods, ssh.Password(host.Password))
+	}
+	if len(methods) == 0 {
+		if keyErr != nil {
+			return nil, nil, fmt.Errorf("read private key %s failed: %v", host.PrivateKeyPath, keyErr)
+		}
+		return nil, nil, errors.New("no password, private key or ssh agent to authenticate")
+	}
+	return methods, closer, nil
+}
+
+func dialSSH(host *kkv1alpha1.HostCfg, agentSocket string, hostKey *api.SSHHostKeyConfig, timeout time.Duration) (Connection, error) {
+	port := host.Port
+	if port == 0 {
+		port = defaultSSHPort
+	}
+	addr := net.JoinHostPort(host.Address, strconv.Itoa(port))
+	connectErr := func(err error) error {
+		return fmt.Errorf("[%s] connect %s in %s failed: %v", host.Name, addr, timeout.String(), err)
+	}
+
+	callback, err := hostKeyCallback(hostKey)
+	if err != nil {
+		return nil, err
+	}
+	methods, closeAgent, err := sshAuthMethods(host, agentSocket)
+	if err != nil {
+		return nil, connectErr(err)
+	}
+	defer closeAgent()
+	config := &ssh.ClientConfig{
+		User:            host.User,
+		Auth:            methods,
+		HostKeyCallback: callback,
+		Timeout:         timeout,
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, connectErr(err)
+	}
+	// handshake and authentication are limited by timeout too
+	if err = conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return nil, connectErr(err)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, connectErr(err)
+	}
+	if err = conn.SetDeadline(time.Time{}); err != nil {
+		c.Close()
+		return nil, connectErr(err)
+	}
+	return &sshConnection{client: ssh.NewClient(c, chans, reqs)}, nil
+}
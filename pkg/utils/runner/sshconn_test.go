@@ -0,0 +1,242 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: ssh connection testcase
+ ******************************************************************************/
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	kkv1alpha1 "github.com/kubesphere/kubekey/apis/kubekey/v1alpha1"
+	"golang.org/x/crypto/ssh"
+
+	"isula.org/eggo/pkg/api"
+)
+
+const (
+	mockSSHUser     = "eggo"
+	mockSSHPassword = "eggo-password"
+
+	defaultTestTimeout = 5 * time.Second
+)
+
+// startMockSSHServer accepts password of mockSSHUser, commands of exec requests are run by local shell
+func startMockSSHServer(t *testing.T, hostKey ssh.Signer) *kkv1alpha1.HostCfg {
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == mockSSHUser && string(pass) == mockSSHPassword {
+				return nil, nil
+			}
+			return nil, errors.New("permission denied")
+		},
+	}
+	config.AddHostKey(hostKey)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen mock ssh server failed: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveMockSSHConn(conn, config)
+		}
+	}()
+	addr := l.Addr().(*net.TCPAddr)
+	return &kkv1alpha1.HostCfg{
+		Name:     "mock",
+		Address:  addr.IP.String(),
+		Port:     addr.Port,
+		User:     mockSSHUser,
+		Password: mockSSHPassword,
+	}
+}
+
+func serveMockSSHConn(conn net.Conn, config *ssh.ServerConfig) {
+	defer conn.Close()
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	defer sconn.Close()
+	go ssh.DiscardRequests(reqs)
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			_ = newChan.Reject(ssh.UnknownChannelType, "only session is supported")
+			continue
+		}
+		ch, chReqs, err := newChan.Accept()
+		if err != nil {
+			continue
+		}
+		go serveMockSession(ch, chReqs)
+	}
+}
+
+// serveMockSession runs command of exec request, the command is killed by signal request or close of session
+func serveMockSession(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+	var cmd *exec.Cmd
+	exited := make(chan struct{})
+	kill := func() {
+		if cmd != nil && cmd.Process != nil {
+			_ = cmd.Process.Kill()
+		}
+	}
+	for {
+		select {
+		case <-exited:
+			return
+		case req, ok := <-reqs:
+			if !ok {
+				kill()
+				return
+			}
+			switch req.Type {
+			case "exec":
+				var payload struct{ Command string }
+				if cmd != nil || ssh.Unmarshal(req.Payload, &payload) != nil {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				cmd = exec.Command("/bin/sh", "-c", payload.Command)
+				cmd.Stdin, cmd.Stdout, cmd.Stderr = ch, ch, ch.Stderr()
+				if err := cmd.Start(); err != nil {
+					_ = req.Reply(false, nil)
+					return
+				}
+				_ = req.Reply(true, nil)
+				go func(c *exec.Cmd) {
+					var status struct{ Status uint32 }
+					if err := c.Wait(); err != nil {
+						status.Status = 1
+						if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 {
+							status.Status = uint32(exitErr.ExitCode())
+						}
+					}
+					_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(&status))
+					close(exited)
+				}(cmd)
+			case "signal":
+				kill()
+				_ = req.Reply(true, nil)
+			default:
+				_ = req.Reply(false, nil)
+			}
+		}
+	}
+}
+
+func TestSSHConnection(t *testing.T) {
+	host := startMockSSHServer(t, newHostKey(t))
+
+	wrong := *host
+	wrong.Password = "wrong"
+	if _, err := dialSSH(&wrong, "", &api.SSHHostKeyConfig{}, defaultTestTimeout); err == nil {
+		t.Fatalf("dial with wrong password should fail")
+	}
+
+	conn, err := dialSSH(host, "", &api.SSHHostKeyConfig{}, defaultTestTimeout)
+	if err != nil {
+		t.Fatalf("dial mock ssh server failed: %v", err)
+	}
+	defer conn.Close()
+
+	output, err := conn.Exec(context.Background(), "echo hello eggo", nil)
+	if err != nil || output != "hello eggo" {
+		t.Fatalf("exec got %q, %v", output, err)
+	}
+	if output, err = conn.Exec(context.Background(), "cat", strings.NewReader("from stdin")); err != nil || output != "from stdin" {
+		t.Fatalf("exec with stdin got %q, %v", output, err)
+	}
+	if _, err = conn.Exec(context.Background(), "exit 3", nil); err == nil {
+		t.Fatalf("exec of failed command should return error")
+	}
+
+	tempdir, err := ioutil.TempDir("", "eggo-sshconn-test-")
+	if err != nil {
+		t.Fatalf("create tempdir failed: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+	src := filepath.Join(tempdir, "src")
+	dst := filepath.Join(tempdir, "it's dst")
+	if err = ioutil.WriteFile(src, []byte("eggo"), 0600); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+	if err = conn.Scp(context.Background(), src, dst); err != nil {
+		t.Fatalf("scp failed: %v", err)
+	}
+	data, err := ioutil.ReadFile(dst)
+	if err != nil || string(data) != "eggo" {
+		t.Fatalf("copied file got %q, %v", string(data), err)
+	}
+	fi, err := os.Stat(dst)
+	if err != nil || fi.Mode().Perm() != 0600 {
+		t.Fatalf("mode of copied file should be kept, got %v, %v", fi, err)
+	}
+}
+
+func TestSSHConnectionInterrupted(t *testing.T) {
+	host := startMockSSHServer(t, newHostKey(t))
+	conn, err := dialSSH(host, "", &api.SSHHostKeyConfig{}, defaultTestTimeout)
+	if err != nil {
+		t.Fatalf("dial mock ssh server failed: %v", err)
+	}
+	defer conn.Close()
+
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err = conn.Exec(ctx, "echo $$ > "+pidFile+" && exec sleep 30", nil); err == nil {
+		t.Fatalf("interrupted command should return error")
+	}
+	if time.Since(start) > defaultTestTimeout {
+		t.Fatalf("command is not interrupted in time")
+	}
+
+	data, err := ioutil.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("read pid of command failed: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatalf("invalid pid %s: %v", string(data), err)
+	}
+	// command on node is killed
+	for i := 0; syscall.Kill(pid, 0) == nil; i++ {
+		if i >= 50 {
+			t.Fatalf("command is still running after interrupted")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if _, err = conn.Exec(ctx, "true", nil); err == nil {
+		t.Fatalf("command should not run after ctx is done")
+	}
+}
@@ -0,0 +1,114 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: elevate commands by sudo with password from stdin or other elevate command
+ ******************************************************************************/
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// prefix of commands built by tasks, replaced by elevate command of host
+	sudoPrefix = "sudo -E "
+	// message of sudo -n when password is required
+	sudoPasswordRequired = "a password is required"
+	// -k ignores cached credentials, so it succeeds only if sudo never requires password, such as NOPASSWD
+	sudoProbeCommand = "sudo -n -k true"
+
+	DefaultElevate = "sudo -E"
+	// run commands without elevate, login user must be root
+	ElevateNone = "none"
+)
+
+var elevateAllowlist = []string{DefaultElevate, "sudo", "doas", ElevateNone}
+
+func CheckElevate(elevate string) error {
+	if elevate == "" {
+		return nil
+	}
+	for _, e := range elevateAllowlist {
+		if elevate == e {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupport elevate command: %s, support: %s", elevate, strings.Join(elevateAllowlist, ", "))
+}
+
+// elevateCommand replaces sudo prefix of cmd by elevate command, all commands of ssh runner go through it,
+// the returned stdin must be fed to the elevated command, it is nil if nothing to feed
+func elevateCommand(cmd, elevate, password string) (string, io.Reader) {
+	if !strings.HasPrefix(cmd, sudoPrefix) {
+		return cmd, nil
+	}
+	cmd = strings.TrimPrefix(cmd, sudoPrefix)
+	switch elevate {
+	case ElevateNone:
+		return cmd, nil
+	case "doas":
+		// doas cannot read password from stdin
+		return "doas -n " + cmd, nil
+	case "sudo":
+		return sudoCommand(cmd, "", password)
+	default:
+		return sudoCommand(cmd, "-E ", password)
+	}
+}
+
+// sudoCommand makes sudo of cmd read password from stdin by -S, password is never put in command line,
+// which is visible to other users of node; without password, sudo fails by -n instead of waiting for password
+func sudoCommand(cmd, flags, password string) (string, io.Reader) {
+	if password == "" {
+		return "sudo -n " + flags + cmd, nil
+	}
+	// -k ignores cached credentials, so sudo always consumes the password instead of leaving it on stdin of cmd;
+	// empty prompt not to be mixed into output
+	return "sudo -k -S -p '' " + flags + cmd, strings.NewReader(password + "\n")
+}
+
+// probeSudoPassword returns sudo password to feed commands of the node, run executes command on the node;
+// the password is dropped if sudo does not require it, so it is never fed to commands not reading it
+func probeSudoPassword(elevate, password string, run func(cmd string) (string, error)) string {
+	if password == "" {
+		return ""
+	}
+	switch elevate {
+	case "", DefaultElevate, "sudo":
+	default:
+		// password is used by sudo only
+		return password
+	}
+	if _, err := run(sudoProbeCommand); err == nil {
+		return ""
+	}
+	return password
+}
+
+// sudoError hides password in error of sudo command, and explains error of password required
+func sudoError(output string, err error, password string) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if password != "" {
+		msg = strings.ReplaceAll(msg, password, "******")
+	}
+	if password == "" && (strings.Contains(output, sudoPasswordRequired) || strings.Contains(msg, sudoPasswordRequired)) {
+		return fmt.Errorf("sudo requires password, please set sudo-password: %s", msg)
+	}
+	return errors.New(msg)
+}
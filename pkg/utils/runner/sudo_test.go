@@ -0,0 +1,222 @@
+/******************************************************************************
+ * Copyright (c) Huawei Technologies Co., Ltd. 2021. All rights reserved.
+ * eggo licensed under the Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *     http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR
+ * PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ * Author: agent
+ * Create: 2026-10-16
+ * Description: sudo command testcase
+ ******************************************************************************/
+
+package runner
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"isula.org/eggo/pkg/api"
+)
+
+// fakeSudo requires password "pass'word" from stdin with -k -S, and fails with -n
+const fakeSudo = `#!/bin/sh
+if [ "$1" = "-n" ]; then
+	echo "sudo: a password is required" 1>&2
+	exit 1
+fi
+if [ "$1" != "-k" ]; then
+	echo "unexpect args without -k: $@" 1>&2
+	exit 2
+fi
+shift
+if [ "$1" != "-S" ] || [ "$2" != "-p" ] || [ "$3" != "" ] || [ "$4" != "-E" ]; then
+	echo "unexpect args: $@" 1>&2
+	exit 2
+fi
+read -r password
+if [ "$password" != "pass'word" ]; then
+	echo "Sorry, try again." 1>&2
+	exit 1
+fi
+shift 4
+exec "$@"
+`
+
+// fakeSudoPath returns PATH with fake sudo in front
+func fakeSudoPath(t *testing.T) string {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "sudo"), []byte(fakeSudo), 0755); err != nil {
+		t.Fatalf("write fake sudo failed: %v", err)
+	}
+	return dir + ":" + os.Getenv("PATH")
+}
+
+func runWithFakeSudo(t *testing.T, cmd string, stdin io.Reader) (string, error) {
+	c := exec.Command("/bin/sh", "-c", cmd)
+	c.Env = append(os.Environ(), "PATH="+fakeSudoPath(t))
+	c.Stdin = stdin
+	output, err := c.CombinedOutput()
+	return string(output), err
+}
+
+func readStdin(t *testing.T, stdin io.Reader) string {
+	if stdin == nil {
+		return ""
+	}
+	data, err := ioutil.ReadAll(stdin)
+	if err != nil {
+		t.Fatalf("read stdin failed: %v", err)
+	}
+	return string(data)
+}
+
+func TestSudoCommand(t *testing.T) {
+	password := "pass'word"
+	cmd := "sudo -E /bin/sh -c \"echo hello\""
+
+	// command without sudo is not changed
+	if got, stdin := elevateCommand("ls /", DefaultElevate, password); got != "ls /" || stdin != nil {
+		t.Fatalf("expect command not changed, get: %s", got)
+	}
+	// password is fed by stdin, never in command line
+	shaped, stdin := elevateCommand(cmd, DefaultElevate, password)
+	if shaped != "sudo -k -S -p '' -E /bin/sh -c \"echo hello\"" {
+		t.Fatalf("unexpect command with sudo password: %s", shaped)
+	}
+	if got := readStdin(t, stdin); got != password+"\n" {
+		t.Fatalf("unexpect stdin of sudo: %q", got)
+	}
+	if got, stdin := elevateCommand(cmd, DefaultElevate, ""); got != "sudo -n -E /bin/sh -c \"echo hello\"" || stdin != nil {
+		t.Fatalf("unexpect command without sudo password: %s", got)
+	}
+
+	shaped, stdin = elevateCommand(cmd, DefaultElevate, password)
+	output, err := runWithFakeSudo(t, shaped, stdin)
+	if err != nil || strings.TrimSpace(output) != "hello" {
+		t.Fatalf("run command with sudo password failed: %v, output: %s", err, output)
+	}
+	shaped, stdin = elevateCommand(cmd, DefaultElevate, "wrong")
+	if output, err = runWithFakeSudo(t, shaped, stdin); err == nil {
+		t.Fatalf("expect sudo with wrong password failed, output: %s", output)
+	}
+	// fail fast without password
+	shaped, stdin = elevateCommand(cmd, DefaultElevate, "")
+	output, err = runWithFakeSudo(t, shaped, stdin)
+	if err == nil {
+		t.Fatalf("expect sudo without password failed")
+	}
+	if err = sudoError(output, err, ""); !strings.Contains(err.Error(), "sudo requires password") {
+		t.Fatalf("expect error of sudo password required, get: %v", err)
+	}
+}
+
+func TestSudoPasswordBySSH(t *testing.T) {
+	// commands of mock ssh server find fake sudo by PATH of test
+	origin := os.Getenv("PATH")
+	if err := os.Setenv("PATH", fakeSudoPath(t)); err != nil {
+		t.Fatalf("set PATH failed: %v", err)
+	}
+	defer os.Setenv("PATH", origin)
+
+	host := startMockSSHServer(t, newHostKey(t))
+	conn, err := dialSSH(host, "", &api.SSHHostKeyConfig{}, defaultTestTimeout)
+	if err != nil {
+		t.Fatalf("dial mock ssh server failed: %v", err)
+	}
+	r := &SSHRunner{Host: host, Conn: conn, Elevate: DefaultElevate, SudoPassword: "pass'word"}
+	defer r.Close()
+
+	output, err := r.RunCommand("sudo -E /bin/sh -c \"echo hello\"")
+	if err != nil || output != "hello" {
+		t.Fatalf("run command with sudo password by ssh failed: %v, output: %s", err, output)
+	}
+	r.SudoPassword = "wrong"
+	if _, err = r.RunCommand("sudo -E /bin/sh -c \"echo hello\""); err == nil {
+		t.Fatalf("expect sudo with wrong password failed")
+	}
+}
+
+func TestProbeSudoPassword(t *testing.T) {
+	var probed []string
+	noPassword := func(cmd string) (string, error) {
+		probed = append(probed, cmd)
+		return "", nil
+	}
+	if got := probeSudoPassword(DefaultElevate, "secret", noPassword); got != "" {
+		t.Fatalf("expect password dropped if sudo does not require it, get: %s", got)
+	}
+	if len(probed) != 1 || probed[0] != sudoProbeCommand {
+		t.Fatalf("expect sudo probed once, get: %v", probed)
+	}
+
+	// fake sudo requires password
+	probeByFakeSudo := func(cmd string) (string, error) {
+		return runWithFakeSudo(t, cmd, nil)
+	}
+	if got := probeSudoPassword("sudo", "secret", probeByFakeSudo); got != "secret" {
+		t.Fatalf("expect password kept if sudo requires it, get: %s", got)
+	}
+
+	probed = nil
+	if got := probeSudoPassword(DefaultElevate, "", noPassword); got != "" || len(probed) != 0 {
+		t.Fatalf("expect no probe without password, get: %s, %v", got, probed)
+	}
+	if got := probeSudoPassword("doas", "secret", noPassword); got != "secret" || len(probed) != 0 {
+		t.Fatalf("expect no probe for doas, get: %s, %v", got, probed)
+	}
+}
+
+func TestSudoErrorHidesPassword(t *testing.T) {
+	password := "secret"
+	err := sudoError("", fmt.Errorf("bad password %s", password), password)
+	if err == nil {
+		t.Fatalf("expect error returned")
+	}
+	if err = sudoError("", fmt.Errorf("bad password %s", password), password); strings.Contains(err.Error(), password) {
+		t.Fatalf("expect password hidden in error, get: %v", err)
+	}
+	if sudoError("output", nil, password) != nil {
+		t.Fatalf("expect nil error")
+	}
+}
+
+func TestElevateCommand(t *testing.T) {
+	cmd := "sudo -E /bin/sh -c \"ls\""
+	tcs := []struct {
+		elevate string
+		expect  string
+	}{
+		{elevate: "", expect: "sudo -n -E /bin/sh -c \"ls\""},
+		{elevate: DefaultElevate, expect: "sudo -n -E /bin/sh -c \"ls\""},
+		{elevate: "sudo", expect: "sudo -n /bin/sh -c \"ls\""},
+		{elevate: "doas", expect: "doas -n /bin/sh -c \"ls\""},
+		{elevate: ElevateNone, expect: "/bin/sh -c \"ls\""},
+	}
+	for _, tc := range tcs {
+		if err := CheckElevate(tc.elevate); err != nil {
+			t.Fatalf("expect elevate %q allowed, get: %v", tc.elevate, err)
+		}
+		if got, _ := elevateCommand(cmd, tc.elevate, ""); got != tc.expect {
+			t.Fatalf("elevate %q: expect %s, get: %s", tc.elevate, tc.expect, got)
+		}
+	}
+	if got, _ := elevateCommand(cmd, "sudo", "secret"); got != "sudo -k -S -p '' /bin/sh -c \"ls\"" {
+		t.Fatalf("unexpect command of sudo with password: %s", got)
+	}
+
+	for _, elevate := range []string{"su -c", "sudo -E; rm -rf /", "pkexec"} {
+		if err := CheckElevate(elevate); err == nil {
+			t.Fatalf("expect elevate %q rejected", elevate)
+		}
+	}
+}
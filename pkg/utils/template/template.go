@@ -15,12 +15,13 @@
 package template
 
 import (
+	"bytes"
 	"fmt"
 	"path/filepath"
 	"text/template"
 
-	kkutil "github.com/kubesphere/kubekey/pkg/util"
 	"github.com/lithammer/dedent"
+	"gopkg.in/yaml.v1"
 )
 
 func Add(a, b int) int {
@@ -124,7 +125,6 @@ func CreateCsrTemplate(name string, conf *CsrConfig) (string, error) {
 	if conf == nil {
 		return "", fmt.Errorf("invalid csr config")
 	}
-	tmpl := template.Must(template.New(name).Funcs(funcMap).Parse(dedent.Dedent(BaseCsrTemplate)))
 	datastore := map[string]interface{}{}
 	if len(conf.IPs) > 0 {
 		datastore["HaveAltNames"] = true
@@ -138,7 +138,8 @@ func CreateCsrTemplate(name string, conf *CsrConfig) (string, error) {
 	datastore["CommonName"] = conf.CommonName
 	datastore["ExtendedKeyUsage"] = conf.ExtendedKeyUsage
 
-	return kkutil.Render(tmpl, datastore)
+	data, err := Render(name, BaseCsrTemplate, datastore)
+	return string(data), err
 }
 
 type SystemdServiceConfig struct {
@@ -158,7 +159,6 @@ func CreateSystemdServiceTemplate(name string, conf *SystemdServiceConfig) (stri
 	if conf == nil {
 		return "", fmt.Errorf("invalid csr config")
 	}
-	tmpl := template.Must(template.New(name).Funcs(funcMap).Parse(dedent.Dedent(BaseSystemdServiceTemplate)))
 	datastore := map[string]interface{}{}
 
 	if conf.Description == "" {
@@ -210,10 +210,37 @@ func CreateSystemdServiceTemplate(name string, conf *SystemdServiceConfig) (stri
 	}
 	datastore["WantedBy"] = wantedBy
 
-	return kkutil.Render(tmpl, datastore)
+	data, err := Render(name, BaseSystemdServiceTemplate, datastore)
+	return string(data), err
 }
 
 func TemplateRender(temp string, datastore map[string]interface{}) (string, error) {
-	tmpl := template.Must(template.New("test").Funcs(funcMap).Parse(dedent.Dedent(temp)))
-	return kkutil.Render(tmpl, datastore)
+	data, err := Render("test", temp, datastore)
+	return string(data), err
+}
+
+// Render renders dedented template with datastore, invalid template returns error rather than panic
+func Render(name, temp string, datastore map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(funcMap).Parse(dedent.Dedent(temp))
+	if err != nil {
+		return nil, fmt.Errorf("parse template %s failed: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, datastore); err != nil {
+		return nil, fmt.Errorf("render template %s failed: %v", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderYaml renders template as Render, and checks the result is valid yaml
+func RenderYaml(name, temp string, datastore map[string]interface{}) ([]byte, error) {
+	data, err := Render(name, temp, datastore)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err = yaml.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("result of template %s is invalid yaml: %v", name, err)
+	}
+	return data, nil
 }
@@ -15,6 +15,7 @@
 package template
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -270,3 +271,42 @@ WantedBy=multi-user.target
 	}
 
 }
+
+func TestRender(t *testing.T) {
+	temp := `
+	kind: ConfigMap
+	data:
+	  {{- range $i, $v := .Servers }}
+	  server{{ $i }}: {{ $v }}{{ if NotLast $i (len $.Servers) }},{{ end }}
+	  {{- end }}
+	`
+	datastore := map[string]interface{}{"Servers": []string{"10.0.0.1", "10.0.0.2"}}
+	data, err := Render("sample", temp, datastore)
+	if err != nil {
+		t.Fatalf("render sample template failed: %v", err)
+	}
+	expect := "\nkind: ConfigMap\ndata:\n  server0: 10.0.0.1,\n  server1: 10.0.0.2\n"
+	if string(data) != expect {
+		t.Fatalf("expect:\n%q\nget:\n%q", expect, string(data))
+	}
+	if _, err = RenderYaml("sample", temp, datastore); err != nil {
+		t.Fatalf("render sample yaml failed: %v", err)
+	}
+
+	// invalid template returns error rather than panic
+	if _, err = Render("invalid", "{{ .Servers ", datastore); err == nil {
+		t.Fatalf("expect invalid template failed")
+	}
+}
+
+func TestRenderYamlInvalid(t *testing.T) {
+	temp := `
+	kind: Secret
+	stringData:
+	  description: {{ .Description }}
+	`
+	_, err := RenderYaml("bad yaml", temp, map[string]interface{}{"Description": "token: for: worker"})
+	if err == nil || !strings.Contains(err.Error(), "invalid yaml") {
+		t.Fatalf("expect invalid yaml of result, get: %v", err)
+	}
+}
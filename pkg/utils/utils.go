@@ -16,7 +16,9 @@
 package utils
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -85,6 +87,21 @@ func RemoveDupString(str []string) []string {
 	return result
 }
 
+// FileSha256 returns sha256 of file in lower case hex
+func FileSha256(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
 func CheckPathExist(path string) (bool, error) {
 	_, err := os.Stat(path)
 	if err == nil {